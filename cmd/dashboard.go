@@ -0,0 +1,34 @@
+package cmd
+
+import (
+	"github.com/lf-edge/eden/pkg/defaults"
+	"github.com/lf-edge/eden/pkg/openevec"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+func newDashboardCmd(configName, verbosity *string) *cobra.Command {
+	cfg := &openevec.EdenSetupArgs{}
+	var listen, historyDB string
+	var historyRuns int
+
+	var dashboardCmd = &cobra.Command{
+		Use:   "dashboard",
+		Short: "Serve web dashboard of the current context",
+		Long: `
+Serves web UI with live state of the device, its applications, networks and volumes,
+recent logs of EVE and results of tests recorded with 'eden test --history'.`,
+		Args:              cobra.NoArgs,
+		PersistentPreRunE: preRunViperLoadFunction(cfg, configName, verbosity),
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := openEVEC.EdenDashboard(listen, historyDB, historyRuns); err != nil {
+				log.Fatalf("Dashboard failed: %s", err)
+			}
+		},
+	}
+
+	dashboardCmd.Flags().StringVar(&listen, "listen", defaults.DefaultDashboardListen, "address to listen on")
+	dashboardCmd.Flags().StringVar(&historyDB, "history-db", "", "path to history database (default is test-history.db inside eden home)")
+	dashboardCmd.Flags().IntVar(&historyRuns, "runs", defaults.DefaultMetricsTestRuns, "number of the last runs of tests to show history of")
+	return dashboardCmd
+}
@@ -0,0 +1,99 @@
+package cmd
+
+import (
+	"time"
+
+	"github.com/dustin/go-humanize"
+	"github.com/lf-edge/eden/pkg/defaults"
+	"github.com/lf-edge/eden/pkg/openevec"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+func newBenchmarkCmd(configName, verbosity *string) *cobra.Command {
+	cfg := &openevec.EdenSetupArgs{}
+	var benchmarkCmd = &cobra.Command{
+		Use:               "benchmark",
+		Short:             "measure deployment of apps",
+		Long:              `Measure time of deployment of apps on EVE to detect performance regressions between releases.`,
+		PersistentPreRunE: preRunViperLoadFunction(cfg, configName, verbosity),
+	}
+
+	groups := CommandGroups{
+		{
+			Message: "Basic Commands",
+			Commands: []*cobra.Command{
+				newBenchmarkRunCmd(),
+				newBenchmarkCompareCmd(),
+			},
+		},
+	}
+
+	groups.AddTo(benchmarkCmd)
+
+	return benchmarkCmd
+}
+
+func newBenchmarkRunCmd() *cobra.Command {
+	var pc openevec.PodConfig
+	var bc openevec.BenchmarkConfig
+
+	var benchmarkRunCmd = &cobra.Command{
+		Use:   "run <docker>://<TAG|URL>[:<VERSION>]",
+		Short: "Deploy app repeatedly and report time of phases of deployment",
+		Long: `Deploy app repeatedly, one deployment at a time, and report time from push of config
+to the controller until EVE received config, downloaded content, created volumes and the app is running.
+Timestamps are taken from info messages of EVE, so its clock must be in sync with eden host.
+Every app is deleted with its volumes before the next repetition. Content may stay cached in EVE,
+so download is usually measured in the first repetition only.`,
+		Args: cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := openEVEC.Benchmark(args[0], pc, bc); err != nil {
+				log.Fatal(err)
+			}
+		},
+	}
+
+	benchmarkRunCmd.Flags().StringVarP(&bc.Name, "name", "n", "benchmark", "prefix of names of apps, repetition number is appended")
+	benchmarkRunCmd.Flags().IntVar(&bc.Repetitions, "repetitions", 3, "number of deployments")
+	benchmarkRunCmd.Flags().DurationVar(&bc.Timeout, "timeout", 10*time.Minute, "timeout of one deployment and removal of app")
+	benchmarkRunCmd.Flags().StringVarP(&bc.Output, "output", "o", "", "file to write report into, summary is printed if set (stdout if not set)")
+	benchmarkRunCmd.Flags().StringVar(&bc.Format, "report-format", "json", "format of report (json or csv)")
+	benchmarkRunCmd.Flags().StringVar(&pc.AppMemory, "memory", humanize.Bytes(defaults.DefaultAppMem*1024), "memory for app")
+	benchmarkRunCmd.Flags().Uint32Var(&pc.AppCpus, "cpus", defaults.DefaultAppCPU, "cpu number for app")
+	benchmarkRunCmd.Flags().StringVar(&pc.DiskSize, "disk-size", humanize.Bytes(0), "disk size (empty or 0 - same as in image)")
+	benchmarkRunCmd.Flags().StringVar(&pc.VolumeSize, "volume-size", humanize.IBytes(defaults.DefaultVolumeSize), "volume size")
+	benchmarkRunCmd.Flags().StringVar(&pc.VolumeType, "volume-type", "qcow2", "volume type for empty volumes (qcow2, raw, qcow, vmdk, vhdx, iso or oci); set it to none to not use volumes")
+	benchmarkRunCmd.Flags().StringVar(&pc.ImageFormat, "format", "", "format for image, one of 'container','qcow2','raw','qcow','vmdk','vhdx','iso'")
+	benchmarkRunCmd.Flags().StringSliceVar(&pc.Networks, "networks", nil, "Networks to connect to app")
+	benchmarkRunCmd.Flags().BoolVar(&pc.NoHyper, "no-hyper", false, "Run pod without hypervisor")
+	benchmarkRunCmd.Flags().StringVar(&pc.Registry, "registry", "remote", "Select registry to use for containers (remote/local/eserver)")
+	benchmarkRunCmd.Flags().BoolVar(&pc.DirectLoad, "direct", true, "Use direct download for image instead of eserver")
+
+	return benchmarkRunCmd
+}
+
+func newBenchmarkCompareCmd() *cobra.Command {
+	var threshold float64
+
+	var benchmarkCompareCmd = &cobra.Command{
+		Use:   "compare <base report> <report>",
+		Short: "Compare median time of phases in JSON reports",
+		Long: `Compare median time of phases of deployment in JSON reports of 'eden benchmark run',
+e.g. of two EVE releases. Fails if some phase became slower by more than threshold.`,
+		Args: cobra.ExactArgs(2),
+		// reports are compared without config of eden
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+			return nil
+		},
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := openevec.BenchmarkCompare(args[0], args[1], threshold); err != nil {
+				log.Fatal(err)
+			}
+		},
+	}
+
+	benchmarkCompareCmd.Flags().Float64Var(&threshold, "threshold", 20, "slowdown of phase in percents to report as regression")
+
+	return benchmarkCompareCmd
+}
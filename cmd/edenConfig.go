@@ -66,8 +66,9 @@ func newConfigAddCmd(cfg *openevec.EdenSetupArgs) *cobra.Command {
 	}
 
 	configAddCmd.Flags().StringVar(&cfg.Eve.DevModel, "devmodel", defaults.DefaultQemuModel,
-		fmt.Sprintf("device model (%s/%s/%s/%s)",
-			defaults.DefaultQemuModel, defaults.DefaultRPIModel, defaults.DefaultGCPModel, defaults.DefaultGeneralModel))
+		fmt.Sprintf("device model (%s/%s/%s/%s/%s/%s/%s/%s)",
+			defaults.DefaultQemuModel, defaults.DefaultRPIModel, defaults.DefaultGCPModel, defaults.DefaultGeneralModel,
+			defaults.DefaultVBoxModel, defaults.DefaultParallelsModel, defaults.DefaultVMwareModel, defaults.DefaultHyperVModel))
 	configAddCmd.Flags().StringVar(&contextFile, "file", "", "file with config to add")
 	//not used in function
 	configAddCmd.Flags().StringVarP(&cfg.Eve.QemuFileToSave, "qemu-config", "", defaults.DefaultQemuFileToSave, "file to save config")
@@ -78,8 +79,9 @@ func newConfigAddCmd(cfg *openevec.EdenSetupArgs) *cobra.Command {
 	configAddCmd.Flags().StringVarP(&cfg.Eve.QemuDTBPath, "dtb-part", "", "", "path for device tree drive (for arm)")
 	configAddCmd.Flags().StringToStringVarP(&cfg.Eve.HostFwd, "eve-hostfwd", "", defaults.DefaultQemuHostFwd, "port forward map")
 	configAddCmd.Flags().StringVar(&cfg.Eve.Ssid, "ssid", "", "set ssid of wifi for rpi")
-	configAddCmd.Flags().StringVar(&cfg.Eve.Arch, "arch", "", "arch of EVE (amd64 or arm64)")
+	configAddCmd.Flags().StringVar(&cfg.Eve.Arch, "arch", "", "arch of EVE (amd64, arm64 or riscv64)")
 	configAddCmd.Flags().StringVar(&cfg.Eve.ModelFile, "devmodel-file", "", "File to use for overwrite of model defaults")
+	configAddCmd.Flags().StringVar(&cfg.Sdn.NetworkType, "network-type", "", "IP versions used by EVE to connect with Eden (ipv4-only, ipv6-only, dual-stack)")
 	configAddCmd.Flags().BoolVarP(&force, "force", "", false, "force overwrite config file")
 
 	return configAddCmd
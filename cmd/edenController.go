@@ -1,9 +1,14 @@
 package cmd
 
 import (
+	"time"
+
+	"github.com/lf-edge/eden/pkg/controller/export"
+	"github.com/lf-edge/eden/pkg/controller/types"
 	"github.com/lf-edge/eden/pkg/openevec"
 	log "github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
+	"github.com/thediveo/enumflag"
 )
 
 func newControllerCmd(configName, verbosity *string) *cobra.Command {
@@ -37,6 +42,9 @@ func newControllerCmd(configName, verbosity *string) *cobra.Command {
 				newEdgeNodeSetConfig(),
 				newEdgeNodeGetOptions(controllerMode),
 				newEdgeNodeSetOptions(controllerMode),
+				newEdgeNodeAttestState(controllerMode),
+				newEdgeNodeReattest(controllerMode),
+				newEdgeNodeTPMState(controllerMode),
 			},
 		},
 	}
@@ -47,8 +55,14 @@ func newControllerCmd(configName, verbosity *string) *cobra.Command {
 
 	controllerCmd.AddCommand(newControllerGetOptions())
 	controllerCmd.AddCommand(newControllerSetOptions())
+	controllerCmd.AddCommand(newControllerBatchApply())
+	controllerCmd.AddCommand(newControllerDiff(&controllerMode))
+	controllerCmd.AddCommand(newControllerExport(&controllerMode))
+	controllerCmd.AddCommand(newControllerReplay())
 
 	controllerCmd.PersistentFlags().StringVarP(&controllerMode, "mode", "m", "", "mode to use [file|proto|adam|zedcloud]://<URL> (default is adam)")
+	controllerCmd.PersistentFlags().BoolVar(&cfg.DryRun, "dry-run", false, "show difference with config in controller instead of pushing changes")
+	controllerCmd.PersistentFlags().BoolVar(&cfg.ForcePush, "force-push", false, "push config unconditionally if controller does not support conditional update of config")
 
 	return controllerCmd
 }
@@ -114,7 +128,7 @@ func newEdgeNodeEVEImageUpdate(controllerMode string) *cobra.Command {
 	}
 
 	edgeNodeEVEImageUpdate.Flags().StringVarP(&baseOSVersion, "os-version", "", "", "version of ROOTFS")
-	edgeNodeEVEImageUpdate.Flags().StringVar(&registry, "registry", "remote", "Select registry to use for containers (remote/local)")
+	edgeNodeEVEImageUpdate.Flags().StringVar(&registry, "registry", "remote", "Select registry to use for containers (remote/local/eserver)")
 	edgeNodeEVEImageUpdate.Flags().BoolVarP(&baseOSImageActivate, "activate", "", true, "activate image")
 	edgeNodeEVEImageUpdate.Flags().BoolVar(&baseOSVDrive, "drive", true, "provide drive to baseOS")
 
@@ -159,7 +173,7 @@ func newEdgeNodeUpdate(controllerMode string) *cobra.Command {
 	configUsage := `set of key=value items.
 Supported keys are defined in https://github.com/lf-edge/eve/blob/master/docs/CONFIG-PROPERTIES.md`
 	deviceUsage := `set of key=value items.
-Supported keys: global_profile,local_profile_server,profile_server_token`
+Supported keys: global_profile,local_profile_server,profile_server_token,loc_url`
 	edgeNodeUpdate.Flags().StringToStringVar(&configItems, "config", make(map[string]string), configUsage)
 	edgeNodeUpdate.Flags().StringToStringVar(&deviceItems, "device", make(map[string]string), deviceUsage)
 
@@ -204,6 +218,64 @@ func newEdgeNodeSetOptions(controllerMode string) *cobra.Command {
 	return edgeNodeSetOptions
 }
 
+func newEdgeNodeAttestState(controllerMode string) *cobra.Command {
+	var waitAttested time.Duration
+	var check bool
+
+	var edgeNodeAttestState = &cobra.Command{
+		Use:   "attest-state",
+		Short: "show EVE attestation state",
+		Long: `Show attestation state of EVE known by controller (nonce, integrity token, PCR template, certificates
+and attest requests) and reported by EVE, with checks of nonce, quote and storage keys stages of attestation.`,
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := openEVEC.EdgeNodeAttestState(controllerMode, waitAttested, check); err != nil {
+				log.Fatal(err)
+			}
+		},
+	}
+
+	edgeNodeAttestState.Flags().DurationVar(&waitAttested, "wait-attested", 0, "wait for EVE to become attested (and pass checks with --check) with timeout")
+	edgeNodeAttestState.Flags().BoolVar(&check, "check", false, "fail if any of checks failed")
+
+	return edgeNodeAttestState
+}
+
+func newEdgeNodeReattest(controllerMode string) *cobra.Command {
+	var edgeNodeReattest = &cobra.Command{
+		Use:   "reattest",
+		Short: "force re-attestation of EVE",
+		Long: `Reset attestation state of EVE inside controller to force it to pass attestation again.
+Use attest-state with --wait-attested to wait for EVE to complete it.`,
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := openEVEC.EdgeNodeReattest(controllerMode); err != nil {
+				log.Fatal(err)
+			}
+		},
+	}
+
+	return edgeNodeReattest
+}
+
+func newEdgeNodeTPMState(controllerMode string) *cobra.Command {
+	var check bool
+
+	var edgeNodeTPMState = &cobra.Command{
+		Use:   "tpm-state",
+		Short: "validate TPM of EVE",
+		Long: `Validate TPM-based onboarding (EK, attestation and ECDH certificates), ECDH-encrypted config
+and measured boot events of EVE against PCR values it sent to controller.`,
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := openEVEC.EdgeNodeTPMState(controllerMode, check); err != nil {
+				log.Fatal(err)
+			}
+		},
+	}
+
+	edgeNodeTPMState.Flags().BoolVar(&check, "check", false, "fail if any of checks failed")
+
+	return edgeNodeTPMState
+}
+
 func newControllerGetOptions() *cobra.Command {
 	var fileWithConfig string
 
@@ -242,6 +314,109 @@ func newControllerSetOptions() *cobra.Command {
 	return controllerSetOptions
 }
 
+func newControllerBatchApply() *cobra.Command {
+	var fileWithConfig string
+	var devices []string
+	var parallel int
+
+	var controllerBatchApply = &cobra.Command{
+		Use:   "batch-apply",
+		Short: "apply config patch to many devices",
+		Long: `Apply the same apps, networks, network instances, volumes, content trees and datastores
+defined in JSON of EdgeDevConfig to the list of devices and report result for every device.
+Objects with the same UUID as existing ones will be replaced.`,
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := openEVEC.ControllerBatchApply(devices, fileWithConfig, parallel); err != nil {
+				log.Fatal(err)
+			}
+		},
+	}
+
+	controllerBatchApply.Flags().StringVar(&fileWithConfig, "file", "", "set config patch from file")
+	controllerBatchApply.Flags().StringSliceVar(&devices, "devices", []string{"all"}, "UUIDs of devices to apply patch to or 'all' for all registered devices")
+	controllerBatchApply.Flags().IntVar(&parallel, "parallel", 4, "max number of devices to push config to at once")
+
+	return controllerBatchApply
+}
+
+func newControllerReplay() *cobra.Command {
+	var speed float64
+
+	var controllerReplay = &cobra.Command{
+		Use:   "replay <dir>",
+		Short: "push recorded configs to device",
+		Long: `Push configs recorded with 'eden test --record' to the current device in the same order
+and with the same delays between them to reproduce behavior of EVE without running tests.
+To run tests against recorded info, metrics and logs without device use 'eden test --replay'.`,
+		Args: cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := openEVEC.ControllerReplay(args[0], speed); err != nil {
+				log.Fatal(err)
+			}
+		},
+	}
+
+	controllerReplay.Flags().Float64Var(&speed, "speed", 1, "divide recorded delays between configs by speed, 0 to push them without delays")
+
+	return controllerReplay
+}
+
+func newControllerDiff(controllerMode *string) *cobra.Command {
+	var fileWithConfig string
+	var outputFormat types.OutputFormat
+
+	var controllerDiff = &cobra.Command{
+		Use:   "diff",
+		Short: "show difference between EVE config in controller and local one",
+		Long: `Show structured difference between the config of EVE stored in controller
+and the config from file, from stdin or the one eden will push on the next change.`,
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := openEVEC.ControllerDiff(*controllerMode, fileWithConfig, outputFormat); err != nil {
+				log.Fatal(err)
+			}
+		},
+	}
+
+	controllerDiff.Flags().StringVar(&fileWithConfig, "file", "", "compare with config from file")
+	controllerDiff.Flags().Var(
+		enumflag.New(&outputFormat, "format", outputFormatIds, enumflag.EnumCaseInsensitive),
+		"format",
+		"Format to print diff, supports: lines, json")
+
+	return controllerDiff
+}
+
+var exportFormatIds = map[export.Format][]string{
+	export.FormatZedcloud:  {"zedcloud"},
+	export.FormatTerraform: {"terraform"},
+}
+
+func newControllerExport(controllerMode *string) *cobra.Command {
+	var outputFile string
+	var exportFormat export.Format
+
+	var controllerExport = &cobra.Command{
+		Use:   "export",
+		Short: "export EVE config to zedcloud API or Terraform format",
+		Long: `Export datastores, images, networks, network instances, volumes and applications
+of EVE config stored in controller as objects of zedcloud API in JSON or as resources
+of zedcloud Terraform provider. Credentials of datastores are not exported.`,
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := openEVEC.ControllerExport(*controllerMode, exportFormat, outputFile); err != nil {
+				log.Fatal(err)
+			}
+		},
+	}
+
+	controllerExport.Flags().StringVar(&outputFile, "file", "", "save exported config into file instead of stdout")
+	controllerExport.Flags().Var(
+		enumflag.New(&exportFormat, "format", exportFormatIds, enumflag.EnumCaseInsensitive),
+		"format",
+		"Format of exported config, supports: zedcloud, terraform")
+
+	return controllerExport
+}
+
 func newEdgeNodeGetConfig(controllerMode string) *cobra.Command {
 	var fileWithConfig string
 
@@ -0,0 +1,29 @@
+package cmd
+
+import (
+	"github.com/lf-edge/eden/pkg/openevec"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+func newDumpCmd(configName, verbosity *string) *cobra.Command {
+	cfg := &openevec.EdenSetupArgs{}
+	var outDir string
+
+	var dumpCmd = &cobra.Command{
+		Use:   "dump",
+		Short: "collect logs and state of eden components",
+		Long: `Collect logs and state of Adam, Redis, EServer, registry, SDN VM
+and output of QEMU monitor of EVE into one directory to debug infrastructure issues.`,
+		PersistentPreRunE: preRunViperLoadFunction(cfg, configName, verbosity),
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := openEVEC.EdenDump(outDir); err != nil {
+				log.Fatal(err)
+			}
+		},
+	}
+
+	dumpCmd.Flags().StringVar(&outDir, "out", "eden-dump", "directory to save dump into")
+
+	return dumpCmd
+}
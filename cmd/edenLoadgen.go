@@ -0,0 +1,121 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/lf-edge/eden/pkg/loadgen"
+	"github.com/lf-edge/eden/pkg/openevec"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+const defaultLoadgenName = "loadgen"
+
+func newLoadgenCmd(configName, verbosity *string) *cobra.Command {
+	cfg := &openevec.EdenSetupArgs{}
+	var loadgenCmd = &cobra.Command{
+		Use:               "loadgen",
+		Short:             "generate traffic to apps",
+		Long:              `Deploy traffic generators (vegeta or iperf3 inside eclient image) against app and collect their results.`,
+		PersistentPreRunE: preRunViperLoadFunction(cfg, configName, verbosity),
+	}
+
+	groups := CommandGroups{
+		{
+			Message: "Control Commands",
+			Commands: []*cobra.Command{
+				newLoadgenStartCmd(cfg),
+				newLoadgenStopCmd(),
+			},
+		},
+		{
+			Message: "Printing Commands",
+			Commands: []*cobra.Command{
+				newLoadgenResultsCmd(),
+				newLoadgenProfilesCmd(),
+			},
+		},
+	}
+
+	groups.AddTo(loadgenCmd)
+
+	return loadgenCmd
+}
+
+func newLoadgenStartCmd(cfg *openevec.EdenSetupArgs) *cobra.Command {
+	var lc openevec.LoadgenConfig
+	var target, profile string
+
+	var loadgenStartCmd = &cobra.Command{
+		Use:   "start",
+		Short: "Deploy traffic generators",
+		Long: `Deploy traffic generators with the given profile against target app.
+Generators are connected to the network instance of target and named <name>-<index>.`,
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := openEVEC.LoadgenStart(target, profile, lc, cfg); err != nil {
+				log.Fatal(err)
+			}
+		},
+	}
+
+	loadgenStartCmd.Flags().StringVar(&target, "target", "", "name of app to send traffic to")
+	loadgenStartCmd.Flags().StringVar(&profile, "profile", "http-1k-rps", "profile of traffic, see 'eden loadgen profiles'")
+	loadgenStartCmd.Flags().StringVarP(&lc.Name, "name", "n", defaultLoadgenName, "name of generators")
+	loadgenStartCmd.Flags().StringVar(&lc.Network, "network", "", "network instance to use (first network of target if not set)")
+	loadgenStartCmd.Flags().IntVar(&lc.Port, "port", 0, "port of target (default port of profile if not set)")
+	loadgenStartCmd.Flags().IntVar(&lc.Count, "count", 1, "number of generators to deploy")
+	loadgenStartCmd.Flags().DurationVar(&lc.Duration, "duration", 0, "duration of generation (default duration of profile if not set)")
+	_ = loadgenStartCmd.MarkFlagRequired("target")
+
+	return loadgenStartCmd
+}
+
+func newLoadgenStopCmd() *cobra.Command {
+	var name string
+
+	var loadgenStopCmd = &cobra.Command{
+		Use:   "stop",
+		Short: "Delete traffic generators",
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := openEVEC.LoadgenStop(name); err != nil {
+				log.Fatal(err)
+			}
+		},
+	}
+
+	loadgenStopCmd.Flags().StringVarP(&name, "name", "n", defaultLoadgenName, "name of generators")
+
+	return loadgenStopCmd
+}
+
+func newLoadgenResultsCmd() *cobra.Command {
+	var name string
+
+	var loadgenResultsCmd = &cobra.Command{
+		Use:   "results",
+		Short: "Show results of traffic generators",
+		Long:  `Show reports of traffic generators collected from app logs and their aggregated result.`,
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := openEVEC.LoadgenResults(name); err != nil {
+				log.Fatal(err)
+			}
+		},
+	}
+
+	loadgenResultsCmd.Flags().StringVarP(&name, "name", "n", defaultLoadgenName, "name of generators")
+
+	return loadgenResultsCmd
+}
+
+func newLoadgenProfilesCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "profiles",
+		Short: "List profiles of traffic",
+		Run: func(cmd *cobra.Command, args []string) {
+			for _, name := range loadgen.ProfileNames() {
+				profile := loadgen.Profiles[name]
+				fmt.Printf("%-24s %-7s %s for %s\n", name, profile.Tool, profile.Description, profile.Duration)
+			}
+		},
+	}
+}
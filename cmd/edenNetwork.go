@@ -1,15 +1,20 @@
 package cmd
 
 import (
+	"strings"
+
 	"github.com/lf-edge/eden/pkg/controller/types"
+	"github.com/lf-edge/eden/pkg/openevec"
 	log "github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
 	"github.com/thediveo/enumflag"
 )
 
-func newNetworkCmd() *cobra.Command {
+func newNetworkCmd(configName, verbosity *string) *cobra.Command {
+	cfg := &openevec.EdenSetupArgs{}
 	var networkCmd = &cobra.Command{
-		Use: "network",
+		Use:               "network",
+		PersistentPreRunE: preRunViperLoadFunction(cfg, configName, verbosity),
 	}
 
 	groups := CommandGroups{
@@ -20,12 +25,16 @@ func newNetworkCmd() *cobra.Command {
 				newNetworkDeleteCmd(),
 				newNetworkNetstatCmd(),
 				newNetworkCreateCmd(),
+				newNetworkAdapterCmd(),
 			},
 		},
 	}
 
 	groups.AddTo(networkCmd)
 
+	networkCmd.PersistentFlags().BoolVar(&cfg.DryRun, "dry-run", false, "show difference with config in controller instead of pushing changes")
+	networkCmd.PersistentFlags().BoolVar(&cfg.ForcePush, "force-push", false, "push config unconditionally if controller does not support conditional update of config")
+
 	return networkCmd
 }
 
@@ -94,6 +103,7 @@ func newNetworkCreateCmd() *cobra.Command {
 	var networkType, networkName, uplinkAdapter string
 	var staticDNSEntries []string
 	var enableFlowlog bool
+	var uplinkIP openevec.UplinkIPConfig
 
 	//networkCreateCmd is command for create network instance in EVE
 	var networkCreateCmd = &cobra.Command{
@@ -106,7 +116,7 @@ func newNetworkCreateCmd() *cobra.Command {
 				subnet = args[0]
 			}
 			if err := openEVEC.NetworkCreate(subnet, networkType, networkName, uplinkAdapter,
-				staticDNSEntries, enableFlowlog); err != nil {
+				staticDNSEntries, enableFlowlog, uplinkIP); err != nil {
 				log.Fatal(err)
 			}
 		},
@@ -117,6 +127,111 @@ func newNetworkCreateCmd() *cobra.Command {
 	networkCreateCmd.Flags().StringVarP(&uplinkAdapter, "uplink", "u", "eth0", "Name of uplink adapter, set to 'none' to not use uplink")
 	networkCreateCmd.Flags().StringArrayVarP(&staticDNSEntries, "static-dns-entries", "s", []string{}, "List of static DNS entries in format HOSTNAME:IP_ADDR,IP_ADDR,...")
 	networkCreateCmd.Flags().BoolVar(&enableFlowlog, "enable-flowlog", false, "enable flow logging (EVE collecting and publishing records of application network flows)")
+	networkCreateCmd.Flags().StringVar(&uplinkIP.StaticIP, "uplink-static-ip", "",
+		"configure uplink adapter with static IP and disable DHCP of the SDN network connected to it")
+	networkCreateCmd.Flags().StringVar(&uplinkIP.DHCPRange, "sdn-dhcp-range", "",
+		"range of IP addresses in format FROM-TO allocated by DHCP of the SDN network connected to uplink")
+	networkCreateCmd.Flags().StringArrayVar(&uplinkIP.StaticLeases, "sdn-dhcp-static-lease", nil,
+		"static lease in format MAC=IP for DHCP of the SDN network connected to uplink")
+	networkCreateCmd.Flags().StringSliceVar(&uplinkIP.DNS, "sdn-dhcp-dns", nil,
+		"IP addresses of DNS servers announced by DHCP of the SDN network connected to uplink")
+	networkCreateCmd.Flags().StringVar(&uplinkIP.NTP, "sdn-dhcp-ntp", "",
+		"IP address of NTP server announced by DHCP of the SDN network connected to uplink")
 
 	return networkCreateCmd
 }
+
+func newNetworkAdapterCmd() *cobra.Command {
+	var networkAdapterCmd = &cobra.Command{
+		Use:   "adapter",
+		Short: "Manage VLAN and bond adapters of EVE ports",
+	}
+	networkAdapterCmd.AddCommand(newNetworkAdapterLsCmd())
+	networkAdapterCmd.AddCommand(newNetworkAdapterVlanCmd())
+	networkAdapterCmd.AddCommand(newNetworkAdapterBondCmd())
+	networkAdapterCmd.AddCommand(newNetworkAdapterDeleteCmd())
+	return networkAdapterCmd
+}
+
+func newNetworkAdapterLsCmd() *cobra.Command {
+	var networkAdapterLsCmd = &cobra.Command{
+		Use:   "ls",
+		Short: "List ports, VLAN and bond adapters of EVE",
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := openEVEC.NetworkAdapterLs(); err != nil {
+				log.Fatal(err)
+			}
+		},
+	}
+	return networkAdapterLsCmd
+}
+
+func newNetworkAdapterVlanCmd() *cobra.Command {
+	var parent, ipMode string
+	var vlanID uint16
+	var uplink, withSdn bool
+
+	var networkAdapterVlanCmd = &cobra.Command{
+		Use:   "vlan <name>",
+		Short: "Add VLAN sub-interface on top of port or bond of EVE",
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := openEVEC.NetworkAdapterVlanAdd(args[0], parent, vlanID, uplink, ipMode, withSdn); err != nil {
+				log.Fatal(err)
+			}
+		},
+	}
+
+	networkAdapterVlanCmd.Flags().StringVar(&parent, "parent", "eth1", "port or bond to create VLAN sub-interface on top of")
+	networkAdapterVlanCmd.Flags().Uint16Var(&vlanID, "vlan-id", 0, "VLAN ID (1-4094)")
+	networkAdapterVlanCmd.Flags().BoolVar(&uplink, "uplink", false, "use VLAN sub-interface for management traffic")
+	networkAdapterVlanCmd.Flags().StringVar(&ipMode, "ip", "dhcp",
+		"IP configuration of VLAN sub-interface: "+strings.Join(openevec.AdapterIPModes, ", ")+" or empty to not use it directly")
+	networkAdapterVlanCmd.Flags().BoolVar(&withSdn, "sdn", true, "configure SDN to carry traffic of VLAN")
+	_ = networkAdapterVlanCmd.MarkFlagRequired("vlan-id")
+
+	return networkAdapterVlanCmd
+}
+
+func newNetworkAdapterBondCmd() *cobra.Command {
+	var ports []string
+	var mode string
+	var withSdn bool
+
+	var networkAdapterBondCmd = &cobra.Command{
+		Use:   "bond <name>",
+		Short: "Aggregate ports of EVE into bond",
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := openEVEC.NetworkAdapterBondAdd(args[0], ports, mode, withSdn); err != nil {
+				log.Fatal(err)
+			}
+		},
+	}
+
+	networkAdapterBondCmd.Flags().StringSliceVar(&ports, "ports", []string{"eth0", "eth1"}, "ports to aggregate")
+	networkAdapterBondCmd.Flags().StringVar(&mode, "mode", "active-backup",
+		"bond mode: "+strings.Join(openevec.BondModes, ", "))
+	networkAdapterBondCmd.Flags().BoolVar(&withSdn, "sdn", true, "aggregate corresponding ports of SDN")
+
+	return networkAdapterBondCmd
+}
+
+func newNetworkAdapterDeleteCmd() *cobra.Command {
+	var withSdn bool
+
+	var networkAdapterDeleteCmd = &cobra.Command{
+		Use:   "delete <name>",
+		Short: "Delete VLAN or bond adapter of EVE",
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := openEVEC.NetworkAdapterDelete(args[0], withSdn); err != nil {
+				log.Fatal(err)
+			}
+		},
+	}
+
+	networkAdapterDeleteCmd.Flags().BoolVar(&withSdn, "sdn", true, "remove corresponding configuration of SDN")
+
+	return networkAdapterDeleteCmd
+}
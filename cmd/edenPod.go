@@ -1,9 +1,13 @@
 package cmd
 
 import (
+	"time"
+
 	"github.com/dustin/go-humanize"
 	"github.com/lf-edge/eden/pkg/controller/types"
 	"github.com/lf-edge/eden/pkg/defaults"
+	"github.com/lf-edge/eden/pkg/eve"
+	"github.com/lf-edge/eden/pkg/helm"
 	"github.com/lf-edge/eden/pkg/openevec"
 	edgeRegistry "github.com/lf-edge/edge-containers/pkg/registry"
 	log "github.com/sirupsen/logrus"
@@ -29,6 +33,7 @@ func newPodCmd(configName, verbosity *string) *cobra.Command {
 				newPodRestartCmd(),
 				newPodPurgeCmd(),
 				newPodModifyCmd(),
+				newPodCloneCmd(),
 				newPodPublishCmd(),
 			},
 		},
@@ -36,13 +41,19 @@ func newPodCmd(configName, verbosity *string) *cobra.Command {
 			Message: "Printing Commands",
 			Commands: []*cobra.Command{
 				newPodPsCmd(),
+				newPodWaitCmd(),
+				newPodWaitErrorCmd(),
 				newPodLogsCmd(cfg),
+				newPodProbeCmd(),
 			},
 		},
 	}
 
 	groups.AddTo(podCmd)
 
+	podCmd.PersistentFlags().BoolVar(&cfg.DryRun, "dry-run", false, "show difference with config in controller instead of pushing changes")
+	podCmd.PersistentFlags().BoolVar(&cfg.ForcePush, "force-push", false, "push config unconditionally if controller does not support conditional update of config")
+
 	return podCmd
 }
 
@@ -76,13 +87,23 @@ func newPodPublishCmd() *cobra.Command {
 
 func newPodDeployCmd(cfg *openevec.EdenSetupArgs) *cobra.Command {
 	var pc openevec.PodConfig
+	var fromHelm bool
+	var helmOpts helm.RenderOptions
 
 	var podDeployCmd = &cobra.Command{
-		Use:   "deploy (docker|http(s)|file|directory)://(<TAG|PATH>[:<VERSION>] | <URL for qcow2 image> | <path to qcow2 image>)",
+		Use:   "deploy (docker|docker-daemon|http(s)|file|directory|ova)://(<TAG|PATH>[:<VERSION>] | <URL for qcow2 image> | <path to qcow2 image> | <path to OVA>)",
 		Short: "Deploy app in pod",
-		Long:  `Deploy app in pod.`,
-		Args:  cobra.ExactArgs(1),
+		Long: `Deploy app in pod.
+With --from-helm the argument is a Helm chart (path or reference), which is rendered with helm template.
+Containers of its workloads are deployed as apps with images, env, ports and volumes from the chart.`,
+		Args: cobra.ExactArgs(1),
 		Run: func(cmd *cobra.Command, args []string) {
+			if fromHelm {
+				if err := openEVEC.PodDeployFromHelm(args[0], helmOpts, pc, cfg); err != nil {
+					log.Fatal(err)
+				}
+				return
+			}
 			appLink := args[0]
 			if err := openEVEC.PodDeploy(appLink, pc, cfg); err != nil {
 				log.Fatal(err)
@@ -100,13 +121,17 @@ func newPodDeployCmd(cfg *openevec.EdenSetupArgs) *cobra.Command {
 	podDeployCmd.Flags().StringVar(&pc.VncPassword, "vnc-password", "", "VNC password (empty - no password)")
 	podDeployCmd.Flags().Uint32Var(&pc.AppCpus, "cpus", defaults.DefaultAppCPU, "cpu number for app")
 	podDeployCmd.Flags().StringSliceVar(&pc.AppAdapters, "adapters", nil, "adapters to assign to the application instance")
+	podDeployCmd.Flags().StringSliceVar(&pc.PCIAdapters, "pci", nil, "labels of PCI passthrough adapters (GPUs, NICs) from eve.pci-passthrough to assign to the application instance")
+	podDeployCmd.Flags().StringSliceVar(&pc.IOMMUGroups, "iommu-group", nil, "assignment groups of device to assign all their adapters to the application instance")
 	podDeployCmd.Flags().StringSliceVar(&pc.Networks, "networks", nil, "Networks to connect to app (ports will be mapped to first network). May have <name:[MAC address]> notation.")
 	podDeployCmd.Flags().StringVar(&pc.ImageFormat, "format", "", "format for image, one of 'container','qcow2','raw','qcow','vmdk','vhdx','iso'; if not provided, defaults to container image for docker and oci transports, qcow2 for file and http/s transports")
 	podDeployCmd.Flags().BoolVar(&pc.ACLOnlyHost, "only-host", false, "Allow access only to host and external networks")
 	podDeployCmd.Flags().BoolVar(&pc.NoHyper, "no-hyper", false, "Run pod without hypervisor")
-	podDeployCmd.Flags().StringVar(&pc.Registry, "registry", "remote", "Select registry to use for containers (remote/local)")
+	podDeployCmd.Flags().StringVar(&pc.Registry, "registry", "remote", "Select registry to use for containers (remote/local/eserver)")
 	podDeployCmd.Flags().BoolVar(&pc.DirectLoad, "direct", true, "Use direct download for image instead of eserver")
 	podDeployCmd.Flags().BoolVar(&pc.SftpLoad, "sftp", false, "Force use of sftp to load http/file image from eserver")
+	podDeployCmd.Flags().BoolVar(&pc.S3Load, "s3", false, "Force use of S3 API to load http/file image from eserver")
+	podDeployCmd.MarkFlagsMutuallyExclusive("sftp", "s3")
 	podDeployCmd.Flags().StringSliceVar(&pc.Disks, "disks", nil, `Additional disks to use. You can write it in notation <link> or <mount point>:<link>. Deprecated. Please use volumes instead.`)
 	podDeployCmd.Flags().StringArrayVar(&pc.Mount, "mount", nil, `Additional volumes to use. You can write it in notation src=<link>,dst=<mount point>.`)
 	podDeployCmd.Flags().StringVar(&pc.VolumeSize, "volume-size", humanize.IBytes(defaults.DefaultVolumeSize), "volume size")
@@ -122,17 +147,22 @@ You can set access VLAN ID (VID) for a particular network in the format '<networ
 	podDeployCmd.Flags().StringVar(&pc.DatastoreOverride, "datastoreOverride", "", "Override datastore path for disks (when we use different URL for Eden and EVE or for local datastore)")
 	podDeployCmd.Flags().Uint32Var(&pc.StartDelay, "start-delay", 0, "The amount of time (in seconds) that EVE waits (after boot finish) before starting application")
 	podDeployCmd.Flags().BoolVar(&pc.PinCpus, "pin-cpus", false, "Pin the CPUs used by the pod")
+	podDeployCmd.Flags().BoolVar(&fromHelm, "from-helm", false, "Deploy workloads of Helm chart provided as argument")
+	podDeployCmd.Flags().StringSliceVar(&helmOpts.ValuesFiles, "helm-values", nil, "files with values for Helm chart")
+	podDeployCmd.Flags().StringArrayVar(&helmOpts.Set, "helm-set", nil, "values for Helm chart in format key=value")
 
 	return podDeployCmd
 }
 
 func newPodPsCmd() *cobra.Command {
 	var outputFormat types.OutputFormat
+	var wide bool
+	var wait time.Duration
 	var podPsCmd = &cobra.Command{
 		Use:   "ps",
 		Short: "List pods",
 		Run: func(cmd *cobra.Command, args []string) {
-			if err := openEVEC.PodPs(outputFormat); err != nil {
+			if err := openEVEC.PodPs(outputFormat, wide, wait); err != nil {
 				log.Fatalf("EVE pod deploy failed: %s", err)
 			}
 		},
@@ -141,10 +171,62 @@ func newPodPsCmd() *cobra.Command {
 		enumflag.New(&outputFormat, "format", outputFormatIds, enumflag.EnumCaseInsensitive),
 		"format",
 		"Format to print logs, supports: lines, json")
+	podPsCmd.Flags().BoolVar(&wide, "wide", false, "Show CPU usage and assigned accelerators")
+	podPsCmd.Flags().DurationVar(&wait, "wait", 0,
+		"Wait up to the duration for pods to become running, halted or failed before listing")
 
 	return podPsCmd
 }
 
+func newPodWaitCmd() *cobra.Command {
+	var conditions []string
+	var timeout time.Duration
+	var podWaitCmd = &cobra.Command{
+		Use:   "wait <app>",
+		Short: "Wait for pod to meet conditions",
+		Long: `Wait for pod to meet all provided conditions.
+Supported conditions: state=<STATE> (e.g. state=RUNNING), ip-assigned, probe-ok (port of pod is reachable),
+healthy (pod passes probes defined with pod probe add).`,
+		Args: cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			appName := args[0]
+			if err := openEVEC.PodWait(appName, conditions, timeout); err != nil {
+				log.Fatalf("EVE pod wait failed: %s", err)
+			}
+		},
+	}
+	podWaitCmd.Flags().StringSliceVar(&conditions, "for", []string{"state=RUNNING"}, "conditions to wait for")
+	podWaitCmd.Flags().DurationVar(&timeout, "timeout", 10*time.Minute, "time to wait for conditions")
+
+	return podWaitCmd
+}
+
+func newPodWaitErrorCmd() *cobra.Command {
+	var reason string
+	var timeout time.Duration
+	var podWaitErrorCmd = &cobra.Command{
+		Use:   "wait-error <app>",
+		Short: "Wait for pod to fail",
+		Long: `Wait for pod to fail with error of provided reason.
+Supported reasons: sha-mismatch, not-found, unauthorized, insufficient-resources, unsupported-format, download-failed, unknown.
+Fails if pod reaches RUNNING state or on timeout.`,
+		Args: cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			appName := args[0]
+			if reason != "" && !eve.IsErrorReason(reason) {
+				log.Fatalf("EVE pod wait-error failed: unknown reason %q", reason)
+			}
+			if err := openEVEC.PodWaitError(appName, reason, timeout); err != nil {
+				log.Fatalf("EVE pod wait-error failed: %s", err)
+			}
+		},
+	}
+	podWaitErrorCmd.Flags().StringVar(&reason, "reason", "", "reason of error to wait for, any error if empty")
+	podWaitErrorCmd.Flags().DurationVar(&timeout, "timeout", 5*time.Minute, "time to wait for error")
+
+	return podWaitErrorCmd
+}
+
 func newPodStopCmd() *cobra.Command {
 	var podStopCmd = &cobra.Command{
 		Use:   "stop",
@@ -263,6 +345,30 @@ func newPodLogsCmd(cfg *openevec.EdenSetupArgs) *cobra.Command {
 	return podLogsCmd
 }
 
+func newPodCloneCmd() *cobra.Command {
+	var podNetworks []string
+	var copyContent bool
+
+	var podCloneCmd = &cobra.Command{
+		Use:   "clone <app> <new-name>",
+		Short: "Clone pod",
+		Long: `Add new pod with configuration of existing one.
+The clone receives new UUIDs, MAC addresses and volumes.
+Volumes of the clone use the same content trees unless --copy-content is set.`,
+		Args: cobra.ExactArgs(2),
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := openEVEC.PodClone(args[0], args[1], podNetworks, copyContent); err != nil {
+				log.Fatalf("EVE pod clone failed: %s", err)
+			}
+		},
+	}
+
+	podCloneCmd.Flags().StringSliceVar(&podNetworks, "network", nil, "Networks to connect interfaces of the clone to, one per interface in order")
+	podCloneCmd.Flags().BoolVar(&copyContent, "copy-content", false, "Copy content trees for volumes of the clone instead of sharing them")
+
+	return podCloneCmd
+}
+
 func newPodModifyCmd() *cobra.Command {
 	var podNetworks, portPublish, acl, vlans []string
 	var startDelay uint32
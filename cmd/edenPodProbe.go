@@ -0,0 +1,111 @@
+package cmd
+
+import (
+	"github.com/lf-edge/eden/pkg/probe"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+func newPodProbeCmd() *cobra.Command {
+	var podProbeCmd = &cobra.Command{
+		Use:   "probe",
+		Short: "Manage health probes of pods",
+		Long: `Manage health probes of pods. Probes are run by eden against ports of pod forwarded
+to the host: results are shown in HEALTH column of pod ps and used by pod wait --for healthy.`,
+	}
+
+	podProbeCmd.AddCommand(newPodProbeAddCmd())
+	podProbeCmd.AddCommand(newPodProbeLsCmd())
+	podProbeCmd.AddCommand(newPodProbeDeleteCmd())
+	podProbeCmd.AddCommand(newPodProbeRunCmd())
+
+	return podProbeCmd
+}
+
+func newPodProbeAddCmd() *cobra.Command {
+	spec := &probe.Spec{}
+	var probeType string
+	var podProbeAddCmd = &cobra.Command{
+		Use:   "add <app>",
+		Short: "Define probe of pod",
+		Long: `Define probe of pod, probe with the same name is replaced.
+Supported types: http (GET request to path, expects 2xx or 3xx status), tcp (connection to port),
+exec (command run over SSH, expects zero exit code).`,
+		Args: cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			spec.Type = probe.Type(probeType)
+			if err := openEVEC.PodProbeAdd(args[0], spec); err != nil {
+				log.Fatalf("EVE pod probe add failed: %s", err)
+			}
+		},
+	}
+	podProbeAddCmd.Flags().StringVar(&spec.Name, "name", "", "name of probe, type of probe if not set")
+	podProbeAddCmd.Flags().StringVar(&probeType, "type", string(probe.TypeHTTP), "type of probe: http, tcp or exec")
+	podProbeAddCmd.Flags().IntVar(&spec.Port, "port", 0, "port of pod to probe (22 for exec)")
+	podProbeAddCmd.Flags().StringVar(&spec.Path, "path", "/", "path of http request")
+	podProbeAddCmd.Flags().IntVar(&spec.ExpectStatus, "expect-status", 0, "expected status of http response, any 2xx or 3xx if not set")
+	podProbeAddCmd.Flags().StringVar(&spec.ExpectBody, "expect-body", "", "regexp to match body of http response or output of command")
+	podProbeAddCmd.Flags().StringVar(&spec.Command, "command", "", "command to run with exec probe")
+	podProbeAddCmd.Flags().StringVar(&spec.User, "user", "root", "user to ssh with exec probe")
+	podProbeAddCmd.Flags().StringVar(&spec.SSHKey, "ssh-key", "", "private key to ssh with exec probe, eden key if not set")
+	podProbeAddCmd.Flags().DurationVar(&spec.Interval, "interval", probe.DefaultInterval, "interval between probes")
+	podProbeAddCmd.Flags().DurationVar(&spec.Timeout, "timeout", probe.DefaultTimeout, "timeout of one probe")
+	podProbeAddCmd.Flags().IntVar(&spec.SuccessThreshold, "success-threshold", probe.DefaultSuccessThreshold, "consecutive successes to consider pod healthy")
+	podProbeAddCmd.Flags().IntVar(&spec.FailureThreshold, "failure-threshold", probe.DefaultFailureThreshold, "consecutive failures to consider pod unhealthy")
+
+	return podProbeAddCmd
+}
+
+func newPodProbeLsCmd() *cobra.Command {
+	var podProbeLsCmd = &cobra.Command{
+		Use:   "ls [app]",
+		Short: "List probes of pods",
+		Args:  cobra.MaximumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			appName := ""
+			if len(args) > 0 {
+				appName = args[0]
+			}
+			if err := openEVEC.PodProbeList(appName); err != nil {
+				log.Fatalf("EVE pod probe ls failed: %s", err)
+			}
+		},
+	}
+
+	return podProbeLsCmd
+}
+
+func newPodProbeDeleteCmd() *cobra.Command {
+	var name string
+	var podProbeDeleteCmd = &cobra.Command{
+		Use:   "delete <app>",
+		Short: "Delete probes of pod",
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := openEVEC.PodProbeDelete(args[0], name); err != nil {
+				log.Fatalf("EVE pod probe delete failed: %s", err)
+			}
+		},
+	}
+	podProbeDeleteCmd.Flags().StringVar(&name, "name", "", "name of probe to delete, all probes of pod if not set")
+
+	return podProbeDeleteCmd
+}
+
+func newPodProbeRunCmd() *cobra.Command {
+	var count int
+	var podProbeRunCmd = &cobra.Command{
+		Use:   "run <app>",
+		Short: "Run probes of pod and print results",
+		Long:  `Run probes of pod count times with their interval. Fails if pod is not healthy after the last run.`,
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := openEVEC.PodProbeRun(args[0], count); err != nil {
+				log.Fatalf("EVE pod probe run failed: %s", err)
+			}
+		},
+	}
+	podProbeRunCmd.Flags().IntVar(&count, "count", 1, "number of runs")
+
+	return podProbeRunCmd
+}
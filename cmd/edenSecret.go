@@ -0,0 +1,141 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/lf-edge/eden/pkg/openevec"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+)
+
+func newSecretCmd() *cobra.Command {
+	var secretCmd = &cobra.Command{
+		Use:   "secret",
+		Short: "manage secrets",
+		Long: `Manage tokens, passwords and registry credentials kept encrypted in eden directory.
+Values of config in form secret:<name> are resolved from secrets when config is loaded.
+Credentials for registry used by pods are taken from secret registry/<host> in form user:password.`,
+	}
+
+	groups := CommandGroups{
+		{
+			Message: "Basic Commands",
+			Commands: []*cobra.Command{
+				newSecretSetCmd(),
+				newSecretGetCmd(),
+				newSecretListCmd(),
+				newSecretDeleteCmd(),
+				newSecretMigrateCmd(),
+			},
+		},
+	}
+
+	groups.AddTo(secretCmd)
+
+	return secretCmd
+}
+
+func newSecretSetCmd() *cobra.Command {
+	var secretSetCmd = &cobra.Command{
+		Use:   "set <name> [value]",
+		Short: "set secret",
+		Long:  `Set secret with name. Value is read from terminal or stdin if not provided, to keep it out of shell history.`,
+		Args:  cobra.RangeArgs(1, 2),
+		Run: func(cmd *cobra.Command, args []string) {
+			var value string
+			if len(args) == 2 {
+				value = args[1]
+			} else {
+				var err error
+				if value, err = readSecretValue(args[0]); err != nil {
+					log.Fatal(err)
+				}
+			}
+			if err := openevec.SecretSet(args[0], value); err != nil {
+				log.Fatal(err)
+			}
+		},
+	}
+
+	return secretSetCmd
+}
+
+func readSecretValue(name string) (string, error) {
+	if term.IsTerminal(int(os.Stdin.Fd())) {
+		fmt.Fprintf(os.Stderr, "Enter value of secret %s: ", name)
+		value, err := term.ReadPassword(int(os.Stdin.Fd()))
+		fmt.Fprintln(os.Stderr)
+		return string(value), err
+	}
+	value, err := io.ReadAll(os.Stdin)
+	return strings.TrimRight(string(value), "\r\n"), err
+}
+
+func newSecretGetCmd() *cobra.Command {
+	var secretGetCmd = &cobra.Command{
+		Use:   "get <name>",
+		Short: "print value of secret",
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := openevec.SecretGet(args[0]); err != nil {
+				log.Fatal(err)
+			}
+		},
+	}
+
+	return secretGetCmd
+}
+
+func newSecretListCmd() *cobra.Command {
+	var secretListCmd = &cobra.Command{
+		Use:   "ls",
+		Short: "list names of secrets",
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := openevec.SecretList(); err != nil {
+				log.Fatal(err)
+			}
+		},
+	}
+
+	return secretListCmd
+}
+
+func newSecretDeleteCmd() *cobra.Command {
+	var secretDeleteCmd = &cobra.Command{
+		Use:   "delete <name>",
+		Short: "delete secret",
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := openevec.SecretDelete(args[0]); err != nil {
+				log.Fatal(err)
+			}
+		},
+	}
+
+	return secretDeleteCmd
+}
+
+func newSecretMigrateCmd() *cobra.Command {
+	var secretMigrateCmd = &cobra.Command{
+		Use:   "migrate [context]",
+		Short: "move plaintext secrets of config into secrets",
+		Long: `Move plaintext values of keys of config context (current one by default) which may keep secrets
+(e.g. controller.zedcloud.token) into secrets and replace them with references.`,
+		Args: cobra.MaximumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			target := ""
+			if len(args) == 1 {
+				target = args[0]
+			}
+			if err := openevec.SecretMigrate(target); err != nil {
+				log.Fatal(err)
+			}
+		},
+	}
+
+	return secretMigrateCmd
+}
@@ -4,6 +4,7 @@ import (
 	"os"
 	"path/filepath"
 	"runtime"
+	"strings"
 
 	"github.com/lf-edge/eden/pkg/defaults"
 	"github.com/lf-edge/eden/pkg/openevec"
@@ -14,19 +15,23 @@ import (
 func newSetupCmd(configName, verbosity *string) *cobra.Command {
 	cfg := &openevec.EdenSetupArgs{}
 	var configDir, softSerial, zedControlURL, ipxeOverride string
-	var grubOptions []string
+	var grubOptions, components, addComponents []string
 	var netboot, installer bool
 
 	var setupCmd = &cobra.Command{
-		Use:               "setup",
-		Short:             "setup harness",
-		Long:              `Setup harness.`,
+		Use:   "setup",
+		Short: "setup harness",
+		Long: `Setup harness.
+
+By default all components are deployed: ` + strings.Join(openevec.AllComponents, ", ") + `.
+Use --components to deploy only some of them (e.g. with physical device or external datastores)
+and --add-component to add component to already selected ones later.`,
 		PersistentPreRunE: preRunViperLoadFunction(cfg, configName, verbosity),
 		Run: func(cmd *cobra.Command, args []string) {
 			if err := openevec.ConfigCheck(*configName); err != nil {
 				log.Fatalf("Config check failed %s", err)
 			}
-			if err := openEVEC.SetupEden(*configName, configDir, softSerial, zedControlURL, ipxeOverride, grubOptions, netboot, installer); err != nil {
+			if err := openEVEC.SetupEden(*configName, configDir, softSerial, zedControlURL, ipxeOverride, grubOptions, netboot, installer, components, addComponents); err != nil {
 
 				log.Fatalf("Setup eden failed: %s", err)
 			}
@@ -39,6 +44,7 @@ func newSetupCmd(configName, verbosity *string) *cobra.Command {
 	}
 
 	setupCmd.Flags().BoolVarP(&cfg.Eden.Download, "download", "", cfg.Eden.Download, "download EVE or build")
+	setupCmd.Flags().BoolVar(&cfg.Eden.NoCache, "no-cache", cfg.Eden.NoCache, "do not use cache of downloaded and generated images shared between contexts")
 	setupCmd.Flags().StringVar(&configDir, "eve-config-dir", filepath.Join(currentPath, "eve-config-dir"), "directory with files to put into EVE`s conf directory during setup")
 	setupCmd.Flags().BoolVar(&netboot, "netboot", false, "Setup for use with network boot")
 	setupCmd.Flags().BoolVar(&installer, "installer", false, "Setup for create installer")
@@ -46,6 +52,9 @@ func newSetupCmd(configName, verbosity *string) *cobra.Command {
 	setupCmd.Flags().StringVar(&zedControlURL, "zedcontrol", "", "Use provided zedcontrol domain instead of adam (as example: zedcloud.alpha.zededa.net)")
 	setupCmd.Flags().StringVar(&ipxeOverride, "ipxe-override", "", "override lines inside ipxe, please use || as delimiter")
 	setupCmd.Flags().StringArrayVar(&grubOptions, "grub-options", []string{}, "append lines to grub options")
+	setupCmd.Flags().StringSliceVar(&components, "components", nil, "components to deploy, saved into config as eden.components (default all)")
+	setupCmd.Flags().StringSliceVar(&addComponents, "add-component", nil, "add components to the ones selected in config")
+	setupCmd.Flags().StringVar(&cfg.Cloud.Provider, "cloud", "", "run EVE in cloud (gcp or aws) configured in config section cloud, saved into config as cloud.provider")
 
 	setupCmd.Flags().StringVarP(&cfg.Eden.CertsDir, "certs-dist", "o", cfg.Eden.CertsDir, "directory with certs")
 	setupCmd.Flags().StringVarP(&cfg.Adam.CertsDomain, "domain", "d", defaults.DefaultDomain, "FQDN for certificates")
@@ -14,6 +14,7 @@ import (
 func newStartCmd(configName, verbosity *string) *cobra.Command {
 	cfg := &openevec.EdenSetupArgs{}
 	var zedControlURL, vmName, tapInterface string
+	var resume bool
 
 	var startCmd = &cobra.Command{
 		Use:               "start",
@@ -21,7 +22,7 @@ func newStartCmd(configName, verbosity *string) *cobra.Command {
 		Long:              `Start harness.`,
 		PersistentPreRunE: preRunViperLoadFunction(cfg, configName, verbosity),
 		Run: func(cmd *cobra.Command, args []string) {
-			if err := openEVEC.StartEden(vmName, zedControlURL, tapInterface); err != nil {
+			if err := openEVEC.StartEden(vmName, zedControlURL, tapInterface, resume); err != nil {
 				log.Fatalf("Start eden failed: %s", err)
 			}
 		},
@@ -52,6 +53,10 @@ func newStartCmd(configName, verbosity *string) *cobra.Command {
 	startCmd.Flags().IntVarP(&cfg.Eden.EServer.Port, "eserver-port", "", defaults.DefaultEserverPort, "eserver port")
 	startCmd.Flags().StringVarP(&cfg.Eden.EServer.Tag, "eserver-tag", "", defaults.DefaultEServerTag, "tag of eserver container to pull")
 	startCmd.Flags().BoolVarP(&cfg.Eden.EServer.Force, "eserver-force", "", cfg.Eden.EServer.Force, "eserver force rebuild")
+	startCmd.Flags().BoolVarP(&cfg.Eden.EServer.TLS, "eserver-tls", "", cfg.Eden.EServer.TLS, "serve eserver over TLS")
+	startCmd.Flags().StringVarP(&cfg.Eden.EServer.TLSClientAuth, "eserver-tls-client-auth", "", defaults.DefaultEServerTLSClientAuth, "verification of client certificates by eserver: none, optional or require")
+	startCmd.Flags().StringVarP(&cfg.Eden.EServer.SFTPUser, "eserver-sftp-user", "", defaults.DefaultSFTPUser, "user for sftp access to eserver")
+	startCmd.Flags().StringVarP(&cfg.Eden.EServer.SFTPPassword, "eserver-sftp-password", "", defaults.DefaultSFTPPassword, "password for sftp access to eserver")
 
 	startCmd.Flags().IntVarP(&cfg.Eve.QemuCpus, "cpus", "", defaults.DefaultCpus, "cpus count")
 	startCmd.Flags().IntVarP(&cfg.Eve.QemuMemory, "memory", "", defaults.DefaultMemory, "memory size (MB)")
@@ -67,6 +72,7 @@ func newStartCmd(configName, verbosity *string) *cobra.Command {
 	startCmd.Flags().StringVarP(&cfg.Eve.ImageFile, "image-file", "", cfg.Eve.ImageFile, "path to image drive, overrides default setting")
 	startCmd.Flags().StringVarP(&vmName, "vmname", "", defaults.DefaultVBoxVMName, "vbox vmname required to create vm")
 	startCmd.Flags().StringVar(&zedControlURL, "zedcontrol", "", "Use provided zedcontrol domain instead of adam (as example: zedcloud.alpha.zededa.net)")
+	startCmd.Flags().BoolVar(&resume, "resume", false, "restore environment saved by the previous start (e.g. after reboot of the host), start without it resets the saved state")
 
 	startCmd.Flags().StringVarP(&cfg.Eve.UsbNetConfFile, "eve-usbnetconf-file", "", "", "path to device network config (aka usb.json) applied in runtime using a USB stick")
 
@@ -80,6 +86,7 @@ func addSdnStartOpts(parentCmd *cobra.Command, cfg *openevec.EdenSetupArgs) {
 	addSdnConfigDirOpt(parentCmd, cfg)
 	addSdnVmOpts(parentCmd, cfg)
 	addSdnNetModelOpt(parentCmd, cfg)
+	addSdnNetworkTypeOpt(parentCmd, cfg)
 	addSdnPortOpts(parentCmd, cfg)
 	addSdnLogOpt(parentCmd, cfg)
 	addSdnImageOpt(parentCmd, cfg)
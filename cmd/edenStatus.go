@@ -14,6 +14,7 @@ func newStatusCmd(configName, verbosity *string) *cobra.Command {
 	cfg := &openevec.EdenSetupArgs{}
 	var allConfigs bool
 	var vmName string
+	var storage openevec.StorageStatusOptions
 
 	var statusCmd = &cobra.Command{
 		Use:               "status",
@@ -21,7 +22,7 @@ func newStatusCmd(configName, verbosity *string) *cobra.Command {
 		Long:              `Status of harness.`,
 		PersistentPreRunE: preRunViperLoadFunction(cfg, configName, verbosity),
 		Run: func(cmd *cobra.Command, args []string) {
-			if err := openEVEC.Status(vmName, allConfigs); err != nil {
+			if err := openEVEC.Status(vmName, allConfigs, storage); err != nil {
 				log.Fatal(err)
 			}
 		},
@@ -35,9 +36,16 @@ func newStatusCmd(configName, verbosity *string) *cobra.Command {
 	statusCmd.Flags().BoolVar(&allConfigs, "all", true, "show status for all configs")
 	statusCmd.Flags().StringVarP(&vmName, "vmname", "", defaults.DefaultVBoxVMName, "vbox vmname required to create vm")
 
+	addStorageStatusOpts(statusCmd, &storage)
 	addSdnPidOpt(statusCmd, cfg)
 	addSdnPortOpts(statusCmd, cfg)
 
 	return statusCmd
 
 }
+
+func addStorageStatusOpts(parentCmd *cobra.Command, storage *openevec.StorageStatusOptions) {
+	parentCmd.Flags().Float64Var(&storage.Warn, "storage-warn", defaults.DefaultStorageWarn, "percent of used capacity of EVE disk or volume to warn about")
+	parentCmd.Flags().Float64Var(&storage.Fail, "storage-fail", defaults.DefaultStorageFail, "percent of used capacity of EVE disk or volume considered as failure")
+	parentCmd.Flags().BoolVar(&storage.FailOnStorage, "fail-on-storage", false, "fail if any EVE disk or volume reaches storage-fail threshold or has errors")
+}
@@ -29,6 +29,12 @@ func newStopCmd(configName, verbosity *string) *cobra.Command {
 				swtpmPidFile(cfg), cfg.Sdn.PidFile,
 				cfg.Eve.DevModel, vmName,
 			)
+			for _, node := range openevec.NodeConfigs(cfg)[1:] {
+				eden.StopEve(node.Eve.Pid, swtpmPidFile(node), node.Sdn.PidFile, node.Eve.DevModel, vmName)
+			}
+			if err := openEVEC.LOCStop(); err != nil {
+				log.Errorf("cannot stop LOC: %s", err)
+			}
 		},
 	}
 
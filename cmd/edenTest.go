@@ -24,6 +24,10 @@ test <test_dir> [-s <scenario>] [-t <timewait>] [-v <level>]
 test <test_dir> -l <regexp>
 test <test_dir> -o
 test <test_dir> -r <regexp> [-t <timewait>] [-v <level>]
+test <test_dir> -s <scenario> --matrix <matrix_file>
+test <test_dir> -s <scenario> --record <dir>
+test <test_dir> -s <scenario> --replay <dir>
+test <test_dir> -s <scenario> --reset-snapshot <name>
 
 `,
 		Args:              cobra.MaximumNArgs(1),
@@ -64,6 +68,8 @@ test <test_dir> -r <regexp> [-t <timewait>] [-v <level>]
 			}
 			tstCfg.ConfigFile = cfg.ConfigFile
 			tstCfg.Verbosity = *verbosity
+			tstCfg.EVEVersion = cfg.Eve.Tag
+			tstCfg.Setup = cfg
 			return nil
 		},
 		Run: func(cmd *cobra.Command, args []string) {
@@ -83,6 +89,74 @@ test <test_dir> -r <regexp> [-t <timewait>] [-v <level>]
 	testCmd.Flags().StringVarP(&tstCfg.TestScenario, "scenario", "s", "", "scenario for tests bunch running")
 	testCmd.Flags().StringVarP(&tstCfg.FailScenario, "fail_scenario", "f", "cfg.FailScenario.txt", "scenario for test failing")
 	testCmd.Flags().BoolVarP(&tstCfg.TestOpts, "opts", "o", false, "Options description for test binary which may be used in test scenarious and '-a|--args' option")
+	testCmd.Flags().BoolVar(&tstCfg.History, "history", false, "record results of tests into history database (enables verbose output of tests)")
+	testCmd.Flags().IntVar(&tstCfg.Retries, "retries", 0, "number of retries of failed test, tests passed only on retry are reported as flaky")
+	testCmd.Flags().StringVar(&tstCfg.Quarantine, "quarantine", "", "file with regular expressions of known flaky tests (one per line), their failures do not fail the run")
+	testCmd.Flags().StringVar(&tstCfg.HistoryDB, "history-db", "", "path to history database (default is test-history.db inside eden home)")
+	testCmd.Flags().StringVar(&tstCfg.APICoverage, "api-coverage", "", "record fields of EVE API exercised by tests and save report into the file")
+	testCmd.Flags().StringVar(&tstCfg.Matrix, "matrix", "", "file with matrix of EVE tags, hypervisors and architectures to run tests with each of them")
+	testCmd.Flags().StringVar(&tstCfg.Record, "record", "", "record configs and info, metrics and logs of device observed by tests into the directory if tests pass")
+	testCmd.Flags().StringVar(&tstCfg.Replay, "replay", "", "run tests against info, metrics and logs recorded into the directory instead of controller")
+
+	testCmd.Flags().StringVar(&tstCfg.ResetSnapshot, "reset-snapshot", "", "restore EVE from snapshot saved with 'eden eve snapshot' before every test of scenario")
+
+	testCmd.AddCommand(newTestReportCmd())
+	testCmd.AddCommand(newTestNewCmd())
 
 	return testCmd
 }
+
+func newTestReportCmd() *cobra.Command {
+	var historyDB, testRegexp string
+	var runs int
+	var trend bool
+
+	var testReportCmd = &cobra.Command{
+		Use:   "report",
+		Short: "Show results of tests from history",
+		Long: `Show results of tests recorded with 'eden test --history'.
+With --trend shows pass rate and duration trends of every test across runs,
+recent values are calculated for the recent half of runs.`,
+		Args: cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := openevec.TestReport(historyDB, runs, trend, testRegexp); err != nil {
+				log.Fatal(err)
+			}
+		},
+	}
+
+	testReportCmd.Flags().StringVar(&historyDB, "history-db", "", "path to history database (default is test-history.db inside eden home)")
+	testReportCmd.Flags().IntVar(&runs, "runs", 20, "number of the last runs to show")
+	testReportCmd.Flags().BoolVar(&trend, "trend", false, "show pass rate and duration trends")
+	testReportCmd.Flags().StringVarP(&testRegexp, "test", "r", "", "show only tests matching the regular expression")
+
+	return testReportCmd
+}
+
+func newTestNewCmd() *cobra.Command {
+	var testsDir, suite string
+
+	var testNewCmd = &cobra.Command{
+		Use:   "new <name>",
+		Short: "Create new test",
+		Long: `Create directory of new test with Makefile, eden-config.yml, scenario, escript skeleton
+and Go test wrapper, ready to build with make and run with 'eden test'.
+Tests are built by 'make build' of eden with all directories inside tests,
+with --suite the escript of test is also added into suite of workflow tests.`,
+		Args: cobra.ExactArgs(1),
+		// test creation does not need config of eden
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+			return nil
+		},
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := openevec.TestNew(testsDir, args[0], suite); err != nil {
+				log.Fatal(err)
+			}
+		},
+	}
+
+	testNewCmd.Flags().StringVar(&testsDir, "dir", "tests", "directory with tests")
+	testNewCmd.Flags().StringVar(&suite, "suite", "", "file of suite inside workflow directory of tests to add test into, e.g. smoke.tests.txt")
+
+	return testNewCmd
+}
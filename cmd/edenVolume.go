@@ -1,6 +1,8 @@
 package cmd
 
 import (
+	"time"
+
 	"github.com/dustin/go-humanize"
 	"github.com/lf-edge/eden/pkg/controller/types"
 	"github.com/lf-edge/eden/pkg/openevec"
@@ -25,12 +27,17 @@ func newVolumeCmd(configName, verbosity *string) *cobra.Command {
 				newVolumeDeleteCmd(),
 				newVolumeDetachCmd(),
 				newVolumeAttachCmd(),
+				newVolumeResizeCmd(),
+				newVolumeWaitCmd(),
 			},
 		},
 	}
 
 	groups.AddTo(volumeCmd)
 
+	volumeCmd.PersistentFlags().BoolVar(&cfg.DryRun, "dry-run", false, "show difference with config in controller instead of pushing changes")
+	volumeCmd.PersistentFlags().BoolVar(&cfg.ForcePush, "force-push", false, "push config unconditionally if controller does not support conditional update of config")
+
 	return volumeCmd
 }
 
@@ -55,7 +62,7 @@ func newVolumeLsCmd() *cobra.Command {
 
 func newVolumeCreateCmd() *cobra.Command {
 	var registry, diskSize, volumeName, volumeType, datastoreOverride string
-	var sftpLoad, directLoad bool
+	var sftpLoad, s3Load, directLoad bool
 
 	//volumeCreateCmd is a command to create volume
 	var volumeCreateCmd = &cobra.Command{
@@ -65,18 +72,20 @@ func newVolumeCreateCmd() *cobra.Command {
 		Run: func(cmd *cobra.Command, args []string) {
 			appLink := args[0]
 			err := openEVEC.VolumeCreate(appLink, registry, diskSize, volumeName,
-				volumeType, datastoreOverride, sftpLoad, directLoad)
+				volumeType, datastoreOverride, sftpLoad, s3Load, directLoad)
 			if err != nil {
 				log.Fatal(err)
 			}
 		},
 	}
 
-	volumeCreateCmd.Flags().StringVar(&registry, "registry", "remote", "Select registry to use for containers (remote/local)")
+	volumeCreateCmd.Flags().StringVar(&registry, "registry", "remote", "Select registry to use for containers (remote/local/eserver)")
 	volumeCreateCmd.Flags().StringVar(&diskSize, "disk-size", humanize.Bytes(0), "disk size (empty or 0 - same as in image)")
 	volumeCreateCmd.Flags().StringVarP(&volumeName, "name", "n", "", "name of volume, random if empty")
 	volumeCreateCmd.Flags().StringVar(&volumeType, "format", "", "volume type (qcow2, raw, qcow, vmdk, vhdx, iso or oci)")
 	volumeCreateCmd.Flags().BoolVar(&sftpLoad, "sftp", false, "force eserver to use sftp")
+	volumeCreateCmd.Flags().BoolVar(&s3Load, "s3", false, "force eserver to use S3 API")
+	volumeCreateCmd.MarkFlagsMutuallyExclusive("sftp", "s3")
 	volumeCreateCmd.Flags().BoolVar(&directLoad, "direct", true, "Use direct download for image instead of eserver")
 	volumeCreateCmd.Flags().StringVar(&datastoreOverride, "datastoreOverride", "", "Override datastore path for volume (when we use different URL for Eden and EVE or for local datastore)")
 
@@ -101,28 +110,35 @@ func newVolumeDeleteCmd() *cobra.Command {
 }
 
 func newVolumeDetachCmd() *cobra.Command {
+	var purge bool
 	//volumeDetachCmd is a command to detach volume
 	var volumeDetachCmd = &cobra.Command{
-		Use:   "detach <name>",
-		Short: "Detach volume",
-		Args:  cobra.ExactArgs(1),
+		Use:   "detach <name> [app name]",
+		Short: "Detach volume from app or from all apps",
+		Args:  cobra.RangeArgs(1, 2),
 		Run: func(cmd *cobra.Command, args []string) {
 			volumeName := args[0]
-			if err := openEVEC.VolumeDetach(volumeName); err != nil {
+			appName := ""
+			if len(args) > 1 {
+				appName = args[1]
+			}
+			if err := openEVEC.VolumeDetach(volumeName, appName, purge); err != nil {
 				log.Fatal(err)
 			}
 		},
 	}
+	volumeDetachCmd.Flags().BoolVar(&purge, "purge", true, "purge app to apply the change, set to false to hot-detach")
 
 	return volumeDetachCmd
 }
 
 func newVolumeAttachCmd() *cobra.Command {
+	var purge bool
 	//volumeAttachCmd is a command to attach volume to app instance
 	var volumeAttachCmd = &cobra.Command{
 		Use:   "attach <volume name> <app name> [mount point]",
 		Short: "Attach volume to app",
-		Args:  cobra.MinimumNArgs(2),
+		Args:  cobra.RangeArgs(2, 3),
 		Run: func(cmd *cobra.Command, args []string) {
 			volumeName := args[0]
 			appName := args[1]
@@ -131,10 +147,51 @@ func newVolumeAttachCmd() *cobra.Command {
 				mountPoint = args[2]
 			}
 
-			if err := openEVEC.VolumeAttach(appName, volumeName, mountPoint); err != nil {
+			if err := openEVEC.VolumeAttach(appName, volumeName, mountPoint, purge); err != nil {
 				log.Fatal(err)
 			}
 		},
 	}
+	volumeAttachCmd.Flags().BoolVar(&purge, "purge", true, "purge app to apply the change, set to false to hot-attach")
+
 	return volumeAttachCmd
 }
+
+func newVolumeResizeCmd() *cobra.Command {
+	//volumeResizeCmd is a command to grow volume
+	var volumeResizeCmd = &cobra.Command{
+		Use:   "resize <name> <size>",
+		Short: "Set maximum size of volume (e.g. 2GB), volumes can only grow",
+		Args:  cobra.ExactArgs(2),
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := openEVEC.VolumeResize(args[0], args[1]); err != nil {
+				log.Fatal(err)
+			}
+		},
+	}
+
+	return volumeResizeCmd
+}
+
+func newVolumeWaitCmd() *cobra.Command {
+	var conditions []string
+	var timeout time.Duration
+	//volumeWaitCmd is a command to wait for volume state reported by EVE
+	var volumeWaitCmd = &cobra.Command{
+		Use:   "wait <name>",
+		Short: "Wait for volume to meet conditions",
+		Long: `Wait for volume to meet all provided conditions reported by EVE.
+Supported conditions: state=<STATE> (e.g. state=CREATED_VOLUME), max-size=<size> (e.g. max-size=2GB),
+attached=<app name>, detached=<app name>.`,
+		Args: cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := openEVEC.VolumeWait(args[0], conditions, timeout); err != nil {
+				log.Fatalf("EVE volume wait failed: %s", err)
+			}
+		},
+	}
+	volumeWaitCmd.Flags().StringSliceVar(&conditions, "for", []string{"state=CREATED_VOLUME"}, "conditions to wait for")
+	volumeWaitCmd.Flags().DurationVar(&timeout, "timeout", 10*time.Minute, "time to wait for conditions")
+
+	return volumeWaitCmd
+}
@@ -0,0 +1,141 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/lf-edge/eden/pkg/controller/types"
+	"github.com/lf-edge/eden/pkg/defaults"
+	"github.com/lf-edge/eden/pkg/edgeview"
+	"github.com/lf-edge/eden/pkg/openevec"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"github.com/thediveo/enumflag"
+)
+
+func newEdgeviewCmd(configName, verbosity *string) *cobra.Command {
+	cfg := &openevec.EdenSetupArgs{}
+	var image string
+	var outputFormat types.OutputFormat
+
+	var edgeviewCmd = &cobra.Command{
+		Use:   "edgeview",
+		Short: "Remote debugging of EVE with edgeview",
+		Long: `
+Edgeview connects to EVE through the dispatcher without direct access to the device.
+Start the session with token signed by the controller and run queries:
+
+  eden edgeview start --dispatcher 192.168.0.1:4000
+  eden edgeview tcpdump eth0 "port 53"
+  eden edgeview log --time 0-1 panic
+  eden edgeview query pub/nim`,
+		PersistentPreRunE: preRunViperLoadFunction(cfg, configName, verbosity),
+	}
+
+	query := func(use, short string, args cobra.PositionalArgs, toQuery func(args []string) []string) *cobra.Command {
+		return &cobra.Command{
+			Use:   use,
+			Short: short,
+			Args:  args,
+			Run: func(cmd *cobra.Command, args []string) {
+				if err := openEVEC.EdgeviewQuery(image, toQuery(args), outputFormat); err != nil {
+					log.Fatalf("Edgeview query failed: %s", err)
+				}
+			},
+		}
+	}
+	var timeRange string
+	logCmd := query("log <pattern>", "Search logs of EVE for pattern", cobra.ExactArgs(1), func(args []string) []string {
+		return edgeview.LogQuery(args[0], timeRange)
+	})
+	logCmd.Flags().StringVar(&timeRange, "time", "", "time range in hours back from now, e.g. 0.5-2")
+
+	edgeviewCmd.AddCommand(newEdgeviewStartCmd())
+	edgeviewCmd.AddCommand(newEdgeviewStopCmd())
+	edgeviewCmd.AddCommand(newEdgeviewTokenCmd())
+	edgeviewCmd.AddCommand(query("query <query...>", "Run raw edgeview query", cobra.MinimumNArgs(1), func(args []string) []string {
+		return args
+	}))
+	edgeviewCmd.AddCommand(query("tcpdump <eve-interface> [filter]", "Capture packets on interface of EVE", cobra.RangeArgs(1, 2), func(args []string) []string {
+		filter := ""
+		if len(args) > 1 {
+			filter = args[1]
+		}
+		return []string{edgeview.TcpdumpQuery(args[0], filter)}
+	}))
+	edgeviewCmd.AddCommand(logCmd)
+	edgeviewCmd.AddCommand(query("app <app>", "Show state of app", cobra.ExactArgs(1), func(args []string) []string {
+		return []string{edgeview.AppQuery(args[0])}
+	}))
+	edgeviewCmd.AddCommand(&cobra.Command{
+		Use:   "console <app>",
+		Short: "Forward VNC console of app to local port",
+		Long:  fmt.Sprintf("Forward VNC console of app to localhost:%d until interrupted, app must be deployed with --vnc-display.", edgeview.ClientPort),
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := openEVEC.EdgeviewConsole(image, args[0]); err != nil {
+				log.Fatalf("Edgeview console failed: %s", err)
+			}
+		},
+	})
+
+	edgeviewCmd.PersistentFlags().StringVar(&image, "image", fmt.Sprintf("%s:%s", defaults.DefaultEdgeviewContainerRef, defaults.DefaultEdgeviewTag), "image of edgeview client")
+	edgeviewCmd.PersistentFlags().Var(
+		enumflag.New(&outputFormat, "format", outputFormatIds, enumflag.EnumCaseInsensitive),
+		"format",
+		"Format to print output of queries, supports: lines, json")
+
+	return edgeviewCmd
+}
+
+func newEdgeviewStartCmd() *cobra.Command {
+	opts := openevec.EdgeviewOptions{}
+	var instances uint8
+
+	var edgeviewStartCmd = &cobra.Command{
+		Use:   "start",
+		Short: "Start edgeview session on EVE",
+		Run: func(cmd *cobra.Command, args []string) {
+			opts.Instances = instances
+			if err := openEVEC.EdgeviewStart(opts); err != nil {
+				log.Fatalf("Edgeview start failed: %s", err)
+			}
+		},
+	}
+
+	edgeviewStartCmd.Flags().StringVar(&opts.Dispatcher, "dispatcher", "", "endpoint of edgeview dispatcher in form of host:port")
+	edgeviewStartCmd.Flags().StringVar(&opts.DispatcherCert, "dispatcher-cert", "", "certificate of dispatcher if it is not signed by well-known CA")
+	edgeviewStartCmd.Flags().DurationVar(&opts.Expire, "expire", defaults.DefaultEdgeviewExpire, "lifetime of session")
+	edgeviewStartCmd.Flags().Uint8Var(&instances, "instances", 1, "number of edgeview instances on EVE")
+	edgeviewStartCmd.Flags().BoolVar(&opts.Encrypt, "encrypt", false, "encrypt payload instead of authentication only")
+	edgeviewStartCmd.Flags().BoolVar(&opts.AllowApp, "allow-app", true, "allow access to apps")
+	edgeviewStartCmd.Flags().BoolVar(&opts.AllowExt, "allow-ext", false, "allow access to external endpoints of EVE")
+	_ = edgeviewStartCmd.MarkFlagRequired("dispatcher")
+
+	return edgeviewStartCmd
+}
+
+func newEdgeviewStopCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "stop",
+		Short: "Stop edgeview session on EVE",
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := openEVEC.EdgeviewStop(); err != nil {
+				log.Fatalf("Edgeview stop failed: %s", err)
+			}
+		},
+	}
+}
+
+func newEdgeviewTokenCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "token",
+		Short: "Print token of edgeview session to use with edgeview client directly",
+		Run: func(cmd *cobra.Command, args []string) {
+			token, err := openEVEC.EdgeviewToken()
+			if err != nil {
+				log.Fatalf("Edgeview token failed: %s", err)
+			}
+			fmt.Println(token)
+		},
+	}
+}
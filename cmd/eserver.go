@@ -25,6 +25,7 @@ func newEserverCmd(configName, verbosity *string) *cobra.Command {
 				newStartEserverCmd(cfg),
 				newStopEserverCmd(),
 				newStatusEserverCmd(cfg),
+				newRotateCertsEserverCmd(),
 			},
 		},
 	}
@@ -47,10 +48,8 @@ func newStartEserverCmd(cfg *openevec.EdenSetupArgs) *cobra.Command {
 			}
 			log.Infof("Executable path: %s", command)
 
-			if err := eden.StartEServer(cfg.Eden.EServer.Port, cfg.Eden.Images.EServerImageDist, cfg.Eden.EServer.Force, cfg.Eden.EServer.Tag); err != nil {
-				log.Errorf("cannot start eserver: %s", err)
-			} else {
-				log.Infof("Eserver is running and accesible on port %d", cfg.Eden.EServer.Port)
+			if err := openEVEC.StartEServer(); err != nil {
+				log.Error(err)
 			}
 		},
 	}
@@ -59,6 +58,10 @@ func newStartEserverCmd(cfg *openevec.EdenSetupArgs) *cobra.Command {
 	startEserverCmd.Flags().IntVarP(&cfg.Eden.EServer.Port, "eserver-port", "", defaults.DefaultEserverPort, "eserver port")
 	startEserverCmd.Flags().StringVarP(&cfg.Eden.EServer.Tag, "eserver-tag", "", defaults.DefaultEServerTag, "tag of eserver container to pull")
 	startEserverCmd.Flags().BoolVarP(&cfg.Eden.EServer.Force, "eserver-force", "", false, "eserver force rebuild")
+	startEserverCmd.Flags().BoolVarP(&cfg.Eden.EServer.TLS, "eserver-tls", "", false, "serve eserver over TLS")
+	startEserverCmd.Flags().StringVarP(&cfg.Eden.EServer.TLSClientAuth, "eserver-tls-client-auth", "", defaults.DefaultEServerTLSClientAuth, "verification of client certificates by eserver: none, optional or require")
+	startEserverCmd.Flags().StringVarP(&cfg.Eden.EServer.SFTPUser, "eserver-sftp-user", "", defaults.DefaultSFTPUser, "user for sftp access to eserver")
+	startEserverCmd.Flags().StringVarP(&cfg.Eden.EServer.SFTPPassword, "eserver-sftp-password", "", defaults.DefaultSFTPPassword, "password for sftp access to eserver")
 
 	return startEserverCmd
 }
@@ -98,3 +101,17 @@ func newStatusEserverCmd(cfg *openevec.EdenSetupArgs) *cobra.Command {
 	}
 	return statusEserverCmd
 }
+
+func newRotateCertsEserverCmd() *cobra.Command {
+	var rotateCertsEserverCmd = &cobra.Command{
+		Use:   "rotate-certs",
+		Short: "rotate certificate of eserver",
+		Long:  `Generate new certificate of eserver served over TLS and update certificates of eserver datastores in config of device.`,
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := openEVEC.EServerRotateCerts(); err != nil {
+				log.Fatal(err)
+			}
+		},
+	}
+	return rotateCertsEserverCmd
+}
@@ -6,11 +6,16 @@ import (
 	"path/filepath"
 	"runtime"
 	"strings"
+	"time"
 
+	"github.com/lf-edge/eden/pkg/controller/types"
 	"github.com/lf-edge/eden/pkg/defaults"
+	"github.com/lf-edge/eden/pkg/eve"
 	"github.com/lf-edge/eden/pkg/openevec"
+	"github.com/lf-edge/eden/pkg/utils"
 	log "github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
+	"github.com/thediveo/enumflag"
 )
 
 func newEveCmd(configName, verbosity *string) *cobra.Command {
@@ -29,11 +34,18 @@ func newEveCmd(configName, verbosity *string) *cobra.Command {
 				newIpEveCmd(),
 				newSshEveCmd(cfg),
 				newConsoleEveCmd(cfg),
+				newConsoleCaptureEveCmd(),
+				newCellularModemEveCmd(),
 				newOnboardEveCmd(cfg),
 				newResetEveCmd(),
 				newVersionEveCmd(),
 				newEpochEveCmd(),
+				newEventsEveCmd(),
 				newLinkEveCmd(cfg),
+				newQmpEveCmd(cfg),
+				newSnapshotEveCmd(),
+				newRestoreEveCmd(),
+				newBootProfileEveCmd(),
 			},
 		},
 	}
@@ -60,8 +72,10 @@ func newStartEveCmd(cfg *openevec.EdenSetupArgs) *cobra.Command {
 		Short: "start eve",
 		Long:  `Start eve.`,
 		Run: func(cmd *cobra.Command, args []string) {
-			if err := openEVEC.StartEve(vmName, tapInterface); err != nil {
-				log.Fatal(err)
+			for _, node := range openevec.NodeConfigs(cfg) {
+				if err := openevec.CreateOpenEVEC(node).StartEve(vmName, tapInterface); err != nil {
+					log.Fatal(err)
+				}
 			}
 		},
 	}
@@ -71,7 +85,7 @@ func newStartEveCmd(cfg *openevec.EdenSetupArgs) *cobra.Command {
 		log.Fatal(err)
 	}
 
-	startEveCmd.Flags().StringVarP(&vmName, "vmname", "", defaults.DefaultVBoxVMName, "vbox vmname required to create vm")
+	startEveCmd.Flags().StringVarP(&vmName, "vmname", "", defaults.DefaultVBoxVMName, "vmname of EVE in hypervisor (VBox, Parallels, VMware, HyperV)")
 
 	startEveCmd.Flags().StringVarP(&cfg.Eve.ImageFile, "image-file", "", "", "path for image drive (required)")
 	startEveCmd.Flags().StringVarP(&cfg.Eve.Arch, "eve-arch", "", runtime.GOARCH, "arch of system")
@@ -99,8 +113,10 @@ func newStopEveCmd(cfg *openevec.EdenSetupArgs) *cobra.Command {
 		Short: "stop eve",
 		Long:  `Stop eve.`,
 		Run: func(cmd *cobra.Command, args []string) {
-			if err := openEVEC.StopEve(vmName); err != nil {
-				log.Fatal(err)
+			for _, node := range openevec.NodeConfigs(cfg) {
+				if err := openevec.CreateOpenEVEC(node).StopEve(vmName); err != nil {
+					log.Fatal(err)
+				}
 			}
 		},
 	}
@@ -111,7 +127,7 @@ func newStopEveCmd(cfg *openevec.EdenSetupArgs) *cobra.Command {
 	}
 
 	stopEveCmd.Flags().StringVarP(&cfg.Eve.Pid, "eve-pid", "", filepath.Join(currentPath, defaults.DefaultDist, "eve.pid"), "file for save EVE pid")
-	stopEveCmd.Flags().StringVarP(&vmName, "vmname", "", defaults.DefaultVBoxVMName, "vbox vmname required to create vm")
+	stopEveCmd.Flags().StringVarP(&vmName, "vmname", "", defaults.DefaultVBoxVMName, "vmname of EVE in hypervisor (VBox, Parallels, VMware, HyperV)")
 
 	return stopEveCmd
 }
@@ -133,14 +149,21 @@ func newVersionEveCmd() *cobra.Command {
 
 func newStatusEveCmd(cfg *openevec.EdenSetupArgs) *cobra.Command {
 	var vmName string
+	var storage openevec.StorageStatusOptions
 
 	var statusEveCmd = &cobra.Command{
 		Use:   "status",
 		Short: "status of eve",
 		Long:  `Status of eve.`,
 		Run: func(cmd *cobra.Command, args []string) {
-			if err := openEVEC.StatusEve(vmName); err != nil {
-				log.Fatal(err)
+			nodes := openevec.NodeConfigs(cfg)
+			for _, node := range nodes {
+				if len(nodes) > 1 {
+					fmt.Printf("--- node: %s ---\n", utils.NodeName(node.Node))
+				}
+				if err := openevec.CreateOpenEVEC(node).StatusEve(vmName, storage); err != nil {
+					log.Fatal(err)
+				}
 			}
 		},
 	}
@@ -151,7 +174,8 @@ func newStatusEveCmd(cfg *openevec.EdenSetupArgs) *cobra.Command {
 	}
 
 	statusEveCmd.Flags().StringVarP(&cfg.Eve.Pid, "eve-pid", "", filepath.Join(currentPath, defaults.DefaultDist, "eve.pid"), "file for save EVE pid")
-	statusEveCmd.Flags().StringVarP(&vmName, "vmname", "", defaults.DefaultVBoxVMName, "vbox vmname required to create vm")
+	statusEveCmd.Flags().StringVarP(&vmName, "vmname", "", defaults.DefaultVBoxVMName, "vmname of EVE in hypervisor (VBox, Parallels, VMware, HyperV)")
+	addStorageStatusOpts(statusEveCmd, &storage)
 
 	return statusEveCmd
 }
@@ -170,14 +194,28 @@ func newIpEveCmd() *cobra.Command {
 }
 
 func newConsoleEveCmd(cfg *openevec.EdenSetupArgs) *cobra.Command {
-	var host string
+	var host, vmName, since string
+	var follow bool
 
 	var consoleEveCmd = &cobra.Command{
 		Use:   "console",
 		Short: "telnet into eve",
-		Long:  `Telnet into eve.`,
+		Long: `Telnet into eve.
+
+With --follow or --since console of EVE in QEMU captured from its start is printed instead,
+every line is prefixed with time it was printed.`,
 		Run: func(cmd *cobra.Command, args []string) {
-			if err := openEVEC.ConsoleEve(host); err != nil {
+			if follow || since != "" {
+				sinceTime, err := openevec.ParseConsoleSince(since)
+				if err != nil {
+					log.Fatal(err)
+				}
+				if err := openEVEC.ConsoleEveCaptured(sinceTime, follow); err != nil {
+					log.Fatal(err)
+				}
+				return
+			}
+			if err := openEVEC.ConsoleEve(host, vmName); err != nil {
 				log.Fatal(err)
 			}
 		},
@@ -185,10 +223,60 @@ func newConsoleEveCmd(cfg *openevec.EdenSetupArgs) *cobra.Command {
 
 	consoleEveCmd.Flags().StringVarP(&host, "eve-host", "", defaults.DefaultEVEHost, "IP of eve")
 	consoleEveCmd.Flags().IntVarP(&cfg.Eve.TelnetPort, "eve-telnet-port", "", defaults.DefaultTelnetPort, "Port for telnet access")
+	consoleEveCmd.Flags().StringVarP(&vmName, "vmname", "", defaults.DefaultVBoxVMName, "vmname of EVE in hypervisor (VBox, Parallels, VMware, HyperV)")
+	consoleEveCmd.Flags().BoolVarP(&follow, "follow", "f", false, "print captured console and wait for new lines")
+	consoleEveCmd.Flags().StringVar(&since, "since", "", "print captured console since duration ago (10m) or time (RFC3339)")
 
 	return consoleEveCmd
 }
 
+func newConsoleCaptureEveCmd() *cobra.Command {
+	var source, dir, qemuPidFile string
+	var offset int64
+
+	var consoleCaptureEveCmd = &cobra.Command{
+		Use:    "console-capture",
+		Short:  "capture console of eve",
+		Long:   `Capture console of EVE written by QEMU into rotating files until QEMU exits, it is started by eden eve start.`,
+		Hidden: true,
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := openevec.ConsoleCapture(source, dir, qemuPidFile, offset); err != nil {
+				log.Fatal(err)
+			}
+		},
+	}
+
+	consoleCaptureEveCmd.Flags().StringVar(&source, "source", "", "console log of QEMU")
+	consoleCaptureEveCmd.Flags().Int64Var(&offset, "offset", 0, "offset in console log to start capture from")
+	consoleCaptureEveCmd.Flags().StringVar(&dir, "dir", "", "directory to keep captured console")
+	consoleCaptureEveCmd.Flags().StringVar(&qemuPidFile, "qemu-pid", "", "pid file of QEMU")
+
+	return consoleCaptureEveCmd
+}
+
+func newCellularModemEveCmd() *cobra.Command {
+	var socket, netDev, mac, apn string
+
+	var cellularModemEveCmd = &cobra.Command{
+		Use:    "cellular-modem",
+		Short:  "emulate cellular modem of eve",
+		Long:   `Emulate MBIM modem attached to QEMU over usbredir with data path into SDN, it is started by eden eve start.`,
+		Hidden: true,
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := openevec.CellularModem(socket, netDev, mac, apn); err != nil {
+				log.Fatal(err)
+			}
+		},
+	}
+
+	cellularModemEveCmd.Flags().StringVar(&socket, "socket", "", "unix socket to serve the modem for usb-redir of QEMU")
+	cellularModemEveCmd.Flags().StringVar(&netDev, "netdev", "", "address of QEMU socket netdev of SDN port")
+	cellularModemEveCmd.Flags().StringVar(&mac, "mac", "", "MAC of the modem in SDN network")
+	cellularModemEveCmd.Flags().StringVar(&apn, "apn", "", "APN accepted by the network")
+
+	return cellularModemEveCmd
+}
+
 func newSshEveCmd(cfg *openevec.EdenSetupArgs) *cobra.Command {
 	var sshEveCmd = &cobra.Command{
 		Use:   "ssh [command]",
@@ -221,8 +309,10 @@ func newOnboardEveCmd(cfg *openevec.EdenSetupArgs) *cobra.Command {
 		Short: "OnBoard EVE in Adam",
 		Long:  `Adding an EVE onboarding certificate to Adam and waiting for EVE to register.`,
 		Run: func(cmd *cobra.Command, args []string) {
-			if err := openEVEC.OnboardEve(cfg.Eve.CertsUUID); err != nil {
-				log.Fatalf("Eve onboard failed: %s", err)
+			for _, node := range openevec.NodeConfigs(cfg) {
+				if err := openevec.CreateOpenEVEC(node).OnboardEve(node.Eve.CertsUUID); err != nil {
+					log.Fatalf("Eve onboard failed: %s", err)
+				}
 			}
 		},
 	}
@@ -262,6 +352,39 @@ func newEpochEveCmd() *cobra.Command {
 	return epochEveCmd
 }
 
+func newEventsEveCmd() *cobra.Command {
+	var timeout time.Duration
+
+	var eventsEveCmd = &cobra.Command{
+		Use:   "events [type...]",
+		Short: "Print changes of EVE state",
+		Long: fmt.Sprintf(`Print changes of EVE state detected in info messages as they come.
+Types of events to print (all if not set): %v`, eve.EventTypes),
+		Run: func(cmd *cobra.Command, args []string) {
+			var types []eve.EventType
+			for _, arg := range args {
+				known := false
+				for _, eventType := range eve.EventTypes {
+					if string(eventType) == arg {
+						known = true
+						types = append(types, eventType)
+					}
+				}
+				if !known {
+					log.Fatalf("Unknown type of event %s, supported: %v", arg, eve.EventTypes)
+				}
+			}
+			if err := openEVEC.EveEvents(types, timeout); err != nil {
+				log.Fatalf("EVE events failed: %s", err)
+			}
+		},
+	}
+
+	eventsEveCmd.Flags().DurationVar(&timeout, "timeout", 0, "stop after timeout (0 to wait forever)")
+
+	return eventsEveCmd
+}
+
 func newLinkEveCmd(cfg *openevec.EdenSetupArgs) *cobra.Command {
 	var eveInterfaceName, vmName string
 
@@ -286,3 +409,125 @@ func newLinkEveCmd(cfg *openevec.EdenSetupArgs) *cobra.Command {
 
 	return linkEveCmd
 }
+
+func newQmpEveCmd(cfg *openevec.EdenSetupArgs) *cobra.Command {
+	var qmpEveCmd = &cobra.Command{
+		Use:   "qmp <command> [args]",
+		Short: "run command in QEMU monitor of EVE",
+		Long: `Run command in QEMU monitor of EVE. Only the subset of commands which do not stop EVE is supported:
+` + openevec.QmpUsage(),
+		Args: cobra.MinimumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			out, err := openEVEC.EveQmp(args[0], args[1:])
+			if err != nil {
+				log.Fatalf("EVE qmp failed: %s", err)
+			}
+			if out != "" {
+				fmt.Println(out)
+			}
+		},
+	}
+
+	qmpEveCmd.Flags().IntVarP(&cfg.Eve.QemuConfig.MonitorPort, "qemu-monitor-port", "", defaults.DefaultQemuMonitorPort, "Port for access to QEMU monitor")
+
+	return qmpEveCmd
+}
+
+func newSnapshotEveCmd() *cobra.Command {
+	var list, del bool
+
+	var snapshotEveCmd = &cobra.Command{
+		Use:   "snapshot [name]",
+		Short: "save snapshot of running EVE",
+		Long: `Save memory and disks of running EVE in QEMU and its config in controller into snapshot.
+Restore it with 'eden eve restore' to return EVE into the same state in seconds.
+Name of snapshot is ` + defaults.DefaultEveSnapshot + ` if not set.`,
+		Args: cobra.MaximumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			if list {
+				if err := openEVEC.EveSnapshotList(); err != nil {
+					log.Fatalf("EVE snapshot failed: %s", err)
+				}
+				return
+			}
+			name := defaults.DefaultEveSnapshot
+			if len(args) > 0 {
+				name = args[0]
+			}
+			if del {
+				if err := openEVEC.EveSnapshotDelete(name); err != nil {
+					log.Fatalf("EVE snapshot failed: %s", err)
+				}
+				return
+			}
+			if err := openEVEC.EveSnapshot(name); err != nil {
+				log.Fatalf("EVE snapshot failed: %s", err)
+			}
+		},
+	}
+
+	snapshotEveCmd.Flags().BoolVar(&list, "list", false, "list snapshots of EVE")
+	snapshotEveCmd.Flags().BoolVar(&del, "delete", false, "delete snapshot of EVE")
+
+	return snapshotEveCmd
+}
+
+func newRestoreEveCmd() *cobra.Command {
+	var restoreEveCmd = &cobra.Command{
+		Use:   "restore [name]",
+		Short: "restore EVE from snapshot",
+		Long: `Restore memory and disks of running EVE in QEMU and its config in controller from snapshot
+saved with 'eden eve snapshot'. Name of snapshot is ` + defaults.DefaultEveSnapshot + ` if not set.`,
+		Args: cobra.MaximumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			name := defaults.DefaultEveSnapshot
+			if len(args) > 0 {
+				name = args[0]
+			}
+			if err := openEVEC.EveRestore(name); err != nil {
+				log.Fatalf("EVE restore failed: %s", err)
+			}
+		},
+	}
+
+	return restoreEveCmd
+}
+
+func newBootProfileEveCmd() *cobra.Command {
+	var markers []string
+	var baselineFile string
+	var outputFormat types.OutputFormat
+
+	var bootProfileEveCmd = &cobra.Command{
+		Use:   "boot-profile",
+		Short: "show time of stages of the last boot of EVE",
+		Long: `Show time of stages of the last boot of EVE from power-on to onboarding and running apps.
+Stages are found with markers in console of EVE, info messages and requests received by controller.
+Save profile with --format json and pass it with --compare to see changes between EVE versions.`,
+		Args: cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			profile, err := openEVEC.EveBootProfile(markers)
+			if err != nil {
+				log.Fatalf("EVE boot-profile failed: %s", err)
+			}
+			var baseline *openevec.BootProfile
+			if baselineFile != "" {
+				if baseline, err = openevec.ReadBootProfile(baselineFile); err != nil {
+					log.Fatal(err)
+				}
+			}
+			if err = openevec.PrintBootProfile(os.Stdout, profile, baseline, outputFormat); err != nil {
+				log.Fatal(err)
+			}
+		},
+	}
+
+	bootProfileEveCmd.Flags().StringArrayVar(&markers, "marker", openevec.DefaultBootMarkers, "marker of stage in console of EVE in format name=regexp, only kernel messages with timestamps are used")
+	bootProfileEveCmd.Flags().StringVar(&baselineFile, "compare", "", "profile saved with --format json to compare with")
+	bootProfileEveCmd.Flags().Var(
+		enumflag.New(&outputFormat, "format", outputFormatIds, enumflag.EnumCaseInsensitive),
+		"format",
+		"Format to print profile, supports: lines, json")
+
+	return bootProfileEveCmd
+}
@@ -0,0 +1,178 @@
+package cmd
+
+import (
+	"github.com/lf-edge/eden/pkg/defaults"
+	"github.com/lf-edge/eden/pkg/openevec"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+func newLocCmd(configName, verbosity *string) *cobra.Command {
+	cfg := &openevec.EdenSetupArgs{}
+	var locCmd = &cobra.Command{
+		Use:   "loc",
+		Short: "emulate Local Operator Console",
+		Long: `Emulate Local Operator Console (LOC) which serves config to EVE when the controller is not reachable.
+Configs are staged on LOC with 'eden loc stage' and EVE is told about LOC with 'eden loc enable'.
+Use 'eden sdn cloud cut' to make the controller unreachable for EVE running with Eden-SDN.`,
+		PersistentPreRunE: preRunViperLoadFunction(cfg, configName, verbosity),
+	}
+
+	groups := CommandGroups{
+		{
+			Message: "Basic Commands",
+			Commands: []*cobra.Command{
+				newLocStartCmd(),
+				newLocStopCmd(),
+				newLocStatusCmd(),
+				newLocStageCmd(),
+				newLocUnstageCmd(),
+				newLocEnableCmd(),
+				newLocDisableCmd(),
+				newLocServeCmd(),
+			},
+		},
+	}
+
+	groups.AddTo(locCmd)
+
+	return locCmd
+}
+
+func newLocStartCmd() *cobra.Command {
+	var locStartCmd = &cobra.Command{
+		Use:   "start",
+		Short: "start LOC",
+		Long:  `Start LOC in background on port eden.loc.port, it serves configs staged in eden.loc.dist.`,
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := openEVEC.LOCStart(); err != nil {
+				log.Fatal(err)
+			}
+		},
+	}
+
+	return locStartCmd
+}
+
+func newLocStopCmd() *cobra.Command {
+	var locStopCmd = &cobra.Command{
+		Use:   "stop",
+		Short: "stop LOC",
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := openEVEC.LOCStop(); err != nil {
+				log.Fatal(err)
+			}
+		},
+	}
+
+	return locStopCmd
+}
+
+func newLocStatusCmd() *cobra.Command {
+	var locStatusCmd = &cobra.Command{
+		Use:   "status",
+		Short: "status of LOC and configs staged on it",
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := openEVEC.LOCStatus(); err != nil {
+				log.Fatal(err)
+			}
+		},
+	}
+
+	return locStatusCmd
+}
+
+func newLocStageCmd() *cobra.Command {
+	var fileWithConfig string
+
+	var locStageCmd = &cobra.Command{
+		Use:   "stage",
+		Short: "stage config of EVE on LOC",
+		Long: `Stage config of EVE on LOC. The current config of EVE in the controller is staged,
+or config from file in JSON (as printed by 'eden controller edge-node get-config') if --file is set.
+LOC signs config with the certificate of the controller, as EVE accepts only configs signed by it.`,
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := openEVEC.LOCStage(fileWithConfig); err != nil {
+				log.Fatal(err)
+			}
+		},
+	}
+
+	locStageCmd.Flags().StringVar(&fileWithConfig, "file", "", "file with config in JSON to stage")
+
+	return locStageCmd
+}
+
+func newLocUnstageCmd() *cobra.Command {
+	var locUnstageCmd = &cobra.Command{
+		Use:   "unstage",
+		Short: "remove config of EVE from LOC",
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := openEVEC.LOCUnstage(); err != nil {
+				log.Fatal(err)
+			}
+		},
+	}
+
+	return locUnstageCmd
+}
+
+func newLocEnableCmd() *cobra.Command {
+	var url string
+
+	var locEnableCmd = &cobra.Command{
+		Use:   "enable",
+		Short: "tell EVE to use LOC",
+		Long: `Push URL of LOC to EVE with config from the controller. URL of LOC started by eden is used
+if --url is not set. Stage config after it to keep LOC in config served by LOC.`,
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := openEVEC.LOCEnable(url); err != nil {
+				log.Fatal(err)
+			}
+		},
+	}
+
+	locEnableCmd.Flags().StringVar(&url, "url", "", "URL of LOC for EVE")
+
+	return locEnableCmd
+}
+
+func newLocDisableCmd() *cobra.Command {
+	var locDisableCmd = &cobra.Command{
+		Use:   "disable",
+		Short: "tell EVE to not use LOC",
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := openEVEC.LOCDisable(); err != nil {
+				log.Fatal(err)
+			}
+		},
+	}
+
+	return locDisableCmd
+}
+
+func newLocServeCmd() *cobra.Command {
+	var dir string
+	var port int
+
+	var locServeCmd = &cobra.Command{
+		Use:    "serve",
+		Short:  "serve LOC",
+		Long:   `Serve configs staged on LOC in foreground, it is started by eden loc start.`,
+		Hidden: true,
+		// LOC is served without config of eden
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+			return nil
+		},
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := openevec.LOCServe(dir, port); err != nil {
+				log.Fatal(err)
+			}
+		},
+	}
+
+	locServeCmd.Flags().StringVar(&dir, "dir", "", "directory with staged configs")
+	locServeCmd.Flags().IntVar(&port, "port", defaults.DefaultLOCPort, "port to serve on")
+
+	return locServeCmd
+}
@@ -1,6 +1,9 @@
 package cmd
 
 import (
+	"regexp"
+
+	"github.com/lf-edge/eden/pkg/controller/elog"
 	"github.com/lf-edge/eden/pkg/controller/types"
 	log "github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
@@ -17,13 +20,31 @@ func newLogCmd() *cobra.Command {
 	var follow bool
 	var printFields []string
 	var logTail uint
+	var severity, since, until, match, logTemplate string
+	var sources []string
 
 	var logCmd = &cobra.Command{
 		Use:   "log [field:regexp ...]",
 		Short: "Get logs from a running EVE device",
-		Long:  ` Scans the ADAM logs for correspondence with regular expressions requests to json fields.`,
+		Long: ` Scans the ADAM logs for correspondence with regular expressions requests to json fields.
+ Entries can be narrowed with severity, source, time range and regexp of content and
+ printed with Go template, e.g.:
+   eden log --severity warning --source zedagent --since 30m --template '{{time .Timestamp}} {{.Source}}: {{trim .Content}}'`,
 		Run: func(cmd *cobra.Command, args []string) {
-			if err := openEVEC.EdenLog(outputFormat, follow, logTail, printFields, args); err != nil {
+			filter := &elog.Filter{Severity: severity, Sources: sources}
+			var err error
+			if filter.Since, err = elog.ParseTime(since); err != nil {
+				log.Fatalf("Invalid --since: %s", err)
+			}
+			if filter.Until, err = elog.ParseTime(until); err != nil {
+				log.Fatalf("Invalid --until: %s", err)
+			}
+			if match != "" {
+				if filter.Match, err = regexp.Compile(match); err != nil {
+					log.Fatalf("Invalid --match: %s", err)
+				}
+			}
+			if err := openEVEC.EdenLog(outputFormat, follow, logTail, printFields, args, filter, logTemplate); err != nil {
 				log.Fatalf("Log eden failed: %s", err)
 			}
 		},
@@ -32,6 +53,12 @@ func newLogCmd() *cobra.Command {
 	logCmd.Flags().UintVar(&logTail, "tail", 0, "Show only last N lines")
 	logCmd.Flags().StringSliceVarP(&printFields, "out", "o", nil, "Fields to print. Whole message if empty.")
 	logCmd.Flags().BoolVarP(&follow, "follow", "f", false, "Monitor changes in selected directory")
+	logCmd.Flags().StringVar(&severity, "severity", "", "Show only entries of this severity or more severe (e.g. warning shows also error)")
+	logCmd.Flags().StringSliceVar(&sources, "source", nil, "Show only entries from these sources (e.g. zedagent)")
+	logCmd.Flags().StringVar(&since, "since", "", "Show only entries newer than time in RFC3339 or duration before now (e.g. 1h)")
+	logCmd.Flags().StringVar(&until, "until", "", "Show only entries older than time in RFC3339 or duration before now")
+	logCmd.Flags().StringVar(&match, "match", "", "Show only entries with content matching regexp")
+	logCmd.Flags().StringVar(&logTemplate, "template", "", "Go template to print entries with, e.g. '{{.Severity}} {{.Source}}: {{trim .Content}}'")
 
 	logCmd.Flags().Var(
 		enumflag.New(&outputFormat, "format", outputFormatIds, enumflag.EnumCaseInsensitive),
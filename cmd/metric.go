@@ -2,6 +2,7 @@ package cmd
 
 import (
 	"github.com/lf-edge/eden/pkg/controller/types"
+	"github.com/lf-edge/eden/pkg/defaults"
 	"github.com/lf-edge/eden/pkg/openevec"
 	log "github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
@@ -11,7 +12,7 @@ import (
 func newMetricCmd(configName, verbosity *string) *cobra.Command {
 	cfg := &openevec.EdenSetupArgs{}
 	var outputFormat types.OutputFormat
-	var follow bool
+	var follow, accelerators bool
 	var printFields []string
 	var metricTail uint
 
@@ -22,7 +23,7 @@ func newMetricCmd(configName, verbosity *string) *cobra.Command {
 Scans the ADAM metrics for correspondence with regular expressions requests to json fields.`,
 		PersistentPreRunE: preRunViperLoadFunction(cfg, configName, verbosity),
 		Run: func(cmd *cobra.Command, args []string) {
-			if err := openEVEC.EdenMetric(outputFormat, follow, metricTail, printFields, args); err != nil {
+			if err := openEVEC.EdenMetric(outputFormat, follow, accelerators, metricTail, printFields, args); err != nil {
 				log.Fatalf("Metric eden failed: %s", err)
 			}
 		},
@@ -31,10 +32,37 @@ Scans the ADAM metrics for correspondence with regular expressions requests to j
 	metricCmd.Flags().UintVar(&metricTail, "tail", 0, "Show only last N lines")
 	metricCmd.Flags().StringSliceVarP(&printFields, "out", "o", nil, "Fields to print. Whole message if empty.")
 	metricCmd.Flags().BoolVarP(&follow, "follow", "f", false, "Monitor changes in selected metrics")
+	metricCmd.Flags().BoolVar(&accelerators, "accelerators", false, "Show GPU and other accelerators with utilization of apps they are assigned to")
 
 	metricCmd.Flags().Var(
 		enumflag.New(&outputFormat, "format", outputFormatIds, enumflag.EnumCaseInsensitive),
 		"format",
 		"Format to print logs, supports: lines, json")
+	metricCmd.AddCommand(newMetricServeCmd())
 	return metricCmd
 }
+
+func newMetricServeCmd() *cobra.Command {
+	var listen, historyDB string
+	var historyRuns int
+
+	var metricServeCmd = &cobra.Command{
+		Use:   "serve",
+		Short: "Serve metrics in Prometheus format",
+		Long: `
+Serves the last metrics of EVE arriving at the controller together with metrics of Eden
+(results and durations of tests recorded with 'eden test --history', latencies of requests
+to the controller) in Prometheus format on /metrics.`,
+		Args: cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := openEVEC.EdenMetricServe(listen, historyDB, historyRuns); err != nil {
+				log.Fatalf("Metric serve failed: %s", err)
+			}
+		},
+	}
+
+	metricServeCmd.Flags().StringVar(&listen, "listen", defaults.DefaultMetricsListen, "address to listen on")
+	metricServeCmd.Flags().StringVar(&historyDB, "history-db", "", "path to history database (default is test-history.db inside eden home)")
+	metricServeCmd.Flags().IntVar(&historyRuns, "runs", defaults.DefaultMetricsTestRuns, "number of the last runs of tests to calculate pass rate from")
+	return metricServeCmd
+}
@@ -0,0 +1,45 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/lf-edge/eden/pkg/defaults"
+	"github.com/lf-edge/eden/pkg/openevec"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+func newNetDumpCmd(configName, verbosity *string) *cobra.Command {
+	cfg := &openevec.EdenSetupArgs{}
+	opts := openevec.NetDumpOptions{}
+
+	var netDumpCmd = &cobra.Command{
+		Use:   "netdump [eve-interface...]",
+		Short: "Capture packets on interfaces of EVE into pcap files",
+		Long: `
+Captures packets on interfaces of EVE (e.g. eth0) for the given duration and saves
+pcap files into the output directory.
+By default packets are captured inside SDN VM on the other side of EVE ports (all ports
+if no interface is set), so EVE does not need to be reachable. With --on-eve tcpdump runs
+on EVE itself over SSH (also for EVE without SDN).
+
+  eden netdump eth0 --duration 1m --filter "port 53 or port 67"`,
+		PersistentPreRunE: preRunViperLoadFunction(cfg, configName, verbosity),
+		Run: func(cmd *cobra.Command, args []string) {
+			opts.Interfaces = args
+			files, err := openEVEC.NetDump(opts)
+			for _, file := range files {
+				fmt.Println(file)
+			}
+			if err != nil {
+				log.Fatalf("Netdump failed: %s", err)
+			}
+		},
+	}
+
+	netDumpCmd.Flags().DurationVarP(&opts.Duration, "duration", "d", defaults.DefaultNetDumpDuration, "duration of capture")
+	netDumpCmd.Flags().StringVarP(&opts.Filter, "filter", "f", "", "capture only packets matching filter in pcap-filter syntax")
+	netDumpCmd.Flags().StringVarP(&opts.OutputDir, "output", "o", ".", "directory to save pcap files into")
+	netDumpCmd.Flags().BoolVar(&opts.OnEVE, "on-eve", false, "capture on EVE over SSH instead of SDN side of EVE ports")
+	return netDumpCmd
+}
@@ -25,6 +25,8 @@ func newRegistryCmd(configName, verbosity *string) *cobra.Command {
 				newStopRegistryCmd(),
 				newStatusRegistryCmd(),
 				newLoadRegistryCmd(cfg),
+				newPushRegistryCmd(),
+				newGCRegistryCmd(),
 			},
 		},
 	}
@@ -106,3 +108,40 @@ func newLoadRegistryCmd(cfg *openevec.EdenSetupArgs) *cobra.Command {
 
 	return loadRegistryCmd
 }
+
+func newPushRegistryCmd() *cobra.Command {
+	var imageName string
+
+	var pushRegistryCmd = &cobra.Command{
+		Use:   "push <image.tar>",
+		Short: "push image into registry of eserver",
+		Long: `Push image from tarball (output of docker save or OCI layout in tar) into registry embedded in eserver.
+	Use --registry=eserver option of pod deploy to run it.`,
+		Args: cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := openEVEC.RegistryPush(args[0], imageName); err != nil {
+				log.Fatalf("Push into registry failed %s", err)
+			}
+		},
+	}
+
+	pushRegistryCmd.Flags().StringVar(&imageName, "name", "", "name of image in registry (e.g. nginx:latest), the first tag from tarball if empty")
+
+	return pushRegistryCmd
+}
+
+func newGCRegistryCmd() *cobra.Command {
+	var gcRegistryCmd = &cobra.Command{
+		Use:   "gc",
+		Short: "remove unreferenced blobs from registry of eserver",
+		Long:  `Remove blobs not referenced by any manifest from registry embedded in eserver.`,
+		Args:  cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := openEVEC.RegistryGC(); err != nil {
+				log.Fatalf("Garbage collection of registry failed %s", err)
+			}
+		},
+	}
+
+	return gcRegistryCmd
+}
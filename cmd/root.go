@@ -4,10 +4,16 @@
 package cmd
 
 import (
+	"fmt"
+	"os"
 	"reflect"
+	"strconv"
+	"strings"
 
 	"github.com/lf-edge/eden/pkg/defaults"
+	"github.com/lf-edge/eden/pkg/logging"
 	"github.com/lf-edge/eden/pkg/openevec"
+	"github.com/lf-edge/eden/pkg/utils"
 	log "github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
 )
@@ -36,6 +42,7 @@ func NewEdenCommand() *cobra.Command {
 				newCleanCmd(&configName, &verbosity),
 				newConfigCmd(&configName, &verbosity),
 				newSdnCmd(&configName, &verbosity),
+				newDashboardCmd(&configName, &verbosity),
 			},
 		},
 		{
@@ -44,17 +51,24 @@ func NewEdenCommand() *cobra.Command {
 				newInfoCmd(),
 				newLogCmd(),
 				newNetStatCmd(&configName, &verbosity),
+				newNetDumpCmd(&configName, &verbosity),
+				newEdgeviewCmd(&configName, &verbosity),
+				newDumpCmd(&configName, &verbosity),
 				newMetricCmd(&configName, &verbosity),
 				newAdamCmd(&configName, &verbosity),
 				newRegistryCmd(&configName, &verbosity),
+				newLoadgenCmd(&configName, &verbosity),
+				newBenchmarkCmd(&configName, &verbosity),
 				newRedisCmd(&configName, &verbosity),
 				newEserverCmd(&configName, &verbosity),
+				newLocCmd(&configName, &verbosity),
 				newTestCmd(&configName, &verbosity),
 				newUtilsCmd(&configName, &verbosity),
 				newControllerCmd(&configName, &verbosity),
-				newNetworkCmd(),
+				newNetworkCmd(&configName, &verbosity),
 				newVolumeCmd(&configName, &verbosity),
 				newDisksCmd(),
+				newSecretCmd(),
 				newPacketCmd(&configName, &verbosity),
 				newRolCmd(&configName, &verbosity),
 			},
@@ -65,23 +79,72 @@ func NewEdenCommand() *cobra.Command {
 
 	rootCmd.PersistentFlags().StringVar(&configName, "config", defaults.DefaultContext, "Name of config")
 	rootCmd.PersistentFlags().StringVarP(&verbosity, "verbosity", "v", log.InfoLevel.String(), "Log level (debug, info, warn, error, fatal, panic")
+	rootCmd.PersistentFlags().String("log-format", "", fmt.Sprintf("Format of logs (%s), text if not set (also %s env)", strings.Join(logging.Formats, ", "), defaults.DefaultLogFormatEnv))
+	rootCmd.PersistentFlags().String("log-components", "", fmt.Sprintf("Log levels of components in format component=level,... e.g. controller=debug,sdn=warn (also %s env)", defaults.DefaultLogComponentsEnv))
+	rootCmd.PersistentFlags().Int("node", 0, fmt.Sprintf("Node of EVE to use from 1 to eve.nodes, all nodes if not set (also %s env)", defaults.DefaultNodeEnv))
 
 	return rootCmd
 }
 
 func preRunViperLoadFunction(cfg *openevec.EdenSetupArgs, configName, verbosity *string) func(cmd *cobra.Command, args []string) error {
 	return func(cmd *cobra.Command, args []string) error {
+		if err := setLogOptions(cmd); err != nil {
+			return err
+		}
 		viperCfg, err := openevec.FromViper(*configName, *verbosity)
 		if err != nil {
 			return err
 		}
 		openevec.Merge(reflect.ValueOf(viperCfg).Elem(), reflect.ValueOf(*cfg), cmd.Flags())
 		*cfg = *viperCfg
+		if err := selectNode(cmd, cfg); err != nil {
+			return err
+		}
 		openEVEC = openevec.CreateOpenEVEC(cfg)
 		return nil
 	}
 }
 
+// setLogOptions passes format of logs and verbosity of components set with flags
+// to logging setup and child processes of eden with env
+func setLogOptions(cmd *cobra.Command) error {
+	options := map[string]string{
+		"log-format":     defaults.DefaultLogFormatEnv,
+		"log-components": defaults.DefaultLogComponentsEnv,
+	}
+	for flag, env := range options {
+		if !cmd.Flags().Changed(flag) {
+			continue
+		}
+		value, err := cmd.Flags().GetString(flag)
+		if err != nil {
+			return err
+		}
+		if err := os.Setenv(env, value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// selectNode switches cfg to node of EVE set with flag or env, the flag is passed
+// to child processes of eden with env
+func selectNode(cmd *cobra.Command, cfg *openevec.EdenSetupArgs) error {
+	node, err := utils.NodeFromEnv()
+	if err != nil {
+		return err
+	}
+	if cmd.Flags().Changed("node") {
+		if node, err = cmd.Flags().GetInt("node"); err != nil {
+			return err
+		}
+		if err := os.Setenv(defaults.DefaultNodeEnv, strconv.Itoa(node)); err != nil {
+			return err
+		}
+	}
+	return openevec.SelectNode(cfg, node)
+}
+
 // Execute primary function for cobra
 func Execute() {
 	rootCmd := NewEdenCommand()
@@ -5,6 +5,7 @@ import (
 	"os"
 	"path/filepath"
 	"strconv"
+	"time"
 
 	"github.com/lf-edge/eden/pkg/defaults"
 	"github.com/lf-edge/eden/pkg/openevec"
@@ -36,6 +37,9 @@ func newSdnCmd(configName, verbosity *string) *cobra.Command {
 				newSdnMgmtIPCmd(cfg),
 				newSdnEndpointCmd(cfg),
 				newSdnFwdCmd(cfg),
+				newSdnImpairCmd(cfg),
+				newSdnProxyCmd(cfg),
+				newSdnCloudCmd(cfg),
 			},
 		},
 	}
@@ -301,6 +305,10 @@ func addSdnNetModelOpt(parentCmd *cobra.Command, cfg *openevec.EdenSetupArgs) {
 	parentCmd.Flags().StringVarP(&cfg.Sdn.NetModelFile, "sdn-network-model", "", "", "path to JSON file with network model to apply into SDN")
 }
 
+func addSdnNetworkTypeOpt(parentCmd *cobra.Command, cfg *openevec.EdenSetupArgs) {
+	parentCmd.Flags().StringVarP(&cfg.Sdn.NetworkType, "sdn-network-type", "", defaults.DefaultSdnNetworkType, "IP versions used by EVE to connect with Eden (ipv4-only, ipv6-only, dual-stack)")
+}
+
 func addSdnVmOpts(parentCmd *cobra.Command, cfg *openevec.EdenSetupArgs) {
 	parentCmd.Flags().IntVarP(&cfg.Sdn.RAM, "sdn-ram", "", defaults.DefaultSdnMemory, "memory (MB) for SDN VM")
 	parentCmd.Flags().IntVarP(&cfg.Sdn.CPU, "sdn-cpu", "", defaults.DefaultSdnCpus, "CPU count for SDN VM")
@@ -339,3 +347,204 @@ func addSdnConfigDirOpt(parentCmd *cobra.Command, cfg *openevec.EdenSetupArgs) {
 func addSdnLinuxkitOpt(parentCmd *cobra.Command, cfg *openevec.EdenSetupArgs) {
 	parentCmd.Flags().StringVarP(&cfg.Sdn.LinuxkitBin, "sdn-linuxkit-bin", "", "", "path to linuxkit binary used to build SDN VM")
 }
+
+func newSdnImpairCmd(cfg *openevec.EdenSetupArgs) *cobra.Command {
+	var sdnImpairCmd = &cobra.Command{
+		Use:   "impair",
+		Short: "Impair network connectivity going through Eden-SDN ports",
+		Long: `Impair network connectivity going through Eden-SDN ports.
+Impairments (latency, jitter, packet loss, bandwidth caps and periodic link flaps)
+are applied on top of the network model without restart of SDN and EVE.
+Ports are referenced by logical labels from the network model (eth0 and eth1 in the default one).`,
+	}
+
+	groups := CommandGroups{
+		{
+			Message: "Basic Commands",
+			Commands: []*cobra.Command{
+				newSdnImpairSetCmd(cfg),
+				newSdnImpairGetCmd(cfg),
+				newSdnImpairClearCmd(cfg),
+			},
+		},
+	}
+
+	groups.AddTo(sdnImpairCmd)
+
+	return sdnImpairCmd
+}
+
+func newSdnImpairSetCmd(cfg *openevec.EdenSetupArgs) *cobra.Command {
+	var imp openevec.SdnImpairment
+
+	var sdnImpairSetCmd = &cobra.Command{
+		Use:   "set <port>",
+		Short: "Apply impairment to the port replacing its previous impairment",
+		Long: `Apply impairment to the port replacing its previous impairment.
+For example, to emulate slow and lossy network with link going down for 10 seconds every minute:
+	eden sdn impair set eth0 --delay 200ms --jitter 50ms --loss 10 --rate 64 --flap-period 1m --flap-down 10s`,
+		Args: cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := openEVEC.SdnImpairSet(args[0], imp); err != nil {
+				log.Fatal(err)
+			}
+		},
+	}
+
+	sdnImpairSetCmd.Flags().DurationVar(&imp.Delay, "delay", 0, "delay of every packet")
+	sdnImpairSetCmd.Flags().DurationVar(&imp.Jitter, "jitter", 0, "jitter added to delay")
+	sdnImpairSetCmd.Flags().Uint8Var(&imp.Loss, "loss", 0, "probability of packet loss in percent")
+	sdnImpairSetCmd.Flags().Uint8Var(&imp.Corrupt, "corrupt", 0, "probability of packet corruption in percent")
+	sdnImpairSetCmd.Flags().Uint8Var(&imp.Duplicate, "duplicate", 0, "probability of packet duplication in percent")
+	sdnImpairSetCmd.Flags().Uint8Var(&imp.Reorder, "reorder", 0, "probability of packet reordering in percent")
+	sdnImpairSetCmd.Flags().Uint32Var(&imp.Rate, "rate", 0, "bandwidth cap in kilobytes per second")
+	sdnImpairSetCmd.Flags().Uint32Var(&imp.Queue, "queue", 0, "kilobytes queued before packets are dropped with --rate (one second of traffic if not set)")
+	sdnImpairSetCmd.Flags().Uint32Var(&imp.Burst, "burst", 0, "kilobytes sent in burst over --rate (tenth of rate if not set)")
+	sdnImpairSetCmd.Flags().DurationVar(&imp.FlapPeriod, "flap-period", 0, "put link down periodically with the period")
+	sdnImpairSetCmd.Flags().DurationVar(&imp.FlapDown, "flap-down", 0, "time link stays down within every --flap-period")
+	addSdnPortOpts(sdnImpairSetCmd, cfg)
+
+	return sdnImpairSetCmd
+}
+
+func newSdnImpairGetCmd(cfg *openevec.EdenSetupArgs) *cobra.Command {
+	var sdnImpairGetCmd = &cobra.Command{
+		Use:   "get",
+		Short: "Get currently applied impairments (in JSON)",
+		Run: func(cmd *cobra.Command, args []string) {
+			impairments, err := openEVEC.SdnImpairGet()
+			if err != nil {
+				log.Fatal(err)
+			}
+			fmt.Println(impairments)
+		},
+	}
+
+	addSdnPortOpts(sdnImpairGetCmd, cfg)
+
+	return sdnImpairGetCmd
+}
+
+func newSdnImpairClearCmd(cfg *openevec.EdenSetupArgs) *cobra.Command {
+	var sdnImpairClearCmd = &cobra.Command{
+		Use:   "clear [port...]",
+		Short: "Remove impairments of the ports, all impairments if no port is given",
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := openEVEC.SdnImpairClear(args); err != nil {
+				log.Fatal(err)
+			}
+		},
+	}
+
+	addSdnPortOpts(sdnImpairClearCmd, cfg)
+
+	return sdnImpairClearCmd
+}
+
+func newSdnCloudCmd(cfg *openevec.EdenSetupArgs) *cobra.Command {
+	var sdnCloudCmd = &cobra.Command{
+		Use:   "cloud",
+		Short: "Cut and restore connectivity between EVE and the controller",
+		Long: `Cut and restore connectivity between EVE and the controller.
+The controller port of the host is blocked by firewall of Eden-SDN, so EVE and its apps
+still reach other services of the host (e.g. LOC started by 'eden loc start') and the Internet.
+Connections established before the cut are not interrupted.`,
+	}
+
+	groups := CommandGroups{
+		{
+			Message: "Basic Commands",
+			Commands: []*cobra.Command{
+				newSdnCloudCutCmd(cfg),
+				newSdnCloudRestoreCmd(cfg),
+			},
+		},
+	}
+
+	groups.AddTo(sdnCloudCmd)
+
+	return sdnCloudCmd
+}
+
+func newSdnCloudCutCmd(cfg *openevec.EdenSetupArgs) *cobra.Command {
+	var sdnCloudCutCmd = &cobra.Command{
+		Use:   "cut",
+		Short: "Make the controller unreachable from EVE",
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := openEVEC.SdnCloudCut(); err != nil {
+				log.Fatal(err)
+			}
+		},
+	}
+
+	addSdnPortOpts(sdnCloudCutCmd, cfg)
+
+	return sdnCloudCutCmd
+}
+
+func newSdnCloudRestoreCmd(cfg *openevec.EdenSetupArgs) *cobra.Command {
+	var sdnCloudRestoreCmd = &cobra.Command{
+		Use:   "restore",
+		Short: "Make the controller reachable from EVE again",
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := openEVEC.SdnCloudRestore(); err != nil {
+				log.Fatal(err)
+			}
+		},
+	}
+
+	addSdnPortOpts(sdnCloudRestoreCmd, cfg)
+
+	return sdnCloudRestoreCmd
+}
+
+func newSdnProxyCmd(cfg *openevec.EdenSetupArgs) *cobra.Command {
+	var sdnProxyCmd = &cobra.Command{
+		Use:   "proxy",
+		Short: "Manage proxy between EVE and the controller in Eden-SDN",
+		Long: `Manage proxy between EVE and the controller in Eden-SDN.
+The proxy is enabled before 'eden setup' with config item sdn.proxy set to one of the scenarios:
+	explicit       EVE is configured to use HTTP/HTTPS proxy, the controller is not reachable otherwise
+	explicit-auth  as explicit, but the proxy requires user authentication
+	transparent    HTTP/HTTPS traffic of EVE is intercepted without EVE being aware of it
+In all scenarios the proxy terminates TLS (MITM) with certificates signed by its own CA, which EVE is told to trust.`,
+	}
+
+	groups := CommandGroups{
+		{
+			Message: "Basic Commands",
+			Commands: []*cobra.Command{
+				newSdnProxyRotateCACmd(cfg),
+			},
+		},
+	}
+
+	groups.AddTo(sdnProxyCmd)
+
+	return sdnProxyCmd
+}
+
+func newSdnProxyRotateCACmd(cfg *openevec.EdenSetupArgs) *cobra.Command {
+	var untrusted bool
+	var timeout time.Duration
+
+	var sdnProxyRotateCACmd = &cobra.Command{
+		Use:   "rotate-ca",
+		Short: "Replace CA of the proxy with a new one",
+		Long: `Replace CA of the proxy with a new one.
+EVE is told to trust both the previous and the new CA and once it receives the config,
+the proxy switches to the new CA. Use --untrusted to switch the proxy without telling EVE,
+which is expected to break connectivity of EVE with the controller.`,
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := openEVEC.SdnProxyRotateCA(untrusted, timeout); err != nil {
+				log.Fatal(err)
+			}
+		},
+	}
+
+	sdnProxyRotateCACmd.Flags().BoolVar(&untrusted, "untrusted", false, "do not tell EVE to trust the new CA")
+	sdnProxyRotateCACmd.Flags().DurationVar(&timeout, "timeout", 5*time.Minute, "time to wait for EVE to receive config with the new CA")
+	addSdnPortOpts(sdnProxyRotateCACmd, cfg)
+
+	return sdnProxyRotateCACmd
+}
@@ -1,19 +1,51 @@
 package cmd
 
 import (
+	"fmt"
 	"strings"
 
+	log "github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 )
 
-var rootCmd = &cobra.Command{Use: "eserver"}
+var (
+	logLevel  string
+	logFormat string
+)
+
+var rootCmd = &cobra.Command{
+	Use: "eserver",
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		return setupLogging(logLevel, logFormat)
+	},
+}
+
+// setupLogging sets level and format (text or json) of logs
+func setupLogging(level, format string) error {
+	lvl, err := log.ParseLevel(level)
+	if err != nil {
+		return err
+	}
+	switch format {
+	case "text":
+		log.SetFormatter(&log.TextFormatter{FullTimestamp: true})
+	case "json":
+		log.SetFormatter(&log.JSONFormatter{})
+	default:
+		return fmt.Errorf("unsupported format of logs %q (supported: text, json)", format)
+	}
+	log.SetLevel(lvl)
+	return nil
+}
 
 func init() {
 	viper.SetEnvPrefix("eserver")
 	viper.SetEnvKeyReplacer(strings.NewReplacer("-", "_"))
 	viper.AutomaticEnv()
 
+	rootCmd.PersistentFlags().StringVar(&logLevel, "log-level", "info", "level of logs (debug, info, warn, error)")
+	rootCmd.PersistentFlags().StringVar(&logFormat, "log-format", "text", "format of logs: text or json")
 	rootCmd.AddCommand(serverCmd)
 	serverInit()
 }
@@ -2,6 +2,7 @@ package cmd
 
 import (
 	"github.com/lf-edge/eden/eserver/pkg/manager"
+	"github.com/lf-edge/eden/eserver/pkg/registry"
 	"github.com/lf-edge/eden/eserver/pkg/server"
 	"github.com/spf13/cobra"
 )
@@ -18,6 +19,14 @@ var (
 	serverSFTPUser     string
 	serverSFTPPassword string
 	serverSFTPReadOnly bool
+	serverS3Region     string
+	serverS3AccessKey  string
+	serverS3SecretKey  string
+	registryDir        string
+	tlsCert            string
+	tlsKey             string
+	tlsClientCA        string
+	tlsClientAuth      string
 )
 
 var serverCmd = &cobra.Command{
@@ -26,12 +35,21 @@ var serverCmd = &cobra.Command{
 	Long:  `Start a server.`,
 	Run: func(cmd *cobra.Command, args []string) {
 		server := &server.EServer{
-			Port:     port,
-			Address:  hostIP,
-			User:     serverSFTPUser,
-			Password: serverSFTPPassword,
-			ReadOnly: serverSFTPReadOnly,
-			Manager:  &manager.EServerManager{Dir: serverDir},
+			Port:        port,
+			Address:     hostIP,
+			User:        serverSFTPUser,
+			Password:    serverSFTPPassword,
+			ReadOnly:    serverSFTPReadOnly,
+			S3Region:    serverS3Region,
+			S3AccessKey: serverS3AccessKey,
+			S3SecretKey: serverS3SecretKey,
+			Manager:     &manager.EServerManager{Dir: serverDir},
+			Registry:    &registry.Store{Dir: registryDir},
+
+			TLSCert:       tlsCert,
+			TLSKey:        tlsKey,
+			TLSClientCA:   tlsClientCA,
+			TLSClientAuth: tlsClientAuth,
 		}
 		server.Start()
 	},
@@ -44,4 +62,12 @@ func serverInit() {
 	serverCmd.Flags().StringVar(&serverSFTPUser, "user", "user", "user for sftp")
 	serverCmd.Flags().StringVar(&serverSFTPPassword, "password", "password", "password for sftp")
 	serverCmd.Flags().BoolVar(&serverSFTPReadOnly, "readonly", true, "Read only access via sftp")
+	serverCmd.Flags().StringVar(&serverS3Region, "s3-region", "us-east-1", "region for S3 API")
+	serverCmd.Flags().StringVar(&serverS3AccessKey, "s3-access-key", "eden", "access key for S3 API")
+	serverCmd.Flags().StringVar(&serverS3SecretKey, "s3-secret-key", "edensecret", "secret key for S3 API")
+	serverCmd.Flags().StringVar(&registryDir, "registry-dir", "./run/registry", "location of registry content")
+	serverCmd.Flags().StringVar(&tlsCert, "tls-cert", "", "certificate of server to serve http over TLS, reloaded after change")
+	serverCmd.Flags().StringVar(&tlsKey, "tls-key", "", "key of server to serve http over TLS")
+	serverCmd.Flags().StringVar(&tlsClientCA, "tls-client-ca", "", "CA to verify certificates of clients")
+	serverCmd.Flags().StringVar(&tlsClientAuth, "tls-client-auth", "none", "verification of client certificates: none, optional (if provided) or require")
 }
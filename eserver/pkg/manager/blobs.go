@@ -0,0 +1,214 @@
+package manager
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"syscall"
+
+	"github.com/lf-edge/eden/eserver/api"
+	log "github.com/sirupsen/logrus"
+)
+
+// BlobsDir is directory inside Dir with content of files keyed by sha256.
+// Files with the same content are hard links to the same blob.
+const BlobsDir = ".blobs"
+
+// ErrBlobNotFound returned if there is no content with requested sha256
+var ErrBlobNotFound = errors.New("no content with provided sha256")
+
+var sha256Re = regexp.MustCompile(`^[0-9a-f]{64}$`)
+
+// isBlobPath checks if name points inside BlobsDir
+func isBlobPath(name string) bool {
+	name = filepath.ToSlash(filepath.Clean(name))
+	return name == BlobsDir || strings.HasPrefix(name, BlobsDir+"/")
+}
+
+func (mgr *EServerManager) blobPath(sha string) string {
+	return filepath.Join(mgr.Dir, BlobsDir, "sha256", sha)
+}
+
+// linkCount returns number of hard links to file or 0 if unknown
+func linkCount(info os.FileInfo) uint64 {
+	if stat, ok := info.Sys().(*syscall.Stat_t); ok {
+		return uint64(stat.Nlink)
+	}
+	return 0
+}
+
+// dedup replaces file with hard link to blob of the same content or stores file as the new blob
+func (mgr *EServerManager) dedup(filePath, sha string) error {
+	mgr.blobMu.Lock()
+	defer mgr.blobMu.Unlock()
+	blob := mgr.blobPath(sha)
+	if err := os.MkdirAll(filepath.Dir(blob), 0755); err != nil {
+		return err
+	}
+	blobInfo, err := os.Stat(blob)
+	if os.IsNotExist(err) {
+		return os.Link(filePath, blob)
+	}
+	if err != nil {
+		return err
+	}
+	fileInfo, err := os.Stat(filePath)
+	if err != nil {
+		return err
+	}
+	if os.SameFile(blobInfo, fileInfo) {
+		return nil
+	}
+	filePathTemp := filePath + ".dedup"
+	if err = os.Link(blob, filePathTemp); err != nil {
+		return err
+	}
+	if err = os.Rename(filePathTemp, filePath); err != nil {
+		_ = os.Remove(filePathTemp)
+		return err
+	}
+	log.WithFields(log.Fields{"file": filePath, "blob": sha}).Info("deduplicated with blob")
+	return nil
+}
+
+// publishFile saves sha256 of fully received temporary file, moves it into filePath
+// and shares its content with other files with the same sha256
+func (mgr *EServerManager) publishFile(filePathTemp, filePath, sha string) error {
+	if err := os.WriteFile(fmt.Sprintf("%s.sha256", filePath), []byte(sha), 0666); err != nil {
+		return err
+	}
+	if err := os.Rename(filePathTemp, filePath); err != nil {
+		return err
+	}
+	if err := mgr.dedup(filePath, sha); err != nil {
+		// file is still served, we only lose space
+		log.WithError(err).WithField("file", filePath).Warn("cannot deduplicate")
+	}
+	return nil
+}
+
+// removeFile removes file and its blob if no other files share it
+func (mgr *EServerManager) removeFile(filePath string) error {
+	sha, _ := os.ReadFile(fmt.Sprintf("%s.sha256", filePath))
+	if err := os.Remove(filePath); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	if !sha256Re.Match(sha) {
+		return nil
+	}
+	mgr.blobMu.Lock()
+	defer mgr.blobMu.Unlock()
+	blob := mgr.blobPath(string(sha))
+	if info, err := os.Stat(blob); err == nil && linkCount(info) == 1 {
+		return os.Remove(blob)
+	}
+	return nil
+}
+
+// LinkFile creates file with provided name from stored content with provided sha256
+// without transfer of data. Returns ErrBlobNotFound if there is no such content.
+func (mgr *EServerManager) LinkFile(name, sha string) (*api.FileInfo, error) {
+	if !sha256Re.MatchString(sha) {
+		return nil, fmt.Errorf("wrong sha256: %q", sha)
+	}
+	if fileInfo := mgr.GetFileInfo(name); fileInfo.ISReady && fileInfo.Sha256 == sha {
+		return fileInfo, nil
+	}
+	filePath := filepath.Join(mgr.Dir, name)
+	if err := os.MkdirAll(filepath.Dir(filePath), os.ModeDir); err != nil {
+		return nil, err
+	}
+	filePathTemp := filePath + ".link"
+	mgr.blobMu.Lock()
+	err := os.Link(mgr.blobPath(sha), filePathTemp)
+	mgr.blobMu.Unlock()
+	if os.IsNotExist(err) {
+		return nil, ErrBlobNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err = mgr.removeFile(filePath); err != nil {
+		_ = os.Remove(filePathTemp)
+		return nil, err
+	}
+	if err = mgr.publishFile(filePathTemp, filePath, sha); err != nil {
+		return nil, err
+	}
+	log.WithFields(log.Fields{"file": filePath, "blob": sha}).Info("linked to blob")
+	return mgr.GetFileInfo(name), nil
+}
+
+// ListDigests returns sha256 of ready files by their names
+func (mgr *EServerManager) ListDigests() (map[string]string, error) {
+	result := map[string]string{}
+	err := filepath.Walk(mgr.Dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		name, err := filepath.Rel(mgr.Dir, path)
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			if isBlobPath(name) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !strings.HasSuffix(name, ".sha256") {
+			return nil
+		}
+		name = strings.TrimSuffix(name, ".sha256")
+		if _, err := os.Stat(filepath.Join(mgr.Dir, name)); err != nil {
+			return nil
+		}
+		sha, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		result[filepath.ToSlash(name)] = string(sha)
+		return nil
+	})
+	return result, err
+}
+
+// initBlobs stores content of files received by previous versions of eserver as blobs
+// and removes blobs not used by files anymore
+func (mgr *EServerManager) initBlobs() error {
+	digests, err := mgr.ListDigests()
+	if err != nil {
+		return err
+	}
+	for name, sha := range digests {
+		if !sha256Re.MatchString(sha) {
+			continue
+		}
+		if err := mgr.dedup(filepath.Join(mgr.Dir, name), sha); err != nil {
+			log.WithError(err).WithField("file", name).Warn("cannot deduplicate")
+		}
+	}
+	blobs, err := os.ReadDir(filepath.Join(mgr.Dir, BlobsDir, "sha256"))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	for _, el := range blobs {
+		info, err := el.Info()
+		if err != nil {
+			return err
+		}
+		if linkCount(info) == 1 {
+			log.WithField("blob", el.Name()).Info("removing unused blob")
+			if err := os.Remove(filepath.Join(mgr.Dir, BlobsDir, "sha256", el.Name())); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
@@ -4,21 +4,29 @@ import (
 	"crypto/sha256"
 	"crypto/tls"
 	"encoding/hex"
+	"errors"
 	"fmt"
 	"io"
-	"log"
 	"mime/multipart"
 	"net/http"
 	"os"
 	"path"
 	"path/filepath"
+	"sync"
+	"time"
 
 	"github.com/lf-edge/eden/eserver/api"
+	log "github.com/sirupsen/logrus"
 )
 
 // EServerManager for process files
 type EServerManager struct {
 	Dir string
+
+	mu        sync.Mutex
+	downloads map[string]bool
+	uploadMu  sync.Mutex
+	blobMu    sync.Mutex
 }
 
 // Init directories for EServerManager
@@ -28,6 +36,9 @@ func (mgr *EServerManager) Init() {
 			log.Fatal(err)
 		}
 	}
+	if err := mgr.initBlobs(); err != nil {
+		log.WithError(err).Error("cannot init blobs")
+	}
 }
 
 // ListFileNames list downloaded files
@@ -37,6 +48,9 @@ func (mgr *EServerManager) ListFileNames() (result []string) {
 		log.Fatal(err)
 	}
 	for _, f := range files {
+		if f.Name() == BlobsDir {
+			continue
+		}
 		result = append(result, f.Name())
 	}
 	return
@@ -51,63 +65,224 @@ func getFileSize(filePath string) int64 {
 	return fi.Size()
 }
 
-// downloadFile downloads a url to a local file.
-func downloadFile(filePath, url string) error {
-	out, err := os.Create(filePath + ".tmp")
+const (
+	// downloadAttempts is the number of attempts to download file before giving up
+	downloadAttempts = 5
+	// downloadRetryDelay is the delay before the next attempt, multiplied by the attempt number
+	downloadRetryDelay = 5 * time.Second
+)
+
+// ErrUploadOffset returned if chunk of upload does not start at the end of uploaded data
+var ErrUploadOffset = errors.New("chunk offset does not match uploaded size")
+
+// fileHash calculates sha256 of file
+func fileHash(filePath string) (string, error) {
+	f, err := os.Open(filePath)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	hash := sha256.New()
+	if _, err := io.Copy(hash, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// completeFile calculates sha256 of fully received temporary file and moves it into filePath
+func (mgr *EServerManager) completeFile(filePathTemp, filePath string) error {
+	sha, err := fileHash(filePathTemp)
 	if err != nil {
 		return err
 	}
-	resp, err := http.Get(url)
+	return mgr.publishFile(filePathTemp, filePath, sha)
+}
+
+// downloadPart continues download of url into filePathTemp from its current size using Range request
+func downloadPart(filePathTemp, url string) error {
+	out, err := os.OpenFile(filePathTemp, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0666)
 	if err != nil {
 		return err
 	}
-	defer resp.Body.Close()
-	hash := sha256.New()
-	_, err = io.Copy(hash, io.TeeReader(resp.Body, out))
+	defer out.Close()
+	info, err := out.Stat()
 	if err != nil {
 		return err
 	}
-	if err = os.WriteFile(fmt.Sprintf("%s.sha256", filePath), []byte(hex.EncodeToString(hash.Sum(nil))), 0666); err != nil {
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
 		return err
 	}
-	if err = os.Rename(filePath+".tmp", filePath); err != nil {
+	if info.Size() > 0 {
+		req.Header.Set("Range", fmt.Sprintf("bytes=%d-", info.Size()))
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
 		return err
 	}
-	return out.Close()
+	defer resp.Body.Close()
+	switch resp.StatusCode {
+	case http.StatusPartialContent:
+		log.WithFields(log.Fields{"url": url, "offset": info.Size()}).Info("resuming download")
+	case http.StatusOK:
+		// server ignores Range, start from the beginning
+		if err := out.Truncate(0); err != nil {
+			return err
+		}
+	case http.StatusRequestedRangeNotSatisfiable:
+		if info.Size() > 0 {
+			// nothing left to download
+			return nil
+		}
+		return fmt.Errorf("bad status: %s", resp.Status)
+	default:
+		return fmt.Errorf("bad status: %s", resp.Status)
+	}
+	_, err = io.Copy(out, resp.Body)
+	return err
 }
 
-// AddFile starts file download and return name of file for fileinfo requests
+// downloadFile downloads a url to a local file.
+// Partially downloaded file is kept and download continues from the received size.
+func (mgr *EServerManager) downloadFile(filePath, url string) error {
+	filePathTemp := filePath + ".tmp"
+	var err error
+	for attempt := 1; attempt <= downloadAttempts; attempt++ {
+		if err = downloadPart(filePathTemp, url); err == nil {
+			return mgr.completeFile(filePathTemp, filePath)
+		}
+		log.WithError(err).WithFields(log.Fields{
+			"url":      url,
+			"attempt":  attempt,
+			"attempts": downloadAttempts,
+		}).Warn("download failed")
+		if attempt < downloadAttempts {
+			time.Sleep(time.Duration(attempt) * downloadRetryDelay)
+		}
+	}
+	return err
+}
+
+// AddFile starts file download and return name of file for fileinfo requests.
+// If previous download of url failed, it continues from the received size.
 func (mgr *EServerManager) AddFile(url string) (string, error) {
-	log.Println("Starting download of image from ", url)
+	log.WithField("url", url).Info("Starting download of image")
 	filePath := filepath.Join(mgr.Dir, path.Base(url))
 	if _, err := os.Stat(filePath); !os.IsNotExist(err) {
-		log.Println("file already exists ", filePath)
-	} else {
-		go func() {
-			http.DefaultTransport.(*http.Transport).TLSClientConfig = &tls.Config{InsecureSkipVerify: true}
-			if err := downloadFile(filePath, url); err != nil {
-				log.Fatal(err)
-			}
-			log.Println("Download done for ", url)
-		}()
+		log.WithField("file", filePath).Info("file already exists")
+		return path.Base(url), nil
+	}
+	mgr.mu.Lock()
+	defer mgr.mu.Unlock()
+	if mgr.downloads == nil {
+		mgr.downloads = map[string]bool{}
+	}
+	if mgr.downloads[filePath] {
+		log.WithField("file", filePath).Info("download already in progress")
+		return path.Base(url), nil
 	}
+	// create temporary file in advance to report progress instead of error
+	out, err := os.OpenFile(filePath+".tmp", os.O_CREATE|os.O_WRONLY, 0666)
+	if err != nil {
+		return "", err
+	}
+	if err = out.Close(); err != nil {
+		return "", err
+	}
+	_ = os.Remove(filePath + ".error")
+	mgr.downloads[filePath] = true
+	go func() {
+		http.DefaultTransport.(*http.Transport).TLSClientConfig = &tls.Config{InsecureSkipVerify: true}
+		if err := mgr.downloadFile(filePath, url); err != nil {
+			log.WithError(err).WithField("url", url).Error("Download failed")
+			if err := os.WriteFile(filePath+".error", []byte(err.Error()), 0666); err != nil {
+				log.WithError(err).WithField("file", filePath).Error("cannot save download error")
+			}
+		} else {
+			log.WithField("url", url).Info("Download done")
+		}
+		mgr.mu.Lock()
+		delete(mgr.downloads, filePath)
+		mgr.mu.Unlock()
+	}()
 	return path.Base(url), nil
 }
 
+// GetUploadSize returns size of data received for chunked upload of file with provided name
+func (mgr *EServerManager) GetUploadSize(name string) int64 {
+	info, err := os.Stat(filepath.Join(mgr.Dir, name) + ".part")
+	if err != nil {
+		return 0
+	}
+	return info.Size()
+}
+
+// AddFileChunk appends chunk started at offset to chunked upload of file with provided name.
+// Chunk with zero offset drops previously received data. Upload completes when total bytes received. Returns ErrUploadOffset and size of received data
+// inside FileInfo if offset does not match it, so client can continue from the right position.
+func (mgr *EServerManager) AddFileChunk(name string, offset, total int64, chunk io.Reader) (*api.FileInfo, error) {
+	filePath := filepath.Join(mgr.Dir, name)
+	filePathPart := filePath + ".part"
+	if err := os.MkdirAll(filepath.Dir(filePath), os.ModeDir); err != nil {
+		return nil, err
+	}
+	mgr.uploadMu.Lock()
+	defer mgr.uploadMu.Unlock()
+	out, err := os.OpenFile(filePathPart, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0666)
+	if err != nil {
+		return nil, err
+	}
+	defer out.Close()
+	info, err := out.Stat()
+	if err != nil {
+		return nil, err
+	}
+	size := info.Size()
+	if offset == 0 && size > 0 {
+		// chunk at zero offset starts the new upload
+		if err = out.Truncate(0); err != nil {
+			return nil, err
+		}
+		size = 0
+	}
+	if size != offset {
+		return &api.FileInfo{Size: size}, ErrUploadOffset
+	}
+	written, err := io.Copy(out, io.LimitReader(chunk, total-offset))
+	size += written
+	if err != nil {
+		return &api.FileInfo{Size: size}, err
+	}
+	if size < total {
+		return &api.FileInfo{Size: size}, nil
+	}
+	if err = out.Close(); err != nil {
+		return nil, err
+	}
+	// remove file if exists, we have new file in upload
+	if err = mgr.removeFile(filePath); err != nil {
+		return nil, err
+	}
+	if err = mgr.completeFile(filePathPart, filePath); err != nil {
+		return nil, err
+	}
+	return mgr.GetFileInfo(name), nil
+}
+
 // AddFileFromMultipart adds file from multipart.Part and returns information
 func (mgr *EServerManager) AddFileFromMultipart(part *multipart.Part) *api.FileInfo {
 	result := &api.FileInfo{ISReady: false}
-	log.Println("Starting copy image from ", part.FileName())
+	log.WithField("file", part.FileName()).Info("Starting copy of image")
 	filePath := filepath.Join(mgr.Dir, part.FileName())
 	if err := os.MkdirAll(filepath.Dir(filePath), os.ModeDir); err != nil {
-		log.Println("cannot create dir for ", filePath)
+		log.WithError(err).WithField("file", filePath).Error("cannot create dir")
 		result.Error = err.Error()
 		return result
 	}
 	if _, err := os.Stat(filePath); !os.IsNotExist(err) {
-		log.Println("file already exists ", filePath)
+		log.WithField("file", filePath).Info("file already exists, replacing")
 		// remove file if exists, we have new file in request
-		if err := os.Remove(filePath); err != nil {
+		if err := mgr.removeFile(filePath); err != nil {
 			result.Error = err.Error()
 			return result
 		}
@@ -125,11 +300,7 @@ func (mgr *EServerManager) AddFileFromMultipart(part *multipart.Part) *api.FileI
 		result.Error = err.Error()
 		return result
 	}
-	if err = os.WriteFile(fmt.Sprintf("%s.sha256", filePath), []byte(hex.EncodeToString(hash.Sum(nil))), 0666); err != nil {
-		result.Error = err.Error()
-		return result
-	}
-	if err = os.Rename(filePathTemp, filePath); err != nil {
+	if err = mgr.publishFile(filePathTemp, filePath, hex.EncodeToString(hash.Sum(nil))); err != nil {
 		result.Error = err.Error()
 		return result
 	}
@@ -142,6 +313,10 @@ func (mgr *EServerManager) GetFileInfo(name string) *api.FileInfo {
 	filePath := filepath.Join(mgr.Dir, name)
 	filePathTMP := filePath + ".tmp"
 	if _, err := os.Stat(filePath); os.IsNotExist(err) {
+		if downloadErr, err := os.ReadFile(filePath + ".error"); err == nil {
+			result.Error = string(downloadErr)
+			return result
+		}
 		if _, err := os.Stat(filePathTMP); os.IsNotExist(err) {
 			result.Error = err.Error()
 			return result
@@ -168,6 +343,9 @@ func (mgr *EServerManager) GetFileInfo(name string) *api.FileInfo {
 
 // GetFilePath returns path to file for serve
 func (mgr *EServerManager) GetFilePath(name string) (string, error) {
+	if isBlobPath(name) {
+		return "", os.ErrNotExist
+	}
 	filePath := filepath.Join(mgr.Dir, name)
 	if _, err := os.Stat(filePath); os.IsNotExist(err) {
 		return "", err
@@ -0,0 +1,431 @@
+package registry
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// uploadTTL is time after which not finished uploads and not referenced blobs are removed by garbage collection
+const uploadTTL = time.Hour
+
+var (
+	// ErrBlobUnknown returned if blob is not in store
+	ErrBlobUnknown = errors.New("blob unknown to registry")
+	// ErrManifestBlobUnknown returned if blob referenced by manifest is not in store
+	ErrManifestBlobUnknown = errors.New("blob unknown to registry")
+	// ErrManifestUnknown returned if manifest is not in repository
+	ErrManifestUnknown = errors.New("manifest unknown")
+	// ErrUploadUnknown returned if upload is not in progress
+	ErrUploadUnknown = errors.New("blob upload unknown to registry")
+	// ErrDigestInvalid returned if content does not match digest
+	ErrDigestInvalid = errors.New("provided digest did not match uploaded content")
+	// ErrNameInvalid returned for wrong name of repository
+	ErrNameInvalid = errors.New("invalid repository name")
+	// ErrTagInvalid returned for wrong tag
+	ErrTagInvalid = errors.New("invalid tag")
+)
+
+var (
+	nameRe   = regexp.MustCompile(`^[a-z0-9]+(?:(?:[._]|__|-+)[a-z0-9]+)*(?:/[a-z0-9]+(?:(?:[._]|__|-+)[a-z0-9]+)*)*$`)
+	tagRe    = regexp.MustCompile(`^[A-Za-z0-9_][A-Za-z0-9_.-]{0,127}$`)
+	digestRe = regexp.MustCompile(`^sha256:([a-f0-9]{64})$`)
+)
+
+// Store keeps blobs, manifests and tags of registry in directory
+//
+//	blobs/sha256/<hex> content of blobs and manifests
+//	uploads/<id> blobs in progress of upload
+//	repositories/<name>/manifests/<hex> media type of manifest pushed into repository
+//	repositories/<name>/tags/<tag> digest of manifest
+type Store struct {
+	Dir string
+}
+
+// Manifest describes manifest stored in repository
+type Manifest struct {
+	Digest    string
+	MediaType string
+	Size      int64
+}
+
+// GCResult contains result of garbage collection
+type GCResult struct {
+	RemovedBlobs   int   `json:"removed_blobs"`
+	FreedBytes     int64 `json:"freed_bytes"`
+	RemovedUploads int   `json:"removed_uploads"`
+}
+
+// descriptor is subset of OCI descriptor used to find references
+type descriptor struct {
+	Digest string `json:"digest"`
+}
+
+// manifestReferences is subset of OCI image manifest and index
+type manifestReferences struct {
+	MediaType string        `json:"mediaType"`
+	Config    *descriptor   `json:"config"`
+	Layers    []*descriptor `json:"layers"`
+	Manifests []*descriptor `json:"manifests"`
+}
+
+// ParseDigest checks digest and returns its hex part
+func ParseDigest(digest string) (string, error) {
+	match := digestRe.FindStringSubmatch(digest)
+	if match == nil {
+		return "", fmt.Errorf("%w: unsupported digest %s", ErrDigestInvalid, digest)
+	}
+	return match[1], nil
+}
+
+// IsDigest returns true if reference is digest, not tag
+func IsDigest(reference string) bool {
+	return strings.Contains(reference, ":")
+}
+
+func checkName(name string) error {
+	if !nameRe.MatchString(name) {
+		return fmt.Errorf("%w: %s", ErrNameInvalid, name)
+	}
+	return nil
+}
+
+// Init creates directories of store
+func (s *Store) Init() error {
+	for _, dir := range []string{"blobs/sha256", "uploads", "repositories"} {
+		if err := os.MkdirAll(filepath.Join(s.Dir, dir), 0755); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *Store) blobPath(hexDigest string) string {
+	return filepath.Join(s.Dir, "blobs", "sha256", hexDigest)
+}
+
+func (s *Store) uploadPath(id string) string {
+	return filepath.Join(s.Dir, "uploads", filepath.Base(id))
+}
+
+func (s *Store) repositoryPath(name string) string {
+	return filepath.Join(s.Dir, "repositories", filepath.FromSlash(name))
+}
+
+// BlobPath returns path to blob with digest
+func (s *Store) BlobPath(digest string) (string, error) {
+	hexDigest, err := ParseDigest(digest)
+	if err != nil {
+		return "", err
+	}
+	blobPath := s.blobPath(hexDigest)
+	if _, err := os.Stat(blobPath); err != nil {
+		return "", ErrBlobUnknown
+	}
+	return blobPath, nil
+}
+
+// StartUpload creates new upload and returns its id
+func (s *Store) StartUpload() (string, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	id := hex.EncodeToString(buf)
+	f, err := os.Create(s.uploadPath(id))
+	if err != nil {
+		return "", err
+	}
+	return id, f.Close()
+}
+
+// AppendUpload appends data to upload and returns size of uploaded data
+func (s *Store) AppendUpload(id string, r io.Reader) (int64, error) {
+	f, err := os.OpenFile(s.uploadPath(id), os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return 0, ErrUploadUnknown
+	}
+	defer f.Close()
+	if _, err := io.Copy(f, r); err != nil {
+		return 0, err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		return 0, err
+	}
+	return info.Size(), nil
+}
+
+// UploadSize returns size of data in upload
+func (s *Store) UploadSize(id string) (int64, error) {
+	info, err := os.Stat(s.uploadPath(id))
+	if err != nil {
+		return 0, ErrUploadUnknown
+	}
+	return info.Size(), nil
+}
+
+// CancelUpload removes upload
+func (s *Store) CancelUpload(id string) error {
+	if err := os.Remove(s.uploadPath(id)); err != nil {
+		return ErrUploadUnknown
+	}
+	return nil
+}
+
+// CommitUpload verifies digest of uploaded data and moves it into blobs
+func (s *Store) CommitUpload(id, digest string) error {
+	hexDigest, err := ParseDigest(digest)
+	if err != nil {
+		return err
+	}
+	uploadPath := s.uploadPath(id)
+	f, err := os.Open(uploadPath)
+	if err != nil {
+		return ErrUploadUnknown
+	}
+	hash := sha256.New()
+	_, err = io.Copy(hash, f)
+	_ = f.Close()
+	if err != nil {
+		return err
+	}
+	if hex.EncodeToString(hash.Sum(nil)) != hexDigest {
+		_ = os.Remove(uploadPath)
+		return ErrDigestInvalid
+	}
+	return os.Rename(uploadPath, s.blobPath(hexDigest))
+}
+
+// PutManifest verifies manifest and stores it in repository under reference (tag or digest)
+func (s *Store) PutManifest(name, reference, mediaType string, content []byte) (string, error) {
+	if err := checkName(name); err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(content)
+	hexDigest := hex.EncodeToString(sum[:])
+	digest := "sha256:" + hexDigest
+	if IsDigest(reference) {
+		if reference != digest {
+			return "", ErrDigestInvalid
+		}
+	} else if !tagRe.MatchString(reference) {
+		return "", fmt.Errorf("%w: %s", ErrTagInvalid, reference)
+	}
+	var refs manifestReferences
+	if err := json.Unmarshal(content, &refs); err != nil {
+		return "", fmt.Errorf("cannot parse manifest: %w", err)
+	}
+	if mediaType == "" {
+		mediaType = refs.MediaType
+	}
+	for _, ref := range refs.references() {
+		if _, err := s.BlobPath(ref); err != nil {
+			return "", fmt.Errorf("%w: %s", ErrManifestBlobUnknown, ref)
+		}
+	}
+	if err := os.WriteFile(s.blobPath(hexDigest), content, 0644); err != nil {
+		return "", err
+	}
+	repoPath := s.repositoryPath(name)
+	for _, dir := range []string{"manifests", "tags"} {
+		if err := os.MkdirAll(filepath.Join(repoPath, dir), 0755); err != nil {
+			return "", err
+		}
+	}
+	if err := os.WriteFile(filepath.Join(repoPath, "manifests", hexDigest), []byte(mediaType), 0644); err != nil {
+		return "", err
+	}
+	if !IsDigest(reference) {
+		if err := os.WriteFile(filepath.Join(repoPath, "tags", reference), []byte(digest), 0644); err != nil {
+			return "", err
+		}
+	}
+	return digest, nil
+}
+
+// GetManifest returns manifest of repository by reference (tag or digest)
+func (s *Store) GetManifest(name, reference string) (*Manifest, string, error) {
+	if err := checkName(name); err != nil {
+		return nil, "", err
+	}
+	repoPath := s.repositoryPath(name)
+	digest := reference
+	if !IsDigest(reference) {
+		if !tagRe.MatchString(reference) {
+			return nil, "", ErrManifestUnknown
+		}
+		data, err := os.ReadFile(filepath.Join(repoPath, "tags", reference))
+		if err != nil {
+			return nil, "", ErrManifestUnknown
+		}
+		digest = string(data)
+	}
+	hexDigest, err := ParseDigest(digest)
+	if err != nil {
+		return nil, "", err
+	}
+	mediaType, err := os.ReadFile(filepath.Join(repoPath, "manifests", hexDigest))
+	if err != nil {
+		return nil, "", ErrManifestUnknown
+	}
+	blobPath := s.blobPath(hexDigest)
+	info, err := os.Stat(blobPath)
+	if err != nil {
+		return nil, "", ErrManifestUnknown
+	}
+	return &Manifest{Digest: digest, MediaType: string(mediaType), Size: info.Size()}, blobPath, nil
+}
+
+// DeleteManifest removes manifest with digest and tags pointing to it from repository,
+// blobs are removed by garbage collection
+func (s *Store) DeleteManifest(name, digest string) error {
+	if err := checkName(name); err != nil {
+		return err
+	}
+	hexDigest, err := ParseDigest(digest)
+	if err != nil {
+		return err
+	}
+	repoPath := s.repositoryPath(name)
+	if err := os.Remove(filepath.Join(repoPath, "manifests", hexDigest)); err != nil {
+		return ErrManifestUnknown
+	}
+	tags, _ := s.Tags(name)
+	for _, tag := range tags {
+		data, err := os.ReadFile(filepath.Join(repoPath, "tags", tag))
+		if err == nil && string(data) == digest {
+			_ = os.Remove(filepath.Join(repoPath, "tags", tag))
+		}
+	}
+	return nil
+}
+
+// Tags returns sorted tags of repository
+func (s *Store) Tags(name string) ([]string, error) {
+	if err := checkName(name); err != nil {
+		return nil, err
+	}
+	entries, err := os.ReadDir(filepath.Join(s.repositoryPath(name), "tags"))
+	if err != nil {
+		return nil, ErrNameInvalid
+	}
+	tags := []string{}
+	for _, el := range entries {
+		tags = append(tags, el.Name())
+	}
+	sort.Strings(tags)
+	return tags, nil
+}
+
+// Repositories returns sorted names of repositories
+func (s *Store) Repositories() ([]string, error) {
+	root := filepath.Join(s.Dir, "repositories")
+	repositories := []string{}
+	err := filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() && info.Name() == "manifests" {
+			name, err := filepath.Rel(root, filepath.Dir(path))
+			if err != nil {
+				return err
+			}
+			repositories = append(repositories, filepath.ToSlash(name))
+			return filepath.SkipDir
+		}
+		return nil
+	})
+	sort.Strings(repositories)
+	return repositories, err
+}
+
+func (refs *manifestReferences) references() []string {
+	var result []string
+	if refs.Config != nil && refs.Config.Digest != "" {
+		result = append(result, refs.Config.Digest)
+	}
+	for _, el := range append(append([]*descriptor{}, refs.Layers...), refs.Manifests...) {
+		if el != nil && el.Digest != "" {
+			result = append(result, el.Digest)
+		}
+	}
+	return result
+}
+
+// GarbageCollect removes blobs not referenced by manifests of repositories and stale uploads
+func (s *Store) GarbageCollect() (*GCResult, error) {
+	referenced := map[string]bool{}
+	root := filepath.Join(s.Dir, "repositories")
+	err := filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() || filepath.Base(filepath.Dir(path)) != "manifests" {
+			return nil
+		}
+		hexDigest := info.Name()
+		referenced[hexDigest] = true
+		content, err := os.ReadFile(s.blobPath(hexDigest))
+		if err != nil {
+			return nil
+		}
+		var refs manifestReferences
+		if err := json.Unmarshal(content, &refs); err != nil {
+			return nil
+		}
+		for _, ref := range refs.references() {
+			if refHex, err := ParseDigest(ref); err == nil {
+				referenced[refHex] = true
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	result := &GCResult{}
+	blobs, err := os.ReadDir(filepath.Join(s.Dir, "blobs", "sha256"))
+	if err != nil {
+		return nil, err
+	}
+	for _, el := range blobs {
+		if referenced[el.Name()] {
+			continue
+		}
+		info, err := el.Info()
+		// keep recent blobs, manifest referencing them may be not pushed yet
+		if err != nil || time.Since(info.ModTime()) < uploadTTL {
+			continue
+		}
+		if err := os.Remove(s.blobPath(el.Name())); err != nil {
+			return nil, err
+		}
+		result.RemovedBlobs++
+		result.FreedBytes += info.Size()
+	}
+	uploads, err := os.ReadDir(filepath.Join(s.Dir, "uploads"))
+	if err != nil {
+		return nil, err
+	}
+	for _, el := range uploads {
+		info, err := el.Info()
+		if err != nil || time.Since(info.ModTime()) < uploadTTL {
+			continue
+		}
+		if err := os.Remove(s.uploadPath(el.Name())); err == nil {
+			result.RemovedUploads++
+		}
+	}
+	return result, nil
+}
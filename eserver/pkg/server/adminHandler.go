@@ -3,8 +3,11 @@ package server
 import (
 	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
+	"regexp"
+	"strconv"
 
 	"github.com/gorilla/mux"
 	"github.com/lf-edge/eden/eserver/api"
@@ -89,3 +92,103 @@ func (h *adminHandler) getFileStatus(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(http.StatusOK)
 	_, _ = w.Write(out)
 }
+
+var contentRangeRe = regexp.MustCompile(`^bytes (\d+)-(\d+)/(\d+)$`)
+
+// parseContentRange parses Content-Range header in form of "bytes start-end/total"
+func parseContentRange(header string) (offset, total int64, err error) {
+	match := contentRangeRe.FindStringSubmatch(header)
+	if match == nil {
+		return 0, 0, fmt.Errorf("wrong Content-Range: %q", header)
+	}
+	offset, _ = strconv.ParseInt(match[1], 10, 64)
+	end, _ := strconv.ParseInt(match[2], 10, 64)
+	total, _ = strconv.ParseInt(match[3], 10, 64)
+	if end < offset || end >= total {
+		return 0, 0, fmt.Errorf("wrong Content-Range: %q", header)
+	}
+	return offset, total, nil
+}
+
+// getUploadStatus returns size of data received for chunked upload
+func (h *adminHandler) getUploadStatus(w http.ResponseWriter, r *http.Request) {
+	u := mux.Vars(r)["filename"]
+	out, err := json.Marshal(&api.FileInfo{Size: h.manager.GetUploadSize(u)})
+	if err != nil {
+		wrapError(err, w)
+		return
+	}
+	w.Header().Add(contentType, mimeTextPlain)
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(out)
+}
+
+// uploadChunk appends chunk defined by Content-Range header to upload of file.
+// Responds with StatusRequestedRangeNotSatisfiable and size of received data
+// if chunk does not continue the upload.
+func (h *adminHandler) uploadChunk(w http.ResponseWriter, r *http.Request) {
+	u := mux.Vars(r)["filename"]
+	offset, total, err := parseContentRange(r.Header.Get("Content-Range"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	status := http.StatusOK
+	fileInfo, err := h.manager.AddFileChunk(u, offset, total, r.Body)
+	if err != nil {
+		if !errors.Is(err, manager.ErrUploadOffset) || fileInfo == nil {
+			wrapError(err, w)
+			return
+		}
+		status = http.StatusRequestedRangeNotSatisfiable
+		fileInfo.Error = err.Error()
+	}
+	out, err := json.Marshal(fileInfo)
+	if err != nil {
+		wrapError(err, w)
+		return
+	}
+	w.Header().Add(contentType, mimeTextPlain)
+	w.WriteHeader(status)
+	_, _ = w.Write(out)
+}
+
+// linkFile creates file from content already stored in eserver with sha256 provided in query.
+// Responds with StatusNotFound if there is no such content, so client must upload the file.
+func (h *adminHandler) linkFile(w http.ResponseWriter, r *http.Request) {
+	u := mux.Vars(r)["filename"]
+	fileInfo, err := h.manager.LinkFile(u, r.URL.Query().Get("sha256"))
+	if err != nil {
+		if errors.Is(err, manager.ErrBlobNotFound) {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		wrapError(err, w)
+		return
+	}
+	out, err := json.Marshal(fileInfo)
+	if err != nil {
+		wrapError(err, w)
+		return
+	}
+	w.Header().Add(contentType, mimeTextPlain)
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(out)
+}
+
+// listDigests returns sha256 of ready files by their names
+func (h *adminHandler) listDigests(w http.ResponseWriter, _ *http.Request) {
+	digests, err := h.manager.ListDigests()
+	if err != nil {
+		wrapError(err, w)
+		return
+	}
+	out, err := json.Marshal(digests)
+	if err != nil {
+		wrapError(err, w)
+		return
+	}
+	w.Header().Add(contentType, mimeTextPlain)
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(out)
+}
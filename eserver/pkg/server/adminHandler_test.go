@@ -0,0 +1,121 @@
+package server_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/lf-edge/eden/eserver/api"
+	"github.com/stretchr/testify/assert"
+)
+
+func fileInfo(t *testing.T, body string) *api.FileInfo {
+	var info api.FileInfo
+	assert.NoError(t, json.Unmarshal([]byte(body), &info))
+	return &info
+}
+
+func uploadChunk(t *testing.T, ts, name, contentRange, chunk string) (*http.Response, string) {
+	req, err := http.NewRequest(http.MethodPut, fmt.Sprintf("%s/admin/upload/%s", ts, name), strings.NewReader(chunk))
+	assert.NoError(t, err)
+	req.Header.Set("Content-Range", contentRange)
+	return doRequestWith(t, req)
+}
+
+func TestGetFileRange(t *testing.T) {
+	t.Parallel()
+
+	s, ts := newTestServer(t)
+	addFile(t, s, "eve.img", "0123456789")
+
+	req, err := http.NewRequest(http.MethodGet, ts.URL+"/eserver/eve.img", nil)
+	assert.NoError(t, err)
+	req.Header.Set("Range", "bytes=6-")
+	resp, body := doRequestWith(t, req)
+	assert.Equal(t, http.StatusPartialContent, resp.StatusCode)
+	assert.Equal(t, "bytes 6-9/10", resp.Header.Get("Content-Range"))
+	assert.Equal(t, "6789", body)
+}
+
+func TestUploadChunks(t *testing.T) {
+	t.Parallel()
+
+	_, ts := newTestServer(t)
+	content := "0123456789"
+
+	resp, body := uploadChunk(t, ts.URL, "dir/eve.img", "bytes 0-4/10", content[:5])
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, int64(5), fileInfo(t, body).Size)
+
+	// client asks where to continue after interruption
+	resp, body = doRequest(t, http.MethodGet, ts.URL+"/admin/upload/dir/eve.img", "", "")
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, int64(5), fileInfo(t, body).Size)
+
+	// chunk not continuing the upload is rejected with received size
+	resp, body = uploadChunk(t, ts.URL, "dir/eve.img", "bytes 7-9/10", content[7:])
+	assert.Equal(t, http.StatusRequestedRangeNotSatisfiable, resp.StatusCode)
+	assert.Equal(t, int64(5), fileInfo(t, body).Size)
+
+	for _, contentRange := range []string{"", "bytes 5-4/10", "bytes 5-10/10", "5-9/10"} {
+		resp, _ = uploadChunk(t, ts.URL, "dir/eve.img", contentRange, content[5:])
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode, contentRange)
+	}
+
+	resp, body = uploadChunk(t, ts.URL, "dir/eve.img", "bytes 5-9/10", content[5:])
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	info := fileInfo(t, body)
+	assert.True(t, info.ISReady)
+	assert.Equal(t, strings.TrimPrefix(digestOf(content), "sha256:"), info.Sha256)
+
+	resp, body = doRequest(t, http.MethodGet, ts.URL+"/eserver/dir/eve.img", "", "")
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, content, body)
+}
+
+func TestAddFromURLResume(t *testing.T) {
+	t.Parallel()
+
+	content := strings.Repeat("0123456789", 100)
+	var mu sync.Mutex
+	var ranges []string
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		ranges = append(ranges, r.Header.Get("Range"))
+		mu.Unlock()
+		http.ServeContent(w, r, "eve.img", time.Time{}, strings.NewReader(content))
+	}))
+	defer upstream.Close()
+
+	s, ts := newTestServer(t)
+	// data received by interrupted download
+	assert.NoError(t, os.WriteFile(filepath.Join(s.Manager.Dir, "eve.img.tmp"), []byte(content[:300]), 0644))
+
+	arg, err := json.Marshal(&api.URLArg{URL: upstream.URL + "/eve.img"})
+	assert.NoError(t, err)
+	resp, body := doRequest(t, http.MethodPost, ts.URL+"/admin/add-from-url", "application/json", string(arg))
+	assert.Equal(t, http.StatusCreated, resp.StatusCode)
+	assert.Equal(t, "eve.img", body)
+
+	var info *api.FileInfo
+	assert.Eventually(t, func() bool {
+		_, body := doRequest(t, http.MethodGet, ts.URL+"/admin/status/eve.img", "", "")
+		info = fileInfo(t, body)
+		return info.ISReady || info.Error != ""
+	}, 10*time.Second, 50*time.Millisecond)
+	assert.True(t, info.ISReady, info.Error)
+	assert.Equal(t, strings.TrimPrefix(digestOf(content), "sha256:"), info.Sha256)
+
+	mu.Lock()
+	assert.Equal(t, []string{"bytes=300-"}, ranges)
+	mu.Unlock()
+	_, body = doRequest(t, http.MethodGet, ts.URL+"/eserver/eve.img", "", "")
+	assert.Equal(t, content, body)
+}
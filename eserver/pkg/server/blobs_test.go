@@ -0,0 +1,72 @@
+package server_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/lf-edge/eden/eserver/pkg/manager"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDeduplication(t *testing.T) {
+	t.Parallel()
+
+	s, ts := newTestServer(t)
+	content := "0123456789"
+	sha := strings.TrimPrefix(digestOf(content), "sha256:")
+	blob := filepath.Join(s.Manager.Dir, manager.BlobsDir, "sha256", sha)
+
+	for _, name := range []string{"eve.img", "copy/eve.img"} {
+		resp, body := uploadChunk(t, ts.URL, name, "bytes 0-9/10", content)
+		assert.Equal(t, http.StatusOK, resp.StatusCode, name)
+		assert.Equal(t, sha, fileInfo(t, body).Sha256, name)
+	}
+	// files with the same content share the blob
+	blobInfo, err := os.Stat(blob)
+	assert.NoError(t, err)
+	for _, name := range []string{"eve.img", "copy/eve.img"} {
+		info, err := os.Stat(filepath.Join(s.Manager.Dir, name))
+		assert.NoError(t, err)
+		assert.True(t, os.SameFile(blobInfo, info), name)
+	}
+
+	// file is created from stored content without upload
+	resp, body := doRequest(t, http.MethodPost, fmt.Sprintf("%s/admin/link/linked.img?sha256=%s", ts.URL, sha), "", "")
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	info := fileInfo(t, body)
+	assert.True(t, info.ISReady)
+	assert.Equal(t, sha, info.Sha256)
+	_, body = doRequest(t, http.MethodGet, ts.URL+"/eserver/linked.img", "", "")
+	assert.Equal(t, content, body)
+
+	unknown := strings.TrimPrefix(digestOf("unknown"), "sha256:")
+	resp, _ = doRequest(t, http.MethodPost, fmt.Sprintf("%s/admin/link/other.img?sha256=%s", ts.URL, unknown), "", "")
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+	resp, _ = doRequest(t, http.MethodPost, ts.URL+"/admin/link/other.img?sha256=wrong", "", "")
+	assert.Equal(t, http.StatusInternalServerError, resp.StatusCode)
+
+	resp, body = doRequest(t, http.MethodGet, ts.URL+"/admin/digests", "", "")
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	var digests map[string]string
+	assert.NoError(t, json.Unmarshal([]byte(body), &digests))
+	assert.Equal(t, map[string]string{"eve.img": sha, "copy/eve.img": sha, "linked.img": sha}, digests)
+
+	// blobs are not visible as files
+	_, body = doRequest(t, http.MethodGet, ts.URL+"/admin/list", "", "")
+	assert.NotContains(t, body, manager.BlobsDir)
+	resp, _ = doRequest(t, http.MethodGet, fmt.Sprintf("%s/eserver/%s/sha256/%s", ts.URL, manager.BlobsDir, sha), "", "")
+	assert.NotEqual(t, http.StatusOK, resp.StatusCode)
+
+	// blob is removed after replacement of the last file using it
+	for _, name := range []string{"eve.img", "copy/eve.img", "linked.img"} {
+		resp, _ := uploadChunk(t, ts.URL, name, "bytes 0-2/3", "new")
+		assert.Equal(t, http.StatusOK, resp.StatusCode, name)
+	}
+	_, err = os.Stat(blob)
+	assert.True(t, os.IsNotExist(err))
+}
@@ -8,7 +8,8 @@ import (
 	"github.com/gorilla/mux"
 )
 
-func (s *EServer) serveHTTP(listener net.Listener, errorChan chan error) {
+// Router returns handler of http endpoints of eserver
+func (s *EServer) Router() http.Handler {
 	api := &apiHandler{
 		manager: s.Manager,
 	}
@@ -17,6 +18,17 @@ func (s *EServer) serveHTTP(listener net.Listener, errorChan chan error) {
 		manager: s.Manager,
 	}
 
+	s3 := &s3Handler{
+		manager:   s.Manager,
+		region:    s.S3Region,
+		accessKey: s.S3AccessKey,
+		secretKey: s.S3SecretKey,
+	}
+
+	reg := &registryHandler{
+		store: s.Registry,
+	}
+
 	router := mux.NewRouter()
 
 	ad := router.PathPrefix("/admin").Subrouter()
@@ -26,12 +38,24 @@ func (s *EServer) serveHTTP(listener net.Listener, errorChan chan error) {
 	ad.HandleFunc("/list", admin.list).Methods("GET")
 	ad.HandleFunc("/add-from-url", admin.addFromURL).Methods("POST")
 	ad.HandleFunc("/add-from-file", admin.addFromFile).Methods("POST")
+	ad.HandleFunc("/upload/{filename:[A-Za-z0-9_\\-.\\/]*}", admin.getUploadStatus).Methods("GET")
+	ad.HandleFunc("/upload/{filename:[A-Za-z0-9_\\-.\\/]*}", admin.uploadChunk).Methods("PUT")
 	ad.HandleFunc("/status/{filename:[A-Za-z0-9_\\-.\\/]*}", admin.getFileStatus).Methods("GET")
+	ad.HandleFunc("/link/{filename:[A-Za-z0-9_\\-.\\/]*}", admin.linkFile).Methods("POST")
+	ad.HandleFunc("/digests", admin.listDigests).Methods("GET")
+	ad.HandleFunc("/presign/{filename:[A-Za-z0-9_\\-.\\/]*}", s3.getPresignedURL).Methods("GET")
 
-	router.HandleFunc("/eserver/{filename:[A-Za-z0-9_\\-.\\/]*}", api.getFile).Methods("GET")
+	router.HandleFunc("/eserver/{filename:[A-Za-z0-9_\\-.\\/]*}", s3.withS3Auth(s3.getObject, api.getFile)).Methods("GET", "HEAD")
+	router.HandleFunc("/eserver", s3.withS3Auth(s3.bucketRequest, s3.bucketRequest)).Methods("GET", "HEAD")
 
+	reg.register(router, ad)
+
+	return router
+}
+
+func (s *EServer) serveHTTP(listener net.Listener, errorChan chan error) {
 	server := &http.Server{
-		Handler: router,
+		Handler: s.Router(),
 		Addr:    fmt.Sprintf("%s:%s", s.Address, s.Port),
 	}
 	errorChan <- server.Serve(listener)
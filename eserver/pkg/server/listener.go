@@ -3,9 +3,10 @@ package server
 import (
 	"bufio"
 	"errors"
-	"log"
 	"net"
 	"time"
+
+	log "github.com/sirupsen/logrus"
 )
 
 type listener struct {
@@ -58,21 +59,21 @@ func MuxListener(l net.Listener) (ssh net.Listener, other net.Listener) {
 		for {
 			conn, err := l.Accept()
 			if err != nil {
-				log.Println("Error accepting conn:", err)
+				log.WithError(err).Error("Error accepting conn")
 				continue
 			}
 			if err := conn.SetReadDeadline(time.Now().Add(time.Second * 10)); err != nil {
-				log.Println("Error SetReadDeadline:", err)
+				log.WithError(err).WithField("client", conn.RemoteAddr()).Error("Error SetReadDeadline")
 				continue
 			}
 			bconn := bufferedConn{conn, bufio.NewReaderSize(conn, 3)}
 			p, err := bconn.Peek(3)
 			if err != nil {
-				log.Println("Error peeking into conn:", err)
+				log.WithError(err).WithField("client", conn.RemoteAddr()).Warn("Error peeking into conn")
 				continue
 			}
 			if err := conn.SetReadDeadline(time.Time{}); err != nil {
-				log.Println("Error SetReadDeadline:", err)
+				log.WithError(err).WithField("client", conn.RemoteAddr()).Error("Error SetReadDeadline")
 				continue
 			}
 			prefix := string(p)
@@ -0,0 +1,292 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"github.com/lf-edge/eden/eserver/pkg/registry"
+)
+
+const (
+	registryNamePattern   = "{name:[a-z0-9]+(?:[._/-][a-z0-9]+)*}"
+	registryDigestPattern = "{digest:sha256:[a-f0-9]{64}}"
+	registryUploadPattern = "{uuid:[a-f0-9]+}"
+	mimeJSON              = "application/json"
+	manifestMaxSize       = 4 * 1024 * 1024
+)
+
+// registryHandler serves OCI distribution API on /v2/ with content in registry.Store
+type registryHandler struct {
+	store *registry.Store
+}
+
+type registryError struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+type registryErrors struct {
+	Errors []*registryError `json:"errors"`
+}
+
+// writeRegistryError writes error in form defined by distribution spec
+func writeRegistryError(w http.ResponseWriter, r *http.Request, err error) {
+	status, code := http.StatusInternalServerError, "UNKNOWN"
+	switch {
+	case errors.Is(err, registry.ErrManifestBlobUnknown):
+		status, code = http.StatusBadRequest, "MANIFEST_BLOB_UNKNOWN"
+	case errors.Is(err, registry.ErrBlobUnknown):
+		status, code = http.StatusNotFound, "BLOB_UNKNOWN"
+	case errors.Is(err, registry.ErrManifestUnknown):
+		status, code = http.StatusNotFound, "MANIFEST_UNKNOWN"
+	case errors.Is(err, registry.ErrUploadUnknown):
+		status, code = http.StatusNotFound, "BLOB_UPLOAD_UNKNOWN"
+	case errors.Is(err, registry.ErrDigestInvalid):
+		status, code = http.StatusBadRequest, "DIGEST_INVALID"
+	case errors.Is(err, registry.ErrNameInvalid):
+		status, code = http.StatusNotFound, "NAME_UNKNOWN"
+	case errors.Is(err, registry.ErrTagInvalid):
+		status, code = http.StatusBadRequest, "TAG_INVALID"
+	}
+	w.Header().Set(contentType, mimeJSON)
+	w.WriteHeader(status)
+	if r.Method == http.MethodHead {
+		return
+	}
+	out, _ := json.Marshal(&registryErrors{Errors: []*registryError{{Code: code, Message: err.Error()}}})
+	_, _ = w.Write(out)
+}
+
+func (h *registryHandler) base(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Docker-Distribution-API-Version", "registry/2.0")
+	w.WriteHeader(http.StatusOK)
+}
+
+func (h *registryHandler) getBlob(w http.ResponseWriter, r *http.Request) {
+	digest := mux.Vars(r)["digest"]
+	blobPath, err := h.store.BlobPath(digest)
+	if err != nil {
+		writeRegistryError(w, r, err)
+		return
+	}
+	f, err := os.Open(blobPath)
+	if err != nil {
+		writeRegistryError(w, r, err)
+		return
+	}
+	defer f.Close()
+	w.Header().Set("Docker-Content-Digest", digest)
+	w.Header().Set(contentType, "application/octet-stream")
+	info, err := f.Stat()
+	if err != nil {
+		writeRegistryError(w, r, err)
+		return
+	}
+	// ServeContent supports Range requests
+	http.ServeContent(w, r, "", info.ModTime(), f)
+}
+
+func (h *registryHandler) uploadLocation(w http.ResponseWriter, name, id string, size int64) {
+	w.Header().Set("Location", fmt.Sprintf("/v2/%s/blobs/uploads/%s", name, id))
+	w.Header().Set("Docker-Upload-UUID", id)
+	end := size - 1
+	if end < 0 {
+		end = 0
+	}
+	w.Header().Set("Range", fmt.Sprintf("0-%d", end))
+	w.Header().Set("Content-Length", "0")
+}
+
+func (h *registryHandler) blobCreated(w http.ResponseWriter, name, digest string) {
+	w.Header().Set("Location", fmt.Sprintf("/v2/%s/blobs/%s", name, digest))
+	w.Header().Set("Docker-Content-Digest", digest)
+	w.Header().Set("Content-Length", "0")
+	w.WriteHeader(http.StatusCreated)
+}
+
+// startUpload starts upload of blob, supports monolithic upload with digest and mount of existing blob
+func (h *registryHandler) startUpload(w http.ResponseWriter, r *http.Request) {
+	name := mux.Vars(r)["name"]
+	query := r.URL.Query()
+	if mount := query.Get("mount"); mount != "" {
+		if _, err := h.store.BlobPath(mount); err == nil {
+			h.blobCreated(w, name, mount)
+			return
+		}
+	}
+	id, err := h.store.StartUpload()
+	if err != nil {
+		writeRegistryError(w, r, err)
+		return
+	}
+	if digest := query.Get("digest"); digest != "" {
+		if _, err := h.store.AppendUpload(id, r.Body); err != nil {
+			writeRegistryError(w, r, err)
+			return
+		}
+		if err := h.store.CommitUpload(id, digest); err != nil {
+			writeRegistryError(w, r, err)
+			return
+		}
+		h.blobCreated(w, name, digest)
+		return
+	}
+	h.uploadLocation(w, name, id, 0)
+	w.WriteHeader(http.StatusAccepted)
+}
+
+func (h *registryHandler) patchUpload(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	size, err := h.store.AppendUpload(vars["uuid"], r.Body)
+	if err != nil {
+		writeRegistryError(w, r, err)
+		return
+	}
+	h.uploadLocation(w, vars["name"], vars["uuid"], size)
+	w.WriteHeader(http.StatusAccepted)
+}
+
+// finishUpload appends the rest of blob and verifies its digest
+func (h *registryHandler) finishUpload(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	digest := r.URL.Query().Get("digest")
+	if _, err := h.store.AppendUpload(vars["uuid"], r.Body); err != nil {
+		writeRegistryError(w, r, err)
+		return
+	}
+	if err := h.store.CommitUpload(vars["uuid"], digest); err != nil {
+		writeRegistryError(w, r, err)
+		return
+	}
+	h.blobCreated(w, vars["name"], digest)
+}
+
+func (h *registryHandler) getUpload(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	size, err := h.store.UploadSize(vars["uuid"])
+	if err != nil {
+		writeRegistryError(w, r, err)
+		return
+	}
+	h.uploadLocation(w, vars["name"], vars["uuid"], size)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *registryHandler) cancelUpload(w http.ResponseWriter, r *http.Request) {
+	if err := h.store.CancelUpload(mux.Vars(r)["uuid"]); err != nil {
+		writeRegistryError(w, r, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *registryHandler) getManifest(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	manifest, manifestPath, err := h.store.GetManifest(vars["name"], vars["reference"])
+	if err != nil {
+		writeRegistryError(w, r, err)
+		return
+	}
+	w.Header().Set(contentType, manifest.MediaType)
+	w.Header().Set("Docker-Content-Digest", manifest.Digest)
+	w.Header().Set("Content-Length", strconv.FormatInt(manifest.Size, 10))
+	w.WriteHeader(http.StatusOK)
+	if r.Method == http.MethodHead {
+		return
+	}
+	f, err := os.Open(manifestPath)
+	if err != nil {
+		return
+	}
+	defer f.Close()
+	_, _ = io.Copy(w, f)
+}
+
+func (h *registryHandler) putManifest(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	content, err := io.ReadAll(io.LimitReader(r.Body, manifestMaxSize))
+	if err != nil {
+		writeRegistryError(w, r, err)
+		return
+	}
+	digest, err := h.store.PutManifest(vars["name"], vars["reference"], r.Header.Get(contentType), content)
+	if err != nil {
+		writeRegistryError(w, r, err)
+		return
+	}
+	w.Header().Set("Location", fmt.Sprintf("/v2/%s/manifests/%s", vars["name"], digest))
+	w.Header().Set("Docker-Content-Digest", digest)
+	w.WriteHeader(http.StatusCreated)
+}
+
+func (h *registryHandler) deleteManifest(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	if err := h.store.DeleteManifest(vars["name"], vars["reference"]); err != nil {
+		writeRegistryError(w, r, err)
+		return
+	}
+	w.WriteHeader(http.StatusAccepted)
+}
+
+func (h *registryHandler) tagsList(w http.ResponseWriter, r *http.Request) {
+	name := mux.Vars(r)["name"]
+	tags, err := h.store.Tags(name)
+	if err != nil {
+		writeRegistryError(w, r, err)
+		return
+	}
+	writeJSON(w, map[string]interface{}{"name": name, "tags": tags})
+}
+
+func (h *registryHandler) catalog(w http.ResponseWriter, r *http.Request) {
+	repositories, err := h.store.Repositories()
+	if err != nil {
+		writeRegistryError(w, r, err)
+		return
+	}
+	writeJSON(w, map[string]interface{}{"repositories": repositories})
+}
+
+// garbageCollect removes blobs not referenced by manifests
+func (h *registryHandler) garbageCollect(w http.ResponseWriter, _ *http.Request) {
+	result, err := h.store.GarbageCollect()
+	if err != nil {
+		wrapError(err, w)
+		return
+	}
+	writeJSON(w, result)
+}
+
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	out, err := json.Marshal(v)
+	if err != nil {
+		wrapError(err, w)
+		return
+	}
+	w.Header().Set(contentType, mimeJSON)
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(out)
+}
+
+func (h *registryHandler) register(router *mux.Router, admin *mux.Router) {
+	v2 := router.PathPrefix("/v2").Subrouter()
+	v2.HandleFunc("/", h.base).Methods("GET")
+	v2.HandleFunc("/_catalog", h.catalog).Methods("GET")
+	v2.HandleFunc(fmt.Sprintf("/%s/tags/list", registryNamePattern), h.tagsList).Methods("GET")
+	v2.HandleFunc(fmt.Sprintf("/%s/manifests/{reference}", registryNamePattern), h.getManifest).Methods("GET", "HEAD")
+	v2.HandleFunc(fmt.Sprintf("/%s/manifests/{reference}", registryNamePattern), h.putManifest).Methods("PUT")
+	v2.HandleFunc(fmt.Sprintf("/%s/manifests/{reference}", registryNamePattern), h.deleteManifest).Methods("DELETE")
+	v2.HandleFunc(fmt.Sprintf("/%s/blobs/%s", registryNamePattern, registryDigestPattern), h.getBlob).Methods("GET", "HEAD")
+	v2.HandleFunc(fmt.Sprintf("/%s/blobs/uploads/", registryNamePattern), h.startUpload).Methods("POST")
+	v2.HandleFunc(fmt.Sprintf("/%s/blobs/uploads/%s", registryNamePattern, registryUploadPattern), h.patchUpload).Methods("PATCH")
+	v2.HandleFunc(fmt.Sprintf("/%s/blobs/uploads/%s", registryNamePattern, registryUploadPattern), h.finishUpload).Methods("PUT")
+	v2.HandleFunc(fmt.Sprintf("/%s/blobs/uploads/%s", registryNamePattern, registryUploadPattern), h.getUpload).Methods("GET")
+	v2.HandleFunc(fmt.Sprintf("/%s/blobs/uploads/%s", registryNamePattern, registryUploadPattern), h.cancelUpload).Methods("DELETE")
+	admin.HandleFunc("/registry/gc", h.garbageCollect).Methods("POST")
+}
@@ -0,0 +1,158 @@
+package server_test
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/lf-edge/eden/eserver/pkg/registry"
+	"github.com/lf-edge/eden/eserver/pkg/server"
+	"github.com/stretchr/testify/assert"
+)
+
+const ociManifest = "application/vnd.oci.image.manifest.v1+json"
+
+func digestOf(content string) string {
+	sum := sha256.Sum256([]byte(content))
+	return "sha256:" + hex.EncodeToString(sum[:])
+}
+
+// pushBlob pushes blob in chunks with PATCH and finishes upload with PUT
+func pushBlob(t *testing.T, ts string, name, content string) string {
+	digest := digestOf(content)
+	resp, _ := doRequest(t, http.MethodPost, fmt.Sprintf("%s/v2/%s/blobs/uploads/", ts, name), "", "")
+	assert.Equal(t, http.StatusAccepted, resp.StatusCode)
+	location := resp.Header.Get("Location")
+	half := len(content) / 2
+	resp, _ = doRequest(t, http.MethodPatch, ts+location, "", content[:half])
+	assert.Equal(t, http.StatusAccepted, resp.StatusCode)
+	assert.Equal(t, fmt.Sprintf("0-%d", half-1), resp.Header.Get("Range"))
+	resp, _ = doRequest(t, http.MethodPut, fmt.Sprintf("%s%s?digest=%s", ts, location, digest), "", content[half:])
+	assert.Equal(t, http.StatusCreated, resp.StatusCode)
+	assert.Equal(t, digest, resp.Header.Get("Docker-Content-Digest"))
+	return digest
+}
+
+func manifest(config, layer string) string {
+	return fmt.Sprintf(`{"schemaVersion":2,"mediaType":%q,`+
+		`"config":{"mediaType":"application/vnd.oci.image.config.v1+json","digest":%q,"size":2},`+
+		`"layers":[{"mediaType":"application/vnd.oci.image.layer.v1.tar","digest":%q,"size":5}]}`,
+		ociManifest, config, layer)
+}
+
+func TestRegistryPush(t *testing.T) {
+	t.Parallel()
+
+	_, ts := newTestServer(t)
+	config := pushBlob(t, ts.URL, "eden/app", "{}")
+	layer := digestOf("layer")
+	resp, _ := doRequest(t, http.MethodPost, fmt.Sprintf("%s/v2/eden/app/blobs/uploads/?digest=%s", ts.URL, layer), "", "layer")
+	assert.Equal(t, http.StatusCreated, resp.StatusCode)
+
+	resp, body := doRequest(t, http.MethodGet, fmt.Sprintf("%s/v2/eden/app/blobs/%s", ts.URL, layer), "", "")
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "layer", body)
+
+	content := manifest(config, layer)
+	resp, _ = doRequest(t, http.MethodPut, ts.URL+"/v2/eden/app/manifests/v1", ociManifest, content)
+	assert.Equal(t, http.StatusCreated, resp.StatusCode)
+	assert.Equal(t, digestOf(content), resp.Header.Get("Docker-Content-Digest"))
+
+	resp, body = doRequest(t, http.MethodGet, ts.URL+"/v2/eden/app/manifests/v1", "", "")
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, ociManifest, resp.Header.Get("Content-Type"))
+	assert.Equal(t, content, body)
+
+	resp, body = doRequest(t, http.MethodGet, ts.URL+"/v2/eden/app/tags/list", "", "")
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.JSONEq(t, `{"name":"eden/app","tags":["v1"]}`, body)
+
+	testMatrix := map[string]struct {
+		method string
+		path   string
+		body   string
+		status int
+		code   string
+	}{
+		"manifest with unknown blob": {
+			method: http.MethodPut,
+			path:   "/v2/eden/app/manifests/v2",
+			body:   manifest(config, digestOf("missing")),
+			status: http.StatusBadRequest,
+			code:   "MANIFEST_BLOB_UNKNOWN",
+		},
+		"blob with wrong digest": {
+			method: http.MethodPost,
+			path:   fmt.Sprintf("/v2/eden/app/blobs/uploads/?digest=%s", digestOf("other")),
+			body:   "layer",
+			status: http.StatusBadRequest,
+			code:   "DIGEST_INVALID",
+		},
+		"unknown manifest": {
+			method: http.MethodGet,
+			path:   "/v2/eden/app/manifests/v2",
+			status: http.StatusNotFound,
+			code:   "MANIFEST_UNKNOWN",
+		},
+		"unknown blob": {
+			method: http.MethodGet,
+			path:   fmt.Sprintf("/v2/eden/app/blobs/%s", digestOf("missing")),
+			status: http.StatusNotFound,
+			code:   "BLOB_UNKNOWN",
+		},
+	}
+
+	for name, test := range testMatrix {
+		resp, body := doRequest(t, test.method, ts.URL+test.path, ociManifest, test.body)
+		assert.Equal(t, test.status, resp.StatusCode, name)
+		assert.Contains(t, body, fmt.Sprintf(`"code":%q`, test.code), name)
+	}
+}
+
+// ageBlob moves modification time of blob behind the time garbage collection keeps blobs
+func ageBlob(t *testing.T, s *server.EServer, digest string) {
+	hexDigest, err := registry.ParseDigest(digest)
+	assert.NoError(t, err)
+	old := time.Now().Add(-2 * time.Hour)
+	assert.NoError(t, os.Chtimes(filepath.Join(s.Registry.Dir, "blobs", "sha256", hexDigest), old, old))
+}
+
+func TestRegistryGarbageCollect(t *testing.T) {
+	t.Parallel()
+
+	s, ts := newTestServer(t)
+	config := pushBlob(t, ts.URL, "eden/app", "{}")
+	layer := pushBlob(t, ts.URL, "eden/app", "layer")
+	orphan := pushBlob(t, ts.URL, "eden/app", "orphan")
+	recent := pushBlob(t, ts.URL, "eden/app", "recent")
+	resp, _ := doRequest(t, http.MethodPut, ts.URL+"/v2/eden/app/manifests/v1", ociManifest, manifest(config, layer))
+	assert.Equal(t, http.StatusCreated, resp.StatusCode)
+	for _, digest := range []string{config, layer, orphan} {
+		ageBlob(t, s, digest)
+	}
+
+	resp, body := doRequest(t, http.MethodPost, ts.URL+"/admin/registry/gc", "", "")
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	var result registry.GCResult
+	assert.NoError(t, json.Unmarshal([]byte(body), &result))
+	assert.Equal(t, registry.GCResult{RemovedBlobs: 1, FreedBytes: int64(len("orphan"))}, result)
+
+	// blobs referenced by manifest and recent blobs are kept
+	for digest, status := range map[string]int{
+		config: http.StatusOK,
+		layer:  http.StatusOK,
+		recent: http.StatusOK,
+		orphan: http.StatusNotFound,
+	} {
+		resp, _ := doRequest(t, http.MethodHead, fmt.Sprintf("%s/v2/eden/app/blobs/%s", ts.URL, digest), "", "")
+		assert.Equal(t, status, resp.StatusCode, digest)
+	}
+	resp, _ = doRequest(t, http.MethodGet, ts.URL+"/v2/eden/app/manifests/v1", "", "")
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+}
@@ -0,0 +1,355 @@
+package server
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/lf-edge/eden/eserver/pkg/manager"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	s3Algorithm     = "AWS4-HMAC-SHA256"
+	s3Service       = "s3"
+	s3Terminator    = "aws4_request"
+	s3TimeFormat    = "20060102T150405Z"
+	s3DateFormat    = "20060102"
+	s3UnsignedBody  = "UNSIGNED-PAYLOAD"
+	s3MaxExpires    = 7 * 24 * time.Hour
+	s3DefaultExpire = time.Hour
+	mimeXML         = "application/xml"
+	// s3Bucket is the only bucket, it matches path of http endpoint
+	s3Bucket = "eserver"
+)
+
+// s3Handler serves content of eserver with subset of S3 API (path-style, one bucket)
+// requests signed with AWS Signature Version 4 (header or query string) are validated
+type s3Handler struct {
+	manager   *manager.EServerManager
+	region    string
+	accessKey string
+	secretKey string
+}
+
+type s3Error struct {
+	XMLName  xml.Name `xml:"Error"`
+	Code     string   `xml:"Code"`
+	Message  string   `xml:"Message"`
+	Resource string   `xml:"Resource"`
+}
+
+type s3Object struct {
+	Key          string `xml:"Key"`
+	LastModified string `xml:"LastModified"`
+	ETag         string `xml:"ETag"`
+	Size         int64  `xml:"Size"`
+	StorageClass string `xml:"StorageClass"`
+}
+
+type s3ListBucketResult struct {
+	XMLName     xml.Name    `xml:"http://s3.amazonaws.com/doc/2006-03-01/ ListBucketResult"`
+	Name        string      `xml:"Name"`
+	Prefix      string      `xml:"Prefix"`
+	KeyCount    int         `xml:"KeyCount"`
+	MaxKeys     int         `xml:"MaxKeys"`
+	IsTruncated bool        `xml:"IsTruncated"`
+	Contents    []*s3Object `xml:"Contents"`
+}
+
+func writeS3Error(w http.ResponseWriter, r *http.Request, status int, code, message string) {
+	log.WithFields(log.Fields{
+		"method": r.Method,
+		"path":   r.URL.Path,
+		"code":   code,
+	}).Warnf("s3: %s", message)
+	w.Header().Set(contentType, mimeXML)
+	w.WriteHeader(status)
+	if r.Method == http.MethodHead {
+		return
+	}
+	out, _ := xml.Marshal(&s3Error{Code: code, Message: message, Resource: r.URL.Path})
+	_, _ = w.Write(append([]byte(xml.Header), out...))
+}
+
+// s3Request returns true if request carries S3 authentication
+func s3Request(r *http.Request) bool {
+	return strings.HasPrefix(r.Header.Get("Authorization"), s3Algorithm) ||
+		r.URL.Query().Get("X-Amz-Algorithm") == s3Algorithm
+}
+
+// uriEncode encodes string as defined for canonical request of AWS Signature Version 4
+func uriEncode(s string, encodeSlash bool) string {
+	var b strings.Builder
+	for _, c := range []byte(s) {
+		switch {
+		case 'A' <= c && c <= 'Z', 'a' <= c && c <= 'z', '0' <= c && c <= '9',
+			c == '-', c == '_', c == '.', c == '~':
+			b.WriteByte(c)
+		case c == '/' && !encodeSlash:
+			b.WriteByte(c)
+		default:
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	_, _ = h.Write([]byte(data))
+	return h.Sum(nil)
+}
+
+func (h *s3Handler) scope(date string) string {
+	return strings.Join([]string{date, h.region, s3Service, s3Terminator}, "/")
+}
+
+func (h *s3Handler) signature(date, amzDate, canonicalRequest string) string {
+	hash := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := strings.Join([]string{s3Algorithm, amzDate, h.scope(date), hex.EncodeToString(hash[:])}, "\n")
+	key := hmacSHA256([]byte("AWS4"+h.secretKey), date)
+	key = hmacSHA256(key, h.region)
+	key = hmacSHA256(key, s3Service)
+	key = hmacSHA256(key, s3Terminator)
+	return hex.EncodeToString(hmacSHA256(key, stringToSign))
+}
+
+func canonicalRequest(r *http.Request, signedHeaders []string, payloadHash string, skipQuery string) string {
+	query := r.URL.Query()
+	keys := make([]string, 0, len(query))
+	for k := range query {
+		if k != skipQuery {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	var params []string
+	for _, k := range keys {
+		values := query[k]
+		sort.Strings(values)
+		for _, v := range values {
+			params = append(params, uriEncode(k, true)+"="+uriEncode(v, true))
+		}
+	}
+	var headers strings.Builder
+	for _, k := range signedHeaders {
+		value := r.Header.Get(k)
+		if k == "host" {
+			value = r.Host
+		}
+		fmt.Fprintf(&headers, "%s:%s\n", k, strings.Join(strings.Fields(value), " "))
+	}
+	return strings.Join([]string{
+		r.Method,
+		uriEncode(r.URL.Path, false),
+		strings.Join(params, "&"),
+		headers.String(),
+		strings.Join(signedHeaders, ";"),
+		payloadHash,
+	}, "\n")
+}
+
+// checkCredential checks credential in form of <access key>/<date>/<region>/s3/aws4_request
+func (h *s3Handler) checkCredential(credential string) (string, error) {
+	parts := strings.Split(credential, "/")
+	if len(parts) != 5 || parts[3] != s3Service || parts[4] != s3Terminator {
+		return "", fmt.Errorf("malformed credential %s", credential)
+	}
+	if parts[0] != h.accessKey {
+		return "", fmt.Errorf("unknown access key %s", parts[0])
+	}
+	if parts[2] != h.region {
+		return "", fmt.Errorf("wrong region %s, expected %s", parts[2], h.region)
+	}
+	return parts[1], nil
+}
+
+// authenticate validates signature of request
+func (h *s3Handler) authenticate(r *http.Request) error {
+	if auth := r.Header.Get("Authorization"); auth != "" {
+		fields := map[string]string{}
+		for _, el := range strings.Split(strings.TrimPrefix(auth, s3Algorithm), ",") {
+			if kv := strings.SplitN(strings.TrimSpace(el), "=", 2); len(kv) == 2 {
+				fields[kv[0]] = kv[1]
+			}
+		}
+		date, err := h.checkCredential(fields["Credential"])
+		if err != nil {
+			return err
+		}
+		payloadHash := r.Header.Get("X-Amz-Content-Sha256")
+		if payloadHash == "" {
+			payloadHash = s3UnsignedBody
+		}
+		amzDate := r.Header.Get("X-Amz-Date")
+		canonical := canonicalRequest(r, strings.Split(fields["SignedHeaders"], ";"), payloadHash, "")
+		if !hmac.Equal([]byte(h.signature(date, amzDate, canonical)), []byte(fields["Signature"])) {
+			return fmt.Errorf("signature does not match")
+		}
+		return nil
+	}
+	query := r.URL.Query()
+	date, err := h.checkCredential(query.Get("X-Amz-Credential"))
+	if err != nil {
+		return err
+	}
+	amzDate := query.Get("X-Amz-Date")
+	signedAt, err := time.Parse(s3TimeFormat, amzDate)
+	if err != nil {
+		return fmt.Errorf("malformed X-Amz-Date: %w", err)
+	}
+	expires, err := strconv.Atoi(query.Get("X-Amz-Expires"))
+	if err != nil {
+		return fmt.Errorf("malformed X-Amz-Expires: %w", err)
+	}
+	if time.Now().After(signedAt.Add(time.Duration(expires) * time.Second)) {
+		return fmt.Errorf("request has expired")
+	}
+	canonical := canonicalRequest(r, strings.Split(query.Get("X-Amz-SignedHeaders"), ";"), s3UnsignedBody, "X-Amz-Signature")
+	if !hmac.Equal([]byte(h.signature(date, amzDate, canonical)), []byte(query.Get("X-Amz-Signature"))) {
+		return fmt.Errorf("signature does not match")
+	}
+	return nil
+}
+
+// presign returns URL for GET of object signed with query string parameters
+func (h *s3Handler) presign(host, key string, expires time.Duration) string {
+	now := time.Now().UTC()
+	date := now.Format(s3DateFormat)
+	u := &url.URL{Scheme: "http", Host: host, Path: fmt.Sprintf("/%s/%s", s3Bucket, key)}
+	query := url.Values{}
+	query.Set("X-Amz-Algorithm", s3Algorithm)
+	query.Set("X-Amz-Credential", fmt.Sprintf("%s/%s", h.accessKey, h.scope(date)))
+	query.Set("X-Amz-Date", now.Format(s3TimeFormat))
+	query.Set("X-Amz-Expires", strconv.Itoa(int(expires.Seconds())))
+	query.Set("X-Amz-SignedHeaders", "host")
+	u.RawQuery = query.Encode()
+	r := &http.Request{Method: http.MethodGet, URL: u, Host: host, Header: http.Header{}}
+	query.Set("X-Amz-Signature", h.signature(date, query.Get("X-Amz-Date"), canonicalRequest(r, []string{"host"}, s3UnsignedBody, "")))
+	u.RawQuery = query.Encode()
+	return u.String()
+}
+
+func etag(filePath string) string {
+	sha, err := os.ReadFile(fmt.Sprintf("%s.sha256", filePath))
+	if err != nil {
+		return ""
+	}
+	return fmt.Sprintf("%q", strings.TrimSpace(string(sha)))
+}
+
+// getObject serves GetObject and HeadObject, Range header is supported for multipart downloads
+func (h *s3Handler) getObject(w http.ResponseWriter, r *http.Request) {
+	key := mux.Vars(r)["filename"]
+	filePath, err := h.manager.GetFilePath(key)
+	if err != nil {
+		writeS3Error(w, r, http.StatusNotFound, "NoSuchKey", "The specified key does not exist.")
+		return
+	}
+	if tag := etag(filePath); tag != "" {
+		w.Header().Set("ETag", tag)
+	}
+	w.Header().Set("Accept-Ranges", "bytes")
+	http.ServeFile(w, r, filePath)
+}
+
+// bucketRequest serves HeadBucket and ListObjectsV2
+func (h *s3Handler) bucketRequest(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodHead {
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+	prefix := r.URL.Query().Get("prefix")
+	result := &s3ListBucketResult{Name: s3Bucket, Prefix: prefix, MaxKeys: 1000}
+	err := filepath.Walk(h.manager.Dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		key, err := filepath.Rel(h.manager.Dir, path)
+		if err != nil {
+			return err
+		}
+		if info.IsDir() && key == manager.BlobsDir {
+			return filepath.SkipDir
+		}
+		if info.IsDir() || strings.HasSuffix(path, ".sha256") || strings.HasSuffix(path, ".tmp") {
+			return nil
+		}
+		key = filepath.ToSlash(key)
+		if !strings.HasPrefix(key, prefix) {
+			return nil
+		}
+		result.Contents = append(result.Contents, &s3Object{
+			Key:          key,
+			LastModified: info.ModTime().UTC().Format(time.RFC3339),
+			ETag:         etag(path),
+			Size:         info.Size(),
+			StorageClass: "STANDARD",
+		})
+		return nil
+	})
+	if err != nil {
+		writeS3Error(w, r, http.StatusInternalServerError, "InternalError", err.Error())
+		return
+	}
+	result.KeyCount = len(result.Contents)
+	out, err := xml.Marshal(result)
+	if err != nil {
+		writeS3Error(w, r, http.StatusInternalServerError, "InternalError", err.Error())
+		return
+	}
+	w.Header().Set(contentType, mimeXML)
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(append([]byte(xml.Header), out...))
+}
+
+// getPresignedURL returns signed URL to download file via S3 API without credentials
+func (h *s3Handler) getPresignedURL(w http.ResponseWriter, r *http.Request) {
+	key := mux.Vars(r)["filename"]
+	if _, err := h.manager.GetFilePath(key); err != nil {
+		wrapError(err, w)
+		return
+	}
+	expires := s3DefaultExpire
+	if value := r.URL.Query().Get("expires"); value != "" {
+		var err error
+		if expires, err = time.ParseDuration(value); err != nil {
+			wrapError(err, w)
+			return
+		}
+		if expires <= 0 || expires > s3MaxExpires {
+			wrapError(fmt.Errorf("expires must be in range (0, %s]", s3MaxExpires), w)
+			return
+		}
+	}
+	w.Header().Add(contentType, mimeTextPlain)
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write([]byte(h.presign(r.Host, key, expires)))
+}
+
+// withS3Auth passes request to s3 handler if it is signed, or to next handler otherwise
+func (h *s3Handler) withS3Auth(s3 http.HandlerFunc, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !s3Request(r) {
+			next(w, r)
+			return
+		}
+		if err := h.authenticate(r); err != nil {
+			writeS3Error(w, r, http.StatusForbidden, "AccessDenied", err.Error())
+			return
+		}
+		s3(w, r)
+	}
+}
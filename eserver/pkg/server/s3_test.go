@@ -0,0 +1,162 @@
+package server_test
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type listBucketResult struct {
+	KeyCount int `xml:"KeyCount"`
+	Contents []struct {
+		Key  string `xml:"Key"`
+		ETag string `xml:"ETag"`
+		Size int64  `xml:"Size"`
+	} `xml:"Contents"`
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(data))
+	return h.Sum(nil)
+}
+
+// signS3 signs request with AWS Signature Version 4 in Authorization header
+func signS3(r *http.Request, secretKey string) {
+	now := time.Now().UTC()
+	amzDate := now.Format("20060102T150405Z")
+	date := now.Format("20060102")
+	r.Header.Set("X-Amz-Date", amzDate)
+	r.Header.Set("X-Amz-Content-Sha256", "UNSIGNED-PAYLOAD")
+	signedHeaders := "host;x-amz-content-sha256;x-amz-date"
+	canonicalRequest := strings.Join([]string{
+		r.Method,
+		r.URL.EscapedPath(),
+		r.URL.Query().Encode(),
+		fmt.Sprintf("host:%s\nx-amz-content-sha256:UNSIGNED-PAYLOAD\nx-amz-date:%s\n", r.URL.Host, amzDate),
+		signedHeaders,
+		"UNSIGNED-PAYLOAD",
+	}, "\n")
+	scope := fmt.Sprintf("%s/%s/s3/aws4_request", date, testS3Region)
+	hash := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := strings.Join([]string{"AWS4-HMAC-SHA256", amzDate, scope, hex.EncodeToString(hash[:])}, "\n")
+	key := hmacSHA256([]byte("AWS4"+secretKey), date)
+	key = hmacSHA256(key, testS3Region)
+	key = hmacSHA256(key, "s3")
+	key = hmacSHA256(key, "aws4_request")
+	r.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		testS3AccessKey, scope, signedHeaders, hex.EncodeToString(hmacSHA256(key, stringToSign))))
+}
+
+func TestS3PresignedURL(t *testing.T) {
+	t.Parallel()
+
+	s, ts := newTestServer(t)
+	sha := addFile(t, s, "images/eve.img", "content of image")
+
+	resp, err := http.Get(ts.URL + "/admin/presign/images/eve.img?expires=10m")
+	assert.NoError(t, err)
+	presigned, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Contains(t, string(presigned), "X-Amz-Expires=600")
+
+	resp, err = http.Get(string(presigned))
+	assert.NoError(t, err)
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "content of image", string(body))
+	assert.Equal(t, fmt.Sprintf("%q", sha), resp.Header.Get("ETag"))
+
+	// signature covers path, so it cannot be used for another object
+	addFile(t, s, "other.img", "other")
+	resp, err = http.Get(strings.Replace(string(presigned), "/images/eve.img", "/other.img", 1))
+	assert.NoError(t, err)
+	body, _ = io.ReadAll(resp.Body)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+	assert.Contains(t, string(body), "<Code>AccessDenied</Code>")
+
+	for _, u := range []string{
+		"/admin/presign/missing.img",
+		"/admin/presign/images/eve.img?expires=0s",
+		"/admin/presign/images/eve.img?expires=1000h",
+	} {
+		resp, err = http.Get(ts.URL + u)
+		assert.NoError(t, err)
+		resp.Body.Close()
+		assert.Equal(t, http.StatusInternalServerError, resp.StatusCode, u)
+	}
+}
+
+func TestS3ListObjects(t *testing.T) {
+	t.Parallel()
+
+	s, ts := newTestServer(t)
+	sha := addFile(t, s, "images/eve.img", "content of image")
+	addFile(t, s, "config.json", "{}")
+
+	testMatrix := map[string]struct {
+		secretKey string
+		prefix    string
+		status    int
+		keys      []string
+	}{
+		"all objects": {
+			secretKey: testS3SecretKey,
+			status:    http.StatusOK,
+			keys:      []string{"config.json", "images/eve.img"},
+		},
+		"prefix": {
+			secretKey: testS3SecretKey,
+			prefix:    "images/",
+			status:    http.StatusOK,
+			keys:      []string{"images/eve.img"},
+		},
+		"wrong secret": {
+			secretKey: "wrong",
+			status:    http.StatusForbidden,
+		},
+	}
+
+	for name, test := range testMatrix {
+		query := url.Values{"list-type": []string{"2"}}
+		if test.prefix != "" {
+			query.Set("prefix", test.prefix)
+		}
+		req, err := http.NewRequest(http.MethodGet, ts.URL+"/eserver?"+query.Encode(), nil)
+		assert.NoError(t, err)
+		signS3(req, test.secretKey)
+		resp, err := http.DefaultClient.Do(req)
+		assert.NoError(t, err)
+		body, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		assert.Equal(t, test.status, resp.StatusCode, name)
+		if test.status != http.StatusOK {
+			continue
+		}
+		var result listBucketResult
+		assert.NoError(t, xml.Unmarshal(body, &result), name)
+		assert.Equal(t, len(test.keys), result.KeyCount, name)
+		var keys []string
+		for _, el := range result.Contents {
+			keys = append(keys, el.Key)
+			if el.Key == "images/eve.img" {
+				assert.Equal(t, fmt.Sprintf("%q", sha), el.ETag, name)
+				assert.Equal(t, int64(len("content of image")), el.Size, name)
+			}
+		}
+		assert.ElementsMatch(t, test.keys, keys, name)
+	}
+}
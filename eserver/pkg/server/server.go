@@ -1,28 +1,43 @@
 package server
 
 import (
+	"crypto/tls"
 	"fmt"
-	"log"
 	"net"
 	"net/http"
 
 	"github.com/lf-edge/eden/eserver/pkg/manager"
+	"github.com/lf-edge/eden/eserver/pkg/registry"
+	log "github.com/sirupsen/logrus"
 )
 
 //EServer stores info about settings
 type EServer struct {
-	Port     string
-	Address  string
-	Manager  *manager.EServerManager
-	User     string
-	Password string
-	ReadOnly bool
+	Port        string
+	Address     string
+	Manager     *manager.EServerManager
+	User        string
+	Password    string
+	ReadOnly    bool
+	S3Region    string
+	S3AccessKey string
+	S3SecretKey string
+	Registry    *registry.Store
+
+	TLSCert       string
+	TLSKey        string
+	TLSClientCA   string
+	TLSClientAuth string
 }
 
 // log the request and client
 func logRequest(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		log.Printf("requested %s", r.URL.Path)
+		log.WithFields(log.Fields{
+			"method": r.Method,
+			"path":   r.URL.Path,
+			"client": r.RemoteAddr,
+		}).Info("requested")
 		next.ServeHTTP(w, r)
 	})
 }
@@ -31,23 +46,47 @@ func logRequest(next http.Handler) http.Handler {
 //  /admin/list endpoint returns list of files
 //  /admin/add-from-url endpoint fires download
 //  /admin/status/{filename} returns fileinfo
-//  /eserver/{filename} returns file
+//  /admin/presign/{filename} returns signed S3 URL of file
+//  /admin/registry/gc removes blobs of registry not referenced by manifests
+//  /eserver/{filename} returns file, also served as object of bucket eserver with S3 API
+//  /v2/ serves OCI distribution API of registry
 func (s *EServer) Start() {
 
 	s.Manager.Init()
+	if err := s.Registry.Init(); err != nil {
+		log.WithError(err).Fatal("registry init error")
+	}
+
+	logger := log.WithFields(log.Fields{
+		"address":     fmt.Sprintf("%s:%s", s.Address, s.Port),
+		"dir":         s.Manager.Dir,
+		"s3Region":    s.S3Region,
+		"registryDir": s.Registry.Dir,
+	})
 
-	log.Println("Starting eserver:")
-	log.Printf("\tIP:Port: %s:%s\n", s.Address, s.Port)
-	log.Printf("\tDirectory: %s\n", s.Manager.Dir)
+	tlsConfig, err := s.tlsConfig()
+	if err != nil {
+		log.WithError(err).Fatal("TLS config error")
+	}
+	if tlsConfig != nil {
+		logger = logger.WithFields(log.Fields{
+			"tlsCert":       s.TLSCert,
+			"tlsClientAuth": s.TLSClientAuth,
+		})
+	}
+	logger.Info("Starting eserver")
 
 	// server both services (sftp and http) on the same port
 	l, err := net.Listen("tcp", fmt.Sprintf("%s:%s", s.Address, s.Port))
 	if err != nil {
-		log.Fatalf("net.Listen error: %s", err)
+		log.WithError(err).Fatal("net.Listen error")
 	}
 	sshListener, httpListener := MuxListener(l)
+	if tlsConfig != nil {
+		httpListener = tls.NewListener(httpListener, tlsConfig)
+	}
 	errorChan := make(chan error)
 	go s.serveHTTP(httpListener, errorChan)
 	go s.serveSFTP(sshListener, errorChan)
-	log.Println(<-errorChan)
+	log.WithError(<-errorChan).Error("eserver stopped")
 }
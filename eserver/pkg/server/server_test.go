@@ -0,0 +1,72 @@
+package server_test
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/lf-edge/eden/eserver/pkg/manager"
+	"github.com/lf-edge/eden/eserver/pkg/registry"
+	"github.com/lf-edge/eden/eserver/pkg/server"
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	testS3Region    = "us-east-1"
+	testS3AccessKey = "eden"
+	testS3SecretKey = "edensecret"
+)
+
+// newTestServer runs http endpoints of eserver with content in temporary directories
+func newTestServer(t *testing.T) (*server.EServer, *httptest.Server) {
+	s := &server.EServer{
+		S3Region:    testS3Region,
+		S3AccessKey: testS3AccessKey,
+		S3SecretKey: testS3SecretKey,
+		Manager:     &manager.EServerManager{Dir: t.TempDir()},
+		Registry:    &registry.Store{Dir: t.TempDir()},
+	}
+	s.Manager.Init()
+	assert.NoError(t, s.Registry.Init())
+	ts := httptest.NewServer(s.Router())
+	t.Cleanup(ts.Close)
+	return s, ts
+}
+
+// addFile stores file in eserver as it is done after download and returns its sha256
+func addFile(t *testing.T, s *server.EServer, name, content string) string {
+	sum := sha256.Sum256([]byte(content))
+	sha := hex.EncodeToString(sum[:])
+	filePath := filepath.Join(s.Manager.Dir, name)
+	assert.NoError(t, os.MkdirAll(filepath.Dir(filePath), 0755))
+	assert.NoError(t, os.WriteFile(filePath, []byte(content), 0644))
+	assert.NoError(t, os.WriteFile(filePath+".sha256", []byte(sha), 0644))
+	return sha
+}
+
+// doRequest sends request with body and returns response with its body
+func doRequest(t *testing.T, method, u, mediaType, body string) (*http.Response, string) {
+	req, err := http.NewRequest(method, u, strings.NewReader(body))
+	assert.NoError(t, err)
+	if mediaType != "" {
+		req.Header.Set("Content-Type", mediaType)
+	}
+	return doRequestWith(t, req)
+}
+
+// doRequestWith sends prepared request and returns response with its body
+func doRequestWith(t *testing.T, req *http.Request) (*http.Response, string) {
+	resp, err := http.DefaultClient.Do(req)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	defer resp.Body.Close()
+	out, _ := io.ReadAll(resp.Body)
+	return resp, string(out)
+}
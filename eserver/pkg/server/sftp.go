@@ -3,11 +3,11 @@ package server
 import (
 	"fmt"
 	"io"
-	"log"
 	"net"
 	"os"
 
 	"github.com/pkg/sftp"
+	log "github.com/sirupsen/logrus"
 	"golang.org/x/crypto/ssh"
 )
 
@@ -15,7 +15,7 @@ func (s *EServer) serveSFTP(listener net.Listener, errorChan chan error) {
 	config := &ssh.ServerConfig{
 		PasswordCallback: func(c ssh.ConnMetadata, pass []byte) (*ssh.Permissions, error) {
 			if c.User() == s.User && string(pass) == s.Password {
-				log.Printf("serveSFTP: login: %s\n", c.User())
+				log.WithFields(log.Fields{"user": c.User(), "client": c.RemoteAddr()}).Info("serveSFTP: login")
 				return nil, nil
 			}
 			return nil, fmt.Errorf("serveSFTP: password rejected for %q", c.User())
@@ -47,13 +47,14 @@ func (s *EServer) serveSFTP(listener net.Listener, errorChan chan error) {
 	for {
 		nConn, err := listener.Accept()
 		if err != nil {
-			log.Printf("serveSFTP: failed to accept incoming connection: %s\n", err)
+			log.WithError(err).Error("serveSFTP: failed to accept incoming connection")
 			continue
 		}
 		go func(conn net.Conn) {
-			_, channel, reqs, err := ssh.NewServerConn(nConn, config)
+			logger := log.WithField("client", conn.RemoteAddr())
+			_, channel, reqs, err := ssh.NewServerConn(conn, config)
 			if err != nil {
-				log.Printf("serveSFTP: failed to handshake: %s\n", err)
+				logger.WithError(err).Warn("serveSFTP: failed to handshake")
 				return
 			}
 
@@ -64,15 +65,15 @@ func (s *EServer) serveSFTP(listener net.Listener, errorChan chan error) {
 			for newChannel := range channel {
 				if newChannel.ChannelType() != "session" {
 					if err := newChannel.Reject(ssh.UnknownChannelType, "unknown channel type"); err != nil {
-						log.Println("serveSFTP: could not reject channel.", err)
+						logger.WithError(err).Error("serveSFTP: could not reject channel")
 						return
 					}
-					log.Printf("serveSFTP: unknown channel type: %s\n", newChannel.ChannelType())
+					logger.WithField("type", newChannel.ChannelType()).Warn("serveSFTP: unknown channel type")
 					continue
 				}
 				channel, requests, err := newChannel.Accept()
 				if err != nil {
-					log.Println("serveSFTP: could not accept channel.", err)
+					logger.WithError(err).Error("serveSFTP: could not accept channel")
 					return
 				}
 
@@ -86,7 +87,7 @@ func (s *EServer) serveSFTP(listener net.Listener, errorChan chan error) {
 							}
 						}
 						if err := req.Reply(ok, nil); err != nil {
-							log.Println("serveSFTP: cannot reply:", err)
+							logger.WithError(err).Error("serveSFTP: cannot reply")
 							return
 						}
 					}
@@ -97,16 +98,16 @@ func (s *EServer) serveSFTP(listener net.Listener, errorChan chan error) {
 					serverOptions...,
 				)
 				if err != nil {
-					log.Printf("serveSFTP: NewServer error: %s\n", err)
+					logger.WithError(err).Error("serveSFTP: NewServer error")
 					return
 				}
 				if err := server.Serve(); err == io.EOF {
 					if err := server.Close(); err != nil {
-						log.Printf("serveSFTP: cannot close server: %s\n", err)
+						logger.WithError(err).Error("serveSFTP: cannot close server")
 					}
-					log.Println("serveSFTP: sftp client exited session.")
+					logger.Info("serveSFTP: sftp client exited session")
 				} else if err != nil {
-					log.Printf("serveSFTP: sftp server completed with error: %s\n", err)
+					logger.WithError(err).Error("serveSFTP: sftp server completed with error")
 				}
 			}
 		}(nConn)
@@ -0,0 +1,124 @@
+package server
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// certReloader loads certificate of server and reloads it after change of files,
+// so certificate may be rotated without restart of eserver
+type certReloader struct {
+	certFile string
+	keyFile  string
+
+	mu      sync.Mutex
+	cert    *tls.Certificate
+	modTime time.Time
+}
+
+func (r *certReloader) getCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	info, err := os.Stat(r.certFile)
+	if err != nil {
+		return nil, err
+	}
+	if r.cert != nil && !info.ModTime().After(r.modTime) {
+		return r.cert, nil
+	}
+	cert, err := tls.LoadX509KeyPair(r.certFile, r.keyFile)
+	if err != nil {
+		if r.cert != nil {
+			// files may be in the middle of update, use previous certificate
+			log.WithError(err).WithField("cert", r.certFile).Warn("cannot reload certificate")
+			return r.cert, nil
+		}
+		return nil, err
+	}
+	if r.cert != nil {
+		log.WithField("cert", r.certFile).Info("certificate reloaded")
+	}
+	r.cert, r.modTime = &cert, info.ModTime()
+	return r.cert, nil
+}
+
+// clientAuthTypes maps values of --tls-client-auth.
+// Certificates of clients are verified in verifyClientCert
+var clientAuthTypes = map[string]tls.ClientAuthType{
+	"none":     tls.NoClientCert,
+	"optional": tls.RequestClientCert,
+	"require":  tls.RequireAnyClientCert,
+}
+
+// verifyClientCert verifies certificate of client against roots.
+// Root certificate of eden limits extended key usage to server auth,
+// so we cannot rely on verification of client auth usage inside crypto/tls
+func verifyClientCert(roots *x509.CertPool) func([][]byte, [][]*x509.Certificate) error {
+	return func(rawCerts [][]byte, _ [][]*x509.Certificate) error {
+		if len(rawCerts) == 0 {
+			// no certificate in optional mode
+			return nil
+		}
+		certs := make([]*x509.Certificate, 0, len(rawCerts))
+		for _, raw := range rawCerts {
+			cert, err := x509.ParseCertificate(raw)
+			if err != nil {
+				return fmt.Errorf("cannot parse certificate of client: %w", err)
+			}
+			certs = append(certs, cert)
+		}
+		opts := x509.VerifyOptions{
+			Roots:         roots,
+			Intermediates: x509.NewCertPool(),
+			KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageAny},
+		}
+		for _, cert := range certs[1:] {
+			opts.Intermediates.AddCert(cert)
+		}
+		if _, err := certs[0].Verify(opts); err != nil {
+			return fmt.Errorf("cannot verify certificate of client: %w", err)
+		}
+		return nil
+	}
+}
+
+// tlsConfig returns configuration of TLS for http listener or nil if TLS is not enabled
+func (s *EServer) tlsConfig() (*tls.Config, error) {
+	if s.TLSCert == "" {
+		return nil, nil
+	}
+	reloader := &certReloader{certFile: s.TLSCert, keyFile: s.TLSKey}
+	if _, err := reloader.getCertificate(nil); err != nil {
+		return nil, fmt.Errorf("cannot load certificate: %w", err)
+	}
+	clientAuth, ok := clientAuthTypes[s.TLSClientAuth]
+	if !ok {
+		return nil, fmt.Errorf("unknown client auth %q", s.TLSClientAuth)
+	}
+	cfg := &tls.Config{
+		GetCertificate: reloader.getCertificate,
+		ClientAuth:     clientAuth,
+		MinVersion:     tls.VersionTLS12,
+	}
+	if clientAuth != tls.NoClientCert {
+		if s.TLSClientCA == "" {
+			return nil, fmt.Errorf("CA of clients is required to verify their certificates")
+		}
+		caPEM, err := os.ReadFile(s.TLSClientCA)
+		if err != nil {
+			return nil, err
+		}
+		cfg.ClientCAs = x509.NewCertPool()
+		if !cfg.ClientCAs.AppendCertsFromPEM(caPEM) {
+			return nil, fmt.Errorf("no certificates in %s", s.TLSClientCA)
+		}
+		cfg.VerifyPeerCertificate = verifyClientCert(cfg.ClientCAs)
+	}
+	return cfg, nil
+}
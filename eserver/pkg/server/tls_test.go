@@ -0,0 +1,163 @@
+package server
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// testCert is certificate with key signed by parent, self-signed if parent is nil
+type testCert struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+}
+
+func newTestCert(t *testing.T, parent *testCert, template *x509.Certificate) *testCert {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template.SerialNumber = big.NewInt(time.Now().UnixNano())
+	template.NotBefore = time.Now().Add(-time.Hour)
+	template.NotAfter = time.Now().Add(time.Hour)
+	signer := &testCert{cert: template, key: key}
+	if parent != nil {
+		signer = parent
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, signer.cert, &key.PublicKey, signer.key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &testCert{cert: cert, key: key}
+}
+
+func newTestCA(t *testing.T, name string) *testCert {
+	return newTestCert(t, nil, &x509.Certificate{
+		Subject:               pkix.Name{CommonName: name},
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageDigitalSignature,
+		// as root certificate of eden, it does not allow client auth
+		ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	})
+}
+
+func (c *testCert) pemFiles(t *testing.T, dir, name string) (certFile, keyFile string) {
+	certFile, keyFile = filepath.Join(dir, name+".pem"), filepath.Join(dir, name+".key")
+	keyDER, err := x509.MarshalECPrivateKey(c.key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.NoError(t, os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: c.cert.Raw}), 0644))
+	assert.NoError(t, os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0600))
+	return certFile, keyFile
+}
+
+func (c *testCert) tlsCertificate() tls.Certificate {
+	return tls.Certificate{Certificate: [][]byte{c.cert.Raw}, PrivateKey: c.key}
+}
+
+func TestTLSClientAuth(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	root := newTestCA(t, "eden root")
+	rootFile, _ := root.pemFiles(t, dir, "root-certificate")
+	serverCert := newTestCert(t, root, &x509.Certificate{
+		Subject:     pkix.Name{CommonName: "eserver"},
+		DNSNames:    []string{"eserver.local"},
+		ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	})
+	serverCertFile, serverKeyFile := serverCert.pemFiles(t, dir, "eserver")
+	// certificate of datastore used by EVE to access eserver
+	datastoreCert := newTestCert(t, root, &x509.Certificate{
+		Subject:     pkix.Name{CommonName: "eden"},
+		ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	})
+	foreignCert := newTestCert(t, newTestCA(t, "foreign root"), &x509.Certificate{
+		Subject:     pkix.Name{CommonName: "eden"},
+		ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	})
+
+	roots := x509.NewCertPool()
+	roots.AddCert(root.cert)
+	get := func(ts *httptest.Server, clientCert *testCert) error {
+		// httptest adds its own certificate, it is not used with SNI
+		clientTLS := &tls.Config{RootCAs: roots, ServerName: "eserver.local", MinVersion: tls.VersionTLS12}
+		if clientCert != nil {
+			// send certificate even if it is not signed by CA server accepts
+			clientTLS.GetClientCertificate = func(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+				cert := clientCert.tlsCertificate()
+				return &cert, nil
+			}
+		}
+		client := &http.Client{Transport: &http.Transport{TLSClientConfig: clientTLS}, Timeout: 10 * time.Second}
+		resp, err := client.Get(ts.URL)
+		if err != nil {
+			return err
+		}
+		resp.Body.Close()
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		return nil
+	}
+
+	testMatrix := map[string]struct {
+		clientAuth string
+		accepted   map[string]*testCert
+		rejected   map[string]*testCert
+	}{
+		"none": {
+			clientAuth: "none",
+			accepted:   map[string]*testCert{"no cert": nil, "datastore cert": datastoreCert},
+		},
+		"optional": {
+			clientAuth: "optional",
+			accepted:   map[string]*testCert{"no cert": nil, "datastore cert": datastoreCert},
+			rejected:   map[string]*testCert{"foreign cert": foreignCert},
+		},
+		"require": {
+			clientAuth: "require",
+			accepted:   map[string]*testCert{"datastore cert": datastoreCert},
+			rejected:   map[string]*testCert{"no cert": nil, "foreign cert": foreignCert},
+		},
+	}
+	for name, tt := range testMatrix {
+		s := &EServer{TLSCert: serverCertFile, TLSKey: serverKeyFile, TLSClientCA: rootFile, TLSClientAuth: tt.clientAuth}
+		tlsConfig, err := s.tlsConfig()
+		if !assert.NoError(t, err, name) {
+			continue
+		}
+		ts := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+		ts.TLS = tlsConfig
+		ts.StartTLS()
+		for client, cert := range tt.accepted {
+			assert.NoError(t, get(ts, cert), "%s: %s", name, client)
+		}
+		for client, cert := range tt.rejected {
+			assert.Error(t, get(ts, cert), "%s: %s", name, client)
+		}
+		ts.Close()
+	}
+
+	_, err := (&EServer{TLSCert: serverCertFile, TLSKey: serverKeyFile, TLSClientAuth: "require"}).tlsConfig()
+	assert.Error(t, err, "CA of clients is required")
+	_, err = (&EServer{TLSCert: serverCertFile, TLSKey: serverKeyFile, TLSClientAuth: "always"}).tlsConfig()
+	assert.Error(t, err)
+}
@@ -0,0 +1,167 @@
+// Package apicoverage records which fields of EVE API messages are exercised by tests
+// and reports fields which are not covered by any test.
+package apicoverage
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/lf-edge/eden/pkg/defaults"
+	log "github.com/sirupsen/logrus"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// maxDepth limits nesting of fields, messages of EVE API are not deeper
+const maxDepth = 16
+
+var (
+	mu   sync.Mutex
+	seen = map[string]bool{}
+)
+
+// Record saves paths of populated fields of msg into directory from
+// defaults.DefaultAPICoverageEnv (EDEN_API_COVERAGE) env. var., it does nothing if it is not set
+func Record(msg proto.Message) {
+	dir := os.Getenv(defaults.DefaultAPICoverageEnv)
+	if dir == "" || msg == nil {
+		return
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	var fresh []string
+	for _, path := range FieldPaths(msg) {
+		if !seen[path] {
+			seen[path] = true
+			fresh = append(fresh, path)
+		}
+	}
+	if len(fresh) == 0 {
+		return
+	}
+	// every process writes its own file, so they do not mix lines
+	file := filepath.Join(dir, fmt.Sprintf("%d.cov", os.Getpid()))
+	f, err := os.OpenFile(file, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		log.Debugf("cannot record API coverage: %s", err)
+		return
+	}
+	defer f.Close()
+	if _, err := fmt.Fprintln(f, strings.Join(fresh, "\n")); err != nil {
+		log.Debugf("cannot record API coverage: %s", err)
+	}
+}
+
+// leaf checks if fields of message are not interesting for coverage, like in google.protobuf.Timestamp
+func leaf(desc protoreflect.MessageDescriptor) bool {
+	return strings.HasPrefix(string(desc.FullName()), "google.protobuf.")
+}
+
+// FieldPaths returns paths of populated fields of msg in format Message.field.subfield
+func FieldPaths(msg proto.Message) []string {
+	m := msg.ProtoReflect()
+	paths := map[string]bool{}
+	var walk func(m protoreflect.Message, prefix string, depth int)
+	walk = func(m protoreflect.Message, prefix string, depth int) {
+		if depth > maxDepth || leaf(m.Descriptor()) {
+			return
+		}
+		m.Range(func(fd protoreflect.FieldDescriptor, v protoreflect.Value) bool {
+			path := prefix + "." + string(fd.Name())
+			paths[path] = true
+			switch {
+			case fd.IsMap():
+				if fd.MapValue().Message() != nil {
+					v.Map().Range(func(_ protoreflect.MapKey, mv protoreflect.Value) bool {
+						walk(mv.Message(), path, depth+1)
+						return true
+					})
+				}
+			case fd.IsList():
+				if fd.Message() != nil {
+					for i := 0; i < v.List().Len(); i++ {
+						walk(v.List().Get(i).Message(), path, depth+1)
+					}
+				}
+			case fd.Message() != nil:
+				walk(v.Message(), path, depth+1)
+			}
+			return true
+		})
+	}
+	walk(m, string(m.Descriptor().Name()), 0)
+	return sortedKeys(paths)
+}
+
+// AllFieldPaths returns paths of all fields of message in format Message.field.subfield,
+// recursive messages are expanded once
+func AllFieldPaths(desc protoreflect.MessageDescriptor) []string {
+	paths := map[string]bool{}
+	var walk func(desc protoreflect.MessageDescriptor, prefix string, parents map[protoreflect.FullName]bool)
+	walk = func(desc protoreflect.MessageDescriptor, prefix string, parents map[protoreflect.FullName]bool) {
+		if len(parents) > maxDepth || leaf(desc) || parents[desc.FullName()] {
+			return
+		}
+		parents[desc.FullName()] = true
+		defer delete(parents, desc.FullName())
+		fields := desc.Fields()
+		for i := 0; i < fields.Len(); i++ {
+			fd := fields.Get(i)
+			path := prefix + "." + string(fd.Name())
+			paths[path] = true
+			sub := fd.Message()
+			if fd.IsMap() {
+				sub = fd.MapValue().Message()
+			}
+			if sub != nil {
+				walk(sub, path, parents)
+			}
+		}
+	}
+	walk(desc, string(desc.Name()), map[protoreflect.FullName]bool{})
+	return sortedKeys(paths)
+}
+
+// ReadPaths reads paths recorded by all processes into directory
+func ReadPaths(dir string) ([]string, error) {
+	files, err := filepath.Glob(filepath.Join(dir, "*.cov"))
+	if err != nil {
+		return nil, err
+	}
+	paths := map[string]bool{}
+	for _, file := range files {
+		if err := readPathsFile(file, paths); err != nil {
+			return nil, err
+		}
+	}
+	return sortedKeys(paths), nil
+}
+
+func readPathsFile(file string, paths map[string]bool) error {
+	f, err := os.Open(file)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		if line := strings.TrimSpace(scanner.Text()); line != "" {
+			paths[line] = true
+		}
+	}
+	return scanner.Err()
+}
+
+func sortedKeys(m map[string]bool) []string {
+	result := make([]string, 0, len(m))
+	for k := range m {
+		result = append(result, k)
+	}
+	sort.Strings(result)
+	return result
+}
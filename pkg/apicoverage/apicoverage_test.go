@@ -0,0 +1,57 @@
+package apicoverage_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/lf-edge/eden/pkg/apicoverage"
+	"github.com/lf-edge/eden/pkg/defaults"
+	"github.com/lf-edge/eve-api/go/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFieldPaths(t *testing.T) {
+	t.Parallel()
+
+	dConfig := &config.EdgeDevConfig{
+		Apps: []*config.AppInstanceConfig{{
+			Displayname:    "app",
+			Fixedresources: &config.VmConfig{Memory: 1024},
+		}},
+	}
+	assert.Equal(t, []string{
+		"EdgeDevConfig.apps",
+		"EdgeDevConfig.apps.displayname",
+		"EdgeDevConfig.apps.fixedresources",
+		"EdgeDevConfig.apps.fixedresources.memory",
+	}, apicoverage.FieldPaths(dConfig))
+
+	all := apicoverage.AllFieldPaths(dConfig.ProtoReflect().Descriptor())
+	assert.Contains(t, all, "EdgeDevConfig.apps.fixedresources.memory")
+	assert.Contains(t, all, "EdgeDevConfig.apps.cipherData.cipherContextId")
+}
+
+func TestReport(t *testing.T) {
+	root := t.TempDir()
+	dir := apicoverage.TestDir(root, "TestEdenScripts/disk")
+	assert.NoError(t, os.MkdirAll(dir, 0755))
+	t.Setenv(defaults.DefaultAPICoverageEnv, dir)
+
+	apicoverage.Record(&config.EdgeDevConfig{Apps: []*config.AppInstanceConfig{{Displayname: "app"}}})
+	files, err := filepath.Glob(filepath.Join(dir, "*.cov"))
+	assert.NoError(t, err)
+	assert.Len(t, files, 1)
+
+	report, err := apicoverage.ReadReport(root)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"EdgeDevConfig.apps", "EdgeDevConfig.apps.displayname"}, report.Tests["TestEdenScripts/disk"])
+	for _, c := range report.Coverage() {
+		if c.Message == "EdgeDevConfig" {
+			assert.Equal(t, []string{"TestEdenScripts/disk"}, c.Covered["EdgeDevConfig.apps.displayname"])
+			assert.Len(t, c.Covered, 2)
+			assert.Equal(t, c.Total, len(c.Covered)+len(c.Uncovered))
+		}
+	}
+	assert.NoError(t, report.PrintSummary(os.Stdout))
+}
@@ -0,0 +1,144 @@
+package apicoverage
+
+import (
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/lf-edge/eve-api/go/config"
+	"github.com/lf-edge/eve-api/go/info"
+	"github.com/lf-edge/eve-api/go/metrics"
+	"google.golang.org/protobuf/proto"
+)
+
+// Messages are root messages of EVE API included into report
+var Messages = []proto.Message{&config.EdgeDevConfig{}, &info.ZInfoMsg{}, &metrics.ZMetricMsg{}}
+
+// TestDir returns directory inside root to record coverage of the test
+func TestDir(root, test string) string {
+	return filepath.Join(root, url.PathEscape(test))
+}
+
+// Report contains fields of EVE API covered by tests
+type Report struct {
+	// Tests maps name of test to paths of fields exercised by it
+	Tests map[string][]string
+}
+
+// ReadReport reads coverage recorded into root, every test records into its TestDir
+func ReadReport(root string) (*Report, error) {
+	entries, err := os.ReadDir(root)
+	if err != nil {
+		return nil, err
+	}
+	report := &Report{Tests: map[string][]string{}}
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		test, err := url.PathUnescape(entry.Name())
+		if err != nil {
+			return nil, fmt.Errorf("unexpected directory %s: %w", entry.Name(), err)
+		}
+		paths, err := ReadPaths(filepath.Join(root, entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+		report.Tests[test] = paths
+	}
+	return report, nil
+}
+
+// MessageCoverage is coverage of fields of one message
+type MessageCoverage struct {
+	Message string
+	Total   int
+	// Covered maps path of field to tests exercised it
+	Covered   map[string][]string
+	Uncovered []string
+}
+
+// Percent returns part of covered fields
+func (c MessageCoverage) Percent() float64 {
+	if c.Total == 0 {
+		return 0
+	}
+	return 100 * float64(len(c.Covered)) / float64(c.Total)
+}
+
+// Coverage calculates coverage of every message from Messages
+func (r *Report) Coverage() []MessageCoverage {
+	covered := map[string][]string{}
+	for test, paths := range r.Tests {
+		for _, path := range paths {
+			covered[path] = append(covered[path], test)
+		}
+	}
+	var result []MessageCoverage
+	for _, msg := range Messages {
+		desc := msg.ProtoReflect().Descriptor()
+		c := MessageCoverage{Message: string(desc.Name()), Covered: map[string][]string{}}
+		for _, path := range AllFieldPaths(desc) {
+			c.Total++
+			if tests, ok := covered[path]; ok {
+				sort.Strings(tests)
+				c.Covered[path] = tests
+			} else {
+				c.Uncovered = append(c.Uncovered, path)
+			}
+		}
+		result = append(result, c)
+	}
+	return result
+}
+
+// PrintSummary prints coverage of every message and number of fields exercised by every test
+func (r *Report) PrintSummary(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 8, 1, '\t', 0)
+	fmt.Fprintln(tw, "MESSAGE\tFIELDS\tCOVERED\tCOVERAGE")
+	for _, c := range r.Coverage() {
+		fmt.Fprintf(tw, "%s\t%d\t%d\t%.1f%%\n", c.Message, c.Total, len(c.Covered), c.Percent())
+	}
+	fmt.Fprintln(tw)
+	fmt.Fprintln(tw, "TEST\tFIELDS")
+	tests := make([]string, 0, len(r.Tests))
+	for test := range r.Tests {
+		tests = append(tests, test)
+	}
+	sort.Strings(tests)
+	for _, test := range tests {
+		fmt.Fprintf(tw, "%s\t%d\n", test, len(r.Tests[test]))
+	}
+	return tw.Flush()
+}
+
+// Write writes full report with tests exercised every field and fields without coverage
+func (r *Report) Write(w io.Writer) error {
+	for _, c := range r.Coverage() {
+		if _, err := fmt.Fprintf(w, "# %s: %d of %d fields covered (%.1f%%)\n",
+			c.Message, len(c.Covered), c.Total, c.Percent()); err != nil {
+			return err
+		}
+		paths := make([]string, 0, len(c.Covered))
+		for path := range c.Covered {
+			paths = append(paths, path)
+		}
+		sort.Strings(paths)
+		for _, path := range paths {
+			if _, err := fmt.Fprintf(w, "+ %s\t%s\n", path, strings.Join(c.Covered[path], ",")); err != nil {
+				return err
+			}
+		}
+		for _, path := range c.Uncovered {
+			if _, err := fmt.Fprintf(w, "- %s\n", path); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,172 @@
+// Package benchmark measures time of deployment of apps on EVE, from push of config
+// to the controller until the app is running, from info messages of the device.
+package benchmark
+
+import (
+	"time"
+
+	"github.com/lf-edge/eve-api/go/info"
+)
+
+// Phase is a step of deployment of app
+type Phase string
+
+// phases of deployment in order of their completion
+const (
+	// PhaseConfigReceived is the first info of app, EVE got config with it
+	PhaseConfigReceived Phase = "config-received"
+	// PhaseDownloadStarted is the start of download of the first content tree of app
+	PhaseDownloadStarted Phase = "download-started"
+	// PhaseDownloaded is completion of download of all content trees of app
+	PhaseDownloaded Phase = "downloaded"
+	// PhaseVolumeCreated is completion of creation of all volumes of app
+	PhaseVolumeCreated Phase = "volume-created"
+	// PhaseRunning is the first report of app in RUNNING state
+	PhaseRunning Phase = "running"
+)
+
+// Phases lists all phases in order of their completion
+var Phases = []Phase{PhaseConfigReceived, PhaseDownloadStarted, PhaseDownloaded, PhaseVolumeCreated, PhaseRunning}
+
+// Run is one deployment of app
+type Run struct {
+	Iteration int    `json:"iteration"`
+	App       string `json:"app"`
+	// Start is time of push of config with app to the controller
+	Start time.Time `json:"start"`
+	// Phases are timestamps of info messages in which EVE reported completion of phases,
+	// download-started is missing if content was already in EVE
+	Phases map[Phase]time.Time `json:"phases"`
+	Error  string              `json:"error,omitempty"`
+}
+
+// Duration returns time from start of run until completion of phase
+func (r *Run) Duration(phase Phase) (time.Duration, bool) {
+	ts, ok := r.Phases[phase]
+	if !ok {
+		return 0, false
+	}
+	return ts.Sub(r.Start), true
+}
+
+// downloadedStates are states of content tree reported after its download
+var downloadedStates = map[info.ZSwState]bool{
+	info.ZSwState_DOWNLOADED: true,
+	info.ZSwState_VERIFYING:  true,
+	info.ZSwState_VERIFIED:   true,
+	info.ZSwState_LOADING:    true,
+	info.ZSwState_LOADED:     true,
+	info.ZSwState_DELIVERED:  true,
+	info.ZSwState_INSTALLED:  true,
+}
+
+// Tracker fills phases of run from info messages about app and its volumes and content trees
+type Tracker struct {
+	run   *Run
+	appID string
+	// contentTrees and volumes keep time when every object completed its phase
+	contentTrees map[string]time.Time
+	volumes      map[string]time.Time
+}
+
+// NewTracker returns tracker of deployment of app with appID and its volumes and content trees
+func NewTracker(run *Run, appID string, volumeIDs, contentTreeIDs []string) *Tracker {
+	if run.Phases == nil {
+		run.Phases = map[Phase]time.Time{}
+	}
+	t := &Tracker{
+		run:          run,
+		appID:        appID,
+		contentTrees: map[string]time.Time{},
+		volumes:      map[string]time.Time{},
+	}
+	for _, id := range contentTreeIDs {
+		t.contentTrees[id] = time.Time{}
+	}
+	for _, id := range volumeIDs {
+		t.volumes[id] = time.Time{}
+	}
+	return t
+}
+
+// set records the first time of phase
+func (t *Tracker) set(phase Phase, ts time.Time) {
+	if _, ok := t.run.Phases[phase]; !ok {
+		t.run.Phases[phase] = ts
+	}
+}
+
+// complete records completion of object and returns time of completion of all objects
+func complete(objects map[string]time.Time, id string, ts time.Time) (time.Time, bool) {
+	if done, ok := objects[id]; !ok || !done.IsZero() {
+		return time.Time{}, false
+	}
+	objects[id] = ts
+	var last time.Time
+	for _, done := range objects {
+		if done.IsZero() {
+			return time.Time{}, false
+		}
+		if done.After(last) {
+			last = done
+		}
+	}
+	return last, true
+}
+
+// Process updates phases with info message, it returns true when app is running
+// or failed with error reported in run
+func (t *Tracker) Process(im *info.ZInfoMsg) bool {
+	ts := im.GetAtTimeStamp().AsTime()
+	switch im.GetZtype() {
+	case info.ZInfoTypes_ZiApp:
+		ainfo := im.GetAinfo()
+		if ainfo.GetAppID() != t.appID {
+			return false
+		}
+		t.set(PhaseConfigReceived, ts)
+		if len(ainfo.GetAppErr()) > 0 || ainfo.GetState() == info.ZSwState_ERROR {
+			t.run.Error = ainfo.GetState().String()
+			for _, appErr := range ainfo.GetAppErr() {
+				t.run.Error += ": " + appErr.GetDescription()
+			}
+			return true
+		}
+		if ainfo.GetState() == info.ZSwState_RUNNING {
+			t.set(PhaseRunning, ts)
+			return true
+		}
+	case info.ZInfoTypes_ZiContentTree:
+		cinfo := im.GetCinfo()
+		if _, ok := t.contentTrees[cinfo.GetUuid()]; !ok {
+			return false
+		}
+		if cinfo.GetErr() != nil {
+			t.run.Error = "content tree: " + cinfo.GetErr().GetDescription()
+			return true
+		}
+		if cinfo.GetState() == info.ZSwState_DOWNLOAD_STARTED {
+			t.set(PhaseDownloadStarted, ts)
+		}
+		if downloadedStates[cinfo.GetState()] {
+			if last, ok := complete(t.contentTrees, cinfo.GetUuid(), ts); ok {
+				t.set(PhaseDownloaded, last)
+			}
+		}
+	case info.ZInfoTypes_ZiVolume:
+		vinfo := im.GetVinfo()
+		if _, ok := t.volumes[vinfo.GetUuid()]; !ok {
+			return false
+		}
+		if vinfo.GetVolumeErr() != nil {
+			t.run.Error = "volume: " + vinfo.GetVolumeErr().GetDescription()
+			return true
+		}
+		if vinfo.GetState() == info.ZSwState_CREATED_VOLUME {
+			if last, ok := complete(t.volumes, vinfo.GetUuid(), ts); ok {
+				t.set(PhaseVolumeCreated, last)
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,106 @@
+package benchmark_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/lf-edge/eden/pkg/benchmark"
+	"github.com/lf-edge/eve-api/go/info"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func infoMsg(ts time.Time, ztype info.ZInfoTypes) *info.ZInfoMsg {
+	return &info.ZInfoMsg{Ztype: ztype, AtTimeStamp: timestamppb.New(ts)}
+}
+
+func appInfo(ts time.Time, id string, state info.ZSwState) *info.ZInfoMsg {
+	msg := infoMsg(ts, info.ZInfoTypes_ZiApp)
+	msg.InfoContent = &info.ZInfoMsg_Ainfo{Ainfo: &info.ZInfoApp{AppID: id, State: state}}
+	return msg
+}
+
+func contentTreeInfo(ts time.Time, id string, state info.ZSwState) *info.ZInfoMsg {
+	msg := infoMsg(ts, info.ZInfoTypes_ZiContentTree)
+	msg.InfoContent = &info.ZInfoMsg_Cinfo{Cinfo: &info.ZInfoContentTree{Uuid: id, State: state}}
+	return msg
+}
+
+func volumeInfo(ts time.Time, id string, state info.ZSwState) *info.ZInfoMsg {
+	msg := infoMsg(ts, info.ZInfoTypes_ZiVolume)
+	msg.InfoContent = &info.ZInfoMsg_Vinfo{Vinfo: &info.ZInfoVolume{Uuid: id, State: state}}
+	return msg
+}
+
+func TestTracker(t *testing.T) {
+	t.Parallel()
+
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	at := func(seconds int) time.Time { return start.Add(time.Duration(seconds) * time.Second) }
+	run := &benchmark.Run{Start: start}
+	tracker := benchmark.NewTracker(run, "app", []string{"v1", "v2"}, []string{"ct"})
+	for _, msg := range []*info.ZInfoMsg{
+		appInfo(at(1), "other", info.ZSwState_RUNNING),
+		appInfo(at(2), "app", info.ZSwState_INITIAL),
+		contentTreeInfo(at(3), "ct", info.ZSwState_DOWNLOAD_STARTED),
+		contentTreeInfo(at(5), "ct", info.ZSwState_VERIFIED),
+		volumeInfo(at(6), "v1", info.ZSwState_CREATED_VOLUME),
+		contentTreeInfo(at(7), "ct", info.ZSwState_LOADED),
+		volumeInfo(at(8), "v2", info.ZSwState_CREATED_VOLUME),
+		appInfo(at(9), "app", info.ZSwState_BOOTING),
+	} {
+		assert.False(t, tracker.Process(msg))
+	}
+	assert.True(t, tracker.Process(appInfo(at(10), "app", info.ZSwState_RUNNING)))
+	expected := map[benchmark.Phase]int{
+		benchmark.PhaseConfigReceived:  2,
+		benchmark.PhaseDownloadStarted: 3,
+		benchmark.PhaseDownloaded:      5,
+		benchmark.PhaseVolumeCreated:   8,
+		benchmark.PhaseRunning:         10,
+	}
+	for phase, seconds := range expected {
+		d, ok := run.Duration(phase)
+		assert.True(t, ok, phase)
+		assert.Equal(t, time.Duration(seconds)*time.Second, d, phase)
+	}
+	assert.Empty(t, run.Error)
+}
+
+func TestReport(t *testing.T) {
+	t.Parallel()
+
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	newReport := func(running ...int) *benchmark.Report {
+		report := &benchmark.Report{EVETag: "0.0.0"}
+		for i, seconds := range running {
+			report.Runs = append(report.Runs, &benchmark.Run{Iteration: i + 1, Start: start,
+				Phases: map[benchmark.Phase]time.Time{benchmark.PhaseRunning: start.Add(time.Duration(seconds) * time.Second)}})
+		}
+		report.Runs = append(report.Runs, &benchmark.Run{Iteration: len(running) + 1, Start: start, Error: "ERROR"})
+		report.Summarize()
+		return report
+	}
+	base := newReport(10, 30, 20)
+	summary, ok := base.PhaseSummary(benchmark.PhaseRunning)
+	assert.True(t, ok)
+	assert.Equal(t, benchmark.PhaseSummary{Phase: benchmark.PhaseRunning, Count: 3, Min: 10, Median: 20, Mean: 20, Max: 30}, summary)
+
+	var csv bytes.Buffer
+	assert.NoError(t, base.WriteCSV(&csv))
+	lines := strings.Split(strings.TrimSpace(csv.String()), "\n")
+	assert.Len(t, lines, 5)
+	assert.True(t, strings.HasSuffix(lines[1], ",10.000,"))
+	assert.True(t, strings.HasSuffix(lines[4], ",ERROR"))
+
+	for _, delta := range benchmark.Compare(base, newReport(25, 26), 20) {
+		if delta.Phase == benchmark.PhaseRunning {
+			assert.InDelta(t, 27.5, delta.Change, 0.001)
+			assert.True(t, delta.Regression)
+		} else {
+			assert.False(t, delta.Regression)
+		}
+	}
+}
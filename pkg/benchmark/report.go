@@ -0,0 +1,158 @@
+package benchmark
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// Report keeps runs of benchmark with environment they were done in,
+// so reports of different EVE releases may be compared
+type Report struct {
+	AppLink string    `json:"appLink"`
+	EVETag  string    `json:"eveTag"`
+	HV      string    `json:"hv"`
+	Arch    string    `json:"arch"`
+	Created time.Time `json:"created"`
+	Runs    []*Run    `json:"runs"`
+	// Summary is filled by Summarize
+	Summary []PhaseSummary `json:"summary"`
+}
+
+// PhaseSummary is statistics of durations of phase in successful runs, in seconds
+type PhaseSummary struct {
+	Phase  Phase   `json:"phase"`
+	Count  int     `json:"count"`
+	Min    float64 `json:"min"`
+	Median float64 `json:"median"`
+	Mean   float64 `json:"mean"`
+	Max    float64 `json:"max"`
+}
+
+// Summarize calculates statistics of phases
+func (r *Report) Summarize() {
+	r.Summary = nil
+	for _, phase := range Phases {
+		var values []float64
+		for _, run := range r.Runs {
+			if run.Error != "" {
+				continue
+			}
+			if d, ok := run.Duration(phase); ok {
+				values = append(values, d.Seconds())
+			}
+		}
+		summary := PhaseSummary{Phase: phase, Count: len(values)}
+		if len(values) > 0 {
+			sort.Float64s(values)
+			summary.Min, summary.Max = values[0], values[len(values)-1]
+			sum := 0.0
+			for _, v := range values {
+				sum += v
+			}
+			summary.Mean = sum / float64(len(values))
+			if mid := len(values) / 2; len(values)%2 == 1 {
+				summary.Median = values[mid]
+			} else {
+				summary.Median = (values[mid-1] + values[mid]) / 2
+			}
+		}
+		r.Summary = append(r.Summary, summary)
+	}
+}
+
+// PhaseSummary returns summary of phase
+func (r *Report) PhaseSummary(phase Phase) (PhaseSummary, bool) {
+	for _, summary := range r.Summary {
+		if summary.Phase == phase {
+			return summary, true
+		}
+	}
+	return PhaseSummary{}, false
+}
+
+// WriteJSON writes report in JSON
+func (r *Report) WriteJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(r)
+}
+
+// WriteCSV writes one line per run with durations of phases in seconds,
+// empty value means phase was not reached
+func (r *Report) WriteCSV(w io.Writer) error {
+	cw := csv.NewWriter(w)
+	header := []string{"iteration", "app", "eve_tag", "hv", "arch", "start"}
+	for _, phase := range Phases {
+		header = append(header, string(phase))
+	}
+	header = append(header, "error")
+	if err := cw.Write(header); err != nil {
+		return err
+	}
+	for _, run := range r.Runs {
+		record := []string{strconv.Itoa(run.Iteration), run.App, r.EVETag, r.HV, r.Arch, run.Start.Format(time.RFC3339)}
+		for _, phase := range Phases {
+			value := ""
+			if d, ok := run.Duration(phase); ok {
+				value = strconv.FormatFloat(d.Seconds(), 'f', 3, 64)
+			}
+			record = append(record, value)
+		}
+		record = append(record, run.Error)
+		if err := cw.Write(record); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// ReadReport reads report in JSON from file
+func ReadReport(path string) (*Report, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var r Report
+	if err := json.Unmarshal(data, &r); err != nil {
+		return nil, fmt.Errorf("cannot parse report %s: %w", path, err)
+	}
+	if len(r.Summary) == 0 {
+		r.Summarize()
+	}
+	return &r, nil
+}
+
+// PhaseDelta is change of median duration of phase between reports
+type PhaseDelta struct {
+	Phase  Phase
+	Base   float64
+	Other  float64
+	Change float64 // in percents, NaN if phase is missing in one of reports
+	// Regression is set if phase became slower more than threshold
+	Regression bool
+}
+
+// Compare returns changes of median durations of phases from base to other report,
+// phase is a regression if it became slower by more than threshold percents
+func Compare(base, other *Report, threshold float64) []PhaseDelta {
+	var deltas []PhaseDelta
+	for _, phase := range Phases {
+		b, okBase := base.PhaseSummary(phase)
+		o, okOther := other.PhaseSummary(phase)
+		delta := PhaseDelta{Phase: phase, Base: b.Median, Other: o.Median, Change: math.NaN()}
+		if okBase && okOther && b.Count > 0 && o.Count > 0 && b.Median > 0 {
+			delta.Change = (o.Median - b.Median) / b.Median * 100
+			delta.Regression = delta.Change > threshold
+		}
+		deltas = append(deltas, delta)
+	}
+	return deltas
+}
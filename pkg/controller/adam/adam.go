@@ -3,11 +3,13 @@ package adam
 import (
 	"encoding/json"
 	"fmt"
+	"net"
 	"net/url"
 	"os"
 	"path"
 	"strconv"
 	"strings"
+	"sync/atomic"
 	"time"
 
 	"github.com/lf-edge/eden/pkg/controller/cachers"
@@ -18,6 +20,7 @@ import (
 	"github.com/lf-edge/eden/pkg/controller/emetric"
 	"github.com/lf-edge/eden/pkg/controller/erequest"
 	"github.com/lf-edge/eden/pkg/controller/loaders"
+	"github.com/lf-edge/eden/pkg/controller/replay"
 	"github.com/lf-edge/eden/pkg/controller/types"
 	"github.com/lf-edge/eden/pkg/defaults"
 	"github.com/lf-edge/eden/pkg/device"
@@ -47,8 +50,16 @@ type Ctx struct {
 	AdamCaching       bool   // enable caching of adam`s logs/info
 	AdamCachingRedis  bool   // caching to redis instead of files
 	AdamCachingPrefix string // custom prefix for file or stream naming for cache
+
+	// conditionalConfig caches support of conditional config update, see configVersionSupported
+	conditionalConfig int32
 }
 
+const (
+	conditionalConfigSupported = iota + 1
+	conditionalConfigUnsupported
+)
+
 // parseRedisURL try to use string from config to obtain redis url
 func parseRedisURL(s string) (addr, password string, databaseID int, err error) {
 	URL, err := url.Parse(s)
@@ -134,13 +145,13 @@ func (adam *Ctx) getLoader() (loader loaders.Loader) {
 		}
 		loader.SetRemoteCache(cache)
 	}
-	return
+	return replay.NewRecordingLoader(loader)
 }
 
 // InitWithVars use variables from viper for init controller
 func (adam *Ctx) InitWithVars(vars *utils.ConfigVars) error {
 	adam.dir = vars.AdamDir
-	adam.url = fmt.Sprintf("https://%s:%s", vars.AdamIP, vars.AdamPort)
+	adam.url = "https://" + net.JoinHostPort(vars.AdamIP, vars.AdamPort)
 	adam.insecureTLS = len(vars.AdamCA) == 0
 	adam.serverCA = vars.AdamCA
 	adam.AdamRemote = vars.AdamRemote
@@ -194,13 +205,47 @@ func (adam *Ctx) ConfigSet(devUUID uuid.UUID, devConfig []byte) (err error) {
 	return adam.putObj(path.Join("/admin/device", devUUID.String(), "config"), devConfig, mimeProto)
 }
 
+// ConfigSetIfVersion set config for devID if version of config in Adam is version.
+// Adam must report version of config in ETag header to check it.
+func (adam *Ctx) ConfigSetIfVersion(devUUID uuid.UUID, version string, devConfig []byte) (err error) {
+	configPath := path.Join("/admin/device", devUUID.String(), "config")
+	supported, err := adam.configVersionSupported(configPath)
+	if err != nil {
+		return err
+	}
+	if !supported {
+		return fmt.Errorf("%w: Adam does not report version of config in ETag", types.ErrConditionalConfigUnsupported)
+	}
+	return adam.putObjIfMatch(configPath, devConfig, mimeProto, strconv.Quote(version))
+}
+
+// configVersionSupported checks once if Adam reports version of config in ETag header
+func (adam *Ctx) configVersionSupported(configPath string) (bool, error) {
+	switch atomic.LoadInt32(&adam.conditionalConfig) {
+	case conditionalConfigSupported:
+		return true, nil
+	case conditionalConfigUnsupported:
+		return false, nil
+	}
+	header, err := adam.headObj(configPath)
+	if err != nil {
+		return false, err
+	}
+	state := int32(conditionalConfigUnsupported)
+	if header.Get("ETag") != "" {
+		state = conditionalConfigSupported
+	}
+	atomic.StoreInt32(&adam.conditionalConfig, state)
+	return state == conditionalConfigSupported, nil
+}
+
 // ConfigGet get config for devID
 func (adam *Ctx) ConfigGet(devUUID uuid.UUID) (out string, err error) {
 	return adam.getObj(path.Join("/admin/device", devUUID.String(), "config"), mimeProto)
 }
 
-// GetECDHCert get cert for ECDH exchange for devID
-func (adam *Ctx) GetECDHCert(devUUID uuid.UUID) ([]byte, error) {
+// GetDeviceCerts get certs published by devID
+func (adam *Ctx) GetDeviceCerts(devUUID uuid.UUID) (*types.Zcerts, error) {
 	attestData, err := adam.getObj(path.Join("/admin/device", devUUID.String(), "certs"), mimeJSON)
 	if err != nil {
 		return nil, fmt.Errorf("cannot get attestation certificates from cloud for %s", devUUID)
@@ -209,6 +254,15 @@ func (adam *Ctx) GetECDHCert(devUUID uuid.UUID) ([]byte, error) {
 	if err := json.Unmarshal([]byte(attestData), req); err != nil {
 		return nil, fmt.Errorf("cannot unmarshal attest: %w", err)
 	}
+	return req, nil
+}
+
+// GetECDHCert get cert for ECDH exchange for devID
+func (adam *Ctx) GetECDHCert(devUUID uuid.UUID) ([]byte, error) {
+	req, err := adam.GetDeviceCerts(devUUID)
+	if err != nil {
+		return nil, err
+	}
 	var devCert []byte
 	for _, c := range req.Certs {
 		if c.Type == certs.ZCertType_CERT_TYPE_DEVICE_ECDH_EXCHANGE {
@@ -11,6 +11,9 @@ import (
 	"strings"
 	"time"
 
+	"github.com/lf-edge/eden/pkg/controller/types"
+	"github.com/lf-edge/eden/pkg/exporter"
+	"github.com/lf-edge/eden/pkg/logging"
 	"github.com/lf-edge/eden/pkg/utils"
 	log "github.com/sirupsen/logrus"
 )
@@ -32,11 +35,11 @@ func (adam *Ctx) getHTTPClient() *http.Client {
 	}
 	var client = &http.Client{
 		Timeout: time.Second * 10,
-		Transport: &http.Transport{
+		Transport: exporter.InstrumentRoundTripper(logging.RoundTripper("controller", &http.Transport{
 			TLSClientConfig:       tlsConfig,
 			TLSHandshakeTimeout:   10 * time.Second,
 			ResponseHeaderTimeout: 10 * time.Second,
-		},
+		})),
 	}
 	return client
 }
@@ -155,3 +158,52 @@ func (adam *Ctx) putObj(path string, obj []byte, mimeType string) (err error) {
 	}
 	return nil
 }
+
+// headObj returns headers of response to HEAD request
+func (adam *Ctx) headObj(path string) (http.Header, error) {
+	u, err := utils.ResolveURL(adam.url, path)
+	if err != nil {
+		return nil, fmt.Errorf("error constructing URL: %w", err)
+	}
+	req, err := http.NewRequest(http.MethodHead, u, nil)
+	if err != nil {
+		return nil, fmt.Errorf("unable to create new http request: %w", err)
+	}
+	response, err := adam.getHTTPClient().Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("unable to send request: %w", err)
+	}
+	response.Body.Close()
+	if response.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("HEAD %s: status code: %d", u, response.StatusCode)
+	}
+	return response.Header, nil
+}
+
+// putObjIfMatch puts object if its ETag in Adam is etag, request is not repeated
+// as repeated request after the first applied one will not match
+func (adam *Ctx) putObjIfMatch(path string, obj []byte, mimeType, etag string) error {
+	u, err := utils.ResolveURL(adam.url, path)
+	if err != nil {
+		return fmt.Errorf("error constructing URL: %w", err)
+	}
+	req, err := http.NewRequest(http.MethodPut, u, bytes.NewBuffer(obj))
+	if err != nil {
+		return fmt.Errorf("unable to create new http request: %w", err)
+	}
+	req.Header.Set("Content-Type", mimeType)
+	req.Header.Set("If-Match", etag)
+	response, err := adam.getHTTPClient().Do(req)
+	if err != nil {
+		return fmt.Errorf("unable to send request: %w", err)
+	}
+	defer response.Body.Close()
+	switch {
+	case response.StatusCode == http.StatusPreconditionFailed:
+		return fmt.Errorf("%w: expected %s", types.ErrConfigVersionMismatch, etag)
+	case response.StatusCode < http.StatusOK || response.StatusCode >= http.StatusMultipleChoices:
+		buf, _ := io.ReadAll(response.Body)
+		return fmt.Errorf("PUT %s: status %s: %s", u, response.Status, strings.TrimSpace(string(buf)))
+	}
+	return nil
+}
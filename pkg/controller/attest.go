@@ -0,0 +1,202 @@
+package controller
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/lf-edge/eden/pkg/controller/types"
+	"github.com/lf-edge/eden/pkg/device"
+	"github.com/lf-edge/eve-api/go/info"
+	uuid "github.com/satori/go.uuid"
+)
+
+// attestRequestPattern matches attest API calls of EVE
+const attestRequestPattern = "/attest$"
+
+// attestStages are states of attestation process of EVE in the order it passes them,
+// restart of the process is not a stage
+var attestStages = []info.AttestationState{
+	info.AttestationState_ATTESTATION_STATE_UNSPECIFIED,
+	info.AttestationState_ATTESTATION_STATE_NONCE_WAIT,
+	info.AttestationState_ATTESTATION_STATE_TPM_QUOTE_WAIT,
+	info.AttestationState_ATTESTATION_STATE_TPM_ESCROW_WAIT,
+	info.AttestationState_ATTESTATION_STATE_ATTEST_WAIT,
+	info.AttestationState_ATTESTATION_STATE_ATTEST_ESCROW_WAIT,
+	info.AttestationState_ATTESTATION_STATE_COMPLETE,
+}
+
+// attestStagePassed returns true if EVE in state is past the stage
+func attestStagePassed(state, stage info.AttestationState) bool {
+	stateIndex, stageIndex := -1, -1
+	for i, el := range attestStages {
+		if el == state {
+			stateIndex = i
+		}
+		if el == stage {
+			stageIndex = i
+		}
+	}
+	return stateIndex >= 0 && stageIndex >= 0 && stateIndex > stageIndex
+}
+
+// eveAttestInfo is the last attestation and vault info reported by EVE
+type eveAttestInfo struct {
+	attestation *info.AttestationInfo
+	vaults      []*info.VaultInfo
+}
+
+func (cloud *CloudCtx) getEVEAttestInfo(devUUID uuid.UUID) (*eveAttestInfo, error) {
+	result := &eveAttestInfo{}
+	var attestTime, vaultsTime int64
+	handler := func(im *info.ZInfoMsg) bool {
+		dinfo := im.GetDinfo()
+		at := im.GetAtTimeStamp().AsTime().UnixNano()
+		if dinfo.GetAttestationInfo() != nil && at >= attestTime {
+			result.attestation, attestTime = dinfo.GetAttestationInfo(), at
+		}
+		if dinfo.GetDataSecAtRestInfo() != nil && at >= vaultsTime {
+			result.vaults, vaultsTime = dinfo.GetDataSecAtRestInfo().GetVaultList(), at
+		}
+		return false
+	}
+	if err := cloud.InfoLastCallback(devUUID, nil, handler); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// CheckAttestNonce checks that EVE obtained nonce from controller
+func CheckAttestNonce(options *types.DeviceOptions, attestation *info.AttestationInfo) error {
+	if attestation == nil {
+		return errors.New("no attestation state reported by EVE")
+	}
+	state := attestation.GetState()
+	if state == info.AttestationState_ATTESTATION_STATE_RESTART_WAIT {
+		return fmt.Errorf("attestation restarts: %s", attestation.GetError().GetDescription())
+	}
+	if !attestStagePassed(state, info.AttestationState_ATTESTATION_STATE_NONCE_WAIT) {
+		return fmt.Errorf("EVE waits for nonce in %s", state)
+	}
+	// after reset controller has no nonce while EVE still reports the previous attestation
+	if options.Nonce == "" && !options.Attested {
+		return errors.New("controller has no nonce issued for the device")
+	}
+	return nil
+}
+
+// CheckAttestQuote checks that controller accepted quote of PCRs sent by EVE
+// and that replay of measured boot events gives the quoted PCR values
+func CheckAttestQuote(options *types.DeviceOptions, attestation *info.AttestationInfo) error {
+	state := attestation.GetState()
+	if !attestStagePassed(state, info.AttestationState_ATTESTATION_STATE_ATTEST_WAIT) {
+		return fmt.Errorf("quote is not accepted by controller, EVE is in %s", state)
+	}
+	if !options.Attested {
+		return errors.New("device is not attested by controller")
+	}
+	if err := CheckEventLog(options.EventLog, options.ReceivedPCRTemplate); err != nil {
+		return fmt.Errorf("quoted PCRs: %w", err)
+	}
+	return nil
+}
+
+// CheckAttestStorageKeys checks that controller accepted storage keys escrowed by EVE
+// and that EVE unlocked its vaults with them
+func CheckAttestStorageKeys(attestation *info.AttestationInfo, vaults []*info.VaultInfo) error {
+	state := attestation.GetState()
+	if state != info.AttestationState_ATTESTATION_STATE_COMPLETE {
+		return fmt.Errorf("storage keys are not accepted by controller, EVE is in %s", state)
+	}
+	if len(vaults) == 0 {
+		return errors.New("no vaults reported by EVE")
+	}
+	for _, vault := range vaults {
+		if vault.GetVaultErr() != nil {
+			return fmt.Errorf("vault %s: %s", vault.GetName(), vault.GetVaultErr().GetDescription())
+		}
+		if vault.GetStatus() != info.DataSecAtRestStatus_DATASEC_AT_REST_ENABLED {
+			return fmt.Errorf("vault %s is in %s", vault.GetName(), vault.GetStatus())
+		}
+	}
+	return nil
+}
+
+// GetAttestState collects attestation state of device from controller and EVE
+// and validates nonce, quote and storage keys stages of attestation
+func (cloud *CloudCtx) GetAttestState(dev *device.Ctx) (*types.AttestState, error) {
+	options, err := cloud.GetDeviceOptions(dev.GetID())
+	if err != nil {
+		return nil, fmt.Errorf("GetDeviceOptions: %w", err)
+	}
+	if options == nil {
+		return nil, errors.New("no device options received from controller")
+	}
+	state := &types.AttestState{
+		Attested:        options.Attested,
+		Nonce:           options.Nonce,
+		IntegrityToken:  options.IntegrityToken,
+		PCRTemplate:     options.ReceivedPCRTemplate,
+		EventLogEntries: len(options.EventLog),
+		Certs:           []string{},
+	}
+	certs, err := cloud.GetDeviceCerts(dev.GetID())
+	if err != nil {
+		return nil, fmt.Errorf("GetDeviceCerts: %w", err)
+	}
+	for _, c := range certs.Certs {
+		state.Certs = append(state.Certs, c.Type.String())
+	}
+	handler := func(request *types.APIRequest) bool {
+		state.AttestRequests++
+		if request.Timestamp.After(state.LastAttestRequest) {
+			state.LastAttestRequest = request.Timestamp
+		}
+		return false
+	}
+	q := map[string]string{"UUID": dev.GetID().String(), "URL": attestRequestPattern}
+	if err := cloud.RequestLastCallback(dev.GetID(), q, handler); err != nil {
+		return nil, fmt.Errorf("RequestLastCallback: %w", err)
+	}
+	eve, err := cloud.getEVEAttestInfo(dev.GetID())
+	if err != nil {
+		return nil, fmt.Errorf("InfoLastCallback: %w", err)
+	}
+	if eve.attestation != nil {
+		state.EVEState = eve.attestation.GetState().String()
+		state.EVEError = eve.attestation.GetError().GetDescription()
+	}
+	for _, vault := range eve.vaults {
+		state.Vaults = append(state.Vaults, fmt.Sprintf("%s: %s", vault.GetName(), vault.GetStatus()))
+	}
+	state.Checks = types.AttestChecks{
+		Nonce:       errString(CheckAttestNonce(options, eve.attestation)),
+		Quote:       errString(CheckAttestQuote(options, eve.attestation)),
+		StorageKeys: errString(CheckAttestStorageKeys(eve.attestation, eve.vaults)),
+	}
+	return state, nil
+}
+
+// ResetAttestation drops attestation state of device inside controller
+// new integrity token will not match the one device has, so controller
+// will reject requests of device until it passes attestation again
+func (cloud *CloudCtx) ResetAttestation(dev *device.Ctx) error {
+	options, err := cloud.GetDeviceOptions(dev.GetID())
+	if err != nil {
+		return fmt.Errorf("GetDeviceOptions: %w", err)
+	}
+	if options == nil {
+		return errors.New("no device options received from controller")
+	}
+	token, err := uuid.NewV4()
+	if err != nil {
+		return fmt.Errorf("cannot generate integrity token: %w", err)
+	}
+	options.Attested = false
+	options.Nonce = ""
+	options.IntegrityToken = token.String()
+	options.EventLog = nil
+	if err := cloud.SetDeviceOptions(dev.GetID(), options); err != nil {
+		return fmt.Errorf("SetDeviceOptions: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,257 @@
+package controller_test
+
+import (
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/lf-edge/eden/pkg/controller"
+	"github.com/lf-edge/eden/pkg/controller/einfo"
+	"github.com/lf-edge/eden/pkg/controller/erequest"
+	"github.com/lf-edge/eden/pkg/controller/types"
+	"github.com/lf-edge/eden/pkg/device"
+	"github.com/lf-edge/eve-api/go/attest"
+	"github.com/lf-edge/eve-api/go/certs"
+	"github.com/lf-edge/eve-api/go/info"
+	uuid "github.com/satori/go.uuid"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// fakeAttestController keeps attestation data of device as Adam does
+type fakeAttestController struct {
+	controller.Controller
+	options  *types.DeviceOptions
+	certs    *types.Zcerts
+	requests []*types.APIRequest
+	infos    []*info.ZInfoMsg
+}
+
+func (ctrl *fakeAttestController) GetDeviceOptions(uuid.UUID) (*types.DeviceOptions, error) {
+	if ctrl.options == nil {
+		return nil, nil
+	}
+	options := *ctrl.options
+	return &options, nil
+}
+
+func (ctrl *fakeAttestController) SetDeviceOptions(_ uuid.UUID, options *types.DeviceOptions) error {
+	ctrl.options = options
+	return nil
+}
+
+func (ctrl *fakeAttestController) GetDeviceCerts(uuid.UUID) (*types.Zcerts, error) {
+	return ctrl.certs, nil
+}
+
+func (ctrl *fakeAttestController) RequestLastCallback(_ uuid.UUID, q map[string]string, handler erequest.HandlerFunc) error {
+	for i := len(ctrl.requests) - 1; i >= 0; i-- {
+		if matched, _ := regexp.MatchString(q["URL"], ctrl.requests[i].URL); matched && handler(ctrl.requests[i]) {
+			return nil
+		}
+	}
+	return nil
+}
+
+func (ctrl *fakeAttestController) InfoLastCallback(_ uuid.UUID, _ map[string]string, handler einfo.HandlerFunc) error {
+	for _, im := range ctrl.infos {
+		if handler(im) {
+			return nil
+		}
+	}
+	return nil
+}
+
+// attestInfo returns info message of EVE with attestation state and vaults
+func attestInfo(at time.Time, state info.AttestationState, vaults ...*info.VaultInfo) *info.ZInfoMsg {
+	dinfo := &info.ZInfoDevice{AttestationInfo: &info.AttestationInfo{State: state}}
+	if vaults != nil {
+		dinfo.DataSecAtRestInfo = &info.DataSecAtRest{VaultList: vaults}
+	}
+	return &info.ZInfoMsg{
+		AtTimeStamp: timestamppb.New(at),
+		InfoContent: &info.ZInfoMsg_Dinfo{Dinfo: dinfo},
+	}
+}
+
+func TestAttestation(t *testing.T) {
+	t.Parallel()
+
+	dev := device.CreateEdgeNode()
+	dev.SetID(uuid.Must(uuid.NewV4()))
+	last := time.Date(2024, 1, 1, 0, 0, 10, 0, time.UTC)
+	ctrl := &fakeAttestController{
+		options: &types.DeviceOptions{
+			Attested:            true,
+			Nonce:               "nonce",
+			IntegrityToken:      "token",
+			ReceivedPCRTemplate: &types.PCRTemplate{PCRValues: []*types.PCRValue{{Index: 0, Value: "*"}}},
+			EventLog:            []*attest.TpmEventLogEntry{{Index: 0}, {Index: 1}},
+		},
+		certs: &types.Zcerts{Certs: []*certs.ZCert{
+			{Type: certs.ZCertType_CERT_TYPE_DEVICE_RESTRICTED_SIGNING},
+			{Type: certs.ZCertType_CERT_TYPE_DEVICE_ECDH_EXCHANGE},
+		}},
+		requests: []*types.APIRequest{
+			{Timestamp: last.Add(-10 * time.Second), URL: "/api/v2/edgedevice/id/" + dev.GetID().String() + "/attest"},
+			{Timestamp: last.Add(time.Second), URL: "/api/v2/edgedevice/id/" + dev.GetID().String() + "/config"},
+			{Timestamp: last, URL: "/api/v2/edgedevice/id/" + dev.GetID().String() + "/attest"},
+		},
+		// the last state is used regardless of order of messages
+		infos: []*info.ZInfoMsg{
+			attestInfo(last, info.AttestationState_ATTESTATION_STATE_COMPLETE,
+				&info.VaultInfo{Name: "Application Data Store", Status: info.DataSecAtRestStatus_DATASEC_AT_REST_ENABLED}),
+			attestInfo(last.Add(-time.Minute), info.AttestationState_ATTESTATION_STATE_NONCE_WAIT),
+		},
+	}
+	cloud := &controller.CloudCtx{Controller: ctrl}
+
+	state, err := cloud.GetAttestState(dev)
+	assert.NoError(t, err)
+	assert.Equal(t, &types.AttestState{
+		Attested:          true,
+		Nonce:             "nonce",
+		IntegrityToken:    "token",
+		PCRTemplate:       ctrl.options.ReceivedPCRTemplate,
+		EventLogEntries:   2,
+		Certs:             []string{"CERT_TYPE_DEVICE_RESTRICTED_SIGNING", "CERT_TYPE_DEVICE_ECDH_EXCHANGE"},
+		AttestRequests:    2,
+		LastAttestRequest: last,
+		EVEState:          "ATTESTATION_STATE_COMPLETE",
+		Vaults:            []string{"Application Data Store: DATASEC_AT_REST_ENABLED"},
+	}, state)
+	assert.True(t, state.Checks.OK())
+
+	// reset drops result of attestation and invalidates integrity token of device
+	assert.NoError(t, cloud.ResetAttestation(dev))
+	state, err = cloud.GetAttestState(dev)
+	assert.NoError(t, err)
+	assert.False(t, state.Attested)
+	assert.Empty(t, state.Nonce)
+	assert.Zero(t, state.EventLogEntries)
+	assert.NotEqual(t, "token", state.IntegrityToken)
+	_, err = uuid.FromString(state.IntegrityToken)
+	assert.NoError(t, err)
+	// EVE still reports the previous attestation
+	assert.NotEmpty(t, state.Checks.Nonce)
+	assert.NotEmpty(t, state.Checks.Quote)
+	assert.False(t, state.Checks.OK())
+
+	// missing options are reported instead of dereferenced
+	ctrl.options = nil
+	_, err = cloud.GetAttestState(dev)
+	assert.Error(t, err)
+	assert.Error(t, cloud.ResetAttestation(dev))
+}
+
+func TestAttestChecks(t *testing.T) {
+	t.Parallel()
+
+	attested := &types.DeviceOptions{
+		Nonce:               "nonce",
+		Attested:            true,
+		ReceivedPCRTemplate: &types.PCRTemplate{PCRValues: []*types.PCRValue{{Index: 0, Value: "*"}}},
+		EventLog:            []*attest.TpmEventLogEntry{{Index: 0}},
+	}
+	enabled := []*info.VaultInfo{{Name: "vault", Status: info.DataSecAtRestStatus_DATASEC_AT_REST_ENABLED}}
+	state := func(state info.AttestationState) *info.AttestationInfo {
+		return &info.AttestationInfo{State: state}
+	}
+
+	tests := []struct {
+		name                      string
+		options                   *types.DeviceOptions
+		attestation               *info.AttestationInfo
+		vaults                    []*info.VaultInfo
+		nonce, quote, storageKeys bool
+	}{
+		{
+			name:        "complete",
+			options:     attested,
+			attestation: state(info.AttestationState_ATTESTATION_STATE_COMPLETE),
+			vaults:      enabled,
+			nonce:       true, quote: true, storageKeys: true,
+		},
+		{
+			name:    "no state from EVE",
+			options: attested,
+			vaults:  enabled,
+		},
+		{
+			name:        "waits for nonce",
+			options:     &types.DeviceOptions{},
+			attestation: state(info.AttestationState_ATTESTATION_STATE_NONCE_WAIT),
+		},
+		{
+			name:    "restarts",
+			options: &types.DeviceOptions{Nonce: "nonce"},
+			attestation: &info.AttestationInfo{
+				State: info.AttestationState_ATTESTATION_STATE_RESTART_WAIT,
+				Error: &info.ErrorInfo{Description: "quote mismatch"},
+			},
+		},
+		{
+			name:        "waits for quote acceptance",
+			options:     &types.DeviceOptions{Nonce: "nonce"},
+			attestation: state(info.AttestationState_ATTESTATION_STATE_ATTEST_WAIT),
+			nonce:       true,
+		},
+		{
+			name:        "reset after attestation",
+			options:     &types.DeviceOptions{IntegrityToken: "new"},
+			attestation: state(info.AttestationState_ATTESTATION_STATE_COMPLETE),
+			vaults:      enabled,
+			storageKeys: true,
+		},
+		{
+			name: "PCRs do not match event log",
+			options: &types.DeviceOptions{
+				Nonce:               "nonce",
+				Attested:            true,
+				ReceivedPCRTemplate: &types.PCRTemplate{PCRValues: []*types.PCRValue{{Index: 0, Value: "00"}}},
+				EventLog: []*attest.TpmEventLogEntry{{
+					Digest: &attest.TpmEventDigest{HashAlgo: attest.TpmHashAlgo_TPM_HASH_ALGO_SHA256, Digest: make([]byte, 32)},
+				}},
+			},
+			attestation: state(info.AttestationState_ATTESTATION_STATE_COMPLETE),
+			vaults:      enabled,
+			nonce:       true, storageKeys: true,
+		},
+		{
+			name:        "waits for escrow acceptance",
+			options:     attested,
+			attestation: state(info.AttestationState_ATTESTATION_STATE_ATTEST_ESCROW_WAIT),
+			vaults:      enabled,
+			nonce:       true, quote: true,
+		},
+		{
+			name:        "no vaults",
+			options:     attested,
+			attestation: state(info.AttestationState_ATTESTATION_STATE_COMPLETE),
+			nonce:       true, quote: true,
+		},
+		{
+			name:        "locked vault",
+			options:     attested,
+			attestation: state(info.AttestationState_ATTESTATION_STATE_COMPLETE),
+			vaults: []*info.VaultInfo{{
+				Name:     "vault",
+				Status:   info.DataSecAtRestStatus_DATASEC_AT_REST_ERROR,
+				VaultErr: &info.ErrorInfo{Description: "cannot unseal key"},
+			}},
+			nonce: true, quote: true,
+		},
+		{
+			name:        "disabled vault",
+			options:     attested,
+			attestation: state(info.AttestationState_ATTESTATION_STATE_COMPLETE),
+			vaults:      []*info.VaultInfo{{Name: "vault", Status: info.DataSecAtRestStatus_DATASEC_AT_REST_DISABLED}},
+			nonce:       true, quote: true,
+		},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.nonce, controller.CheckAttestNonce(tt.options, tt.attestation) == nil, "%s: nonce", tt.name)
+		assert.Equal(t, tt.quote, controller.CheckAttestQuote(tt.options, tt.attestation) == nil, "%s: quote", tt.name)
+		assert.Equal(t, tt.storageKeys, controller.CheckAttestStorageKeys(tt.attestation, tt.vaults) == nil, "%s: storage keys", tt.name)
+	}
+}
@@ -0,0 +1,152 @@
+package controller
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"sync"
+
+	"github.com/lf-edge/eden/pkg/device"
+	"github.com/lf-edge/eve-api/go/config"
+	uuid "github.com/satori/go.uuid"
+)
+
+// DeviceChange modifies configuration of device inside cloud context
+type DeviceChange func(dev *device.Ctx) error
+
+// BatchResult stores result of change applied to device
+type BatchResult struct {
+	DevUUID uuid.UUID
+	Changed bool
+	Err     error
+}
+
+// BatchApply applies change to every device and pushes resulting configs into controller.
+// Changes are applied one by one as they modify shared cloud context,
+// configs are pushed concurrently with no more than parallel requests at once.
+// In dry-run mode difference with controller is printed for every device instead of pushing.
+func (cloud *CloudCtx) BatchApply(devices []*device.Ctx, change DeviceChange, parallel int) []*BatchResult {
+	if parallel < 1 {
+		parallel = 1
+	}
+	results := make([]*BatchResult, len(devices))
+	configs := make([][]byte, len(devices))
+	for i, dev := range devices {
+		results[i] = &BatchResult{DevUUID: dev.GetID()}
+		if err := change(dev); err != nil {
+			results[i].Err = fmt.Errorf("change: %w", err)
+			continue
+		}
+		devConfig, err := cloud.GetConfigBytes(dev, false)
+		if err != nil {
+			results[i].Err = fmt.Errorf("GetConfigBytes: %w", err)
+			continue
+		}
+		// hash is recorded by configPush once the config is pushed
+		if !dev.HashChanged(sha256.Sum256(devConfig)) {
+			continue
+		}
+		if cloud.vars.DryRun {
+			diff, err := cloud.ConfigDiffPending(dev)
+			if err != nil {
+				results[i].Err = fmt.Errorf("ConfigDiffPending: %w", err)
+				continue
+			}
+			fmt.Printf("device %s:\n", dev.GetID())
+			PrintConfigDiff(diff)
+			continue
+		}
+		configs[i] = devConfig
+	}
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, parallel)
+	for i, devConfig := range configs {
+		if devConfig == nil {
+			continue
+		}
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(result *BatchResult, dev *device.Ctx, devConfig []byte) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			recordConfigCoverage(devConfig)
+			pushed, err := cloud.configPush(dev, devConfig)
+			if err != nil {
+				result.Err = fmt.Errorf("configPush: %w", err)
+				return
+			}
+			result.Changed = pushed
+		}(results[i], devices[i], devConfig)
+	}
+	wg.Wait()
+	return results
+}
+
+func appendIfMissing(ids []string, id string) []string {
+	for _, el := range ids {
+		if el == id {
+			return ids
+		}
+	}
+	return append(ids, id)
+}
+
+// PatchChange returns DeviceChange which merges datastores, content trees, volumes,
+// networks, network instances and apps from patch into device config.
+// Objects with the same UUID as existing ones replace them.
+func (cloud *CloudCtx) PatchChange(patch *config.EdgeDevConfig) DeviceChange {
+	return func(dev *device.Ctx) error {
+		for _, el := range patch.Datastores {
+			_ = cloud.RemoveDataStore(el.Id)
+			if err := cloud.AddDataStore(el); err != nil {
+				return fmt.Errorf("AddDataStore %s: %w", el.Id, err)
+			}
+		}
+		for _, el := range patch.ContentInfo {
+			_ = cloud.RemoveContentTree(el.Uuid)
+			if err := cloud.AddContentTree(el); err != nil {
+				return fmt.Errorf("AddContentTree %s: %w", el.Uuid, err)
+			}
+			dev.SetContentTreeConfig(appendIfMissing(dev.GetContentTrees(), el.Uuid))
+		}
+		for _, el := range patch.Volumes {
+			_ = cloud.RemoveVolume(el.Uuid)
+			if err := cloud.AddVolume(el); err != nil {
+				return fmt.Errorf("AddVolume %s: %w", el.Uuid, err)
+			}
+			dev.SetVolumeConfigs(appendIfMissing(dev.GetVolumes(), el.Uuid))
+		}
+		for _, el := range patch.Networks {
+			_ = cloud.RemoveNetworkConfig(el.Id)
+			if err := cloud.AddNetworkConfig(el); err != nil {
+				return fmt.Errorf("AddNetworkConfig %s: %w", el.Id, err)
+			}
+			dev.SetNetworkConfig(appendIfMissing(dev.GetNetworks(), el.Id))
+		}
+		for _, el := range patch.NetworkInstances {
+			id, err := getUUID(el)
+			if err != nil {
+				return err
+			}
+			_ = cloud.RemoveNetworkInstanceConfig(id.String())
+			if err := cloud.AddNetworkInstanceConfig(el); err != nil {
+				return fmt.Errorf("AddNetworkInstanceConfig %s: %w", id, err)
+			}
+			dev.SetNetworkInstanceConfig(appendIfMissing(dev.GetNetworkInstances(), id.String()))
+		}
+		for _, el := range patch.Apps {
+			id, err := getUUID(el)
+			if err != nil {
+				return err
+			}
+			_ = cloud.RemoveApplicationInstanceConfig(id.String())
+			if err := cloud.AddApplicationInstanceConfig(el); err != nil {
+				return fmt.Errorf("AddApplicationInstanceConfig %s: %w", id, err)
+			}
+			for _, img := range el.Drives {
+				_ = cloud.AddImage(img.Image)
+			}
+			dev.SetApplicationInstanceConfig(appendIfMissing(dev.GetApplicationInstances(), id.String()))
+		}
+		return nil
+	}
+}
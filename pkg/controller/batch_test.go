@@ -0,0 +1,114 @@
+package controller_test
+
+import (
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/lf-edge/eden/pkg/controller"
+	"github.com/lf-edge/eden/pkg/controller/einfo"
+	"github.com/lf-edge/eden/pkg/device"
+	"github.com/lf-edge/eden/pkg/utils"
+	uuid "github.com/satori/go.uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeController stores pushed configs and fails pushes for devices from failing
+type fakeController struct {
+	controller.Controller
+	mu      sync.Mutex
+	pushed  map[uuid.UUID]int
+	failing map[uuid.UUID]bool
+}
+
+func (ctrl *fakeController) ConfigSet(devUUID uuid.UUID, _ []byte) error {
+	ctrl.mu.Lock()
+	defer ctrl.mu.Unlock()
+	if ctrl.failing[devUUID] {
+		return errors.New("controller is not reachable")
+	}
+	ctrl.pushed[devUUID]++
+	return nil
+}
+
+// ConfigGet returns empty config, it is used to print difference in dry-run mode
+func (ctrl *fakeController) ConfigGet(uuid.UUID) (string, error) {
+	return "", nil
+}
+
+// InfoLastCallback reports no info, so configs are not adapted to EVE version
+func (ctrl *fakeController) InfoLastCallback(uuid.UUID, map[string]string, einfo.HandlerFunc) error {
+	return nil
+}
+
+func TestBatchApply(t *testing.T) {
+	t.Parallel()
+
+	var devices []*device.Ctx
+	for i := 0; i < 3; i++ {
+		dev := device.CreateEdgeNode()
+		dev.SetID(uuid.Must(uuid.NewV4()))
+		devices = append(devices, dev)
+	}
+	ctrl := &fakeController{
+		pushed:  map[uuid.UUID]int{},
+		failing: map[uuid.UUID]bool{devices[2].GetID(): true},
+	}
+	cloud := &controller.CloudCtx{Controller: ctrl}
+	cloud.SetVars(&utils.ConfigVars{})
+
+	setItem := func(value string) controller.DeviceChange {
+		return func(dev *device.Ctx) error {
+			dev.SetConfigItem("timer.config.interval", value)
+			return nil
+		}
+	}
+
+	results := cloud.BatchApply(devices, setItem("10"), 2)
+	assert.Len(t, results, 3)
+	for i, result := range results {
+		assert.Equal(t, devices[i].GetID(), result.DevUUID)
+	}
+	assert.True(t, results[0].Changed)
+	assert.NoError(t, results[0].Err)
+	assert.True(t, results[1].Changed)
+	assert.NoError(t, results[1].Err)
+	assert.False(t, results[2].Changed)
+	assert.Error(t, results[2].Err)
+
+	// the same change does not produce new configs
+	results = cloud.BatchApply(devices[:2], setItem("10"), 2)
+	for _, result := range results {
+		assert.False(t, result.Changed)
+		assert.NoError(t, result.Err)
+	}
+	assert.Equal(t, 1, ctrl.pushed[devices[0].GetID()])
+	assert.Equal(t, 1, ctrl.pushed[devices[1].GetID()])
+
+	// error of change is reported and nothing is pushed
+	results = cloud.BatchApply(devices[:1], func(dev *device.Ctx) error {
+		return errors.New("cannot change")
+	}, 1)
+	assert.Error(t, results[0].Err)
+	assert.False(t, results[0].Changed)
+	assert.Equal(t, 1, ctrl.pushed[devices[0].GetID()])
+
+	// failed push is repeated once the controller is reachable
+	delete(ctrl.failing, devices[2].GetID())
+	results = cloud.BatchApply(devices[2:], setItem("10"), 1)
+	assert.True(t, results[0].Changed)
+	assert.NoError(t, results[0].Err)
+	assert.Equal(t, 1, ctrl.pushed[devices[2].GetID()])
+
+	// dry-run does not push and does not hide the change from the next run
+	cloud.SetVars(&utils.ConfigVars{DryRun: true})
+	results = cloud.BatchApply(devices[:1], setItem("20"), 1)
+	assert.False(t, results[0].Changed)
+	assert.NoError(t, results[0].Err)
+	assert.Equal(t, 1, ctrl.pushed[devices[0].GetID()])
+	cloud.SetVars(&utils.ConfigVars{})
+	results = cloud.BatchApply(devices[:1], setItem("20"), 1)
+	assert.True(t, results[0].Changed)
+	assert.NoError(t, results[0].Err)
+	assert.Equal(t, 2, ctrl.pushed[devices[0].GetID()])
+}
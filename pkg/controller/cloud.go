@@ -1,6 +1,7 @@
 package controller
 
 import (
+	"github.com/lf-edge/eden/pkg/controller/types"
 	"github.com/lf-edge/eden/pkg/device"
 	"github.com/lf-edge/eden/pkg/models"
 	"github.com/lf-edge/eden/pkg/utils"
@@ -25,6 +26,7 @@ type CloudCtx struct {
 	bondAdapters         map[string]*config.BondAdapter
 	applicationInstances []*config.AppInstanceConfig
 	vars                 *utils.ConfigVars
+	eveVersions          map[string]*EVEVersion
 }
 
 // Cloud is an interface of cloud
@@ -59,6 +61,8 @@ type Cloud interface {
 	GetConfigBytes(dev *device.Ctx, jsonFormat bool) ([]byte, error)
 	GetDeviceCurrent() (dev *device.Ctx, err error)
 	ConfigSync(dev *device.Ctx) (err error)
+	ConfigDiffWithController(dev *device.Ctx, newConfig *config.EdgeDevConfig) ([]*DiffEntry, error)
+	ConfigDiffPending(dev *device.Ctx) ([]*DiffEntry, error)
 	ConfigParse(config *config.EdgeDevConfig) (dev *device.Ctx, err error)
 	GetNetworkConfig(id string) (networkConfig *config.NetworkConfig, err error)
 	AddNetworkConfig(networkInstanceConfig *config.NetworkConfig) error
@@ -83,6 +87,11 @@ type Cloud interface {
 	StateUpdate(dev *device.Ctx) (err error)
 	ResetDev(node *device.Ctx) error
 	OnBoardDev(node *device.Ctx) error
+	GetAttestState(dev *device.Ctx) (*types.AttestState, error)
+	ResetAttestation(dev *device.Ctx) error
+	GetTPMState(dev *device.Ctx) (*types.TPMState, error)
+	BatchApply(devices []*device.Ctx, change DeviceChange, parallel int) []*BatchResult
+	PatchChange(patch *config.EdgeDevConfig) DeviceChange
 	GetVars() *utils.ConfigVars
 	SetVars(*utils.ConfigVars)
 	GetAllNodes()
@@ -0,0 +1,237 @@
+package controller
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+
+	"github.com/lf-edge/eden/pkg/device"
+	"github.com/lf-edge/eve-api/go/config"
+	"github.com/lf-edge/eve-api/go/info"
+	log "github.com/sirupsen/logrus"
+)
+
+// EVEVersion is release version of EVE, which defines supported API
+type EVEVersion struct {
+	Major int
+	Minor int
+	Patch int
+}
+
+var eveVersionRe = regexp.MustCompile(`^(\d+)\.(\d+)\.(\d+)`)
+
+// ParseEVEVersion parses version of EVE in form of 12.3.0-kvm-amd64
+func ParseEVEVersion(version string) (*EVEVersion, error) {
+	match := eveVersionRe.FindStringSubmatch(version)
+	if match == nil {
+		return nil, fmt.Errorf("cannot parse EVE version %q", version)
+	}
+	var result EVEVersion
+	for i, el := range []*int{&result.Major, &result.Minor, &result.Patch} {
+		v, err := strconv.Atoi(match[i+1])
+		if err != nil {
+			return nil, fmt.Errorf("cannot parse EVE version %q: %w", version, err)
+		}
+		*el = v
+	}
+	return &result, nil
+}
+
+func (v *EVEVersion) String() string {
+	return fmt.Sprintf("%d.%d.%d", v.Major, v.Minor, v.Patch)
+}
+
+// Less returns true if v is older than other
+func (v *EVEVersion) Less(other *EVEVersion) bool {
+	if v.Major != other.Major {
+		return v.Major < other.Major
+	}
+	if v.Minor != other.Minor {
+		return v.Minor < other.Minor
+	}
+	return v.Patch < other.Patch
+}
+
+// IsDevelopment returns true for version of EVE built from sources (0.0.0-...),
+// which supports the latest API
+func (v *EVEVersion) IsDevelopment() bool {
+	return v.Major == 0 && v.Minor == 0 && v.Patch == 0
+}
+
+// compatShim adapts config for releases of EVE older than introduced
+type compatShim struct {
+	name       string
+	introduced EVEVersion
+	apply      func(devConfig *config.EdgeDevConfig)
+}
+
+// compatShims is the list of API changes eden must hide from older EVE releases
+var compatShims = []*compatShim{
+	{
+		name:       "profiles",
+		introduced: EVEVersion{6, 4, 0},
+		apply: func(devConfig *config.EdgeDevConfig) {
+			devConfig.GlobalProfile = ""
+			devConfig.LocalProfileServer = ""
+			devConfig.ProfileServerToken = ""
+			for _, app := range devConfig.Apps {
+				app.ProfileList = nil
+			}
+		},
+	},
+	{
+		name:       "baseos",
+		introduced: EVEVersion{8, 0, 0},
+		apply:      legacyBaseOS,
+	},
+	{
+		name:       "app start delay",
+		introduced: EVEVersion{8, 11, 0},
+		apply: func(devConfig *config.EdgeDevConfig) {
+			for _, app := range devConfig.Apps {
+				app.StartDelayInSeconds = 0
+			}
+		},
+	},
+	{
+		name:       "disks",
+		introduced: EVEVersion{8, 11, 0},
+		apply: func(devConfig *config.EdgeDevConfig) {
+			devConfig.Disks = nil
+		},
+	},
+	{
+		name:       "vlans and bonds",
+		introduced: EVEVersion{9, 0, 0},
+		apply: func(devConfig *config.EdgeDevConfig) {
+			devConfig.Vlans = nil
+			devConfig.Bonds = nil
+		},
+	},
+	{
+		name:       "cpu pinning",
+		introduced: EVEVersion{10, 1, 0},
+		apply: func(devConfig *config.EdgeDevConfig) {
+			for _, app := range devConfig.Apps {
+				if app.Fixedresources != nil {
+					app.Fixedresources.PinCpu = false
+				}
+			}
+		},
+	},
+	{
+		name:       "network instance mtu",
+		introduced: EVEVersion{10, 2, 0},
+		apply: func(devConfig *config.EdgeDevConfig) {
+			for _, ni := range devConfig.NetworkInstances {
+				ni.Mtu = 0
+			}
+		},
+	},
+	{
+		name:       "shutdown",
+		introduced: EVEVersion{11, 0, 0},
+		apply: func(devConfig *config.EdgeDevConfig) {
+			devConfig.Shutdown = nil
+		},
+	},
+}
+
+// legacyBaseOS encodes BaseOS referencing content tree as BaseOSConfig with image inside drive
+func legacyBaseOS(devConfig *config.EdgeDevConfig) {
+	baseOS := devConfig.Baseos
+	devConfig.Baseos = nil
+	if baseOS == nil || len(devConfig.Base) > 0 {
+		return
+	}
+	for _, contentTree := range devConfig.ContentInfo {
+		if contentTree.Uuid != baseOS.ContentTreeUuid {
+			continue
+		}
+		devConfig.Base = append(devConfig.Base, &config.BaseOSConfig{
+			Uuidandversion: &config.UUIDandVersion{Uuid: contentTree.Uuid, Version: "1"},
+			Activate:       baseOS.Activate,
+			BaseOSVersion:  baseOS.BaseOsVersion,
+			Drives: []*config.Drive{{
+				Image: &config.Image{
+					Uuidandversion: &config.UUIDandVersion{Uuid: contentTree.Uuid, Version: "1"},
+					Name:           contentTree.URL,
+					Sha256:         contentTree.Sha256,
+					Iformat:        contentTree.Iformat,
+					DsId:           contentTree.DsId,
+					SizeBytes:      int64(contentTree.MaxSizeBytes),
+				},
+				Drvtype: config.DriveType_Unclassified,
+			}},
+		})
+		return
+	}
+}
+
+// ApplyCompatShims adapts devConfig for EVE of provided version and returns names of applied shims
+func ApplyCompatShims(devConfig *config.EdgeDevConfig, version *EVEVersion) []string {
+	if version == nil || version.IsDevelopment() {
+		return nil
+	}
+	var applied []string
+	for _, shim := range compatShims {
+		if version.Less(&shim.introduced) {
+			shim.apply(devConfig)
+			applied = append(applied, shim.name)
+		}
+	}
+	return applied
+}
+
+// DetectEVEVersion returns version of EVE running on device.
+// Version from eve.api-version config option is used if defined,
+// otherwise it is taken from the latest device info sent by EVE.
+// Returns nil if version is not known yet.
+func (cloud *CloudCtx) DetectEVEVersion(dev *device.Ctx) (*EVEVersion, error) {
+	if cloud.vars != nil && cloud.vars.EveAPIVersion != "" {
+		return ParseEVEVersion(cloud.vars.EveAPIVersion)
+	}
+	if version, ok := cloud.eveVersions[dev.GetID().String()]; ok {
+		return version, nil
+	}
+	var shortVersion string
+	handleInfo := func(im *info.ZInfoMsg) bool {
+		if im.GetZtype() != info.ZInfoTypes_ZiDevice {
+			return false
+		}
+		for _, sw := range im.GetDinfo().GetSwList() {
+			if sw.GetActivated() {
+				shortVersion = sw.GetShortVersion()
+			}
+		}
+		return false
+	}
+	// use controller directly to not count this request in API coverage of tests
+	if err := cloud.Controller.InfoLastCallback(dev.GetID(), map[string]string{"devId": dev.GetID().String()}, handleInfo); err != nil {
+		return nil, fmt.Errorf("InfoLastCallback: %w", err)
+	}
+	var version *EVEVersion
+	if shortVersion != "" {
+		var err error
+		if version, err = ParseEVEVersion(shortVersion); err != nil {
+			return nil, err
+		}
+	}
+	if cloud.eveVersions == nil {
+		cloud.eveVersions = map[string]*EVEVersion{}
+	}
+	cloud.eveVersions[dev.GetID().String()] = version
+	return version, nil
+}
+
+// applyCompatShims adapts devConfig for EVE running on device
+func (cloud *CloudCtx) applyCompatShims(dev *device.Ctx, devConfig *config.EdgeDevConfig) {
+	version, err := cloud.DetectEVEVersion(dev)
+	if err != nil {
+		log.Debugf("cannot detect EVE version of %s: %s", dev.GetID(), err)
+		return
+	}
+	if applied := ApplyCompatShims(devConfig, version); len(applied) > 0 {
+		log.Debugf("EVE %s on %s: compatibility shims applied: %v", version, dev.GetID(), applied)
+	}
+}
@@ -0,0 +1,47 @@
+package controller_test
+
+import (
+	"testing"
+
+	"github.com/lf-edge/eden/pkg/controller"
+	"github.com/lf-edge/eve-api/go/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestApplyCompatShims(t *testing.T) {
+	t.Parallel()
+
+	newConfig := func() *config.EdgeDevConfig {
+		return &config.EdgeDevConfig{
+			ContentInfo: []*config.ContentTree{{Uuid: "ct", URL: "eve.img", Sha256: "abc", DsId: "ds"}},
+			Baseos:      &config.BaseOS{ContentTreeUuid: "ct", BaseOsVersion: "8.0.0", Activate: true},
+			Vlans:       []*config.VlanAdapter{{Logicallabel: "vlan100"}},
+			Shutdown:    &config.DeviceOpsCmd{Counter: 1},
+		}
+	}
+
+	version, err := controller.ParseEVEVersion("12.3.0-kvm-amd64")
+	assert.NoError(t, err)
+	devConfig := newConfig()
+	assert.Empty(t, controller.ApplyCompatShims(devConfig, version))
+	assert.Equal(t, newConfig().String(), devConfig.String())
+
+	version, err = controller.ParseEVEVersion("0.0.0-master-2b6b2a0a-kvm-amd64")
+	assert.NoError(t, err)
+	assert.Empty(t, controller.ApplyCompatShims(newConfig(), version))
+
+	version, err = controller.ParseEVEVersion("7.10.0-kvm-amd64")
+	assert.NoError(t, err)
+	devConfig = newConfig()
+	assert.Contains(t, controller.ApplyCompatShims(devConfig, version), "baseos")
+	assert.Nil(t, devConfig.Baseos)
+	assert.Nil(t, devConfig.Vlans)
+	assert.Nil(t, devConfig.Shutdown)
+	if assert.Len(t, devConfig.Base, 1) {
+		assert.Equal(t, "8.0.0", devConfig.Base[0].BaseOSVersion)
+		assert.Equal(t, "eve.img", devConfig.Base[0].Drives[0].Image.Name)
+	}
+
+	_, err = controller.ParseEVEVersion("unknown")
+	assert.Error(t, err)
+}
@@ -21,6 +21,9 @@ type Controller interface {
 	SigningCertGet() (signCert []byte, err error)
 	ConfigGet(devUUID uuid.UUID) (out string, err error)
 	ConfigSet(devUUID uuid.UUID, devConfig []byte) (err error)
+	// ConfigSetIfVersion sets config only if version of config in controller is version,
+	// returns types.ErrConfigVersionMismatch otherwise
+	ConfigSetIfVersion(devUUID uuid.UUID, version string, devConfig []byte) (err error)
 	LogAppsChecker(devUUID uuid.UUID, appUUID uuid.UUID, q map[string]string, handler eapps.HandlerFunc, mode eapps.LogCheckerMode, timeout time.Duration) (err error)
 	LogAppsLastCallback(devUUID uuid.UUID, appUUID uuid.UUID, q map[string]string, handler eapps.HandlerFunc) (err error)
 	LogChecker(devUUID uuid.UUID, q map[string]string, handler elog.HandlerFunc, mode elog.LogCheckerMode, timeout time.Duration) (err error)
@@ -37,6 +40,7 @@ type Controller interface {
 	DeviceGetByOnboardUUID(onboardUUID string) (devUUID uuid.UUID, err error)
 	DeviceGetOnboard(devUUID uuid.UUID) (onboardUUID uuid.UUID, err error)
 	GetDeviceCert(device *device.Ctx) (*types.DeviceCert, error)
+	GetDeviceCerts(devUUID uuid.UUID) (*types.Zcerts, error)
 	SetDeviceOptions(uuid.UUID, *types.DeviceOptions) error
 	GetDeviceOptions(uuid.UUID) (*types.DeviceOptions, error)
 	SetGlobalOptions(*types.GlobalOptions) error
@@ -0,0 +1,70 @@
+package controller
+
+import (
+	"os"
+	"time"
+
+	"github.com/lf-edge/eden/pkg/apicoverage"
+	"github.com/lf-edge/eden/pkg/controller/einfo"
+	"github.com/lf-edge/eden/pkg/controller/emetric"
+	"github.com/lf-edge/eden/pkg/defaults"
+	"github.com/lf-edge/eve-api/go/config"
+	"github.com/lf-edge/eve-api/go/info"
+	"github.com/lf-edge/eve-api/go/metrics"
+	uuid "github.com/satori/go.uuid"
+	"google.golang.org/protobuf/proto"
+)
+
+// Methods below record messages passed to handlers of tests into API coverage,
+// see apicoverage.Record
+
+// recordConfigCoverage records config of device sent to controller
+func recordConfigCoverage(devConfig []byte) {
+	if os.Getenv(defaults.DefaultAPICoverageEnv) == "" {
+		return
+	}
+	var dConfig config.EdgeDevConfig
+	if err := proto.Unmarshal(devConfig, &dConfig); err == nil {
+		apicoverage.Record(&dConfig)
+	}
+}
+
+func infoCoverage(handler einfo.HandlerFunc) einfo.HandlerFunc {
+	if handler == nil {
+		return nil
+	}
+	return func(im *info.ZInfoMsg) bool {
+		apicoverage.Record(im)
+		return handler(im)
+	}
+}
+
+func metricCoverage(handler emetric.HandlerFunc) emetric.HandlerFunc {
+	if handler == nil {
+		return nil
+	}
+	return func(mm *metrics.ZMetricMsg) bool {
+		apicoverage.Record(mm)
+		return handler(mm)
+	}
+}
+
+// InfoChecker checks info messages of device with handler
+func (cloud *CloudCtx) InfoChecker(devUUID uuid.UUID, q map[string]string, handler einfo.HandlerFunc, mode einfo.InfoCheckerMode, timeout time.Duration) (err error) {
+	return cloud.Controller.InfoChecker(devUUID, q, infoCoverage(handler), mode, timeout)
+}
+
+// InfoLastCallback passes info messages of device to handler
+func (cloud *CloudCtx) InfoLastCallback(devUUID uuid.UUID, q map[string]string, handler einfo.HandlerFunc) (err error) {
+	return cloud.Controller.InfoLastCallback(devUUID, q, infoCoverage(handler))
+}
+
+// MetricChecker checks metric messages of device with handler
+func (cloud *CloudCtx) MetricChecker(devUUID uuid.UUID, q map[string]string, handler emetric.HandlerFunc, mode emetric.MetricCheckerMode, timeout time.Duration) (err error) {
+	return cloud.Controller.MetricChecker(devUUID, q, metricCoverage(handler), mode, timeout)
+}
+
+// MetricLastCallback passes metric messages of device to handler
+func (cloud *CloudCtx) MetricLastCallback(devUUID uuid.UUID, q map[string]string, handler emetric.HandlerFunc) (err error) {
+	return cloud.Controller.MetricLastCallback(devUUID, q, metricCoverage(handler))
+}
@@ -0,0 +1,189 @@
+package controller
+
+import (
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/lf-edge/eden/pkg/controller/types"
+	"github.com/lf-edge/eden/pkg/device"
+	"github.com/lf-edge/eve-api/go/config"
+	log "github.com/sirupsen/logrus"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// ErrConfigConflict is returned when sections of config to push were changed in controller
+// after config was loaded
+var ErrConfigConflict = errors.New("config was changed in controller concurrently")
+
+// ConfigSections returns names of top-level sections (e.g. apps, volumes) which differ
+// between configs. Identity of config is not a section as its version changes on every push.
+func ConfigSections(oldConfig, newConfig *config.EdgeDevConfig) []string {
+	var sections []string
+	fields := oldConfig.ProtoReflect().Descriptor().Fields()
+	for i := 0; i < fields.Len(); i++ {
+		fd := fields.Get(i)
+		if fd.Name() == "id" {
+			continue
+		}
+		if !proto.Equal(configSection(oldConfig, fd), configSection(newConfig, fd)) {
+			sections = append(sections, string(fd.Name()))
+		}
+	}
+	return sections
+}
+
+// configSection returns config with only one section filled in
+func configSection(cfg *config.EdgeDevConfig, fd protoreflect.FieldDescriptor) proto.Message {
+	m := cfg.ProtoReflect()
+	out := m.Type().New()
+	if m.Has(fd) {
+		out.Set(fd, m.Get(fd))
+	}
+	return out.Interface()
+}
+
+// MergeConfigSections returns copy of base config with sections taken from changes
+func MergeConfigSections(base, changes *config.EdgeDevConfig, sections []string) *config.EdgeDevConfig {
+	merged := proto.Clone(base).(*config.EdgeDevConfig)
+	m := merged.ProtoReflect()
+	src := changes.ProtoReflect()
+	fields := m.Descriptor().Fields()
+	for _, section := range sections {
+		fd := fields.ByName(protoreflect.Name(section))
+		if fd == nil {
+			continue
+		}
+		if src.Has(fd) {
+			m.Set(fd, src.Get(fd))
+		} else {
+			m.Clear(fd)
+		}
+	}
+	return merged
+}
+
+func intersectSections(a, b []string) (result []string) {
+	for _, el := range a {
+		for _, other := range b {
+			if el == other {
+				result = append(result, el)
+				break
+			}
+		}
+	}
+	return result
+}
+
+// configPushAttempts is number of attempts to push config on top of config changed in controller concurrently
+const configPushAttempts = 3
+
+// configPush pushes sections of devConfig changed since config of device was loaded from controller
+// on top of the loaded config with incremented version. Controller API accepts the whole config only,
+// so the merged config is uploaded, but the write is conditional on the version of the loaded config.
+// If the config in controller was changed in the meantime, it is fetched and the sections are pushed
+// on top of it, so changes of other sections made in controller are kept; ErrConfigConflict is returned
+// if the same sections were changed there.
+// Config of device not loaded from controller is pushed as a whole.
+// Returns false if there were no sections to push.
+func (cloud *CloudCtx) configPush(dev *device.Ctx, devConfig []byte) (bool, error) {
+	if dev.GetControllerConfig() == nil {
+		devConfig, err := VersionIncrement(devConfig)
+		if err != nil {
+			return false, fmt.Errorf("VersionIncrement error: %w", err)
+		}
+		if err := cloud.ConfigSet(dev.GetID(), devConfig); err != nil {
+			return false, err
+		}
+		// keep version of pushed config to not push the same config again
+		var pushed config.EdgeDevConfig
+		if err := proto.Unmarshal(devConfig, &pushed); err != nil {
+			return true, fmt.Errorf("cannot unmarshal pushed config: %w", err)
+		}
+		version, err := strconv.Atoi(pushed.GetId().GetVersion())
+		if err != nil {
+			return true, fmt.Errorf("cannot parse version of pushed config: %w", err)
+		}
+		dev.SetConfigVersion(version)
+		dev.CheckHash(sha256.Sum256(devConfig))
+		return true, nil
+	}
+	var generated, ours, loaded config.EdgeDevConfig
+	if err := proto.Unmarshal(dev.GetGeneratedConfig(), &generated); err != nil {
+		return false, fmt.Errorf("cannot unmarshal generated config: %w", err)
+	}
+	if err := proto.Unmarshal(devConfig, &ours); err != nil {
+		return false, fmt.Errorf("cannot unmarshal config: %w", err)
+	}
+	if err := proto.Unmarshal(dev.GetControllerConfig(), &loaded); err != nil {
+		return false, fmt.Errorf("cannot unmarshal loaded config: %w", err)
+	}
+	changed := ConfigSections(&generated, &ours)
+	if len(changed) == 0 {
+		return false, nil
+	}
+	log.Debugf("push sections of config of %s: %s", dev.GetID(), strings.Join(changed, ", "))
+	base := &loaded
+	var merged []byte
+	for attempt := 1; ; attempt++ {
+		var err error
+		if merged, err = proto.Marshal(MergeConfigSections(base, &ours, changed)); err != nil {
+			return false, fmt.Errorf("cannot marshal config: %w", err)
+		}
+		if merged, err = VersionIncrement(merged); err != nil {
+			return false, fmt.Errorf("VersionIncrement error: %w", err)
+		}
+		err = cloud.ConfigSetIfVersion(dev.GetID(), base.GetId().GetVersion(), merged)
+		if errors.Is(err, types.ErrConditionalConfigUnsupported) && cloud.vars.ForcePush {
+			log.Warnf("%s, push config of %s unconditionally", err, dev.GetID())
+			err = cloud.ConfigSet(dev.GetID(), merged)
+		}
+		if err == nil {
+			break
+		}
+		if !errors.Is(err, types.ErrConfigVersionMismatch) {
+			return false, fmt.Errorf("ConfigSetIfVersion: %w", err)
+		}
+		if attempt == configPushAttempts {
+			return false, fmt.Errorf("%w: config of %s changes faster than it can be pushed", ErrConfigConflict, dev.GetID())
+		}
+		current, err := cloud.ConfigGet(dev.GetID())
+		if err != nil {
+			return false, fmt.Errorf("ConfigGet: %w", err)
+		}
+		var theirs config.EdgeDevConfig
+		if err := proto.Unmarshal([]byte(current), &theirs); err != nil {
+			return false, fmt.Errorf("cannot unmarshal config from controller: %w", err)
+		}
+		concurrent := ConfigSections(&loaded, &theirs)
+		if conflicts := intersectSections(changed, concurrent); len(conflicts) > 0 {
+			return false, fmt.Errorf("%w (version %s, loaded %s): %s", ErrConfigConflict,
+				theirs.GetId().GetVersion(), loaded.GetId().GetVersion(), strings.Join(conflicts, ", "))
+		}
+		log.Infof("config of %s was changed in controller (version %s, loaded %s), keep its sections %s",
+			dev.GetID(), theirs.GetId().GetVersion(), loaded.GetId().GetVersion(), strings.Join(concurrent, ", "))
+		base = &theirs
+	}
+	// pushed config is the base for the next push
+	var pushed config.EdgeDevConfig
+	if err := proto.Unmarshal(merged, &pushed); err != nil {
+		return true, fmt.Errorf("cannot unmarshal pushed config: %w", err)
+	}
+	ours.Id = pushed.Id
+	generatedConfig, err := proto.Marshal(&ours)
+	if err != nil {
+		return true, fmt.Errorf("cannot marshal config: %w", err)
+	}
+	version, err := strconv.Atoi(pushed.GetId().GetVersion())
+	if err != nil {
+		return true, fmt.Errorf("cannot parse version of pushed config: %w", err)
+	}
+	dev.SetConfigVersion(version)
+	dev.SetControllerConfig(merged)
+	dev.SetGeneratedConfig(generatedConfig)
+	dev.CheckHash(sha256.Sum256(generatedConfig))
+	return true, nil
+}
@@ -0,0 +1,201 @@
+package controller_test
+
+import (
+	"strconv"
+	"sync"
+	"testing"
+
+	"github.com/lf-edge/eden/pkg/controller"
+	"github.com/lf-edge/eden/pkg/controller/einfo"
+	"github.com/lf-edge/eden/pkg/controller/types"
+	"github.com/lf-edge/eden/pkg/device"
+	"github.com/lf-edge/eden/pkg/utils"
+	"github.com/lf-edge/eve-api/go/config"
+	uuid "github.com/satori/go.uuid"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestMergeConfigSections(t *testing.T) {
+	t.Parallel()
+
+	app := &config.AppInstanceConfig{Uuidandversion: &config.UUIDandVersion{Uuid: "a", Version: "1"}}
+	volume := &config.Volume{Uuid: "v"}
+	loaded := &config.EdgeDevConfig{
+		Id:      &config.UUIDandVersion{Uuid: "dev", Version: "5"},
+		Apps:    []*config.AppInstanceConfig{app},
+		Volumes: []*config.Volume{volume},
+	}
+	// volume removed locally
+	ours := &config.EdgeDevConfig{
+		Id:   &config.UUIDandVersion{Uuid: "dev", Version: "5"},
+		Apps: []*config.AppInstanceConfig{app},
+	}
+	// config items added in controller concurrently
+	theirs := proto.Clone(loaded).(*config.EdgeDevConfig)
+	theirs.Id.Version = "6"
+	theirs.ConfigItems = []*config.ConfigItem{{Key: "timer.config.interval", Value: "10"}}
+
+	changed := controller.ConfigSections(loaded, ours)
+	assert.Equal(t, []string{"volumes"}, changed)
+	assert.Equal(t, []string{"configItems"}, controller.ConfigSections(loaded, theirs))
+
+	merged := controller.MergeConfigSections(theirs, ours, changed)
+	assert.Empty(t, merged.Volumes)
+	assert.Len(t, merged.Apps, 1)
+	assert.Len(t, merged.ConfigItems, 1)
+	assert.Equal(t, "6", merged.Id.Version)
+	// source configs are not modified
+	assert.Len(t, theirs.Volumes, 1)
+}
+
+// casController keeps config of one device and updates it only if version precondition matches
+type casController struct {
+	controller.Controller
+	mu          sync.Mutex
+	config      []byte
+	unsupported bool
+}
+
+func (ctrl *casController) ConfigSet(_ uuid.UUID, devConfig []byte) error {
+	ctrl.mu.Lock()
+	defer ctrl.mu.Unlock()
+	ctrl.config = devConfig
+	return nil
+}
+
+func (ctrl *casController) ConfigGet(uuid.UUID) (string, error) {
+	ctrl.mu.Lock()
+	defer ctrl.mu.Unlock()
+	return string(ctrl.config), nil
+}
+
+func (ctrl *casController) ConfigSetIfVersion(_ uuid.UUID, version string, devConfig []byte) error {
+	if ctrl.unsupported {
+		return types.ErrConditionalConfigUnsupported
+	}
+	ctrl.mu.Lock()
+	defer ctrl.mu.Unlock()
+	var current config.EdgeDevConfig
+	if err := proto.Unmarshal(ctrl.config, &current); err != nil {
+		return err
+	}
+	if current.GetId().GetVersion() != version {
+		return types.ErrConfigVersionMismatch
+	}
+	ctrl.config = devConfig
+	return nil
+}
+
+func (ctrl *casController) InfoLastCallback(uuid.UUID, map[string]string, einfo.HandlerFunc) error {
+	return nil
+}
+
+// loadDevice returns device parsed from config in controller as loaded by separate eden instance
+func loadDevice(t *testing.T, ctrl *casController, vars *utils.ConfigVars) (*controller.CloudCtx, *device.Ctx) {
+	cloud := &controller.CloudCtx{Controller: ctrl}
+	cloud.SetVars(vars)
+	var devConfig config.EdgeDevConfig
+	assert.NoError(t, proto.Unmarshal(ctrl.config, &devConfig))
+	dev, err := cloud.ConfigParse(&devConfig)
+	assert.NoError(t, err)
+	dev.SetControllerConfig(ctrl.config)
+	return cloud, dev
+}
+
+func TestConfigPushConcurrent(t *testing.T) {
+	t.Parallel()
+
+	setItem := func(dev *device.Ctx) error {
+		dev.SetConfigItem("timer.config.interval", "10")
+		return nil
+	}
+	setOtherItem := func(dev *device.Ctx) error {
+		dev.SetConfigItem("timer.config.interval", "20")
+		return nil
+	}
+	setReboot := func(dev *device.Ctx) error {
+		dev.SetRebootCounter(5, true)
+		return nil
+	}
+
+	tests := []struct {
+		name        string
+		changes     [2]controller.DeviceChange
+		unsupported bool
+		forcePush   bool
+		conflicts   int
+	}{
+		{name: "different sections", changes: [2]controller.DeviceChange{setItem, setReboot}},
+		{name: "same section", changes: [2]controller.DeviceChange{setItem, setOtherItem}, conflicts: 1},
+		{name: "unsupported", changes: [2]controller.DeviceChange{setItem, setReboot}, unsupported: true},
+		// the last push wins without conditional update
+		{name: "forced", changes: [2]controller.DeviceChange{setItem, setReboot}, unsupported: true, forcePush: true},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			base := device.CreateEdgeNode()
+			base.SetID(uuid.Must(uuid.NewV4()))
+			ctrl := &casController{unsupported: tt.unsupported}
+			baseCloud := &controller.CloudCtx{Controller: ctrl}
+			baseCloud.SetVars(&utils.ConfigVars{})
+			baseConfig, err := baseCloud.GetConfigBytes(base, false)
+			assert.NoError(t, err)
+			ctrl.config = baseConfig
+			var loaded config.EdgeDevConfig
+			assert.NoError(t, proto.Unmarshal(baseConfig, &loaded))
+			baseVersion, err := strconv.Atoi(loaded.GetId().GetVersion())
+			assert.NoError(t, err)
+
+			// both writers load config before any of them pushes
+			var clouds [2]*controller.CloudCtx
+			var devs [2]*device.Ctx
+			for i := range tt.changes {
+				clouds[i], devs[i] = loadDevice(t, ctrl, &utils.ConfigVars{ForcePush: tt.forcePush})
+			}
+			var results [2]*controller.BatchResult
+			var wg sync.WaitGroup
+			for i := range tt.changes {
+				wg.Add(1)
+				go func(i int) {
+					defer wg.Done()
+					results[i] = clouds[i].BatchApply([]*device.Ctx{devs[i]}, tt.changes[i], 1)[0]
+				}(i)
+			}
+			wg.Wait()
+
+			if tt.unsupported && !tt.forcePush {
+				for _, result := range results {
+					assert.ErrorIs(t, result.Err, types.ErrConditionalConfigUnsupported)
+				}
+				assert.Equal(t, baseConfig, ctrl.config)
+				return
+			}
+			conflicts := 0
+			for _, result := range results {
+				if result.Err != nil {
+					assert.ErrorIs(t, result.Err, controller.ErrConfigConflict)
+					conflicts++
+				}
+			}
+			assert.Equal(t, tt.conflicts, conflicts)
+
+			var final config.EdgeDevConfig
+			assert.NoError(t, proto.Unmarshal(ctrl.config, &final))
+			// every successful push increments version once
+			if tt.forcePush {
+				assert.Equal(t, strconv.Itoa(baseVersion+1), final.GetId().GetVersion())
+				return
+			}
+			assert.Equal(t, strconv.Itoa(baseVersion+len(results)-conflicts), final.GetId().GetVersion())
+			if tt.conflicts == 0 {
+				// changes of both writers are kept
+				assert.Len(t, final.GetConfigItems(), 1)
+				assert.Equal(t, uint32(5), final.GetReboot().GetCounter())
+			}
+		})
+	}
+}
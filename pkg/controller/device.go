@@ -9,8 +9,11 @@ import (
 	"strconv"
 	"time"
 
+	"github.com/lf-edge/eden/pkg/controller/replay"
 	"github.com/lf-edge/eden/pkg/controller/types"
+	"github.com/lf-edge/eden/pkg/defaults"
 	"github.com/lf-edge/eden/pkg/device"
+	"github.com/lf-edge/eden/pkg/edensdn"
 	"github.com/lf-edge/eden/pkg/models"
 	"github.com/lf-edge/eden/pkg/utils"
 	"github.com/lf-edge/eve-api/go/config"
@@ -21,6 +24,10 @@ import (
 
 // StateUpdate refresh state file
 func (cloud *CloudCtx) StateUpdate(dev *device.Ctx) (err error) {
+	if replay.Replaying() {
+		// configs of replay do not reach device, so state of eden is not changed
+		return nil
+	}
 	devConfig, err := cloud.GetConfigBytes(dev, true)
 	if err != nil {
 		return err
@@ -220,9 +227,11 @@ func (cloud *CloudCtx) ConfigParse(config *config.EdgeDevConfig) (*device.Ctx, e
 	dev.SetGlobalProfile(config.GlobalProfile)
 	dev.SetLocalProfileServer(config.LocalProfileServer)
 	dev.SetProfileServerToken(config.ProfileServerToken)
+	dev.SetLocURL(config.GetLocConfig().GetLocUrl())
 	dev.SetRemote(cloud.vars.EveRemote)
 	dev.SetRemoteAddr(cloud.vars.EveRemoteAddr)
 	dev.SetCipherContexts(config.CipherContexts)
+	dev.SetEdgeViewConfig(config.Edgeview)
 
 	if config.Disks != nil {
 		layout, err := device.ParseDiskLayout(config.Disks)
@@ -237,6 +246,7 @@ func (cloud *CloudCtx) ConfigParse(config *config.EdgeDevConfig) (*device.Ctx, e
 		return nil, fmt.Errorf("GetConfigBytes error: %s", err)
 	}
 	dev.CheckHash(sha256.Sum256(res))
+	dev.SetGeneratedConfig(res)
 	return dev, nil
 }
 
@@ -246,14 +256,20 @@ func (cloud *CloudCtx) ConfigSync(dev *device.Ctx) (err error) {
 	if err != nil {
 		return err
 	}
-	hash := sha256.Sum256(devConfig)
-	if dev.CheckHash(hash) {
-		fmt.Println("config changed, to see config run 'eden controller edge-node get-config'")
-		if devConfig, err = VersionIncrement(devConfig); err != nil {
-			return fmt.Errorf("VersionIncrement error: %s", err)
+	recordConfigCoverage(devConfig)
+	if cloud.vars.DryRun {
+		diff, err := cloud.ConfigDiffPending(dev)
+		if err != nil {
+			return fmt.Errorf("ConfigDiffPending: %w", err)
 		}
-		dev.CheckHash(sha256.Sum256(devConfig))
-		if err = cloud.ConfigSet(dev.GetID(), devConfig); err != nil {
+		PrintConfigDiff(diff)
+		fmt.Println("dry-run: config not pushed to controller")
+		return nil
+	}
+	// hash is recorded by configPush once the config is pushed
+	if dev.HashChanged(sha256.Sum256(devConfig)) {
+		fmt.Println("config changed, to see config run 'eden controller edge-node get-config'")
+		if _, err = cloud.configPush(dev, devConfig); err != nil {
 			return err
 		}
 		time.Sleep(time.Second)
@@ -298,6 +314,7 @@ func (cloud *CloudCtx) processDev(id uuid.UUID, state device.EdgeNodeState) {
 	if err != nil {
 		log.Fatalf("configParse error: %s", err)
 	}
+	dev.SetControllerConfig([]byte(configString))
 	dev.SetState(state)
 	cloud.devices = append(cloud.devices, dev)
 
@@ -350,6 +367,46 @@ func (cloud *CloudCtx) ApplyDevModel(dev *device.Ctx, devModel models.DevModel)
 			log.Errorf("ApplyDevModel: cannot overwrite devmodel from file: %v", err)
 		}
 	}
+	if len(cloud.vars.EvePCIPassthrough) > 0 {
+		devices, err := models.ParsePCIPassthroughList(cloud.vars.EvePCIPassthrough)
+		if err != nil {
+			return err
+		}
+		// devices of host are passed into EVE running in QEMU
+		virtual := devModel.DevModelType() == defaults.DefaultQemuModel
+		physicalIOs, err := models.PCIPhysicalIOs(devices, virtual)
+		if err != nil {
+			return err
+		}
+		devModel.SetPhysicalIOs(append(devModel.PhysicalIOs(), physicalIOs...))
+	}
+	if cloud.vars.EveCellularAPN != "" {
+		models.AddCellularModem(devModel, cloud.vars.EveCellularAPN)
+	}
+	if cloud.vars.SdnProxy != "" {
+		// controller is reachable only through the proxy of SDN
+		caCert, _, err := edensdn.LoadProxyCA(cloud.vars.SdnConfigDir)
+		if err != nil {
+			return err
+		}
+		proxyConfig, err := edensdn.GetProxyDeviceConfig(cloud.vars.SdnProxy, caCert)
+		if err != nil {
+			return err
+		}
+		for _, el := range devModel.Networks() {
+			el.EntProxy = proto.Clone(proxyConfig).(*config.ProxyConfig)
+		}
+	}
+	networkType, err := models.NetworkTypeForIPVersions(cloud.vars.SdnNetworkType)
+	if err != nil {
+		return err
+	}
+	for _, el := range devModel.Networks() {
+		// networks with explicitly selected IP versions are kept as they are
+		if el.Type == config.NetworkType_V4 {
+			el.Type = networkType
+		}
+	}
 	dev.SetAdaptersForSwitch(devModel.AdapterForSwitches())
 	var adapters []string
 	for _, el := range devModel.Adapters() {
@@ -620,6 +677,11 @@ volumeLoop:
 		})
 	}
 
+	var locConfig *config.LOCConfig
+	if locURL := dev.GetLocURL(); locURL != "" {
+		locConfig = &config.LOCConfig{LocUrl: locURL}
+	}
+
 	var disksConfig *config.DisksConfig
 	if layout := dev.GetDiskLayout(); layout != nil {
 		var err error
@@ -663,7 +725,10 @@ volumeLoop:
 		LocalProfileServer: dev.GetLocalProfileServer(),
 		ProfileServerToken: dev.GetProfileServerToken(),
 		Disks:              disksConfig,
+		Edgeview:           dev.GetEdgeViewConfig(),
+		LocConfig:          locConfig,
 	}
+	cloud.applyCompatShims(dev, devConfig)
 	if jsonFormat {
 		return json.MarshalIndent(devConfig, "", "    ")
 	}
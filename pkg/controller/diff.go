@@ -0,0 +1,259 @@
+package controller
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/lf-edge/eden/pkg/device"
+	"github.com/lf-edge/eve-api/go/config"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
+
+// DiffOp is type of change in config
+type DiffOp string
+
+// types of change in config
+const (
+	DiffAdded    DiffOp = "+"
+	DiffRemoved  DiffOp = "-"
+	DiffModified DiffOp = "~"
+)
+
+// DiffEntry describes one change between two configs
+type DiffEntry struct {
+	Op   DiffOp      `json:"op"`
+	Path string      `json:"path"`
+	Old  interface{} `json:"old,omitempty"`
+	New  interface{} `json:"new,omitempty"`
+}
+
+func (entry *DiffEntry) String() string {
+	toString := func(v interface{}) string {
+		data, err := json.Marshal(v)
+		if err != nil {
+			return fmt.Sprint(v)
+		}
+		return string(data)
+	}
+	switch entry.Op {
+	case DiffAdded:
+		return fmt.Sprintf("%s %s: %s", entry.Op, entry.Path, toString(entry.New))
+	case DiffRemoved:
+		return fmt.Sprintf("%s %s: %s", entry.Op, entry.Path, toString(entry.Old))
+	default:
+		return fmt.Sprintf("%s %s: %s -> %s", entry.Op, entry.Path, toString(entry.Old), toString(entry.New))
+	}
+}
+
+// keys used to match elements of lists, the first found one is used
+var diffIDKeys = []string{"uuidandversion.uuid", "id.uuid", "uuid", "id", "key", "logicallabel", "name"}
+
+func configToMap(cfg *config.EdgeDevConfig) (map[string]interface{}, error) {
+	// emit default values to show fields reset to them as modified, not removed
+	data, err := protojson.MarshalOptions{EmitUnpopulated: true}.Marshal(cfg)
+	if err != nil {
+		return nil, err
+	}
+	result := map[string]interface{}{}
+	if err := json.Unmarshal(data, &result); err != nil {
+		return nil, err
+	}
+	// version is incremented on every push, so it is not interesting
+	if id, ok := result["id"].(map[string]interface{}); ok {
+		delete(id, "version")
+	}
+	return result, nil
+}
+
+func lookupKey(obj map[string]interface{}, key string) (string, bool) {
+	var cur interface{} = obj
+	for _, part := range strings.Split(key, ".") {
+		m, ok := cur.(map[string]interface{})
+		if !ok {
+			return "", false
+		}
+		// field names in JSON are lowerCamelCase, compare in lower case
+		found := false
+		for k, v := range m {
+			if strings.EqualFold(k, part) {
+				cur, found = v, true
+				break
+			}
+		}
+		if !found {
+			return "", false
+		}
+	}
+	switch v := cur.(type) {
+	case string:
+		return v, v != ""
+	case map[string]interface{}, []interface{}:
+		return "", false
+	default:
+		return fmt.Sprint(v), true
+	}
+}
+
+// listIDs returns key to match elements of both lists or empty string if elements cannot be matched
+func listIDs(oldList, newList []interface{}) string {
+	for _, key := range diffIDKeys {
+		suitable := true
+		for _, el := range append(append([]interface{}{}, oldList...), newList...) {
+			obj, ok := el.(map[string]interface{})
+			if !ok {
+				return ""
+			}
+			if _, ok := lookupKey(obj, key); !ok {
+				suitable = false
+				break
+			}
+		}
+		if suitable {
+			return key
+		}
+	}
+	return ""
+}
+
+func diffValues(path string, oldValue, newValue interface{}) []*DiffEntry {
+	switch {
+	case oldValue == nil && newValue == nil:
+		return nil
+	case oldValue == nil:
+		return []*DiffEntry{{Op: DiffAdded, Path: path, New: newValue}}
+	case newValue == nil:
+		return []*DiffEntry{{Op: DiffRemoved, Path: path, Old: oldValue}}
+	}
+	oldMap, oldIsMap := oldValue.(map[string]interface{})
+	newMap, newIsMap := newValue.(map[string]interface{})
+	if oldIsMap && newIsMap {
+		return diffMaps(path, oldMap, newMap)
+	}
+	oldList, oldIsList := oldValue.([]interface{})
+	newList, newIsList := newValue.([]interface{})
+	if oldIsList && newIsList {
+		return diffLists(path, oldList, newList)
+	}
+	if !reflect.DeepEqual(oldValue, newValue) {
+		return []*DiffEntry{{Op: DiffModified, Path: path, Old: oldValue, New: newValue}}
+	}
+	return nil
+}
+
+func diffMaps(path string, oldMap, newMap map[string]interface{}) []*DiffEntry {
+	keys := map[string]struct{}{}
+	for k := range oldMap {
+		keys[k] = struct{}{}
+	}
+	for k := range newMap {
+		keys[k] = struct{}{}
+	}
+	sortedKeys := make([]string, 0, len(keys))
+	for k := range keys {
+		sortedKeys = append(sortedKeys, k)
+	}
+	sort.Strings(sortedKeys)
+	var result []*DiffEntry
+	for _, k := range sortedKeys {
+		subPath := k
+		if path != "" {
+			subPath = fmt.Sprintf("%s.%s", path, k)
+		}
+		result = append(result, diffValues(subPath, oldMap[k], newMap[k])...)
+	}
+	return result
+}
+
+func diffLists(path string, oldList, newList []interface{}) []*DiffEntry {
+	key := listIDs(oldList, newList)
+	if key == "" {
+		var result []*DiffEntry
+		for i := 0; i < len(oldList) || i < len(newList); i++ {
+			var oldValue, newValue interface{}
+			if i < len(oldList) {
+				oldValue = oldList[i]
+			}
+			if i < len(newList) {
+				newValue = newList[i]
+			}
+			result = append(result, diffValues(fmt.Sprintf("%s[%d]", path, i), oldValue, newValue)...)
+		}
+		return result
+	}
+	oldByID := map[string]interface{}{}
+	var ids []string
+	for _, el := range oldList {
+		id, _ := lookupKey(el.(map[string]interface{}), key)
+		oldByID[id] = el
+		ids = append(ids, id)
+	}
+	newByID := map[string]interface{}{}
+	for _, el := range newList {
+		id, _ := lookupKey(el.(map[string]interface{}), key)
+		newByID[id] = el
+		if _, ok := oldByID[id]; !ok {
+			ids = append(ids, id)
+		}
+	}
+	var result []*DiffEntry
+	for _, id := range ids {
+		subPath := fmt.Sprintf("%s[%s=%s]", path, key, id)
+		result = append(result, diffValues(subPath, oldByID[id], newByID[id])...)
+	}
+	return result
+}
+
+// ConfigDiff returns structured difference between two configs of device
+func ConfigDiff(oldConfig, newConfig *config.EdgeDevConfig) ([]*DiffEntry, error) {
+	oldMap, err := configToMap(oldConfig)
+	if err != nil {
+		return nil, fmt.Errorf("cannot convert old config: %w", err)
+	}
+	newMap, err := configToMap(newConfig)
+	if err != nil {
+		return nil, fmt.Errorf("cannot convert new config: %w", err)
+	}
+	return diffMaps("", oldMap, newMap), nil
+}
+
+// ConfigDiffWithController returns difference between config of device stored in controller and newConfig
+func (cloud *CloudCtx) ConfigDiffWithController(dev *device.Ctx, newConfig *config.EdgeDevConfig) ([]*DiffEntry, error) {
+	current, err := cloud.ConfigGet(dev.GetID())
+	if err != nil {
+		return nil, fmt.Errorf("ConfigGet: %w", err)
+	}
+	var currentConfig config.EdgeDevConfig
+	if err := proto.Unmarshal([]byte(current), &currentConfig); err != nil {
+		return nil, fmt.Errorf("cannot unmarshal config from controller: %w", err)
+	}
+	return ConfigDiff(&currentConfig, newConfig)
+}
+
+// ConfigDiffPending returns difference between config of device stored in controller
+// and config which will be pushed by ConfigSync
+func (cloud *CloudCtx) ConfigDiffPending(dev *device.Ctx) ([]*DiffEntry, error) {
+	pending, err := cloud.GetConfigBytes(dev, false)
+	if err != nil {
+		return nil, fmt.Errorf("GetConfigBytes: %w", err)
+	}
+	var pendingConfig config.EdgeDevConfig
+	if err := proto.Unmarshal(pending, &pendingConfig); err != nil {
+		return nil, fmt.Errorf("cannot unmarshal pending config: %w", err)
+	}
+	return cloud.ConfigDiffWithController(dev, &pendingConfig)
+}
+
+// PrintConfigDiff prints difference between configs in human-readable form
+func PrintConfigDiff(diff []*DiffEntry) {
+	if len(diff) == 0 {
+		fmt.Println("no changes in config")
+		return
+	}
+	for _, el := range diff {
+		fmt.Println(el)
+	}
+}
@@ -0,0 +1,46 @@
+package controller_test
+
+import (
+	"testing"
+
+	"github.com/lf-edge/eden/pkg/controller"
+	"github.com/lf-edge/eve-api/go/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConfigDiff(t *testing.T) {
+	t.Parallel()
+
+	app := func(id, name string, activate bool) *config.AppInstanceConfig {
+		return &config.AppInstanceConfig{
+			Uuidandversion: &config.UUIDandVersion{Uuid: id, Version: "1"},
+			Displayname:    name,
+			Activate:       activate,
+		}
+	}
+	oldConfig := &config.EdgeDevConfig{
+		Id:   &config.UUIDandVersion{Uuid: "dev", Version: "1"},
+		Apps: []*config.AppInstanceConfig{app("a", "first", true), app("b", "second", true)},
+	}
+	newConfig := &config.EdgeDevConfig{
+		Id:   &config.UUIDandVersion{Uuid: "dev", Version: "2"},
+		Apps: []*config.AppInstanceConfig{app("b", "second", false), app("c", "third", true)},
+	}
+
+	diff, err := controller.ConfigDiff(oldConfig, newConfig)
+	assert.NoError(t, err)
+
+	var result []string
+	for _, el := range diff {
+		result = append(result, string(el.Op)+" "+el.Path)
+	}
+	assert.Equal(t, []string{
+		"- apps[uuidandversion.uuid=a]",
+		"~ apps[uuidandversion.uuid=b].activate",
+		"+ apps[uuidandversion.uuid=c]",
+	}, result)
+
+	diff, err = controller.ConfigDiff(oldConfig, oldConfig)
+	assert.NoError(t, err)
+	assert.Empty(t, diff)
+}
@@ -0,0 +1,145 @@
+package elog
+
+import (
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strings"
+	"text/template"
+	"time"
+
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// severityRanks orders severities of EVE logs from the most severe, EVE uses names of
+// logrus levels for its agents and syslog names for kernel and other system logs
+var severityRanks = map[string]int{
+	"panic":   0,
+	"emerg":   0,
+	"fatal":   1,
+	"alert":   1,
+	"crit":    1,
+	"error":   2,
+	"err":     2,
+	"warning": 3,
+	"warn":    3,
+	"notice":  4,
+	"info":    4,
+	"debug":   5,
+	"trace":   6,
+}
+
+// Filter selects log entries by severity, source, time range and content.
+// Zero values of fields do not restrict entries.
+type Filter struct {
+	// Severity is the least severe level to show, e.g. warning shows also error and fatal
+	Severity string
+	// Sources are names of agents or components emitted log, e.g. zedagent
+	Sources []string
+	Since   time.Time
+	Until   time.Time
+	// Match is checked against content of log entry
+	Match *regexp.Regexp
+}
+
+// Validate checks that severity is known
+func (f *Filter) Validate() error {
+	if f.Severity == "" {
+		return nil
+	}
+	if _, ok := severityRanks[strings.ToLower(f.Severity)]; !ok {
+		var known []string
+		for name := range severityRanks {
+			known = append(known, name)
+		}
+		sort.Strings(known)
+		return fmt.Errorf("unknown severity %q (known: %s)", f.Severity, strings.Join(known, ", "))
+	}
+	return nil
+}
+
+// IsEmpty returns true if filter passes all entries
+func (f *Filter) IsEmpty() bool {
+	return f.Severity == "" && len(f.Sources) == 0 && f.Since.IsZero() && f.Until.IsZero() && f.Match == nil
+}
+
+// Matches returns true if entry passes the filter
+func (f *Filter) Matches(le *FullLogEntry) bool {
+	if f.Severity != "" {
+		rank, ok := severityRanks[strings.ToLower(le.Severity)]
+		if !ok || rank > severityRanks[strings.ToLower(f.Severity)] {
+			return false
+		}
+	}
+	if len(f.Sources) > 0 {
+		found := false
+		for _, source := range f.Sources {
+			if le.Source == source {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	if !f.Since.IsZero() || !f.Until.IsZero() {
+		ts := le.Timestamp.AsTime()
+		if !f.Since.IsZero() && ts.Before(f.Since) {
+			return false
+		}
+		if !f.Until.IsZero() && ts.After(f.Until) {
+			return false
+		}
+	}
+	if f.Match != nil && !f.Match.MatchString(le.Content) {
+		return false
+	}
+	return true
+}
+
+// FilterHandler calls handler only for entries passing the filter
+func FilterHandler(f *Filter, handler HandlerFunc) HandlerFunc {
+	return func(le *FullLogEntry) bool {
+		if !f.Matches(le) {
+			return false
+		}
+		return handler(le)
+	}
+}
+
+// ParseTime parses moment of time for filter either in RFC3339 format or as duration
+// before now (e.g. 15m)
+func ParseTime(value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	if d, err := time.ParseDuration(value); err == nil {
+		return time.Now().Add(-d), nil
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("cannot parse time %q: expected RFC3339 or duration", value)
+	}
+	return t, nil
+}
+
+// NewTemplate parses template to print log entries with, fields of FullLogEntry are
+// available inside (e.g. {{.Source}}) with functions time (converts timestamp)
+// and trim (trims spaces)
+func NewTemplate(text string) (*template.Template, error) {
+	return template.New("log").Funcs(template.FuncMap{
+		"time": func(ts *timestamppb.Timestamp) time.Time { return ts.AsTime() },
+		"trim": strings.TrimSpace,
+	}).Parse(text)
+}
+
+// LogTemplatePrint prints entry using template followed by newline
+func LogTemplatePrint(w io.Writer, le *FullLogEntry, tmpl *template.Template) error {
+	if err := tmpl.Execute(w, le); err != nil {
+		return err
+	}
+	_, err := fmt.Fprintln(w)
+	return err
+}
@@ -0,0 +1,85 @@
+package elog_test
+
+import (
+	"bytes"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/lf-edge/eden/pkg/controller/elog"
+	"github.com/lf-edge/eve-api/go/logs"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func entry(severity, source, content string, ts time.Time) *elog.FullLogEntry {
+	return &elog.FullLogEntry{LogEntry: logs.LogEntry{
+		Severity:  severity,
+		Source:    source,
+		Content:   content,
+		Timestamp: timestamppb.New(ts),
+	}}
+}
+
+func TestFilter(t *testing.T) {
+	t.Parallel()
+
+	now := time.Now()
+	entries := []*elog.FullLogEntry{
+		entry("info", "zedagent", "config received", now.Add(-2*time.Hour)),
+		entry("warning", "zedagent", "slow download", now.Add(-30*time.Minute)),
+		entry("error", "nim", "no connectivity", now.Add(-10*time.Minute)),
+		entry("err", "kernel", "I/O error", now.Add(-5*time.Minute)),
+		entry("unknown", "zedagent", "strange", now),
+	}
+	count := func(f *elog.Filter) (n int) {
+		for _, le := range entries {
+			if f.Matches(le) {
+				n++
+			}
+		}
+		return n
+	}
+
+	assert.True(t, (&elog.Filter{}).IsEmpty())
+	assert.Equal(t, 5, count(&elog.Filter{}))
+	assert.Equal(t, 3, count(&elog.Filter{Severity: "WARNING"}))
+	assert.Equal(t, 2, count(&elog.Filter{Severity: "error"}))
+	assert.Equal(t, 3, count(&elog.Filter{Sources: []string{"zedagent"}}))
+	assert.Equal(t, 2, count(&elog.Filter{Sources: []string{"zedagent"}, Severity: "info"}))
+	assert.Equal(t, 3, count(&elog.Filter{Since: now.Add(-time.Hour), Until: now.Add(-time.Minute)}))
+	assert.Equal(t, 2, count(&elog.Filter{Match: regexp.MustCompile(`(?i)error|connectivity`)}))
+
+	assert.NoError(t, (&elog.Filter{Severity: "notice"}).Validate())
+	assert.Error(t, (&elog.Filter{Severity: "loud"}).Validate())
+}
+
+func TestParseTime(t *testing.T) {
+	t.Parallel()
+
+	ts, err := elog.ParseTime("1h")
+	assert.NoError(t, err)
+	assert.WithinDuration(t, time.Now().Add(-time.Hour), ts, time.Minute)
+
+	ts, err = elog.ParseTime("2024-01-02T03:04:05Z")
+	assert.NoError(t, err)
+	assert.Equal(t, time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC), ts)
+
+	ts, err = elog.ParseTime("")
+	assert.NoError(t, err)
+	assert.True(t, ts.IsZero())
+
+	_, err = elog.ParseTime("yesterday")
+	assert.Error(t, err)
+}
+
+func TestTemplate(t *testing.T) {
+	t.Parallel()
+
+	tmpl, err := elog.NewTemplate(`{{(time .Timestamp).UTC.Format "15:04"}} {{.Severity}} {{.Source}}: {{trim .Content}}`)
+	assert.NoError(t, err)
+	var buf bytes.Buffer
+	le := entry("info", "zedagent", " started \n", time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC))
+	assert.NoError(t, elog.LogTemplatePrint(&buf, le, tmpl))
+	assert.Equal(t, "03:04 info zedagent: started\n", buf.String())
+}
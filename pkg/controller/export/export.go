@@ -0,0 +1,387 @@
+// Package export converts config of device stored in Adam into objects of zedcloud API
+// and into resources of zedcloud Terraform provider to promote them to other controllers.
+package export
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/lf-edge/eve-api/go/config"
+	log "github.com/sirupsen/logrus"
+)
+
+// Format of exported config
+type Format int
+
+// supported formats
+const (
+	FormatZedcloud Format = iota
+	FormatTerraform
+)
+
+// Kind of zedcloud object
+type Kind string
+
+// kinds of exported objects
+const (
+	KindDatastore           Kind = "datastore"
+	KindImage               Kind = "image"
+	KindNetwork             Kind = "network"
+	KindNetworkInstance     Kind = "network_instance"
+	KindVolumeInstance      Kind = "volume_instance"
+	KindApplication         Kind = "application"
+	KindApplicationInstance Kind = "application_instance"
+)
+
+// jsonKey returns key of list of objects of kind in JSON
+func (k Kind) jsonKey() string {
+	parts := strings.Split(string(k), "_")
+	for i := 1; i < len(parts); i++ {
+		parts[i] = strings.ToUpper(parts[i][:1]) + parts[i][1:]
+	}
+	return strings.Join(parts, "") + "s"
+}
+
+// Ref is reference to another exported object, it is rendered as name of the object
+// in JSON and as id attribute of the resource in Terraform
+type Ref struct {
+	Kind Kind
+	Name string
+}
+
+// MarshalJSON implements json.Marshaler
+func (r Ref) MarshalJSON() ([]byte, error) {
+	return json.Marshal(r.Name)
+}
+
+// Object is object of zedcloud API
+type Object struct {
+	Kind Kind
+	Name string
+	// Fields of object in notation of zedcloud API, values are strings, numbers,
+	// booleans, Ref, nested maps and lists of them
+	Fields map[string]interface{}
+}
+
+// Objects is set of exported objects
+type Objects []*Object
+
+// MarshalJSON implements json.Marshaler, objects are grouped by kind
+func (o Objects) MarshalJSON() ([]byte, error) {
+	result := map[string][]map[string]interface{}{}
+	for _, obj := range o {
+		fields := map[string]interface{}{"name": obj.Name, "title": obj.Name}
+		for k, v := range obj.Fields {
+			fields[k] = v
+		}
+		result[obj.Kind.jsonKey()] = append(result[obj.Kind.jsonKey()], fields)
+	}
+	return json.Marshal(result)
+}
+
+// Render returns objects in format
+func (o Objects) Render(format Format) ([]byte, error) {
+	switch format {
+	case FormatZedcloud:
+		return json.MarshalIndent(o, "", "    ")
+	case FormatTerraform:
+		return []byte(renderHCL(o)), nil
+	}
+	return nil, fmt.Errorf("unsupported format %d", format)
+}
+
+var nameRe = regexp.MustCompile(`[^A-Za-z0-9_-]`)
+
+// objectName returns name of object usable in zedcloud and as label of Terraform resource
+func objectName(name, prefix, id string) string {
+	if name == "" {
+		name = prefix + "-" + strings.Split(id, "-")[0]
+	}
+	name = nameRe.ReplaceAllString(name, "_")
+	if name[0] >= '0' && name[0] <= '9' || name[0] == '-' {
+		name = "_" + name
+	}
+	return name
+}
+
+type exporter struct {
+	cfg     *config.EdgeDevConfig
+	objects Objects
+	// names of exported objects by UUID
+	names map[Kind]map[string]string
+}
+
+func (e *exporter) add(kind Kind, id, name string, fields map[string]interface{}) {
+	if e.names[kind] == nil {
+		e.names[kind] = map[string]string{}
+	}
+	e.names[kind][id] = name
+	e.objects = append(e.objects, &Object{Kind: kind, Name: name, Fields: fields})
+}
+
+func (e *exporter) ref(kind Kind, id string) interface{} {
+	if name, ok := e.names[kind][id]; ok {
+		return Ref{Kind: kind, Name: name}
+	}
+	log.Warnf("%s %s is referenced but not found in config", kind, id)
+	return id
+}
+
+// Export returns objects of zedcloud API for datastores, images, networks, network
+// instances, volumes and applications from config of device. Credentials of
+// datastores are not exported and must be set on the target controller.
+func Export(cfg *config.EdgeDevConfig) Objects {
+	e := &exporter{cfg: cfg, names: map[Kind]map[string]string{}}
+	e.datastores()
+	e.images()
+	e.networks()
+	e.networkInstances()
+	e.volumes()
+	e.applications()
+	return e.objects
+}
+
+var dsTypes = map[config.DsType]string{
+	config.DsType_DsHttp:              "DATASTORE_TYPE_HTTP",
+	config.DsType_DsHttps:             "DATASTORE_TYPE_HTTPS",
+	config.DsType_DsS3:                "DATASTORE_TYPE_AWS_S3",
+	config.DsType_DsSFTP:              "DATASTORE_TYPE_SFTP",
+	config.DsType_DsContainerRegistry: "DATASTORE_TYPE_CONTAINERREGISTRY",
+	config.DsType_DsAzureBlob:         "DATASTORE_TYPE_AZUREBLOB",
+	config.DsType_DsGoogleStorage:     "DATASTORE_TYPE_GOOGLE_STORAGE",
+}
+
+func (e *exporter) datastores() {
+	for _, ds := range e.cfg.GetDatastores() {
+		name := objectName("", "datastore", ds.GetId())
+		fields := map[string]interface{}{
+			"dsType": dsTypes[ds.GetDType()],
+			"dsFQDN": ds.GetFqdn(),
+			"dsPath": ds.GetDpath(),
+		}
+		if ds.GetRegion() != "" {
+			fields["region"] = ds.GetRegion()
+		}
+		if ds.GetApiKey() != "" || ds.GetPassword() != "" {
+			log.Warnf("credentials of datastore %s are not exported", name)
+		}
+		e.add(KindDatastore, ds.GetId(), name, fields)
+	}
+}
+
+func (e *exporter) images() {
+	for _, ct := range e.cfg.GetContentInfo() {
+		name := objectName(ct.GetDisplayName(), "image", ct.GetUuid())
+		fields := map[string]interface{}{
+			"datastoreId": e.ref(KindDatastore, ct.GetDsId()),
+			"imageFormat": ct.GetIformat().String(),
+			"imageType":   "IMAGE_TYPE_APPLICATION",
+			"imageRelUrl": ct.GetURL(),
+		}
+		if ct.GetSha256() != "" {
+			fields["imageSha256"] = ct.GetSha256()
+		}
+		if ct.GetMaxSizeBytes() > 0 {
+			fields["imageSizeBytes"] = ct.GetMaxSizeBytes()
+		}
+		e.add(KindImage, ct.GetUuid(), name, fields)
+	}
+}
+
+var dhcpTypes = map[config.DHCPType]string{
+	config.DHCPType_Static:   "NETWORK_DHCP_TYPE_STATIC",
+	config.DHCPType_DHCPNone: "NETWORK_DHCP_TYPE_PASSTHROUGH",
+	config.DHCPType_Client:   "NETWORK_DHCP_TYPE_CLIENT",
+}
+
+// ipSpec returns ip block of network or network instance
+func ipSpec(ip *config.Ipspec) map[string]interface{} {
+	if ip == nil {
+		return nil
+	}
+	result := map[string]interface{}{}
+	if dhcp, ok := dhcpTypes[ip.GetDhcp()]; ok {
+		result["dhcp"] = dhcp
+	}
+	for k, v := range map[string]string{
+		"subnet": ip.GetSubnet(), "gateway": ip.GetGateway(),
+		"domain": ip.GetDomain(), "ntp": ip.GetNtp(),
+	} {
+		if v != "" {
+			result[k] = v
+		}
+	}
+	if len(ip.GetDns()) > 0 {
+		result["dns"] = ip.GetDns()
+	}
+	if r := ip.GetDhcpRange(); r.GetStart() != "" {
+		result["dhcpRange"] = map[string]interface{}{"start": r.GetStart(), "end": r.GetEnd()}
+	}
+	return result
+}
+
+func (e *exporter) networks() {
+	for _, nw := range e.cfg.GetNetworks() {
+		name := objectName("", "network", nw.GetId())
+		fields := map[string]interface{}{"kind": "NETWORK_KIND_V4"}
+		if nw.GetType() == config.NetworkType_V6 || nw.GetType() == config.NetworkType_V6Only {
+			fields["kind"] = "NETWORK_KIND_V6"
+		}
+		if ip := ipSpec(nw.GetIp()); ip != nil {
+			fields["ip"] = ip
+		}
+		e.add(KindNetwork, nw.GetId(), name, fields)
+	}
+}
+
+func (e *exporter) networkInstances() {
+	for _, ni := range e.cfg.GetNetworkInstances() {
+		id := ni.GetUuidandversion().GetUuid()
+		name := objectName(ni.GetDisplayname(), "network-instance", id)
+		fields := map[string]interface{}{
+			"kind": "NETWORK_INSTANCE_KIND_LOCAL",
+			"type": "NETWORK_INSTANCE_DHCP_TYPE_V4",
+			"port": ni.GetPort().GetName(),
+		}
+		switch ni.GetInstType() {
+		case config.ZNetworkInstType_ZnetInstSwitch:
+			fields["kind"] = "NETWORK_INSTANCE_KIND_SWITCH"
+			fields["type"] = "NETWORK_INSTANCE_DHCP_TYPE_UNSPECIFIED"
+		case config.ZNetworkInstType_ZnetInstLocal:
+		default:
+			log.Warnf("network instance %s of type %s is exported as local", name, ni.GetInstType())
+		}
+		if ip := ipSpec(ni.GetIp()); len(ip) > 0 {
+			fields["ip"] = ip
+		}
+		e.add(KindNetworkInstance, id, name, fields)
+	}
+}
+
+func (e *exporter) volumes() {
+	formats := map[string]config.Format{}
+	for _, ct := range e.cfg.GetContentInfo() {
+		formats[ct.GetUuid()] = ct.GetIformat()
+	}
+	for _, vol := range e.cfg.GetVolumes() {
+		name := objectName(vol.GetDisplayName(), "volume", vol.GetUuid())
+		fields := map[string]interface{}{
+			"type":      "VOLUME_INSTANCE_TYPE_EMPTYDIR",
+			"sizeBytes": vol.GetMaxsizebytes(),
+			"cleartext": vol.GetClearText(),
+		}
+		if vol.GetOrigin().GetType() == config.VolumeContentOriginType_VCOT_DOWNLOAD {
+			ctID := vol.GetOrigin().GetDownloadContentTreeID()
+			fields["type"] = "VOLUME_INSTANCE_TYPE_BLOCK"
+			if formats[ctID] == config.Format_CONTAINER {
+				fields["type"] = "VOLUME_INSTANCE_TYPE_CONTENT_TREE"
+			}
+			fields["image"] = e.ref(KindImage, ctID)
+		}
+		e.add(KindVolumeInstance, vol.GetUuid(), name, fields)
+	}
+}
+
+// interfaces returns interfaces of application instance with ACLs
+func (e *exporter) interfaces(app *config.AppInstanceConfig) []interface{} {
+	var result []interface{}
+	for _, intf := range app.GetInterfaces() {
+		var acls []interface{}
+		for _, ace := range intf.GetAcls() {
+			var matches []interface{}
+			for _, m := range ace.GetMatches() {
+				matches = append(matches, map[string]interface{}{"type": m.GetType(), "value": m.GetValue()})
+			}
+			acl := map[string]interface{}{"matches": matches}
+			var actions []interface{}
+			for _, a := range ace.GetActions() {
+				action := map[string]interface{}{"drop": a.GetDrop(), "portmap": a.GetPortmap()}
+				if a.GetPortmap() {
+					action["portmapto"] = map[string]interface{}{"appPort": a.GetAppPort()}
+				}
+				actions = append(actions, action)
+			}
+			if len(actions) > 0 {
+				acl["actions"] = actions
+			}
+			acls = append(acls, acl)
+		}
+		fields := map[string]interface{}{
+			"intfname":    intf.GetName(),
+			"netinstname": e.ref(KindNetworkInstance, intf.GetNetworkId()),
+		}
+		if len(acls) > 0 {
+			fields["acls"] = acls
+		}
+		result = append(result, fields)
+	}
+	return result
+}
+
+func (e *exporter) applications() {
+	for _, app := range e.cfg.GetApps() {
+		id := app.GetUuidandversion().GetUuid()
+		name := objectName(app.GetDisplayname(), "app", id)
+		res := app.GetFixedresources()
+		var images, drives []interface{}
+		for _, ref := range app.GetVolumeRefList() {
+			drive := map[string]interface{}{"volumeInstance": e.ref(KindVolumeInstance, ref.GetUuid())}
+			if ref.GetMountDir() != "" {
+				drive["mountpath"] = ref.GetMountDir()
+			}
+			drives = append(drives, drive)
+			if vol := e.volume(ref.GetUuid()); vol.GetOrigin().GetType() == config.VolumeContentOriginType_VCOT_DOWNLOAD {
+				images = append(images, map[string]interface{}{
+					"imagename": e.ref(KindImage, vol.GetOrigin().GetDownloadContentTreeID()),
+				})
+			}
+		}
+		var intfNames []interface{}
+		for _, intf := range app.GetInterfaces() {
+			intfNames = append(intfNames, map[string]interface{}{"name": intf.GetName()})
+		}
+		manifest := map[string]interface{}{
+			"acKind":     "PodManifest",
+			"name":       name,
+			"vmmode":     "HV_" + res.GetVirtualizationMode().String(),
+			"enablevnc":  res.GetEnableVnc(),
+			"cpuPinning": res.GetPinCpu(),
+			"resources": []interface{}{
+				map[string]interface{}{"name": "cpus", "value": fmt.Sprint(res.GetVcpus())},
+				map[string]interface{}{"name": "memory", "value": fmt.Sprint(res.GetMemory())},
+			},
+		}
+		if len(images) > 0 {
+			manifest["images"] = images
+		}
+		if len(intfNames) > 0 {
+			manifest["interfaces"] = intfNames
+		}
+		e.add(KindApplication, id, name, map[string]interface{}{"manifest": manifest})
+		fields := map[string]interface{}{
+			"appId":    Ref{Kind: KindApplication, Name: name},
+			"activate": app.GetActivate(),
+		}
+		if intfs := e.interfaces(app); len(intfs) > 0 {
+			fields["interfaces"] = intfs
+		}
+		if len(drives) > 0 {
+			fields["drives"] = drives
+		}
+		if app.GetUserData() != "" {
+			fields["customConfig"] = map[string]interface{}{"template": app.GetUserData()}
+		}
+		e.add(KindApplicationInstance, id, name, fields)
+	}
+}
+
+func (e *exporter) volume(id string) *config.Volume {
+	for _, vol := range e.cfg.GetVolumes() {
+		if vol.GetUuid() == id {
+			return vol
+		}
+	}
+	return nil
+}
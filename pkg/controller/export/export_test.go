@@ -0,0 +1,77 @@
+package export_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/lf-edge/eden/pkg/controller/export"
+	"github.com/lf-edge/eve-api/go/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func testConfig() *config.EdgeDevConfig {
+	return &config.EdgeDevConfig{
+		Datastores: []*config.DatastoreConfig{{
+			Id: "ds-uuid", DType: config.DsType_DsContainerRegistry, Fqdn: "docker://docker.io", Password: "secret",
+		}},
+		ContentInfo: []*config.ContentTree{{
+			Uuid: "ct-uuid", DsId: "ds-uuid", URL: "library/nginx:latest",
+			Iformat: config.Format_CONTAINER, DisplayName: "nginx-image",
+		}},
+		NetworkInstances: []*config.NetworkInstanceConfig{{
+			Uuidandversion: &config.UUIDandVersion{Uuid: "ni-uuid"},
+			Displayname:    "local net",
+			InstType:       config.ZNetworkInstType_ZnetInstLocal,
+			Port:           &config.Adapter{Name: "uplink"},
+			Ip:             &config.Ipspec{Subnet: "10.1.0.0/24", Dns: []string{"10.1.0.1"}},
+		}},
+		Volumes: []*config.Volume{{
+			Uuid: "vol-uuid", DisplayName: "nginx-vol", Maxsizebytes: 1024,
+			Origin: &config.VolumeContentOrigin{
+				Type:                  config.VolumeContentOriginType_VCOT_DOWNLOAD,
+				DownloadContentTreeID: "ct-uuid",
+			},
+		}},
+		Apps: []*config.AppInstanceConfig{{
+			Uuidandversion: &config.UUIDandVersion{Uuid: "app-uuid"},
+			Displayname:    "nginx",
+			Activate:       true,
+			Fixedresources: &config.VmConfig{Memory: 1024, Vcpus: 1},
+			Interfaces:     []*config.NetworkAdapter{{Name: "eth0", NetworkId: "ni-uuid"}},
+			VolumeRefList:  []*config.VolumeRef{{Uuid: "vol-uuid", MountDir: "/"}},
+		}},
+	}
+}
+
+func TestExportZedcloud(t *testing.T) {
+	t.Parallel()
+
+	data, err := export.Export(testConfig()).Render(export.FormatZedcloud)
+	assert.NoError(t, err)
+
+	var result map[string][]map[string]interface{}
+	assert.NoError(t, json.Unmarshal(data, &result))
+	assert.Len(t, result["datastores"], 1)
+	assert.NotContains(t, string(data), "secret")
+	assert.Equal(t, "datastore-ds", result["images"][0]["datastoreId"])
+	assert.Equal(t, "local_net", result["networkInstances"][0]["name"])
+	assert.Equal(t, "VOLUME_INSTANCE_TYPE_CONTENT_TREE", result["volumeInstances"][0]["type"])
+	assert.Equal(t, "nginx-image", result["volumeInstances"][0]["image"])
+	assert.Equal(t, "nginx", result["applicationInstances"][0]["appId"])
+	assert.Len(t, result["applications"], 1)
+}
+
+func TestExportTerraform(t *testing.T) {
+	t.Parallel()
+
+	data, err := export.Export(testConfig()).Render(export.FormatTerraform)
+	assert.NoError(t, err)
+
+	hcl := string(data)
+	assert.Contains(t, hcl, `resource "zedcloud_image" "nginx-image" {`)
+	assert.Contains(t, hcl, `datastore_id  = zedcloud_datastore.datastore-ds.id`)
+	assert.Contains(t, hcl, `ds_fqdn = "docker://docker.io"`)
+	assert.Contains(t, hcl, `dns    = ["10.1.0.1"]`)
+	assert.Contains(t, hcl, `netinstname = zedcloud_network_instance.local_net.id`)
+	assert.Contains(t, hcl, `app_id   = zedcloud_application.nginx.id`)
+}
@@ -0,0 +1,125 @@
+package export
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// resourcePrefix is prefix of resources of zedcloud Terraform provider
+const resourcePrefix = "zedcloud_"
+
+// snakeCase converts camelCase key of zedcloud API into attribute of Terraform resource
+func snakeCase(s string) string {
+	runes := []rune(s)
+	var b strings.Builder
+	for i, r := range runes {
+		if i > 0 && unicode.IsUpper(r) {
+			prev := runes[i-1]
+			nextLower := i+1 < len(runes) && unicode.IsLower(runes[i+1])
+			if unicode.IsLower(prev) || unicode.IsDigit(prev) || (unicode.IsUpper(prev) && nextLower) {
+				b.WriteByte('_')
+			}
+		}
+		b.WriteRune(unicode.ToLower(r))
+	}
+	return b.String()
+}
+
+// isBlock returns true if value must be rendered as nested block(s)
+func isBlock(v interface{}) bool {
+	switch val := v.(type) {
+	case map[string]interface{}:
+		return true
+	case []interface{}:
+		if len(val) == 0 {
+			return false
+		}
+		_, ok := val[0].(map[string]interface{})
+		return ok
+	}
+	return false
+}
+
+// hclValue returns value of attribute in HCL
+func hclValue(v interface{}) string {
+	switch val := v.(type) {
+	case Ref:
+		return fmt.Sprintf("%s%s.%s.id", resourcePrefix, val.Kind, val.Name)
+	case string:
+		// escape interpolation sequences
+		val = strings.ReplaceAll(val, "${", "$${")
+		val = strings.ReplaceAll(val, "%{", "%%{")
+		return strconv.Quote(val)
+	case []string:
+		items := make([]string, 0, len(val))
+		for _, el := range val {
+			items = append(items, hclValue(el))
+		}
+		return "[" + strings.Join(items, ", ") + "]"
+	case []interface{}:
+		items := make([]string, 0, len(val))
+		for _, el := range val {
+			items = append(items, hclValue(el))
+		}
+		return "[" + strings.Join(items, ", ") + "]"
+	}
+	return fmt.Sprint(v)
+}
+
+// writeBody writes attributes and then nested blocks of fields sorted by keys
+func writeBody(b *strings.Builder, fields map[string]interface{}, indent string) {
+	var attrs, blocks []string
+	for k, v := range fields {
+		if isBlock(v) {
+			blocks = append(blocks, k)
+		} else {
+			attrs = append(attrs, k)
+		}
+	}
+	sort.Strings(attrs)
+	sort.Strings(blocks)
+	width := 0
+	for _, k := range attrs {
+		if l := len(snakeCase(k)); l > width {
+			width = l
+		}
+	}
+	for _, k := range attrs {
+		fmt.Fprintf(b, "%s%-*s = %s\n", indent, width, snakeCase(k), hclValue(fields[k]))
+	}
+	for _, k := range blocks {
+		var items []interface{}
+		switch val := fields[k].(type) {
+		case map[string]interface{}:
+			items = []interface{}{val}
+		case []interface{}:
+			items = val
+		}
+		for _, item := range items {
+			fmt.Fprintf(b, "%s%s {\n", indent, snakeCase(k))
+			writeBody(b, item.(map[string]interface{}), indent+"  ")
+			fmt.Fprintf(b, "%s}\n", indent)
+		}
+	}
+}
+
+// renderHCL returns objects as resources of zedcloud Terraform provider
+func renderHCL(objects Objects) string {
+	var b strings.Builder
+	for i, obj := range objects {
+		if i > 0 {
+			b.WriteString("\n")
+		}
+		fields := map[string]interface{}{"name": obj.Name, "title": obj.Name}
+		for k, v := range obj.Fields {
+			fields[k] = v
+		}
+		fmt.Fprintf(&b, "resource %q %q {\n", resourcePrefix+string(obj.Kind), obj.Name)
+		writeBody(&b, fields, "  ")
+		b.WriteString("}\n")
+	}
+	return b.String()
+}
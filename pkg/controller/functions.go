@@ -11,6 +11,9 @@ import (
 	"time"
 
 	"github.com/lf-edge/eden/pkg/controller/adam"
+	"github.com/lf-edge/eden/pkg/controller/replay"
+	"github.com/lf-edge/eden/pkg/controller/zedcloud"
+	"github.com/lf-edge/eden/pkg/defaults"
 	"github.com/lf-edge/eden/pkg/device"
 	"github.com/lf-edge/eden/pkg/models"
 	"github.com/lf-edge/eden/pkg/utils"
@@ -28,7 +31,16 @@ func CloudPrepare() (Cloud, error) {
 	if err != nil {
 		return nil, fmt.Errorf("utils.InitVars: %s", err)
 	}
-	ctx := &CloudCtx{vars: vars, Controller: &adam.Ctx{}}
+	return CloudPrepareWithVars(vars)
+}
+
+// CloudPrepareWithVars is for init controller connection with provided vars and obtain device list
+func CloudPrepareWithVars(vars *utils.ConfigVars) (Cloud, error) {
+	ctrl, err := newController(vars.ControllerType)
+	if err != nil {
+		return nil, err
+	}
+	ctx := &CloudCtx{vars: vars, Controller: ctrl}
 	if err := ctx.InitWithVars(vars); err != nil {
 		return nil, fmt.Errorf("cloud.InitWithVars: %s", err)
 	}
@@ -36,6 +48,22 @@ func CloudPrepare() (Cloud, error) {
 	return ctx, nil
 }
 
+// newController returns implementation of Controller for provided type,
+// recording is replayed instead of any controller if it is requested
+func newController(controllerType string) (Controller, error) {
+	if replay.Replaying() {
+		return &replay.Ctx{}, nil
+	}
+	switch controllerType {
+	case "", defaults.DefaultControllerAdam:
+		return &adam.Ctx{}, nil
+	case defaults.DefaultControllerZedcloud:
+		return &zedcloud.Ctx{}, nil
+	default:
+		return nil, fmt.Errorf("unsupported controller type: %s", controllerType)
+	}
+}
+
 // GetVars returns variables of controller
 func (cloud *CloudCtx) GetVars() *utils.ConfigVars {
 	return cloud.vars
@@ -62,6 +90,7 @@ func (cloud *CloudCtx) ResetDev(node *device.Ctx) error {
 	node.SetDevModel(vars.DevModel)
 	node.SetGlobalProfile("")
 	node.SetLocalProfileServer("")
+	node.SetLocURL("")
 	return cloud.OnBoardDev(node)
 }
 
@@ -0,0 +1,25 @@
+package loaders
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReconnectDelay(t *testing.T) {
+	t.Parallel()
+
+	testMatrix := map[int]time.Duration{
+		0:   time.Second,
+		1:   2 * time.Second,
+		2:   4 * time.Second,
+		3:   8 * time.Second,
+		4:   reconnectDelayMax,
+		19:  reconnectDelayMax,
+		100: reconnectDelayMax,
+	}
+	for attempt, delay := range testMatrix {
+		assert.Equal(t, delay, reconnectDelay(attempt), "attempt %d", attempt)
+	}
+}
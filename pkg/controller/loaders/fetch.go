@@ -0,0 +1,63 @@
+package loaders
+
+type fetchResult struct {
+	data []byte
+	err  error
+}
+
+type fetchJob struct {
+	index  int
+	result chan fetchResult
+}
+
+// fetchOrdered fetches count objects with no more than workers at once and passes them
+// to process in order of their indexes with error of fetch if any. Objects are fetched
+// no further than 2*workers ahead of processing, so slow processing slows down fetching
+// and only a bounded number of objects is kept in memory.
+// It stops when process returns false or error.
+func fetchOrdered(count, workers int, fetch func(index int) ([]byte, error), process func(data []byte, err error) (bool, error)) error {
+	if workers < 1 {
+		workers = 1
+	}
+	done := make(chan struct{})
+	defer close(done)
+	jobs := make(chan *fetchJob)
+	// pending keeps jobs in order of indexes, its capacity bounds fetching ahead
+	pending := make(chan *fetchJob, 2*workers)
+	go func() {
+		defer close(jobs)
+		defer close(pending)
+		for i := 0; i < count; i++ {
+			job := &fetchJob{index: i, result: make(chan fetchResult, 1)}
+			select {
+			case pending <- job:
+			case <-done:
+				return
+			}
+			select {
+			case jobs <- job:
+			case <-done:
+				return
+			}
+		}
+	}()
+	for w := 0; w < workers; w++ {
+		go func() {
+			for job := range jobs {
+				data, err := fetch(job.index)
+				job.result <- fetchResult{data: data, err: err}
+			}
+		}()
+	}
+	for job := range pending {
+		res := <-job.result
+		doContinue, err := process(res.data, res.err)
+		if err != nil {
+			return err
+		}
+		if !doContinue {
+			return nil
+		}
+	}
+	return nil
+}
@@ -11,6 +11,7 @@ import (
 	"github.com/fsnotify/fsnotify"
 	"github.com/lf-edge/eden/pkg/controller/cachers"
 	"github.com/lf-edge/eden/pkg/controller/types"
+	"github.com/lf-edge/eden/pkg/defaults"
 	uuid "github.com/satori/go.uuid"
 	log "github.com/sirupsen/logrus"
 )
@@ -91,31 +92,31 @@ func (loader *FileLoader) ProcessExisting(process ProcessFunction, typeToProcess
 		return files[i].ModTime().Unix() > files[j].ModTime().Unix()
 	})
 	time.Sleep(1 * time.Second) // wait for write ends
+	dir := loader.getFilePath(typeToProcess)
+	regular := files[:0]
 	for _, file := range files {
-		if file.IsDir() {
-			continue
+		if !file.IsDir() {
+			regular = append(regular, file)
 		}
-		fileFullPath := path.Join(loader.getFilePath(typeToProcess), file.Name())
+	}
+	// files are read concurrently, but processed in order
+	fetch := func(i int) ([]byte, error) {
+		fileFullPath := path.Join(dir, regular[i].Name())
 		log.Debugf("local controller parse %s", fileFullPath)
-		data, err := os.ReadFile(fileFullPath)
+		return os.ReadFile(fileFullPath)
+	}
+	return fetchOrdered(len(regular), defaults.DefaultLoaderWorkers, fetch, func(data []byte, err error) (bool, error) {
 		if err != nil {
-			log.Error("Can't open ", fileFullPath)
-			continue
+			log.Errorf("Can't open: %s", err)
+			return true, nil
 		}
 		if loader.cache != nil {
 			if err = loader.cache.CheckAndSave(loader.devUUID, typeToProcess, data); err != nil {
 				log.Errorf("error in cache: %s", err)
 			}
 		}
-		doContinue, err := process(data)
-		if err != nil {
-			return err
-		}
-		if !doContinue {
-			return nil
-		}
-	}
-	return nil
+		return process(data)
+	})
 }
 
 // ProcessStream for observe new files
@@ -0,0 +1,42 @@
+package loaders_test
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/lf-edge/eden/pkg/controller/loaders"
+	"github.com/lf-edge/eden/pkg/controller/types"
+	uuid "github.com/satori/go.uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFileLoaderProcessExisting(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	now := time.Now()
+	for i := 0; i < 50; i++ {
+		file := filepath.Join(dir, fmt.Sprintf("%d.json", i))
+		assert.NoError(t, os.WriteFile(file, []byte(fmt.Sprint(i)), 0644))
+		modTime := now.Add(-time.Duration(i) * time.Second)
+		assert.NoError(t, os.Chtimes(file, modTime, modTime))
+	}
+	loader := loaders.NewFileLoader(types.DirGetters{
+		InfoGetter: func(uuid.UUID) string { return dir },
+	})
+
+	// files are processed from the newest one in order and processing stops on request
+	var processed []string
+	err := loader.ProcessExisting(func(data []byte) (bool, error) {
+		processed = append(processed, string(data))
+		return len(processed) < 20, nil
+	}, types.InfoType)
+	assert.NoError(t, err)
+	assert.Len(t, processed, 20)
+	for i, el := range processed {
+		assert.Equal(t, fmt.Sprint(i), el)
+	}
+}
@@ -87,115 +87,114 @@ func (loader *RedisLoader) SetAppUUID(appUUID uuid.UUID) {
 	loader.appUUID = appUUID
 }
 
-func (loader *RedisLoader) process(process ProcessFunction, typeToProcess types.LoaderObjectType, stream bool) (processed, found bool, err error) {
-	OrderStream := loader.getStream(typeToProcess)
-	log.Debugf("XRead from %s", OrderStream)
-	if !stream {
+// processMessage feeds message from stream into process function and cache
+// returns false if process function asks to stop
+func (loader *RedisLoader) processMessage(process ProcessFunction, typeToProcess types.LoaderObjectType, msg redis.XMessage) (bool, error) {
+	loader.lastID = msg.ID
+	dataString, ok := msg.Values["object"].(string)
+	if !ok {
+		return true, nil
+	}
+	data := []byte(dataString)
+	tocontinue, err := process(data)
+	if err != nil {
+		return false, err
+	}
+	if loader.cache != nil {
+		if err = loader.cache.CheckAndSave(loader.devUUID, typeToProcess, data); err != nil {
+			log.Errorf("error in cache: %s", err)
+		}
+	}
+	return tocontinue, nil
+}
+
+type redisPage struct {
+	messages []redis.XMessage
+	err      error
+}
+
+// readPages reads messages of stream by pages in background, the next page is requested
+// while the current one is processed, but no further, so memory is bounded by two pages
+func (loader *RedisLoader) readPages(ctx context.Context, stream string) <-chan redisPage {
+	pages := make(chan redisPage, 1)
+	go func() {
+		defer close(pages)
 		start := "-"
 		for {
-			rr, err := loader.client.XRangeN(context.Background(), OrderStream, start, "+", 10).Result()
-			if err != nil {
-				return false, false, fmt.Errorf("XRange error: %s", err)
+			rr, err := loader.client.XRangeN(ctx, stream, start, "+", defaults.DefaultLoaderPageSize).Result()
+			if err == nil && len(rr) == 0 {
+				return
 			}
-
-			if len(rr) == 0 {
-				return true, false, nil
+			select {
+			case pages <- redisPage{messages: rr, err: err}:
+			case <-ctx.Done():
+				return
+			}
+			if err != nil || len(rr) < defaults.DefaultLoaderPageSize {
+				return
 			}
+			// IDs are in form of <ms>-<seq>, continue right after the last one
+			splitted := strings.Split(rr[len(rr)-1].ID, "-")
+			counter, _ := strconv.Atoi(splitted[1])
+			start = fmt.Sprintf("%s-%v", splitted[0], counter+1)
+		}
+	}()
+	return pages
+}
 
-			for _, r := range rr {
-				loader.lastID = r.ID
-				dataString, ok := r.Values["object"].(string)
-				if !ok {
-					continue
-				}
-				data := []byte(dataString)
-				tocontinue, err := process(data)
+func (loader *RedisLoader) process(ctx context.Context, process ProcessFunction, typeToProcess types.LoaderObjectType, stream bool) (processed, found bool, err error) {
+	OrderStream := loader.getStream(typeToProcess)
+	log.Debugf("XRead from %s", OrderStream)
+	if !stream {
+		ctx, cancel := context.WithCancel(ctx)
+		defer cancel()
+		for page := range loader.readPages(ctx, OrderStream) {
+			if page.err != nil {
+				return false, false, fmt.Errorf("XRange error: %s", page.err)
+			}
+			for _, r := range page.messages {
+				tocontinue, err := loader.processMessage(process, typeToProcess, r)
 				if err != nil {
 					return false, false, fmt.Errorf("process: %s", err)
 				}
-				if loader.cache != nil {
-					if err = loader.cache.CheckAndSave(loader.devUUID, typeToProcess, data); err != nil {
-						log.Errorf("error in cache: %s", err)
-					}
-				}
 				if !tocontinue {
 					return true, true, nil
 				}
 			}
-			splitted := strings.Split(loader.lastID, "-")
-			counter, _ := strconv.Atoi(splitted[1])
-			start = fmt.Sprintf("%s-%v", splitted[0], counter+1)
 		}
-	} else {
-		start := "$"
-		rr, err := loader.client.XRead(context.Background(), &redis.XReadArgs{
+		return true, false, nil
+	}
+	// subscribe to the stream: XRead blocks until new entries appear after the last seen one,
+	// so we deliver them as soon as controller saves them without polling
+	start := "$"
+	for {
+		rr, err := loader.client.XRead(ctx, &redis.XReadArgs{
 			Streams: []string{OrderStream, start},
-			Count:   1,
+			Count:   100,
 			Block:   0,
 		}).Result()
 		if err != nil {
+			if ctx.Err() != nil {
+				return false, false, nil
+			}
 			return false, false, fmt.Errorf("XRead error: %s", err)
 		}
 
 		for _, r := range rr[0].Messages {
-			loader.lastID = r.ID
-			dataString, ok := r.Values["object"].(string)
-			if !ok {
-				continue
-			}
-			data := []byte(dataString)
-			tocontinue, err := process(data)
+			tocontinue, err := loader.processMessage(process, typeToProcess, r)
 			if err != nil {
-				return false, false, fmt.Errorf("process first: %s", err)
-			}
-			if loader.cache != nil {
-				if err = loader.cache.CheckAndSave(loader.devUUID, typeToProcess, data); err != nil {
-					log.Errorf("error in cache: %s", err)
-				}
+				return false, false, fmt.Errorf("process: %s", err)
 			}
 			if !tocontinue {
 				return true, true, nil
 			}
 		}
-		splitted := strings.Split(loader.lastID, "-")
-		counter, _ := strconv.Atoi(splitted[1])
-		start = fmt.Sprintf("%s-%v", splitted[0], counter+1)
-		for {
-			rr, err := loader.client.XRangeN(context.Background(), OrderStream, start, "+", 100).Result()
-			if err != nil {
-				return false, false, fmt.Errorf("XRange error: %s", err)
-			}
-
-			for _, r := range rr {
-				loader.lastID = r.ID
-				dataString, ok := r.Values["object"].(string)
-				if !ok {
-					continue
-				}
-				data := []byte(dataString)
-				tocontinue, err := process(data)
-				if err != nil {
-					return false, false, fmt.Errorf("process: %s", err)
-				}
-				if loader.cache != nil {
-					if err = loader.cache.CheckAndSave(loader.devUUID, typeToProcess, data); err != nil {
-						log.Errorf("error in cache: %s", err)
-					}
-				}
-				if !tocontinue {
-					return true, true, nil
-				}
-			}
-			splitted := strings.Split(loader.lastID, "-")
-			counter, _ := strconv.Atoi(splitted[1])
-			start = fmt.Sprintf("%s-%v", splitted[0], counter+1)
-			time.Sleep(time.Second) //sleep for second to reduce the load of redis
-		}
+		start = loader.lastID
 	}
 }
 
-func (loader *RedisLoader) repeatableConnection(process ProcessFunction, typeToProcess types.LoaderObjectType, stream bool) error {
-	if _, _, err := loader.process(process, typeToProcess, stream); err != nil {
+func (loader *RedisLoader) repeatableConnection(ctx context.Context, process ProcessFunction, typeToProcess types.LoaderObjectType, stream bool) error {
+	if _, _, err := loader.process(ctx, process, typeToProcess, stream); err != nil {
 		log.Errorf("RedisLoader repeatableConnection error: %s", err)
 	}
 	return nil
@@ -221,7 +220,7 @@ func (loader *RedisLoader) ProcessExisting(process ProcessFunction, typeToProces
 	if _, err := loader.getOrCreateClient(); err != nil {
 		return err
 	}
-	return loader.repeatableConnection(process, typeToProcess, false)
+	return loader.repeatableConnection(context.Background(), process, typeToProcess, false)
 }
 
 // ProcessStream for observe new files
@@ -229,15 +228,18 @@ func (loader *RedisLoader) ProcessStream(process ProcessFunction, typeToProcess
 	if _, err := loader.getOrCreateClient(); err != nil {
 		return err
 	}
-	done := make(chan error)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	done := make(chan error, 2)
 	if timeoutSeconds != 0 {
-		time.AfterFunc(timeoutSeconds, func() {
+		timer := time.AfterFunc(timeoutSeconds, func() {
 			done <- fmt.Errorf("timeout")
 		})
+		defer timer.Stop()
 	}
 
 	go func() {
-		done <- loader.repeatableConnection(process, typeToProcess, true)
+		done <- loader.repeatableConnection(ctx, process, typeToProcess, true)
 	}()
 	if err = <-done; err != nil {
 		return err
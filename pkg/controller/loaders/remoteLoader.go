@@ -101,7 +101,7 @@ func (loader *RemoteLoader) SetAppUUID(appUUID uuid.UUID) {
 	loader.appUUID = appUUID
 }
 
-func (loader *RemoteLoader) processNext(decoder *json.Decoder, process ProcessFunction, typeToProcess types.LoaderObjectType, stream bool) (processed, tocontinue bool, err error) {
+func (loader *RemoteLoader) processNext(decoder *json.Decoder, process ProcessFunction, typeToProcess types.LoaderObjectType) (processed, tocontinue bool, err error) {
 	var buf []byte
 	switch typeToProcess {
 	case types.LogsType:
@@ -135,9 +135,6 @@ func (loader *RemoteLoader) processNext(decoder *json.Decoder, process ProcessFu
 		return false, true, nil
 	}
 	tocontinue, err = process(buf)
-	if stream {
-		time.Sleep(1 * time.Second) //wait for load all data from buffer
-	}
 	loader.curCount++
 	loader.lastCount = loader.curCount
 	return true, tocontinue, err
@@ -154,9 +151,11 @@ func (loader *RemoteLoader) process(process ProcessFunction, typeToProcess types
 	if err != nil {
 		return false, false, fmt.Errorf("error reading URL %s: %v", u, err)
 	}
+	// objects are decoded one by one from the body without reading it into memory
+	defer response.Body.Close()
 	dec := json.NewDecoder(response.Body)
 	for {
-		processed, doContinue, err := loader.processNext(dec, process, typeToProcess, stream)
+		processed, doContinue, err := loader.processNext(dec, process, typeToProcess)
 		if err != nil {
 			return false, false, fmt.Errorf("process: %s", err)
 		}
@@ -170,6 +169,25 @@ func infoProcessInit(_ []byte) (bool, error) {
 	return true, nil
 }
 
+const (
+	// reconnectDelayMin is delay before the first reconnection, it doubles with every attempt
+	reconnectDelayMin = time.Second
+	// reconnectDelayMax bounds delay between reconnections
+	reconnectDelayMax = 2 * defaults.DefaultRepeatTimeout
+)
+
+// reconnectDelay returns delay before reconnection attempt (starting from 0)
+func reconnectDelay(attempt int) time.Duration {
+	delay := reconnectDelayMin
+	for i := 0; i < attempt && delay < reconnectDelayMax; i++ {
+		delay *= 2
+	}
+	if delay > reconnectDelayMax {
+		return reconnectDelayMax
+	}
+	return delay
+}
+
 func (loader *RemoteLoader) repeatableConnection(process ProcessFunction, typeToProcess types.LoaderObjectType, stream bool) error {
 	if !stream {
 		loader.client.Timeout = time.Second * 10
@@ -212,7 +230,10 @@ repeatLoop:
 		}
 		timer.Stop()
 		log.Infof("Attempt to re-establish connection with controller (%d) of (%d)", i, maxRepeat)
-		time.Sleep(delayTime)
+		// objects are read from the stream as soon as controller sends them,
+		// the delay is a backoff between reconnections only,
+		// it starts from the minimum again once connection lives long enough
+		time.Sleep(reconnectDelay(i))
 	}
 	return fmt.Errorf("all connection attempts failed")
 }
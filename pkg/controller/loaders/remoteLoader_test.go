@@ -0,0 +1,55 @@
+package loaders_test
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/lf-edge/eden/pkg/controller/loaders"
+	"github.com/lf-edge/eden/pkg/controller/types"
+	uuid "github.com/satori/go.uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRemoteLoaderProcessStream(t *testing.T) {
+	t.Parallel()
+
+	existing := []string{"existing-1", "existing-2"}
+	added := []string{"new-1", "new-2"}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get(loaders.StreamHeader) != loaders.StreamValue {
+			for _, id := range existing {
+				fmt.Fprintf(w, `{"devId":%q}`, id)
+			}
+			return
+		}
+		for _, id := range added {
+			fmt.Fprintf(w, `{"devId":%q}`, id)
+		}
+		w.(http.Flusher).Flush()
+		// keep stream open as controller does
+		<-r.Context().Done()
+	}))
+	defer server.Close()
+
+	loader := loaders.NewRemoteLoader(server.Client, types.URLGetters{
+		URLInfo: func(uuid.UUID) string { return server.URL },
+	})
+
+	// only objects sent after the first load are processed, without delays between them
+	var processed []string
+	start := time.Now()
+	err := loader.ProcessStream(func(data []byte) (bool, error) {
+		processed = append(processed, string(data))
+		return len(processed) < len(added), nil
+	}, types.InfoType, 10*time.Second)
+	assert.NoError(t, err)
+	assert.Less(t, time.Since(start), time.Second)
+	if assert.Len(t, processed, len(added)) {
+		for i, id := range added {
+			assert.Contains(t, processed[i], id)
+		}
+	}
+}
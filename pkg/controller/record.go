@@ -0,0 +1,33 @@
+package controller
+
+import (
+	"github.com/lf-edge/eden/pkg/controller/replay"
+	uuid "github.com/satori/go.uuid"
+)
+
+// Methods below record controller side of traffic for replay, see replay.Record,
+// device side is recorded by loaders of controller
+
+// ConfigSet set config for devID
+func (cloud *CloudCtx) ConfigSet(devUUID uuid.UUID, devConfig []byte) (err error) {
+	if err = cloud.Controller.ConfigSet(devUUID, devConfig); err == nil {
+		replay.Record(replay.KindConfig, devUUID, uuid.Nil, devConfig)
+	}
+	return err
+}
+
+// ConfigSetIfVersion set config for devID if version of config in controller matches
+func (cloud *CloudCtx) ConfigSetIfVersion(devUUID uuid.UUID, version string, devConfig []byte) (err error) {
+	if err = cloud.Controller.ConfigSetIfVersion(devUUID, version, devConfig); err == nil {
+		replay.Record(replay.KindConfig, devUUID, uuid.Nil, devConfig)
+	}
+	return err
+}
+
+// DeviceGetByOnboardUUID try to get device by onboard uuid
+func (cloud *CloudCtx) DeviceGetByOnboardUUID(onboardUUID string) (devUUID uuid.UUID, err error) {
+	if devUUID, err = cloud.Controller.DeviceGetByOnboardUUID(onboardUUID); err == nil {
+		replay.Record(replay.KindOnboard, devUUID, uuid.Nil, []byte(onboardUUID))
+	}
+	return devUUID, err
+}
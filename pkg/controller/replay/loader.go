@@ -0,0 +1,131 @@
+package replay
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/lf-edge/eden/pkg/controller/cachers"
+	"github.com/lf-edge/eden/pkg/controller/loaders"
+	"github.com/lf-edge/eden/pkg/controller/types"
+	uuid "github.com/satori/go.uuid"
+)
+
+// recordingLoader records objects passed by loader to processing functions
+type recordingLoader struct {
+	loaders.Loader
+	devUUID uuid.UUID
+	appUUID uuid.UUID
+}
+
+// NewRecordingLoader returns loader which records objects loaded by loader if recording
+// is enabled (see Record), otherwise it returns loader itself
+func NewRecordingLoader(loader loaders.Loader) loaders.Loader {
+	if !Recording() {
+		return loader
+	}
+	return &recordingLoader{Loader: loader}
+}
+
+// SetUUID set device UUID
+func (loader *recordingLoader) SetUUID(devUUID uuid.UUID) {
+	loader.devUUID = devUUID
+	loader.Loader.SetUUID(devUUID)
+}
+
+// SetAppUUID set app UUID
+func (loader *recordingLoader) SetAppUUID(appUUID uuid.UUID) {
+	loader.appUUID = appUUID
+	loader.Loader.SetAppUUID(appUUID)
+}
+
+// Clone create copy
+func (loader *recordingLoader) Clone() loaders.Loader {
+	return &recordingLoader{
+		Loader:  loader.Loader.Clone(),
+		devUUID: loader.devUUID,
+		appUUID: loader.appUUID,
+	}
+}
+
+// ProcessExisting for observe existing objects
+func (loader *recordingLoader) ProcessExisting(process loaders.ProcessFunction, typeToProcess types.LoaderObjectType) error {
+	// existing objects come from the newest one, record them in order they were sent
+	var observed [][]byte
+	err := loader.Loader.ProcessExisting(func(data []byte) (bool, error) {
+		observed = append(observed, data)
+		return process(data)
+	}, typeToProcess)
+	for i := len(observed) - 1; i >= 0; i-- {
+		Record(KindOf(typeToProcess), loader.devUUID, loader.appUUID, observed[i])
+	}
+	return err
+}
+
+// ProcessStream for observe new objects
+func (loader *recordingLoader) ProcessStream(process loaders.ProcessFunction, typeToProcess types.LoaderObjectType, timeoutSeconds time.Duration) error {
+	return loader.Loader.ProcessStream(func(data []byte) (bool, error) {
+		Record(KindOf(typeToProcess), loader.devUUID, loader.appUUID, data)
+		return process(data)
+	}, typeToProcess, timeoutSeconds)
+}
+
+// replayLoader serves recorded objects instead of controller
+type replayLoader struct {
+	rec     *Recorded
+	devUUID uuid.UUID
+	appUUID uuid.UUID
+}
+
+// NewReplayLoader returns loader which serves objects from recording
+func NewReplayLoader(rec *Recorded) loaders.Loader {
+	return &replayLoader{rec: rec}
+}
+
+// SetUUID set device UUID
+func (loader *replayLoader) SetUUID(devUUID uuid.UUID) {
+	loader.devUUID = devUUID
+}
+
+// SetAppUUID set app UUID
+func (loader *replayLoader) SetAppUUID(appUUID uuid.UUID) {
+	loader.appUUID = appUUID
+}
+
+// SetRemoteCache does nothing as recording is local
+func (loader *replayLoader) SetRemoteCache(_ cachers.CacheProcessor) {
+}
+
+// Clone create copy
+func (loader *replayLoader) Clone() loaders.Loader {
+	return &replayLoader{rec: loader.rec, devUUID: loader.devUUID, appUUID: loader.appUUID}
+}
+
+// ProcessExisting passes recorded objects to process from the newest one as controller does
+func (loader *replayLoader) ProcessExisting(process loaders.ProcessFunction, typeToProcess types.LoaderObjectType) error {
+	events := loader.rec.Select(KindOf(typeToProcess), loader.devUUID, loader.appUUID)
+	for i := len(events) - 1; i >= 0; i-- {
+		doContinue, err := process(events[i].Data)
+		if err != nil {
+			return err
+		}
+		if !doContinue {
+			return nil
+		}
+	}
+	return nil
+}
+
+// ProcessStream passes recorded objects to process in order they were sent, device
+// sends nothing after the end of recording, so it ends with timeout immediately
+func (loader *replayLoader) ProcessStream(process loaders.ProcessFunction, typeToProcess types.LoaderObjectType, _ time.Duration) error {
+	for _, ev := range loader.rec.Select(KindOf(typeToProcess), loader.devUUID, loader.appUUID) {
+		doContinue, err := process(ev.Data)
+		if err != nil {
+			return err
+		}
+		if !doContinue {
+			return nil
+		}
+	}
+	return fmt.Errorf("timeout")
+}
@@ -0,0 +1,209 @@
+// Package replay records traffic between controller and device observed by tests
+// and replays it later without live EVE: recorded configs may be pushed to device again
+// and recorded info, metrics and logs may be served to test logic instead of controller.
+package replay
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/lf-edge/eden/pkg/controller/types"
+	"github.com/lf-edge/eden/pkg/defaults"
+	uuid "github.com/satori/go.uuid"
+	log "github.com/sirupsen/logrus"
+)
+
+// Kind is kind of recorded object
+type Kind string
+
+// Kinds of recorded objects
+const (
+	KindConfig  Kind = "config"
+	KindOnboard Kind = "onboard"
+	KindInfo    Kind = "info"
+	KindMetrics Kind = "metrics"
+	KindLogs    Kind = "logs"
+	KindAppLogs Kind = "app-logs"
+	KindFlowLog Kind = "flow-log"
+	KindRequest Kind = "request"
+)
+
+// recordingExt is extension of files with recorded events
+const recordingExt = ".jsonl"
+
+// KindOf returns Kind of objects processed by loader
+func KindOf(typeToProcess types.LoaderObjectType) Kind {
+	switch typeToProcess {
+	case types.LogsType:
+		return KindLogs
+	case types.InfoType:
+		return KindInfo
+	case types.MetricsType:
+		return KindMetrics
+	case types.RequestType:
+		return KindRequest
+	case types.AppsType:
+		return KindAppLogs
+	case types.FlowLogType:
+		return KindFlowLog
+	default:
+		return Kind(fmt.Sprintf("unknown-%d", typeToProcess))
+	}
+}
+
+// Event is one object passed between controller and device
+type Event struct {
+	Time   time.Time `json:"time"`
+	Kind   Kind      `json:"kind"`
+	Device string    `json:"device"`
+	App    string    `json:"app,omitempty"`
+	// Data is object in format of controller, proto-binary config for KindConfig
+	// and onboard UUID for KindOnboard
+	Data []byte `json:"data"`
+}
+
+var (
+	mu       sync.Mutex
+	recorded = map[[sha256.Size]byte]bool{}
+)
+
+// eventKey identifies event regardless of time it was observed
+func eventKey(ev *Event) [sha256.Size]byte {
+	h := sha256.New()
+	_, _ = fmt.Fprintf(h, "%s\x00%s\x00%s\x00", ev.Kind, ev.Device, ev.App)
+	_, _ = h.Write(ev.Data)
+	var key [sha256.Size]byte
+	copy(key[:], h.Sum(nil))
+	return key
+}
+
+// Recording checks if defaults.DefaultRecordEnv (EDEN_RECORD) env. var. is set
+func Recording() bool {
+	return os.Getenv(defaults.DefaultRecordEnv) != ""
+}
+
+// Record saves object into directory from defaults.DefaultRecordEnv (EDEN_RECORD) env. var.,
+// it does nothing if it is not set. Objects observed several times are recorded once.
+func Record(kind Kind, devUUID, appUUID uuid.UUID, data []byte) {
+	dir := os.Getenv(defaults.DefaultRecordEnv)
+	if dir == "" {
+		return
+	}
+	ev := &Event{Time: time.Now(), Kind: kind, Device: devUUID.String(), Data: data}
+	if appUUID != uuid.Nil {
+		ev.App = appUUID.String()
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	key := eventKey(ev)
+	if recorded[key] {
+		return
+	}
+	recorded[key] = true
+	line, err := json.Marshal(ev)
+	if err != nil {
+		log.Debugf("cannot record %s: %s", kind, err)
+		return
+	}
+	// every process writes its own file, so they do not mix lines
+	file := filepath.Join(dir, fmt.Sprintf("%d%s", os.Getpid(), recordingExt))
+	f, err := os.OpenFile(file, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		log.Debugf("cannot record %s: %s", kind, err)
+		return
+	}
+	defer f.Close()
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		log.Debugf("cannot record %s: %s", kind, err)
+	}
+}
+
+// Recorded is sequence of events loaded from directory
+type Recorded struct {
+	Events []*Event
+}
+
+// Load reads events recorded into directory by all processes,
+// events are sorted by time they were observed first
+func Load(dir string) (*Recorded, error) {
+	files, err := filepath.Glob(filepath.Join(dir, "*"+recordingExt))
+	if err != nil {
+		return nil, err
+	}
+	if len(files) == 0 {
+		return nil, fmt.Errorf("no recording in %s", dir)
+	}
+	rec := &Recorded{}
+	seen := map[[sha256.Size]byte]*Event{}
+	for _, file := range files {
+		if err := rec.loadFile(file, seen); err != nil {
+			return nil, fmt.Errorf("cannot load recording %s: %w", file, err)
+		}
+	}
+	sort.SliceStable(rec.Events, func(i, j int) bool {
+		return rec.Events[i].Time.Before(rec.Events[j].Time)
+	})
+	return rec, nil
+}
+
+func (rec *Recorded) loadFile(file string, seen map[[sha256.Size]byte]*Event) error {
+	f, err := os.Open(file)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	scanner := bufio.NewScanner(f)
+	// objects of EVE may be large, logs are sent in bundles
+	scanner.Buffer(make([]byte, 0, 64*1024), 64*1024*1024)
+	for scanner.Scan() {
+		ev := &Event{}
+		if err := json.Unmarshal(scanner.Bytes(), ev); err != nil {
+			return err
+		}
+		key := eventKey(ev)
+		if prev, ok := seen[key]; ok {
+			if ev.Time.Before(prev.Time) {
+				prev.Time = ev.Time
+			}
+			continue
+		}
+		seen[key] = ev
+		rec.Events = append(rec.Events, ev)
+	}
+	return scanner.Err()
+}
+
+// Select returns events of kind for device (and app if it is not uuid.Nil) in order of recording
+func (rec *Recorded) Select(kind Kind, devUUID, appUUID uuid.UUID) []*Event {
+	var result []*Event
+	for _, ev := range rec.Events {
+		if ev.Kind != kind || ev.Device != devUUID.String() {
+			continue
+		}
+		if appUUID != uuid.Nil && ev.App != appUUID.String() {
+			continue
+		}
+		result = append(result, ev)
+	}
+	return result
+}
+
+// Devices returns UUIDs of devices in recording
+func (rec *Recorded) Devices() []string {
+	var result []string
+	seen := map[string]bool{}
+	for _, ev := range rec.Events {
+		if !seen[ev.Device] {
+			seen[ev.Device] = true
+			result = append(result, ev.Device)
+		}
+	}
+	return result
+}
@@ -0,0 +1,275 @@
+package replay
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/lf-edge/eden/pkg/controller/eapps"
+	"github.com/lf-edge/eden/pkg/controller/eflowlog"
+	"github.com/lf-edge/eden/pkg/controller/einfo"
+	"github.com/lf-edge/eden/pkg/controller/elog"
+	"github.com/lf-edge/eden/pkg/controller/emetric"
+	"github.com/lf-edge/eden/pkg/controller/erequest"
+	"github.com/lf-edge/eden/pkg/controller/loaders"
+	"github.com/lf-edge/eden/pkg/controller/types"
+	"github.com/lf-edge/eden/pkg/defaults"
+	"github.com/lf-edge/eden/pkg/device"
+	"github.com/lf-edge/eden/pkg/utils"
+	"github.com/lf-edge/eve-api/go/config"
+	uuid "github.com/satori/go.uuid"
+	"google.golang.org/protobuf/proto"
+)
+
+// ErrNotSupported returned for operations which are not recorded
+var ErrNotSupported = errors.New("operation is not supported in replay of recording")
+
+// Ctx serves recorded device side of traffic to test logic instead of controller,
+// configs set by tests are kept in memory and do not reach any device
+type Ctx struct {
+	dir string
+	rec *Recorded
+
+	mu      sync.Mutex
+	configs map[uuid.UUID][]byte
+}
+
+// Replaying checks if defaults.DefaultReplayEnv (EDEN_REPLAY) env. var. is set
+func Replaying() bool {
+	return os.Getenv(defaults.DefaultReplayEnv) != ""
+}
+
+// InitWithVars loads recording from directory defined by defaults.DefaultReplayEnv (EDEN_REPLAY) env. var.
+func (ctx *Ctx) InitWithVars(_ *utils.ConfigVars) error {
+	ctx.dir = os.Getenv(defaults.DefaultReplayEnv)
+	if ctx.dir == "" {
+		return fmt.Errorf("%s is not set", defaults.DefaultReplayEnv)
+	}
+	rec, err := Load(ctx.dir)
+	if err != nil {
+		return err
+	}
+	ctx.rec = rec
+	ctx.configs = make(map[uuid.UUID][]byte)
+	return nil
+}
+
+func (ctx *Ctx) getLoader() loaders.Loader {
+	return NewReplayLoader(ctx.rec)
+}
+
+// GetDir return directory with recording
+func (ctx *Ctx) GetDir() (dir string) {
+	return ctx.dir
+}
+
+// Register does nothing as device is registered in recording
+func (ctx *Ctx) Register(_ *device.Ctx) error {
+	return nil
+}
+
+// DeviceList return devices of recording
+func (ctx *Ctx) DeviceList(filter types.DeviceStateFilter) (out []string, err error) {
+	if filter == types.RegisteredDeviceFilter || filter == types.AllDevicesFilter {
+		return ctx.rec.Devices(), nil
+	}
+	return []string{}, nil
+}
+
+// ConfigSet keeps config for devID in memory
+func (ctx *Ctx) ConfigSet(devUUID uuid.UUID, devConfig []byte) (err error) {
+	ctx.mu.Lock()
+	defer ctx.mu.Unlock()
+	ctx.configs[devUUID] = devConfig
+	return nil
+}
+
+// ConfigSetIfVersion keeps config for devID in memory if version of the current config is version
+func (ctx *Ctx) ConfigSetIfVersion(devUUID uuid.UUID, version string, devConfig []byte) (err error) {
+	ctx.mu.Lock()
+	defer ctx.mu.Unlock()
+	current, err := ctx.configGet(devUUID)
+	if err != nil {
+		return err
+	}
+	var currentConfig config.EdgeDevConfig
+	if err := proto.Unmarshal([]byte(current), &currentConfig); err != nil {
+		return fmt.Errorf("cannot unmarshal config: %w", err)
+	}
+	if currentConfig.GetId().GetVersion() != version {
+		return fmt.Errorf("%w: %s, expected %s", types.ErrConfigVersionMismatch, currentConfig.GetId().GetVersion(), version)
+	}
+	ctx.configs[devUUID] = devConfig
+	return nil
+}
+
+// ConfigGet returns the last config set for devID or the last recorded one
+func (ctx *Ctx) ConfigGet(devUUID uuid.UUID) (out string, err error) {
+	ctx.mu.Lock()
+	defer ctx.mu.Unlock()
+	return ctx.configGet(devUUID)
+}
+
+func (ctx *Ctx) configGet(devUUID uuid.UUID) (out string, err error) {
+	if devConfig, ok := ctx.configs[devUUID]; ok {
+		return string(devConfig), nil
+	}
+	configs := ctx.rec.Select(KindConfig, devUUID, uuid.Nil)
+	if len(configs) == 0 {
+		return "", fmt.Errorf("no config of device %s in recording", devUUID)
+	}
+	return string(configs[len(configs)-1].Data), nil
+}
+
+// RequestLastCallback check request by pattern from recording with callback
+func (ctx *Ctx) RequestLastCallback(devUUID uuid.UUID, q map[string]string, handler erequest.HandlerFunc) (err error) {
+	var loader = ctx.getLoader()
+	loader.SetUUID(devUUID)
+	return erequest.RequestLast(loader, q, handler)
+}
+
+// LogAppsChecker check app logs by pattern from recording
+func (ctx *Ctx) LogAppsChecker(devUUID uuid.UUID, appUUID uuid.UUID, q map[string]string, handler eapps.HandlerFunc, mode eapps.LogCheckerMode, timeout time.Duration) (err error) {
+	return eapps.LogChecker(ctx.getLoader(), devUUID, appUUID, q, handler, mode, timeout)
+}
+
+// LogAppsLastCallback check app logs by pattern from recording with callback
+func (ctx *Ctx) LogAppsLastCallback(devUUID uuid.UUID, appUUID uuid.UUID, q map[string]string, handler eapps.HandlerFunc) (err error) {
+	var loader = ctx.getLoader()
+	loader.SetUUID(devUUID)
+	loader.SetAppUUID(appUUID)
+	return eapps.LogLast(loader, q, handler)
+}
+
+// LogChecker check logs by pattern from recording
+func (ctx *Ctx) LogChecker(devUUID uuid.UUID, q map[string]string, handler elog.HandlerFunc, mode elog.LogCheckerMode, timeout time.Duration) (err error) {
+	return elog.LogChecker(ctx.getLoader(), devUUID, q, handler, mode, timeout)
+}
+
+// LogLastCallback check logs by pattern from recording with callback
+func (ctx *Ctx) LogLastCallback(devUUID uuid.UUID, q map[string]string, handler elog.HandlerFunc) (err error) {
+	var loader = ctx.getLoader()
+	loader.SetUUID(devUUID)
+	return elog.LogLast(loader, q, handler)
+}
+
+// FlowLogChecker check FlowLogs by pattern from recording
+func (ctx *Ctx) FlowLogChecker(devUUID uuid.UUID, q map[string]string, handler eflowlog.HandlerFunc, mode eflowlog.FlowLogCheckerMode, timeout time.Duration) (err error) {
+	return eflowlog.FlowLogChecker(ctx.getLoader(), devUUID, q, handler, mode, timeout)
+}
+
+// FlowLogLastCallback check FlowLogs by pattern from recording with callback
+func (ctx *Ctx) FlowLogLastCallback(devUUID uuid.UUID, q map[string]string, handler eflowlog.HandlerFunc) (err error) {
+	var loader = ctx.getLoader()
+	loader.SetUUID(devUUID)
+	return eflowlog.FlowLogLast(loader, q, handler)
+}
+
+// InfoChecker check info by pattern from recording
+func (ctx *Ctx) InfoChecker(devUUID uuid.UUID, q map[string]string, handler einfo.HandlerFunc, mode einfo.InfoCheckerMode, timeout time.Duration) (err error) {
+	return einfo.InfoChecker(ctx.getLoader(), devUUID, q, handler, mode, timeout)
+}
+
+// InfoLastCallback check info by pattern from recording with callback
+func (ctx *Ctx) InfoLastCallback(devUUID uuid.UUID, q map[string]string, handler einfo.HandlerFunc) (err error) {
+	var loader = ctx.getLoader()
+	loader.SetUUID(devUUID)
+	return einfo.InfoLast(loader, q, einfo.ZInfoFind, handler)
+}
+
+// MetricChecker check metrics by pattern from recording
+func (ctx *Ctx) MetricChecker(devUUID uuid.UUID, q map[string]string, handler emetric.HandlerFunc, mode emetric.MetricCheckerMode, timeout time.Duration) (err error) {
+	return emetric.MetricChecker(ctx.getLoader(), devUUID, q, handler, mode, timeout)
+}
+
+// MetricLastCallback check metrics by pattern from recording with callback
+func (ctx *Ctx) MetricLastCallback(devUUID uuid.UUID, q map[string]string, handler emetric.HandlerFunc) (err error) {
+	var loader = ctx.getLoader()
+	loader.SetUUID(devUUID)
+	return emetric.MetricLast(loader, q, handler)
+}
+
+// DeviceGetByOnboardUUID returns device recorded for onboard uuid,
+// the only device of recording is returned if onboarding is not recorded
+func (ctx *Ctx) DeviceGetByOnboardUUID(onboardUUID string) (devUUID uuid.UUID, err error) {
+	for _, ev := range ctx.rec.Events {
+		if ev.Kind == KindOnboard && string(ev.Data) == onboardUUID {
+			return uuid.FromString(ev.Device)
+		}
+	}
+	if devices := ctx.rec.Devices(); len(devices) == 1 {
+		return uuid.FromString(devices[0])
+	}
+	return uuid.Nil, fmt.Errorf("no device found")
+}
+
+// DeviceGetOnboard returns onboard uuid recorded for device
+func (ctx *Ctx) DeviceGetOnboard(devUUID uuid.UUID) (onboardUUID uuid.UUID, err error) {
+	events := ctx.rec.Select(KindOnboard, devUUID, uuid.Nil)
+	if len(events) == 0 {
+		return uuid.Nil, fmt.Errorf("no onboarding of device %s in recording", devUUID)
+	}
+	return uuid.FromString(string(events[0].Data))
+}
+
+// DeviceGetByOnboard is not supported in replay
+func (ctx *Ctx) DeviceGetByOnboard(_ string) (devUUID uuid.UUID, err error) {
+	return uuid.Nil, ErrNotSupported
+}
+
+// OnboardRemove is not supported in replay
+func (ctx *Ctx) OnboardRemove(_ string) (err error) {
+	return ErrNotSupported
+}
+
+// DeviceRemove is not supported in replay
+func (ctx *Ctx) DeviceRemove(_ uuid.UUID) (err error) {
+	return ErrNotSupported
+}
+
+// GetDeviceCerts is not supported in replay
+func (ctx *Ctx) GetDeviceCerts(_ uuid.UUID) (*types.Zcerts, error) {
+	return nil, ErrNotSupported
+}
+
+// GetECDHCert is not supported in replay
+func (ctx *Ctx) GetECDHCert(_ uuid.UUID) ([]byte, error) {
+	return nil, ErrNotSupported
+}
+
+// SigningCertGet is not supported in replay
+func (ctx *Ctx) SigningCertGet() (signCert []byte, err error) {
+	return nil, ErrNotSupported
+}
+
+// GetDeviceCert is not supported in replay
+func (ctx *Ctx) GetDeviceCert(_ *device.Ctx) (*types.DeviceCert, error) {
+	return nil, ErrNotSupported
+}
+
+// UploadDeviceCert is not supported in replay
+func (ctx *Ctx) UploadDeviceCert(_ types.DeviceCert) error {
+	return ErrNotSupported
+}
+
+// SetDeviceOptions is not supported in replay
+func (ctx *Ctx) SetDeviceOptions(_ uuid.UUID, _ *types.DeviceOptions) error {
+	return ErrNotSupported
+}
+
+// GetDeviceOptions is not supported in replay
+func (ctx *Ctx) GetDeviceOptions(_ uuid.UUID) (*types.DeviceOptions, error) {
+	return nil, ErrNotSupported
+}
+
+// SetGlobalOptions is not supported in replay
+func (ctx *Ctx) SetGlobalOptions(_ *types.GlobalOptions) error {
+	return ErrNotSupported
+}
+
+// GetGlobalOptions is not supported in replay
+func (ctx *Ctx) GetGlobalOptions() (*types.GlobalOptions, error) {
+	return nil, ErrNotSupported
+}
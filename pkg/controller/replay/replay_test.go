@@ -0,0 +1,56 @@
+package replay_test
+
+import (
+	"testing"
+
+	"github.com/lf-edge/eden/pkg/controller/replay"
+	"github.com/lf-edge/eden/pkg/controller/types"
+	"github.com/lf-edge/eden/pkg/defaults"
+	uuid "github.com/satori/go.uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRecordAndReplay(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv(defaults.DefaultRecordEnv, dir)
+	dev := uuid.Must(uuid.NewV4())
+	other := uuid.Must(uuid.NewV4())
+
+	replay.Record(replay.KindConfig, dev, uuid.Nil, []byte("config-1"))
+	replay.Record(replay.KindInfo, dev, uuid.Nil, []byte("info-1"))
+	replay.Record(replay.KindInfo, other, uuid.Nil, []byte("info-other"))
+	replay.Record(replay.KindInfo, dev, uuid.Nil, []byte("info-2"))
+	// observed again by another check
+	replay.Record(replay.KindInfo, dev, uuid.Nil, []byte("info-1"))
+
+	rec, err := replay.Load(dir)
+	assert.NoError(t, err)
+	assert.Len(t, rec.Events, 4)
+	assert.ElementsMatch(t, []string{dev.String(), other.String()}, rec.Devices())
+	assert.Len(t, rec.Select(replay.KindConfig, dev, uuid.Nil), 1)
+
+	loader := replay.NewReplayLoader(rec)
+	loader.SetUUID(dev)
+	collect := func(stop string) (func([]byte) (bool, error), *[]string) {
+		var seen []string
+		return func(data []byte) (bool, error) {
+			seen = append(seen, string(data))
+			return string(data) != stop, nil
+		}, &seen
+	}
+
+	process, seen := collect("")
+	assert.NoError(t, loader.ProcessExisting(process, types.InfoType))
+	assert.Equal(t, []string{"info-2", "info-1"}, *seen)
+
+	process, seen = collect("")
+	assert.EqualError(t, loader.ProcessStream(process, types.InfoType, 0), "timeout")
+	assert.Equal(t, []string{"info-1", "info-2"}, *seen)
+
+	process, seen = collect("info-1")
+	assert.NoError(t, loader.Clone().ProcessStream(process, types.InfoType, 0))
+	assert.Equal(t, []string{"info-1"}, *seen)
+
+	_, err = replay.Load(t.TempDir())
+	assert.Error(t, err)
+}
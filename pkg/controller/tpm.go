@@ -0,0 +1,154 @@
+package controller
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/lf-edge/eden/pkg/controller/types"
+	"github.com/lf-edge/eden/pkg/device"
+	"github.com/lf-edge/eve-api/go/attest"
+	"github.com/lf-edge/eve-api/go/certs"
+	"github.com/lf-edge/eve-api/go/evecommon"
+)
+
+// evNoAction is type of events of measured boot which are not extended into PCRs
+const evNoAction = 0x3
+
+// tpmOnboardingCerts are certificates EVE with TPM sends to controller after onboarding
+var tpmOnboardingCerts = []certs.ZCertType{
+	certs.ZCertType_CERT_TYPE_DEVICE_ENDORSEMENT_RSA,
+	certs.ZCertType_CERT_TYPE_DEVICE_RESTRICTED_SIGNING,
+	certs.ZCertType_CERT_TYPE_DEVICE_ECDH_EXCHANGE,
+}
+
+func errString(err error) string {
+	if err == nil {
+		return ""
+	}
+	return err.Error()
+}
+
+// CheckTPMOnboarding checks that device sent certificates of its TPM
+func CheckTPMOnboarding(devCerts *types.Zcerts) error {
+	if devCerts == nil {
+		return errors.New("no device certificates")
+	}
+	for _, certType := range tpmOnboardingCerts {
+		found := false
+		for _, c := range devCerts.Certs {
+			if c.Type == certType && len(c.Cert) > 0 {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return fmt.Errorf("no %s certificate", certType)
+		}
+	}
+	return nil
+}
+
+// CheckCipherContexts checks that config has cipher context to decrypt data with ECDH
+// using device certificate devCert and controller certificate ctrlCert
+func CheckCipherContexts(contexts []*evecommon.CipherContext, devCert, ctrlCert []byte) error {
+	devCertHash := sha256.Sum256(devCert)
+	ctrlCertHash := sha256.Sum256([]byte(strings.TrimSpace(string(ctrlCert))))
+	for _, c := range contexts {
+		if !bytes.Equal(c.DeviceCertHash, devCertHash[:16]) {
+			continue
+		}
+		if !bytes.Equal(c.ControllerCertHash, ctrlCertHash[:16]) {
+			return fmt.Errorf("cipher context %s uses outdated controller certificate", c.ContextId)
+		}
+		if c.KeyExchangeScheme != evecommon.KeyExchangeScheme_KEA_ECDH {
+			return fmt.Errorf("cipher context %s uses %s key exchange", c.ContextId, c.KeyExchangeScheme)
+		}
+		return nil
+	}
+	return errors.New("no cipher context for ECDH certificate of device")
+}
+
+// ReplayEventLog calculates values of SHA256 PCRs by extending them with digests of event log
+func ReplayEventLog(eventLog []*attest.TpmEventLogEntry) (map[uint32][]byte, error) {
+	pcrs := make(map[uint32][]byte)
+	for _, entry := range eventLog {
+		if entry.EventType == evNoAction {
+			continue
+		}
+		digest := entry.GetDigest()
+		if digest.GetHashAlgo() != attest.TpmHashAlgo_TPM_HASH_ALGO_SHA256 {
+			continue
+		}
+		if len(digest.Digest) != sha256.Size {
+			return nil, fmt.Errorf("event %d: wrong size of digest %d", entry.Index, len(digest.Digest))
+		}
+		value, ok := pcrs[entry.PcrIndex]
+		if !ok {
+			value = make([]byte, sha256.Size)
+		}
+		extended := sha256.Sum256(append(value, digest.Digest...))
+		pcrs[entry.PcrIndex] = extended[:]
+	}
+	return pcrs, nil
+}
+
+// CheckEventLog checks that replay of event log gives PCR values received from device,
+// PCRs without events in the log are not checked
+func CheckEventLog(eventLog []*attest.TpmEventLogEntry, template *types.PCRTemplate) error {
+	if len(eventLog) == 0 {
+		return errors.New("no measured boot events")
+	}
+	if template == nil || len(template.PCRValues) == 0 {
+		return errors.New("no PCR values")
+	}
+	replayed, err := ReplayEventLog(eventLog)
+	if err != nil {
+		return err
+	}
+	for _, pcr := range template.PCRValues {
+		value, ok := replayed[pcr.Index]
+		if !ok || strings.Contains(pcr.Value, "*") {
+			continue
+		}
+		received, err := hex.DecodeString(pcr.Value)
+		if err != nil {
+			return fmt.Errorf("PCR %d: cannot decode value: %w", pcr.Index, err)
+		}
+		if !bytes.Equal(received, value) {
+			return fmt.Errorf("PCR %d: value %s does not match event log %x", pcr.Index, pcr.Value, value)
+		}
+	}
+	return nil
+}
+
+// GetTPMState validates TPM-based onboarding, ECDH-encrypted config and measured boot of device
+func (cloud *CloudCtx) GetTPMState(dev *device.Ctx) (*types.TPMState, error) {
+	devCerts, err := cloud.GetDeviceCerts(dev.GetID())
+	if err != nil {
+		return nil, fmt.Errorf("GetDeviceCerts: %w", err)
+	}
+	state := &types.TPMState{Onboarding: errString(CheckTPMOnboarding(devCerts))}
+	state.CipherConfig = errString(cloud.checkCipherConfig(dev))
+	options, err := cloud.GetDeviceOptions(dev.GetID())
+	if err != nil {
+		return nil, fmt.Errorf("GetDeviceOptions: %w", err)
+	}
+	state.MeasuredBoot = errString(CheckEventLog(options.EventLog, options.ReceivedPCRTemplate))
+	return state, nil
+}
+
+func (cloud *CloudCtx) checkCipherConfig(dev *device.Ctx) error {
+	devCert, err := cloud.GetECDHCert(dev.GetID())
+	if err != nil {
+		return err
+	}
+	ctrlCert, err := cloud.SigningCertGet()
+	if err != nil {
+		return fmt.Errorf("SigningCertGet: %w", err)
+	}
+	return CheckCipherContexts(dev.GetCipherContexts(), devCert, ctrlCert)
+}
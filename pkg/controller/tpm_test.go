@@ -0,0 +1,132 @@
+package controller_test
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"testing"
+
+	"github.com/lf-edge/eden/pkg/controller"
+	"github.com/lf-edge/eden/pkg/controller/types"
+	"github.com/lf-edge/eve-api/go/attest"
+	"github.com/lf-edge/eve-api/go/certs"
+	"github.com/lf-edge/eve-api/go/evecommon"
+	"github.com/stretchr/testify/assert"
+)
+
+func sha256Event(index, pcr uint32, data string) *attest.TpmEventLogEntry {
+	digest := sha256.Sum256([]byte(data))
+	return &attest.TpmEventLogEntry{
+		Index:    index,
+		PcrIndex: pcr,
+		Digest: &attest.TpmEventDigest{
+			HashAlgo: attest.TpmHashAlgo_TPM_HASH_ALGO_SHA256,
+			Digest:   digest[:],
+		},
+	}
+}
+
+func extend(values ...string) string {
+	pcr := make([]byte, sha256.Size)
+	for _, v := range values {
+		digest := sha256.Sum256([]byte(v))
+		extended := sha256.Sum256(append(pcr, digest[:]...))
+		pcr = extended[:]
+	}
+	return hex.EncodeToString(pcr)
+}
+
+func TestCheckEventLog(t *testing.T) {
+	t.Parallel()
+
+	eventLog := []*attest.TpmEventLogEntry{
+		sha256Event(0, 0, "firmware"),
+		{Index: 1, PcrIndex: 0, EventType: 0x3},
+		sha256Event(2, 0, "bootloader"),
+		sha256Event(3, 4, "kernel"),
+		{Index: 4, PcrIndex: 4, Digest: &attest.TpmEventDigest{HashAlgo: attest.TpmHashAlgo_TPM_HASH_ALGO_SHA1}},
+	}
+
+	testMatrix := map[string]struct {
+		pcrs  []*types.PCRValue
+		valid bool
+	}{
+		"match": {
+			pcrs: []*types.PCRValue{
+				{Index: 0, Value: extend("firmware", "bootloader")},
+				{Index: 4, Value: extend("kernel")},
+			},
+			valid: true,
+		},
+		"wildcard and PCR without events": {
+			pcrs: []*types.PCRValue{
+				{Index: 0, Value: "*"},
+				{Index: 7, Value: extend("other")},
+			},
+			valid: true,
+		},
+		"mismatch": {
+			pcrs: []*types.PCRValue{
+				{Index: 0, Value: extend("bootloader", "firmware")},
+			},
+			valid: false,
+		},
+		"not hex": {
+			pcrs:  []*types.PCRValue{{Index: 4, Value: "kernel"}},
+			valid: false,
+		},
+		"no PCRs": {
+			valid: false,
+		},
+	}
+
+	for name, test := range testMatrix {
+		err := controller.CheckEventLog(eventLog, &types.PCRTemplate{PCRValues: test.pcrs})
+		assert.Equal(t, test.valid, err == nil, name)
+	}
+	assert.Error(t, controller.CheckEventLog(nil, &types.PCRTemplate{
+		PCRValues: []*types.PCRValue{{Index: 0, Value: "*"}},
+	}))
+}
+
+func TestCheckTPMOnboarding(t *testing.T) {
+	t.Parallel()
+
+	withTPM := &types.Zcerts{Certs: []*certs.ZCert{
+		{Type: certs.ZCertType_CERT_TYPE_DEVICE_ENDORSEMENT_RSA, Cert: []byte("ek")},
+		{Type: certs.ZCertType_CERT_TYPE_DEVICE_RESTRICTED_SIGNING, Cert: []byte("ak")},
+		{Type: certs.ZCertType_CERT_TYPE_DEVICE_ECDH_EXCHANGE, Cert: []byte("ecdh")},
+	}}
+	assert.NoError(t, controller.CheckTPMOnboarding(withTPM))
+
+	withoutTPM := &types.Zcerts{Certs: []*certs.ZCert{
+		{Type: certs.ZCertType_CERT_TYPE_DEVICE_ECDH_EXCHANGE, Cert: []byte("ecdh")},
+	}}
+	assert.Error(t, controller.CheckTPMOnboarding(withoutTPM))
+	assert.Error(t, controller.CheckTPMOnboarding(nil))
+}
+
+func TestCheckCipherContexts(t *testing.T) {
+	t.Parallel()
+
+	devCert := []byte("device")
+	ctrlCert := []byte("controller\n")
+	devHash := sha256.Sum256(devCert)
+	ctrlHash := sha256.Sum256([]byte("controller"))
+
+	valid := &evecommon.CipherContext{
+		ContextId:          "valid",
+		KeyExchangeScheme:  evecommon.KeyExchangeScheme_KEA_ECDH,
+		DeviceCertHash:     devHash[:16],
+		ControllerCertHash: ctrlHash[:16],
+	}
+	outdated := &evecommon.CipherContext{
+		ContextId:          "outdated",
+		KeyExchangeScheme:  evecommon.KeyExchangeScheme_KEA_ECDH,
+		DeviceCertHash:     devHash[:16],
+		ControllerCertHash: devHash[:16],
+	}
+
+	assert.NoError(t, controller.CheckCipherContexts([]*evecommon.CipherContext{valid}, devCert, ctrlCert))
+	assert.Error(t, controller.CheckCipherContexts([]*evecommon.CipherContext{outdated}, devCert, ctrlCert))
+	assert.Error(t, controller.CheckCipherContexts(nil, devCert, ctrlCert))
+}
@@ -1,6 +1,7 @@
 package types
 
 import (
+	"errors"
 	"fmt"
 	"time"
 
@@ -9,6 +10,15 @@ import (
 	uuid "github.com/satori/go.uuid"
 )
 
+var (
+	// ErrConfigVersionMismatch returned by conditional update of config
+	// if version of config in controller is not the expected one
+	ErrConfigVersionMismatch = errors.New("version of config in controller does not match")
+	// ErrConditionalConfigUnsupported returned by conditional update of config
+	// if controller does not report version of config to check it
+	ErrConditionalConfigUnsupported = errors.New("controller does not support conditional update of config")
+)
+
 // DeviceStateFilter for filter device by state
 type DeviceStateFilter int
 
@@ -183,6 +193,48 @@ type DeviceOptions struct {
 	EventLog            []*attest.TpmEventLogEntry `json:"eventLog,omitempty"`
 }
 
+// AttestState aggregates attestation data known by controller for the device
+type AttestState struct {
+	Attested          bool         `json:"attested"`
+	Nonce             string       `json:"nonce"`
+	IntegrityToken    string       `json:"integrityToken"`
+	PCRTemplate       *PCRTemplate `json:"receivedPCRTemplate"`
+	EventLogEntries   int          `json:"eventLogEntries"`
+	Certs             []string     `json:"certs"`
+	AttestRequests    int          `json:"attestRequests"`
+	LastAttestRequest time.Time    `json:"lastAttestRequest,omitempty"`
+	EVEState          string       `json:"eveState,omitempty"`
+	EVEError          string       `json:"eveError,omitempty"`
+	Vaults            []string     `json:"vaults,omitempty"`
+	Checks            AttestChecks `json:"checks"`
+}
+
+// AttestChecks is result of validation of attestation stages, empty error string
+// means that the stage passed
+type AttestChecks struct {
+	Nonce       string `json:"nonce"`
+	Quote       string `json:"quote"`
+	StorageKeys string `json:"storageKeys"`
+}
+
+// OK returns true if all checks passed
+func (checks *AttestChecks) OK() bool {
+	return checks.Nonce == "" && checks.Quote == "" && checks.StorageKeys == ""
+}
+
+// TPMState is result of validation of TPM-based device inside controller,
+// empty error string means that the check passed
+type TPMState struct {
+	Onboarding   string `json:"onboarding"`
+	CipherConfig string `json:"cipherConfig"`
+	MeasuredBoot string `json:"measuredBoot"`
+}
+
+// OK returns true if all checks passed
+func (state *TPMState) OK() bool {
+	return state.Onboarding == "" && state.CipherConfig == "" && state.MeasuredBoot == ""
+}
+
 // OutputFormat the format to print output of metrics/logs/info
 type OutputFormat byte
 
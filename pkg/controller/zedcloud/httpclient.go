@@ -0,0 +1,153 @@
+package zedcloud
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/lf-edge/eden/pkg/controller/types"
+	"github.com/lf-edge/eden/pkg/exporter"
+	"github.com/lf-edge/eden/pkg/logging"
+	"github.com/lf-edge/eden/pkg/utils"
+	log "github.com/sirupsen/logrus"
+)
+
+// http client for zedcloud API
+func (zc *Ctx) getHTTPClient() *http.Client {
+	return &http.Client{
+		Timeout: time.Second * 30,
+		Transport: exporter.InstrumentRoundTripper(logging.RoundTripper("controller", &http.Transport{
+			TLSHandshakeTimeout:   10 * time.Second,
+			ResponseHeaderTimeout: 30 * time.Second,
+		})),
+	}
+}
+
+const (
+	// maxAttempts is number of attempts to send request to zedcloud
+	maxAttempts = 5
+	// retryDelay is delay before the second attempt, it grows with every attempt
+	retryDelay = 2 * time.Second
+)
+
+var (
+	// ErrUnauthorized returned if zedcloud rejects API token
+	ErrUnauthorized = errors.New("unauthorized by zedcloud, check API token")
+	// ErrNotFound returned if object is not found in zedcloud
+	ErrNotFound = errors.New("not found in zedcloud")
+)
+
+// statusError maps unexpected status of response into error
+func statusError(response *http.Response, body []byte) error {
+	var err error
+	switch response.StatusCode {
+	case http.StatusUnauthorized, http.StatusForbidden:
+		err = ErrUnauthorized
+	case http.StatusNotFound:
+		err = ErrNotFound
+	case http.StatusPreconditionFailed:
+		err = types.ErrConfigVersionMismatch
+	default:
+		return fmt.Errorf("unexpected status %s: %s", response.Status, strings.TrimSpace(string(body)))
+	}
+	return fmt.Errorf("%w: %s", err, response.Status)
+}
+
+// retryable returns true for responses which may succeed if repeated
+func retryable(statusCode int) bool {
+	return statusCode == http.StatusTooManyRequests || statusCode >= http.StatusInternalServerError
+}
+
+// attemptDelay returns delay before the next attempt, Retry-After of response is respected
+func attemptDelay(response *http.Response, attempt int) time.Duration {
+	if response != nil {
+		if seconds, err := strconv.Atoi(response.Header.Get("Retry-After")); err == nil && seconds >= 0 {
+			return time.Duration(seconds) * time.Second
+		}
+	}
+	return retryDelay * time.Duration(attempt)
+}
+
+// doRequest sends authorized request with header to zedcloud and returns body and header of response.
+// Request is repeated on transport errors and on 429 and 5xx responses.
+func (zc *Ctx) doRequest(method, path string, obj []byte, header http.Header) ([]byte, http.Header, error) {
+	u, err := utils.ResolveURL(zc.url, path)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error constructing URL: %w", err)
+	}
+	client := zc.getHTTPClient()
+	var lastErr error
+	for attempt := 1; attempt <= maxAttempts; attempt++ {
+		// body is consumed by every attempt, so request is created again
+		var body io.Reader
+		if obj != nil {
+			body = bytes.NewReader(obj)
+		}
+		req, err := http.NewRequest(method, u, body)
+		if err != nil {
+			return nil, nil, fmt.Errorf("unable to create new http request: %w", err)
+		}
+		for k, v := range header {
+			req.Header[k] = v
+		}
+		req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", zc.token))
+		log.Debugf("zedcloud: %s %s (attempt %d of %d)", method, u, attempt, maxAttempts)
+		response, err := client.Do(req)
+		if err != nil {
+			lastErr = fmt.Errorf("unable to send request: %w", err)
+		} else {
+			buf, err := io.ReadAll(response.Body)
+			response.Body.Close()
+			if err != nil {
+				lastErr = fmt.Errorf("unable to read data from URL %s: %w", u, err)
+			} else if response.StatusCode >= http.StatusOK && response.StatusCode < http.StatusMultipleChoices {
+				return buf, response.Header, nil
+			} else if lastErr = statusError(response, buf); !retryable(response.StatusCode) {
+				return nil, nil, lastErr
+			}
+		}
+		if attempt < maxAttempts {
+			delay := attemptDelay(response, attempt)
+			log.Infof("zedcloud: %s %s failed: %s, repeat in %s", method, u, lastErr, delay)
+			time.Sleep(delay)
+		}
+	}
+	return nil, nil, fmt.Errorf("%s %s failed after %d attempts: %w", method, u, maxAttempts, lastErr)
+}
+
+func (zc *Ctx) getObj(path string, acceptMime string) ([]byte, error) {
+	buf, _, err := zc.doRequest(http.MethodGet, path, nil, http.Header{"Accept": {acceptMime}})
+	return buf, err
+}
+
+func (zc *Ctx) headObj(path string) (http.Header, error) {
+	_, header, err := zc.doRequest(http.MethodHead, path, nil, nil)
+	return header, err
+}
+
+func (zc *Ctx) postObj(path string, obj []byte, mimeType string) error {
+	_, _, err := zc.doRequest(http.MethodPost, path, obj, http.Header{"Content-Type": {mimeType}})
+	return err
+}
+
+func (zc *Ctx) putObj(path string, obj []byte, mimeType string) error {
+	_, _, err := zc.doRequest(http.MethodPut, path, obj, http.Header{"Content-Type": {mimeType}})
+	return err
+}
+
+// putObjIfMatch puts object if its ETag in zedcloud is etag, repeat of already applied
+// request does not match anymore and is reported as types.ErrConfigVersionMismatch
+func (zc *Ctx) putObjIfMatch(path string, obj []byte, mimeType, etag string) error {
+	_, _, err := zc.doRequest(http.MethodPut, path, obj, http.Header{"Content-Type": {mimeType}, "If-Match": {etag}})
+	return err
+}
+
+func (zc *Ctx) deleteObj(path string) error {
+	_, _, err := zc.doRequest(http.MethodDelete, path, nil, nil)
+	return err
+}
@@ -0,0 +1,117 @@
+package zedcloud_test
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/lf-edge/eden/pkg/controller/zedcloud"
+	"github.com/lf-edge/eden/pkg/utils"
+	uuid "github.com/satori/go.uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+// newZedcloud returns zedcloud client for server which answers with statuses in order,
+// the last status is repeated
+func newZedcloud(t *testing.T, statuses ...int) (*zedcloud.Ctx, *int32) {
+	var attempts int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempt := int(atomic.AddInt32(&attempts, 1))
+		assert.Equal(t, "Bearer token", r.Header.Get("Authorization"))
+		if r.Method == http.MethodPut {
+			// body must be sent with every attempt
+			body, err := io.ReadAll(r.Body)
+			assert.NoError(t, err)
+			assert.Equal(t, "config", string(body))
+		}
+		status := statuses[len(statuses)-1]
+		if attempt <= len(statuses) {
+			status = statuses[attempt-1]
+		}
+		if status == 0 {
+			// drop connection to emulate transport error
+			conn, _, err := w.(http.Hijacker).Hijack()
+			if assert.NoError(t, err) {
+				conn.Close()
+			}
+			return
+		}
+		w.Header().Set("Retry-After", "0")
+		w.WriteHeader(status)
+		_, _ = w.Write([]byte(http.StatusText(status)))
+	}))
+	t.Cleanup(srv.Close)
+	zc := &zedcloud.Ctx{}
+	assert.NoError(t, zc.InitWithVars(&utils.ConfigVars{ZedcloudURL: srv.URL, ZedcloudToken: "token"}))
+	return zc, &attempts
+}
+
+func TestDoRequest(t *testing.T) {
+	t.Parallel()
+
+	testMatrix := map[string]struct {
+		statuses []int
+		attempts int32
+		err      error
+		failed   bool
+	}{
+		"ok": {
+			statuses: []int{http.StatusOK},
+			attempts: 1,
+		},
+		"retry on 503 and 429": {
+			statuses: []int{http.StatusServiceUnavailable, http.StatusTooManyRequests, http.StatusOK},
+			attempts: 3,
+		},
+		"retry on transport error": {
+			statuses: []int{0, http.StatusOK},
+			attempts: 2,
+		},
+		"no retry on 400": {
+			statuses: []int{http.StatusBadRequest, http.StatusOK},
+			attempts: 1,
+			failed:   true,
+		},
+		"unauthorized": {
+			statuses: []int{http.StatusUnauthorized},
+			attempts: 1,
+			err:      zedcloud.ErrUnauthorized,
+		},
+		"forbidden": {
+			statuses: []int{http.StatusForbidden},
+			attempts: 1,
+			err:      zedcloud.ErrUnauthorized,
+		},
+		"not found": {
+			statuses: []int{http.StatusNotFound},
+			attempts: 1,
+			err:      zedcloud.ErrNotFound,
+		},
+		"give up on 500": {
+			statuses: []int{http.StatusInternalServerError},
+			attempts: 5,
+			failed:   true,
+		},
+	}
+
+	for name, tt := range testMatrix {
+		tt := tt
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			zc, attempts := newZedcloud(t, tt.statuses...)
+			err := zc.ConfigSet(uuid.Must(uuid.NewV4()), []byte("config"))
+			switch {
+			case tt.err != nil:
+				assert.True(t, errors.Is(err, tt.err), "unexpected error: %v", err)
+			case tt.failed:
+				assert.Error(t, err)
+			default:
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, tt.attempts, atomic.LoadInt32(attempts))
+		})
+	}
+}
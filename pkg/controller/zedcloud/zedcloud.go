@@ -0,0 +1,356 @@
+package zedcloud
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/lf-edge/eden/pkg/controller/eapps"
+	"github.com/lf-edge/eden/pkg/controller/eflowlog"
+	"github.com/lf-edge/eden/pkg/controller/einfo"
+	"github.com/lf-edge/eden/pkg/controller/elog"
+	"github.com/lf-edge/eden/pkg/controller/emetric"
+	"github.com/lf-edge/eden/pkg/controller/erequest"
+	"github.com/lf-edge/eden/pkg/controller/types"
+	"github.com/lf-edge/eden/pkg/defaults"
+	"github.com/lf-edge/eden/pkg/device"
+	"github.com/lf-edge/eden/pkg/utils"
+	"github.com/lf-edge/eve-api/go/config"
+	uuid "github.com/satori/go.uuid"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
+
+const (
+	mimeProto = "application/x-proto-binary"
+	mimeJSON  = "application/json"
+
+	apiPrefix = "/api/v1"
+)
+
+// ErrNotSupported returned for operations zedcloud API does not expose
+var ErrNotSupported = errors.New("operation is not supported by zedcloud controller")
+
+// Ctx stores zedcloud controller settings
+type Ctx struct {
+	url     string
+	token   string
+	project string
+	device  string
+
+	// conditionalConfig caches support of conditional config update, see configVersionSupported
+	conditionalConfig int32
+}
+
+const (
+	conditionalConfigSupported = iota + 1
+	conditionalConfigUnsupported
+)
+
+// deviceObject is a subset of zedcloud device representation
+type deviceObject struct {
+	ID         string            `json:"id,omitempty"`
+	Name       string            `json:"name"`
+	ProjectID  string            `json:"projectId,omitempty"`
+	Serial     string            `json:"serialno,omitempty"`
+	Model      string            `json:"modelId,omitempty"`
+	Onboarding *onboardingObject `json:"onboarding,omitempty"`
+}
+
+type onboardingObject struct {
+	PemCert string `json:"pemCert"`
+}
+
+type deviceList struct {
+	List []*deviceObject `json:"list"`
+}
+
+// InitWithVars use variables from viper for init controller
+func (zc *Ctx) InitWithVars(vars *utils.ConfigVars) error {
+	zc.url = vars.ZedcloudURL
+	if zc.url == "" {
+		zc.url = defaults.DefaultZedcloudURL
+	}
+	if !strings.Contains(zc.url, "://") {
+		zc.url = fmt.Sprintf("https://%s", zc.url)
+	}
+	zc.token = vars.ZedcloudToken
+	if zc.token == "" {
+		zc.token = os.Getenv(defaults.DefaultZedcloudTokenEnv)
+	}
+	if zc.token == "" {
+		return fmt.Errorf("no API token for zedcloud: set controller.zedcloud.token or %s", defaults.DefaultZedcloudTokenEnv)
+	}
+	zc.project = vars.ZedcloudProject
+	zc.device = vars.ZedcloudDevice
+	if zc.device == "" {
+		zc.device = vars.EveName
+	}
+	return nil
+}
+
+// GetDir return dir
+func (zc *Ctx) GetDir() (dir string) {
+	return ""
+}
+
+func (zc *Ctx) getDeviceByName(name string) (*deviceObject, error) {
+	data, err := zc.getObj(path.Join(apiPrefix, "devices", "name", url.PathEscape(name)), mimeJSON)
+	if err != nil {
+		return nil, err
+	}
+	var dev deviceObject
+	if err := json.Unmarshal(data, &dev); err != nil {
+		return nil, fmt.Errorf("cannot unmarshal device %s: %w", name, err)
+	}
+	if dev.ID == "" {
+		return nil, fmt.Errorf("device %s not found", name)
+	}
+	return &dev, nil
+}
+
+// Register device in zedcloud
+func (zc *Ctx) Register(device *device.Ctx) error {
+	b, err := os.ReadFile(device.GetOnboardKey())
+	if err != nil {
+		return fmt.Errorf("error reading cert file %s: %w", device.GetOnboardKey(), err)
+	}
+	objToSend := deviceObject{
+		Name:      zc.device,
+		ProjectID: zc.project,
+		Serial:    device.GetSerial(),
+		Model:     device.GetDevModel(),
+		Onboarding: &onboardingObject{
+			PemCert: base64.StdEncoding.EncodeToString(b),
+		},
+	}
+	body, err := json.Marshal(objToSend)
+	if err != nil {
+		return fmt.Errorf("error encoding json: %w", err)
+	}
+	return zc.postObj(path.Join(apiPrefix, "devices"), body, mimeJSON)
+}
+
+// DeviceList return device list of the project
+func (zc *Ctx) DeviceList(filter types.DeviceStateFilter) (out []string, err error) {
+	if filter != types.RegisteredDeviceFilter && filter != types.AllDevicesFilter {
+		return []string{}, nil
+	}
+	query := url.Values{}
+	if zc.project != "" {
+		query.Set("projectName", zc.project)
+	}
+	data, err := zc.getObj(fmt.Sprintf("%s?%s", path.Join(apiPrefix, "devices"), query.Encode()), mimeJSON)
+	if err != nil {
+		return nil, err
+	}
+	var devices deviceList
+	if err := json.Unmarshal(data, &devices); err != nil {
+		return nil, fmt.Errorf("cannot unmarshal device list: %w", err)
+	}
+	for _, dev := range devices.List {
+		out = append(out, dev.ID)
+	}
+	return out, nil
+}
+
+// ConfigSet set config for devID
+func (zc *Ctx) ConfigSet(devUUID uuid.UUID, devConfig []byte) (err error) {
+	return zc.putObj(path.Join(apiPrefix, "devices", "id", devUUID.String(), "config"), devConfig, mimeProto)
+}
+
+// ConfigSetIfVersion set config for devID if version of config in zedcloud is version.
+// Zedcloud must report version of config in ETag header to check it.
+func (zc *Ctx) ConfigSetIfVersion(devUUID uuid.UUID, version string, devConfig []byte) (err error) {
+	configPath := path.Join(apiPrefix, "devices", "id", devUUID.String(), "config")
+	supported, err := zc.configVersionSupported(configPath)
+	if err != nil {
+		return err
+	}
+	if !supported {
+		return fmt.Errorf("%w: zedcloud does not report version of config in ETag", types.ErrConditionalConfigUnsupported)
+	}
+	return zc.putObjIfMatch(configPath, devConfig, mimeProto, strconv.Quote(version))
+}
+
+// configVersionSupported checks once if zedcloud reports version of config in ETag header
+func (zc *Ctx) configVersionSupported(configPath string) (bool, error) {
+	switch atomic.LoadInt32(&zc.conditionalConfig) {
+	case conditionalConfigSupported:
+		return true, nil
+	case conditionalConfigUnsupported:
+		return false, nil
+	}
+	header, err := zc.headObj(configPath)
+	if err != nil {
+		return false, err
+	}
+	state := int32(conditionalConfigUnsupported)
+	if header.Get("ETag") != "" {
+		state = conditionalConfigSupported
+	}
+	atomic.StoreInt32(&zc.conditionalConfig, state)
+	return state == conditionalConfigSupported, nil
+}
+
+// ConfigGet get config for devID in proto binary format
+func (zc *Ctx) ConfigGet(devUUID uuid.UUID) (out string, err error) {
+	data, err := zc.getObj(path.Join(apiPrefix, "devices", "id", devUUID.String(), "config"), mimeProto)
+	if err != nil {
+		return "", err
+	}
+	// zedcloud may ignore Accept header and answer with JSON
+	if !json.Valid(data) {
+		return string(data), nil
+	}
+	var devConfig config.EdgeDevConfig
+	if err := protojson.Unmarshal(data, &devConfig); err != nil {
+		return "", fmt.Errorf("cannot unmarshal config: %w", err)
+	}
+	res, err := proto.Marshal(&devConfig)
+	if err != nil {
+		return "", fmt.Errorf("cannot marshal config: %w", err)
+	}
+	return string(res), nil
+}
+
+// OnboardRemove is not applicable for zedcloud, device removal deletes onboarding
+func (zc *Ctx) OnboardRemove(_ string) (err error) {
+	return nil
+}
+
+// DeviceRemove remove device by devUUID
+func (zc *Ctx) DeviceRemove(devUUID uuid.UUID) (err error) {
+	return zc.deleteObj(path.Join(apiPrefix, "devices", "id", devUUID.String()))
+}
+
+// DeviceGetOnboard is not supported for zedcloud
+func (zc *Ctx) DeviceGetOnboard(_ uuid.UUID) (onboardUUID uuid.UUID, err error) {
+	return uuid.Nil, ErrNotSupported
+}
+
+// DeviceGetByOnboard returns device selected by name in config
+func (zc *Ctx) DeviceGetByOnboard(_ string) (devUUID uuid.UUID, err error) {
+	return zc.DeviceGetByOnboardUUID("")
+}
+
+// DeviceGetByOnboardUUID returns device selected by name in config
+// zedcloud does not expose onboarding UUID, so we rely on device name
+func (zc *Ctx) DeviceGetByOnboardUUID(_ string) (devUUID uuid.UUID, err error) {
+	if zc.device == "" {
+		return uuid.Nil, fmt.Errorf("no device name defined for zedcloud")
+	}
+	dev, err := zc.getDeviceByName(zc.device)
+	if err != nil {
+		return uuid.Nil, err
+	}
+	return uuid.FromString(dev.ID)
+}
+
+// GetDeviceCert is not supported for zedcloud
+func (zc *Ctx) GetDeviceCert(_ *device.Ctx) (*types.DeviceCert, error) {
+	return nil, ErrNotSupported
+}
+
+// GetDeviceCerts is not supported for zedcloud
+func (zc *Ctx) GetDeviceCerts(_ uuid.UUID) (*types.Zcerts, error) {
+	return nil, ErrNotSupported
+}
+
+// UploadDeviceCert is not supported for zedcloud
+func (zc *Ctx) UploadDeviceCert(_ types.DeviceCert) error {
+	return ErrNotSupported
+}
+
+// GetECDHCert is not supported for zedcloud
+func (zc *Ctx) GetECDHCert(_ uuid.UUID) ([]byte, error) {
+	return nil, ErrNotSupported
+}
+
+// SigningCertGet is not supported for zedcloud
+func (zc *Ctx) SigningCertGet() (signCert []byte, err error) {
+	return nil, ErrNotSupported
+}
+
+// SetDeviceOptions is not supported for zedcloud
+func (zc *Ctx) SetDeviceOptions(_ uuid.UUID, _ *types.DeviceOptions) error {
+	return ErrNotSupported
+}
+
+// GetDeviceOptions is not supported for zedcloud
+func (zc *Ctx) GetDeviceOptions(_ uuid.UUID) (*types.DeviceOptions, error) {
+	return nil, ErrNotSupported
+}
+
+// SetGlobalOptions is not supported for zedcloud
+func (zc *Ctx) SetGlobalOptions(_ *types.GlobalOptions) error {
+	return ErrNotSupported
+}
+
+// GetGlobalOptions is not supported for zedcloud
+func (zc *Ctx) GetGlobalOptions() (*types.GlobalOptions, error) {
+	return nil, ErrNotSupported
+}
+
+// RequestLastCallback is not supported for zedcloud
+func (zc *Ctx) RequestLastCallback(_ uuid.UUID, _ map[string]string, _ erequest.HandlerFunc) (err error) {
+	return ErrNotSupported
+}
+
+// LogAppsChecker is not supported for zedcloud
+func (zc *Ctx) LogAppsChecker(_ uuid.UUID, _ uuid.UUID, _ map[string]string, _ eapps.HandlerFunc, _ eapps.LogCheckerMode, _ time.Duration) (err error) {
+	return ErrNotSupported
+}
+
+// LogAppsLastCallback is not supported for zedcloud
+func (zc *Ctx) LogAppsLastCallback(_ uuid.UUID, _ uuid.UUID, _ map[string]string, _ eapps.HandlerFunc) (err error) {
+	return ErrNotSupported
+}
+
+// LogChecker is not supported for zedcloud
+func (zc *Ctx) LogChecker(_ uuid.UUID, _ map[string]string, _ elog.HandlerFunc, _ elog.LogCheckerMode, _ time.Duration) (err error) {
+	return ErrNotSupported
+}
+
+// LogLastCallback is not supported for zedcloud
+func (zc *Ctx) LogLastCallback(_ uuid.UUID, _ map[string]string, _ elog.HandlerFunc) (err error) {
+	return ErrNotSupported
+}
+
+// FlowLogChecker is not supported for zedcloud
+func (zc *Ctx) FlowLogChecker(_ uuid.UUID, _ map[string]string, _ eflowlog.HandlerFunc, _ eflowlog.FlowLogCheckerMode, _ time.Duration) (err error) {
+	return ErrNotSupported
+}
+
+// FlowLogLastCallback is not supported for zedcloud
+func (zc *Ctx) FlowLogLastCallback(_ uuid.UUID, _ map[string]string, _ eflowlog.HandlerFunc) (err error) {
+	return ErrNotSupported
+}
+
+// InfoChecker is not supported for zedcloud
+func (zc *Ctx) InfoChecker(_ uuid.UUID, _ map[string]string, _ einfo.HandlerFunc, _ einfo.InfoCheckerMode, _ time.Duration) (err error) {
+	return ErrNotSupported
+}
+
+// InfoLastCallback is not supported for zedcloud
+func (zc *Ctx) InfoLastCallback(_ uuid.UUID, _ map[string]string, _ einfo.HandlerFunc) (err error) {
+	return ErrNotSupported
+}
+
+// MetricChecker is not supported for zedcloud
+func (zc *Ctx) MetricChecker(_ uuid.UUID, _ map[string]string, _ emetric.HandlerFunc, _ emetric.MetricCheckerMode, _ time.Duration) (err error) {
+	return ErrNotSupported
+}
+
+// MetricLastCallback is not supported for zedcloud
+func (zc *Ctx) MetricLastCallback(_ uuid.UUID, _ map[string]string, _ emetric.HandlerFunc) (err error) {
+	return ErrNotSupported
+}
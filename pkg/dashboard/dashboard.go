@@ -0,0 +1,222 @@
+// Package dashboard serves web UI with live state of the device, its applications, networks
+// and volumes, recent logs and results of tests for the current context.
+package dashboard
+
+import (
+	"embed"
+	"encoding/json"
+	"io/fs"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/lf-edge/eden/pkg/controller/einfo"
+	"github.com/lf-edge/eden/pkg/controller/elog"
+	"github.com/lf-edge/eden/pkg/controller/emetric"
+	"github.com/lf-edge/eden/pkg/eve"
+	"github.com/lf-edge/eden/pkg/testhistory"
+	"github.com/lf-edge/eve-api/go/info"
+	"github.com/lf-edge/eve-api/go/metrics"
+	log "github.com/sirupsen/logrus"
+)
+
+//go:embed static
+var static embed.FS
+
+// maxLogs is count of the recent log entries kept for UI
+const maxLogs = 200
+
+// Device summarizes the device
+type Device struct {
+	ID          string
+	Name        string
+	State       string
+	EVEVersion  string
+	Arch        string
+	CPUs        uint32
+	MemoryMB    uint64
+	MemoryUsed  uint32
+	StorageMB   uint64
+	BootTime    time.Time
+	RebootCause string
+	LastInfo    time.Time
+}
+
+// LogLine is log entry of EVE shown in UI
+type LogLine struct {
+	Timestamp time.Time
+	Severity  string
+	Source    string
+	Content   string
+}
+
+// TestStatus is result of test in the last run of 'eden test --history'
+type TestStatus struct {
+	Test     string
+	Status   string
+	Duration time.Duration
+	PassRate float64
+	History  []string
+}
+
+// State is the document served to UI
+type State struct {
+	Device       Device
+	Applications []*eve.AppInstState
+	Networks     []*eve.NetInstState
+	Volumes      []*eve.VolInstState
+	Logs         []LogLine
+	Tests        []TestStatus
+	Updated      time.Time
+}
+
+// Server keeps state of the device fed by callbacks of the controller and serves it to UI
+type Server struct {
+	mu          sync.Mutex
+	deviceID    string
+	state       *eve.State
+	logs        []LogLine
+	history     *testhistory.DB
+	historyRuns int
+}
+
+// New returns server for the device state, history of tests is optional
+func New(deviceID string, state *eve.State, history *testhistory.DB, historyRuns int) *Server {
+	return &Server{deviceID: deviceID, state: state, history: history, historyRuns: historyRuns}
+}
+
+// InfoCallback feeds info messages into the state
+func (s *Server) InfoCallback() einfo.HandlerFunc {
+	callback := s.state.InfoCallback()
+	return func(msg *info.ZInfoMsg) bool {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		return callback(msg)
+	}
+}
+
+// MetricCallback feeds metric messages into the state
+func (s *Server) MetricCallback() emetric.HandlerFunc {
+	callback := s.state.MetricCallback()
+	return func(msg *metrics.ZMetricMsg) bool {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		return callback(msg)
+	}
+}
+
+// LogCallback keeps the recent log entries
+func (s *Server) LogCallback() elog.HandlerFunc {
+	return func(entry *elog.FullLogEntry) bool {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		s.logs = append(s.logs, LogLine{
+			Timestamp: entry.GetTimestamp().AsTime(),
+			Severity:  entry.GetSeverity(),
+			Source:    entry.GetSource(),
+			Content:   entry.GetContent(),
+		})
+		if len(s.logs) > maxLogs {
+			s.logs = s.logs[len(s.logs)-maxLogs:]
+		}
+		return false
+	}
+}
+
+// StateJSON returns the current state encoded for UI, it is encoded under lock as the objects
+// of applications, networks and volumes are updated by callbacks in place
+func (s *Server) StateJSON() ([]byte, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	snapshot := State{
+		Device:       s.device(),
+		Applications: s.state.Applications(),
+		Networks:     s.state.Networks(),
+		Volumes:      s.state.Volumes(),
+		Logs:         append([]LogLine{}, s.logs...),
+		Updated:      time.Now(),
+	}
+	snapshot.Tests = s.tests()
+	return json.Marshal(snapshot)
+}
+
+func (s *Server) device() Device {
+	device := Device{ID: s.deviceID}
+	infoAndMetrics := s.state.InfoAndMetrics()
+	if dinfo := infoAndMetrics.GetDinfo(); dinfo != nil {
+		device.Name = dinfo.GetHostName()
+		device.State = dinfo.GetState().String()
+		device.Arch = dinfo.GetMachineArch()
+		device.CPUs = dinfo.GetNcpu()
+		device.MemoryMB = dinfo.GetMemory()
+		device.StorageMB = dinfo.GetStorage()
+		device.RebootCause = dinfo.GetLastRebootReason()
+		if dinfo.GetBootTime() != nil {
+			device.BootTime = dinfo.GetBootTime().AsTime()
+		}
+		for _, sw := range dinfo.GetSwList() {
+			if sw.GetActivated() {
+				device.EVEVersion = sw.GetShortVersion()
+			}
+		}
+	}
+	if dm := infoAndMetrics.GetDeviceMetrics(); dm != nil {
+		device.MemoryUsed = dm.GetMemory().GetUsedMem()
+	}
+	if lastInfo := infoAndMetrics.GetLastInfoTime(); lastInfo != nil {
+		device.LastInfo = lastInfo.AsTime()
+	}
+	return device
+}
+
+func (s *Server) tests() []TestStatus {
+	if s.history == nil {
+		return nil
+	}
+	results, err := s.history.Results(s.historyRuns)
+	if err != nil {
+		log.Warnf("cannot read history of tests: %s", err)
+		return nil
+	}
+	if len(results) == 0 {
+		return nil
+	}
+	lastRun := results[len(results)-1].RunID
+	last := map[string]testhistory.Result{}
+	for _, result := range results {
+		if result.RunID == lastRun {
+			last[result.Test] = result
+		}
+	}
+	var tests []TestStatus
+	// trends are sorted by name of test
+	for _, trend := range testhistory.Trends(results) {
+		status := TestStatus{Test: trend.Test, PassRate: trend.PassRate, History: trend.History}
+		if result, ok := last[trend.Test]; ok {
+			status.Status = result.Status
+			status.Duration = result.Duration
+		}
+		tests = append(tests, status)
+	}
+	return tests
+}
+
+// Handler returns HTTP handler serving UI and its API
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	staticFS, err := fs.Sub(static, "static")
+	if err != nil {
+		log.Fatalf("embedded UI is missing: %s", err)
+	}
+	mux.Handle("/", http.FileServer(http.FS(staticFS)))
+	mux.HandleFunc("/api/state", func(w http.ResponseWriter, r *http.Request) {
+		data, err := s.StateJSON()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write(data)
+	})
+	return mux
+}
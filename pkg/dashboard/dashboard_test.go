@@ -0,0 +1,24 @@
+package dashboard_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/lf-edge/eden/pkg/dashboard"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEmbeddedUI(t *testing.T) {
+	t.Parallel()
+
+	handler := dashboard.New("dev", nil, nil, 0).Handler()
+	for _, path := range []string{"/", "/app.js", "/style.css"} {
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, path, nil))
+		assert.Equal(t, http.StatusOK, recorder.Code, path)
+	}
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/app.js", nil))
+	assert.Contains(t, recorder.Body.String(), "api/state")
+}
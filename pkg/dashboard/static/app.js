@@ -0,0 +1,157 @@
+// Polls state of the device from eden and renders it into tables.
+"use strict";
+
+const refreshInterval = 5000;
+let lastState = null;
+
+function text(value) {
+  if (value === undefined || value === null) {
+    return "";
+  }
+  if (Array.isArray(value)) {
+    return value.join(", ");
+  }
+  return String(value);
+}
+
+function time(value) {
+  if (!value || value.startsWith("0001-")) {
+    return "";
+  }
+  return new Date(value).toLocaleString();
+}
+
+function duration(ns) {
+  return (ns / 1e9).toFixed(1) + "s";
+}
+
+function stateClass(value) {
+  const v = text(value).toUpperCase();
+  if (v.includes("ERROR") || v.includes("FAIL") || v.includes("HALT")) {
+    return "bad";
+  }
+  if (v.includes("RUNNING") || v.includes("ONLINE") || v.includes("ACTIVATED") || v === "PASS" ||
+      v.includes("DELIVERED") || v.includes("CREATED_VOLUME")) {
+    return "ok";
+  }
+  return "";
+}
+
+// render fills table with rows, columns are [header, getter, styled] tuples
+function render(id, columns, rows) {
+  const table = document.getElementById(id);
+  table.replaceChildren();
+  const header = table.insertRow();
+  for (const [name] of columns) {
+    const th = document.createElement("th");
+    th.textContent = name;
+    header.appendChild(th);
+  }
+  if (!rows || rows.length === 0) {
+    const cell = table.insertRow().insertCell();
+    cell.colSpan = columns.length;
+    cell.className = "empty";
+    cell.textContent = "nothing to show";
+    return;
+  }
+  for (const row of rows) {
+    const tr = table.insertRow();
+    for (const [, get, styled] of columns) {
+      const cell = tr.insertCell();
+      cell.textContent = get(row);
+      if (styled) {
+        cell.className = stateClass(cell.textContent);
+      }
+    }
+  }
+}
+
+function renderDevice(device) {
+  const memory = device.MemoryMB ? `${device.MemoryUsed} / ${device.MemoryMB} MB` : "";
+  render("device", [["Property", (r) => r[0]], ["Value", (r) => r[1], true]], [
+    ["ID", device.ID],
+    ["Name", device.Name],
+    ["State", device.State],
+    ["EVE version", device.EVEVersion],
+    ["Architecture", device.Arch],
+    ["CPUs", device.CPUs],
+    ["Memory used", memory],
+    ["Storage", device.StorageMB ? `${device.StorageMB} MB` : ""],
+    ["Boot time", time(device.BootTime)],
+    ["Last reboot reason", device.RebootCause],
+    ["Last info", time(device.LastInfo)],
+  ].map(([name, value]) => [name, text(value)]));
+}
+
+function renderLogs() {
+  if (!lastState) {
+    return;
+  }
+  const filter = document.getElementById("log-filter").value.toLowerCase();
+  const logs = (lastState.Logs || []).filter((l) =>
+    !filter || `${l.Severity} ${l.Source} ${l.Content}`.toLowerCase().includes(filter));
+  render("logs", [
+    ["Time", (l) => time(l.Timestamp)],
+    ["Severity", (l) => l.Severity, true],
+    ["Source", (l) => l.Source],
+    ["Content", (l) => l.Content],
+  ], logs.reverse());
+}
+
+function renderState(state) {
+  lastState = state;
+  document.getElementById("updated").textContent = "updated " + time(state.Updated);
+  renderDevice(state.Device);
+  render("applications", [
+    ["Name", (a) => a.Name],
+    ["Image", (a) => a.Image],
+    ["Internal IP", (a) => text(a.InternalIP)],
+    ["External", (a) => a.ExternalPort ? `${a.ExternalIP}:${a.ExternalPort}` : a.ExternalIP],
+    ["Memory", (a) => a.MemoryAvail ? `${a.MemoryUsed} / ${a.MemoryAvail} MB` : ""],
+    ["CPU", (a) => `${a.CPUUsage}%`],
+    ["State (Adam)", (a) => a.AdamState],
+    ["State (EVE)", (a) => a.EVEState, true],
+  ], state.Applications);
+  render("networks", [
+    ["Name", (n) => n.Name],
+    ["Type", (n) => n.NetworkType],
+    ["CIDR", (n) => n.CIDR],
+    ["Stats", (n) => n.Stats],
+    ["State (Adam)", (n) => n.AdamState],
+    ["State (EVE)", (n) => n.EveState, true],
+  ], state.Networks);
+  render("volumes", [
+    ["Name", (v) => v.Name],
+    ["Image", (v) => v.Image],
+    ["Type", (v) => v.VolumeType],
+    ["Size", (v) => v.Size],
+    ["Max size", (v) => v.MaxSize],
+    ["Mount", (v) => v.MountPoint],
+    ["State (Adam)", (v) => v.AdamState],
+    ["State (EVE)", (v) => v.LastError || v.EveState, true],
+  ], state.Volumes);
+  render("tests", [
+    ["Test", (t) => t.Test],
+    ["Last status", (t) => t.Status, true],
+    ["Duration", (t) => t.Status ? duration(t.Duration) : ""],
+    ["Pass rate", (t) => `${Math.round(t.PassRate * 100)}%`],
+    ["History", (t) => text(t.History)],
+  ], state.Tests);
+  renderLogs();
+}
+
+async function refresh() {
+  try {
+    const response = await fetch("api/state");
+    if (!response.ok) {
+      throw new Error(await response.text());
+    }
+    renderState(await response.json());
+  } catch (err) {
+    document.getElementById("updated").textContent = "cannot get state: " + err.message;
+  }
+}
+
+document.getElementById("log-filter").addEventListener("input", renderLogs);
+refresh();
+setInterval(refresh, refreshInterval);
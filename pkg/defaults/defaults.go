@@ -17,23 +17,43 @@ const (
 	DefaultAdamDist         = ""                 //directory for volume of adam inside dist
 	DefaultEVEDist          = "eve"              //directory for build EVE inside dist
 	DefaultCertsDist        = "certs"            //directory for certs inside dist
+	DefaultLOCDist          = "loc"              //directory for configs staged on Local Operator Console inside dist
 	DefaultBinDist          = "bin"              //directory for binaries inside dist
 	DefaultEdenHomeDir      = ".eden"            //directory inside HOME directory for configs
 	DefaultBuildtoolsDir    = "build-tools"      //directory to store tools needed for building (e.g. linuxkit)
 	DefaultCurrentDirConfig = "eden-config.yml"  //file for search config in current directory
 	DefaultContextFile      = "context.yml"      //file for saving current context inside DefaultEdenHomeDir
 	DefaultContextDirectory = "contexts"         //directory for saving contexts inside DefaultEdenHomeDir
+	DefaultCacheDist        = "cache"            //directory for cache of artifacts shared between contexts inside DefaultEdenHomeDir
+	DefaultSecretsFile      = "secrets.enc"      //encrypted file with secrets shared between contexts inside DefaultEdenHomeDir
 	DefaultQemuFileToSave   = "qemu.conf"        //qemu config file inside DefaultEdenHomeDir
 	DefaultSSHKey           = "certs/id_rsa.pub" //file for save ssh key
 	DefaultConfigHidden     = ".eden-config.yml" //file to save config get --all
 	DefaultConfigSaved      = "config_saved.yml" //file to save config during 'eden setup'
 	DefaultSwtpmSockFile    = "swtpm-sock"       //file to communicate with swtpm
+	DefaultCellularSockFile = "usbredir-sock"    //file to attach emulated cellular modem to QEMU
+	DefaultResumeStateFile  = "resume.json"      //file to save state to resume environment after host reboot
+	DefaultTestHistoryFile  = "test-history.db"  //sqlite database with history of test results inside DefaultEdenHomeDir
+	DefaultMetricsListen    = ":9101"            //address to serve metrics in Prometheus format on
+	DefaultMetricsTestRuns  = 20                 //number of runs of tests from history exported as metrics
+	DefaultDashboardListen  = "localhost:9102"   //address to serve web dashboard on
 	DefaultAdditionalDisks  = 0                  //number of disks to use alongside with bootable one
+	DefaultEveNodes         = 1                  //number of EVE nodes started by eden
 
 	DefaultContext = "default" //default context name
 
-	DefaultConfigEnv   = "EDEN_CONFIG"    //default env for set config
-	DefaultTestArgsEnv = "EDEN_TEST_ARGS" //default env for test arguments
+	DefaultConfigEnv        = "EDEN_CONFIG"         //default env for set config
+	DefaultTestArgsEnv      = "EDEN_TEST_ARGS"      //default env for test arguments
+	DefaultTestSuiteEnv     = "EDEN_TEST_SUITE"     //default env for directory with state of test suites
+	DefaultAPICoverageEnv   = "EDEN_API_COVERAGE"   //default env for directory to record coverage of EVE API by test
+	DefaultZedcloudTokenEnv = "EDEN_ZEDCLOUD_TOKEN" //default env for zedcloud API token
+	DefaultSecretsPassEnv   = "EDEN_SECRETS_PASS"   //default env for passphrase to encrypt secrets with instead of key in OS keyring
+	DefaultRecordEnv        = "EDEN_RECORD"         //default env for directory to record traffic of controller
+	DefaultReplayEnv        = "EDEN_REPLAY"         //default env for directory with recorded traffic of controller to replay
+	DefaultNodeEnv          = "EDEN_NODE"           //default env for node of multi-node EVE to use
+	DefaultLogFormatEnv     = "EDEN_LOG_FORMAT"     //default env for format of logs (text or json)
+	DefaultLogComponentsEnv = "EDEN_LOG_COMPONENTS" //default env for verbosity of components in format component=level,...
+	DefaultRequestIDEnv     = "EDEN_REQUEST_ID"     //default env for ID of operation shared by requests to controller
 )
 
 // domains, ips, ports
@@ -42,15 +62,28 @@ const (
 	DefaultIP                   = "192.168.0.1"
 	DefaultEVEIP                = "192.168.1.2"
 	DefaultEserverPort          = 8888
+	DefaultLOCPort              = 8889
 	DefaultTelnetPort           = 17777
 	DefaultQemuMonitorPort      = 7788
 	DefaultQemuNetdevSocketPort = 7790
 	DefaultSSHPort              = 2222
+	DefaultNodePortOffset       = 100              // ports of host used by every next node of multi-node EVE are shifted by offset
+	DefaultEveSnapshot          = "clean"          // name of snapshot of EVE used by eden eve snapshot and restore if not set
+	DefaultConsoleMaxSize       = 10 * 1024 * 1024 // size of file with captured console of EVE to rotate it
+	DefaultConsoleMaxFiles      = 5                // count of rotated files with captured console of EVE to keep
+	DefaultConsoleExcerptLines  = 200              // max lines of console of EVE printed for failed test
+	DefaultClusterNetMcast      = "230.0.0.1:7780" // multicast address of QEMU socket connecting nodes of multi-node EVE
 	DefaultEVEHost              = "127.0.0.1"
 	DefaultRedisHost            = "localhost"
 	DefaultRedisPort            = 6379
 	DefaultAdamPort             = 3333
 	DefaultRegistryPort         = 5050
+	DefaultZedcloudURL          = "zedcontrol.zededa.net"
+
+	//controller types
+	DefaultControllerAdam     = "adam"
+	DefaultControllerZedcloud = "zedcloud"
+	DefaultControllerType     = DefaultControllerAdam
 
 	//tags, versions, repos
 	DefaultEVETag               = "13.2.0" // DefaultEVETag tag for EVE image
@@ -74,6 +107,17 @@ const (
 	DefaultSFTPPassword  = "password"
 	DefaultSFTPDirPrefix = "/eserver/run"
 
+	DefaultS3Bucket    = "eserver"
+	DefaultS3Region    = "us-east-1"
+	DefaultS3AccessKey = "eden"
+	DefaultS3SecretKey = "edensecret"
+
+	DefaultEServerTLSClientAuth = "none"
+	DefaultEServerCertFile      = "eserver.pem"
+	DefaultEServerKeyFile       = "eserver-key.pem"
+	DefaultEServerClientCert    = "eserver-client.pem"
+	DefaultEServerClientKey     = "eserver-client-key.pem"
+
 	DefaultEVEPlatform = "none"
 
 	DefaultRedisPasswordFile = "redis.pass"
@@ -84,10 +128,29 @@ const (
 	DefaultEClientTag          = "b1c1de6"
 	DefaultEClientContainerRef = "lfedge/eden-eclient"
 
+	DefaultEdgeviewTag          = "latest"
+	DefaultEdgeviewContainerRef = "lfedge/eve-edgeview"
+	//DefaultEdgeviewExpire is lifetime of token of edgeview session
+	DefaultEdgeviewExpire = 24 * time.Hour
+
+	//DefaultHelmTag and DefaultHelmContainerRef define image with helm used to render charts if it is not installed
+	DefaultHelmTag          = "3.14.4"
+	DefaultHelmContainerRef = "alpine/helm"
+
 	//DefaultRepeatCount is repeat count for requests
 	DefaultRepeatCount = 20
 	//DefaultRepeatTimeout is time wait for next attempt
-	DefaultRepeatTimeout         = 5 * time.Second
+	DefaultRepeatTimeout = 5 * time.Second
+	//DefaultLoaderWorkers is number of objects (logs, info, metrics) loaded from controller concurrently
+	DefaultLoaderWorkers = 4
+	//DefaultLoaderPageSize is number of objects requested from redis of controller at once
+	DefaultLoaderPageSize = 100
+	//DefaultStorageWarn is percent of used capacity of EVE disks and volumes to warn about in status
+	DefaultStorageWarn = 80
+	//DefaultStorageFail is percent of used capacity of EVE disks and volumes considered as failure in status
+	DefaultStorageFail = 95
+	//DefaultEServerChunkSize is size of chunk for resumable uploads into eserver
+	DefaultEServerChunkSize      = 16 * 1024 * 1024
 	DefaultUUID                  = "1"
 	DefaultFileToSave            = "./test.tar"
 	DefaultIsLocal               = false
@@ -99,9 +162,10 @@ const (
 	NetDHCPID2                   = "6822e35f-c1b8-43ca-b344-0bbc0ece8cf2"
 	NetWiFiID                    = "6822e35f-c1b8-43ca-b344-0bbc0ece8cf3"
 	NetSwitch                    = "6822e35f-c1b8-43ca-b344-0bbc0ece8cf4"
+	NetCellularID                = "6822e35f-c1b8-43ca-b344-0bbc0ece8cf5"
 	DefaultTestProg              = "eden.escript.test"
 	DefaultTestScenario          = ""
-	DefaultRootFSVersionPattern  = `^.*-(xen|kvm|acrn|rpi|rpi-xen|rpi-kvm)-(amd64|arm64)$`
+	DefaultRootFSVersionPattern  = `^.*-(xen|kvm|acrn|rpi|rpi-xen|rpi-kvm)-(amd64|arm64|riscv64)$`
 	DefaultControllerModePattern = `^(?P<Type>(file|proto|adam|zedcloud)):\/\/(?P<URL>.*)$`
 	DefaultPodLinkPattern        = `^(?P<TYPE>(oci|docker|http[s]{0,1}|file|directory)):\/\/(?P<TAG>[^:]+):*(?P<VERSION>.*)$`
 	DefaultRedisContainerName    = "eden_redis"
@@ -130,11 +194,22 @@ const (
 	DefaultQemuAccelArm64 = "-machine virt,accel=kvm,usb=off,dump-guest-core=off -cpu host "
 	DefaultQemuArm64      = "-machine virt,virtualization=true -cpu cortex-a57 "
 
+	DefaultQemuAccelRiscv64 = "-machine virt,accel=kvm,acpi=off -cpu host "
+	DefaultQemuRiscv64      = "-machine virt,acpi=off -cpu rv64,h=true "
+
 	DefaultAppSubnet        = "10.11.12.0/24"
 	DefaultHostOnlyNotation = "host-only-acl"
 
 	DefaultQemuModel = "ZedVirtual-4G"
 
+	// DefaultQemuIPv6Subnet is IPv6 prefix of QEMU user networking used without SDN
+	DefaultQemuIPv6Subnet = "fd00:e0e::/64"
+
+	// DefaultCellularAPN is APN of the cellular network provided by SDN to emulated modem
+	DefaultCellularAPN = "internet"
+	// DefaultCellularLabel is logical label of emulated cellular modem and its SDN port
+	DefaultCellularLabel = "wwan0"
+
 	DefaultRPIModel = "RPi4"
 
 	DefaultGCPModel = "GCP"
@@ -147,6 +222,10 @@ const (
 
 	DefaultParallelsModel = "parallels"
 
+	DefaultVMwareModel = "VMware"
+
+	DefaultHyperVModel = "HyperV"
+
 	DefaultGeneralModel = "general"
 
 	DefaultEVERemote = false
@@ -178,6 +257,18 @@ const (
 	DefaultGcpMachineType  = "n1-standard-2" // 2 vCPU 7.5 GB RAM
 	DefaultGcpRulePriority = 10
 
+	//defaults for aws
+
+	DefaultAwsBucketName   = "eden-eve-live"
+	DefaultAwsRegion       = "us-west-2"
+	DefaultAwsInstanceType = "c5.metal" // bare metal as EVE requires virtualization
+
+	//defaults for cloud
+
+	DefaultCloudInstanceName = "eden-eve"
+	DefaultCloudGCP          = "gcp"
+	DefaultCloudAWS          = "aws"
+
 	//defaults for packet
 
 	DefaultVMName            = "eden-packet-test"
@@ -191,9 +282,15 @@ const (
 
 	DefaultParallelsUUID = "{5fbaabe3-6958-40ff-92a7-860e329aab41}"
 
+	//defaults for Hyper-V
+
+	DefaultHyperVSwitch = "Default Switch" //switch with NAT and DHCP created by Hyper-V
+
 	DefaultPerfEVELocation       = "/persist/perf.data"
 	DefaultPerfScriptEVELocation = "/persist/perf.script.out"
 	DefaultHWEVELocation         = "/persist/lshw.out"
+	DefaultNetDumpEVEDir         = "/persist/tmp" //directory on EVE to save packet captures into
+	DefaultNetDumpDuration       = 30 * time.Second
 
 	//defaults for SDN
 	DefaultSdnTelnetPort = 6623
@@ -201,6 +298,8 @@ const (
 	DefaultSdnMgmtPort   = 6666
 	DefaultSdnCpus       = 2
 	DefaultSdnMemory     = 2048
+	// DefaultSdnNetworkType is IP versions used by Eden environment, including default network model of SDN
+	DefaultSdnNetworkType = "ipv4-only"
 )
 
 var (
@@ -75,7 +75,11 @@ eve:
     #This is a legacy method soon to be replaced by EdgeDevConfig-based bootstrapping.
     usbnetconf-file: '{{parse "eve.usbnetconf-file"}}'
 
-    #EVE arch (amd64/arm64)
+    #Version of EVE API used to adapt generated configs for older EVE releases (e.g. 9.4.0).
+    #Detected from the info messages of device if empty.
+    api-version: '{{parse "eve.api-version"}}'
+
+    #EVE arch (amd64/arm64/riscv64)
     arch: '{{parse "eve.arch"}}'
 
     #EVE platform (imx8mk_evk, opi3_lts and others)
@@ -181,6 +185,23 @@ eve:
     #additional disks count
     disks: {{parse "eve.disks"}}
 
+    #count of EVE nodes connected to the same Adam and to shared network,
+    #select node with --node flag or EDEN_NODE env
+    nodes: {{parse "eve.nodes"}}
+
+    #PCI devices to assign to applications as <label>=<pci address>[@<group>],
+    #devices of host are passed into EVE running in QEMU with VFIO
+    pci-passthrough: {{parse "eve.pci-passthrough"}}
+
+    #emulated cellular modem attached to QEMU-emulated device and connected to SDN
+    cellular:
+        #attach MBIM modem emulated by eden over usbredir,
+        #it requires SDN which gets extra cellular network for the modem
+        enabled: {{parse "eve.cellular.enabled"}}
+
+        #APN of the cellular network
+        apn: '{{parse "eve.cellular.apn"}}'
+
     #configuration specific to QEMU-emulated device
     qemu:
         #port for QEMU Monitor
@@ -218,6 +239,24 @@ eden:
         #force eserver rebuild
         force: {{parse "eden.eserver.force"}}
 
+        #serve eserver over TLS with certificate signed by root-certificate
+        tls: {{parse "eden.eserver.tls"}}
+
+        #verification of client certificates by eserver: none, optional or require
+        tls-client-auth: '{{parse "eden.eserver.tls-client-auth"}}'
+
+        #credentials for read-only sftp access to eserver used by SFTP datastores
+        sftp-user: '{{parse "eden.eserver.sftp-user"}}'
+        sftp-password: '{{parse "eden.eserver.sftp-password"}}'
+
+    #local operator console emulated by eden (eden loc)
+    loc:
+        #port for loc
+        port: {{parse "eden.loc.port"}}
+
+        #directory with configs staged on loc
+        dist: '{{parse "eden.loc.dist"}}'
+
     #eclient is tool we use in tests
     eclient:
         #tag of eclient container
@@ -243,10 +282,35 @@ eden:
     #test scenario
     test-scenario: '{{parse "eden.test-scenario"}}'
 
+    #components deployed by eden: adam, redis, registry, eserver, sdn and eve
+    components: '{{parse "eden.components"}}'
+
 gcp:
     #path to the key to interact with gcp
     key: '{{parse "gcp.key"}}'
 
+cloud:
+    #cloud to run EVE in by eden setup (gcp or aws), empty to run it locally
+    provider: '{{parse "cloud.provider"}}'
+
+    #project of gcp
+    project: '{{parse "cloud.project"}}'
+
+    #zone of gcp
+    zone: '{{parse "cloud.zone"}}'
+
+    #region of aws
+    region: '{{parse "cloud.region"}}'
+
+    #machine type of gcp or instance type of aws, default of provider if empty
+    machine-type: '{{parse "cloud.machine-type"}}'
+
+    #name of instance, image and firewall rules
+    instance: '{{parse "cloud.instance"}}'
+
+    #bucket to upload image into, default of provider if empty
+    bucket: '{{parse "cloud.bucket"}}'
+
 packet:
     #path to the key to interact with packet
     key: '{{parse "packet.key"}}'
@@ -315,6 +379,32 @@ sdn:
     #path to JSON file with network model to apply into SDN
     #leave empty for default network model
     network-model: '{{parse "sdn.network-model"}}'
+
+    #IP versions used to connect EVE with Adam, EServer and registry in default network model,
+    #also used for QEMU user networking with SDN disabled and for networks in device config
+    #(ipv4-only, ipv6-only or dual-stack)
+    network-type: '{{parse "sdn.network-type"}}'
+
+    #proxy between EVE and the controller in the network model of SDN
+    #(explicit, explicit-auth or transparent), leave empty for no proxy
+    proxy: '{{parse "sdn.proxy"}}'
+
+controller:
+    #type of controller to use (adam/zedcloud)
+    type: '{{parse "controller.type"}}'
+
+    zedcloud:
+        #address of zedcloud API
+        url: '{{parse "controller.zedcloud.url"}}'
+
+        #API token for zedcloud (EDEN_ZEDCLOUD_TOKEN env is used if empty), may be secret:<name> reference to eden secret
+        token: '{{parse "controller.zedcloud.token"}}'
+
+        #name of project to look for device in
+        project: '{{parse "controller.zedcloud.project"}}'
+
+        #name of device in zedcloud (eve.name is used if empty)
+        device: '{{parse "controller.zedcloud.device"}}'
 `
 
 //DefaultQemuTemplate is configuration template for qemu
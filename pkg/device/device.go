@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"log"
 
+	"github.com/lf-edge/eve-api/go/config"
 	"github.com/lf-edge/eve-api/go/evecommon"
 	uuid "github.com/satori/go.uuid"
 )
@@ -55,7 +56,11 @@ type Ctx struct {
 	globalProfile              string
 	localProfileServer         string
 	profileServerToken         string
+	locURL                     string
 	diskLayout                 *DisksLayout
+	edgeViewConfig             *config.EdgeViewConfig
+	controllerConfig           []byte
+	generatedConfig            []byte
 }
 
 // CreateEdgeNode generates EdgeNode
@@ -81,6 +86,18 @@ func (cfg *Ctx) GetConfigVersion() int { return cfg.configVersion }
 // SetConfigVersion set configVersion of device
 func (cfg *Ctx) SetConfigVersion(version int) { cfg.configVersion = version }
 
+// GetControllerConfig return config of device as it was loaded from controller or pushed into it
+func (cfg *Ctx) GetControllerConfig() []byte { return cfg.controllerConfig }
+
+// SetControllerConfig set config of device loaded from controller or pushed into it
+func (cfg *Ctx) SetControllerConfig(devConfig []byte) { cfg.controllerConfig = devConfig }
+
+// GetGeneratedConfig return config generated by eden for device when controller config was set
+func (cfg *Ctx) GetGeneratedConfig() []byte { return cfg.generatedConfig }
+
+// SetGeneratedConfig set config generated by eden for device when controller config was set
+func (cfg *Ctx) SetGeneratedConfig(devConfig []byte) { cfg.generatedConfig = devConfig }
+
 // GetBaseOSContentTree return baseOSContentTree of device
 func (cfg *Ctx) GetBaseOSContentTree() string { return cfg.baseOSContentTree }
 
@@ -329,6 +346,12 @@ func (cfg *Ctx) SetID(id uuid.UUID) {
 	cfg.id = id
 }
 
+// HashChanged check hash without update
+// returns true if hash is new
+func (cfg *Ctx) HashChanged(newHash [32]byte) bool {
+	return cfg.hash != newHash
+}
+
 // CheckHash check hash and update
 // returns true if hash is new
 func (cfg *Ctx) CheckHash(newHash [32]byte) bool {
@@ -361,6 +384,17 @@ func (cfg *Ctx) GetDiskLayout() *DisksLayout {
 	return cfg.diskLayout
 }
 
+// SetEdgeViewConfig set EdgeViewConfig for device, nil disables edgeview
+func (cfg *Ctx) SetEdgeViewConfig(edgeViewConfig *config.EdgeViewConfig) *Ctx {
+	cfg.edgeViewConfig = edgeViewConfig
+	return cfg
+}
+
+// GetEdgeViewConfig get EdgeViewConfig of device
+func (cfg *Ctx) GetEdgeViewConfig() *config.EdgeViewConfig {
+	return cfg.edgeViewConfig
+}
+
 // SetDeviceItem for setting devConfig fields
 func (cfg *Ctx) SetDeviceItem(key string, val string) error {
 	switch key {
@@ -370,6 +404,8 @@ func (cfg *Ctx) SetDeviceItem(key string, val string) error {
 		cfg.localProfileServer = val
 	case "profile_server_token":
 		cfg.profileServerToken = val
+	case "loc_url":
+		cfg.locURL = val
 	default:
 		return fmt.Errorf("unsopported key: %s", key)
 	}
@@ -405,3 +441,13 @@ func (cfg *Ctx) GetProfileServerToken() string {
 func (cfg *Ctx) SetProfileServerToken(profileServerToken string) {
 	cfg.profileServerToken = profileServerToken
 }
+
+// GetLocURL get URL of Local Operator Console
+func (cfg *Ctx) GetLocURL() string {
+	return cfg.locURL
+}
+
+// SetLocURL set URL of Local Operator Console, empty disables it
+func (cfg *Ctx) SetLocURL(locURL string) {
+	cfg.locURL = locURL
+}
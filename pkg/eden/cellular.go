@@ -0,0 +1,29 @@
+package eden
+
+import (
+	"path/filepath"
+
+	"github.com/lf-edge/eden/pkg/defaults"
+	"github.com/lf-edge/eden/pkg/utils"
+)
+
+// CellularModemSocket returns path of the usbredir socket served by the modem emulator
+// running with the state in stateDir
+func CellularModemSocket(stateDir string) string {
+	return filepath.Join(stateDir, defaults.DefaultCellularSockFile)
+}
+
+// CellularModemPidFile returns pid file of the modem emulator running with the state in stateDir
+func CellularModemPidFile(stateDir string) string {
+	return filepath.Join(stateDir, "modem.pid")
+}
+
+// CellularModemLogFile returns log file of the modem emulator running with the state in stateDir
+func CellularModemLogFile(stateDir string) string {
+	return filepath.Join(stateDir, "modem.log")
+}
+
+// StopCellularModem stops emulator of cellular modem using pid from stateDir
+func StopCellularModem(stateDir string) error {
+	return utils.StopCommandWithPid(CellularModemPidFile(stateDir))
+}
@@ -0,0 +1,296 @@
+package eden
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/lf-edge/eden/pkg/utils"
+)
+
+const (
+	// consoleFile is the current file of captured console, rotated files have suffixes .1, .2 and so on
+	consoleFile = "console.log"
+	// consoleTimeLayout is layout of timestamp every captured line starts with
+	consoleTimeLayout = "2006-01-02T15:04:05.000000Z"
+	// consolePollInterval is interval to check for new output of QEMU
+	consolePollInterval = 200 * time.Millisecond
+)
+
+// ConsoleCaptureDir returns directory with captured console of EVE, it is placed next to log of QEMU
+func ConsoleCaptureDir(logFile string) string {
+	return strings.TrimSuffix(logFile, filepath.Ext(logFile)) + "-console"
+}
+
+// ConsoleCapturePidFile returns pid file of process capturing console of EVE
+func ConsoleCapturePidFile(logFile string) string {
+	return filepath.Join(ConsoleCaptureDir(logFile), "capture.pid")
+}
+
+// ConsoleCapture copies serial console of EVE written by QEMU into Source to rotating files in Dir,
+// every line is prefixed with time it was captured
+type ConsoleCapture struct {
+	// Source is log file of serial console written by QEMU
+	Source string
+	// Offset in Source to start capture from, QEMU appends to the log on every start
+	Offset int64
+	// Dir to keep captured files
+	Dir string
+	// MaxSize of one file in bytes
+	MaxSize int64
+	// MaxFiles is count of rotated files to keep besides the current one
+	MaxFiles int
+	// QemuPidFile is pid file of QEMU, capture ends when QEMU exits
+	QemuPidFile string
+
+	out  *os.File
+	size int64
+}
+
+// Run captures console until QEMU exits
+func (c *ConsoleCapture) Run() error {
+	if err := os.MkdirAll(c.Dir, 0755); err != nil {
+		return err
+	}
+	src, err := c.openSource()
+	if err != nil || src == nil {
+		return err
+	}
+	defer src.Close()
+	if _, err := src.Seek(c.Offset, io.SeekStart); err != nil {
+		return fmt.Errorf("cannot seek in %s: %w", c.Source, err)
+	}
+	if err := c.openOut(); err != nil {
+		return err
+	}
+	defer c.out.Close()
+	reader := bufio.NewReader(src)
+	var line []byte
+	for {
+		chunk, err := reader.ReadBytes('\n')
+		line = append(line, chunk...)
+		if errors.Is(err, io.EOF) {
+			if !c.qemuRunning() {
+				if len(line) > 0 {
+					return c.write(line)
+				}
+				return nil
+			}
+			time.Sleep(consolePollInterval)
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("cannot read %s: %w", c.Source, err)
+		}
+		if err := c.write(line); err != nil {
+			return err
+		}
+		line = nil
+	}
+}
+
+// openSource waits for QEMU to create its log, it returns nil if QEMU exits before
+func (c *ConsoleCapture) openSource() (*os.File, error) {
+	for {
+		src, err := os.Open(c.Source)
+		if err == nil {
+			return src, nil
+		}
+		if !os.IsNotExist(err) {
+			return nil, err
+		}
+		if !c.qemuRunning() {
+			return nil, nil
+		}
+		time.Sleep(consolePollInterval)
+	}
+}
+
+func (c *ConsoleCapture) qemuRunning() bool {
+	status, err := utils.StatusCommandWithPid(c.QemuPidFile)
+	return err == nil && strings.HasPrefix(status, "running")
+}
+
+func (c *ConsoleCapture) openOut() error {
+	out, err := os.OpenFile(filepath.Join(c.Dir, consoleFile), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+	info, err := out.Stat()
+	if err != nil {
+		out.Close()
+		return err
+	}
+	c.out, c.size = out, info.Size()
+	return nil
+}
+
+func (c *ConsoleCapture) write(line []byte) error {
+	s := strings.TrimRight(string(line), "\r\n")
+	n, err := fmt.Fprintf(c.out, "%s %s\n", time.Now().UTC().Format(consoleTimeLayout), s)
+	if err != nil {
+		return err
+	}
+	c.size += int64(n)
+	if c.MaxSize > 0 && c.size >= c.MaxSize {
+		return c.rotate()
+	}
+	return nil
+}
+
+func (c *ConsoleCapture) rotate() error {
+	if err := c.out.Close(); err != nil {
+		return err
+	}
+	current := filepath.Join(c.Dir, consoleFile)
+	if c.MaxFiles < 1 {
+		if err := os.Remove(current); err != nil {
+			return err
+		}
+		return c.openOut()
+	}
+	for i := c.MaxFiles - 1; i > 0; i-- {
+		err := os.Rename(fmt.Sprintf("%s.%d", current, i), fmt.Sprintf("%s.%d", current, i+1))
+		if err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	if err := os.Rename(current, current+".1"); err != nil {
+		return err
+	}
+	return c.openOut()
+}
+
+// rotatedConsoleFiles returns existing rotated files of captured console from the oldest one
+func rotatedConsoleFiles(dir string) []string {
+	var files []string
+	for i := 1; ; i++ {
+		file := filepath.Join(dir, fmt.Sprintf("%s.%d", consoleFile, i))
+		if _, err := os.Stat(file); err != nil {
+			break
+		}
+		files = append([]string{file}, files...)
+	}
+	return files
+}
+
+// consoleLineTime returns time the captured line was written
+func consoleLineTime(line string) (time.Time, bool) {
+	stamp, _, ok := strings.Cut(line, " ")
+	if !ok {
+		return time.Time{}, false
+	}
+	t, err := time.Parse(consoleTimeLayout, stamp)
+	return t, err == nil
+}
+
+// readConsoleFile passes lines of file captured not before since to fn and returns offset it read up to
+func readConsoleFile(file *os.File, since time.Time, fn func(line string) error) (int64, error) {
+	reader := bufio.NewReader(file)
+	var offset int64
+	for {
+		line, err := reader.ReadString('\n')
+		if errors.Is(err, io.EOF) {
+			// partial line is read again next time
+			return offset, nil
+		}
+		if err != nil {
+			return offset, err
+		}
+		offset += int64(len(line))
+		if t, ok := consoleLineTime(line); ok && t.Before(since) {
+			continue
+		}
+		if err := fn(strings.TrimSuffix(line, "\n")); err != nil {
+			return offset, err
+		}
+	}
+}
+
+// ReadConsole passes lines of console captured in dir not before since to fn from the oldest one.
+// With follow it waits for new lines until fn returns error.
+func ReadConsole(dir string, since time.Time, follow bool, fn func(line string) error) error {
+	for _, name := range rotatedConsoleFiles(dir) {
+		file, err := os.Open(name)
+		if err != nil {
+			// rotated while reading
+			continue
+		}
+		_, err = readConsoleFile(file, since, fn)
+		file.Close()
+		if err != nil {
+			return err
+		}
+	}
+	current := filepath.Join(dir, consoleFile)
+	file, err := os.Open(current)
+	if err != nil {
+		if os.IsNotExist(err) && follow {
+			file = nil
+		} else if os.IsNotExist(err) {
+			return fmt.Errorf("no captured console of EVE in %s", dir)
+		} else {
+			return err
+		}
+	}
+	var offset int64
+	for {
+		if file != nil {
+			if _, err := file.Seek(offset, io.SeekStart); err != nil {
+				file.Close()
+				return err
+			}
+			read, err := readConsoleFile(file, since, fn)
+			offset += read
+			if err != nil {
+				file.Close()
+				return err
+			}
+		}
+		if !follow {
+			if file != nil {
+				file.Close()
+			}
+			return nil
+		}
+		time.Sleep(consolePollInterval)
+		// reopen the file if it was rotated
+		info, err := os.Stat(current)
+		if err != nil {
+			continue
+		}
+		if file != nil {
+			opened, err := file.Stat()
+			if err == nil && os.SameFile(info, opened) {
+				continue
+			}
+			// the rest of rotated file
+			if _, err := file.Seek(offset, io.SeekStart); err == nil {
+				_, _ = readConsoleFile(file, since, fn)
+			}
+			file.Close()
+		}
+		if file, err = os.Open(current); err != nil {
+			file = nil
+		}
+		offset = 0
+	}
+}
+
+// ConsoleExcerpt returns up to maxLines last lines of console captured in dir not before since
+func ConsoleExcerpt(dir string, since time.Time, maxLines int) ([]string, error) {
+	var lines []string
+	err := ReadConsole(dir, since, false, func(line string) error {
+		lines = append(lines, line)
+		if len(lines) > maxLines {
+			lines = lines[1:]
+		}
+		return nil
+	})
+	return lines, err
+}
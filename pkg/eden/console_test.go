@@ -0,0 +1,51 @@
+package eden_test
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/lf-edge/eden/pkg/eden"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConsoleCapture(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	source := filepath.Join(dir, "eve.log")
+	pidFile := filepath.Join(dir, "qemu.pid")
+	// output of the previous run of QEMU is skipped with offset
+	previous := "old line\n"
+	assert.NoError(t, os.WriteFile(source, []byte(previous+"line 1\r\nline 2\nline 3\r\nline 4\n"), 0644))
+	// QEMU is gone, so capture stops at the end of log
+	assert.NoError(t, os.WriteFile(pidFile, []byte(strconv.Itoa(1<<30)), 0644))
+
+	capture := &eden.ConsoleCapture{
+		Source:      source,
+		Offset:      int64(len(previous)),
+		Dir:         filepath.Join(dir, "console"),
+		MaxSize:     30,
+		MaxFiles:    2,
+		QemuPidFile: pidFile,
+	}
+	assert.NoError(t, capture.Run())
+
+	// every file keeps one timestamped line, the oldest ones are dropped
+	lines, err := eden.ConsoleExcerpt(capture.Dir, time.Time{}, 10)
+	assert.NoError(t, err)
+	if assert.Len(t, lines, 2) {
+		assert.Regexp(t, `^\d{4}-\d\d-\d\dT\S+Z line 3$`, lines[0])
+		assert.Regexp(t, `Z line 4$`, lines[1])
+	}
+
+	lines, err = eden.ConsoleExcerpt(capture.Dir, time.Time{}, 1)
+	assert.NoError(t, err)
+	assert.Len(t, lines, 1)
+
+	lines, err = eden.ConsoleExcerpt(capture.Dir, time.Now().Add(time.Hour), 10)
+	assert.NoError(t, err)
+	assert.Empty(t, lines)
+}
@@ -3,8 +3,10 @@ package eden
 import (
 	"bytes"
 	"crypto/tls"
+	"crypto/x509"
 	"encoding/json"
 	"encoding/pem"
+	"errors"
 	"fmt"
 	"io"
 	"math/big"
@@ -18,6 +20,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/dustin/go-humanize"
 	"github.com/lf-edge/eden/eserver/api"
 	"github.com/lf-edge/eden/pkg/controller"
 	"github.com/lf-edge/eden/pkg/defaults"
@@ -313,13 +316,27 @@ func StatusRegistry() (status string, err error) {
 
 // StartEServer function run eserver in docker
 // if eserverForce is set, it recreates container
-func StartEServer(serverPort int, imageDist string, eserverForce bool, eserverTag string) (err error) {
+func StartEServer(serverPort int, imageDist string, eserverForce bool, eserverTag string, opts ...string) (err error) {
+	edenHome, err := utils.DefaultEdenDir()
+	if err != nil {
+		return err
+	}
+	globalCertsDir := filepath.Join(edenHome, defaults.DefaultCertsDist)
 	portMap := map[string]string{"8888": strconv.Itoa(serverPort)}
-	volumeMap := map[string]string{"/eserver/run/eserver/": imageDist}
-	eserverServerCommand := strings.Fields("server")
+	volumeMap := map[string]string{
+		"/eserver/run/eserver/": imageDist,
+		globalCertsDir:          globalCertsDir,
+	}
+	if imageDist != "" {
+		// content of embedded registry is stored next to images
+		volumeMap["/eserver/run/registry/"] = strings.TrimSuffix(imageDist, string(os.PathSeparator)) + "-registry"
+	}
+	eserverServerCommand := append(strings.Fields("server"), opts...)
 	// lets make sure eserverImageDist exists
-	if imageDist != "" && os.MkdirAll(imageDist, os.ModePerm) != nil {
-		return fmt.Errorf("StartEServer: %s does not exist and can not be created", imageDist)
+	for _, dir := range volumeMap {
+		if dir != "" && os.MkdirAll(dir, os.ModePerm) != nil {
+			return fmt.Errorf("StartEServer: %s does not exist and can not be created", dir)
+		}
 	}
 	if eserverForce {
 		_ = utils.StopContainer(defaults.DefaultEServerContainerName, true)
@@ -344,6 +361,109 @@ func StartEServer(serverPort int, imageDist string, eserverForce bool, eserverTa
 	return nil
 }
 
+// GenerateEServerCerts generates certificate of eserver signed by root-certificate for provided ips and domain
+// and certificate of eden to access eserver if client certificates are verified.
+// Existing certificates are used unless rotate is set.
+func GenerateEServerCerts(ips []string, domain string, rotate bool) error {
+	edenHome, err := utils.DefaultEdenDir()
+	if err != nil {
+		return fmt.Errorf("GenerateEServerCerts: %w", err)
+	}
+	globalCertsDir := filepath.Join(edenHome, defaults.DefaultCertsDist)
+	caCertPath := filepath.Join(globalCertsDir, "root-certificate.pem")
+	caKeyPath := filepath.Join(globalCertsDir, "root-certificate-key.pem")
+	rootCert, err := utils.ParseCertificate(caCertPath)
+	if err != nil {
+		return fmt.Errorf("GenerateEServerCerts: cannot parse certificate from %s: %w", caCertPath, err)
+	}
+	rootKey, err := utils.ParsePrivateKey(caKeyPath)
+	if err != nil {
+		return fmt.Errorf("GenerateEServerCerts: cannot parse key from %s: %w", caKeyPath, err)
+	}
+	// new serial number for every generated certificate to distinguish rotated ones
+	serial := big.NewInt(time.Now().UnixNano())
+	certPath := filepath.Join(globalCertsDir, defaults.DefaultEServerCertFile)
+	keyPath := filepath.Join(globalCertsDir, defaults.DefaultEServerKeyFile)
+	if _, err := tls.LoadX509KeyPair(certPath, keyPath); err != nil || rotate {
+		log.Debug("generating eserver cert and key")
+		netIPs := []net.IP{net.ParseIP("127.0.0.1")}
+		for _, ip := range ips {
+			if parsed := net.ParseIP(ip); parsed != nil {
+				netIPs = append(netIPs, parsed)
+			}
+		}
+		serverCert, serverKey := utils.GenServerCertElliptic(rootCert, rootKey, serial, netIPs, []string{domain}, domain)
+		if err := utils.WriteToFiles(serverCert, serverKey, certPath, keyPath); err != nil {
+			return fmt.Errorf("GenerateEServerCerts: %w", err)
+		}
+	}
+	clientCertPath := filepath.Join(globalCertsDir, defaults.DefaultEServerClientCert)
+	clientKeyPath := filepath.Join(globalCertsDir, defaults.DefaultEServerClientKey)
+	if _, err := tls.LoadX509KeyPair(clientCertPath, clientKeyPath); err != nil {
+		log.Debug("generating eserver client cert and key")
+		clientCert, clientKey := utils.GenClientCertElliptic(rootCert, rootKey, serial, "eden")
+		if err := utils.WriteToFiles(clientCert, clientKey, clientCertPath, clientKeyPath); err != nil {
+			return fmt.Errorf("GenerateEServerCerts: %w", err)
+		}
+	}
+	return nil
+}
+
+// EServerSFTPOpts returns options for eserver to accept sftp logins with provided credentials,
+// eserver uses defaults.DefaultSFTPUser and defaults.DefaultSFTPPassword for empty ones
+func EServerSFTPOpts(user, password string) []string {
+	var opts []string
+	if user != "" {
+		opts = append(opts, "--user", user)
+	}
+	if password != "" {
+		opts = append(opts, "--password", password)
+	}
+	return opts
+}
+
+// EServerLogOpts returns options for eserver to log with level of Eden and format taken from environment
+func EServerLogOpts() []string {
+	opts := []string{"--log-level", log.GetLevel().String()}
+	if format := os.Getenv(defaults.DefaultLogFormatEnv); format != "" {
+		opts = append(opts, "--log-format", format)
+	}
+	return opts
+}
+
+// EServerTLSOpts returns options for eserver to serve over TLS with certificates generated by GenerateEServerCerts
+func EServerTLSOpts(clientAuth string) ([]string, error) {
+	edenHome, err := utils.DefaultEdenDir()
+	if err != nil {
+		return nil, err
+	}
+	globalCertsDir := filepath.Join(edenHome, defaults.DefaultCertsDist)
+	return []string{
+		"--tls-cert", filepath.Join(globalCertsDir, defaults.DefaultEServerCertFile),
+		"--tls-key", filepath.Join(globalCertsDir, defaults.DefaultEServerKeyFile),
+		"--tls-client-ca", filepath.Join(globalCertsDir, "root-certificate.pem"),
+		"--tls-client-auth", clientAuth,
+	}, nil
+}
+
+// EServerCertsPEM returns certificate of eserver and root-certificate to verify it
+func EServerCertsPEM() ([][]byte, error) {
+	edenHome, err := utils.DefaultEdenDir()
+	if err != nil {
+		return nil, err
+	}
+	globalCertsDir := filepath.Join(edenHome, defaults.DefaultCertsDist)
+	var result [][]byte
+	for _, name := range []string{defaults.DefaultEServerCertFile, "root-certificate.pem"} {
+		content, err := os.ReadFile(filepath.Join(globalCertsDir, name))
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, content)
+	}
+	return result, nil
+}
+
 // StopEServer function stop eserver container
 func StopEServer(eserverRm bool) (err error) {
 	state, err := utils.StateContainer(defaults.DefaultEServerContainerName)
@@ -747,6 +867,10 @@ func MakeEveInRepo(desc utils.EVEDescription, dist string) (image, additional st
 		biosPath1 := filepath.Join(dist, "dist", desc.Arch, "current", "installer", "firmware", "OVMF.fd")
 		biosPath2 := filepath.Join(dist, "dist", desc.Arch, "current", "installer", "firmware", "OVMF_VARS.fd")
 		additional = strings.Join([]string{biosPath1, biosPath2}, ",")
+	case "riscv64":
+		biosPath1 := filepath.Join(dist, "dist", desc.Arch, "current", "installer", "firmware", "OVMF_CODE.fd")
+		biosPath2 := filepath.Join(dist, "dist", desc.Arch, "current", "installer", "firmware", "OVMF_VARS.fd")
+		additional = strings.Join([]string{biosPath1, biosPath2}, ",")
 	default:
 		return "", "", fmt.Errorf("MakeEveInRepo: unsupported arch %s", desc.Arch)
 	}
@@ -796,26 +920,16 @@ func CleanContext(eveDist, certsDist, imagesDist, evePID, eveUUID, sdnPID, vmNam
 	}
 	if !remote {
 		devModel := viper.GetString("eve.devModel")
-		switch devModel {
-		case defaults.DefaultVBoxModel:
-			if err := StopEVEVBox(vmName); err != nil {
-				log.Infof("cannot stop EVE: %s", err)
-			} else {
-				log.Infof("EVE stopped")
-			}
-			if err := DeleteEVEVBox(vmName); err != nil {
-				log.Infof("cannot delete EVE: %s", err)
-			}
-		case defaults.DefaultParallelsModel:
-			if err := StopEVEParallels(vmName); err != nil {
+		if driver, ok := GetVMDriver(devModel); ok {
+			if err := driver.Stop(vmName); err != nil {
 				log.Infof("cannot stop EVE: %s", err)
 			} else {
 				log.Infof("EVE stopped")
 			}
-			if err := DeleteEVEParallels(vmName); err != nil {
+			if err := driver.Delete(vmName); err != nil {
 				log.Infof("cannot delete EVE: %s", err)
 			}
-		default:
+		} else {
 			if err := StopEVEQemu(evePID); err != nil {
 				log.Infof("cannot stop EVE: %s", err)
 			} else {
@@ -883,14 +997,8 @@ func StopEden(adamRm, redisRm, registryRm, eserverRm, eveRemote bool,
 
 // StopEve stops EVE, vTPM and SDN.
 func StopEve(evePidFile, swtpmPidFile, sdnPidFile, devModel, vmName string) {
-	if devModel == defaults.DefaultVBoxModel {
-		if err := StopEVEVBox(vmName); err != nil {
-			log.Infof("cannot stop EVE: %s", err)
-		} else {
-			log.Infof("EVE stopped")
-		}
-	} else if devModel == defaults.DefaultParallelsModel {
-		if err := StopEVEParallels(vmName); err != nil {
+	if driver, ok := GetVMDriver(devModel); ok {
+		if err := driver.Stop(vmName); err != nil {
 			log.Infof("cannot stop EVE: %s", err)
 		} else {
 			log.Infof("EVE stopped")
@@ -999,12 +1107,8 @@ func CleanEden(eveDist, adamDist, certsDist, imagesDist, eserverDist, redisDist,
 	if err = utils.RemoveGeneratedVolumeOfContainer(defaults.DefaultRegistryContainerName); err != nil {
 		return fmt.Errorf("CleanEden: RemoveGeneratedVolumeOfContainer for %s: %s", defaults.DefaultRegistryContainerName, err)
 	}
-	if devModel == defaults.DefaultVBoxModel {
-		if err := DeleteEVEVBox(vmName); err != nil {
-			log.Infof("cannot delete EVE: %s", err)
-		}
-	} else if devModel == defaults.DefaultParallelsModel {
-		if err := DeleteEVEParallels(vmName); err != nil {
+	if driver, ok := GetVMDriver(devModel); ok {
+		if err := driver.Delete(vmName); err != nil {
 			log.Infof("cannot delete EVE: %s", err)
 		}
 	}
@@ -1015,20 +1119,61 @@ func CleanEden(eveDist, adamDist, certsDist, imagesDist, eserverDist, redisDist,
 type EServer struct {
 	EServerIP   string
 	EServerPort string
+	// TLS is set if eserver is served over TLS
+	TLS bool
+}
+
+// baseURL returns URL of eserver for eden access
+func (server *EServer) baseURL() string {
+	if server.TLS {
+		return "https://" + net.JoinHostPort(server.EServerIP, server.EServerPort)
+	}
+	return "http://" + net.JoinHostPort(server.EServerIP, server.EServerPort)
+}
+
+// tlsConfig returns configuration to verify eserver with root-certificate
+// and to present certificate of eden if eserver verifies clients
+func (server *EServer) tlsConfig() (*tls.Config, error) {
+	edenHome, err := utils.DefaultEdenDir()
+	if err != nil {
+		return nil, err
+	}
+	globalCertsDir := filepath.Join(edenHome, defaults.DefaultCertsDist)
+	caCert, err := os.ReadFile(filepath.Join(globalCertsDir, "root-certificate.pem"))
+	if err != nil {
+		return nil, err
+	}
+	cfg := &tls.Config{RootCAs: x509.NewCertPool()}
+	cfg.RootCAs.AppendCertsFromPEM(caCert)
+	clientCert, err := tls.LoadX509KeyPair(
+		filepath.Join(globalCertsDir, defaults.DefaultEServerClientCert),
+		filepath.Join(globalCertsDir, defaults.DefaultEServerClientKey))
+	if err == nil {
+		cfg.Certificates = []tls.Certificate{clientCert}
+	}
+	return cfg, nil
 }
 
 func (server *EServer) getHTTPClient(timeout time.Duration) *http.Client {
+	transport := &http.Transport{
+		ResponseHeaderTimeout: defaults.DefaultRepeatTimeout * defaults.DefaultRepeatCount,
+	}
+	if server.TLS {
+		tlsConfig, err := server.tlsConfig()
+		if err != nil {
+			log.Fatalf("cannot prepare TLS config for eserver: %s", err)
+		}
+		transport.TLSClientConfig = tlsConfig
+	}
 	return &http.Client{
-		Timeout: timeout,
-		Transport: &http.Transport{
-			ResponseHeaderTimeout: defaults.DefaultRepeatTimeout * defaults.DefaultRepeatCount,
-		},
+		Timeout:   timeout,
+		Transport: transport,
 	}
 }
 
 // EServerAddFileURL send url to download image into eserver
 func (server *EServer) EServerAddFileURL(url string) (name string) {
-	u, err := utils.ResolveURL(fmt.Sprintf("http://%s:%s", server.EServerIP, server.EServerPort), "admin/add-from-url")
+	u, err := utils.ResolveURL(server.baseURL(), "admin/add-from-url")
 	if err != nil {
 		log.Fatalf("error constructing URL: %v", err)
 	}
@@ -1058,7 +1203,7 @@ func (server *EServer) EServerAddFileURL(url string) (name string) {
 
 // EServerCheckStatus checks status of image in eserver
 func (server *EServer) EServerCheckStatus(name string) (fileInfo *api.FileInfo) {
-	u, err := utils.ResolveURL(fmt.Sprintf("http://%s:%s", server.EServerIP, server.EServerPort), fmt.Sprintf("admin/status/%s", name))
+	u, err := utils.ResolveURL(server.baseURL(), fmt.Sprintf("admin/status/%s", name))
 	if err != nil {
 		log.Fatalf("EServerAddFileURL: error constructing URL: %v", err)
 	}
@@ -1082,9 +1227,177 @@ func (server *EServer) EServerCheckStatus(name string) (fileInfo *api.FileInfo)
 	return
 }
 
-// EServerAddFile send file with image into eserver
+// errNoChunkedUpload returned if eserver does not support chunked uploads
+var errNoChunkedUpload = errors.New("chunked upload is not supported")
+
+// eserverUploadSize returns size of data received by eserver for chunked upload
+func (server *EServer) eserverUploadSize(client *http.Client, u string) (int64, error) {
+	response, err := client.Get(u)
+	if err != nil {
+		return 0, err
+	}
+	defer response.Body.Close()
+	if response.StatusCode != http.StatusOK {
+		return 0, fmt.Errorf("bad status: %s", response.Status)
+	}
+	var fileInfo api.FileInfo
+	if err := json.NewDecoder(response.Body).Decode(&fileInfo); err != nil {
+		return 0, err
+	}
+	return fileInfo.Size, nil
+}
+
+// eserverUploadChunk sends chunk of file started at offset.
+// Returns information with size of data received by eserver.
+func (server *EServer) eserverUploadChunk(client *http.Client, u string, f *os.File, offset, total int64) (*api.FileInfo, error) {
+	end := offset + defaults.DefaultEServerChunkSize
+	if end > total {
+		end = total
+	}
+	req, err := http.NewRequest(http.MethodPut, u, io.NewSectionReader(f, offset, end-offset))
+	if err != nil {
+		return nil, err
+	}
+	req.ContentLength = end - offset
+	req.Header.Set("Content-Range", fmt.Sprintf("bytes %d-%d/%d", offset, end-1, total))
+	response, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer response.Body.Close()
+	switch response.StatusCode {
+	case http.StatusOK, http.StatusRequestedRangeNotSatisfiable:
+	case http.StatusNotFound, http.StatusMethodNotAllowed:
+		return nil, errNoChunkedUpload
+	default:
+		buf, _ := io.ReadAll(response.Body)
+		return nil, fmt.Errorf("bad status (%s) in response (%s)", response.Status, string(buf))
+	}
+	var fileInfo api.FileInfo
+	if err := json.NewDecoder(response.Body).Decode(&fileInfo); err != nil {
+		return nil, err
+	}
+	if fileInfo.Error != "" {
+		log.Debugf("eserver received %d bytes: %s", fileInfo.Size, fileInfo.Error)
+	}
+	return &fileInfo, nil
+}
+
+// eserverUploadChunks sends file into eserver by chunks.
+// After failure it asks eserver for size of received data and continues from it.
+func (server *EServer) eserverUploadChunks(filePath, prefix string) (*api.FileInfo, error) {
+	fileName := filepath.Base(filePath)
+	if prefix != "" {
+		fileName = fmt.Sprintf("%s/%s", prefix, fileName)
+	}
+	u, err := utils.ResolveURL(server.baseURL(), fmt.Sprintf("admin/upload/%s", fileName))
+	if err != nil {
+		return nil, fmt.Errorf("error constructing URL: %w", err)
+	}
+	f, err := os.Open(filePath)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+	total := info.Size()
+	if total == 0 {
+		return nil, errNoChunkedUpload
+	}
+	client := server.getHTTPClient(0)
+	var offset int64
+	failures := 0
+	for {
+		fileInfo, err := server.eserverUploadChunk(client, u, f, offset, total)
+		if err == nil {
+			failures = 0
+			if fileInfo.ISReady {
+				fmt.Printf("\n")
+				return fileInfo, nil
+			}
+			offset = fileInfo.Size
+			if log.IsLevelEnabled(log.InfoLevel) {
+				fmt.Printf("\rUploading... %s of %s complete", humanize.Bytes(uint64(offset)), humanize.Bytes(uint64(total)))
+			}
+			continue
+		}
+		if errors.Is(err, errNoChunkedUpload) {
+			return nil, err
+		}
+		failures++
+		if failures > defaults.DefaultRepeatCount {
+			return nil, err
+		}
+		log.Infof("Upload of %s interrupted: %s, resume (%d) of (%d)", filePath, err, failures, defaults.DefaultRepeatCount)
+		time.Sleep(defaults.DefaultRepeatTimeout)
+		// eserver may store part of failed chunk
+		if size, err := server.eserverUploadSize(client, u); err == nil && size < total {
+			offset = size
+		}
+	}
+}
+
+// eserverLinkFile asks eserver to create file from content with the same sha256 stored before.
+// Returns nil if eserver has no such content or does not support deduplication.
+func (server *EServer) eserverLinkFile(filePath, prefix string) (*api.FileInfo, error) {
+	fileName := filepath.Base(filePath)
+	if prefix != "" {
+		fileName = fmt.Sprintf("%s/%s", prefix, fileName)
+	}
+	u, err := utils.ResolveURL(server.baseURL(), fmt.Sprintf("admin/link/%s", fileName))
+	if err != nil {
+		return nil, fmt.Errorf("error constructing URL: %w", err)
+	}
+	sha := utils.SHA256SUM(filePath)
+	client := server.getHTTPClient(defaults.DefaultRepeatTimeout)
+	req, err := http.NewRequest(http.MethodPost, fmt.Sprintf("%s?sha256=%s", u, sha), nil)
+	if err != nil {
+		return nil, err
+	}
+	response, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer response.Body.Close()
+	switch response.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotFound, http.StatusMethodNotAllowed:
+		return nil, nil
+	default:
+		buf, _ := io.ReadAll(response.Body)
+		return nil, fmt.Errorf("bad status (%s) in response (%s)", response.Status, string(buf))
+	}
+	var fileInfo api.FileInfo
+	if err := json.NewDecoder(response.Body).Decode(&fileInfo); err != nil {
+		return nil, err
+	}
+	return &fileInfo, nil
+}
+
+// EServerAddFile send file with image into eserver.
+// Upload is skipped if eserver already stores content with the same sha256.
+// File is sent by chunks to continue upload after network failures,
+// eserver without support of chunked uploads receives it in single request.
 func (server *EServer) EServerAddFile(filepath, prefix string) (fileInfo *api.FileInfo) {
-	u, err := utils.ResolveURL(fmt.Sprintf("http://%s:%s", server.EServerIP, server.EServerPort), "admin/add-from-file")
+	fileInfo, err := server.eserverLinkFile(filepath, prefix)
+	if err != nil {
+		log.Fatalf("EServerAddFile: %s", err)
+	}
+	if fileInfo != nil {
+		log.Infof("%s is already stored in eserver, upload skipped", filepath)
+		return fileInfo
+	}
+	fileInfo, err = server.eserverUploadChunks(filepath, prefix)
+	if err == nil {
+		return fileInfo
+	}
+	if !errors.Is(err, errNoChunkedUpload) {
+		log.Fatalf("EServerAddFile: %s", err)
+	}
+	u, err := utils.ResolveURL(server.baseURL(), "admin/add-from-file")
 	if err != nil {
 		log.Fatalf("EServerAddFile: error constructing URL: %v", err)
 	}
@@ -1103,6 +1416,32 @@ func (server *EServer) EServerAddFile(filepath, prefix string) (fileInfo *api.Fi
 	return
 }
 
+// EServerRegistryGC removes blobs not referenced by manifests from registry of eserver
+func (server *EServer) EServerRegistryGC() (string, error) {
+	u, err := utils.ResolveURL(server.baseURL(), "admin/registry/gc")
+	if err != nil {
+		return "", fmt.Errorf("EServerRegistryGC: error constructing URL: %w", err)
+	}
+	client := server.getHTTPClient(defaults.DefaultRepeatTimeout * defaults.DefaultRepeatCount)
+	req, err := http.NewRequest("POST", u, nil)
+	if err != nil {
+		return "", fmt.Errorf("EServerRegistryGC: unable to create new http request: %w", err)
+	}
+	response, err := utils.RepeatableAttempt(client, req)
+	if err != nil {
+		return "", fmt.Errorf("EServerRegistryGC: unable to send request: %w", err)
+	}
+	defer response.Body.Close()
+	buf, err := io.ReadAll(response.Body)
+	if err != nil {
+		return "", fmt.Errorf("EServerRegistryGC: unable to read data from URL %s: %w", u, err)
+	}
+	if response.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("EServerRegistryGC: %s: %s", response.Status, buf)
+	}
+	return string(buf), nil
+}
+
 // ReadFileInSquashFS returns the content of a single file (filePath) inside squashfs (squashFSPath)
 func ReadFileInSquashFS(squashFSPath, filePath string) (content []byte, err error) {
 	tmpdir, err := os.MkdirTemp("", "squashfs-unpack")
@@ -0,0 +1,94 @@
+package eden_test
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"testing"
+
+	"github.com/lf-edge/eden/eserver/api"
+	"github.com/lf-edge/eden/pkg/eden"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEServerIPv6(t *testing.T) {
+	t.Parallel()
+
+	ln, err := net.Listen("tcp6", "[::1]:0")
+	if err != nil {
+		t.Skipf("IPv6 is not supported: %s", err)
+	}
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/admin/registry/gc", r.URL.Path)
+		_, _ = w.Write([]byte("removed 0 blobs"))
+	}))
+	srv.Listener = ln
+	srv.Start()
+	defer srv.Close()
+
+	server := &eden.EServer{
+		EServerIP:   "::1",
+		EServerPort: strconv.Itoa(ln.Addr().(*net.TCPAddr).Port),
+	}
+	result, err := server.EServerRegistryGC()
+	assert.NoError(t, err)
+	assert.Equal(t, "removed 0 blobs", result)
+}
+
+func TestEServerAddFileSkipsStored(t *testing.T) {
+	t.Parallel()
+
+	var mu sync.Mutex
+	stored := map[string]bool{}
+	uploads := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		switch {
+		case r.Method == http.MethodPost && r.URL.Path == "/admin/link/images/eve.img":
+			sha := r.URL.Query().Get("sha256")
+			if !stored[sha] {
+				http.NotFound(w, r)
+				return
+			}
+			_ = json.NewEncoder(w).Encode(&api.FileInfo{Sha256: sha, FileName: "eserver/images/eve.img", ISReady: true})
+		case r.Method == http.MethodPut && r.URL.Path == "/admin/upload/images/eve.img":
+			content, _ := io.ReadAll(r.Body)
+			sum := sha256.Sum256(content)
+			sha := hex.EncodeToString(sum[:])
+			stored[sha] = true
+			uploads++
+			_ = json.NewEncoder(w).Encode(&api.FileInfo{Sha256: sha, Size: int64(len(content)), FileName: "eserver/images/eve.img", ISReady: true})
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL)
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}))
+	defer srv.Close()
+
+	file := filepath.Join(t.TempDir(), "eve.img")
+	assert.NoError(t, os.WriteFile(file, []byte("content of image"), 0644))
+	sum := sha256.Sum256([]byte("content of image"))
+	server := &eden.EServer{
+		EServerIP:   "127.0.0.1",
+		EServerPort: strconv.Itoa(srv.Listener.Addr().(*net.TCPAddr).Port),
+	}
+
+	// the second upload of the same content is skipped
+	for i := 0; i < 2; i++ {
+		fileInfo := server.EServerAddFile(file, "images")
+		assert.True(t, fileInfo.ISReady)
+		assert.Equal(t, hex.EncodeToString(sum[:]), fileInfo.Sha256)
+	}
+	mu.Lock()
+	assert.Equal(t, 1, uploads)
+	mu.Unlock()
+}
@@ -0,0 +1,219 @@
+package eden
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/lf-edge/eden/pkg/defaults"
+	"github.com/lf-edge/eden/pkg/utils"
+	log "github.com/sirupsen/logrus"
+)
+
+// hypervDriver runs EVE in Hyper-V. VM is connected to the default switch of Hyper-V
+// providing NAT and DHCP, ports are forwarded to EVE with portproxy of netsh.
+type hypervDriver struct{}
+
+// Name returns name of hypervisor
+func (d *hypervDriver) Name() string {
+	return "Hyper-V"
+}
+
+// DiskFormat returns format of disks of Hyper-V
+func (d *hypervDriver) DiskFormat() string {
+	return "vhdx"
+}
+
+// runPowerShell runs command of PowerShell and returns its output
+func runPowerShell(command string) (string, error) {
+	log.Debugf("powershell: %s", command)
+	out, stderr, err := utils.RunCommandAndWait("powershell", "-NoProfile", "-NonInteractive", "-Command", command)
+	if err != nil {
+		return "", fmt.Errorf("powershell error for command %s: %s %w", command, stderr, err)
+	}
+	return strings.TrimSpace(out), nil
+}
+
+// hypervConsolePipe returns named pipe of serial console of VM
+func hypervConsolePipe(vmName string) string {
+	return fmt.Sprintf(`\\.\pipe\%s-console`, vmName)
+}
+
+// createEVEHyperV creates VM of generation 2 with disks and serial console of EVE
+func createEVEHyperV(vm VMConfig) error {
+	image, err := diskWithExt(vm.ImageFile, ".vhdx")
+	if err != nil {
+		return err
+	}
+	commands := []string{
+		fmt.Sprintf("New-VM -Name '%s' -Generation 2 -MemoryStartupBytes %dMB -VHDPath '%s' -SwitchName '%s'",
+			vm.Name, vm.MemoryMB, image, defaults.DefaultHyperVSwitch),
+		fmt.Sprintf("Set-VM -Name '%s' -ProcessorCount %d -CheckpointType Disabled -AutomaticStartAction Nothing", vm.Name, vm.CPUs),
+		fmt.Sprintf("Set-VMProcessor -VMName '%s' -ExposeVirtualizationExtensions $true", vm.Name),
+		fmt.Sprintf("Set-VMFirmware -VMName '%s' -EnableSecureBoot Off", vm.Name),
+		fmt.Sprintf("Add-VMNetworkAdapter -VMName '%s' -SwitchName '%s'", vm.Name, defaults.DefaultHyperVSwitch),
+		fmt.Sprintf("Get-VMNetworkAdapter -VMName '%s' | Set-VMNetworkAdapter -MacAddressSpoofing On", vm.Name),
+		fmt.Sprintf("Set-VMComPort -VMName '%s' -Number 1 -Path '%s'", vm.Name, hypervConsolePipe(vm.Name)),
+	}
+	disks := vm.Disks
+	if vm.USBImage != "" {
+		// Hyper-V cannot emulate USB stick, so image is attached as disk
+		usbImage, err := usbDisk(vm.USBImage, "vhdx")
+		if err != nil {
+			return err
+		}
+		disks = append(disks, usbImage)
+	}
+	for _, disk := range disks {
+		commands = append(commands, fmt.Sprintf("Add-VMHardDiskDrive -VMName '%s' -ControllerType SCSI -Path '%s'", vm.Name, disk))
+	}
+	for _, command := range commands {
+		if _, err := runPowerShell(command); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Start runs EVE in Hyper-V
+func (d *hypervDriver) Start(vm VMConfig) error {
+	status, err := d.Status(vm.Name)
+	if err != nil {
+		return err
+	}
+	if status == "process doesn't exist" {
+		if err := createEVEHyperV(vm); err != nil {
+			return err
+		}
+	}
+	if status != "running" {
+		if _, err := runPowerShell(fmt.Sprintf("Start-VM -Name '%s'", vm.Name)); err != nil {
+			return err
+		}
+	}
+	return configurePortFwdHyperV(vm.Name, vm.HostFwd)
+}
+
+// guestIPsHyperV returns IP addresses of interfaces of VM found in neighbor table of host
+func guestIPsHyperV(vmName string) (ipAddrs []string, err error) {
+	macs, err := runPowerShell(fmt.Sprintf("(Get-VMNetworkAdapter -VMName '%s').MacAddress", vmName))
+	if err != nil {
+		return nil, err
+	}
+	for i, mac := range strings.Fields(macs) {
+		if len(mac) != 12 {
+			return nil, fmt.Errorf("unexpected MAC address of eth%d: %s", i, mac)
+		}
+		// Get-NetNeighbor expects MAC in form 00-15-5D-01-02-03
+		var parts []string
+		for j := 0; j < len(mac); j += 2 {
+			parts = append(parts, mac[j:j+2])
+		}
+		ip, err := runPowerShell(fmt.Sprintf(
+			"Get-NetNeighbor -AddressFamily IPv4 -LinkLayerAddress '%s' -ErrorAction SilentlyContinue | Select-Object -First 1 -ExpandProperty IPAddress",
+			strings.Join(parts, "-")))
+		if err != nil {
+			return nil, err
+		}
+		if ip == "" {
+			return nil, fmt.Errorf("failed to get IP address of eth%d", i)
+		}
+		ipAddrs = append(ipAddrs, ip)
+	}
+	return ipAddrs, nil
+}
+
+// configurePortFwdHyperV forwards ports of host to EVE, ports of eth1 are shifted by defaults.DefaultPortMapOffset
+func configurePortFwdHyperV(vmName string, hostFwd map[string]string) (err error) {
+	if len(hostFwd) == 0 {
+		return nil
+	}
+	var ipAddrs []string
+	fmt.Print("Waiting for IP addresses of EVE...")
+	for start := time.Now(); time.Since(start) < 3*time.Minute; time.Sleep(defaults.DefaultRepeatTimeout) {
+		if ipAddrs, err = guestIPsHyperV(vmName); err == nil {
+			break
+		}
+	}
+	if err != nil {
+		fmt.Println(" [TIMEOUT]")
+		return err
+	}
+	fmt.Println(" [DONE]")
+	for i, ipAddr := range ipAddrs {
+		for k, v := range hostFwd {
+			hostPort, err := strconv.Atoi(k)
+			if err != nil {
+				log.Errorf("Parsing %s to Integer value failed", k)
+				continue
+			}
+			guestPort, err := strconv.Atoi(v)
+			if err != nil {
+				log.Errorf("Parsing %s to Integer value failed", v)
+				continue
+			}
+			hostPort += i * defaults.DefaultPortMapOffset
+			guestPort += i * defaults.DefaultPortMapOffset
+			// IP of EVE may change between starts, so replace rule left by previous one
+			_, _, _ = utils.RunCommandAndWait("netsh", "interface", "portproxy", "delete", "v4tov4",
+				fmt.Sprintf("listenport=%d", hostPort), "listenaddress=127.0.0.1")
+			commandArgsString := fmt.Sprintf("interface portproxy add v4tov4 listenport=%d listenaddress=127.0.0.1 connectport=%d connectaddress=%s",
+				hostPort, guestPort, ipAddr)
+			if err = utils.RunCommandWithLogAndWait("netsh", defaults.DefaultLogLevelToPrint, strings.Fields(commandArgsString)...); err != nil {
+				return fmt.Errorf("netsh error for command %s %s", commandArgsString, err)
+			}
+		}
+	}
+	return nil
+}
+
+// Stop turns off EVE in Hyper-V
+func (d *hypervDriver) Stop(vmName string) error {
+	_, err := runPowerShell(fmt.Sprintf("Stop-VM -Name '%s' -TurnOff -Force", vmName))
+	return err
+}
+
+// Delete removes EVE from Hyper-V together with forwarding of its ports
+func (d *hypervDriver) Delete(vmName string) error {
+	ipAddrs, _ := guestIPsHyperV(vmName)
+	if _, err := runPowerShell(fmt.Sprintf("Remove-VM -Name '%s' -Force", vmName)); err != nil {
+		return err
+	}
+	// portproxy has no names of rules, so remove ones pointing to EVE
+	out, _, err := utils.RunCommandAndWait("netsh", "interface", "portproxy", "show", "v4tov4")
+	if err != nil {
+		return nil
+	}
+	for _, line := range strings.Split(out, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) != 4 {
+			continue
+		}
+		for _, ipAddr := range ipAddrs {
+			if fields[2] == ipAddr {
+				_ = utils.RunCommandWithLogAndWait("netsh", defaults.DefaultLogLevelToPrint, "interface", "portproxy", "delete", "v4tov4",
+					"listenport="+fields[1], "listenaddress="+fields[0])
+			}
+		}
+	}
+	return nil
+}
+
+// Status returns state of EVE VM, like running or off
+func (d *hypervDriver) Status(vmName string) (string, error) {
+	status, err := runPowerShell(fmt.Sprintf("Get-VM -Name '%s' -ErrorAction SilentlyContinue | Select-Object -ExpandProperty State", vmName))
+	if err != nil {
+		return "", err
+	}
+	if status == "" {
+		return "process doesn't exist", nil
+	}
+	// lower case to be consistent with other hypervisors
+	return strings.ToLower(status), nil
+}
+
+// Console connects to the first COM port of VM exposed by Hyper-V as named pipe
+func (d *hypervDriver) Console(vm VMConfig) error {
+	return pipeConsole(hypervConsolePipe(vm.Name))
+}
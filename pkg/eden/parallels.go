@@ -10,6 +10,47 @@ import (
 	log "github.com/sirupsen/logrus"
 )
 
+// parallelsDriver runs EVE in Parallels Desktop
+type parallelsDriver struct{}
+
+// Name returns name of hypervisor
+func (d *parallelsDriver) Name() string {
+	return "Parallels"
+}
+
+// DiskFormat returns format of disks of Parallels
+func (d *parallelsDriver) DiskFormat() string {
+	return "parallels"
+}
+
+// Start runs EVE in Parallels
+func (d *parallelsDriver) Start(vm VMConfig) error {
+	if len(vm.Disks) > 0 || vm.USBImage != "" {
+		return fmt.Errorf("additional disks and USB image: %w", ErrNotSupportedByDriver)
+	}
+	return StartEVEParallels(vm.Name, vm.ImageFile, vm.CPUs, vm.MemoryMB, vm.HostFwd)
+}
+
+// Stop stops and removes EVE in Parallels
+func (d *parallelsDriver) Stop(vmName string) error {
+	return StopEVEParallels(vmName)
+}
+
+// Delete removes EVE from Parallels
+func (d *parallelsDriver) Delete(vmName string) error {
+	return DeleteEVEParallels(vmName)
+}
+
+// Status returns state of EVE VM
+func (d *parallelsDriver) Status(vmName string) (string, error) {
+	return StatusEVEParallels(vmName)
+}
+
+// Console is not supported for Parallels
+func (d *parallelsDriver) Console(_ VMConfig) error {
+	return ErrNotSupportedByDriver
+}
+
 //DeleteEVEParallels function removes EVE from parallels
 func DeleteEVEParallels(vmName string) (err error) {
 	commandArgsString := fmt.Sprintf("delete %s", vmName)
@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"net"
 	"os"
+	"os/exec"
 	"path/filepath"
 	"regexp"
 	"runtime"
@@ -15,6 +16,7 @@ import (
 
 	"github.com/lf-edge/eden/pkg/defaults"
 	"github.com/lf-edge/eden/pkg/edensdn"
+	"github.com/lf-edge/eden/pkg/models"
 	"github.com/lf-edge/eden/pkg/utils"
 	sdnapi "github.com/lf-edge/eden/sdn/vm/api"
 	log "github.com/sirupsen/logrus"
@@ -26,15 +28,42 @@ func StartSWTPM(stateDir string) error {
 		return err
 	}
 	command := "swtpm"
+	if _, err := exec.LookPath(command); err != nil {
+		return fmt.Errorf("StartSWTPM: %s not found, install it to run EVE with TPM: %w", command, err)
+	}
 	logFile := filepath.Join(stateDir, fmt.Sprintf("%s.log", command))
 	pidFile := filepath.Join(stateDir, fmt.Sprintf("%s.pid", command))
-	options := fmt.Sprintf("socket --tpmstate dir=%s --ctrl type=unixio,path=%s --log level=20 --tpm2", stateDir, filepath.Join(stateDir, defaults.DefaultSwtpmSockFile))
+	sockFile := filepath.Join(stateDir, defaults.DefaultSwtpmSockFile)
+	// socket left by previous run must not be taken as a sign of readiness
+	if err := os.Remove(sockFile); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("StartSWTPM: %w", err)
+	}
+	options := fmt.Sprintf("socket --tpmstate dir=%s --ctrl type=unixio,path=%s --log level=20 --tpm2", stateDir, sockFile)
 	if err := utils.RunCommandNohup(command, logFile, pidFile, strings.Fields(options)...); err != nil {
 		return fmt.Errorf("StartSWTPM: %s", err)
 	}
+	// QEMU fails to start if socket of swtpm is not ready
+	if err := waitForSocket(sockFile, swtpmStartTimeout); err != nil {
+		return fmt.Errorf("StartSWTPM: %w, see %s", err, logFile)
+	}
 	return nil
 }
 
+const swtpmStartTimeout = 10 * time.Second
+
+func waitForSocket(sockFile string, timeout time.Duration) error {
+	deadline := time.Now().Add(timeout)
+	for {
+		if info, err := os.Stat(sockFile); err == nil && info.Mode()&os.ModeSocket != 0 {
+			return nil
+		}
+		if time.Now().After(deadline) {
+			return fmt.Errorf("socket %s is not ready after %s", sockFile, timeout)
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+}
+
 // StopSWTPM stops swtpm process using pid from stateDir
 func StopSWTPM(stateDir string) error {
 	command := "swtpm"
@@ -74,8 +103,8 @@ func startQMPLogger(qmpSockFile string, qmpLogFile string) error {
 func StartEVEQemu(qemuARCH, qemuOS, eveImageFile, imageFormat string, isInstaller bool,
 	qemuSMBIOSSerial string, eveTelnetPort, qemuMonitorPort, netDevBasePort int,
 	qemuHostFwd map[string]string, qemuAccel bool, qemuConfigFile, logFile, pidFile string,
-	netModel sdnapi.NetworkModel, withSDN bool, tapInterface, usbImagePath string,
-	swtpm, foreground bool) (err error) {
+	netModel sdnapi.NetworkModel, withSDN bool, tapInterface, clusterNet, clusterMAC, usbImagePath, cellularSocket string,
+	pciPassthrough []string, swtpm, foreground bool) (err error) {
 	var qemuCommand, qemuOptions string
 	qemuOptions += "-nodefaults -no-user-config "
 	netDev := "virtio-net-pci"
@@ -111,6 +140,15 @@ func StartEVEQemu(qemuARCH, qemuOS, eveImageFile, imageFormat string, isInstalle
 			qemuOptions += defaults.DefaultQemuArm64
 		}
 		tpmDev = "tpm-tis-device"
+	case "riscv64":
+		qemuCommand = "qemu-system-riscv64"
+		if qemuAccel && qemuOS != "darwin" {
+			qemuOptions += defaults.DefaultQemuAccelRiscv64
+		} else {
+			// no hypervisor for riscv64 on darwin, emulate with hypervisor extension for EVE
+			qemuOptions += defaults.DefaultQemuRiscv64
+		}
+		tpmDev = "tpm-tis-device"
 	default:
 		return fmt.Errorf("StartEVEQemu: Arch not supported: %s", qemuARCH)
 	}
@@ -125,6 +163,11 @@ func StartEVEQemu(qemuARCH, qemuOS, eveImageFile, imageFormat string, isInstalle
 		// Ports connecting SDN VM with EVE VM.
 		socketPort := netDevBasePort
 		for i, port := range netModel.Ports {
+			if port.EVEConnect.Cellular {
+				// connected to the modem emulator instead
+				socketPort++
+				continue
+			}
 			qemuOptions += fmt.Sprintf("-netdev socket,id=eth%d,connect=:%d", i, socketPort)
 			qemuOptions += fmt.Sprintf(" -device %s,netdev=eth%d,mac=%s ", netDev, i,
 				port.EVEConnect.MAC)
@@ -137,6 +180,16 @@ func StartEVEQemu(qemuARCH, qemuOS, eveImageFile, imageFormat string, isInstalle
 			return fmt.Errorf("StartEVEQemu: %s", err)
 		}
 		network := nets[0].Subnet
+		// IP versions of the host are also provided by SLIRP to EVE
+		ipVersions := "ipv6=off"
+		if netModel.Host != nil {
+			switch netModel.Host.NetworkType {
+			case sdnapi.Ipv6Only:
+				ipVersions = "ipv4=off,ipv6=on,ipv6-net=" + defaults.DefaultQemuIPv6Subnet
+			case sdnapi.DualStack:
+				ipVersions = "ipv6=on,ipv6-net=" + defaults.DefaultQemuIPv6Subnet
+			}
+		}
 		var ip net.IP
 		for i, port := range netModel.Ports {
 			switch i {
@@ -148,8 +201,8 @@ func StartEVEQemu(qemuARCH, qemuOS, eveImageFile, imageFormat string, isInstalle
 				return fmt.Errorf("unexpected number of ports (in non-SDN mode): %d",
 					len(netModel.Ports))
 			}
-			qemuOptions += fmt.Sprintf("-netdev user,id=eth%d,net=%s,dhcpstart=%s,ipv6=off",
-				i, network, ip)
+			qemuOptions += fmt.Sprintf("-netdev user,id=eth%d,net=%s,dhcpstart=%s,%s",
+				i, network, ip, ipVersions)
 			for k, v := range qemuHostFwd {
 				origPort, err := strconv.Atoi(k)
 				if err != nil {
@@ -168,11 +221,25 @@ func StartEVEQemu(qemuARCH, qemuOS, eveImageFile, imageFormat string, isInstalle
 		}
 	}
 
+	if tapInterface != "" && clusterNet != "" {
+		return fmt.Errorf("StartEVEQemu: tap interface cannot be used with network of nodes")
+	}
 	if tapInterface != "" {
 		tapIdx := len(netModel.Ports)
 		qemuOptions += fmt.Sprintf("-netdev tap,id=eth%d,ifname=%s", tapIdx, tapInterface)
 		qemuOptions += fmt.Sprintf(" -device %s,netdev=eth%d ", netDev, tapIdx)
 	}
+	if clusterNet != "" {
+		// Nodes of multi-node EVE are connected with multicast socket into one L2 network
+		clusterIdx := len(netModel.Ports)
+		qemuOptions += fmt.Sprintf("-netdev socket,id=eth%d,mcast=%s", clusterIdx, clusterNet)
+		qemuOptions += fmt.Sprintf(" -device %s,netdev=eth%d,mac=%s ", netDev, clusterIdx, clusterMAC)
+	}
+
+	// PCI devices of host are placed on root bus at addresses expected by device model
+	for i, address := range pciPassthrough {
+		qemuOptions += fmt.Sprintf("-device vfio-pci,host=%s,bus=pcie.0,addr=0x%x ", address, models.QemuPCISlot(i))
+	}
 
 	if swtpm {
 		tpmSocket := filepath.Join(filepath.Dir(eveImageFile), "swtpm", defaults.DefaultSwtpmSockFile)
@@ -221,6 +288,12 @@ func StartEVEQemu(qemuARCH, qemuOS, eveImageFile, imageFormat string, isInstalle
 	if qemuConfigFile != "" {
 		qemuOptions += fmt.Sprintf("-readconfig %s ", qemuConfigFile)
 	}
+	if cellularSocket != "" {
+		// modem is plugged into USB controller defined in qemuConfigFile,
+		// QEMU keeps reconnecting to the emulator until it is ready
+		qemuOptions += fmt.Sprintf("-chardev socket,id=wwan,path=%s,reconnect=1 -device usb-redir,chardev=wwan ",
+			cellularSocket)
+	}
 
 	context, err := utils.ContextLoad()
 	if err != nil {
@@ -234,7 +307,9 @@ func StartEVEQemu(qemuARCH, qemuOS, eveImageFile, imageFormat string, isInstalle
 	qmpLogFile = filepath.Join(filepath.Dir(pidFile), qmpLogFile)
 
 	// QMP sock
-	qemuOptions += fmt.Sprintf("-qmp unix:%s,server,wait=off", qmpSockFile)
+	qemuOptions += fmt.Sprintf("-qmp unix:%s,server,wait=off ", qmpSockFile)
+	// the first QMP sock is held by logger, so eden controls VM with another one
+	qemuOptions += fmt.Sprintf("-qmp unix:%s,server,wait=off", QemuControlSocket(pidFile, context.Current))
 
 	log.Infof("Start EVE: %s %s", qemuCommand, qemuOptions)
 	if foreground {
@@ -347,3 +422,34 @@ func GetLinkStatesQemu(qemuMonitorPort int, ifNames []string) (linkStates []eden
 	}
 	return linkStates, nil
 }
+
+// QemuMonitorCommand runs command in QEMU monitor and returns its output
+func QemuMonitorCommand(qemuMonitorPort int, cmd string) (string, error) {
+	tcpAddr, _ := net.ResolveTCPAddr("tcp", fmt.Sprintf("localhost:%d", qemuMonitorPort))
+	conn, err := net.DialTCP("tcp", nil, tcpAddr)
+	if err != nil {
+		return "", err
+	}
+	defer conn.Close()
+	_, err = conn.Write([]byte(cmd + "\n"))
+	if err == nil {
+		err = conn.CloseWrite()
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to send '%s' command to qemu: %v", cmd, err)
+	}
+	var lines []string
+	scanner := bufio.NewScanner(conn)
+	for scanner.Scan() {
+		// skip banner and prompt of the QEMU monitor
+		line := scanner.Text()
+		if strings.HasPrefix(line, "QEMU") || strings.HasPrefix(line, "(qemu)") {
+			continue
+		}
+		lines = append(lines, line)
+	}
+	if scanner.Err() != nil {
+		return "", fmt.Errorf("failed to read response from QEMU monitor: %v", scanner.Err())
+	}
+	return strings.Join(lines, "\n"), nil
+}
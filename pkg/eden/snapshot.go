@@ -0,0 +1,237 @@
+package eden
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"net"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/lf-edge/eden/pkg/defaults"
+)
+
+// QemuControlSocket returns location of QMP socket eden uses to control QEMU with EVE,
+// it is placed next to pidFile of QEMU
+func QemuControlSocket(pidFile, context string) string {
+	return filepath.Join(filepath.Dir(pidFile), fmt.Sprintf("%s-qmp-ctl.sock", strings.ToLower(context)))
+}
+
+// qmpClient is connection to QMP socket of QEMU
+type qmpClient struct {
+	conn    net.Conn
+	scanner *bufio.Scanner
+}
+
+// qmpResponse is reply of QEMU to command, events are skipped
+type qmpResponse struct {
+	Return json.RawMessage `json:"return"`
+	Error  *struct {
+		Class string `json:"class"`
+		Desc  string `json:"desc"`
+	} `json:"error"`
+	Event string `json:"event"`
+}
+
+func qmpConnect(socket string) (*qmpClient, error) {
+	conn, err := net.DialTimeout("unix", socket, defaults.DefaultRepeatTimeout)
+	if err != nil {
+		return nil, fmt.Errorf("cannot connect to QMP socket %s: %w", socket, err)
+	}
+	scanner := bufio.NewScanner(conn)
+	// replies with list of block devices may be long
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	c := &qmpClient{conn: conn, scanner: scanner}
+	// QEMU greets with its version and waits for negotiation of capabilities
+	if !scanner.Scan() {
+		conn.Close()
+		return nil, fmt.Errorf("no greeting from QMP socket %s: %v", socket, scanner.Err())
+	}
+	if err := c.execute("qmp_capabilities", nil, nil); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return c, nil
+}
+
+func (c *qmpClient) close() {
+	c.conn.Close()
+}
+
+// execute runs command with arguments and decodes its return value into result if it is not nil
+func (c *qmpClient) execute(command string, arguments, result interface{}) error {
+	request := map[string]interface{}{"execute": command}
+	if arguments != nil {
+		request["arguments"] = arguments
+	}
+	data, err := json.Marshal(request)
+	if err != nil {
+		return err
+	}
+	if _, err := c.conn.Write(append(data, '\n')); err != nil {
+		return fmt.Errorf("cannot send %s to QMP: %w", command, err)
+	}
+	for c.scanner.Scan() {
+		var resp qmpResponse
+		if err := json.Unmarshal(c.scanner.Bytes(), &resp); err != nil {
+			return fmt.Errorf("cannot parse reply of QMP: %w", err)
+		}
+		if resp.Event != "" {
+			continue
+		}
+		if resp.Error != nil {
+			return fmt.Errorf("%s: %s", command, resp.Error.Desc)
+		}
+		if result == nil {
+			return nil
+		}
+		return json.Unmarshal(resp.Return, result)
+	}
+	return fmt.Errorf("no reply of QMP to %s: %v", command, c.scanner.Err())
+}
+
+// qmpBlock is block device from reply of query-block
+type qmpBlock struct {
+	Device   string `json:"device"`
+	Inserted *struct {
+		NodeName string `json:"node-name"`
+		Drv      string `json:"drv"`
+		RO       bool   `json:"ro"`
+		Image    struct {
+			Snapshots []QemuSnapshot `json:"snapshots"`
+		} `json:"image"`
+	} `json:"inserted"`
+}
+
+// QemuSnapshot is internal snapshot of disk of EVE
+type QemuSnapshot struct {
+	Name        string `json:"name"`
+	VMStateSize int64  `json:"vm-state-size"`
+	DateSec     int64  `json:"date-sec"`
+}
+
+// snapshotNodes returns block nodes which keep snapshot of VM: writable qcow2 disks.
+// Raw images (UEFI variables, USB image) cannot keep snapshots and are not included.
+// State of VM is saved into the first node.
+func (c *qmpClient) snapshotNodes() ([]string, []QemuSnapshot, error) {
+	var blocks []qmpBlock
+	if err := c.execute("query-block", nil, &blocks); err != nil {
+		return nil, nil, err
+	}
+	var nodes []string
+	var snapshots []QemuSnapshot
+	for _, block := range blocks {
+		if block.Inserted == nil || block.Inserted.RO || block.Inserted.Drv != "qcow2" {
+			continue
+		}
+		if len(nodes) == 0 {
+			snapshots = block.Inserted.Image.Snapshots
+		}
+		nodes = append(nodes, block.Inserted.NodeName)
+	}
+	if len(nodes) == 0 {
+		return nil, nil, fmt.Errorf("no qcow2 disks to keep snapshot of EVE found")
+	}
+	return nodes, snapshots, nil
+}
+
+// runJob runs snapshot job and waits for its end
+func (c *qmpClient) runJob(command, tag string, arguments map[string]interface{}) error {
+	jobID := fmt.Sprintf("eden-%s", command)
+	arguments["job-id"] = jobID
+	arguments["tag"] = tag
+	if err := c.execute(command, arguments, nil); err != nil {
+		return err
+	}
+	for {
+		var jobs []struct {
+			ID     string `json:"id"`
+			Status string `json:"status"`
+			Error  string `json:"error"`
+		}
+		if err := c.execute("query-jobs", nil, &jobs); err != nil {
+			return err
+		}
+		for _, job := range jobs {
+			if job.ID != jobID || job.Status != "concluded" {
+				continue
+			}
+			if err := c.execute("job-dismiss", map[string]interface{}{"id": jobID}, nil); err != nil {
+				return err
+			}
+			if job.Error != "" {
+				return fmt.Errorf("%s %s: %s", command, tag, job.Error)
+			}
+			return nil
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+}
+
+// QemuSnapshotSave saves memory and disks of running EVE into snapshot with name
+func QemuSnapshotSave(socket, name string) error {
+	c, err := qmpConnect(socket)
+	if err != nil {
+		return err
+	}
+	defer c.close()
+	nodes, snapshots, err := c.snapshotNodes()
+	if err != nil {
+		return err
+	}
+	for _, snapshot := range snapshots {
+		if snapshot.Name == name {
+			return fmt.Errorf("snapshot %s already exists", name)
+		}
+	}
+	return c.runJob("snapshot-save", name, map[string]interface{}{"vmstate": nodes[0], "devices": nodes})
+}
+
+// QemuSnapshotLoad restores memory and disks of running EVE from snapshot with name
+func QemuSnapshotLoad(socket, name string) error {
+	c, err := qmpConnect(socket)
+	if err != nil {
+		return err
+	}
+	defer c.close()
+	nodes, _, err := c.snapshotNodes()
+	if err != nil {
+		return err
+	}
+	return c.runJob("snapshot-load", name, map[string]interface{}{"vmstate": nodes[0], "devices": nodes})
+}
+
+// QemuSnapshotDelete removes snapshot with name from disks of EVE
+func QemuSnapshotDelete(socket, name string) error {
+	c, err := qmpConnect(socket)
+	if err != nil {
+		return err
+	}
+	defer c.close()
+	nodes, _, err := c.snapshotNodes()
+	if err != nil {
+		return err
+	}
+	return c.runJob("snapshot-delete", name, map[string]interface{}{"devices": nodes})
+}
+
+// QemuSnapshotList returns snapshots of running EVE with saved state of VM
+func QemuSnapshotList(socket string) ([]QemuSnapshot, error) {
+	c, err := qmpConnect(socket)
+	if err != nil {
+		return nil, err
+	}
+	defer c.close()
+	_, snapshots, err := c.snapshotNodes()
+	if err != nil {
+		return nil, err
+	}
+	var result []QemuSnapshot
+	for _, snapshot := range snapshots {
+		if snapshot.VMStateSize > 0 {
+			result = append(result, snapshot)
+		}
+	}
+	return result, nil
+}
@@ -0,0 +1,181 @@
+package eden_test
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"net"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/lf-edge/eden/pkg/eden"
+	"github.com/stretchr/testify/assert"
+)
+
+const qmpEvent = `{"timestamp": {"seconds": 1700000000, "microseconds": 1}, "event": "JOB_STATUS_CHANGE", "data": {"status": "running"}}`
+
+// qmpBlocks are block devices of EVE: writable qcow2 disks, UEFI variables, read-only image and empty drive
+const qmpBlocks = `[
+	{"device": "hd0", "inserted": {"node-name": "disk0", "drv": "qcow2", "ro": false, "image": {"snapshots": [
+		{"name": "base", "vm-state-size": 1024, "date-sec": 1700000000},
+		{"name": "disk-only", "vm-state-size": 0, "date-sec": 1700000100}]}}},
+	{"device": "pflash1", "inserted": {"node-name": "vars", "drv": "raw", "ro": false, "image": {}}},
+	{"device": "usb", "inserted": {"node-name": "installer", "drv": "qcow2", "ro": true, "image": {}}},
+	{"device": "cd0"},
+	{"device": "hd1", "inserted": {"node-name": "disk1", "drv": "qcow2", "ro": false, "image": {"snapshots": []}}}
+]`
+
+type qmpRequest struct {
+	Execute   string                 `json:"execute"`
+	Arguments map[string]interface{} `json:"arguments"`
+}
+
+// fakeQMP serves QMP socket of QEMU, it reports blocks on query-block,
+// fails commands with tag "locked" and concludes jobs with tag "broken" with error
+type fakeQMP struct {
+	socket   string
+	blocks   string
+	greeting bool
+
+	mu       sync.Mutex
+	requests []qmpRequest
+}
+
+func newFakeQMP(t *testing.T, blocks string, greeting bool) *fakeQMP {
+	q := &fakeQMP{socket: filepath.Join(t.TempDir(), "qmp.sock"), blocks: blocks, greeting: greeting}
+	l, err := net.Listen("unix", q.socket)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { l.Close() })
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			go q.serve(conn)
+		}
+	}()
+	return q
+}
+
+func (q *fakeQMP) serve(conn net.Conn) {
+	defer conn.Close()
+	if !q.greeting {
+		return
+	}
+	fmt.Fprintln(conn, `{"QMP": {"version": {"qemu": {"major": 8, "minor": 0, "micro": 0}}, "capabilities": []}}`)
+	var jobID, jobError string
+	jobPolls := 0
+	scanner := bufio.NewScanner(conn)
+	for scanner.Scan() {
+		var req qmpRequest
+		if err := json.Unmarshal(scanner.Bytes(), &req); err != nil {
+			return
+		}
+		q.mu.Lock()
+		q.requests = append(q.requests, req)
+		q.mu.Unlock()
+		switch req.Execute {
+		case "qmp_capabilities", "job-dismiss":
+			fmt.Fprintln(conn, `{"return": {}}`)
+		case "query-block":
+			fmt.Fprintln(conn, qmpEvent)
+			out, _ := json.Marshal(json.RawMessage(q.blocks))
+			fmt.Fprintf(conn, "{\"return\": %s}\n", out)
+		case "snapshot-save", "snapshot-load", "snapshot-delete":
+			if req.Arguments["tag"] == "locked" {
+				fmt.Fprintln(conn, `{"error": {"class": "GenericError", "desc": "Device 'disk0' is locked"}}`)
+				continue
+			}
+			jobID = req.Arguments["job-id"].(string)
+			if req.Arguments["tag"] == "broken" {
+				jobError = "Snapshot 'broken' does not exist"
+			}
+			fmt.Fprintln(conn, `{"return": {}}`)
+		case "query-jobs":
+			status := "running"
+			if jobPolls++; jobPolls > 1 {
+				status = "concluded"
+			}
+			fmt.Fprintln(conn, qmpEvent)
+			fmt.Fprintf(conn, "{\"return\": [{\"id\": \"other\", \"status\": \"concluded\"}, {\"id\": %q, \"status\": %q, \"error\": %q}]}\n",
+				jobID, status, jobError)
+		default:
+			fmt.Fprintf(conn, "{\"error\": {\"class\": \"CommandNotFound\", \"desc\": \"The command %s has not been found\"}}\n", req.Execute)
+		}
+	}
+}
+
+// commands returns requests received by server
+func (q *fakeQMP) commands() []qmpRequest {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return append([]qmpRequest{}, q.requests...)
+}
+
+func TestQemuSnapshotList(t *testing.T) {
+	t.Parallel()
+
+	q := newFakeQMP(t, qmpBlocks, true)
+	snapshots, err := eden.QemuSnapshotList(q.socket)
+	assert.NoError(t, err)
+	// only snapshots with state of VM are reported
+	assert.Equal(t, []eden.QemuSnapshot{{Name: "base", VMStateSize: 1024, DateSec: 1700000000}}, snapshots)
+	commands := q.commands()
+	if assert.Len(t, commands, 2) {
+		assert.Equal(t, "qmp_capabilities", commands[0].Execute)
+		assert.Equal(t, "query-block", commands[1].Execute)
+	}
+}
+
+func TestQemuSnapshotSave(t *testing.T) {
+	t.Parallel()
+
+	q := newFakeQMP(t, qmpBlocks, true)
+	assert.NoError(t, eden.QemuSnapshotSave(q.socket, "onboarded"))
+	var executed []string
+	for _, req := range q.commands() {
+		executed = append(executed, req.Execute)
+		if req.Execute == "snapshot-save" {
+			// state of VM goes into the first writable qcow2 disk, raw and read-only disks are skipped
+			assert.Equal(t, map[string]interface{}{
+				"job-id":  "eden-snapshot-save",
+				"tag":     "onboarded",
+				"vmstate": "disk0",
+				"devices": []interface{}{"disk0", "disk1"},
+			}, req.Arguments)
+		}
+		if req.Execute == "job-dismiss" {
+			assert.Equal(t, map[string]interface{}{"id": "eden-snapshot-save"}, req.Arguments)
+		}
+	}
+	assert.Equal(t, []string{"qmp_capabilities", "query-block", "snapshot-save", "query-jobs", "query-jobs", "job-dismiss"}, executed)
+
+	assert.ErrorContains(t, eden.QemuSnapshotSave(q.socket, "base"), "snapshot base already exists")
+}
+
+func TestQemuSnapshotErrors(t *testing.T) {
+	t.Parallel()
+
+	q := newFakeQMP(t, qmpBlocks, true)
+	// error reply to command
+	assert.EqualError(t, eden.QemuSnapshotDelete(q.socket, "locked"), "snapshot-delete: Device 'disk0' is locked")
+	// job concluded with error is dismissed and its error is reported
+	assert.EqualError(t, eden.QemuSnapshotLoad(q.socket, "broken"), "snapshot-load broken: Snapshot 'broken' does not exist")
+	commands := q.commands()
+	assert.Equal(t, "job-dismiss", commands[len(commands)-1].Execute)
+
+	noDisks := newFakeQMP(t, `[{"device": "pflash1", "inserted": {"node-name": "vars", "drv": "raw", "ro": false, "image": {}}}]`, true)
+	_, err := eden.QemuSnapshotList(noDisks.socket)
+	assert.ErrorContains(t, err, "no qcow2 disks")
+
+	noGreeting := newFakeQMP(t, qmpBlocks, false)
+	_, err = eden.QemuSnapshotList(noGreeting.socket)
+	assert.ErrorContains(t, err, "no greeting")
+
+	_, err = eden.QemuSnapshotList(filepath.Join(t.TempDir(), "missing.sock"))
+	assert.ErrorContains(t, err, "cannot connect to QMP socket")
+}
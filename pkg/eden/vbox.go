@@ -18,61 +18,126 @@ import (
 
 const natNetworkName = "natnet1"
 
-//StartEVEVBox function runs EVE in VirtualBox
-func StartEVEVBox(vmName, eveImageFile string, cpus int, mem int, hostFwd map[string]string) (err error) {
-	vmStatus, err := getEveVMStatusVbox(vmName)
-	if err != nil {
-		log.Info("No VMs with eve_live name", err)
-		commandArgsString := fmt.Sprintf("createvm --name %s --register", vmName)
-		if err = utils.RunCommandWithLogAndWait("VBoxManage", defaults.DefaultLogLevelToPrint, strings.Fields(commandArgsString)...); err != nil {
-			log.Fatalf("VBoxManage error for command %s %s", commandArgsString, err)
+// vboxDriver runs EVE in VirtualBox
+type vboxDriver struct{}
+
+// Name returns name of hypervisor
+func (d *vboxDriver) Name() string {
+	return "Virtual Box"
+}
+
+// DiskFormat returns format of disks of VirtualBox
+func (d *vboxDriver) DiskFormat() string {
+	return "vdi"
+}
+
+// Start runs EVE in VirtualBox
+func (d *vboxDriver) Start(vm VMConfig) error {
+	return StartEVEVBox(vm)
+}
+
+// Stop stops EVE in VirtualBox
+func (d *vboxDriver) Stop(vmName string) error {
+	return StopEVEVBox(vmName)
+}
+
+// Delete removes EVE from VirtualBox
+func (d *vboxDriver) Delete(vmName string) error {
+	return DeleteEVEVBox(vmName)
+}
+
+// Status returns state of EVE VM
+func (d *vboxDriver) Status(vmName string) (string, error) {
+	return StatusEVEVBox(vmName)
+}
+
+// Console connects to the first serial port of VM exposed by VirtualBox with TCP server
+func (d *vboxDriver) Console(vm VMConfig) error {
+	return telnetConsole(vm.TelnetPort)
+}
+
+// runVBoxManage runs VBoxManage with arguments
+func runVBoxManage(args ...string) error {
+	if err := utils.RunCommandWithLogAndWait("VBoxManage", defaults.DefaultLogLevelToPrint, args...); err != nil {
+		return fmt.Errorf("VBoxManage error for command %s %s", strings.Join(args, " "), err)
+	}
+	return nil
+}
+
+// createEVEVBox creates VM with disks and serial console of EVE
+func createEVEVBox(vm VMConfig) error {
+	commandArgsString := fmt.Sprintf("createvm --name %s --register", vm.Name)
+	if err := runVBoxManage(strings.Fields(commandArgsString)...); err != nil {
+		return err
+	}
+	commandArgsString = fmt.Sprintf("modifyvm %s --cpus %d --memory %d --vram 16 --nested-hw-virt on --ostype Ubuntu_64  --mouse usbtablet --graphicscontroller vmsvga --boot1 disk --boot2 net", vm.Name, vm.CPUs, vm.MemoryMB)
+	if err := runVBoxManage(strings.Fields(commandArgsString)...); err != nil {
+		return err
+	}
+	if vm.TelnetPort != 0 {
+		commandArgsString = fmt.Sprintf("modifyvm %s --uart1 0x3F8 4 --uartmode1 tcpserver %d", vm.Name, vm.TelnetPort)
+		if err := runVBoxManage(strings.Fields(commandArgsString)...); err != nil {
+			return err
 		}
-		commandArgsString = fmt.Sprintf("modifyvm %s --cpus %d --memory %d --vram 16 --nested-hw-virt on --ostype Ubuntu_64  --mouse usbtablet --graphicscontroller vmsvga --boot1 disk --boot2 net", vmName, cpus, mem)
-		if err = utils.RunCommandWithLogAndWait("VBoxManage", defaults.DefaultLogLevelToPrint, strings.Fields(commandArgsString)...); err != nil {
-			log.Fatalf("VBoxManage error for command %s %s", commandArgsString, err)
+	}
+	commandArgsString = fmt.Sprintf("storagectl %s --name \"SATA\" --add sata --bootable on --hostiocache on", vm.Name)
+	if err := runVBoxManage(strings.Fields(commandArgsString)...); err != nil {
+		return err
+	}
+	for i, disk := range append([]string{vm.ImageFile}, vm.Disks...) {
+		commandArgsString = fmt.Sprintf("storageattach %s  --storagectl \"SATA\" --port %d --device 0 --type hdd --medium %s", vm.Name, i, disk)
+		if err := runVBoxManage(strings.Fields(commandArgsString)...); err != nil {
+			return err
 		}
-		commandArgsString = fmt.Sprintf("storagectl %s --name \"SATA\" --add sata --bootable on --hostiocache on", vmName)
-		if err = utils.RunCommandWithLogAndWait("VBoxManage", defaults.DefaultLogLevelToPrint, strings.Fields(commandArgsString)...); err != nil {
-			log.Fatalf("VBoxManage error for command %s %s", commandArgsString, err)
+	}
+	if vm.USBImage != "" {
+		usbImage, err := usbDisk(vm.USBImage, "vdi")
+		if err != nil {
+			return err
 		}
-		commandArgsString = fmt.Sprintf("storageattach %s  --storagectl \"SATA\" --port 0 --device 0 --type hdd --medium %s", vmName, eveImageFile)
-		if err = utils.RunCommandWithLogAndWait("VBoxManage", defaults.DefaultLogLevelToPrint, strings.Fields(commandArgsString)...); err != nil {
-			log.Fatalf("VBoxManage error for command %s %s", commandArgsString, err)
+		commandArgsString = fmt.Sprintf("storagectl %s --name \"USB\" --add usb", vm.Name)
+		if err := runVBoxManage(strings.Fields(commandArgsString)...); err != nil {
+			return err
 		}
-		if err := createNATNetworkVBox(vmName); err != nil {
-			log.Fatal(err)
+		commandArgsString = fmt.Sprintf("storageattach %s  --storagectl \"USB\" --port 0 --device 0 --type hdd --medium %s", vm.Name, usbImage)
+		if err := runVBoxManage(strings.Fields(commandArgsString)...); err != nil {
+			return err
 		}
-		commandArgsString = fmt.Sprintf("startvm  %s", vmName)
-		if err = utils.RunCommandWithLogAndWait("VBoxManage", defaults.DefaultLogLevelToPrint, strings.Fields(commandArgsString)...); err != nil {
-			log.Fatalf("VBoxManage error for command %s %s", commandArgsString, err)
+	}
+	return createNATNetworkVBox(vm.Name)
+}
+
+//StartEVEVBox function runs EVE in VirtualBox
+func StartEVEVBox(vm VMConfig) (err error) {
+	vmStatus, err := getEveVMStatusVbox(vm.Name)
+	if err != nil {
+		log.Info("No VMs with eve_live name", err)
+		if err := createEVEVBox(vm); err != nil {
+			return err
 		}
-		if err := configurePortFwdVBox(vmName, hostFwd); err != nil {
-			log.Fatal(err)
+		if err := runVBoxManage("startvm", vm.Name); err != nil {
+			return err
 		}
-		return nil
+		return configurePortFwdVBox(vm.Name, vm.HostFwd)
 	}
 
 	log.Info("EVE VM already exists")
 	netEnabled, err := isNATNetworkEnabledVBox()
 	if err != nil {
-		log.Fatal(err)
+		return err
 	}
 	if !netEnabled {
 		log.Info("NAT Network is not created/enabled")
-		if err := createNATNetworkVBox(vmName); err != nil {
-			log.Fatal(err)
+		if err := createNATNetworkVBox(vm.Name); err != nil {
+			return err
 		}
 	}
 	if vmStatus != "running" {
-		commandArgsString := fmt.Sprintf("startvm  %s", vmName)
-		if err = utils.RunCommandWithLogAndWait("VBoxManage", defaults.DefaultLogLevelToPrint, strings.Fields(commandArgsString)...); err != nil {
-			log.Fatalf("VBoxManage error for command %s %s", commandArgsString, err)
+		if err := runVBoxManage("startvm", vm.Name); err != nil {
+			return err
 		}
 	}
-	if err := configurePortFwdVBox(vmName, hostFwd); err != nil {
-		log.Fatal(err)
-	}
-	return err
+	return configurePortFwdVBox(vm.Name, vm.HostFwd)
 }
 
 // getEveVMStatusVbox retrieves the status of EVE VM or non-nil error if VM is not created.
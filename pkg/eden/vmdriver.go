@@ -0,0 +1,113 @@
+package eden
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/lf-edge/eden/pkg/defaults"
+	"github.com/lf-edge/eden/pkg/utils"
+)
+
+// ErrNotSupportedByDriver returned for operations hypervisor does not provide
+var ErrNotSupportedByDriver = errors.New("operation is not supported by hypervisor")
+
+// VMConfig describes EVE VM to run in hypervisor
+type VMConfig struct {
+	Name      string
+	ImageFile string
+	CPUs      int
+	MemoryMB  int
+	// HostFwd maps ports of host to ports of the first interface of EVE,
+	// ports of the second one are shifted by defaults.DefaultPortMapOffset
+	HostFwd map[string]string
+	// TelnetPort is port of host to access serial console of EVE
+	TelnetPort int
+	// Disks are additional disks of EVE in format of driver
+	Disks []string
+	// USBImage is raw image to attach as USB stick, like one with network config
+	USBImage string
+}
+
+// VMDriver manages lifecycle of EVE VM in hypervisor of host.
+// QEMU is not one of drivers as eden runs it together with SDN and vTPM.
+type VMDriver interface {
+	// Name returns name of hypervisor to show to user
+	Name() string
+	// DiskFormat returns format of disks the hypervisor uses
+	DiskFormat() string
+	// Start creates VM if it does not exist and starts it
+	Start(vm VMConfig) error
+	// Stop stops VM
+	Stop(vmName string) error
+	// Delete removes VM
+	Delete(vmName string) error
+	// Status returns state of VM
+	Status(vmName string) (string, error)
+	// Console connects stdin and stdout to serial console of VM
+	Console(vm VMConfig) error
+}
+
+// GetVMDriver returns driver of hypervisor for devModel, it returns false
+// for devModels running in QEMU or on hardware
+func GetVMDriver(devModel string) (VMDriver, bool) {
+	switch devModel {
+	case defaults.DefaultVBoxModel:
+		return &vboxDriver{}, true
+	case defaults.DefaultParallelsModel:
+		return &parallelsDriver{}, true
+	case defaults.DefaultVMwareModel:
+		return &vmwareDriver{}, true
+	case defaults.DefaultHyperVModel:
+		return &hypervDriver{}, true
+	default:
+		return nil, false
+	}
+}
+
+// diskWithExt returns path to disk with extension required by hypervisor,
+// disk is linked there if its extension is different
+func diskWithExt(disk, ext string) (string, error) {
+	if filepath.Ext(disk) == ext {
+		return disk, nil
+	}
+	linked := strings.TrimSuffix(disk, filepath.Ext(disk)) + ext
+	if _, err := os.Stat(linked); err == nil {
+		return linked, nil
+	}
+	if err := os.Link(disk, linked); err != nil {
+		return "", fmt.Errorf("cannot link %s to %s: %w", disk, linked, err)
+	}
+	return linked, nil
+}
+
+// usbDisk converts raw image of USB stick into format of hypervisor
+func usbDisk(usbImage, format string) (string, error) {
+	disk := strings.TrimSuffix(usbImage, filepath.Ext(usbImage)) + "." + format
+	if err := utils.ConvertDisk(usbImage, "raw", disk, format); err != nil {
+		return "", fmt.Errorf("cannot convert USB image: %w", err)
+	}
+	return disk, nil
+}
+
+// telnetConsole connects to serial console of VM exposed on port of host
+func telnetConsole(port int) error {
+	return utils.RunCommandForeground("telnet", "127.0.0.1", fmt.Sprint(port))
+}
+
+// pipeConsole connects stdin and stdout to serial console of VM exposed as named pipe or socket
+func pipeConsole(path string) error {
+	pipe, err := os.OpenFile(path, os.O_RDWR, 0)
+	if err != nil {
+		return fmt.Errorf("cannot open console of VM: %w", err)
+	}
+	defer pipe.Close()
+	go func() {
+		_, _ = io.Copy(pipe, os.Stdin)
+	}()
+	_, err = io.Copy(os.Stdout, pipe)
+	return err
+}
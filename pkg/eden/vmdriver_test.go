@@ -0,0 +1,100 @@
+package eden_test
+
+import (
+	"fmt"
+	"regexp"
+	"testing"
+
+	"github.com/lf-edge/eden/pkg/defaults"
+	"github.com/lf-edge/eden/pkg/eden"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetVMDriver(t *testing.T) {
+	t.Parallel()
+
+	testMatrix := map[string]struct {
+		name       string
+		diskFormat string
+	}{
+		defaults.DefaultVBoxModel:      {name: "Virtual Box", diskFormat: "vdi"},
+		defaults.DefaultParallelsModel: {name: "Parallels", diskFormat: "parallels"},
+		defaults.DefaultVMwareModel:    {name: "VMware", diskFormat: "vmdk"},
+		defaults.DefaultHyperVModel:    {name: "Hyper-V", diskFormat: "vhdx"},
+		defaults.DefaultQemuModel:      {},
+		defaults.DefaultRPIModel:       {},
+		defaults.DefaultGeneralModel:   {},
+		"":                             {},
+	}
+
+	for devModel, test := range testMatrix {
+		driver, ok := eden.GetVMDriver(devModel)
+		if test.name == "" {
+			assert.False(t, ok, devModel)
+			assert.Nil(t, driver, devModel)
+			continue
+		}
+		if assert.True(t, ok, devModel) {
+			assert.Equal(t, test.name, driver.Name(), devModel)
+			assert.Equal(t, test.diskFormat, driver.DiskFormat(), devModel)
+		}
+	}
+}
+
+func TestVMwareMACs(t *testing.T) {
+	t.Parallel()
+
+	// static addresses must be inside of range VMware allows
+	staticMAC := regexp.MustCompile(`^00:50:56:[0-3][0-9a-f]:[0-9a-f]{2}:[0-9a-f]{2}$`)
+	for _, vmName := range []string{"eve", "eve-1", "eve-2", "another-eve"} {
+		macs := eden.VMwareMACs(vmName)
+		assert.Equal(t, macs, eden.VMwareMACs(vmName), vmName)
+		assert.NotEqual(t, macs[0], macs[1], vmName)
+		for _, mac := range macs {
+			assert.Regexp(t, staticMAC, mac, vmName)
+		}
+	}
+	assert.NotEqual(t, eden.VMwareMACs("eve-1"), eden.VMwareMACs("eve-2"))
+}
+
+func TestVMwareConfig(t *testing.T) {
+	t.Parallel()
+
+	vm := eden.VMConfig{Name: "eve", CPUs: 4, MemoryMB: 4096, TelnetPort: 7777}
+	macs := eden.VMwareMACs(vm.Name)
+	expected := `.encoding = "UTF-8"
+config.version = "8"
+virtualHW.version = "16"
+displayName = "eve"
+guestOS = "other5xlinux-64"
+firmware = "efi"
+numvcpus = "4"
+memsize = "4096"
+vhv.enable = "TRUE"
+sata0.present = "TRUE"
+sata0:0.present = "TRUE"
+sata0:0.fileName = "live.vmdk"
+sata0:1.present = "TRUE"
+sata0:1.fileName = "disk-1.vmdk"
+ethernet0.present = "TRUE"
+ethernet0.connectionType = "nat"
+ethernet0.virtualDev = "e1000"
+ethernet0.addressType = "static"
+ethernet0.address = "%s"
+ethernet1.present = "TRUE"
+ethernet1.connectionType = "nat"
+ethernet1.virtualDev = "e1000"
+ethernet1.addressType = "static"
+ethernet1.address = "%s"
+serial0.present = "TRUE"
+serial0.fileType = "network"
+serial0.fileName = "telnet://127.0.0.1:7777"
+serial0.network.endPoint = "server"
+`
+	assert.Equal(t, fmt.Sprintf(expected, macs[0], macs[1]),
+		eden.VMwareConfig(vm, []string{"live.vmdk", "disk-1.vmdk"}))
+
+	// serial console is not configured without port
+	vm.TelnetPort = 0
+	assert.NotContains(t, eden.VMwareConfig(vm, []string{"live.vmdk"}), "serial0")
+}
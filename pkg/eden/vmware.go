@@ -0,0 +1,325 @@
+package eden
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/lf-edge/eden/pkg/defaults"
+	"github.com/lf-edge/eden/pkg/utils"
+	log "github.com/sirupsen/logrus"
+)
+
+// vmwareDriver runs EVE in VMware Workstation or Fusion with vmrun. VM is connected
+// to NAT network vmnet8, ports are forwarded to EVE by NAT service of VMware.
+type vmwareDriver struct{}
+
+// vmwareNetworkFiles are locations of configuration of NAT and leases of DHCP of vmnet8
+type vmwareNetworkFiles struct {
+	natConf string
+	leases  string
+	// restart restarts network services of VMware to apply natConf
+	restart [][]string
+}
+
+func getVMwareNetworkFiles() vmwareNetworkFiles {
+	switch runtime.GOOS {
+	case "darwin":
+		vmnetCli := "/Applications/VMware Fusion.app/Contents/Library/vmnet-cli"
+		return vmwareNetworkFiles{
+			natConf: "/Library/Preferences/VMware Fusion/vmnet8/nat.conf",
+			leases:  "/var/db/vmware/vmnet-dhcpd-vmnet8.leases",
+			restart: [][]string{{vmnetCli, "--stop"}, {vmnetCli, "--start"}},
+		}
+	case "windows":
+		return vmwareNetworkFiles{
+			natConf: `C:\ProgramData\VMware\vmnetnat.conf`,
+			leases:  `C:\ProgramData\VMware\vmnetdhcp.leases`,
+			restart: [][]string{{"net", "stop", "VMware NAT Service"}, {"net", "start", "VMware NAT Service"}},
+		}
+	default:
+		return vmwareNetworkFiles{
+			natConf: "/etc/vmware/vmnet8/nat/nat.conf",
+			leases:  "/etc/vmware/vmnet8/dhcpd/dhcpd.leases",
+			restart: [][]string{{"vmware-networks", "--stop"}, {"vmware-networks", "--start"}},
+		}
+	}
+}
+
+// Name returns name of hypervisor
+func (d *vmwareDriver) Name() string {
+	return "VMware"
+}
+
+// DiskFormat returns format of disks of VMware
+func (d *vmwareDriver) DiskFormat() string {
+	return "vmdk"
+}
+
+// runVmrun runs vmrun with arguments for VMware Fusion on macOS and Workstation otherwise
+func runVmrun(args ...string) (string, error) {
+	hostType := "ws"
+	if runtime.GOOS == "darwin" {
+		hostType = "fusion"
+	}
+	out, stderr, err := utils.RunCommandAndWait("vmrun", append([]string{"-T", hostType}, args...)...)
+	if err != nil {
+		return "", fmt.Errorf("vmrun error for command %s: %s%s %w", strings.Join(args, " "), out, stderr, err)
+	}
+	return out, nil
+}
+
+// vmxPath returns location of configuration of VM inside eden home
+func vmxPath(vmName string) (string, error) {
+	edenDir, err := utils.DefaultEdenDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(edenDir, "vmware", vmName, vmName+".vmx"), nil
+}
+
+// VMwareMACs returns static MAC addresses of interfaces of VM, they are derived from its
+// name to find leases of DHCP, VMware allows static addresses from 00:50:56:00:00:00 to 00:50:56:3F:FF:FF
+func VMwareMACs(vmName string) [2]string {
+	sum := sha256.Sum256([]byte(vmName))
+	var macs [2]string
+	for i := range macs {
+		macs[i] = fmt.Sprintf("00:50:56:%02x:%02x:%02x", sum[0]&0x3f, sum[1], (sum[2]&0xfe)+byte(i))
+	}
+	return macs
+}
+
+// VMwareConfig returns content of .vmx file of VM with disks and serial console of EVE
+func VMwareConfig(vm VMConfig, disks []string) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, ".encoding = \"UTF-8\"\nconfig.version = \"8\"\nvirtualHW.version = \"16\"\n")
+	fmt.Fprintf(&b, "displayName = \"%s\"\nguestOS = \"other5xlinux-64\"\nfirmware = \"efi\"\n", vm.Name)
+	fmt.Fprintf(&b, "numvcpus = \"%d\"\nmemsize = \"%d\"\nvhv.enable = \"TRUE\"\n", vm.CPUs, vm.MemoryMB)
+	fmt.Fprintf(&b, "sata0.present = \"TRUE\"\n")
+	for i, disk := range disks {
+		fmt.Fprintf(&b, "sata0:%d.present = \"TRUE\"\nsata0:%d.fileName = \"%s\"\n", i, i, disk)
+	}
+	for i, mac := range VMwareMACs(vm.Name) {
+		fmt.Fprintf(&b, "ethernet%d.present = \"TRUE\"\nethernet%d.connectionType = \"nat\"\n", i, i)
+		fmt.Fprintf(&b, "ethernet%d.virtualDev = \"e1000\"\nethernet%d.addressType = \"static\"\nethernet%d.address = \"%s\"\n", i, i, i, mac)
+	}
+	if vm.TelnetPort != 0 {
+		fmt.Fprintf(&b, "serial0.present = \"TRUE\"\nserial0.fileType = \"network\"\n")
+		fmt.Fprintf(&b, "serial0.fileName = \"telnet://127.0.0.1:%d\"\nserial0.network.endPoint = \"server\"\n", vm.TelnetPort)
+	}
+	return b.String()
+}
+
+// createEVEVMware writes configuration of VM with disks and serial console of EVE
+func createEVEVMware(vmx string, vm VMConfig) error {
+	image, err := diskWithExt(vm.ImageFile, ".vmdk")
+	if err != nil {
+		return err
+	}
+	disks := append([]string{image}, vm.Disks...)
+	if vm.USBImage != "" {
+		// VMware cannot emulate USB stick from image, so it is attached as disk
+		usbImage, err := usbDisk(vm.USBImage, "vmdk")
+		if err != nil {
+			return err
+		}
+		disks = append(disks, usbImage)
+	}
+	if err := os.MkdirAll(filepath.Dir(vmx), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(vmx, []byte(VMwareConfig(vm, disks)), 0644)
+}
+
+// Start runs EVE in VMware
+func (d *vmwareDriver) Start(vm VMConfig) error {
+	vmx, err := vmxPath(vm.Name)
+	if err != nil {
+		return err
+	}
+	if _, err := os.Stat(vmx); os.IsNotExist(err) {
+		if err := createEVEVMware(vmx, vm); err != nil {
+			return err
+		}
+	}
+	status, err := d.Status(vm.Name)
+	if err != nil {
+		return err
+	}
+	if status != "running" {
+		if _, err := runVmrun("start", vmx, "nogui"); err != nil {
+			return err
+		}
+	}
+	return configurePortFwdVMware(vm.Name, vm.HostFwd)
+}
+
+// guestIPsVMware returns IP addresses leased by DHCP of VMware to interfaces of VM
+func guestIPsVMware(vmName string) (ipAddrs [2]string, err error) {
+	leases, err := os.ReadFile(getVMwareNetworkFiles().leases)
+	if err != nil {
+		return ipAddrs, err
+	}
+	macs := VMwareMACs(vmName)
+	var leaseIP string
+	scanner := bufio.NewScanner(bytes.NewReader(leases))
+	for scanner.Scan() {
+		fields := strings.Fields(strings.TrimSuffix(strings.TrimSpace(scanner.Text()), ";"))
+		switch {
+		case len(fields) >= 2 && fields[0] == "lease":
+			leaseIP = fields[1]
+		case len(fields) == 3 && fields[0] == "hardware" && fields[1] == "ethernet":
+			for i, mac := range macs {
+				// the latest lease wins
+				if strings.EqualFold(fields[2], mac) {
+					ipAddrs[i] = leaseIP
+				}
+			}
+		}
+	}
+	for i, ipAddr := range ipAddrs {
+		if ipAddr == "" {
+			return ipAddrs, fmt.Errorf("failed to get IP address of eth%d", i)
+		}
+	}
+	return ipAddrs, nil
+}
+
+// natConfMarker marks rules of nat.conf added for VM
+func natConfMarker(vmName string) string {
+	return fmt.Sprintf("# eden %s", vmName)
+}
+
+// updateNATConfVMware replaces rules of VM in section incomingtcp of nat.conf with rules and restarts NAT
+func updateNATConfVMware(vmName string, rules []string) error {
+	files := getVMwareNetworkFiles()
+	data, err := os.ReadFile(files.natConf)
+	if err != nil {
+		return err
+	}
+	marker := natConfMarker(vmName)
+	var lines []string
+	skip := false
+	for _, line := range strings.Split(strings.TrimRight(string(data), "\n"), "\n") {
+		if skip {
+			// rule after marker
+			skip = false
+			continue
+		}
+		if strings.TrimSpace(line) == marker {
+			skip = true
+			continue
+		}
+		lines = append(lines, line)
+		if strings.TrimSpace(line) == "[incomingtcp]" {
+			for _, rule := range rules {
+				lines = append(lines, marker, rule)
+			}
+		}
+	}
+	if err := os.WriteFile(files.natConf, []byte(strings.Join(lines, "\n")+"\n"), 0644); err != nil {
+		return fmt.Errorf("cannot update %s (it usually requires administrator): %w", files.natConf, err)
+	}
+	for _, command := range files.restart {
+		if err := utils.RunCommandWithLogAndWait(command[0], defaults.DefaultLogLevelToPrint, command[1:]...); err != nil {
+			return fmt.Errorf("cannot restart NAT of VMware: %w", err)
+		}
+	}
+	return nil
+}
+
+// configurePortFwdVMware forwards ports of host to EVE, ports of eth1 are shifted by defaults.DefaultPortMapOffset
+func configurePortFwdVMware(vmName string, hostFwd map[string]string) (err error) {
+	if len(hostFwd) == 0 {
+		return nil
+	}
+	var ipAddrs [2]string
+	fmt.Print("Waiting for DHCP leases...")
+	for start := time.Now(); time.Since(start) < 3*time.Minute; time.Sleep(defaults.DefaultRepeatTimeout) {
+		if ipAddrs, err = guestIPsVMware(vmName); err == nil {
+			break
+		}
+	}
+	if err != nil {
+		fmt.Println(" [TIMEOUT]")
+		return err
+	}
+	fmt.Println(" [DONE]")
+	var rules []string
+	for i, ipAddr := range ipAddrs {
+		for k, v := range hostFwd {
+			hostPort, err := strconv.Atoi(k)
+			if err != nil {
+				log.Errorf("Parsing %s to Integer value failed", k)
+				continue
+			}
+			guestPort, err := strconv.Atoi(v)
+			if err != nil {
+				log.Errorf("Parsing %s to Integer value failed", v)
+				continue
+			}
+			rules = append(rules, fmt.Sprintf("%d = %s:%d",
+				hostPort+i*defaults.DefaultPortMapOffset, ipAddr, guestPort+i*defaults.DefaultPortMapOffset))
+		}
+	}
+	return updateNATConfVMware(vmName, rules)
+}
+
+// Stop powers off EVE in VMware
+func (d *vmwareDriver) Stop(vmName string) error {
+	vmx, err := vmxPath(vmName)
+	if err != nil {
+		return err
+	}
+	_, err = runVmrun("stop", vmx, "hard")
+	return err
+}
+
+// Delete removes EVE from VMware together with forwarding of its ports
+func (d *vmwareDriver) Delete(vmName string) error {
+	vmx, err := vmxPath(vmName)
+	if err != nil {
+		return err
+	}
+	if err := updateNATConfVMware(vmName, nil); err != nil {
+		log.Errorf("cannot remove forwarding of ports: %s", err)
+	}
+	if _, err := runVmrun("deleteVM", vmx); err != nil {
+		return err
+	}
+	return os.RemoveAll(filepath.Dir(vmx))
+}
+
+// Status returns state of EVE VM
+func (d *vmwareDriver) Status(vmName string) (string, error) {
+	vmx, err := vmxPath(vmName)
+	if err != nil {
+		return "", err
+	}
+	if _, err := os.Stat(vmx); os.IsNotExist(err) {
+		return "process doesn't exist", nil
+	}
+	out, err := runVmrun("list")
+	if err != nil {
+		return "", err
+	}
+	// list contains paths of running VMs
+	for _, line := range strings.Split(out, "\n") {
+		if filepath.Clean(strings.TrimSpace(line)) == filepath.Clean(vmx) {
+			return "running", nil
+		}
+	}
+	return "stopped", nil
+}
+
+// Console connects to the serial port of VM exposed by VMware with telnet server
+func (d *vmwareDriver) Console(vm VMConfig) error {
+	return telnetConsole(vm.TelnetPort)
+}
@@ -0,0 +1,81 @@
+package edensdn
+
+import (
+	"fmt"
+	"net"
+
+	"github.com/lf-edge/eden/pkg/defaults"
+	sdnapi "github.com/lf-edge/eden/sdn/vm/api"
+)
+
+// cellularTC imitates latency and throughput of LTE connection
+var cellularTC = sdnapi.TrafficControl{
+	Delay:       50,
+	DelayJitter: 20,
+	RateLimit:   20 * 1024 / 8, // 20 Mbit/s in KB/s
+	QueueLimit:  256,
+	BurstLimit:  64,
+}
+
+// AddCellularNetwork adds port for emulated cellular modem into the model, with its own
+// network providing DHCP for the modem and using DNS servers of the first network
+// of the model. Nothing is done if the port already exists.
+func AddCellularNetwork(model *sdnapi.NetworkModel) error {
+	label := defaults.DefaultCellularLabel
+	for _, port := range model.Ports {
+		if port.EVEConnect.Cellular {
+			return nil
+		}
+		if port.LogicalLabel == label {
+			return fmt.Errorf("port %s of the network model is not cellular", label)
+		}
+	}
+	network := sdnapi.Network{
+		LogicalLabel: "cellular0",
+		Bridge:       "bridge-" + label,
+		Subnet:       "172.22.20.0/24",
+		GwIP:         "172.22.20.1",
+		DHCP: sdnapi.DHCP{
+			Enable: true,
+			IPRange: sdnapi.IPRange{
+				FromIP: "172.22.20.10",
+				ToIP:   "172.22.20.20",
+			},
+			DomainName: "sdn",
+		},
+	}
+	for _, other := range model.Networks {
+		ip := net.ParseIP(other.GwIP)
+		if ip == nil || ip.To4() == nil {
+			continue
+		}
+		network.DHCP.DNSClientConfig = other.DHCP.DNSClientConfig
+		break
+	}
+	port := sdnapi.Port{
+		LogicalLabel: label,
+		AdminUP:      true,
+		EVEConnect: sdnapi.EVEConnect{
+			Cellular: true,
+		},
+		TC: cellularTC,
+	}
+	model.Ports = append(model.Ports, port)
+	model.Bridges = append(model.Bridges, sdnapi.Bridge{
+		LogicalLabel: network.Bridge,
+		Ports:        []string{label},
+	})
+	model.Networks = append(model.Networks, network)
+	addMissingMACs(model)
+	return nil
+}
+
+// CellularPortIndex returns index of the port connected to emulated cellular modem or -1
+func CellularPortIndex(model sdnapi.NetworkModel) int {
+	for i, port := range model.Ports {
+		if port.EVEConnect.Cellular {
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,88 @@
+package edensdn_test
+
+import (
+	"testing"
+
+	"github.com/lf-edge/eden/pkg/defaults"
+	"github.com/lf-edge/eden/pkg/edensdn"
+	sdnapi "github.com/lf-edge/eden/sdn/vm/api"
+	"github.com/stretchr/testify/assert"
+)
+
+func ethernetModel() sdnapi.NetworkModel {
+	return sdnapi.NetworkModel{
+		Ports: []sdnapi.Port{{LogicalLabel: "eth0", AdminUP: true}},
+		Bridges: []sdnapi.Bridge{{
+			LogicalLabel: "bridge0",
+			Ports:        []string{"eth0"},
+		}},
+		Networks: []sdnapi.Network{
+			{
+				LogicalLabel: "network6",
+				Bridge:       "bridge0",
+				Subnet:       "2001:db8::/64",
+				GwIP:         "2001:db8::1",
+				DHCP: sdnapi.DHCP{
+					DNSClientConfig: sdnapi.DNSClientConfig{PublicDNS: []string{"2001:4860:4860::8888"}},
+				},
+			},
+			{
+				LogicalLabel: "network0",
+				Bridge:       "bridge0",
+				Subnet:       "172.22.12.0/24",
+				GwIP:         "172.22.12.1",
+				DHCP: sdnapi.DHCP{
+					DNSClientConfig: sdnapi.DNSClientConfig{PublicDNS: []string{"1.1.1.1"}, PrivateDNS: []string{"dns0"}},
+				},
+			},
+		},
+	}
+}
+
+func TestAddCellularNetwork(t *testing.T) {
+	t.Parallel()
+
+	model := ethernetModel()
+	assert.Equal(t, -1, edensdn.CellularPortIndex(model))
+	assert.NoError(t, edensdn.AddCellularNetwork(&model))
+
+	idx := edensdn.CellularPortIndex(model)
+	if !assert.Equal(t, 1, idx) {
+		return
+	}
+	port := model.Ports[idx]
+	assert.Equal(t, defaults.DefaultCellularLabel, port.LogicalLabel)
+	assert.True(t, port.AdminUP)
+	assert.NotEmpty(t, port.MAC)
+	assert.NotEmpty(t, port.EVEConnect.MAC)
+	assert.NotEqual(t, port.MAC, port.EVEConnect.MAC)
+	assert.NotZero(t, port.TC.Delay, "latency of mobile network")
+	assert.NotZero(t, port.TC.RateLimit, "throughput of mobile network")
+
+	assert.Len(t, model.Bridges, 2)
+	bridge := model.Bridges[1]
+	assert.Equal(t, []string{defaults.DefaultCellularLabel}, bridge.Ports)
+
+	assert.Len(t, model.Networks, 3)
+	network := model.Networks[2]
+	assert.Equal(t, bridge.LogicalLabel, network.Bridge)
+	assert.True(t, network.DHCP.Enable)
+	assert.Equal(t, model.Networks[1].DHCP.DNSClientConfig, network.DHCP.DNSClientConfig,
+		"DNS of the first IPv4 network")
+
+	// the network is added only once
+	assert.NoError(t, edensdn.AddCellularNetwork(&model))
+	assert.Len(t, model.Ports, 2)
+	assert.Len(t, model.Bridges, 2)
+	assert.Len(t, model.Networks, 3)
+}
+
+func TestAddCellularNetworkLabelConflict(t *testing.T) {
+	t.Parallel()
+
+	model := ethernetModel()
+	model.Ports = append(model.Ports, sdnapi.Port{LogicalLabel: defaults.DefaultCellularLabel})
+	assert.Error(t, edensdn.AddCellularNetwork(&model))
+	assert.Len(t, model.Ports, 2)
+	assert.Equal(t, -1, edensdn.CellularPortIndex(model))
+}
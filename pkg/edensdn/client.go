@@ -103,6 +103,78 @@ func (client *SdnClient) ApplyNetworkModel(netModel model.NetworkModel) (err err
 	return
 }
 
+// GetImpairments : get network impairments currently applied to ports of Eden-SDN.
+func (client *SdnClient) GetImpairments() (impairments model.Impairments, err error) {
+	req, err := http.NewRequest(http.MethodGet,
+		fmt.Sprintf("http://localhost:%d/impairments.json", client.MgmtPort), nil)
+	if err != nil {
+		err = fmt.Errorf("failed to build HTTP request: %w", err)
+		return
+	}
+	httpClient := &http.Client{}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		err = fmt.Errorf("request to GET impairments failed: %w", err)
+		return
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		err = fmt.Errorf("request to GET impairments failed with resp: %s",
+			resp.Status)
+		return
+	}
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		err = fmt.Errorf("failed to read retrieved impairments: %w", err)
+		return
+	}
+	err = json.Unmarshal(data, &impairments)
+	if err != nil {
+		err = fmt.Errorf("failed to unmarshal retrieved impairments: %w", err)
+		return
+	}
+	return
+}
+
+// ApplyImpairments : submit network impairments to Eden-SDN.
+// Impairments replace all previously applied ones, empty impairments remove them all.
+func (client *SdnClient) ApplyImpairments(impairments model.Impairments) (err error) {
+	json, err := json.Marshal(impairments)
+	if err != nil {
+		err = fmt.Errorf("failed to marshal impairments: %w", err)
+		return
+	}
+	req, err := http.NewRequest(http.MethodPut,
+		fmt.Sprintf("http://localhost:%d/impairments.json", client.MgmtPort),
+		bytes.NewBuffer(json))
+	if err != nil {
+		err = fmt.Errorf("failed to build HTTP request: %w", err)
+		return
+	}
+	req.Header.Set("Content-Type", "application/json")
+	httpClient := &http.Client{}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		err = fmt.Errorf("request to PUT impairments failed: %w", err)
+		return
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		var respBytes []byte
+		var response string
+		respBytes, err = io.ReadAll(resp.Body)
+		if err == nil {
+			response = string(respBytes)
+		} else {
+			response = fmt.Sprintf("failed to read response: %v", err)
+		}
+		err = fmt.Errorf("request to PUT impairments failed with code=%d, "+
+			"response: %s", resp.StatusCode, response)
+		return
+	}
+	return
+}
+
 // GetNetworkConfigGraph : get network config applied by Eden-SDN.
 // Network config items and their dependencies are depicted using a DOT graph.
 func (client *SdnClient) GetNetworkConfigGraph() (config string, err error) {
@@ -307,3 +379,70 @@ func (client *SdnClient) getEveIfIndex(eveIfName string) (eveIfIndex int, err er
 	}
 	return
 }
+
+// GetEveIfSdnSide : get name of the interface inside SDN VM which is connected
+// with the given EVE interface.
+func (client *SdnClient) GetEveIfSdnSide(eveIfName string) (ifName string, err error) {
+	netModel, err := client.GetNetworkModel()
+	if err != nil {
+		return "", fmt.Errorf("failed to get network model: %v", err)
+	}
+	eveIfIndex, err := client.getEveIfIndex(eveIfName)
+	if err != nil {
+		return "", err
+	}
+	if eveIfIndex < 0 || eveIfIndex >= len(netModel.Ports) {
+		return "", fmt.Errorf("EVE interface index is out-of-range: %d <%d-%d)",
+			eveIfIndex, 0, len(netModel.Ports))
+	}
+	command := exec.Command("ssh", client.sshArgs("ip", "-o", "link")...)
+	output, err := command.CombinedOutput()
+	if err != nil {
+		return "", fmt.Errorf("command %s failed: %v", command, string(output))
+	}
+	mac := netModel.Ports[eveIfIndex].MAC
+	ifName = ifNameByMAC(string(output), mac)
+	if ifName == "" {
+		return "", fmt.Errorf("no interface with MAC %s found in SDN VM", mac)
+	}
+	return ifName, nil
+}
+
+// ifNameByMAC finds interface with the MAC in output of "ip -o link".
+func ifNameByMAC(ipLinkOutput, mac string) string {
+	for _, line := range strings.Split(ipLinkOutput, "\n") {
+		fields := strings.Fields(line)
+		for i := 0; i+1 < len(fields); i++ {
+			if fields[i] == "link/ether" && strings.EqualFold(fields[i+1], mac) {
+				// e.g. "3: eth1: <...>" or "5: veth1@if4: <...>"
+				ifName := strings.TrimSuffix(fields[1], ":")
+				ifName, _, _ = strings.Cut(ifName, "@")
+				return ifName
+			}
+		}
+	}
+	return ""
+}
+
+// CapturePackets : capture packets on the interface inside SDN VM with tcpdump
+// for the given duration and write them in pcap format into w.
+func (client *SdnClient) CapturePackets(ifName, filter string, duration time.Duration, w io.Writer) error {
+	seconds := int(duration.Round(time.Second).Seconds())
+	if seconds < 1 {
+		seconds = 1
+	}
+	// tcpdump flushes the capture file when interrupted
+	remoteCmd := fmt.Sprintf("timeout -s INT %d tcpdump -U -n -i %s -w -", seconds, ifName)
+	if filter != "" {
+		remoteCmd += " " + utils.ShellQuote(filter)
+	}
+	command := exec.Command("ssh", client.sshArgs(remoteCmd)...)
+	var stderr bytes.Buffer
+	command.Stdout = w
+	command.Stderr = &stderr
+	if err := command.Run(); err != nil {
+		return fmt.Errorf("tcpdump on %s failed: %v (%s)", ifName, err,
+			strings.TrimSpace(stderr.String()))
+	}
+	return nil
+}
@@ -0,0 +1,141 @@
+package edensdn
+
+import (
+	"fmt"
+	"net"
+	"strings"
+
+	sdnapi "github.com/lf-edge/eden/sdn/vm/api"
+)
+
+// DHCPOptions customizes DHCP server of SDN network.
+// Zero values keep the current settings of the network.
+type DHCPOptions struct {
+	// Disable DHCP server, EVE has to use static IP configuration.
+	Disable bool
+	// IPRange to allocate addresses from.
+	IPRange sdnapi.IPRange
+	// StaticLeases are added to (or replace the same MAC in) the static entries.
+	StaticLeases []sdnapi.MACToIP
+	// DNS servers announced instead of the DNS endpoints of SDN.
+	DNS []string
+	// NTP server announced instead of the NTP endpoint of SDN.
+	NTP string
+}
+
+// ParseIPRange parses range of IP addresses in format FROM-TO.
+func ParseIPRange(ipRange string) (sdnapi.IPRange, error) {
+	fromIP, toIP, found := strings.Cut(ipRange, "-")
+	if !found || net.ParseIP(fromIP) == nil || net.ParseIP(toIP) == nil {
+		return sdnapi.IPRange{}, fmt.Errorf("invalid IP range %q, expected FROM-TO", ipRange)
+	}
+	return sdnapi.IPRange{FromIP: fromIP, ToIP: toIP}, nil
+}
+
+// ParseStaticLease parses static DHCP lease in format MAC=IP.
+func ParseStaticLease(lease string) (sdnapi.MACToIP, error) {
+	mac, ip, found := strings.Cut(lease, "=")
+	if !found {
+		return sdnapi.MACToIP{}, fmt.Errorf("invalid static lease %q, expected MAC=IP", lease)
+	}
+	hwAddr, err := net.ParseMAC(mac)
+	if err != nil {
+		return sdnapi.MACToIP{}, fmt.Errorf("invalid MAC address in static lease %q: %w", lease, err)
+	}
+	if net.ParseIP(ip) == nil {
+		return sdnapi.MACToIP{}, fmt.Errorf("invalid IP address in static lease %q", lease)
+	}
+	return sdnapi.MACToIP{MAC: hwAddr.String(), IP: ip}, nil
+}
+
+// UpdateNetworkDHCP applies the options to the DHCP server of the untagged network
+// of the bridge which the ports of SDN are attached to. Updated network is returned.
+func UpdateNetworkDHCP(model *sdnapi.NetworkModel, ports []string, opts DHCPOptions) (sdnapi.Network, error) {
+	bridgeIdx, _, err := findBridge(model, ports)
+	if err != nil {
+		return sdnapi.Network{}, err
+	}
+	bridge := model.Bridges[bridgeIdx].LogicalLabel
+	var network *sdnapi.Network
+	for i := range model.Networks {
+		if model.Networks[i].Bridge == bridge && model.Networks[i].VlanID == 0 {
+			network = &model.Networks[i]
+			break
+		}
+	}
+	if network == nil {
+		return sdnapi.Network{}, fmt.Errorf("bridge %s of SDN has no untagged network", bridge)
+	}
+	_, subnet, err := net.ParseCIDR(network.Subnet)
+	if err != nil {
+		return sdnapi.Network{}, fmt.Errorf("network %s has invalid subnet: %w", network.LogicalLabel, err)
+	}
+	inSubnet := func(ip string) error {
+		if !subnet.Contains(net.ParseIP(ip)) {
+			return fmt.Errorf("IP %s is outside of subnet %s of network %s",
+				ip, network.Subnet, network.LogicalLabel)
+		}
+		return nil
+	}
+	dhcp := network.DHCP
+	if opts.IPRange.FromIP != "" {
+		for _, ip := range []string{opts.IPRange.FromIP, opts.IPRange.ToIP} {
+			if err = inSubnet(ip); err != nil {
+				return sdnapi.Network{}, err
+			}
+		}
+		dhcp.IPRange = opts.IPRange
+	}
+	for _, lease := range opts.StaticLeases {
+		if err = inSubnet(lease.IP); err != nil {
+			return sdnapi.Network{}, err
+		}
+		var leases []sdnapi.MACToIP
+		for _, entry := range dhcp.StaticEntries {
+			if entry.MAC != lease.MAC {
+				leases = append(leases, entry)
+			}
+		}
+		dhcp.StaticEntries = append(leases, lease)
+	}
+	if len(opts.DNS) > 0 {
+		dhcp.PublicDNS = opts.DNS
+		dhcp.PrivateDNS = nil
+	}
+	if opts.NTP != "" {
+		dhcp.PublicNTP = opts.NTP
+		dhcp.PrivateNTP = ""
+	}
+	if opts.Disable {
+		dhcp.Enable = false
+	}
+	network.DHCP = dhcp
+	return *network, nil
+}
+
+// NetworkDNSServers returns IP addresses of DNS servers announced by DHCP of the network,
+// DNS endpoints of SDN are translated to their IPs.
+func NetworkDNSServers(model sdnapi.NetworkModel, network sdnapi.Network) []string {
+	servers := append([]string{}, network.DHCP.PublicDNS...)
+	for _, label := range network.DHCP.PrivateDNS {
+		for _, dnsServer := range model.Endpoints.DNSServers {
+			if dnsServer.LogicalLabel == label {
+				servers = append(servers, dnsServer.IP)
+			}
+		}
+	}
+	return servers
+}
+
+// NetworkNTPServer returns IP address of NTP server announced by DHCP of the network.
+func NetworkNTPServer(model sdnapi.NetworkModel, network sdnapi.Network) string {
+	if network.DHCP.PublicNTP != "" {
+		return network.DHCP.PublicNTP
+	}
+	for _, ntpServer := range model.Endpoints.NTPServers {
+		if ntpServer.LogicalLabel == network.DHCP.PrivateNTP {
+			return ntpServer.IP
+		}
+	}
+	return ""
+}
@@ -0,0 +1,69 @@
+package edensdn_test
+
+import (
+	"testing"
+
+	"github.com/lf-edge/eden/pkg/edensdn"
+	sdnapi "github.com/lf-edge/eden/sdn/vm/api"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUpdateNetworkDHCP(t *testing.T) {
+	t.Parallel()
+
+	model := sdnapi.NetworkModel{
+		Ports:   []sdnapi.Port{{LogicalLabel: "eth0"}},
+		Bridges: []sdnapi.Bridge{{LogicalLabel: "bridge0", Ports: []string{"eth0"}}},
+		Networks: []sdnapi.Network{{
+			LogicalLabel: "network0",
+			Bridge:       "bridge0",
+			Subnet:       "172.22.12.0/24",
+			GwIP:         "172.22.12.1",
+			DHCP: sdnapi.DHCP{
+				Enable: true,
+				DNSClientConfig: sdnapi.DNSClientConfig{
+					PrivateDNS: []string{"dns-server0"},
+				},
+			},
+		}},
+		Endpoints: sdnapi.Endpoints{
+			DNSServers: []sdnapi.DNSServer{{
+				Endpoint: sdnapi.Endpoint{LogicalLabel: "dns-server0", IP: "10.16.16.25"},
+			}},
+		},
+	}
+
+	network, err := edensdn.UpdateNetworkDHCP(&model, []string{"eth0"}, edensdn.DHCPOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"10.16.16.25"}, edensdn.NetworkDNSServers(model, network))
+
+	_, err = edensdn.ParseStaticLease("02:fe:00:00:00:01")
+	assert.Error(t, err)
+	lease, err := edensdn.ParseStaticLease("02:FE:00:00:00:01=172.22.12.50")
+	assert.NoError(t, err)
+	assert.Equal(t, "02:fe:00:00:00:01", lease.MAC)
+	ipRange, err := edensdn.ParseIPRange("172.22.12.100-172.22.12.120")
+	assert.NoError(t, err)
+
+	_, err = edensdn.UpdateNetworkDHCP(&model, []string{"eth0"}, edensdn.DHCPOptions{
+		StaticLeases: []sdnapi.MACToIP{{MAC: lease.MAC, IP: "10.0.0.1"}},
+	})
+	assert.Error(t, err)
+	network, err = edensdn.UpdateNetworkDHCP(&model, []string{"eth0"}, edensdn.DHCPOptions{
+		IPRange:      ipRange,
+		StaticLeases: []sdnapi.MACToIP{lease, lease},
+		DNS:          []string{"1.1.1.1"},
+		NTP:          "129.6.15.28",
+	})
+	assert.NoError(t, err)
+	assert.True(t, network.DHCP.Enable)
+	assert.Equal(t, ipRange, network.DHCP.IPRange)
+	assert.Equal(t, []sdnapi.MACToIP{lease}, network.DHCP.StaticEntries)
+	assert.Equal(t, []string{"1.1.1.1"}, edensdn.NetworkDNSServers(model, network))
+	assert.Equal(t, "129.6.15.28", edensdn.NetworkNTPServer(model, network))
+
+	network, err = edensdn.UpdateNetworkDHCP(&model, []string{"eth0"}, edensdn.DHCPOptions{Disable: true})
+	assert.NoError(t, err)
+	assert.False(t, network.DHCP.Enable)
+	assert.Equal(t, network, model.Networks[0])
+}
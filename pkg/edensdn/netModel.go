@@ -84,12 +84,215 @@ var defaultNetModel = sdnapi.NetworkModel{
 	},
 }
 
-// GetDefaultNetModel : get default network model.
+// defaultIPv6NetModel : default network model for IPv6-only setup.
+// Same topology as defaultNetModel, but EVE, endpoints and the controller
+// are connected using IPv6 only.
+var defaultIPv6NetModel = sdnapi.NetworkModel{
+	Ports: []sdnapi.Port{
+		{
+			LogicalLabel: "eth0",
+			AdminUP:      true,
+		},
+		{
+			LogicalLabel: "eth1",
+			AdminUP:      true,
+		},
+	},
+	Bridges: []sdnapi.Bridge{
+		{
+			LogicalLabel: "bridge0",
+			Ports:        []string{"eth0", "eth1"},
+		},
+	},
+	Networks: []sdnapi.Network{
+		{
+			LogicalLabel: "network0",
+			Bridge:       "bridge0",
+			Subnet:       "fd22:1::/64",
+			GwIP:         "fd22:1::1",
+			DHCP: sdnapi.DHCP{
+				// Addresses are assigned using SLAAC.
+				Enable:     true,
+				DomainName: "sdn",
+				DNSClientConfig: sdnapi.DNSClientConfig{
+					PrivateDNS: []string{"dns-server0"},
+				},
+			},
+		},
+	},
+	Endpoints: sdnapi.Endpoints{
+		Clients: []sdnapi.Client{
+			{
+				Endpoint: sdnapi.Endpoint{
+					LogicalLabel: "client0",
+					FQDN:         "client0.sdn",
+					Subnet:       "fd17:17::/64",
+					IP:           "fd17:17::2",
+				},
+			},
+		},
+		DNSServers: []sdnapi.DNSServer{
+			{
+				Endpoint: sdnapi.Endpoint{
+					LogicalLabel: "dns-server0",
+					FQDN:         "dns-server0.sdn",
+					Subnet:       "fd18:18::/64",
+					IP:           "fd18:18::2",
+				},
+				StaticEntries: []sdnapi.DNSEntry{
+					{
+						// See config item "adam.domain".
+						FQDN: "mydomain.adam",
+						IP:   "adam-ip",
+					},
+				},
+				UpstreamServers: []string{
+					"2001:4860:4860::8888",
+					"2606:4700:4700::1111",
+				},
+			},
+		},
+	},
+}
+
+// defaultDualStackNetModel : default network model for dual-stack setup.
+// EVE port eth0 is connected to IPv4 network and eth1 to IPv6 network, each with its own
+// DNS server. The controller is reachable from both networks, using IP of the host
+// of the same version.
+var defaultDualStackNetModel = sdnapi.NetworkModel{
+	Ports: []sdnapi.Port{
+		{
+			LogicalLabel: "eth0",
+			AdminUP:      true,
+		},
+		{
+			LogicalLabel: "eth1",
+			AdminUP:      true,
+		},
+	},
+	Bridges: []sdnapi.Bridge{
+		{
+			LogicalLabel: "bridge0",
+			Ports:        []string{"eth0"},
+		},
+		{
+			LogicalLabel: "bridge1",
+			Ports:        []string{"eth1"},
+		},
+	},
+	Networks: []sdnapi.Network{
+		{
+			LogicalLabel: "network0",
+			Bridge:       "bridge0",
+			Subnet:       "172.22.1.0/24",
+			GwIP:         "172.22.1.1",
+			DHCP: sdnapi.DHCP{
+				Enable: true,
+				IPRange: sdnapi.IPRange{
+					FromIP: "172.22.1.10",
+					ToIP:   "172.22.1.20",
+				},
+				DomainName: "sdn",
+				DNSClientConfig: sdnapi.DNSClientConfig{
+					PrivateDNS: []string{"dns-server0"},
+				},
+			},
+		},
+		{
+			LogicalLabel: "network1",
+			Bridge:       "bridge1",
+			Subnet:       "fd22:1::/64",
+			GwIP:         "fd22:1::1",
+			DHCP: sdnapi.DHCP{
+				// Addresses are assigned using SLAAC.
+				Enable:     true,
+				DomainName: "sdn",
+				DNSClientConfig: sdnapi.DNSClientConfig{
+					PrivateDNS: []string{"dns-server1"},
+				},
+			},
+		},
+	},
+	Endpoints: sdnapi.Endpoints{
+		Clients: []sdnapi.Client{
+			{
+				Endpoint: sdnapi.Endpoint{
+					LogicalLabel: "client0",
+					FQDN:         "client0.sdn",
+					Subnet:       "10.17.17.0/24",
+					IP:           "10.17.17.2",
+				},
+			},
+			{
+				Endpoint: sdnapi.Endpoint{
+					LogicalLabel: "client1",
+					FQDN:         "client1.sdn",
+					Subnet:       "fd17:17::/64",
+					IP:           "fd17:17::2",
+				},
+			},
+		},
+		DNSServers: []sdnapi.DNSServer{
+			{
+				Endpoint: sdnapi.Endpoint{
+					LogicalLabel: "dns-server0",
+					FQDN:         "dns-server0.sdn",
+					Subnet:       "10.18.18.0/24",
+					IP:           "10.18.18.2",
+				},
+				StaticEntries: []sdnapi.DNSEntry{
+					{
+						// See config item "adam.domain".
+						FQDN: "mydomain.adam",
+						IP:   "adam-ip",
+					},
+				},
+				UpstreamServers: []string{
+					"8.8.8.8",
+					"1.1.1.1",
+				},
+			},
+			{
+				Endpoint: sdnapi.Endpoint{
+					LogicalLabel: "dns-server1",
+					FQDN:         "dns-server1.sdn",
+					Subnet:       "fd18:18::/64",
+					IP:           "fd18:18::2",
+				},
+				StaticEntries: []sdnapi.DNSEntry{
+					{
+						// See config item "adam.domain".
+						FQDN: "mydomain.adam",
+						IP:   "adam-ip",
+					},
+				},
+				UpstreamServers: []string{
+					"2001:4860:4860::8888",
+					"2606:4700:4700::1111",
+				},
+			},
+		},
+	},
+}
+
+// GetDefaultNetModel : get default network model for the given type of the host network
+// (see sdnapi.NetworkTypeToID for supported values, empty means IPv4 only).
 // Used unless the user selects custom network model.
-func GetDefaultNetModel() (model sdnapi.NetworkModel, err error) {
-	model = defaultNetModel
+func GetDefaultNetModel(networkType string) (model sdnapi.NetworkModel, err error) {
+	netType, ok := sdnapi.NetworkTypeToID[networkType]
+	if !ok {
+		return model, fmt.Errorf("unsupported network type: %s", networkType)
+	}
+	switch netType {
+	case sdnapi.Ipv6Only:
+		model = defaultIPv6NetModel
+	case sdnapi.DualStack:
+		model = defaultDualStackNetModel
+	default:
+		model = defaultNetModel
+	}
 	addMissingMACs(&model)
-	err = addMissingHostConfig(&model)
+	err = addMissingHostConfig(&model, netType)
 	return
 }
 
@@ -109,7 +312,7 @@ func LoadNetModeFromFile(filepath string) (sdnapi.NetworkModel, error) {
 		return model, err
 	}
 	addMissingMACs(&model)
-	err = addMissingHostConfig(&model)
+	err = addMissingHostConfig(&model, sdnapi.Ipv4Only)
 	return model, err
 }
 
@@ -166,15 +369,28 @@ func addMissingMACs(model *sdnapi.NetworkModel) {
 	}
 }
 
-func addMissingHostConfig(netModel *sdnapi.NetworkModel) error {
+// addMissingHostConfig adds host config with host IP of the given network type
+// if not defined in the model
+func addMissingHostConfig(netModel *sdnapi.NetworkModel, networkType sdnapi.NetworkType) error {
 	if netModel.Host == nil {
-		hostIP, err := utils.GetIPForDockerAccess()
-		if err != nil {
-			return fmt.Errorf("failed to find suitable host IP: %v", err)
+		getHostIPs := []func() (string, error){utils.GetIPForDockerAccess}
+		switch networkType {
+		case sdnapi.Ipv6Only:
+			getHostIPs = []func() (string, error){utils.GetIPv6ForDockerAccess}
+		case sdnapi.DualStack:
+			getHostIPs = append(getHostIPs, utils.GetIPv6ForDockerAccess)
+		}
+		var hostIPs []string
+		for _, getHostIP := range getHostIPs {
+			hostIP, err := getHostIP()
+			if err != nil {
+				return fmt.Errorf("failed to find suitable host IP: %v", err)
+			}
+			hostIPs = append(hostIPs, hostIP)
 		}
 		netModel.Host = &sdnapi.HostConfig{
-			HostIPs:     []string{hostIP},
-			NetworkType: sdnapi.Ipv4Only, // XXX For now everything is IPv4 only
+			HostIPs:     hostIPs,
+			NetworkType: networkType,
 			// ControllerPort is not know at this level, must be filled in by the caller
 		}
 	}
@@ -0,0 +1,59 @@
+package edensdn_test
+
+import (
+	"net"
+	"strings"
+	"testing"
+
+	"github.com/lf-edge/eden/pkg/edensdn"
+	sdnapi "github.com/lf-edge/eden/sdn/vm/api"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetDefaultNetModel(t *testing.T) {
+	t.Parallel()
+
+	_, err := edensdn.GetDefaultNetModel("ipv5-only")
+	assert.Error(t, err)
+
+	testMatrix := map[string]struct {
+		networkType sdnapi.NetworkType
+		ipv4, ipv6  bool
+	}{
+		"":           {networkType: sdnapi.Ipv4Only, ipv4: true},
+		"ipv4-only":  {networkType: sdnapi.Ipv4Only, ipv4: true},
+		"ipv6-only":  {networkType: sdnapi.Ipv6Only, ipv6: true},
+		"dual-stack": {networkType: sdnapi.DualStack, ipv4: true, ipv6: true},
+	}
+
+	for name, tt := range testMatrix {
+		model, err := edensdn.GetDefaultNetModel(name)
+		if err != nil && strings.Contains(err.Error(), "failed to find suitable host IP") {
+			// host IPs of required families are not available
+			continue
+		}
+		if !assert.NoError(t, err, name) {
+			continue
+		}
+		assert.Equal(t, tt.networkType, model.Host.NetworkType, name)
+		var hasIPv4, hasIPv6 bool
+		for _, hostIP := range model.Host.HostIPs {
+			ip := net.ParseIP(hostIP)
+			if assert.NotNil(t, ip, name) {
+				hasIPv4 = hasIPv4 || ip.To4() != nil
+				hasIPv6 = hasIPv6 || ip.To4() == nil
+			}
+		}
+		assert.Equal(t, tt.ipv4, hasIPv4, name)
+		assert.Equal(t, tt.ipv6, hasIPv6, name)
+		for _, network := range model.Networks {
+			_, subnet, err := net.ParseCIDR(network.Subnet)
+			if assert.NoError(t, err, name) {
+				// IPv6-only model must not have IPv4 networks
+				if !tt.ipv4 {
+					assert.Nil(t, subnet.IP.To4(), name)
+				}
+			}
+		}
+	}
+}
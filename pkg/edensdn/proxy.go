@@ -0,0 +1,289 @@
+package edensdn
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/lf-edge/eden/pkg/defaults"
+	sdnapi "github.com/lf-edge/eden/sdn/vm/api"
+	"github.com/lf-edge/eve-api/go/config"
+)
+
+// Proxy scenarios with a proxy placed between EVE and the controller (see config item "sdn.proxy").
+const (
+	// ProxyExplicit : EVE is configured to use HTTP/HTTPS proxy, which is the only way
+	// to reach the controller and datastores.
+	ProxyExplicit = "explicit"
+	// ProxyExplicitAuth : same as ProxyExplicit, but the proxy requires user authentication.
+	ProxyExplicitAuth = "explicit-auth"
+	// ProxyTransparent : HTTP/HTTPS traffic of EVE is intercepted by the proxy without
+	// EVE being aware of it.
+	ProxyTransparent = "transparent"
+)
+
+// ProxyScenarios : all supported proxy scenarios.
+var ProxyScenarios = []string{ProxyExplicit, ProxyExplicitAuth, ProxyTransparent}
+
+// Credentials required by the proxy in the ProxyExplicitAuth scenario.
+const (
+	ProxyUsername = "eden"
+	ProxyPassword = "eden-proxy"
+)
+
+const (
+	proxyLogicalLabel = "proxy0"
+	proxyFQDN         = "proxy0.sdn"
+	proxyHTTPPort     = 9090
+	proxyHTTPSPort    = 9091
+	proxyCACertFile   = "proxy-ca.pem"
+	proxyCAKeyFile    = "proxy-ca-key.pem"
+)
+
+// IsProxyScenario returns true if the scenario is one of ProxyScenarios.
+func IsProxyScenario(scenario string) bool {
+	for _, s := range ProxyScenarios {
+		if s == scenario {
+			return true
+		}
+	}
+	return false
+}
+
+// GenerateProxyCA generates a new certificate authority for the MITM proxy and stores it
+// into configDir, replacing the previous one.
+// Returns the new CA certificate in the PEM format.
+func GenerateProxyCA(configDir string) (caCertPEM string, err error) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		return "", fmt.Errorf("failed to generate key of proxy CA: %w", err)
+	}
+	// Serial number has to differ between rotated CAs.
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 64))
+	if err != nil {
+		return "", fmt.Errorf("failed to generate serial number of proxy CA: %w", err)
+	}
+	template := x509.Certificate{
+		SerialNumber: serial,
+		Subject: pkix.Name{
+			Country:      []string{defaults.DefaultX509Country},
+			Organization: []string{defaults.DefaultX509Company},
+			CommonName:   fmt.Sprintf("Eden-SDN Proxy CA %s", time.Now().UTC().Format(time.RFC3339)),
+		},
+		NotBefore:             time.Now().Add(-10 * time.Second),
+		NotAfter:              time.Now().AddDate(10, 0, 0),
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign | x509.KeyUsageDigitalSignature,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	certDER, err := x509.CreateCertificate(rand.Reader, &template, &template, &key.PublicKey, key)
+	if err != nil {
+		return "", fmt.Errorf("failed to create certificate of proxy CA: %w", err)
+	}
+	keyDER, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal key of proxy CA: %w", err)
+	}
+	if err = os.MkdirAll(configDir, 0755); err != nil {
+		return "", err
+	}
+	caCertPEM = string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: certDER}))
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: keyDER})
+	if err = os.WriteFile(filepath.Join(configDir, proxyCAKeyFile), keyPEM, 0600); err != nil {
+		return "", fmt.Errorf("failed to write key of proxy CA: %w", err)
+	}
+	if err = os.WriteFile(filepath.Join(configDir, proxyCACertFile), []byte(caCertPEM), 0644); err != nil {
+		return "", fmt.Errorf("failed to write certificate of proxy CA: %w", err)
+	}
+	return caCertPEM, nil
+}
+
+// LoadProxyCA loads certificate and key of the proxy CA generated by GenerateProxyCA.
+func LoadProxyCA(configDir string) (caCertPEM, caKeyPEM string, err error) {
+	cert, err := os.ReadFile(filepath.Join(configDir, proxyCACertFile))
+	if err != nil {
+		return "", "", fmt.Errorf("failed to read certificate of proxy CA: %w", err)
+	}
+	key, err := os.ReadFile(filepath.Join(configDir, proxyCAKeyFile))
+	if err != nil {
+		return "", "", fmt.Errorf("failed to read key of proxy CA: %w", err)
+	}
+	return string(cert), string(key), nil
+}
+
+// GetProxyNetModel : get network model for the proxy scenario.
+// Topology is the same as with the default network model (two EVE ports bridged into one
+// network), so that the proxy can be enabled without restarting EVE. Only IPv4 is supported.
+func GetProxyNetModel(scenario, networkType, caCertPEM, caKeyPEM string) (model sdnapi.NetworkModel, err error) {
+	if netType, ok := sdnapi.NetworkTypeToID[networkType]; !ok || netType != sdnapi.Ipv4Only {
+		return model, fmt.Errorf("proxy scenario %s is not supported with network type %s",
+			scenario, networkType)
+	}
+	proxy := sdnapi.Proxy{
+		CACertPEM: caCertPEM,
+		CAKeyPEM:  caKeyPEM,
+		ProxyRules: []sdnapi.ProxyRule{
+			{Action: sdnapi.PxMITM},
+		},
+	}
+	proxyEndpoint := sdnapi.Endpoint{
+		LogicalLabel: proxyLogicalLabel,
+		FQDN:         proxyFQDN,
+		Subnet:       "10.19.19.0/24",
+		IP:           "10.19.19.2",
+	}
+	model = sdnapi.NetworkModel{
+		Ports: []sdnapi.Port{
+			{LogicalLabel: "eth0", AdminUP: true},
+			{LogicalLabel: "eth1", AdminUP: true},
+		},
+		Bridges: []sdnapi.Bridge{
+			{LogicalLabel: "bridge0", Ports: []string{"eth0", "eth1"}},
+		},
+		Networks: []sdnapi.Network{
+			{
+				LogicalLabel: "network0",
+				Bridge:       "bridge0",
+				Subnet:       "172.22.1.0/24",
+				GwIP:         "172.22.1.1",
+				DHCP: sdnapi.DHCP{
+					Enable: true,
+					IPRange: sdnapi.IPRange{
+						FromIP: "172.22.1.10",
+						ToIP:   "172.22.1.20",
+					},
+					DomainName: "sdn",
+					DNSClientConfig: sdnapi.DNSClientConfig{
+						PrivateDNS: []string{"dns-server0"},
+					},
+				},
+				Router: &sdnapi.Router{
+					ReachableEndpoints: []string{"client0", "dns-server0", proxyLogicalLabel},
+				},
+			},
+		},
+		Endpoints: sdnapi.Endpoints{
+			Clients: []sdnapi.Client{
+				{
+					Endpoint: sdnapi.Endpoint{
+						LogicalLabel: "client0",
+						FQDN:         "client0.sdn",
+						Subnet:       "10.17.17.0/24",
+						IP:           "10.17.17.2",
+					},
+				},
+			},
+		},
+	}
+	adamEntry := sdnapi.DNSEntry{
+		// See config item "adam.domain".
+		FQDN: "mydomain.adam",
+		IP:   "adam-ip",
+	}
+	upstreamServers := []string{"8.8.8.8", "1.1.1.1"}
+	switch scenario {
+	case ProxyExplicit, ProxyExplicitAuth:
+		// EVE is not able to resolve domain name of the controller and there is no route
+		// outside of SDN, i.e. the controller and datastores are reachable only through
+		// the proxy, which uses its own DNS server.
+		model.Endpoints.DNSServers = []sdnapi.DNSServer{
+			{
+				Endpoint: sdnapi.Endpoint{
+					LogicalLabel: "dns-server0",
+					FQDN:         "dns-server0.sdn",
+					Subnet:       "10.18.18.0/24",
+					IP:           "10.18.18.2",
+				},
+				StaticEntries: []sdnapi.DNSEntry{
+					{
+						FQDN: "endpoint-fqdn." + proxyLogicalLabel,
+						IP:   "endpoint-ip." + proxyLogicalLabel,
+					},
+				},
+				UpstreamServers: upstreamServers,
+			},
+			{
+				Endpoint: sdnapi.Endpoint{
+					LogicalLabel: "dns-server-proxy",
+					FQDN:         "dns-server-proxy.sdn",
+					Subnet:       "10.20.20.0/24",
+					IP:           "10.20.20.2",
+				},
+				StaticEntries:   []sdnapi.DNSEntry{adamEntry},
+				UpstreamServers: upstreamServers,
+			},
+		}
+		proxy.DNSClientConfig = sdnapi.DNSClientConfig{PrivateDNS: []string{"dns-server-proxy"}}
+		explicitProxy := sdnapi.ExplicitProxy{
+			Endpoint:   proxyEndpoint,
+			Proxy:      proxy,
+			HTTPProxy:  sdnapi.ProxyPort{Port: proxyHTTPPort, ListenProto: sdnapi.ProxyListenProtoHTTP},
+			HTTPSProxy: sdnapi.ProxyPort{Port: proxyHTTPSPort, ListenProto: sdnapi.ProxyListenProtoHTTP},
+		}
+		if scenario == ProxyExplicitAuth {
+			explicitProxy.Users = []sdnapi.UserCredentials{
+				{Username: ProxyUsername, Password: ProxyPassword},
+			}
+		}
+		model.Endpoints.ExplicitProxies = []sdnapi.ExplicitProxy{explicitProxy}
+	case ProxyTransparent:
+		model.Endpoints.DNSServers = []sdnapi.DNSServer{
+			{
+				Endpoint: sdnapi.Endpoint{
+					LogicalLabel: "dns-server0",
+					FQDN:         "dns-server0.sdn",
+					Subnet:       "10.18.18.0/24",
+					IP:           "10.18.18.2",
+				},
+				StaticEntries:   []sdnapi.DNSEntry{adamEntry},
+				UpstreamServers: upstreamServers,
+			},
+		}
+		proxy.DNSClientConfig = sdnapi.DNSClientConfig{PrivateDNS: []string{"dns-server0"}}
+		model.Networks[0].TransparentProxy = proxyLogicalLabel
+		model.Networks[0].Router.OutsideReachability = true
+		model.Endpoints.TransparentProxies = []sdnapi.TransparentProxy{
+			{Endpoint: proxyEndpoint, Proxy: proxy},
+		}
+	default:
+		return model, fmt.Errorf("unsupported proxy scenario: %s (supported: %v)",
+			scenario, ProxyScenarios)
+	}
+	addMissingMACs(&model)
+	err = addMissingHostConfig(&model, sdnapi.Ipv4Only)
+	return model, err
+}
+
+// GetProxyDeviceConfig : get proxy configuration for networks of EVE in the proxy scenario.
+// EVE trusts all the given CA certificates, which allows to rotate CA of the proxy
+// without breaking connectivity.
+func GetProxyDeviceConfig(scenario string, caCertsPEM ...string) (*config.ProxyConfig, error) {
+	proxyConfig := &config.ProxyConfig{}
+	for _, caCertPEM := range caCertsPEM {
+		proxyConfig.ProxyCertPEM = append(proxyConfig.ProxyCertPEM, []byte(caCertPEM))
+	}
+	switch scenario {
+	case ProxyExplicit, ProxyExplicitAuth:
+		server := "http://" + proxyFQDN
+		if scenario == ProxyExplicitAuth {
+			server = fmt.Sprintf("http://%s:%s@%s", ProxyUsername, ProxyPassword, proxyFQDN)
+		}
+		proxyConfig.Proxies = []*config.ProxyServer{
+			{Proto: config.ProxyProto_PROXY_HTTP, Server: server, Port: proxyHTTPPort},
+			{Proto: config.ProxyProto_PROXY_HTTPS, Server: server, Port: proxyHTTPSPort},
+		}
+	case ProxyTransparent:
+		// EVE is not aware of the proxy, it only needs to trust its CA.
+	default:
+		return nil, fmt.Errorf("unsupported proxy scenario: %s (supported: %v)",
+			scenario, ProxyScenarios)
+	}
+	return proxyConfig, nil
+}
@@ -0,0 +1,46 @@
+package edensdn_test
+
+import (
+	"crypto/tls"
+	"testing"
+
+	"github.com/lf-edge/eden/pkg/edensdn"
+	"github.com/lf-edge/eve-api/go/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProxyCARotation(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	_, _, err := edensdn.LoadProxyCA(dir)
+	assert.Error(t, err)
+
+	prevCert, err := edensdn.GenerateProxyCA(dir)
+	assert.NoError(t, err)
+	cert, err := edensdn.GenerateProxyCA(dir)
+	assert.NoError(t, err)
+	assert.NotEqual(t, prevCert, cert)
+
+	// proxy of SDN loads the pair with tls.X509KeyPair
+	loadedCert, loadedKey, err := edensdn.LoadProxyCA(dir)
+	assert.NoError(t, err)
+	assert.Equal(t, cert, loadedCert)
+	_, err = tls.X509KeyPair([]byte(loadedCert), []byte(loadedKey))
+	assert.NoError(t, err)
+
+	proxyConfig, err := edensdn.GetProxyDeviceConfig(edensdn.ProxyExplicitAuth, cert, prevCert)
+	assert.NoError(t, err)
+	assert.Len(t, proxyConfig.ProxyCertPEM, 2)
+	if assert.Len(t, proxyConfig.Proxies, 2) {
+		assert.Equal(t, config.ProxyProto_PROXY_HTTPS, proxyConfig.Proxies[1].Proto)
+		assert.Contains(t, proxyConfig.Proxies[1].Server, edensdn.ProxyUsername+":"+edensdn.ProxyPassword+"@")
+	}
+
+	proxyConfig, err = edensdn.GetProxyDeviceConfig(edensdn.ProxyTransparent, cert)
+	assert.NoError(t, err)
+	assert.Empty(t, proxyConfig.Proxies)
+
+	_, err = edensdn.GetProxyDeviceConfig("socks", cert)
+	assert.Error(t, err)
+}
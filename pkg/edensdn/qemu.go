@@ -81,9 +81,14 @@ func (vm *SdnVMQemuRunner) Start() error {
 	}
 
 	// Management port.
-	qemuOptions += fmt.Sprintf("-netdev user,id=eth%d,net=%s,dhcpstart=%s,ipv6=off,"+
+	// IPv6 is enabled only if the host uses it to connect with the controller.
+	ipv6 := "off"
+	if hostUsesIPv6(vm.NetModel) {
+		ipv6 = "on"
+	}
+	qemuOptions += fmt.Sprintf("-netdev user,id=eth%d,net=%s,dhcpstart=%s,ipv6=%s,"+
 		"hostfwd=tcp::%d-:22,hostfwd=tcp::%d-:6666", len(vm.NetModel.Ports), vm.MgmtSubnet.String(),
-		vm.MgmtSubnet.DHCPStart.String(), vm.SSHPort, vm.MgmtPort)
+		vm.MgmtSubnet.DHCPStart.String(), ipv6, vm.SSHPort, vm.MgmtPort)
 	qemuOptions += fmt.Sprintf(" -device %s,netdev=eth%d,mac=%s ", netDev,
 		len(vm.NetModel.Ports), GenerateSdnMgmtMAC())
 	_ = os.Chmod(vm.SSHKeyPath, 0600)
@@ -103,7 +108,7 @@ func (vm *SdnVMQemuRunner) Start() error {
 	}
 	conf, err := settings.GenerateQemuConfig()
 	if err != nil {
-		fmt.Errorf("failed to generate QEMU config: %v", err)
+		return fmt.Errorf("failed to generate QEMU config: %v", err)
 	}
 	err = os.WriteFile(qemuConfigPath, conf, 0664)
 	if err != nil {
@@ -124,8 +129,12 @@ func (vm *SdnVMQemuRunner) Stop() (err error) {
 	return nil
 }
 
-// RequiresVmRestart returns true if the set of ports has changed.
+// RequiresVmRestart returns true if the set of ports or IP versions used
+// by the host have changed.
 func (vm *SdnVMQemuRunner) RequiresVmRestart(oldModel, newModel model.NetworkModel) bool {
+	if hostUsesIPv6(oldModel) != hostUsesIPv6(newModel) {
+		return true
+	}
 	for _, oldPort := range oldModel.Ports {
 		newPort := newModel.GetPortByMAC(oldPort.MAC)
 		if newPort == nil || oldPort.EVEConnect != newPort.EVEConnect {
@@ -140,3 +149,7 @@ func (vm *SdnVMQemuRunner) RequiresVmRestart(oldModel, newModel model.NetworkMod
 	}
 	return false
 }
+
+func hostUsesIPv6(netModel model.NetworkModel) bool {
+	return netModel.Host != nil && netModel.Host.NetworkType != model.Ipv4Only
+}
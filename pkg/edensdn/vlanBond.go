@@ -0,0 +1,213 @@
+package edensdn
+
+import (
+	"fmt"
+	"net"
+	"strings"
+
+	sdnapi "github.com/lf-edge/eden/sdn/vm/api"
+)
+
+// findBridge returns index of the bridge which the ports are attached to, either directly
+// or all of them through a bond, and the index of the bond (-1 if attached directly).
+func findBridge(model *sdnapi.NetworkModel, ports []string) (bridgeIdx, bondIdx int, err error) {
+	bondIdx = findBond(model, ports)
+	for i, bridge := range model.Bridges {
+		if bondIdx >= 0 {
+			for _, bond := range bridge.Bonds {
+				if bond == model.Bonds[bondIdx].LogicalLabel {
+					return i, bondIdx, nil
+				}
+			}
+			continue
+		}
+		attached := 0
+		for _, port := range ports {
+			for _, bridgePort := range bridge.Ports {
+				if bridgePort == port {
+					attached++
+				}
+			}
+		}
+		if attached == len(ports) {
+			return i, -1, nil
+		}
+	}
+	return -1, -1, fmt.Errorf("ports %s are not attached to the same bridge of SDN",
+		strings.Join(ports, ", "))
+}
+
+// findBond returns index of the bond aggregating exactly the ports or -1.
+func findBond(model *sdnapi.NetworkModel, ports []string) int {
+	for i, bond := range model.Bonds {
+		if len(bond.Ports) != len(ports) {
+			continue
+		}
+		same := true
+		for j := range ports {
+			if bond.Ports[j] != ports[j] {
+				same = false
+			}
+		}
+		if same {
+			return i
+		}
+	}
+	return -1
+}
+
+// vlanSubnet returns subnet and gateway for network with VLAN, of the same IP version
+// as the untagged network of the bridge.
+func vlanSubnet(vlanID uint16, ipv6 bool) (subnet, gwIP, fromIP, toIP string) {
+	if ipv6 {
+		return fmt.Sprintf("fd10:%x::/64", vlanID), fmt.Sprintf("fd10:%x::1", vlanID), "", ""
+	}
+	// 10.100.0.0 - 10.115.255.0 covers all VLAN IDs.
+	prefix := fmt.Sprintf("10.%d.%d", 100+vlanID/256, vlanID%256)
+	return prefix + ".0/24", prefix + ".1", prefix + ".10", prefix + ".20"
+}
+
+// AddVlanNetwork adds network with the VLAN into the bridge which the ports of SDN
+// are attached to (directly or aggregated into one bond), so that EVE can send traffic
+// tagged with the VLAN ID through these ports. DHCP, DNS and routing are copied
+// from the untagged network of the bridge. Nothing is done if the network already exists.
+func AddVlanNetwork(model *sdnapi.NetworkModel, ports []string, vlanID uint16) error {
+	if vlanID == 0 || vlanID > 4094 {
+		return fmt.Errorf("invalid VLAN ID: %d", vlanID)
+	}
+	bridgeIdx, _, err := findBridge(model, ports)
+	if err != nil {
+		return err
+	}
+	bridge := model.Bridges[bridgeIdx].LogicalLabel
+	var untagged *sdnapi.Network
+	for i, network := range model.Networks {
+		if network.Bridge != bridge {
+			continue
+		}
+		if network.VlanID == vlanID {
+			return nil
+		}
+		if network.VlanID == 0 {
+			untagged = &model.Networks[i]
+		}
+	}
+	var ipv6 bool
+	if untagged != nil {
+		ip, _, _ := net.ParseCIDR(untagged.Subnet)
+		ipv6 = ip != nil && ip.To4() == nil
+	}
+	subnet, gwIP, fromIP, toIP := vlanSubnet(vlanID, ipv6)
+	network := sdnapi.Network{
+		LogicalLabel: fmt.Sprintf("%s-vlan%d", bridge, vlanID),
+		Bridge:       bridge,
+		VlanID:       vlanID,
+		Subnet:       subnet,
+		GwIP:         gwIP,
+		DHCP: sdnapi.DHCP{
+			Enable: true,
+			IPRange: sdnapi.IPRange{
+				FromIP: fromIP,
+				ToIP:   toIP,
+			},
+			DomainName: "sdn",
+		},
+	}
+	if untagged != nil {
+		network.MTU = untagged.MTU
+		network.DHCP.DomainName = untagged.DHCP.DomainName
+		network.DHCP.DNSClientConfig = untagged.DHCP.DNSClientConfig
+		network.DHCP.PrivateNTP = untagged.DHCP.PrivateNTP
+		network.TransparentProxy = untagged.TransparentProxy
+		if untagged.Router != nil {
+			router := *untagged.Router
+			network.Router = &router
+		}
+	}
+	model.Networks = append(model.Networks, network)
+	return nil
+}
+
+// RemoveVlanNetwork removes network with the VLAN added by AddVlanNetwork.
+func RemoveVlanNetwork(model *sdnapi.NetworkModel, ports []string, vlanID uint16) error {
+	bridgeIdx, _, err := findBridge(model, ports)
+	if err != nil {
+		return err
+	}
+	bridge := model.Bridges[bridgeIdx].LogicalLabel
+	var networks []sdnapi.Network
+	for _, network := range model.Networks {
+		if network.Bridge != bridge || network.VlanID != vlanID {
+			networks = append(networks, network)
+		}
+	}
+	model.Networks = networks
+	return nil
+}
+
+// AddBond aggregates the ports of SDN attached to the same bridge into a bond with the mode,
+// to act as the link partner of bond of EVE. Nothing is done if the bond already exists.
+func AddBond(model *sdnapi.NetworkModel, ports []string, mode sdnapi.BondMode) error {
+	if len(ports) < 2 {
+		return fmt.Errorf("bond must aggregate at least two ports")
+	}
+	bridgeIdx, bondIdx, err := findBridge(model, ports)
+	if err != nil {
+		return err
+	}
+	if bondIdx >= 0 {
+		model.Bonds[bondIdx].Mode = mode
+		return nil
+	}
+	bond := sdnapi.Bond{
+		LogicalLabel: "bond-" + strings.Join(ports, "-"),
+		Ports:        ports,
+		Mode:         mode,
+		MIIMonitor: sdnapi.BondMIIMonitor{
+			Enabled:  true,
+			Interval: 100,
+		},
+	}
+	bridge := &model.Bridges[bridgeIdx]
+	var bridgePorts []string
+	for _, bridgePort := range bridge.Ports {
+		aggregated := false
+		for _, port := range ports {
+			if port == bridgePort {
+				aggregated = true
+			}
+		}
+		if !aggregated {
+			bridgePorts = append(bridgePorts, bridgePort)
+		}
+	}
+	bridge.Ports = bridgePorts
+	bridge.Bonds = append(bridge.Bonds, bond.LogicalLabel)
+	model.Bonds = append(model.Bonds, bond)
+	return nil
+}
+
+// RemoveBond removes the bond of ports added by AddBond, the ports are attached back
+// to the bridge directly.
+func RemoveBond(model *sdnapi.NetworkModel, ports []string) error {
+	bondIdx := findBond(model, ports)
+	if bondIdx < 0 {
+		return nil
+	}
+	bridgeIdx, _, err := findBridge(model, ports)
+	if err != nil {
+		return err
+	}
+	label := model.Bonds[bondIdx].LogicalLabel
+	bridge := &model.Bridges[bridgeIdx]
+	var bonds []string
+	for _, bond := range bridge.Bonds {
+		if bond != label {
+			bonds = append(bonds, bond)
+		}
+	}
+	bridge.Bonds = bonds
+	bridge.Ports = append(bridge.Ports, ports...)
+	model.Bonds = append(model.Bonds[:bondIdx], model.Bonds[bondIdx+1:]...)
+	return nil
+}
@@ -0,0 +1,54 @@
+package edensdn_test
+
+import (
+	"testing"
+
+	"github.com/lf-edge/eden/pkg/edensdn"
+	sdnapi "github.com/lf-edge/eden/sdn/vm/api"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVlanOverBond(t *testing.T) {
+	t.Parallel()
+
+	ports := []string{"eth0", "eth1"}
+	model := sdnapi.NetworkModel{
+		Ports:   []sdnapi.Port{{LogicalLabel: "eth0"}, {LogicalLabel: "eth1"}},
+		Bridges: []sdnapi.Bridge{{LogicalLabel: "bridge0", Ports: ports}},
+		Networks: []sdnapi.Network{{
+			LogicalLabel: "network0",
+			Bridge:       "bridge0",
+			Subnet:       "172.22.12.0/24",
+			GwIP:         "172.22.12.1",
+			DHCP: sdnapi.DHCP{
+				Enable: true,
+				DNSClientConfig: sdnapi.DNSClientConfig{
+					PrivateDNS: []string{"dns-server0"},
+				},
+			},
+		}},
+	}
+
+	assert.Error(t, edensdn.AddBond(&model, ports[:1], sdnapi.BondModeActiveBackup))
+	assert.NoError(t, edensdn.AddBond(&model, ports, sdnapi.BondModeActiveBackup))
+	if assert.Len(t, model.Bonds, 1) {
+		assert.Equal(t, []string{model.Bonds[0].LogicalLabel}, model.Bridges[0].Bonds)
+		assert.Empty(t, model.Bridges[0].Ports)
+	}
+
+	assert.Error(t, edensdn.AddVlanNetwork(&model, ports, 4095))
+	assert.NoError(t, edensdn.AddVlanNetwork(&model, ports, 100))
+	assert.NoError(t, edensdn.AddVlanNetwork(&model, ports, 100))
+	if assert.Len(t, model.Networks, 2) {
+		vlan := model.Networks[1]
+		assert.Equal(t, uint16(100), vlan.VlanID)
+		assert.Equal(t, "10.100.100.0/24", vlan.Subnet)
+		assert.Equal(t, model.Networks[0].DHCP.DNSClientConfig, vlan.DHCP.DNSClientConfig)
+	}
+
+	assert.NoError(t, edensdn.RemoveVlanNetwork(&model, ports, 100))
+	assert.Len(t, model.Networks, 1)
+	assert.NoError(t, edensdn.RemoveBond(&model, ports))
+	assert.Empty(t, model.Bonds)
+	assert.ElementsMatch(t, ports, model.Bridges[0].Ports)
+}
@@ -0,0 +1,76 @@
+package edgeview
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/client"
+	"github.com/docker/docker/pkg/stdcopy"
+	"github.com/lf-edge/eden/pkg/utils"
+	log "github.com/sirupsen/logrus"
+)
+
+// Client runs queries with edgeview client container
+type Client struct {
+	// Image of edgeview client
+	Image string
+	// Token is signed JWT of the session
+	Token string
+}
+
+// Run runs query with edgeview client and writes its output into w, it stops
+// the client when ctx is done (e.g. for queries which do not exit by themselves)
+func (c *Client) Run(ctx context.Context, query []string, w io.Writer) error {
+	log.Debugf("Run edgeview query %q with %s", query, c.Image)
+	cli, err := client.NewClientWithOpts(client.FromEnv, client.WithAPIVersionNegotiation())
+	if err != nil {
+		return fmt.Errorf("NewClientWithOpts: %w", err)
+	}
+	if err = utils.PullImage(c.Image); err != nil {
+		return err
+	}
+	resp, err := cli.ContainerCreate(ctx, &container.Config{
+		Image: c.Image,
+		Cmd:   append([]string{"-token", c.Token}, query...),
+	}, &container.HostConfig{
+		// client listens on local ports for TCP forwarding
+		NetworkMode: "host",
+	}, nil, nil, "")
+	if err != nil {
+		return fmt.Errorf("ContainerCreate: %w", err)
+	}
+	defer func() {
+		// context may be already canceled here
+		if err := cli.ContainerRemove(context.Background(), resp.ID,
+			types.ContainerRemoveOptions{Force: true}); err != nil {
+			log.Errorf("ContainerRemove error: %s", err)
+		}
+	}()
+	if err = cli.ContainerStart(ctx, resp.ID, types.ContainerStartOptions{}); err != nil {
+		return fmt.Errorf("ContainerStart: %w", err)
+	}
+	out, err := cli.ContainerLogs(ctx, resp.ID, types.ContainerLogsOptions{ShowStdout: true, ShowStderr: true, Follow: true})
+	if err != nil {
+		return fmt.Errorf("ContainerLogs: %w", err)
+	}
+	defer out.Close()
+	if _, err = stdcopy.StdCopy(w, w, out); err != nil && ctx.Err() == nil {
+		return fmt.Errorf("cannot read output of edgeview client: %w", err)
+	}
+	if ctx.Err() != nil {
+		return nil
+	}
+	statusCh, errCh := cli.ContainerWait(context.Background(), resp.ID, container.WaitConditionNotRunning)
+	select {
+	case err = <-errCh:
+		return err
+	case status := <-statusCh:
+		if status.StatusCode != 0 {
+			return fmt.Errorf("edgeview client exited with code %d", status.StatusCode)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,43 @@
+package edgeview
+
+import (
+	"fmt"
+	"strings"
+)
+
+// vncBasePort is port of VNC display 0 of apps on EVE
+const vncBasePort = 5900
+
+// ClientPort is the first local port edgeview client listens on for TCP forwarding
+const ClientPort = 9001
+
+// TcpdumpQuery returns query to capture packets on interface of EVE matching filter
+func TcpdumpQuery(ifName, filter string) string {
+	return fmt.Sprintf("tcpdump/%s/%s", ifName, filter)
+}
+
+// LogQuery returns query to search logs of EVE for pattern, timeRange is in
+// form of <start>-<end> in hours back from now (e.g. 0.5-2)
+func LogQuery(pattern, timeRange string) []string {
+	query := []string{"log/" + pattern}
+	if timeRange != "" {
+		query = append(query, "-time", timeRange)
+	}
+	return query
+}
+
+// AppQuery returns query of state of app
+func AppQuery(appName string) string {
+	return "app/" + appName
+}
+
+// TCPQuery returns query to forward local port of client to addresses reachable from
+// EVE, they are assigned to ClientPort and the next ports in the order of addresses
+func TCPQuery(addrs ...string) string {
+	return "tcp/" + strings.Join(addrs, "/")
+}
+
+// VNCAddr returns address of VNC console of app with display on EVE
+func VNCAddr(display uint32) string {
+	return fmt.Sprintf("localhost:%d", vncBasePort+int(display))
+}
@@ -0,0 +1,123 @@
+// Package edgeview creates tokens of edgeview sessions and runs queries to EVE with
+// edgeview client through the dispatcher.
+package edgeview
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Token is the payload of JWT of edgeview session, EVE verifies it with signing
+// certificate of the controller
+type Token struct {
+	// Dispatcher is endpoint of edgeview dispatcher in form of host:port
+	Dispatcher string `json:"dep"`
+	// Subject is UUID of the device
+	Subject string `json:"sub"`
+	// Expire is unix time of expiration of the token
+	Expire uint64 `json:"exp"`
+	// Key is nonce used for authentication of payload
+	Key string `json:"key"`
+	// Instances is number of edgeview instances on EVE
+	Instances uint8 `json:"num"`
+	// Encrypt enables encryption of payload instead of authentication only
+	Encrypt bool `json:"enc"`
+}
+
+type tokenHeader struct {
+	Alg string `json:"alg"`
+	Typ string `json:"typ"`
+}
+
+// NewToken returns token of session to device with devID through dispatcher valid for expire
+func NewToken(dispatcher, devID string, expire time.Duration, instances uint8, encrypt bool) (*Token, error) {
+	nonce := make([]byte, 16)
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	if instances == 0 {
+		instances = 1
+	}
+	return &Token{
+		Dispatcher: dispatcher,
+		Subject:    devID,
+		Expire:     uint64(time.Now().Add(expire).Unix()),
+		Key:        hex.EncodeToString(nonce),
+		Instances:  instances,
+		Encrypt:    encrypt,
+	}, nil
+}
+
+// Sign returns JWT signed with private key of the controller: ES256 for ECDSA
+// keys and RS256 for RSA ones
+func (t *Token) Sign(key crypto.PrivateKey) (string, error) {
+	header := tokenHeader{Typ: "JWT"}
+	switch key.(type) {
+	case *ecdsa.PrivateKey:
+		header.Alg = "ES256"
+	case *rsa.PrivateKey:
+		header.Alg = "RS256"
+	default:
+		return "", fmt.Errorf("unsupported type of signing key: %T", key)
+	}
+	headerData, err := json.Marshal(header)
+	if err != nil {
+		return "", err
+	}
+	payloadData, err := json.Marshal(t)
+	if err != nil {
+		return "", err
+	}
+	signed := base64.RawURLEncoding.EncodeToString(headerData) + "." +
+		base64.RawURLEncoding.EncodeToString(payloadData)
+	hash := sha256.Sum256([]byte(signed))
+	var signature []byte
+	switch k := key.(type) {
+	case *ecdsa.PrivateKey:
+		r, s, err := ecdsa.Sign(rand.Reader, k, hash[:])
+		if err != nil {
+			return "", err
+		}
+		// JWS expects r and s padded to the size of the key
+		size := (k.Curve.Params().BitSize + 7) / 8
+		signature = make([]byte, 2*size)
+		r.FillBytes(signature[:size])
+		s.FillBytes(signature[size:])
+	case *rsa.PrivateKey:
+		if signature, err = rsa.SignPKCS1v15(rand.Reader, k, crypto.SHA256, hash[:]); err != nil {
+			return "", err
+		}
+	}
+	return signed + "." + base64.RawURLEncoding.EncodeToString(signature), nil
+}
+
+// ParseToken returns payload of JWT without verification of its signature
+func ParseToken(jwt string) (*Token, error) {
+	parts := strings.Split(jwt, ".")
+	if len(parts) != 3 {
+		return nil, fmt.Errorf("token must contain 3 parts, got %d", len(parts))
+	}
+	payloadData, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return nil, fmt.Errorf("cannot decode payload of token: %w", err)
+	}
+	token := &Token{}
+	if err = json.Unmarshal(payloadData, token); err != nil {
+		return nil, fmt.Errorf("cannot parse payload of token: %w", err)
+	}
+	return token, nil
+}
+
+// ExpireTime returns time of expiration of the token
+func (t *Token) ExpireTime() time.Time {
+	return time.Unix(int64(t.Expire), 0)
+}
@@ -0,0 +1,61 @@
+package edgeview_test
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"math/big"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/lf-edge/eden/pkg/edgeview"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTokenSign(t *testing.T) {
+	t.Parallel()
+
+	token, err := edgeview.NewToken("192.168.0.1:4000", "dev-id", time.Hour, 0, false)
+	assert.NoError(t, err)
+	assert.Equal(t, uint8(1), token.Instances)
+	assert.Len(t, token.Key, 32)
+
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(t, err)
+	jwt, err := token.Sign(ecKey)
+	assert.NoError(t, err)
+	parts := strings.Split(jwt, ".")
+	if assert.Len(t, parts, 3) {
+		hash := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+		signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+		assert.NoError(t, err)
+		if assert.Len(t, signature, 64) {
+			r := new(big.Int).SetBytes(signature[:32])
+			s := new(big.Int).SetBytes(signature[32:])
+			assert.True(t, ecdsa.Verify(&ecKey.PublicKey, hash[:], r, s))
+		}
+	}
+	parsed, err := edgeview.ParseToken(jwt)
+	assert.NoError(t, err)
+	assert.Equal(t, token, parsed)
+
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.NoError(t, err)
+	jwt, err = token.Sign(rsaKey)
+	assert.NoError(t, err)
+	parts = strings.Split(jwt, ".")
+	if assert.Len(t, parts, 3) {
+		hash := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+		signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+		assert.NoError(t, err)
+		assert.NoError(t, rsa.VerifyPKCS1v15(&rsaKey.PublicKey, crypto.SHA256, hash[:], signature))
+	}
+
+	_, err = edgeview.ParseToken("not-a-token")
+	assert.Error(t, err)
+}
@@ -0,0 +1,165 @@
+package eve
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/dustin/go-humanize"
+	"github.com/lf-edge/eden/pkg/controller/types"
+	"github.com/lf-edge/eve-api/go/info"
+)
+
+// there is no dedicated type for accelerators in EVE API,
+// so we detect them by name of adapter and members
+var acceleratorRe = regexp.MustCompile(`(?i)(gpu|vga|npu|tpu|vpu|fpga|accel|nvidia|cuda|hailo|coral)`)
+
+// AccelState stores state of accelerator (GPU, NPU, etc.) reported by EVE
+type AccelState struct {
+	Name        string
+	Type        string
+	Members     []string
+	Usage       string
+	UsedByApp   string
+	UsedByAppID string
+	LastError   string
+
+	// utilization of the whole app the accelerator is assigned to,
+	// EVE does not report utilization of adapters themselves
+	AppCPUUsage    int
+	AppMemoryUsed  uint32
+	AppMemoryAvail uint32
+}
+
+func accelStateHeader() string {
+	return "NAME\tTYPE\tMEMBERS\tUSAGE\tASSIGNED_TO\tASSIGNED_APP_CPU\tASSIGNED_APP_MEMORY\tERROR"
+}
+
+func (accelStateObj *AccelState) toString() string {
+	app, cpu, memory := "-", "-", "-"
+	// app is not known until its info is received
+	if accelStateObj.UsedByApp != "" {
+		app = accelStateObj.UsedByApp
+	} else if accelStateObj.UsedByAppID != "" {
+		app = accelStateObj.UsedByAppID
+	}
+	if accelStateObj.UsedByAppID != "" {
+		cpu = fmt.Sprintf("%d%%", accelStateObj.AppCPUUsage)
+		memory = fmt.Sprintf("%s/%s",
+			humanize.Bytes((uint64)(accelStateObj.AppMemoryUsed*humanize.MByte)),
+			humanize.Bytes((uint64)(accelStateObj.AppMemoryAvail*humanize.MByte)))
+	}
+	lastError := accelStateObj.LastError
+	if lastError == "" {
+		lastError = "-"
+	}
+	return fmt.Sprintf("%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s",
+		accelStateObj.Name, accelStateObj.Type, strings.Join(accelStateObj.Members, ","),
+		accelStateObj.Usage, app, cpu, memory, lastError)
+}
+
+// isAccelerator checks if adapter looks like GPU or other accelerator,
+// type of adapter is not used as HDMI ports are display outputs of any graphics
+func isAccelerator(bundle *info.ZioBundle) bool {
+	if acceleratorRe.MatchString(bundle.GetName()) {
+		return true
+	}
+	for _, member := range bundle.GetMembers() {
+		if acceleratorRe.MatchString(member) {
+			return true
+		}
+	}
+	return false
+}
+
+// acceleratorNames returns names of accelerators from list of adapters
+func acceleratorNames(bundles []*info.ZioBundle) []string {
+	var names []string
+	for _, bundle := range bundles {
+		if isAccelerator(bundle) {
+			names = append(names, bundle.GetName())
+		}
+	}
+	return names
+}
+
+func (ctx *State) processAcceleratorsByInfo(im *info.ZInfoMsg) {
+	if im.GetZtype() != info.ZInfoTypes_ZiDevice {
+		return
+	}
+	ctx.accelerators = make(map[string]*AccelState)
+	for _, bundle := range im.GetDinfo().GetAssignableAdapters() {
+		if !isAccelerator(bundle) {
+			continue
+		}
+		accelStateObj := &AccelState{
+			Name:        bundle.GetName(),
+			Type:        strings.TrimPrefix(bundle.GetType().String(), "PhyIo"),
+			Members:     bundle.GetMembers(),
+			Usage:       strings.TrimPrefix(bundle.GetUsage().String(), "PhyIoUsage"),
+			UsedByAppID: bundle.GetUsedByAppUUID(),
+			LastError:   bundle.GetErr().GetDescription(),
+		}
+		if bundle.GetUsedByBaseOS() {
+			accelStateObj.UsedByApp = "EVE"
+		}
+		ctx.accelerators[accelStateObj.Name] = accelStateObj
+	}
+}
+
+// Accelerators extracts accelerators states with utilization of apps which use them
+func (ctx *State) Accelerators() []*AccelState {
+	v := make([]*AccelState, 0, len(ctx.accelerators))
+	for _, value := range ctx.accelerators {
+		if app, ok := ctx.applications[value.UsedByAppID]; ok {
+			value.UsedByApp = app.Name
+			value.AppCPUUsage = app.CPUUsage
+			value.AppMemoryUsed = app.MemoryUsed
+			value.AppMemoryAvail = app.MemoryAvail
+		}
+		v = append(v, value)
+	}
+	sort.SliceStable(v, func(i, j int) bool {
+		return v[i].Name < v[j].Name
+	})
+	return v
+}
+
+func (ctx *State) printAcceleratorListLines() error {
+	w := new(tabwriter.Writer)
+	w.Init(os.Stdout, 0, 8, 1, '\t', 0)
+	if _, err := fmt.Fprintln(w, accelStateHeader()); err != nil {
+		return err
+	}
+	for _, el := range ctx.Accelerators() {
+		if _, err := fmt.Fprintln(w, el.toString()); err != nil {
+			return err
+		}
+	}
+	return w.Flush()
+}
+
+func (ctx *State) printAcceleratorListJSON() error {
+	result, err := json.MarshalIndent(ctx.Accelerators(), "", "    ")
+	if err != nil {
+		return err
+	}
+	//nolint:forbidigo
+	fmt.Println(string(result))
+	return nil
+}
+
+// AcceleratorsList prints accelerators
+func (ctx *State) AcceleratorsList(outputFormat types.OutputFormat) error {
+	switch outputFormat {
+	case types.OutputFormatLines:
+		return ctx.printAcceleratorListLines()
+	case types.OutputFormatJSON:
+		return ctx.printAcceleratorListJSON()
+	}
+	return fmt.Errorf("unimplemented output format")
+}
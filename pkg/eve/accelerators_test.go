@@ -0,0 +1,111 @@
+package eve
+
+import (
+	"testing"
+	"time"
+
+	"github.com/lf-edge/eden/pkg/device"
+	"github.com/lf-edge/eve-api/go/evecommon"
+	"github.com/lf-edge/eve-api/go/info"
+	"github.com/lf-edge/eve-api/go/metrics"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func TestIsAccelerator(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name   string
+		bundle *info.ZioBundle
+		accel  bool
+	}{
+		{name: "GPU by name", bundle: &info.ZioBundle{Name: "GPU0", Type: evecommon.PhyIoType_PhyIoOther}, accel: true},
+		{name: "NPU by member", bundle: &info.ZioBundle{Name: "PCIe1", Members: []string{"hailo8-0"}}, accel: true},
+		{name: "VGA controller", bundle: &info.ZioBundle{Name: "VGA", Type: evecommon.PhyIoType_PhyIoOther}, accel: true},
+		{name: "HDMI output", bundle: &info.ZioBundle{Name: "HDMI1", Type: evecommon.PhyIoType_PhyIoHDMI}},
+		{name: "ethernet", bundle: &info.ZioBundle{Name: "eth1", Type: evecommon.PhyIoType_PhyIoNetEth, Members: []string{"eth1"}}},
+		{name: "USB", bundle: &info.ZioBundle{Name: "USB0", Type: evecommon.PhyIoType_PhyIoUSB}},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.accel, isAccelerator(tt.bundle), tt.name)
+	}
+	assert.Equal(t, []string{"GPU0"}, acceleratorNames([]*info.ZioBundle{tests[0].bundle, tests[3].bundle}))
+}
+
+func TestAccelerators(t *testing.T) {
+	t.Parallel()
+
+	dev := device.CreateEdgeNode()
+	state := Init(nil, dev)
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	gpu := &info.ZioBundle{
+		Name:          "GPU0",
+		Type:          evecommon.PhyIoType_PhyIoOther,
+		Members:       []string{"gpu0", "gpu0-audio"},
+		Usage:         evecommon.PhyIoMemberUsage_PhyIoUsageDedicated,
+		UsedByAppUUID: "app-id",
+	}
+	device := func(bundles ...*info.ZioBundle) *info.ZInfoMsg {
+		return &info.ZInfoMsg{
+			Ztype:       info.ZInfoTypes_ZiDevice,
+			DevId:       dev.GetID().String(),
+			AtTimeStamp: timestamppb.New(start),
+			InfoContent: &info.ZInfoMsg_Dinfo{Dinfo: &info.ZInfoDevice{AssignableAdapters: bundles}},
+		}
+	}
+	state.InfoCallback()(device(
+		gpu,
+		&info.ZioBundle{Name: "NPU0", Members: []string{"npu0"}, UsedByBaseOS: true,
+			Err: &info.ErrorInfo{Description: "driver is not loaded"}},
+		&info.ZioBundle{Name: "HDMI1", Type: evecommon.PhyIoType_PhyIoHDMI},
+	))
+	state.InfoCallback()(&info.ZInfoMsg{
+		Ztype:       info.ZInfoTypes_ZiApp,
+		DevId:       dev.GetID().String(),
+		AtTimeStamp: timestamppb.New(start),
+		InfoContent: &info.ZInfoMsg_Ainfo{Ainfo: &info.ZInfoApp{
+			AppID:            "app-id",
+			AppName:          "inference",
+			State:            info.ZSwState_RUNNING,
+			AssignedAdapters: []*info.ZioBundle{gpu},
+		}},
+	})
+	for i, cpu := range []uint64{0, 5e8} {
+		state.MetricCallback()(&metrics.ZMetricMsg{
+			DevID:       dev.GetID().String(),
+			AtTimeStamp: timestamppb.New(start.Add(time.Duration(i) * time.Second)),
+			Am: []*metrics.AppMetric{{
+				AppID:  "app-id",
+				Cpu:    &metrics.AppCpuMetric{TotalNs: cpu},
+				Memory: &metrics.MemoryMetric{UsedMem: 256, AvailMem: 768},
+			}},
+		})
+	}
+
+	accels := state.Accelerators()
+	if !assert.Len(t, accels, 2) {
+		return
+	}
+	assert.Equal(t, &AccelState{
+		Name:           "GPU0",
+		Type:           "Other",
+		Members:        []string{"gpu0", "gpu0-audio"},
+		Usage:          "Dedicated",
+		UsedByApp:      "inference",
+		UsedByAppID:    "app-id",
+		AppCPUUsage:    50,
+		AppMemoryUsed:  256,
+		AppMemoryAvail: 768,
+	}, accels[0])
+	assert.Equal(t, "NPU0", accels[1].Name)
+	assert.Equal(t, "EVE", accels[1].UsedByApp)
+	assert.Equal(t, "driver is not loaded", accels[1].LastError)
+	assert.Equal(t, "GPU0\tOther\tgpu0,gpu0-audio\tDedicated\tinference\t50%\t256 MB/768 MB\t-", accels[0].toString())
+	assert.Equal(t, "NPU0\tNoop\tnpu0\tNone\tEVE\t-\t-\tdriver is not loaded", accels[1].toString())
+	assert.Equal(t, []string{"GPU0"}, state.applications["app-id"].Accelerators)
+
+	// the list is replaced with the next device info
+	state.InfoCallback()(device(gpu))
+	assert.Len(t, state.Accelerators(), 1)
+}
@@ -35,6 +35,12 @@ type AppInstState struct {
 	CPUUsage     int
 	Macs         []string
 	Volumes      map[string]uint32
+	Accelerators []string
+	Errors       []*AppError
+	// Health is combined result of probes of app defined with eden pod probe
+	Health string
+	// VolumeRefs are UUIDs of volumes used by app as reported by EVE
+	VolumeRefs []string
 
 	prevCPUNS     uint64
 	prevCPUNSTime time.Time
@@ -43,7 +49,11 @@ type AppInstState struct {
 }
 
 func appStateHeader() string {
-	return "NAME\tIMAGE\tUUID\tINTERNAL\tEXTERNAL\tMEMORY\tSTATE(ADAM)\tLAST_STATE(EVE)"
+	return "NAME\tIMAGE\tUUID\tINTERNAL\tEXTERNAL\tMEMORY\tSTATE(ADAM)\tLAST_STATE(EVE)\tHEALTH"
+}
+
+func appStateHeaderWide() string {
+	return appStateHeader() + "\tCPU\tACCELERATORS"
 }
 
 func (appStateObj *AppInstState) toString() string {
@@ -76,10 +86,22 @@ func (appStateObj *AppInstState) toString() string {
 	memory := fmt.Sprintf("%s/%s",
 		humanize.Bytes((uint64)(appStateObj.MemoryUsed*humanize.MByte)),
 		humanize.Bytes((uint64)(appStateObj.MemoryAvail*humanize.MByte)))
-	return fmt.Sprintf("%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s",
+	health := appStateObj.Health
+	if health == "" {
+		health = "-"
+	}
+	return fmt.Sprintf("%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s",
 		appStateObj.Name, appStateObj.Image, appStateObj.UUID,
 		internal, external, memory,
-		appStateObj.AdamState, appStateObj.EVEState)
+		appStateObj.AdamState, appStateObj.EVEState, health)
+}
+
+func (appStateObj *AppInstState) toStringWide() string {
+	accelerators := "-"
+	if len(appStateObj.Accelerators) > 0 {
+		accelerators = strings.Join(appStateObj.Accelerators, ",")
+	}
+	return fmt.Sprintf("%s\t%d%%\t%s", appStateObj.toString(), appStateObj.CPUUsage, accelerators)
 }
 
 func getPortMapping(appConfig *config.AppInstanceConfig, qemuPorts map[string]string) (intports, extports string) {
@@ -200,6 +222,9 @@ func (ctx *State) processApplicationsByInfo(im *info.ZInfoMsg) {
 			ctx.applications[im.GetAinfo().AppID] = appStateObj
 		}
 		appStateObj.EVEState = im.GetAinfo().State.String()
+		appStateObj.Accelerators = acceleratorNames(im.GetAinfo().AssignedAdapters)
+		appStateObj.Errors = parseAppErrors(im.GetAinfo().AppErr)
+		appStateObj.VolumeRefs = im.GetAinfo().VolumeRefs
 		if len(im.GetAinfo().AppErr) > 0 {
 			//if AppErr, show them
 			appStateObj.EVEState = fmt.Sprintf("%s: %s", im.GetAinfo().State.String(), im.GetAinfo().AppErr)
@@ -302,10 +327,14 @@ func (ctx *State) processApplicationsByInfo(im *info.ZInfoMsg) {
 	}
 }
 
-func (ctx *State) printPodListLines() error {
+func (ctx *State) printPodListLines(wide bool) error {
 	w := new(tabwriter.Writer)
 	w.Init(os.Stdout, 0, 8, 1, '\t', 0)
-	if _, err := fmt.Fprintln(w, appStateHeader()); err != nil {
+	header := appStateHeader()
+	if wide {
+		header = appStateHeaderWide()
+	}
+	if _, err := fmt.Fprintln(w, header); err != nil {
 		return err
 	}
 	appStatesSlice := make([]*AppInstState, 0, len(ctx.Applications()))
@@ -314,7 +343,11 @@ func (ctx *State) printPodListLines() error {
 		return appStatesSlice[i].Name < appStatesSlice[j].Name
 	})
 	for _, el := range appStatesSlice {
-		if _, err := fmt.Fprintln(w, el.toString()); err != nil {
+		line := el.toString()
+		if wide {
+			line = el.toStringWide()
+		}
+		if _, err := fmt.Fprintln(w, line); err != nil {
 			return err
 		}
 	}
@@ -331,11 +364,11 @@ func (ctx *State) printPodListJSON() error {
 	return nil
 }
 
-// PodsList prints applications
-func (ctx *State) PodsList(outputFormat types.OutputFormat) error {
+// PodsList prints applications, wide adds CPU usage and assigned accelerators
+func (ctx *State) PodsList(outputFormat types.OutputFormat, wide bool) error {
 	switch outputFormat {
 	case types.OutputFormatLines:
-		return ctx.printPodListLines()
+		return ctx.printPodListLines(wide)
 	case types.OutputFormatJSON:
 		return ctx.printPodListJSON()
 	}
@@ -0,0 +1,194 @@
+package eve
+
+import (
+	"fmt"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/dustin/go-humanize"
+	"github.com/lf-edge/eden/pkg/probe"
+	"github.com/lf-edge/eve-api/go/info"
+)
+
+// conditions supported by AppCondition
+const (
+	AppConditionState      = "state"
+	AppConditionIPAssigned = "ip-assigned"
+	AppConditionProbeOK    = "probe-ok"
+	AppConditionHealthy    = "healthy"
+)
+
+// probeTimeout is timeout for connection to app port
+const probeTimeout = 5 * time.Second
+
+// AppCondition is one of readiness conditions of app
+type AppCondition struct {
+	Name  string
+	Value string
+}
+
+// ParseAppConditions parses conditions in form of name[=value]
+func ParseAppConditions(conditions []string) ([]*AppCondition, error) {
+	var result []*AppCondition
+	for _, el := range conditions {
+		el = strings.TrimSpace(el)
+		if el == "" {
+			continue
+		}
+		name, value, _ := strings.Cut(el, "=")
+		switch name {
+		case AppConditionState:
+			if value == "" {
+				return nil, fmt.Errorf("no value for condition %s", name)
+			}
+			value = strings.ToUpper(value)
+		case AppConditionIPAssigned, AppConditionProbeOK, AppConditionHealthy:
+			if value != "" {
+				return nil, fmt.Errorf("condition %s does not expect value", name)
+			}
+		default:
+			return nil, fmt.Errorf("unknown condition %s", name)
+		}
+		result = append(result, &AppCondition{Name: name, Value: value})
+	}
+	if len(result) == 0 {
+		return nil, fmt.Errorf("no conditions provided")
+	}
+	return result, nil
+}
+
+func (cond *AppCondition) String() string {
+	if cond.Value == "" {
+		return cond.Name
+	}
+	return fmt.Sprintf("%s=%s", cond.Name, cond.Value)
+}
+
+// Check returns true if app meets condition
+func (cond *AppCondition) Check(app *AppInstState) bool {
+	switch cond.Name {
+	case AppConditionState:
+		// EVEState may contain error or progress after the state name
+		state, _, _ := strings.Cut(app.EVEState, ":")
+		state, _, _ = strings.Cut(state, " ")
+		return state == cond.Value
+	case AppConditionIPAssigned:
+		for _, ip := range app.InternalIP {
+			if net.ParseIP(ip) != nil {
+				return true
+			}
+		}
+		return false
+	case AppConditionProbeOK:
+		if app.ExternalIP == "" || app.ExternalIP == "-" || app.ExternalPort == "" {
+			return false
+		}
+		port := strings.Split(app.ExternalPort, ",")[0]
+		conn, err := net.DialTimeout("tcp", net.JoinHostPort(app.ExternalIP, port), probeTimeout)
+		if err != nil {
+			return false
+		}
+		_ = conn.Close()
+		return true
+	case AppConditionHealthy:
+		return app.Health == probe.HealthHealthy
+	}
+	return false
+}
+
+// Settled returns true if app is not in transition between states: it is running or halted,
+// reports an error or is removed from config of controller and not reported by EVE anymore
+func (app *AppInstState) Settled() bool {
+	if len(app.Errors) > 0 || strings.Contains(app.EVEState, ":") {
+		return true
+	}
+	state, _, _ := strings.Cut(app.EVEState, " ")
+	switch state {
+	case info.ZSwState_RUNNING.String(), info.ZSwState_HALTED.String():
+		return true
+	case "UNKNOWN":
+		return app.AdamState == notInControllerConfig
+	}
+	return false
+}
+
+// conditions supported by VolumeCondition
+const (
+	VolumeConditionState    = "state"
+	VolumeConditionMaxSize  = "max-size"
+	VolumeConditionAttached = "attached"
+	VolumeConditionDetached = "detached"
+)
+
+// VolumeCondition is one of conditions of volume state
+type VolumeCondition struct {
+	Name  string
+	Value string
+	// size parsed from value of max-size
+	size uint64
+}
+
+// ParseVolumeConditions parses conditions in form of name=value
+func ParseVolumeConditions(conditions []string) ([]*VolumeCondition, error) {
+	var result []*VolumeCondition
+	for _, el := range conditions {
+		el = strings.TrimSpace(el)
+		if el == "" {
+			continue
+		}
+		name, value, _ := strings.Cut(el, "=")
+		if value == "" {
+			return nil, fmt.Errorf("no value for condition %s", name)
+		}
+		cond := &VolumeCondition{Name: name, Value: value}
+		switch name {
+		case VolumeConditionState:
+			cond.Value = strings.ToUpper(value)
+		case VolumeConditionMaxSize:
+			size, err := humanize.ParseBytes(value)
+			if err != nil {
+				return nil, fmt.Errorf("cannot parse size %s: %w", value, err)
+			}
+			cond.size = size
+		case VolumeConditionAttached, VolumeConditionDetached:
+		default:
+			return nil, fmt.Errorf("unknown condition %s", name)
+		}
+		result = append(result, cond)
+	}
+	if len(result) == 0 {
+		return nil, fmt.Errorf("no conditions provided")
+	}
+	return result, nil
+}
+
+func (cond *VolumeCondition) String() string {
+	return fmt.Sprintf("%s=%s", cond.Name, cond.Value)
+}
+
+// Check returns true if volume meets condition, apps are used to check attachment reported by EVE
+func (cond *VolumeCondition) Check(vol *VolInstState, apps []*AppInstState) bool {
+	switch cond.Name {
+	case VolumeConditionState:
+		// EveState may contain progress of download after the state name
+		state, _, _ := strings.Cut(vol.EveState, " ")
+		return state == cond.Value && vol.LastError == ""
+	case VolumeConditionMaxSize:
+		return vol.maxSizeBytes >= cond.size
+	case VolumeConditionAttached, VolumeConditionDetached:
+		attached := false
+		for _, app := range apps {
+			if app.Name != cond.Value {
+				continue
+			}
+			for _, ref := range app.VolumeRefs {
+				if ref == vol.UUID {
+					attached = true
+				}
+			}
+		}
+		return attached == (cond.Name == VolumeConditionAttached)
+	}
+	return false
+}
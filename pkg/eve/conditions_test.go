@@ -0,0 +1,71 @@
+package eve_test
+
+import (
+	"testing"
+
+	"github.com/lf-edge/eden/pkg/eve"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAppConditions(t *testing.T) {
+	t.Parallel()
+
+	conds, err := eve.ParseAppConditions([]string{"state=running", "ip-assigned"})
+	assert.NoError(t, err)
+	assert.Len(t, conds, 2)
+
+	app := &eve.AppInstState{EVEState: "RUNNING", InternalIP: []string{"-"}}
+	assert.True(t, conds[0].Check(app))
+	assert.False(t, conds[1].Check(app))
+
+	app.InternalIP = []string{"10.11.12.2"}
+	assert.True(t, conds[1].Check(app))
+
+	app.EVEState = "DOWNLOAD_STARTED (50%)"
+	assert.False(t, conds[0].Check(app))
+
+	_, err = eve.ParseAppConditions([]string{"state"})
+	assert.Error(t, err)
+	_, err = eve.ParseAppConditions([]string{"unknown"})
+	assert.Error(t, err)
+}
+
+func TestAppSettled(t *testing.T) {
+	t.Parallel()
+
+	for state, settled := range map[string]bool{
+		"RUNNING":                         true,
+		"HALTED":                          true,
+		"INSTALLED: no free IP addresses": true,
+		"DOWNLOAD_STARTED (50%)":          false,
+		"BOOTING":                         false,
+		"UNKNOWN":                         false,
+	} {
+		app := &eve.AppInstState{EVEState: state}
+		assert.Equal(t, settled, app.Settled(), state)
+	}
+}
+
+func TestVolumeConditions(t *testing.T) {
+	t.Parallel()
+
+	conds, err := eve.ParseVolumeConditions([]string{"state=created_volume", "attached=app1", "detached=app2"})
+	assert.NoError(t, err)
+	assert.Len(t, conds, 3)
+
+	vol := &eve.VolInstState{UUID: "v", EveState: "CREATED_VOLUME"}
+	apps := []*eve.AppInstState{{Name: "app1", VolumeRefs: []string{"v"}}, {Name: "app2"}}
+	for _, cond := range conds {
+		assert.True(t, cond.Check(vol, apps), cond.String())
+	}
+	apps[1].VolumeRefs = []string{"v"}
+	assert.False(t, conds[2].Check(vol, apps))
+
+	vol.EveState = "DOWNLOAD_STARTED (50%)"
+	assert.False(t, conds[0].Check(vol, apps))
+
+	_, err = eve.ParseVolumeConditions([]string{"max-size=big"})
+	assert.Error(t, err)
+	_, err = eve.ParseVolumeConditions([]string{"attached"})
+	assert.Error(t, err)
+}
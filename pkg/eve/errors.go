@@ -0,0 +1,94 @@
+package eve
+
+import (
+	"regexp"
+	"time"
+
+	"github.com/lf-edge/eve-api/go/info"
+)
+
+// reasons of errors reported by EVE
+const (
+	ErrorReasonSHAMismatch           = "sha-mismatch"
+	ErrorReasonNotFound              = "not-found"
+	ErrorReasonUnauthorized          = "unauthorized"
+	ErrorReasonInsufficientResources = "insufficient-resources"
+	ErrorReasonUnsupportedFormat     = "unsupported-format"
+	ErrorReasonDownloadFailed        = "download-failed"
+	ErrorReasonUnknown               = "unknown"
+)
+
+// order matters: the first matched pattern defines the reason
+var errorReasonPatterns = []struct {
+	reason string
+	re     *regexp.Regexp
+}{
+	// sha256 is a part of most image references, so it is a mismatch only with explicit wording
+	{ErrorReasonSHAMismatch, regexp.MustCompile(`(?i)(sha-?256\b.*(\bmismatch(es|ed)?\b|does not match)|(\bmismatch(es|ed)?\b|does not match).*\bsha-?256|\b(hash|checksum|digest) (mismatch|does not match)|computed (sha-?256|hash|checksum|digest)\b.*\bexpected\b)`)},
+	{ErrorReasonNotFound, regexp.MustCompile(`(?i)(not found|manifest unknown|no matching manifest|\bno such\b|\b404\b)`)},
+	{ErrorReasonUnauthorized, regexp.MustCompile(`(?i)(unauthorized|forbidden|authentication|\bdenied\b|\b401\b|\b403\b)`)},
+	// resource-specific wording only, "exceeded" alone is used by timeouts (e.g. context deadline exceeded)
+	{ErrorReasonInsufficientResources, regexp.MustCompile(`(?i)(insufficient|not enough|out of memory|no space|exceeds? (the )?(available|maximum|memory|capacity|quota|limit)|(quota|limit) exceeded)`)},
+	{ErrorReasonUnsupportedFormat, regexp.MustCompile(`(?i)(unsupported|unknown format|invalid format)`)},
+	{ErrorReasonDownloadFailed, regexp.MustCompile(`(?i)(download|fetch|timeout|timed out|deadline exceeded|connection refused|no route)`)},
+}
+
+// AppError stores error reported by EVE for app in structured form
+type AppError struct {
+	Reason         string
+	Description    string
+	Severity       string
+	RetryCondition string
+	Timestamp      time.Time
+}
+
+// IsErrorReason checks if reason is one of known reasons of errors
+func IsErrorReason(reason string) bool {
+	if reason == ErrorReasonUnknown {
+		return true
+	}
+	for _, el := range errorReasonPatterns {
+		if el.reason == reason {
+			return true
+		}
+	}
+	return false
+}
+
+// ErrorReason classifies description of error reported by EVE
+func ErrorReason(description string) string {
+	for _, el := range errorReasonPatterns {
+		if el.re.MatchString(description) {
+			return el.reason
+		}
+	}
+	return ErrorReasonUnknown
+}
+
+func parseAppErrors(errs []*info.ErrorInfo) []*AppError {
+	var result []*AppError
+	for _, el := range errs {
+		if el.GetDescription() == "" {
+			continue
+		}
+		result = append(result, &AppError{
+			Reason:         ErrorReason(el.GetDescription()),
+			Description:    el.GetDescription(),
+			Severity:       el.GetSeverity().String(),
+			RetryCondition: el.GetRetryCondition(),
+			Timestamp:      el.GetTimestamp().AsTime(),
+		})
+	}
+	return result
+}
+
+// ErrorsByReason returns errors of app with provided reason or all errors if reason is empty
+func (appStateObj *AppInstState) ErrorsByReason(reason string) []*AppError {
+	var result []*AppError
+	for _, el := range appStateObj.Errors {
+		if reason == "" || el.Reason == reason {
+			result = append(result, el)
+		}
+	}
+	return result
+}
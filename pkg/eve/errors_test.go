@@ -0,0 +1,160 @@
+package eve_test
+
+import (
+	"testing"
+
+	"github.com/lf-edge/eden/pkg/eve"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestErrorReason(t *testing.T) {
+	t.Parallel()
+
+	testMatrix := map[string]struct {
+		description string
+		reason      string
+	}{
+		"sha mismatch": {
+			description: "verifyObjectSha: computed sha256 4d8f... does not match expected 1c3b...",
+			reason:      eve.ErrorReasonSHAMismatch,
+		},
+		"manifest unknown": {
+			description: "GET https://index.docker.io/v2/library/none/manifests/latest: MANIFEST_UNKNOWN: manifest unknown",
+			reason:      eve.ErrorReasonNotFound,
+		},
+		"unauthorized": {
+			description: "GET https://registry/v2/token: UNAUTHORIZED: authentication required",
+			reason:      eve.ErrorReasonUnauthorized,
+		},
+		"memory": {
+			description: "insufficient memory to start app instance",
+			reason:      eve.ErrorReasonInsufficientResources,
+		},
+		"unclassified": {
+			description: "something went wrong",
+			reason:      eve.ErrorReasonUnknown,
+		},
+		"no space": {
+			description: "write /persist/vault/volumes/file: no space left on device",
+			reason:      eve.ErrorReasonInsufficientResources,
+		},
+		"404 inside port": {
+			description: "dial tcp 10.0.0.1:14040: connect: connection refused",
+			reason:      eve.ErrorReasonDownloadFailed,
+		},
+		"403 inside digest": {
+			description: "failed to fetch blob 2a403f9c",
+			reason:      eve.ErrorReasonDownloadFailed,
+		},
+		"404 status": {
+			description: "GET https://registry/v2/app/blobs/sha256:abc: 404 Not Found",
+			reason:      eve.ErrorReasonNotFound,
+		},
+		"no such file": {
+			description: "open /persist/img/app.qcow2: no such file or directory",
+			reason:      eve.ErrorReasonNotFound,
+		},
+		"no suchlike word": {
+			description: "nosuch_volume is in bad state",
+			reason:      eve.ErrorReasonUnknown,
+		},
+		"permission denied": {
+			description: "open /persist/vault/volumes/file: permission denied",
+			reason:      eve.ErrorReasonUnauthorized,
+		},
+		"denied inside word": {
+			description: "undeniedvolume is in bad state",
+			reason:      eve.ErrorReasonUnknown,
+		},
+		"401 inside size": {
+			description: "volume of 24015 bytes is in bad state",
+			reason:      eve.ErrorReasonUnknown,
+		},
+		"deadline exceeded": {
+			description: "context deadline exceeded",
+			reason:      eve.ErrorReasonDownloadFailed,
+		},
+		"exceeds memory": {
+			description: "app instance memory 8G exceeds available memory 4G",
+			reason:      eve.ErrorReasonInsufficientResources,
+		},
+		"quota exceeded": {
+			description: "disk quota exceeded",
+			reason:      eve.ErrorReasonInsufficientResources,
+		},
+		"sha256 reference without matching manifest": {
+			description: "docker.io/lfedge/app@sha256:4d8f3c: no matching manifest for linux/arm64 in the manifest list entries",
+			reason:      eve.ErrorReasonNotFound,
+		},
+		"sha256 reference of unauthorized blob": {
+			description: "GET https://registry/v2/app/blobs/sha256:4d8f3c: UNAUTHORIZED: authentication required",
+			reason:      eve.ErrorReasonUnauthorized,
+		},
+		"sha256 reference with denied pull": {
+			description: "pull access denied for app@sha256:4d8f3c, repository does not exist or may require 'docker login'",
+			reason:      eve.ErrorReasonUnauthorized,
+		},
+		"sha256 reference with forbidden status": {
+			description: "failed to resolve sha256:4d8f3c: 403 Forbidden",
+			reason:      eve.ErrorReasonUnauthorized,
+		},
+		"sha256 mismatch": {
+			description: "sha256 mismatch: got 4d8f3c, want 1c3b9a",
+			reason:      eve.ErrorReasonSHAMismatch,
+		},
+		"mismatched sha256": {
+			description: "image content mismatched sha256 of the manifest",
+			reason:      eve.ErrorReasonSHAMismatch,
+		},
+		"checksum mismatch": {
+			description: "checksum mismatch for volume 1c3b9a",
+			reason:      eve.ErrorReasonSHAMismatch,
+		},
+		"digest does not match": {
+			description: "digest does not match content of blob",
+			reason:      eve.ErrorReasonSHAMismatch,
+		},
+		"checksum without mismatch": {
+			description: "checksum of volume is being verified",
+			reason:      eve.ErrorReasonUnknown,
+		},
+		"match inside word": {
+			description: "sha256:4d8f3c rematched to other platform",
+			reason:      eve.ErrorReasonUnknown,
+		},
+		"computed size": {
+			description: "computed size 10 differs from expected 12 of the volume",
+			reason:      eve.ErrorReasonUnknown,
+		},
+		"download": {
+			description: "download failed: dial tcp 10.0.0.1:8888: connect: connection refused",
+			reason:      eve.ErrorReasonDownloadFailed,
+		},
+	}
+
+	for name, tt := range testMatrix {
+		tt := tt
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			assert.Equal(t, tt.reason, eve.ErrorReason(tt.description))
+		})
+	}
+}
+
+func TestIsErrorReason(t *testing.T) {
+	t.Parallel()
+
+	for _, reason := range []string{
+		eve.ErrorReasonSHAMismatch,
+		eve.ErrorReasonNotFound,
+		eve.ErrorReasonUnauthorized,
+		eve.ErrorReasonInsufficientResources,
+		eve.ErrorReasonUnsupportedFormat,
+		eve.ErrorReasonDownloadFailed,
+		eve.ErrorReasonUnknown,
+	} {
+		assert.True(t, eve.IsErrorReason(reason), reason)
+	}
+	assert.False(t, eve.IsErrorReason("not_found"))
+	assert.False(t, eve.IsErrorReason("timeout"))
+}
@@ -0,0 +1,263 @@
+package eve
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/lf-edge/eve-api/go/info"
+	log "github.com/sirupsen/logrus"
+)
+
+// EventType is kind of change of EVE state
+type EventType string
+
+// types of events delivered to subscribers
+const (
+	// EventAppState is transition of app to another state reported by EVE
+	EventAppState EventType = "AppState"
+	// EventVolumeState is transition of volume to another state reported by EVE
+	EventVolumeState EventType = "VolumeState"
+	// EventVolumeError is new error of volume or its content tree
+	EventVolumeError EventType = "VolumeError"
+	// EventNetworkState is transition of network instance to another state
+	EventNetworkState EventType = "NetworkState"
+	// EventReboot is change of boot time of the device
+	EventReboot EventType = "Reboot"
+	// EventPortChange is change of link state or addresses of the device port
+	EventPortChange EventType = "PortChange"
+)
+
+// EventTypes lists all types of events
+var EventTypes = []EventType{EventAppState, EventVolumeState, EventVolumeError,
+	EventNetworkState, EventReboot, EventPortChange}
+
+// Event describes change of EVE state detected in info message
+type Event struct {
+	Type EventType
+	// Time is timestamp of info message with the change
+	Time time.Time
+	// Name of app, volume, network instance or port
+	Name string
+	// UUID of app, volume or network instance
+	UUID string
+	Old  string
+	New  string
+}
+
+// String returns human-readable form of event
+func (e Event) String() string {
+	if e.Name == "" {
+		return fmt.Sprintf("%s: %q -> %q", e.Type, e.Old, e.New)
+	}
+	return fmt.Sprintf("%s %s: %q -> %q", e.Type, e.Name, e.Old, e.New)
+}
+
+// EventHandler is called for every event of subscribed types
+type EventHandler func(Event)
+
+type subscription struct {
+	handler EventHandler
+	types   map[EventType]bool
+}
+
+func (s *subscription) wants(t EventType) bool {
+	return len(s.types) == 0 || s.types[t]
+}
+
+// subscribers keeps handlers of events of State
+type subscribers struct {
+	mu     sync.Mutex
+	nextID int
+	subs   map[int]*subscription
+}
+
+// Subscribe calls handler for events of types (all types if not set) detected in info
+// messages fed into state with InfoCallback. Handler is called from the callback, so it
+// should not block. Returned function cancels the subscription.
+func (ctx *State) Subscribe(handler EventHandler, types ...EventType) (unsubscribe func()) {
+	sub := &subscription{handler: handler, types: map[EventType]bool{}}
+	for _, t := range types {
+		sub.types[t] = true
+	}
+	ctx.subscribers.mu.Lock()
+	defer ctx.subscribers.mu.Unlock()
+	if ctx.subscribers.subs == nil {
+		ctx.subscribers.subs = map[int]*subscription{}
+	}
+	id := ctx.subscribers.nextID
+	ctx.subscribers.nextID++
+	ctx.subscribers.subs[id] = sub
+	return func() {
+		ctx.subscribers.mu.Lock()
+		defer ctx.subscribers.mu.Unlock()
+		delete(ctx.subscribers.subs, id)
+	}
+}
+
+// Watch returns channel delivering events of types (all types if not set), events are
+// dropped if the buffer is full. Call stop to unsubscribe and close the channel.
+func (ctx *State) Watch(buffer int, types ...EventType) (events <-chan Event, stop func()) {
+	ch := make(chan Event, buffer)
+	var mu sync.Mutex
+	closed := false
+	unsubscribe := ctx.Subscribe(func(e Event) {
+		mu.Lock()
+		defer mu.Unlock()
+		if closed {
+			return
+		}
+		select {
+		case ch <- e:
+		default:
+			log.Warnf("event dropped, watcher is too slow: %s", e)
+		}
+	}, types...)
+	return ch, func() {
+		unsubscribe()
+		mu.Lock()
+		defer mu.Unlock()
+		if !closed {
+			closed = true
+			close(ch)
+		}
+	}
+}
+
+func (ctx *State) hasSubscribers() bool {
+	ctx.subscribers.mu.Lock()
+	defer ctx.subscribers.mu.Unlock()
+	return len(ctx.subscribers.subs) > 0
+}
+
+// publish delivers events to subscribers, handlers are called without lock,
+// so they may unsubscribe
+func (ctx *State) publish(events []Event) {
+	if len(events) == 0 {
+		return
+	}
+	ctx.subscribers.mu.Lock()
+	ids := make([]int, 0, len(ctx.subscribers.subs))
+	for id := range ctx.subscribers.subs {
+		ids = append(ids, id)
+	}
+	sort.Ints(ids)
+	subs := make([]*subscription, 0, len(ids))
+	for _, id := range ids {
+		subs = append(subs, ctx.subscribers.subs[id])
+	}
+	ctx.subscribers.mu.Unlock()
+	for _, e := range events {
+		for _, sub := range subs {
+			if sub.wants(e.Type) {
+				sub.handler(e)
+			}
+		}
+	}
+}
+
+type objectSnapshot struct {
+	name  string
+	state string
+	err   string
+}
+
+// stateSnapshot keeps parts of state which changes are reported as events
+type stateSnapshot struct {
+	apps     map[string]objectSnapshot
+	volumes  map[string]objectSnapshot
+	networks map[string]objectSnapshot
+	bootTime time.Time
+	ports    map[string]string
+}
+
+func (ctx *State) snapshot() *stateSnapshot {
+	s := &stateSnapshot{
+		apps:     map[string]objectSnapshot{},
+		volumes:  map[string]objectSnapshot{},
+		networks: map[string]objectSnapshot{},
+		ports:    map[string]string{},
+	}
+	for id, app := range ctx.applications {
+		s.apps[id] = objectSnapshot{name: app.Name, state: app.EVEState}
+	}
+	for id, vol := range ctx.volumes {
+		s.volumes[id] = objectSnapshot{name: vol.Name, state: vol.EveState, err: vol.LastError}
+	}
+	for id, ni := range ctx.networks {
+		s.networks[id] = objectSnapshot{name: ni.Name, state: ni.EveState}
+	}
+	if dinfo := ctx.infoAndMetrics.GetDinfo(); dinfo != nil {
+		if dinfo.GetBootTime() != nil {
+			s.bootTime = dinfo.GetBootTime().AsTime()
+		}
+		for _, port := range dinfo.GetNetwork() {
+			s.ports[port.GetDevName()] = portState(port)
+		}
+	}
+	return s
+}
+
+func portState(port *info.ZInfoNetwork) string {
+	state := "DOWN"
+	if port.GetIpv4Up() {
+		state = "UP"
+	}
+	if len(port.GetIPAddrs()) > 0 {
+		state += " " + strings.Join(port.GetIPAddrs(), ",")
+	}
+	if errDescription := port.GetNetworkErr().GetDescription(); errDescription != "" {
+		state += " ERROR: " + errDescription
+	}
+	return state
+}
+
+func stateEvents(t EventType, ts time.Time, before, after map[string]objectSnapshot) (events []Event) {
+	for id, obj := range after {
+		old := before[id]
+		if obj.state != old.state {
+			events = append(events, Event{Type: t, Time: ts, Name: obj.name, UUID: id, Old: old.state, New: obj.state})
+		}
+	}
+	return events
+}
+
+// diff returns events for changes from snapshot s to the next one
+func (s *stateSnapshot) diff(next *stateSnapshot, ts time.Time) []Event {
+	var events []Event
+	events = append(events, stateEvents(EventAppState, ts, s.apps, next.apps)...)
+	events = append(events, stateEvents(EventVolumeState, ts, s.volumes, next.volumes)...)
+	for id, vol := range next.volumes {
+		if old := s.volumes[id]; vol.err != "" && vol.err != old.err {
+			events = append(events, Event{Type: EventVolumeError, Time: ts, Name: vol.name, UUID: id, Old: old.err, New: vol.err})
+		}
+	}
+	events = append(events, stateEvents(EventNetworkState, ts, s.networks, next.networks)...)
+	if !s.bootTime.IsZero() && !next.bootTime.Equal(s.bootTime) {
+		events = append(events, Event{Type: EventReboot, Time: ts,
+			Old: s.bootTime.Format(time.RFC3339), New: next.bootTime.Format(time.RFC3339)})
+	}
+	// ports are reported only in device info, so missing ports mean no device info yet
+	if len(next.ports) > 0 {
+		for name, state := range next.ports {
+			if old := s.ports[name]; old != state {
+				events = append(events, Event{Type: EventPortChange, Time: ts, Name: name, Old: old, New: state})
+			}
+		}
+		for name, old := range s.ports {
+			if _, ok := next.ports[name]; !ok {
+				events = append(events, Event{Type: EventPortChange, Time: ts, Name: name, Old: old, New: ""})
+			}
+		}
+	}
+	// deliver events in stable order
+	sort.SliceStable(events, func(i, j int) bool {
+		if events[i].Type != events[j].Type {
+			return events[i].Type < events[j].Type
+		}
+		return events[i].Name < events[j].Name
+	})
+	return events
+}
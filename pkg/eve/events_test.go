@@ -0,0 +1,83 @@
+package eve_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/lf-edge/eden/pkg/device"
+	"github.com/lf-edge/eden/pkg/eve"
+	"github.com/lf-edge/eve-api/go/info"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func deviceInfo(devID string, bootTime time.Time, up bool) *info.ZInfoMsg {
+	return &info.ZInfoMsg{
+		Ztype:       info.ZInfoTypes_ZiDevice,
+		DevId:       devID,
+		AtTimeStamp: timestamppb.Now(),
+		InfoContent: &info.ZInfoMsg_Dinfo{Dinfo: &info.ZInfoDevice{
+			BootTime: timestamppb.New(bootTime),
+			Network:  []*info.ZInfoNetwork{{DevName: "eth0", Ipv4Up: up, IPAddrs: []string{"192.168.0.10"}}},
+		}},
+	}
+}
+
+func TestEvents(t *testing.T) {
+	t.Parallel()
+
+	dev := device.CreateEdgeNode()
+	state := eve.Init(nil, dev)
+	callback := state.InfoCallback()
+	bootTime := time.Now().Add(-time.Hour)
+
+	// no subscribers
+	callback(deviceInfo(dev.GetID().String(), bootTime, true))
+
+	var all []eve.Event
+	unsubscribe := state.Subscribe(func(e eve.Event) { all = append(all, e) })
+	events, stop := state.Watch(10, eve.EventReboot)
+
+	callback(deviceInfo(dev.GetID().String(), bootTime, false))
+	if assert.Len(t, all, 1) {
+		assert.Equal(t, eve.EventPortChange, all[0].Type)
+		assert.Equal(t, "eth0", all[0].Name)
+		assert.Equal(t, "UP 192.168.0.10", all[0].Old)
+		assert.Equal(t, "DOWN 192.168.0.10", all[0].New)
+	}
+
+	callback(&info.ZInfoMsg{
+		Ztype:       info.ZInfoTypes_ZiNetworkInstance,
+		DevId:       dev.GetID().String(),
+		AtTimeStamp: timestamppb.Now(),
+		InfoContent: &info.ZInfoMsg_Niinfo{Niinfo: &info.ZInfoNetworkInstance{
+			NetworkID:   "ni-id",
+			Displayname: "ni",
+			Activated:   true,
+			State:       info.ZNetworkInstanceState_ZNETINST_STATE_ONLINE,
+		}},
+	})
+	if assert.Len(t, all, 2) {
+		assert.Equal(t, eve.EventNetworkState, all[1].Type)
+		assert.Equal(t, "ni", all[1].Name)
+		assert.Equal(t, "ni-id", all[1].UUID)
+		assert.Equal(t, "ACTIVATED", all[1].New)
+	}
+	// the same state again is not a change
+	unsubscribe()
+	callback(deviceInfo(dev.GetID().String(), bootTime, false))
+	assert.Len(t, all, 2)
+
+	callback(deviceInfo(dev.GetID().String(), time.Now(), false))
+	select {
+	case e := <-events:
+		assert.Equal(t, eve.EventReboot, e.Type)
+	default:
+		t.Error("no reboot event")
+	}
+	stop()
+	_, ok := <-events
+	assert.False(t, ok)
+	// no panic after stop
+	callback(deviceInfo(dev.GetID().String(), time.Now().Add(time.Hour), false))
+}
@@ -22,8 +22,10 @@ type State struct {
 	applications   map[string]*AppInstState
 	networks       map[string]*NetInstState
 	volumes        map[string]*VolInstState
+	accelerators   map[string]*AccelState
 	infoAndMetrics *projects.State
 	device         *device.Ctx
+	subscribers    subscribers
 }
 
 // Init State object with controller and device
@@ -31,6 +33,7 @@ func Init(ctrl controller.Cloud, dev *device.Ctx) (ctx *State) {
 	ctx = &State{device: dev, infoAndMetrics: projects.InitState(dev)}
 	ctx.applications = make(map[string]*AppInstState)
 	ctx.networks = make(map[string]*NetInstState)
+	ctx.accelerators = make(map[string]*AccelState)
 	if err := ctx.initApplications(ctrl, dev); err != nil {
 		log.Fatalf("EVE State initApplications error: %s", err)
 	}
@@ -84,12 +87,20 @@ func (ctx *State) Volumes() []*VolInstState {
 // InfoCallback should be assigned to feed new values from info messages into state
 func (ctx *State) InfoCallback() einfo.HandlerFunc {
 	return func(msg *info.ZInfoMsg) bool {
+		var before *stateSnapshot
+		if ctx.hasSubscribers() {
+			before = ctx.snapshot()
+		}
 		ctx.processVolumesByInfo(msg)
 		ctx.processApplicationsByInfo(msg)
 		ctx.processNetworksByInfo(msg)
+		ctx.processAcceleratorsByInfo(msg)
 		if err := ctx.infoAndMetrics.GetInfoProcessingFunction()(msg); err != nil {
 			log.Fatalf("EVE State GetInfoProcessingFunction error: %s", err)
 		}
+		if before != nil {
+			ctx.publish(before.diff(ctx.snapshot(), msg.GetAtTimeStamp().AsTime()))
+		}
 		return false
 	}
 }
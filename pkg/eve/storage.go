@@ -0,0 +1,173 @@
+package eve
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/dustin/go-humanize"
+	"github.com/lf-edge/eve-api/go/metrics"
+)
+
+// StorageLevel is result of check of storage utilization against thresholds
+type StorageLevel int
+
+// levels of storage utilization
+const (
+	StorageOK StorageLevel = iota
+	StorageWarn
+	StorageFail
+)
+
+// StorageThresholds defines percents of used capacity to warn about and to consider
+// as failure, zero value disables the threshold. Any I/O errors are failures.
+type StorageThresholds struct {
+	Warn float64
+	Fail float64
+}
+
+// Check returns level of storage with used and total bytes and errors
+func (t StorageThresholds) Check(used, total uint64, hasErrors bool) StorageLevel {
+	if hasErrors {
+		return StorageFail
+	}
+	if total == 0 {
+		return StorageOK
+	}
+	percent := float64(used) * 100 / float64(total)
+	switch {
+	case t.Fail > 0 && percent >= t.Fail:
+		return StorageFail
+	case t.Warn > 0 && percent >= t.Warn:
+		return StorageWarn
+	}
+	return StorageOK
+}
+
+// StorageUsage is utilization of disk, partition, ZFS pool or volume of the device
+type StorageUsage struct {
+	// Name of disk (e.g. sda1), zpool (with zfs: prefix) or volume
+	Name string
+	// UUID of volume
+	UUID string
+	// MountPath of disk
+	MountPath string
+	Total     uint64
+	Used      uint64
+	// Errors contains I/O errors reported by ZFS or last error of volume
+	Errors string
+}
+
+// UsedPercent returns percent of used capacity
+func (u *StorageUsage) UsedPercent() float64 {
+	if u.Total == 0 {
+		return 0
+	}
+	return float64(u.Used) * 100 / float64(u.Total)
+}
+
+// Level returns level of utilization with thresholds
+func (u *StorageUsage) Level(t StorageThresholds) StorageLevel {
+	return t.Check(u.Used, u.Total, u.Errors != "")
+}
+
+// String returns human-readable form of utilization
+func (u *StorageUsage) String() string {
+	usage := "unknown capacity"
+	if u.Total > 0 {
+		usage = fmt.Sprintf("%s/%s (%.0f%%)", humanize.Bytes(u.Used), humanize.Bytes(u.Total), u.UsedPercent())
+	} else if u.Used > 0 {
+		usage = fmt.Sprintf("%s used", humanize.Bytes(u.Used))
+	}
+	name := u.Name
+	if u.MountPath != "" {
+		name = fmt.Sprintf("%s (%s)", u.Name, u.MountPath)
+	}
+	if u.Errors != "" {
+		return fmt.Sprintf("%s: %s, errors: %s", name, usage, u.Errors)
+	}
+	return fmt.Sprintf("%s: %s", name, usage)
+}
+
+// vdevErrors returns description of I/O errors of ZFS device
+func vdevErrors(m *metrics.StorageVDevMetrics) string {
+	if m.GetReadErrors()+m.GetWriteErrors()+m.GetChecksumErrors() == 0 {
+		return ""
+	}
+	return fmt.Sprintf("read %d, write %d, checksum %d",
+		m.GetReadErrors(), m.GetWriteErrors(), m.GetChecksumErrors())
+}
+
+// DisksUsage returns utilization of mounted disks and ZFS pools of the device
+// from the last device metrics
+func (ctx *State) DisksUsage() []*StorageUsage {
+	deviceMetrics := ctx.infoAndMetrics.GetDeviceMetrics()
+	if deviceMetrics == nil {
+		return nil
+	}
+	var result []*StorageUsage
+	byName := map[string]*StorageUsage{}
+	for _, disk := range deviceMetrics.GetDisk() {
+		// capacity is known only for mounted disks
+		if disk.GetMountPath() == "" || disk.GetTotal() == 0 {
+			continue
+		}
+		usage := &StorageUsage{
+			Name:      disk.GetDisk(),
+			MountPath: disk.GetMountPath(),
+			Total:     disk.GetTotal() * humanize.MByte,
+			Used:      disk.GetUsed() * humanize.MByte,
+		}
+		byName[filepath.Base(disk.GetDisk())] = usage
+		result = append(result, usage)
+	}
+	for _, pool := range deviceMetrics.GetStorageMetrics() {
+		zpool := pool.GetZpoolMetrics()
+		result = append(result, &StorageUsage{
+			Name:   "zfs:" + pool.GetPoolName(),
+			Total:  zpool.GetTotal(),
+			Used:   zpool.GetAlloc(),
+			Errors: vdevErrors(zpool),
+		})
+		disks := append([]*metrics.StorageDiskMetric{}, pool.GetDisks()...)
+		for _, child := range pool.GetChildrenDatasets() {
+			disks = append(disks, child.GetDisks()...)
+		}
+		for _, disk := range disks {
+			errs := vdevErrors(disk.GetMetrics())
+			if errs == "" {
+				continue
+			}
+			name := disk.GetDiskName().GetName()
+			if usage, ok := byName[filepath.Base(name)]; ok {
+				usage.Errors = errs
+				continue
+			}
+			result = append(result, &StorageUsage{Name: name, Errors: errs})
+		}
+	}
+	return result
+}
+
+// VolumesUsage returns utilization of volumes from the last metrics and errors from info
+func (ctx *State) VolumesUsage() []*StorageUsage {
+	var result []*StorageUsage
+	for _, vol := range ctx.Volumes() {
+		total := vol.totalBytes
+		if total == 0 {
+			total = vol.maxSizeBytes
+		}
+		result = append(result, &StorageUsage{
+			Name:   vol.Name,
+			UUID:   vol.UUID,
+			Total:  total,
+			Used:   vol.usedBytes,
+			Errors: strings.TrimSpace(vol.LastError),
+		})
+	}
+	sort.SliceStable(result, func(i, j int) bool {
+		return result[i].Name < result[j].Name
+	})
+	return result
+}
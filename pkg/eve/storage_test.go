@@ -0,0 +1,73 @@
+package eve_test
+
+import (
+	"testing"
+
+	"github.com/dustin/go-humanize"
+	"github.com/lf-edge/eden/pkg/device"
+	"github.com/lf-edge/eden/pkg/eve"
+	"github.com/lf-edge/eve-api/go/evecommon"
+	"github.com/lf-edge/eve-api/go/info"
+	"github.com/lf-edge/eve-api/go/metrics"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func TestStorageUsage(t *testing.T) {
+	t.Parallel()
+
+	dev := device.CreateEdgeNode()
+	state := eve.Init(nil, dev)
+	assert.Nil(t, state.DisksUsage())
+
+	state.InfoCallback()(&info.ZInfoMsg{
+		Ztype:       info.ZInfoTypes_ZiVolume,
+		DevId:       dev.GetID().String(),
+		AtTimeStamp: timestamppb.Now(),
+		InfoContent: &info.ZInfoMsg_Vinfo{Vinfo: &info.ZInfoVolume{
+			Uuid:        "vol-id",
+			DisplayName: "vol",
+			State:       info.ZSwState_INSTALLED,
+		}},
+	})
+	state.MetricCallback()(&metrics.ZMetricMsg{
+		DevID:       dev.GetID().String(),
+		AtTimeStamp: timestamppb.Now(),
+		MetricContent: &metrics.ZMetricMsg_Dm{Dm: &metrics.DeviceMetric{
+			Disk: []*metrics.DiskMetric{
+				{Disk: "sda9", MountPath: "/persist", Total: 1000, Used: 850},
+				{Disk: "sda1", MountPath: "/config", Total: 100, Used: 10},
+				{Disk: "sda"},
+			},
+			StorageMetrics: []*metrics.StorageMetric{{
+				PoolName:     "persist",
+				ZpoolMetrics: &metrics.StorageVDevMetrics{Total: 1000, Alloc: 990},
+				Disks: []*metrics.StorageDiskMetric{{
+					DiskName: &evecommon.DiskDescription{Name: "/dev/sdb"},
+					Metrics:  &metrics.StorageVDevMetrics{ChecksumErrors: 2},
+				}},
+			}},
+		}},
+		Vm: []*metrics.ZMetricVolume{{Uuid: "vol-id", TotalBytes: 1000, UsedBytes: 100}},
+	})
+
+	thresholds := eve.StorageThresholds{Warn: 80, Fail: 95}
+	disks := state.DisksUsage()
+	if assert.Len(t, disks, 4) {
+		assert.Equal(t, "sda9", disks[0].Name)
+		assert.Equal(t, uint64(1000*humanize.MByte), disks[0].Total)
+		assert.Equal(t, eve.StorageWarn, disks[0].Level(thresholds))
+		assert.Equal(t, eve.StorageOK, disks[1].Level(thresholds))
+		assert.Equal(t, "zfs:persist", disks[2].Name)
+		assert.Equal(t, eve.StorageFail, disks[2].Level(thresholds))
+		assert.Equal(t, "/dev/sdb", disks[3].Name)
+		assert.Contains(t, disks[3].Errors, "checksum 2")
+		assert.Equal(t, eve.StorageFail, disks[3].Level(eve.StorageThresholds{}))
+	}
+	volumes := state.VolumesUsage()
+	if assert.Len(t, volumes, 1) {
+		assert.Equal(t, "vol", volumes[0].Name)
+		assert.Equal(t, 10.0, volumes[0].UsedPercent())
+		assert.Equal(t, eve.StorageOK, volumes[0].Level(thresholds))
+	}
+}
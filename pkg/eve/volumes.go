@@ -33,6 +33,9 @@ type VolInstState struct {
 	MountPoint    string
 	OriginType    string
 	deleted       bool
+	usedBytes     uint64
+	totalBytes    uint64
+	maxSizeBytes  uint64
 }
 
 func volInstStateHeader() string {
@@ -130,6 +133,7 @@ func (ctx *State) processVolumesByInfo(im *info.ZInfoMsg) {
 				//MaxSizeBytes to show in MAX_SIZE column
 				if maxSize := infoObject.GetResources().GetMaxSizeBytes(); maxSize > 0 {
 					volInstStateObj.MaxSize = humanize.Bytes(maxSize)
+					volInstStateObj.maxSizeBytes = maxSize
 				}
 			}
 		}
@@ -165,6 +169,8 @@ func (ctx *State) processVolumesByMetric(msg *metrics.ZMetricMsg) {
 			volInstStateObj, ok := ctx.volumes[volumeMetric.GetUuid()]
 			if ok {
 				volInstStateObj.Size = humanize.Bytes(volumeMetric.GetUsedBytes())
+				volInstStateObj.usedBytes = volumeMetric.GetUsedBytes()
+				volInstStateObj.totalBytes = volumeMetric.GetTotalBytes()
 			}
 		}
 	}
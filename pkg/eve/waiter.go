@@ -0,0 +1,130 @@
+package eve
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/lf-edge/eden/pkg/controller"
+	"github.com/lf-edge/eden/pkg/controller/einfo"
+	"github.com/lf-edge/eden/pkg/defaults"
+	"github.com/lf-edge/eden/pkg/device"
+	"github.com/lf-edge/eve-api/go/info"
+	log "github.com/sirupsen/logrus"
+)
+
+// WaitCondition checks if state of EVE is the expected one
+type WaitCondition func(state *State) bool
+
+// Waiter blocks until state of EVE meets condition. The condition is checked on every new info
+// message streamed from controller, so transitions are detected as soon as EVE reports them.
+// Messages may be missed by the stream (e.g. on reconnect of loader), so the state is also
+// reloaded from the last info messages with exponential backoff from MinInterval to MaxInterval.
+type Waiter struct {
+	MinInterval time.Duration
+	MaxInterval time.Duration
+	// StreamOnly disables loading of the last info messages,
+	// so condition is checked only on messages received after start of Wait
+	StreamOnly bool
+
+	ctrl  controller.Cloud
+	dev   *device.Ctx
+	state *State
+	// mu serializes feeding of state and checks of condition
+	mu sync.Mutex
+}
+
+// NewWaiter returns Waiter for state of device
+func NewWaiter(ctrl controller.Cloud, dev *device.Ctx, state *State) *Waiter {
+	return &Waiter{
+		MinInterval: time.Second,
+		MaxInterval: defaults.DefaultRepeatTimeout * 6,
+		ctrl:        ctrl,
+		dev:         dev,
+		state:       state,
+	}
+}
+
+// check reloads state from controller if requested and checks condition
+func (w *Waiter) check(cond WaitCondition, reload bool) (bool, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if reload {
+		if err := w.ctrl.InfoLastCallback(w.dev.GetID(), nil, w.state.InfoCallback()); err != nil {
+			return false, fmt.Errorf("InfoLastCallback: %w", err)
+		}
+	}
+	return cond(w.state), nil
+}
+
+// Wait blocks until cond returns true or timeout expires. Condition is called with state
+// locked, so it must not feed the state itself.
+func (w *Waiter) Wait(cond WaitCondition, timeout time.Duration) error {
+	if !w.StreamOnly {
+		if ok, err := w.check(cond, true); err != nil || ok {
+			return err
+		}
+	}
+	deadline := time.Now().Add(timeout)
+	met := make(chan struct{})
+	stop := make(chan struct{})
+	// condition is not called after return, so caller may use its results
+	defer func() {
+		w.mu.Lock()
+		close(stop)
+		w.mu.Unlock()
+	}()
+	streamDone := make(chan error, 1)
+	go func() {
+		var once sync.Once
+		handler := func(msg *info.ZInfoMsg) bool {
+			w.mu.Lock()
+			defer w.mu.Unlock()
+			select {
+			case <-stop:
+				return true
+			default:
+			}
+			w.state.InfoCallback()(msg)
+			if cond(w.state) {
+				once.Do(func() { close(met) })
+				return true
+			}
+			return false
+		}
+		streamDone <- w.ctrl.InfoChecker(w.dev.GetID(), nil, handler, einfo.InfoNew, timeout)
+	}()
+	interval := w.MinInterval
+	for {
+		remaining := time.Until(deadline)
+		if remaining <= 0 {
+			return fmt.Errorf("condition not met in %s", timeout)
+		}
+		if interval > remaining {
+			interval = remaining
+		}
+		timer := time.NewTimer(interval)
+		select {
+		case <-met:
+			timer.Stop()
+			return nil
+		case err := <-streamDone:
+			timer.Stop()
+			if err != nil {
+				log.Debugf("streaming of info stopped, polling only: %s", err)
+			}
+			// stream is over, receive from nil channel blocks forever
+			streamDone = nil
+		case <-timer.C:
+			if !w.StreamOnly {
+				ok, err := w.check(cond, true)
+				if err != nil || ok {
+					return err
+				}
+			}
+			if interval *= 2; interval > w.MaxInterval {
+				interval = w.MaxInterval
+			}
+		}
+	}
+}
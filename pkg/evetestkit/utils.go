@@ -254,7 +254,7 @@ func (node *EveNode) AppSSHExec(appName, command string) (string, error) {
 		return "", fmt.Errorf("app %s not found, make sure to deploy app/vm with WithSSH option", appName)
 	}
 
-	host := fmt.Sprintf("%s:%s", node.ip, appConfig.sshPort)
+	host := net.JoinHostPort(node.ip, appConfig.sshPort)
 
 	config := &ssh.ClientConfig{
 		User: appConfig.sshUser,
@@ -291,7 +291,7 @@ func (node *EveNode) AppSCPCopy(appName, localFile, remoteFile string) error {
 		return fmt.Errorf("app %s not found, make sure to deploy app/vm with WithSSH option", appName)
 	}
 
-	host := fmt.Sprintf("%s:%s", node.ip, appConfig.sshPort)
+	host := net.JoinHostPort(node.ip, appConfig.sshPort)
 
 	config := &ssh.ClientConfig{
 		User: appConfig.sshUser,
@@ -389,6 +389,7 @@ func GetDefaultVMConfig(appName, cloudConfig string, portPub []string) openevec.
 	pc.NoHyper = false
 	pc.DirectLoad = true
 	pc.SftpLoad = false
+	pc.S3Load = false
 	pc.Disks = nil
 	pc.Mount = nil
 	pc.Profiles = nil
@@ -0,0 +1,77 @@
+package expect
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/lf-edge/eve-api/go/config"
+	"github.com/lf-edge/eve-api/go/evecommon"
+)
+
+// physicalIOs returns adapters of device model in order of device config
+func (exp *AppExpectation) physicalIOs() []*config.PhysicalIO {
+	var physicalIOs []*config.PhysicalIO
+	if exp.device == nil {
+		return nil
+	}
+	for _, id := range exp.device.GetPhysicalIOs() {
+		physicalIO, err := exp.ctrl.GetPhysicalIO(id)
+		if err != nil {
+			continue
+		}
+		physicalIOs = append(physicalIOs, physicalIO)
+	}
+	return physicalIOs
+}
+
+// adapters returns adapters of device assigned to app, types of adapters are taken from device model
+func (exp *AppExpectation) adapters() ([]*config.Adapter, error) {
+	physicalIOs := exp.physicalIOs()
+	byLabel := make(map[string]*config.PhysicalIO, len(physicalIOs))
+	for _, physicalIO := range physicalIOs {
+		byLabel[physicalIO.Logicallabel] = physicalIO
+	}
+	var adapters []*config.Adapter
+	added := map[string]bool{}
+	add := func(name string, adapterType evecommon.PhyIoType) {
+		if added[name] {
+			return
+		}
+		added[name] = true
+		adapters = append(adapters, &config.Adapter{
+			Type: adapterType,
+			Name: name,
+		})
+	}
+	for _, adapterName := range exp.appAdapters {
+		if physicalIO, ok := byLabel[adapterName]; ok {
+			add(adapterName, physicalIO.Ptype)
+			continue
+		}
+		adapterType := evecommon.PhyIoType_PhyIoUSB
+		if strings.HasPrefix(adapterName, "eth") {
+			adapterType = evecommon.PhyIoType_PhyIoNetEth
+		}
+		add(adapterName, adapterType)
+	}
+	for _, label := range exp.appPCIAdapters {
+		physicalIO, ok := byLabel[label]
+		if !ok || physicalIO.Phyaddrs["PciLong"] == "" {
+			return nil, fmt.Errorf("PCI adapter %s not found in device model, define it in eve.pci-passthrough", label)
+		}
+		add(label, physicalIO.Ptype)
+	}
+	for _, group := range exp.appIOMMUGroups {
+		found := false
+		for _, physicalIO := range physicalIOs {
+			if physicalIO.Assigngrp == group {
+				add(physicalIO.Logicallabel, physicalIO.Ptype)
+				found = true
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("no adapters of group %s found in device model", group)
+		}
+	}
+	return adapters, nil
+}
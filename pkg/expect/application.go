@@ -7,7 +7,6 @@ import (
 	"github.com/lf-edge/eden/pkg/defaults"
 	"github.com/lf-edge/eden/pkg/utils"
 	"github.com/lf-edge/eve-api/go/config"
-	"github.com/lf-edge/eve-api/go/evecommon"
 	uuid "github.com/satori/go.uuid"
 	log "github.com/sirupsen/logrus"
 )
@@ -78,7 +77,9 @@ func (exp *AppExpectation) createAppInstanceConfig(img *config.Image, netInstanc
 				return false
 			})
 		}
-		tempExp := AppExpectationFromURL(exp.ctrl, exp.device, proccessedLink, "")
+		// additional disks are loaded from eserver the same way as image of app
+		tempExp := AppExpectationFromURL(exp.ctrl, exp.device, proccessedLink, "",
+			WithSFTPLoad(exp.sftpLoad), WithS3Load(exp.s3Load))
 		if tempExp.appType != dockerApp {
 			//we should not overwrite type for docker
 			tempExp.imageFormat = string(exp.volumesType)
@@ -136,16 +137,9 @@ func (exp *AppExpectation) createAppInstanceConfig(img *config.Image, netInstanc
 		bundle.appInstanceConfig.Fixedresources.VncDisplay = exp.vncDisplay
 		bundle.appInstanceConfig.Fixedresources.VncPasswd = exp.vncPassword
 	}
-	var adapters []*config.Adapter
-	for _, adapterName := range exp.appAdapters {
-		adapterType := evecommon.PhyIoType_PhyIoUSB
-		if strings.HasPrefix(adapterName, "eth") {
-			adapterType = evecommon.PhyIoType_PhyIoNetEth
-		}
-		adapters = append(adapters, &config.Adapter{
-			Type: adapterType,
-			Name: adapterName,
-		})
+	adapters, err := exp.adapters()
+	if err != nil {
+		return nil, err
 	}
 	bundle.appInstanceConfig.Adapters = adapters
 	bundle.appInstanceConfig.ProfileList = exp.profiles
@@ -37,6 +37,9 @@ func (exp *AppExpectation) createDataStore() (*config.DatastoreConfig, error) {
 		if exp.sftpLoad {
 			return exp.createDataStoreSFTP(id), nil
 		}
+		if exp.s3Load {
+			return exp.createDataStoreS3(id), nil
+		}
 		return exp.createDataStoreHTTP(id), nil
 	case directoryApp:
 		return exp.createDataStoreDirectory(id), nil
@@ -2,6 +2,7 @@ package expect
 
 import (
 	"fmt"
+	"net"
 	"path/filepath"
 
 	"github.com/lf-edge/eden/pkg/utils"
@@ -20,7 +21,7 @@ func (exp *AppExpectation) createImageDirectory(id uuid.UUID, dsID string) *conf
 	if err := utils.CreateImage(exp.appURL, tag, exp.ctrl.GetVars().ZArch); err != nil {
 		log.Fatalf("createImageDirectory CreateImage: %v", err)
 	}
-	if _, err := utils.LoadRegistry(tag, fmt.Sprintf("%s:%s", exp.ctrl.GetVars().RegistryIP, exp.ctrl.GetVars().RegistryPort)); err != nil {
+	if _, err := utils.LoadRegistry(tag, exp.localRegistry()); err != nil {
 		log.Fatalf("createImageDirectory LoadRegistry: %s", err)
 	}
 	return &config.Image{
@@ -34,10 +35,15 @@ func (exp *AppExpectation) createImageDirectory(id uuid.UUID, dsID string) *conf
 	}
 }
 
+// localRegistry returns address of registry started by eden
+func (exp *AppExpectation) localRegistry() string {
+	return net.JoinHostPort(exp.ctrl.GetVars().RegistryIP, exp.ctrl.GetVars().RegistryPort)
+}
+
 // checkDataStoreDirectory checks if provided ds match expectation
 func (exp *AppExpectation) checkDataStoreDirectory(ds *config.DatastoreConfig) bool {
 	if ds.DType == config.DsType_DsContainerRegistry {
-		if ds.Fqdn == fmt.Sprintf("docker://%s:%s", exp.ctrl.GetVars().RegistryIP, exp.ctrl.GetVars().RegistryPort) {
+		if ds.Fqdn == "docker://"+exp.localRegistry() {
 			return true
 		}
 	}
@@ -54,7 +60,7 @@ func (exp *AppExpectation) createDataStoreDirectory(id uuid.UUID) *config.Datast
 		Dpath:      "",
 		Region:     "",
 		CipherData: nil,
-		Fqdn:       fmt.Sprintf("docker://%s:%s", exp.ctrl.GetVars().RegistryIP, exp.ctrl.GetVars().RegistryPort),
+		Fqdn:       "docker://" + exp.localRegistry(),
 	}
 	if exp.datastoreOverride != "" {
 		ds.Fqdn = exp.datastoreOverride
@@ -2,13 +2,16 @@ package expect
 
 import (
 	"bytes"
+	"errors"
 	"fmt"
 	"strings"
 
+	"github.com/google/go-containerregistry/pkg/authn"
 	"github.com/google/go-containerregistry/pkg/crane"
 	"github.com/google/go-containerregistry/pkg/name"
 	v1 "github.com/google/go-containerregistry/pkg/v1"
 	"github.com/lf-edge/eden/pkg/defaults"
+	"github.com/lf-edge/eden/pkg/secrets"
 	"github.com/lf-edge/eden/pkg/utils"
 	"github.com/lf-edge/edge-containers/pkg/registry"
 	"github.com/lf-edge/eve-api/go/config"
@@ -70,14 +73,26 @@ func (exp *AppExpectation) checkDataStoreDocker(ds *config.DatastoreConfig) bool
 	return false
 }
 
+// registryCredentials returns credentials for registry of datastore kept in secrets,
+// empty ones are returned for anonymous access
+func (exp *AppExpectation) registryCredentials() (user, password string) {
+	host := strings.TrimPrefix(exp.getDataStoreFQDN(false), "docker://")
+	user, password, err := secrets.Default().RegistryCredentials(host)
+	if err != nil && !errors.Is(err, secrets.ErrNotFound) {
+		log.Warnf("cannot get credentials for registry %s, use anonymous access: %s", host, err)
+	}
+	return user, password
+}
+
 // createDataStoreDocker creates DatastoreConfig for docker.io with provided id
 func (exp *AppExpectation) createDataStoreDocker(id uuid.UUID) *config.DatastoreConfig {
+	user, password := exp.registryCredentials()
 	return &config.DatastoreConfig{
 		Id:         id.String(),
 		DType:      config.DsType_DsContainerRegistry,
 		Fqdn:       exp.getDataStoreFQDN(true),
-		ApiKey:     "",
-		Password:   "",
+		ApiKey:     user,
+		Password:   password,
 		Dpath:      "",
 		Region:     "",
 		CipherData: nil,
@@ -91,7 +106,11 @@ func (exp *AppExpectation) applyRootFSType(image *config.Image) error {
 		return nil
 	}
 	ref := fmt.Sprintf("%s/%s", exp.getDataStoreFQDN(false), image.Name)
-	manifest, err := crane.Manifest(ref)
+	var opts []crane.Option
+	if user, password := exp.registryCredentials(); user != "" {
+		opts = append(opts, crane.WithAuth(&authn.Basic{Username: user, Password: password}))
+	}
+	manifest, err := crane.Manifest(ref, opts...)
 	if err != nil {
 		return err
 	}
@@ -67,6 +67,11 @@ type AppExpectation struct {
 
 	device *device.Ctx
 
+	// appPCIAdapters are labels of PCI passthrough adapters of device
+	appPCIAdapters []string
+	// appIOMMUGroups are assignment groups of device with all their adapters
+	appIOMMUGroups []string
+
 	volumesType VolumeType
 	volumeSize  int64
 
@@ -76,6 +81,7 @@ type AppExpectation struct {
 
 	httpDirectLoad bool // use eserver for SHA calculation only
 	sftpLoad       bool
+	s3Load         bool
 
 	disks []string
 	acl   ACLs
@@ -142,7 +148,7 @@ func AppExpectationFromURL(ctrl controller.Cloud, device *device.Ctx, appLink st
 	switch expectation.ctrl.GetVars().ZArch {
 	case "amd64":
 		expectation.virtualizationMode = config.VmMode_HVM
-	case "arm64":
+	case "arm64", "riscv64":
 		expectation.virtualizationMode = config.VmMode_PV
 	default:
 		log.Fatalf("Unexpected arch %s", expectation.ctrl.GetVars().ZArch)
@@ -17,6 +17,7 @@ func (exp *AppExpectation) createImageFile(id uuid.UUID, dsID string) *config.Im
 	server := &eden.EServer{
 		EServerIP:   exp.ctrl.GetVars().EServerIP,
 		EServerPort: exp.ctrl.GetVars().EServerPort,
+		TLS:         exp.ctrl.GetVars().EServerTLS,
 	}
 	var fileSize int64
 	sha256 := ""
@@ -38,6 +39,8 @@ func (exp *AppExpectation) createImageFile(id uuid.UUID, dsID string) *config.Im
 	}
 	if exp.sftpLoad {
 		filePath = filepath.Join(defaults.DefaultSFTPDirPrefix, filePath)
+	} else if exp.s3Load {
+		filePath = s3Key(filePath)
 	}
 	return &config.Image{
 		Uuidandversion: &config.UUIDandVersion{
@@ -2,6 +2,7 @@ package expect
 
 import (
 	"fmt"
+	"net"
 	"net/url"
 	"path"
 	"path/filepath"
@@ -22,6 +23,7 @@ func (exp *AppExpectation) createImageHTTP(id uuid.UUID, dsID string) *config.Im
 	server := &eden.EServer{
 		EServerIP:   exp.ctrl.GetVars().EServerIP,
 		EServerPort: exp.ctrl.GetVars().EServerPort,
+		TLS:         exp.ctrl.GetVars().EServerTLS,
 	}
 	var fileSize int64
 	sha256 := ""
@@ -35,9 +37,18 @@ func (exp *AppExpectation) createImageHTTP(id uuid.UUID, dsID string) *config.Im
 
 		delayTime := defaults.DefaultRepeatTimeout
 
+		failures := 0
 		for {
 			status := server.EServerCheckStatus(name)
-			if !status.ISReady {
+			if status.Error != "" {
+				failures++
+				if failures > defaults.DefaultRepeatCount {
+					log.Fatalf("Download of %s failed: %s", exp.appLink, status.Error)
+				}
+				// eserver continues download from the received size
+				log.Infof("Download failed: %s, resume (%d) of (%d)", status.Error, failures, defaults.DefaultRepeatCount)
+				server.EServerAddFileURL(exp.appLink)
+			} else if !status.ISReady {
 				log.Infof("Downloading... Ready %s", humanize.Bytes(uint64(status.Size)))
 			} else {
 				sha256 = status.Sha256
@@ -54,6 +65,8 @@ func (exp *AppExpectation) createImageHTTP(id uuid.UUID, dsID string) *config.Im
 	}
 	if exp.sftpLoad {
 		filePath = filepath.Join(defaults.DefaultSFTPDirPrefix, filePath)
+	} else if exp.s3Load {
+		filePath = s3Key(filePath)
 	} else if exp.httpDirectLoad {
 		u, err := url.Parse(exp.appLink)
 		if err != nil {
@@ -76,7 +89,11 @@ func (exp *AppExpectation) createImageHTTP(id uuid.UUID, dsID string) *config.Im
 
 // checkImageHTTP checks if provided img match expectation
 func (exp *AppExpectation) checkImageHTTP(img *config.Image, dsID string) bool {
-	if img.DsId == dsID && img.Name == path.Join("eserver", path.Base(exp.appURL)) && img.Iformat == config.Format_QCOW2 {
+	name := path.Join("eserver", path.Base(exp.appURL))
+	if exp.s3Load {
+		name = s3Key(name)
+	}
+	if img.DsId == dsID && img.Name == name && img.Iformat == config.Format_QCOW2 {
 		return true
 	}
 	return false
@@ -85,11 +102,18 @@ func (exp *AppExpectation) checkImageHTTP(img *config.Image, dsID string) bool {
 // checkDataStoreHTTP checks if provided ds match expectation
 func (exp *AppExpectation) checkDataStoreHTTP(ds *config.DatastoreConfig) bool {
 	if exp.sftpLoad && ds.DType == config.DsType_DsSFTP {
-		if ds.Fqdn == fmt.Sprintf("%s:%s", exp.ctrl.GetVars().AdamDomain, exp.ctrl.GetVars().EServerPort) {
+		user, password := exp.sftpCredentials()
+		if ds.Fqdn == net.JoinHostPort(exp.ctrl.GetVars().AdamDomain, exp.ctrl.GetVars().EServerPort) &&
+			ds.ApiKey == user && ds.Password == password {
+			return true
+		}
+	} else if exp.s3Load && ds.DType == config.DsType_DsS3 {
+		if ds.Fqdn == exp.eserverURL() &&
+			ds.Dpath == defaults.DefaultS3Bucket {
 			return true
 		}
 	} else if ds.DType == config.DsType_DsHttp || ds.DType == config.DsType_DsHttps {
-		if !exp.httpDirectLoad && ds.Fqdn == fmt.Sprintf("http://%s:%s", exp.ctrl.GetVars().AdamDomain, exp.ctrl.GetVars().EServerPort) {
+		if !exp.httpDirectLoad && ds.Fqdn == exp.eserverURL() {
 			return true
 		}
 		u, err := url.Parse(exp.appLink)
@@ -103,24 +127,81 @@ func (exp *AppExpectation) checkDataStoreHTTP(ds *config.DatastoreConfig) bool {
 	return false
 }
 
-// createDataStoreHTTP creates datastore, pointed onto EServer sftp endpoint
+// createDataStoreSFTP creates datastore, pointed onto EServer sftp endpoint
 func (exp *AppExpectation) createDataStoreSFTP(id uuid.UUID) *config.DatastoreConfig {
 	var ds = &config.DatastoreConfig{
 		Id:         id.String(),
 		DType:      config.DsType_DsSFTP,
-		ApiKey:     defaults.DefaultSFTPUser,
-		Password:   defaults.DefaultSFTPPassword,
-		Fqdn:       fmt.Sprintf("%s:%s", exp.ctrl.GetVars().AdamDomain, exp.ctrl.GetVars().EServerPort),
+		Fqdn:       net.JoinHostPort(exp.ctrl.GetVars().AdamDomain, exp.ctrl.GetVars().EServerPort),
 		Dpath:      "",
 		Region:     "",
 		CipherData: nil,
 	}
+	ds.ApiKey, ds.Password = exp.sftpCredentials()
 	if exp.datastoreOverride != "" {
 		ds.Fqdn = exp.datastoreOverride
 	}
 	return ds
 }
 
+// sftpCredentials returns user and password for sftp access to EServer
+func (exp *AppExpectation) sftpCredentials() (string, string) {
+	user, password := exp.ctrl.GetVars().EServerSFTPUser, exp.ctrl.GetVars().EServerSFTPPass
+	if user == "" {
+		user = defaults.DefaultSFTPUser
+	}
+	if password == "" {
+		password = defaults.DefaultSFTPPassword
+	}
+	return user, password
+}
+
+// createDataStoreS3 creates datastore, pointed onto EServer S3 endpoint
+func (exp *AppExpectation) createDataStoreS3(id uuid.UUID) *config.DatastoreConfig {
+	var ds = &config.DatastoreConfig{
+		Id:         id.String(),
+		DType:      config.DsType_DsS3,
+		ApiKey:     defaults.DefaultS3AccessKey,
+		Password:   defaults.DefaultS3SecretKey,
+		Fqdn:       exp.eserverURL(),
+		Dpath:      defaults.DefaultS3Bucket,
+		Region:     defaults.DefaultS3Region,
+		CipherData: nil,
+	}
+	if exp.datastoreOverride != "" {
+		ds.Fqdn = exp.datastoreOverride
+	} else {
+		exp.applyEServerCerts(ds)
+	}
+	return ds
+}
+
+// eserverURL returns URL of EServer for EVE access
+func (exp *AppExpectation) eserverURL() string {
+	scheme := "http"
+	if exp.ctrl.GetVars().EServerTLS {
+		scheme = "https"
+	}
+	return fmt.Sprintf("%s://%s", scheme, net.JoinHostPort(exp.ctrl.GetVars().AdamDomain, exp.ctrl.GetVars().EServerPort))
+}
+
+// applyEServerCerts sets certificates to verify EServer served over TLS into datastore
+func (exp *AppExpectation) applyEServerCerts(ds *config.DatastoreConfig) {
+	if !exp.ctrl.GetVars().EServerTLS {
+		return
+	}
+	certs, err := eden.EServerCertsPEM()
+	if err != nil {
+		log.Fatalf("cannot read certificates of eserver: %s", err)
+	}
+	ds.DsCertPEM = certs
+}
+
+// s3Key returns key of object inside S3 bucket of EServer for file path returned by EServer
+func s3Key(filePath string) string {
+	return strings.TrimPrefix(filePath, defaults.DefaultS3Bucket+"/")
+}
+
 // createDataStoreHTTP creates datastore, pointed onto EServer http endpoint
 func (exp *AppExpectation) createDataStoreHTTP(id uuid.UUID) *config.DatastoreConfig {
 	ds := &config.DatastoreConfig{
@@ -146,7 +227,11 @@ func (exp *AppExpectation) createDataStoreHTTP(id uuid.UUID) *config.DatastoreCo
 		// we want to preserve it.
 		ds.Fqdn = fmt.Sprintf("%s://%s", u.Scheme, u.Host)
 	} else {
-		ds.Fqdn = fmt.Sprintf("http://%s:%s", exp.ctrl.GetVars().AdamDomain, exp.ctrl.GetVars().EServerPort)
+		ds.Fqdn = exp.eserverURL()
+		if exp.ctrl.GetVars().EServerTLS {
+			ds.DType = config.DsType_DsHttps
+		}
+		exp.applyEServerCerts(ds)
 	}
 	return ds
 }
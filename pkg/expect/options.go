@@ -95,6 +95,21 @@ func WithAppAdapters(appadapters []string) ExpectationOption {
 	}
 }
 
+// WithAppPCIAdapters assigns PCI passthrough adapters (GPUs, NICs) of device for created apps
+func WithAppPCIAdapters(labels []string) ExpectationOption {
+	return func(expectation *AppExpectation) {
+		expectation.appPCIAdapters = labels
+	}
+}
+
+// WithAppIOMMUGroups assigns all adapters of assignment groups of device for created apps,
+// PCI devices of one IOMMU group are in the same assignment group
+func WithAppIOMMUGroups(groups []string) ExpectationOption {
+	return func(expectation *AppExpectation) {
+		expectation.appIOMMUGroups = groups
+	}
+}
+
 // AddNetInstanceNameAndPortPublish adds NetInstance with defined name and ports mapping for apps in format ["EXTERNAL_PORT:INTERNAL_PORT"]
 func AddNetInstanceNameAndPortPublish(netInstance string, portPublish []string) ExpectationOption {
 	mac := ""
@@ -260,6 +275,13 @@ func WithSFTPLoad(sftp bool) ExpectationOption {
 	}
 }
 
+// WithS3Load force eserver to serve image via S3 API
+func WithS3Load(s3 bool) ExpectationOption {
+	return func(expectation *AppExpectation) {
+		expectation.s3Load = s3
+	}
+}
+
 // WithAdditionalDisks adds disks to application
 func WithAdditionalDisks(disks []string) ExpectationOption {
 	return func(expectation *AppExpectation) {
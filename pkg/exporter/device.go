@@ -0,0 +1,142 @@
+package exporter
+
+import (
+	"sync"
+
+	"github.com/lf-edge/eve-api/go/metrics"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const mb = 1024 * 1024
+
+var (
+	metricTimestampDesc = prometheus.NewDesc("eve_metric_timestamp_seconds",
+		"Time when EVE sent the last metrics.", []string{"device"}, nil)
+	deviceCPUDesc = prometheus.NewDesc("eve_device_cpu_seconds_total",
+		"CPU time used by EVE.", []string{"device"}, nil)
+	deviceMemoryUsedDesc = prometheus.NewDesc("eve_device_memory_used_bytes",
+		"Memory of the device in use.", []string{"device"}, nil)
+	deviceMemoryAvailDesc = prometheus.NewDesc("eve_device_memory_available_bytes",
+		"Memory of the device available.", []string{"device"}, nil)
+	deviceDiskUsedDesc = prometheus.NewDesc("eve_device_disk_used_bytes",
+		"Used space of the disk or mount path.", []string{"device", "disk", "mount"}, nil)
+	deviceDiskTotalDesc = prometheus.NewDesc("eve_device_disk_total_bytes",
+		"Size of the disk or mount path.", []string{"device", "disk", "mount"}, nil)
+	deviceRxBytesDesc = prometheus.NewDesc("eve_device_network_receive_bytes_total",
+		"Bytes received by the network interface of the device.", []string{"device", "interface"}, nil)
+	deviceTxBytesDesc = prometheus.NewDesc("eve_device_network_transmit_bytes_total",
+		"Bytes transmitted by the network interface of the device.", []string{"device", "interface"}, nil)
+	deviceRxDropsDesc = prometheus.NewDesc("eve_device_network_receive_drops_total",
+		"Received packets dropped on the network interface of the device.", []string{"device", "interface"}, nil)
+	deviceTxDropsDesc = prometheus.NewDesc("eve_device_network_transmit_drops_total",
+		"Transmitted packets dropped on the network interface of the device.", []string{"device", "interface"}, nil)
+	appCPUDesc = prometheus.NewDesc("eve_app_cpu_seconds_total",
+		"CPU time used by the application.", []string{"device", "app", "app_id"}, nil)
+	appMemoryUsedDesc = prometheus.NewDesc("eve_app_memory_used_bytes",
+		"Memory in use by the application.", []string{"device", "app", "app_id"}, nil)
+	appMemoryAvailDesc = prometheus.NewDesc("eve_app_memory_available_bytes",
+		"Memory available to the application.", []string{"device", "app", "app_id"}, nil)
+	appRxBytesDesc = prometheus.NewDesc("eve_app_network_receive_bytes_total",
+		"Bytes received by the application.", []string{"device", "app", "app_id", "interface"}, nil)
+	appTxBytesDesc = prometheus.NewDesc("eve_app_network_transmit_bytes_total",
+		"Bytes transmitted by the application.", []string{"device", "app", "app_id", "interface"}, nil)
+)
+
+// DeviceCollector converts the last metrics message of EVE into Prometheus metrics
+type DeviceCollector struct {
+	mu  sync.Mutex
+	msg *metrics.ZMetricMsg
+}
+
+// NewDeviceCollector returns collector without metrics, fill it with Update
+func NewDeviceCollector() *DeviceCollector {
+	return &DeviceCollector{}
+}
+
+// Update replaces metrics of the collector with metrics from msg
+func (c *DeviceCollector) Update(msg *metrics.ZMetricMsg) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.msg = msg
+}
+
+// Describe implements prometheus.Collector
+func (c *DeviceCollector) Describe(ch chan<- *prometheus.Desc) {
+	for _, desc := range []*prometheus.Desc{metricTimestampDesc, deviceCPUDesc, deviceMemoryUsedDesc,
+		deviceMemoryAvailDesc, deviceDiskUsedDesc, deviceDiskTotalDesc, deviceRxBytesDesc, deviceTxBytesDesc,
+		deviceRxDropsDesc, deviceTxDropsDesc, appCPUDesc, appMemoryUsedDesc, appMemoryAvailDesc,
+		appRxBytesDesc, appTxBytesDesc} {
+		ch <- desc
+	}
+}
+
+// Collect implements prometheus.Collector
+func (c *DeviceCollector) Collect(ch chan<- prometheus.Metric) {
+	c.mu.Lock()
+	msg := c.msg
+	c.mu.Unlock()
+	if msg == nil {
+		return
+	}
+	dev := msg.GetDevID()
+	if ts := msg.GetAtTimeStamp(); ts != nil {
+		ch <- prometheus.MustNewConstMetric(metricTimestampDesc, prometheus.GaugeValue,
+			float64(ts.AsTime().UnixNano())/1e9, dev)
+	}
+	if dm := msg.GetDm(); dm != nil {
+		if cpu := dm.GetCpuMetric(); cpu != nil {
+			ch <- prometheus.MustNewConstMetric(deviceCPUDesc, prometheus.CounterValue, cpuSeconds(cpu), dev)
+		}
+		if memory := dm.GetMemory(); memory != nil {
+			ch <- prometheus.MustNewConstMetric(deviceMemoryUsedDesc, prometheus.GaugeValue,
+				float64(memory.GetUsedMem())*mb, dev)
+			ch <- prometheus.MustNewConstMetric(deviceMemoryAvailDesc, prometheus.GaugeValue,
+				float64(memory.GetAvailMem())*mb, dev)
+		}
+		for _, disk := range dm.GetDisk() {
+			ch <- prometheus.MustNewConstMetric(deviceDiskUsedDesc, prometheus.GaugeValue,
+				float64(disk.GetUsed())*mb, dev, disk.GetDisk(), disk.GetMountPath())
+			ch <- prometheus.MustNewConstMetric(deviceDiskTotalDesc, prometheus.GaugeValue,
+				float64(disk.GetTotal())*mb, dev, disk.GetDisk(), disk.GetMountPath())
+		}
+		for _, network := range dm.GetNetwork() {
+			ifName := network.GetIName()
+			ch <- prometheus.MustNewConstMetric(deviceRxBytesDesc, prometheus.CounterValue,
+				float64(network.GetRxBytes()), dev, ifName)
+			ch <- prometheus.MustNewConstMetric(deviceTxBytesDesc, prometheus.CounterValue,
+				float64(network.GetTxBytes()), dev, ifName)
+			ch <- prometheus.MustNewConstMetric(deviceRxDropsDesc, prometheus.CounterValue,
+				float64(network.GetRxDrops()), dev, ifName)
+			ch <- prometheus.MustNewConstMetric(deviceTxDropsDesc, prometheus.CounterValue,
+				float64(network.GetTxDrops()), dev, ifName)
+		}
+	}
+	for _, am := range msg.GetAm() {
+		app, appID := am.GetAppName(), am.GetAppID()
+		if cpu := am.GetCpu(); cpu != nil {
+			ch <- prometheus.MustNewConstMetric(appCPUDesc, prometheus.CounterValue, cpuSeconds(cpu),
+				dev, app, appID)
+		}
+		if memory := am.GetMemory(); memory != nil {
+			ch <- prometheus.MustNewConstMetric(appMemoryUsedDesc, prometheus.GaugeValue,
+				float64(memory.GetUsedMem())*mb, dev, app, appID)
+			ch <- prometheus.MustNewConstMetric(appMemoryAvailDesc, prometheus.GaugeValue,
+				float64(memory.GetAvailMem())*mb, dev, app, appID)
+		}
+		for _, network := range am.GetNetwork() {
+			ifName := network.GetIName()
+			ch <- prometheus.MustNewConstMetric(appRxBytesDesc, prometheus.CounterValue,
+				float64(network.GetRxBytes()), dev, app, appID, ifName)
+			ch <- prometheus.MustNewConstMetric(appTxBytesDesc, prometheus.CounterValue,
+				float64(network.GetTxBytes()), dev, app, appID, ifName)
+		}
+	}
+}
+
+// cpuSeconds prefers the precise total in nanoseconds if reported by EVE
+func cpuSeconds(cpu *metrics.AppCpuMetric) float64 {
+	if cpu.GetTotalNs() > 0 {
+		return float64(cpu.GetTotalNs()) / 1e9
+	}
+	return float64(cpu.GetTotal())
+}
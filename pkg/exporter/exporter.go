@@ -0,0 +1,43 @@
+// Package exporter exposes metrics of EVE received by the controller and counters of Eden itself
+// (durations of tests, latencies of requests to the controller) in Prometheus format.
+package exporter
+
+import (
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "eden"
+
+// controllerRequestDuration observes requests of Eden to the controller
+var controllerRequestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+	Namespace: namespace,
+	Subsystem: "controller",
+	Name:      "request_duration_seconds",
+	Help:      "Latency of requests sent by Eden to the controller.",
+	Buckets:   prometheus.ExponentialBuckets(0.005, 2, 12),
+}, []string{"method", "code"})
+
+func init() {
+	prometheus.MustRegister(controllerRequestDuration)
+}
+
+// InstrumentRoundTripper wraps transport of the controller client to observe latencies of requests
+func InstrumentRoundTripper(next http.RoundTripper) http.RoundTripper {
+	return promhttp.InstrumentRoundTripperDuration(controllerRequestDuration, next)
+}
+
+// Handler returns HTTP handler serving metrics of EVE collected by the collectors together
+// with metrics of Eden registered by default
+func Handler(collectors ...prometheus.Collector) (http.Handler, error) {
+	registry := prometheus.NewRegistry()
+	for _, collector := range collectors {
+		if err := registry.Register(collector); err != nil {
+			return nil, err
+		}
+	}
+	gatherers := prometheus.Gatherers{prometheus.DefaultGatherer, registry}
+	return promhttp.HandlerFor(gatherers, promhttp.HandlerOpts{}), nil
+}
@@ -0,0 +1,48 @@
+package exporter_test
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/lf-edge/eden/pkg/exporter"
+	"github.com/lf-edge/eve-api/go/metrics"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDeviceCollector(t *testing.T) {
+	t.Parallel()
+
+	collector := exporter.NewDeviceCollector()
+	handler, err := exporter.Handler(collector)
+	assert.NoError(t, err)
+	collector.Update(&metrics.ZMetricMsg{
+		DevID: "dev",
+		MetricContent: &metrics.ZMetricMsg_Dm{Dm: &metrics.DeviceMetric{
+			Memory:    &metrics.MemoryMetric{UsedMem: 512},
+			CpuMetric: &metrics.AppCpuMetric{TotalNs: 1500000000},
+			Network:   []*metrics.NetworkMetric{{IName: "eth0", RxBytes: 100}},
+		}},
+		Am: []*metrics.AppMetric{{AppName: "app", AppID: "id", Memory: &metrics.MemoryMetric{UsedMem: 1}}},
+	})
+
+	// requests to the controller are observed with the default registry
+	controller := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer controller.Close()
+	client := &http.Client{Transport: exporter.InstrumentRoundTripper(http.DefaultTransport)}
+	resp, err := client.Get(controller.URL)
+	if assert.NoError(t, err) {
+		resp.Body.Close()
+	}
+
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	body, err := io.ReadAll(recorder.Body)
+	assert.NoError(t, err)
+	assert.Contains(t, string(body), `eve_device_memory_used_bytes{device="dev"} 5.36870912e+08`)
+	assert.Contains(t, string(body), `eve_device_cpu_seconds_total{device="dev"} 1.5`)
+	assert.Contains(t, string(body), `eve_device_network_receive_bytes_total{device="dev",interface="eth0"} 100`)
+	assert.Contains(t, string(body), `eve_app_memory_used_bytes{app="app",app_id="id",device="dev"} 1.048576e+06`)
+	assert.Contains(t, string(body), `eden_controller_request_duration_seconds_count{code="200",method="get"} 1`)
+}
@@ -0,0 +1,71 @@
+package exporter
+
+import (
+	"github.com/lf-edge/eden/pkg/testhistory"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	testDurationDesc = prometheus.NewDesc(namespace+"_test_duration_seconds",
+		"Duration of the test in the last run of 'eden test'.", []string{"test", "status"}, nil)
+	testPassRateDesc = prometheus.NewDesc(namespace+"_test_pass_rate",
+		"Ratio of passed runs of the test in the recorded history.", []string{"test"}, nil)
+	testLastRunDesc = prometheus.NewDesc(namespace+"_test_last_run_timestamp_seconds",
+		"Time when the last run of 'eden test' started.", nil, nil)
+	testHistoryErrorDesc = prometheus.NewDesc(namespace+"_test_history_error",
+		"Set to 1 if the history of tests cannot be read.", nil, nil)
+)
+
+// TestCollector exposes results of tests recorded in history of 'eden test'
+type TestCollector struct {
+	db   *testhistory.DB
+	runs int
+}
+
+// NewTestCollector returns collector reading the last runs from the history on every scrape
+func NewTestCollector(db *testhistory.DB, runs int) *TestCollector {
+	return &TestCollector{db: db, runs: runs}
+}
+
+// Describe implements prometheus.Collector
+func (c *TestCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- testDurationDesc
+	ch <- testPassRateDesc
+	ch <- testLastRunDesc
+	ch <- testHistoryErrorDesc
+}
+
+// Collect implements prometheus.Collector
+func (c *TestCollector) Collect(ch chan<- prometheus.Metric) {
+	results, err := c.db.Results(c.runs)
+	if err != nil {
+		ch <- prometheus.MustNewConstMetric(testHistoryErrorDesc, prometheus.GaugeValue, 1)
+		return
+	}
+	ch <- prometheus.MustNewConstMetric(testHistoryErrorDesc, prometheus.GaugeValue, 0)
+	if len(results) == 0 {
+		return
+	}
+	// results are ordered from the oldest run
+	lastRun := results[len(results)-1].RunID
+	var lastRunStarted float64
+	last := map[string]testhistory.Result{}
+	for _, result := range results {
+		if result.RunID != lastRun {
+			continue
+		}
+		if lastRunStarted == 0 {
+			lastRunStarted = float64(result.Started.Unix())
+		}
+		// test may be reported several times in one run, keep the last result
+		last[result.Test] = result
+	}
+	ch <- prometheus.MustNewConstMetric(testLastRunDesc, prometheus.GaugeValue, lastRunStarted)
+	for _, result := range last {
+		ch <- prometheus.MustNewConstMetric(testDurationDesc, prometheus.GaugeValue,
+			result.Duration.Seconds(), result.Test, result.Status)
+	}
+	for _, trend := range testhistory.Trends(results) {
+		ch <- prometheus.MustNewConstMetric(testPassRateDesc, prometheus.GaugeValue, trend.PassRate, trend.Test)
+	}
+}
@@ -0,0 +1,309 @@
+package helm
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+	"gopkg.in/yaml.v2"
+)
+
+// App is container of workload to deploy as container app instance on EVE
+type App struct {
+	Name  string
+	Image string
+	// Env contains environment variables in form of KEY=VALUE
+	Env []string
+	// Ports to publish in form of EXTERNAL_PORT:INTERNAL_PORT
+	Ports []string
+	// Mounts are paths of volumes inside container
+	Mounts []string
+	// VolumeSize is the largest size of volumes requested in bytes
+	VolumeSize uint64
+	// CPUs and Memory (in bytes) are limits of container, zero if not defined
+	CPUs   uint32
+	Memory uint64
+}
+
+type metadata struct {
+	Name   string            `yaml:"name"`
+	Labels map[string]string `yaml:"labels"`
+}
+
+type containerPort struct {
+	Name          string `yaml:"name"`
+	ContainerPort int    `yaml:"containerPort"`
+}
+
+type resources struct {
+	Limits   map[string]string `yaml:"limits"`
+	Requests map[string]string `yaml:"requests"`
+}
+
+type container struct {
+	Name  string `yaml:"name"`
+	Image string `yaml:"image"`
+	Env   []struct {
+		Name      string      `yaml:"name"`
+		Value     string      `yaml:"value"`
+		ValueFrom interface{} `yaml:"valueFrom"`
+	} `yaml:"env"`
+	Ports        []containerPort `yaml:"ports"`
+	VolumeMounts []struct {
+		Name      string `yaml:"name"`
+		MountPath string `yaml:"mountPath"`
+	} `yaml:"volumeMounts"`
+	Resources resources `yaml:"resources"`
+	Command   []string  `yaml:"command"`
+	Args      []string  `yaml:"args"`
+}
+
+type volume struct {
+	Name     string `yaml:"name"`
+	EmptyDir *struct {
+		SizeLimit string `yaml:"sizeLimit"`
+	} `yaml:"emptyDir"`
+	PersistentVolumeClaim *struct {
+		ClaimName string `yaml:"claimName"`
+	} `yaml:"persistentVolumeClaim"`
+}
+
+type podSpec struct {
+	Containers     []container `yaml:"containers"`
+	InitContainers []container `yaml:"initContainers"`
+	Volumes        []volume    `yaml:"volumes"`
+}
+
+type servicePort struct {
+	Port       int         `yaml:"port"`
+	TargetPort interface{} `yaml:"targetPort"`
+	NodePort   int         `yaml:"nodePort"`
+}
+
+type manifest struct {
+	Kind     string   `yaml:"kind"`
+	Metadata metadata `yaml:"metadata"`
+	Spec     struct {
+		// Pod
+		podSpec `yaml:",inline"`
+		// Deployment, StatefulSet, DaemonSet, ReplicaSet and Job
+		Replicas *int `yaml:"replicas"`
+		Template struct {
+			Metadata metadata `yaml:"metadata"`
+			Spec     podSpec  `yaml:"spec"`
+		} `yaml:"template"`
+		VolumeClaimTemplates []manifest `yaml:"volumeClaimTemplates"`
+		// Service
+		Selector interface{}   `yaml:"selector"`
+		Ports    []servicePort `yaml:"ports"`
+		// PersistentVolumeClaim
+		Resources resources `yaml:"resources"`
+	} `yaml:"spec"`
+}
+
+// workload is pod template with its labels
+type workload struct {
+	name   string
+	labels map[string]string
+	spec   podSpec
+	claims map[string]string
+}
+
+// quantity returns value of quantity of Kubernetes resource (e.g. 500m or 1Gi)
+func quantity(s string) (float64, error) {
+	suffixes := []struct {
+		suffix string
+		mult   float64
+	}{
+		{"Ki", 1 << 10}, {"Mi", 1 << 20}, {"Gi", 1 << 30}, {"Ti", 1 << 40},
+		{"k", 1e3}, {"M", 1e6}, {"G", 1e9}, {"T", 1e12}, {"m", 1e-3},
+	}
+	for _, el := range suffixes {
+		if strings.HasSuffix(s, el.suffix) {
+			v, err := strconv.ParseFloat(strings.TrimSuffix(s, el.suffix), 64)
+			return v * el.mult, err
+		}
+	}
+	return strconv.ParseFloat(s, 64)
+}
+
+// value returns limit of resource or its request if limit is not defined
+func (r resources) value(name string) (float64, error) {
+	s, ok := r.Limits[name]
+	if !ok {
+		s, ok = r.Requests[name]
+	}
+	if !ok {
+		return 0, nil
+	}
+	return quantity(s)
+}
+
+// matches returns true if selector of service selects labels of pod
+func matches(selector interface{}, labels map[string]string) bool {
+	sel, ok := selector.(map[interface{}]interface{})
+	if !ok || len(sel) == 0 {
+		return false
+	}
+	for k, v := range sel {
+		if labels[fmt.Sprint(k)] != fmt.Sprint(v) {
+			return false
+		}
+	}
+	return true
+}
+
+// Apps returns apps for containers of workloads from manifests rendered by helm.
+// Replicas, init containers and unsupported kinds of objects and volumes are skipped
+// with warning.
+func Apps(manifests []byte) ([]*App, error) {
+	var workloads []*workload
+	var services []manifest
+	claims := map[string]string{}
+	decoder := yaml.NewDecoder(strings.NewReader(string(manifests)))
+	for {
+		var m manifest
+		err := decoder.Decode(&m)
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			return nil, fmt.Errorf("cannot parse manifests: %w", err)
+		}
+		switch m.Kind {
+		case "":
+			// empty document
+		case "Pod":
+			workloads = append(workloads, &workload{name: m.Metadata.Name, labels: m.Metadata.Labels, spec: m.Spec.podSpec})
+		case "Deployment", "StatefulSet", "DaemonSet", "ReplicaSet", "Job":
+			if m.Spec.Replicas != nil && *m.Spec.Replicas > 1 {
+				log.Warnf("%s %s has %d replicas, only one is deployed", m.Kind, m.Metadata.Name, *m.Spec.Replicas)
+			}
+			w := &workload{
+				name:   m.Metadata.Name,
+				labels: m.Spec.Template.Metadata.Labels,
+				spec:   m.Spec.Template.Spec,
+				claims: map[string]string{},
+			}
+			for _, claim := range m.Spec.VolumeClaimTemplates {
+				w.claims[claim.Metadata.Name] = claim.Spec.Resources.Requests["storage"]
+			}
+			workloads = append(workloads, w)
+		case "Service":
+			services = append(services, m)
+		case "PersistentVolumeClaim":
+			claims[m.Metadata.Name] = m.Spec.Resources.Requests["storage"]
+		case "ConfigMap", "Secret", "ServiceAccount":
+			log.Warnf("%s %s is not supported and skipped, define its data with env of pod", m.Kind, m.Metadata.Name)
+		default:
+			log.Warnf("%s %s is not supported and skipped", m.Kind, m.Metadata.Name)
+		}
+	}
+	var apps []*App
+	for _, w := range workloads {
+		for _, c := range w.spec.InitContainers {
+			log.Warnf("init container %s of %s is skipped", c.Name, w.name)
+		}
+		for _, c := range w.spec.Containers {
+			name := w.name
+			if len(w.spec.Containers) > 1 {
+				name = fmt.Sprintf("%s-%s", w.name, c.Name)
+			}
+			app, err := w.app(name, c, services, claims)
+			if err != nil {
+				return nil, fmt.Errorf("container %s of %s: %w", c.Name, w.name, err)
+			}
+			apps = append(apps, app)
+		}
+	}
+	return apps, nil
+}
+
+// app returns app for container of workload
+func (w *workload) app(name string, c container, services []manifest, claims map[string]string) (*App, error) {
+	app := &App{Name: name, Image: c.Image}
+	if len(c.Command) > 0 || len(c.Args) > 0 {
+		log.Warnf("command and args of %s are not supported, entrypoint of image is used", name)
+	}
+	for _, env := range c.Env {
+		if env.ValueFrom != nil {
+			log.Warnf("env %s of %s with valueFrom is not supported", env.Name, name)
+			continue
+		}
+		app.Env = append(app.Env, fmt.Sprintf("%s=%s", env.Name, env.Value))
+	}
+	cpus, err := c.Resources.value("cpu")
+	if err != nil {
+		return nil, fmt.Errorf("cannot parse cpu: %w", err)
+	}
+	app.CPUs = uint32(math.Ceil(cpus))
+	memory, err := c.Resources.value("memory")
+	if err != nil {
+		return nil, fmt.Errorf("cannot parse memory: %w", err)
+	}
+	app.Memory = uint64(memory)
+	for _, p := range c.Ports {
+		external := p.ContainerPort
+		// publish on port of service selecting the pod, node port is preferred
+		for _, svc := range services {
+			if !matches(svc.Spec.Selector, w.labels) {
+				continue
+			}
+			for _, sp := range svc.Spec.Ports {
+				target := fmt.Sprint(sp.TargetPort)
+				if sp.TargetPort == nil {
+					target = strconv.Itoa(sp.Port)
+				}
+				if target != strconv.Itoa(p.ContainerPort) && target != p.Name {
+					continue
+				}
+				external = sp.Port
+				if sp.NodePort > 0 {
+					external = sp.NodePort
+				}
+			}
+		}
+		app.Ports = append(app.Ports, fmt.Sprintf("%d:%d", external, p.ContainerPort))
+	}
+	volumes := map[string]volume{}
+	for _, v := range w.spec.Volumes {
+		volumes[v.Name] = v
+	}
+	for _, m := range c.VolumeMounts {
+		var size string
+		if v, ok := volumes[m.Name]; ok {
+			switch {
+			case v.EmptyDir != nil:
+				size = v.EmptyDir.SizeLimit
+			case v.PersistentVolumeClaim != nil:
+				size = claims[v.PersistentVolumeClaim.ClaimName]
+			default:
+				log.Warnf("volume %s of %s is not supported and skipped", m.Name, name)
+				continue
+			}
+		} else if s, ok := w.claims[m.Name]; ok {
+			size = s
+		} else {
+			log.Warnf("volume %s of %s is not found and skipped", m.Name, name)
+			continue
+		}
+		app.Mounts = append(app.Mounts, m.MountPath)
+		if size == "" {
+			continue
+		}
+		bytes, err := quantity(size)
+		if err != nil {
+			return nil, fmt.Errorf("cannot parse size of volume %s: %w", m.Name, err)
+		}
+		if uint64(bytes) > app.VolumeSize {
+			app.VolumeSize = uint64(bytes)
+		}
+	}
+	sort.Strings(app.Mounts)
+	return app, nil
+}
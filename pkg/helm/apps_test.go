@@ -0,0 +1,99 @@
+package helm_test
+
+import (
+	"testing"
+
+	"github.com/lf-edge/eden/pkg/helm"
+	"github.com/stretchr/testify/assert"
+)
+
+const manifests = `---
+# Source: web/templates/pvc.yaml
+apiVersion: v1
+kind: PersistentVolumeClaim
+metadata:
+  name: web-data
+spec:
+  resources:
+    requests:
+      storage: 2Gi
+---
+# Source: web/templates/service.yaml
+apiVersion: v1
+kind: Service
+metadata:
+  name: web
+spec:
+  type: NodePort
+  selector:
+    app: web
+  ports:
+  - port: 80
+    targetPort: http
+    nodePort: 30080
+---
+# Source: web/templates/deployment.yaml
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+spec:
+  replicas: 2
+  template:
+    metadata:
+      labels:
+        app: web
+    spec:
+      containers:
+      - name: nginx
+        image: nginx:1.25
+        env:
+        - name: MODE
+          value: test
+        - name: TOKEN
+          valueFrom:
+            secretKeyRef:
+              name: web
+              key: token
+        ports:
+        - name: http
+          containerPort: 8080
+        - containerPort: 9090
+        resources:
+          limits:
+            cpu: 1500m
+            memory: 256Mi
+        volumeMounts:
+        - name: data
+          mountPath: /data
+        - name: cache
+          mountPath: /cache
+        - name: config
+          mountPath: /etc/web
+      volumes:
+      - name: data
+        persistentVolumeClaim:
+          claimName: web-data
+      - name: cache
+        emptyDir: {}
+      - name: config
+        configMap:
+          name: web
+`
+
+func TestApps(t *testing.T) {
+	t.Parallel()
+
+	apps, err := helm.Apps([]byte(manifests))
+	assert.NoError(t, err)
+	assert.Equal(t, []*helm.App{{
+		Name:       "web",
+		Image:      "nginx:1.25",
+		Env:        []string{"MODE=test"},
+		Ports:      []string{"30080:8080", "9090:9090"},
+		Mounts:     []string{"/cache", "/data"},
+		VolumeSize: 2 << 30,
+		CPUs:       2,
+		Memory:     256 << 20,
+	}}, apps)
+}
@@ -0,0 +1,88 @@
+// Package helm renders Helm charts and translates workloads from the rendered manifests
+// into container apps to deploy on EVE.
+package helm
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/lf-edge/eden/pkg/defaults"
+	"github.com/lf-edge/eden/pkg/utils"
+	log "github.com/sirupsen/logrus"
+)
+
+// RenderOptions defines parameters of rendering of chart
+type RenderOptions struct {
+	// Release is name of release used in names of rendered objects
+	Release string
+	// ValuesFiles are files with values to override the default ones of chart
+	ValuesFiles []string
+	// Set are values in form of key=value
+	Set []string
+}
+
+// Render returns manifests rendered from chart with helm template. Chart is path
+// to the chart or its reference (e.g. oci://). Local helm is used if installed,
+// otherwise helm runs in container.
+func Render(chart string, opts RenderOptions) ([]byte, error) {
+	if opts.Release == "" {
+		opts.Release = filepath.Base(strings.TrimSuffix(chart, "/"))
+	}
+	if _, err := exec.LookPath("helm"); err == nil {
+		args := []string{"template", opts.Release, chart}
+		for _, f := range opts.ValuesFiles {
+			args = append(args, "--values", f)
+		}
+		for _, s := range opts.Set {
+			args = append(args, "--set", s)
+		}
+		log.Debugf("helm %s", strings.Join(args, " "))
+		var stdout, stderr bytes.Buffer
+		cmd := exec.Command("helm", args...)
+		cmd.Stdout = &stdout
+		cmd.Stderr = &stderr
+		if err := cmd.Run(); err != nil {
+			return nil, fmt.Errorf("helm template: %s %w", strings.TrimSpace(stderr.String()), err)
+		}
+		return stdout.Bytes(), nil
+	}
+	return renderInContainer(chart, opts)
+}
+
+// renderInContainer runs helm template in container with local chart and values files mounted
+func renderInContainer(chart string, opts RenderOptions) ([]byte, error) {
+	volumeMap := map[string]string{}
+	if _, err := os.Stat(chart); err == nil {
+		volumeMap["/chart"] = utils.ResolveAbsPath(chart)
+		chart = "/chart"
+	}
+	args := []string{"template", opts.Release, chart}
+	for i, f := range opts.ValuesFiles {
+		target := fmt.Sprintf("/values/%d.yaml", i)
+		volumeMap[target] = utils.ResolveAbsPath(f)
+		args = append(args, "--values", target)
+	}
+	for _, s := range opts.Set {
+		if strings.ContainsAny(s, " \t") {
+			return nil, fmt.Errorf("values with spaces are not supported without installed helm: %s", s)
+		}
+		args = append(args, "--set", s)
+	}
+	image := fmt.Sprintf("%s:%s", defaults.DefaultHelmContainerRef, defaults.DefaultHelmTag)
+	out, err := utils.RunDockerCommand(image, strings.Join(args, " "), volumeMap)
+	if err != nil {
+		return nil, fmt.Errorf("helm template in %s: %w", image, err)
+	}
+	// container runs with tty, so output has CRLF and may contain messages of helm
+	// before the first manifest
+	out = strings.ReplaceAll(out, "\r\n", "\n")
+	ind := strings.Index(out, "---\n")
+	if ind < 0 {
+		return nil, fmt.Errorf("helm template returns no manifests: %s", strings.TrimSpace(out))
+	}
+	return []byte(out[ind:]), nil
+}
@@ -0,0 +1,303 @@
+package linuxkit
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os/exec"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// importTimeout is time to wait for import of image into snapshot
+	importTimeout = 60 * time.Minute
+	// importPollInterval is delay between checks of import progress
+	importPollInterval = 10 * time.Second
+)
+
+// AWSClient manages resources of AWS with aws CLI using its configured credentials
+type AWSClient struct {
+	region string
+	// tool is path to aws CLI
+	tool string
+}
+
+// NewAWSClient creates a new AWS client for region
+func NewAWSClient(region string) (*AWSClient, error) {
+	if region == "" {
+		return nil, fmt.Errorf("the region is not specified")
+	}
+	tool, err := exec.LookPath("aws")
+	if err != nil {
+		return nil, fmt.Errorf("aws CLI is required: %w", err)
+	}
+	return &AWSClient{region: region, tool: tool}, nil
+}
+
+// run runs aws CLI and decodes its JSON output into result if it is not nil
+func (a AWSClient) run(result interface{}, args ...string) error {
+	args = append(args, "--region", a.region, "--output", "json")
+	log.Debugf("aws %s", strings.Join(args, " "))
+	var stdout, stderr bytes.Buffer
+	cmd := exec.Command(a.tool, args...)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("aws %s: %s %w", strings.Join(args[:2], " "), strings.TrimSpace(stderr.String()), err)
+	}
+	if result == nil || stdout.Len() == 0 {
+		return nil
+	}
+	return json.Unmarshal(stdout.Bytes(), result)
+}
+
+// UploadFile uploads file src into bucket with name dst
+func (a AWSClient) UploadFile(src, dst, bucketName string) error {
+	log.Infof("Uploading file %s to s3://%s/%s", src, bucketName, dst)
+	return a.run(nil, "s3", "cp", src, fmt.Sprintf("s3://%s/%s", bucketName, dst), "--only-show-errors")
+}
+
+// RemoveFile removes file from bucket
+func (a AWSClient) RemoveFile(file, bucketName string) error {
+	log.Infof("Removing file s3://%s/%s", bucketName, file)
+	return a.run(nil, "s3", "rm", fmt.Sprintf("s3://%s/%s", bucketName, file), "--only-show-errors")
+}
+
+// CreateImage imports raw disk uploaded into bucket as snapshot and registers UEFI image with name from it
+func (a AWSClient) CreateImage(name, file, bucketName, arch string) (string, error) {
+	if err := a.DeleteImage(name); err != nil {
+		return "", err
+	}
+	log.Infof("Importing snapshot from s3://%s/%s", bucketName, file)
+	var task struct {
+		ImportTaskID string `json:"ImportTaskId"`
+	}
+	if err := a.run(&task, "ec2", "import-snapshot", "--description", name,
+		"--disk-container", fmt.Sprintf("Format=RAW,UserBucket={S3Bucket=%s,S3Key=%s}", bucketName, file)); err != nil {
+		return "", err
+	}
+	snapshotID := ""
+	for start := time.Now(); ; time.Sleep(importPollInterval) {
+		if time.Since(start) > importTimeout {
+			return "", fmt.Errorf("timeout waiting for import of snapshot %s", task.ImportTaskID)
+		}
+		var tasks struct {
+			ImportSnapshotTasks []struct {
+				SnapshotTaskDetail struct {
+					Status        string
+					StatusMessage string
+					SnapshotID    string `json:"SnapshotId"`
+				}
+			}
+		}
+		if err := a.run(&tasks, "ec2", "describe-import-snapshot-tasks", "--import-task-ids", task.ImportTaskID); err != nil {
+			return "", err
+		}
+		if len(tasks.ImportSnapshotTasks) != 1 {
+			return "", fmt.Errorf("import task %s not found", task.ImportTaskID)
+		}
+		detail := tasks.ImportSnapshotTasks[0].SnapshotTaskDetail
+		switch detail.Status {
+		case "completed":
+			snapshotID = detail.SnapshotID
+		case "deleted", "deleting":
+			return "", fmt.Errorf("import of snapshot failed: %s", detail.StatusMessage)
+		default:
+			log.Debugf("import of snapshot: %s %s", detail.Status, detail.StatusMessage)
+		}
+		if snapshotID != "" {
+			break
+		}
+	}
+	awsArch := "x86_64"
+	if arch == "arm64" {
+		awsArch = "arm64"
+	}
+	log.Infof("Registering image %s from snapshot %s", name, snapshotID)
+	var image struct {
+		ImageID string `json:"ImageId"`
+	}
+	if err := a.run(&image, "ec2", "register-image", "--name", name,
+		"--architecture", awsArch, "--virtualization-type", "hvm", "--ena-support", "--boot-mode", "uefi",
+		"--root-device-name", "/dev/xvda",
+		"--block-device-mappings", fmt.Sprintf("DeviceName=/dev/xvda,Ebs={SnapshotId=%s,DeleteOnTermination=true}", snapshotID)); err != nil {
+		return "", err
+	}
+	return image.ImageID, nil
+}
+
+// DeleteImage deregisters image with name and removes its snapshots
+func (a AWSClient) DeleteImage(name string) error {
+	var images struct {
+		Images []struct {
+			ImageID             string `json:"ImageId"`
+			BlockDeviceMappings []struct {
+				Ebs struct {
+					SnapshotID string `json:"SnapshotId"`
+				}
+			}
+		}
+	}
+	if err := a.run(&images, "ec2", "describe-images", "--owners", "self",
+		"--filters", fmt.Sprintf("Name=name,Values=%s", name)); err != nil {
+		return err
+	}
+	for _, image := range images.Images {
+		log.Infof("Deleting existing image %s", image.ImageID)
+		if err := a.run(nil, "ec2", "deregister-image", "--image-id", image.ImageID); err != nil {
+			return err
+		}
+		for _, mapping := range image.BlockDeviceMappings {
+			if mapping.Ebs.SnapshotID == "" {
+				continue
+			}
+			if err := a.run(nil, "ec2", "delete-snapshot", "--snapshot-id", mapping.Ebs.SnapshotID); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// SetSecurityGroup creates security group with name allowing all traffic from sourceRanges
+func (a AWSClient) SetSecurityGroup(name string, sourceRanges []string) (string, error) {
+	if err := a.DeleteSecurityGroup(name); err != nil {
+		return "", err
+	}
+	log.Infof("setting security group %s for %s", name, sourceRanges)
+	var group struct {
+		GroupID string `json:"GroupId"`
+	}
+	if err := a.run(&group, "ec2", "create-security-group", "--group-name", name,
+		"--description", "access to EVE from eden"); err != nil {
+		return "", err
+	}
+	var ranges []string
+	for _, sourceRange := range sourceRanges {
+		ranges = append(ranges, fmt.Sprintf("{CidrIp=%s}", sourceRange))
+	}
+	if err := a.run(nil, "ec2", "authorize-security-group-ingress", "--group-id", group.GroupID,
+		"--ip-permissions", fmt.Sprintf("IpProtocol=-1,IpRanges=[%s]", strings.Join(ranges, ","))); err != nil {
+		return "", err
+	}
+	return group.GroupID, nil
+}
+
+// DeleteSecurityGroup deletes security group with name if it exists
+func (a AWSClient) DeleteSecurityGroup(name string) error {
+	var groups struct {
+		SecurityGroups []struct {
+			GroupID string `json:"GroupId"`
+		}
+	}
+	if err := a.run(&groups, "ec2", "describe-security-groups",
+		"--filters", fmt.Sprintf("Name=group-name,Values=%s", name)); err != nil {
+		return err
+	}
+	for _, group := range groups.SecurityGroups {
+		log.Infof("deleting security group %s", group.GroupID)
+		if err := a.run(nil, "ec2", "delete-security-group", "--group-id", group.GroupID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// instanceIDs returns identifiers of not terminated instances with name
+func (a AWSClient) instanceIDs(name string) ([]string, error) {
+	var reservations struct {
+		Reservations []struct {
+			Instances []struct {
+				InstanceID string `json:"InstanceId"`
+			}
+		}
+	}
+	if err := a.run(&reservations, "ec2", "describe-instances",
+		"--filters", fmt.Sprintf("Name=tag:Name,Values=%s", name),
+		"Name=instance-state-name,Values=pending,running,stopping,stopped"); err != nil {
+		return nil, err
+	}
+	var ids []string
+	for _, reservation := range reservations.Reservations {
+		for _, instance := range reservation.Instances {
+			ids = append(ids, instance.InstanceID)
+		}
+	}
+	return ids, nil
+}
+
+// CreateInstance runs instance with name from image with additional disks and waits for it
+func (a AWSClient) CreateInstance(name, imageID, instanceType, securityGroupID string, disks Disks) error {
+	if err := a.DeleteInstance(name); err != nil {
+		return err
+	}
+	log.Infof("Creating instance %s from image %s (type: %s in %s)", name, imageID, instanceType, a.region)
+	args := []string{"ec2", "run-instances", "--image-id", imageID, "--instance-type", instanceType,
+		"--security-group-ids", securityGroupID, "--count", "1",
+		"--tag-specifications", fmt.Sprintf("ResourceType=instance,Tags=[{Key=Name,Value=%s}]", name)}
+	var mappings []string
+	for i, disk := range disks {
+		mappings = append(mappings, fmt.Sprintf("DeviceName=/dev/sd%c,Ebs={VolumeSize=%d,DeleteOnTermination=true}",
+			'b'+i, convertMBtoGB(disk.Size)))
+	}
+	if len(mappings) > 0 {
+		args = append(args, append([]string{"--block-device-mappings"}, mappings...)...)
+	}
+	var reservation struct {
+		Instances []struct {
+			InstanceID string `json:"InstanceId"`
+		}
+	}
+	if err := a.run(&reservation, args...); err != nil {
+		return err
+	}
+	if len(reservation.Instances) != 1 {
+		return fmt.Errorf("unexpected count of instances: %d", len(reservation.Instances))
+	}
+	return a.run(nil, "ec2", "wait", "instance-running", "--instance-ids", reservation.Instances[0].InstanceID)
+}
+
+// DeleteInstance terminates instances with name and waits for termination
+func (a AWSClient) DeleteInstance(name string) error {
+	ids, err := a.instanceIDs(name)
+	if err != nil || len(ids) == 0 {
+		return err
+	}
+	log.Infof("Deleting existing instance %s", strings.Join(ids, ", "))
+	if err := a.run(nil, append([]string{"ec2", "terminate-instances", "--instance-ids"}, ids...)...); err != nil {
+		return err
+	}
+	return a.run(nil, append([]string{"ec2", "wait", "instance-terminated", "--instance-ids"}, ids...)...)
+}
+
+// GetInstancePublicIP returns public IP of instance with name
+func (a AWSClient) GetInstancePublicIP(name string) (string, error) {
+	ids, err := a.instanceIDs(name)
+	if err != nil {
+		return "", err
+	}
+	if len(ids) != 1 {
+		return "", fmt.Errorf("expected one instance %s, found %d", name, len(ids))
+	}
+	var reservations struct {
+		Reservations []struct {
+			Instances []struct {
+				PublicIPAddress string `json:"PublicIpAddress"`
+			}
+		}
+	}
+	if err := a.run(&reservations, "ec2", "describe-instances", "--instance-ids", ids[0]); err != nil {
+		return "", err
+	}
+	for _, reservation := range reservations.Reservations {
+		for _, instance := range reservation.Instances {
+			if instance.PublicIPAddress != "" {
+				return instance.PublicIPAddress, nil
+			}
+		}
+	}
+	return "", fmt.Errorf("instance %s has no public IP", name)
+}
@@ -0,0 +1,114 @@
+package linuxkit
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeAWS writes script imitating aws CLI into dir, it records calls into dir/calls,
+// reports instance with publicIP once it is created and fails subcommand failing
+func fakeAWS(t *testing.T, dir, publicIP, failing string) string {
+	tool := filepath.Join(dir, "aws")
+	script := `#!/bin/sh
+dir=` + dir + `
+echo "$@" >> "$dir/calls"
+case "$1 $2" in
+"` + failing + `")
+	echo "An error occurred (UnauthorizedOperation) when calling the $2 operation" >&2
+	exit 254;;
+"ec2 import-snapshot") echo '{"ImportTaskId": "import-snap-1"}';;
+"ec2 describe-import-snapshot-tasks") echo '{"ImportSnapshotTasks": [{"SnapshotTaskDetail": {"Status": "completed", "SnapshotId": "snap-1"}}]}';;
+"ec2 register-image") echo '{"ImageId": "ami-1"}';;
+"ec2 describe-images") echo '{"Images": []}';;
+"ec2 create-security-group") echo '{"GroupId": "sg-1"}';;
+"ec2 describe-security-groups") echo '{"SecurityGroups": []}';;
+"ec2 run-instances") touch "$dir/running"; echo '{"Instances": [{"InstanceId": "i-1"}]}';;
+"ec2 describe-instances")
+	if [ -f "$dir/running" ]; then
+		echo '{"Reservations": [{"Instances": [{"InstanceId": "i-1", "PublicIpAddress": "` + publicIP + `"}]}]}'
+	else
+		echo '{"Reservations": []}'
+	fi;;
+esac
+`
+	assert.NoError(t, os.WriteFile(tool, []byte(script), 0755))
+	return tool
+}
+
+// awsCalls returns calls of fake aws CLI
+func awsCalls(t *testing.T, dir string) []string {
+	calls, err := os.ReadFile(filepath.Join(dir, "calls"))
+	assert.NoError(t, err)
+	return strings.Split(strings.TrimSpace(string(calls)), "\n")
+}
+
+func TestNewAWSClient(t *testing.T) {
+	t.Parallel()
+
+	_, err := NewAWSClient("")
+	assert.Error(t, err)
+}
+
+func TestAWSClient(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	a := &AWSClient{region: "us-west-2", tool: fakeAWS(t, dir, "203.0.113.10", "")}
+
+	assert.NoError(t, a.UploadFile("/dist/eve.raw", "eden-eve.raw", "eden-eve-live"))
+	imageID, err := a.CreateImage("eden-eve", "eden-eve.raw", "eden-eve-live", "arm64")
+	assert.NoError(t, err)
+	assert.Equal(t, "ami-1", imageID)
+	groupID, err := a.SetSecurityGroup("eden-eve", []string{"198.51.100.1/32", "198.51.100.2/32"})
+	assert.NoError(t, err)
+	assert.Equal(t, "sg-1", groupID)
+	assert.NoError(t, a.CreateInstance("eden-eve", imageID, "c5.metal", groupID,
+		Disks{{Size: 2048}, {Size: 100}}))
+	ip, err := a.GetInstancePublicIP("eden-eve")
+	assert.NoError(t, err)
+	assert.Equal(t, "203.0.113.10", ip)
+
+	assert.Equal(t, []string{
+		"s3 cp /dist/eve.raw s3://eden-eve-live/eden-eve.raw --only-show-errors --region us-west-2 --output json",
+		"ec2 describe-images --owners self --filters Name=name,Values=eden-eve --region us-west-2 --output json",
+		"ec2 import-snapshot --description eden-eve --disk-container Format=RAW,UserBucket={S3Bucket=eden-eve-live,S3Key=eden-eve.raw} --region us-west-2 --output json",
+		"ec2 describe-import-snapshot-tasks --import-task-ids import-snap-1 --region us-west-2 --output json",
+		"ec2 register-image --name eden-eve --architecture arm64 --virtualization-type hvm --ena-support --boot-mode uefi " +
+			"--root-device-name /dev/xvda --block-device-mappings DeviceName=/dev/xvda,Ebs={SnapshotId=snap-1,DeleteOnTermination=true} --region us-west-2 --output json",
+		"ec2 describe-security-groups --filters Name=group-name,Values=eden-eve --region us-west-2 --output json",
+		"ec2 create-security-group --group-name eden-eve --description access to EVE from eden --region us-west-2 --output json",
+		"ec2 authorize-security-group-ingress --group-id sg-1 --ip-permissions IpProtocol=-1,IpRanges=[{CidrIp=198.51.100.1/32},{CidrIp=198.51.100.2/32}] --region us-west-2 --output json",
+		"ec2 describe-instances --filters Name=tag:Name,Values=eden-eve Name=instance-state-name,Values=pending,running,stopping,stopped --region us-west-2 --output json",
+		"ec2 run-instances --image-id ami-1 --instance-type c5.metal --security-group-ids sg-1 --count 1 " +
+			"--tag-specifications ResourceType=instance,Tags=[{Key=Name,Value=eden-eve}] --block-device-mappings " +
+			"DeviceName=/dev/sdb,Ebs={VolumeSize=2,DeleteOnTermination=true} DeviceName=/dev/sdc,Ebs={VolumeSize=1,DeleteOnTermination=true} --region us-west-2 --output json",
+		"ec2 wait instance-running --instance-ids i-1 --region us-west-2 --output json",
+		"ec2 describe-instances --filters Name=tag:Name,Values=eden-eve Name=instance-state-name,Values=pending,running,stopping,stopped --region us-west-2 --output json",
+		"ec2 describe-instances --instance-ids i-1 --region us-west-2 --output json",
+	}, awsCalls(t, dir))
+
+	// existing instance is terminated before removal of security group
+	assert.NoError(t, a.DeleteInstance("eden-eve"))
+	calls := awsCalls(t, dir)
+	assert.Equal(t, []string{
+		"ec2 terminate-instances --instance-ids i-1 --region us-west-2 --output json",
+		"ec2 wait instance-terminated --instance-ids i-1 --region us-west-2 --output json",
+	}, calls[len(calls)-2:])
+}
+
+func TestAWSClientErrors(t *testing.T) {
+	t.Parallel()
+
+	a := &AWSClient{region: "us-west-2", tool: fakeAWS(t, t.TempDir(), "", "ec2 create-security-group")}
+	_, err := a.SetSecurityGroup("eden-eve", []string{"198.51.100.1/32"})
+	assert.ErrorContains(t, err, "aws ec2 create-security-group: An error occurred (UnauthorizedOperation)")
+
+	// instance without public IP is not reachable by eden
+	assert.NoError(t, a.CreateInstance("eden-eve", "ami-1", "c5.metal", "sg-1", nil))
+	_, err = a.GetInstancePublicIP("eden-eve")
+	assert.ErrorContains(t, err, "has no public IP")
+}
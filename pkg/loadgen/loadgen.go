@@ -0,0 +1,285 @@
+// Package loadgen defines profiles of traffic generators running inside eclient image
+// and processes reports of them
+package loadgen
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	// ToolVegeta is HTTP load generator
+	ToolVegeta = "vegeta"
+	// ToolIperf3 is TCP/UDP throughput generator
+	ToolIperf3 = "iperf3"
+
+	// ResultPrefix marks line with report of generator in app logs
+	ResultPrefix = "LOADGEN_RESULT "
+)
+
+// Profile defines traffic to generate
+type Profile struct {
+	Name        string
+	Description string
+	Tool        string
+	// Port is the default port of target
+	Port int
+	// Rate is requests per second for vegeta
+	Rate int
+	// Duration of generation
+	Duration time.Duration
+	// Args are additional arguments for the tool
+	Args string
+}
+
+// Profiles contains supported profiles by name
+var Profiles = map[string]*Profile{
+	"http-100-rps": {
+		Description: "HTTP GET requests with rate of 100 per second",
+		Tool:        ToolVegeta, Port: 80, Rate: 100, Duration: time.Minute,
+	},
+	"http-1k-rps": {
+		Description: "HTTP GET requests with rate of 1000 per second",
+		Tool:        ToolVegeta, Port: 80, Rate: 1000, Duration: time.Minute,
+	},
+	"http-10k-rps": {
+		Description: "HTTP GET requests with rate of 10000 per second",
+		Tool:        ToolVegeta, Port: 80, Rate: 10000, Duration: time.Minute,
+	},
+	"tcp-throughput": {
+		Description: "TCP stream with maximal throughput",
+		Tool:        ToolIperf3, Port: 5201, Duration: 30 * time.Second,
+	},
+	"tcp-throughput-parallel": {
+		Description: "4 parallel TCP streams with maximal throughput",
+		Tool:        ToolIperf3, Port: 5201, Duration: 30 * time.Second, Args: "-P 4",
+	},
+	"udp-100mbit": {
+		Description: "UDP stream with bandwidth of 100 Mbit/s",
+		Tool:        ToolIperf3, Port: 5201, Duration: 30 * time.Second, Args: "-u -b 100M",
+	},
+}
+
+func init() {
+	for name, profile := range Profiles {
+		profile.Name = name
+	}
+}
+
+// ProfileNames returns sorted names of profiles
+func ProfileNames() []string {
+	var names []string
+	for name := range Profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// GetProfile returns profile by name
+func GetProfile(name string) (*Profile, error) {
+	profile, ok := Profiles[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown profile %q, available: %s", name, strings.Join(ProfileNames(), ", "))
+	}
+	return profile, nil
+}
+
+// Metadata returns envs for eclient image to run generator against target
+func (p *Profile) Metadata(target string, port int, duration time.Duration) string {
+	if port == 0 {
+		port = p.Port
+	}
+	if duration == 0 {
+		duration = p.Duration
+	}
+	envs := []string{
+		fmt.Sprintf("LOADGEN_TOOL=%s", p.Tool),
+		fmt.Sprintf("LOADGEN_TARGET=%s", target),
+		fmt.Sprintf("LOADGEN_PORT=%d", port),
+		fmt.Sprintf("LOADGEN_DURATION=%d", int(duration.Seconds())),
+	}
+	if p.Rate > 0 {
+		envs = append(envs, fmt.Sprintf("LOADGEN_RATE=%d", p.Rate))
+	}
+	if p.Args != "" {
+		envs = append(envs, fmt.Sprintf("LOADGEN_ARGS=%s", p.Args))
+	}
+	return strings.Join(envs, "\n")
+}
+
+// Result is the report of generator
+type Result struct {
+	Generator string `json:"generator,omitempty"`
+	Tool      string `json:"tool"`
+	// HTTP results
+	Requests    uint64         `json:"requests,omitempty"`
+	Rate        float64        `json:"rate,omitempty"`
+	Throughput  float64        `json:"throughput,omitempty"`
+	Success     float64        `json:"success,omitempty"`
+	LatencyMean time.Duration  `json:"latency_mean,omitempty"`
+	LatencyP99  time.Duration  `json:"latency_p99,omitempty"`
+	LatencyMax  time.Duration  `json:"latency_max,omitempty"`
+	StatusCodes map[string]int `json:"status_codes,omitempty"`
+	// TCP/UDP results
+	BitsPerSecond float64 `json:"bits_per_second,omitempty"`
+	Retransmits   int64   `json:"retransmits,omitempty"`
+	LostPercent   float64 `json:"lost_percent,omitempty"`
+	JitterMs      float64 `json:"jitter_ms,omitempty"`
+}
+
+type vegetaReport struct {
+	Latencies struct {
+		Mean int64 `json:"mean"`
+		P99  int64 `json:"99th"`
+		Max  int64 `json:"max"`
+	} `json:"latencies"`
+	Requests    uint64         `json:"requests"`
+	Rate        float64        `json:"rate"`
+	Throughput  float64        `json:"throughput"`
+	Success     float64        `json:"success"`
+	StatusCodes map[string]int `json:"status_codes"`
+}
+
+type iperfSum struct {
+	BitsPerSecond float64 `json:"bits_per_second"`
+	Retransmits   int64   `json:"retransmits"`
+	LostPercent   float64 `json:"lost_percent"`
+	JitterMs      float64 `json:"jitter_ms"`
+}
+
+type iperfReport struct {
+	End struct {
+		Sum         *iperfSum `json:"sum"`
+		SumSent     *iperfSum `json:"sum_sent"`
+		SumReceived *iperfSum `json:"sum_received"`
+	} `json:"end"`
+	Error string `json:"error"`
+}
+
+// ParseResult parses report of generator from line of app logs.
+// Returns nil if line does not contain report.
+func ParseResult(line string) (*Result, error) {
+	ind := strings.Index(line, ResultPrefix)
+	if ind < 0 {
+		return nil, nil
+	}
+	data := []byte(strings.TrimSpace(line[ind+len(ResultPrefix):]))
+	var keys map[string]json.RawMessage
+	if err := json.Unmarshal(data, &keys); err != nil {
+		return nil, fmt.Errorf("cannot parse report: %w", err)
+	}
+	if _, ok := keys["latencies"]; ok {
+		var report vegetaReport
+		if err := json.Unmarshal(data, &report); err != nil {
+			return nil, fmt.Errorf("cannot parse %s report: %w", ToolVegeta, err)
+		}
+		return &Result{
+			Tool:        ToolVegeta,
+			Requests:    report.Requests,
+			Rate:        report.Rate,
+			Throughput:  report.Throughput,
+			Success:     report.Success,
+			LatencyMean: time.Duration(report.Latencies.Mean),
+			LatencyP99:  time.Duration(report.Latencies.P99),
+			LatencyMax:  time.Duration(report.Latencies.Max),
+			StatusCodes: report.StatusCodes,
+		}, nil
+	}
+	var report iperfReport
+	if err := json.Unmarshal(data, &report); err != nil {
+		return nil, fmt.Errorf("cannot parse %s report: %w", ToolIperf3, err)
+	}
+	if report.Error != "" {
+		return nil, fmt.Errorf("%s failed: %s", ToolIperf3, report.Error)
+	}
+	result := &Result{Tool: ToolIperf3}
+	switch {
+	case report.End.SumReceived != nil:
+		// TCP
+		result.BitsPerSecond = report.End.SumReceived.BitsPerSecond
+		if report.End.SumSent != nil {
+			result.Retransmits = report.End.SumSent.Retransmits
+		}
+	case report.End.Sum != nil:
+		// UDP
+		result.BitsPerSecond = report.End.Sum.BitsPerSecond
+		result.LostPercent = report.End.Sum.LostPercent
+		result.JitterMs = report.End.Sum.JitterMs
+	default:
+		return nil, fmt.Errorf("no summary in %s report", ToolIperf3)
+	}
+	return result, nil
+}
+
+// Aggregate combines results of generators running in parallel:
+// rates and throughput are summed up, latencies and success are weighted by requests,
+// maximal values are taken for p99 and max latencies, loss and jitter are averaged
+func Aggregate(results []*Result) *Result {
+	if len(results) == 0 {
+		return nil
+	}
+	total := &Result{Tool: results[0].Tool}
+	var latencySum, successSum float64
+	for _, r := range results {
+		total.Requests += r.Requests
+		total.Rate += r.Rate
+		total.Throughput += r.Throughput
+		successSum += r.Success * float64(r.Requests)
+		latencySum += float64(r.LatencyMean) * float64(r.Requests)
+		if r.LatencyP99 > total.LatencyP99 {
+			total.LatencyP99 = r.LatencyP99
+		}
+		if r.LatencyMax > total.LatencyMax {
+			total.LatencyMax = r.LatencyMax
+		}
+		for code, count := range r.StatusCodes {
+			if total.StatusCodes == nil {
+				total.StatusCodes = map[string]int{}
+			}
+			total.StatusCodes[code] += count
+		}
+		total.BitsPerSecond += r.BitsPerSecond
+		total.Retransmits += r.Retransmits
+		total.LostPercent += r.LostPercent / float64(len(results))
+		total.JitterMs += r.JitterMs / float64(len(results))
+	}
+	if total.Requests > 0 {
+		total.Success = successSum / float64(total.Requests)
+		total.LatencyMean = time.Duration(latencySum / float64(total.Requests))
+	}
+	return total
+}
+
+// String returns human readable summary of result
+func (r *Result) String() string {
+	var parts []string
+	if r.Generator != "" {
+		parts = append(parts, r.Generator)
+	}
+	switch r.Tool {
+	case ToolVegeta:
+		parts = append(parts,
+			fmt.Sprintf("requests: %d", r.Requests),
+			fmt.Sprintf("rate: %.1f/s", r.Rate),
+			fmt.Sprintf("throughput: %.1f/s", r.Throughput),
+			fmt.Sprintf("success: %.2f%%", r.Success*100),
+			fmt.Sprintf("latency mean: %s", r.LatencyMean),
+			fmt.Sprintf("p99: %s", r.LatencyP99),
+			fmt.Sprintf("max: %s", r.LatencyMax))
+	case ToolIperf3:
+		parts = append(parts, fmt.Sprintf("bandwidth: %.2f Mbit/s", r.BitsPerSecond/1e6))
+		if r.Retransmits > 0 {
+			parts = append(parts, fmt.Sprintf("retransmits: %d", r.Retransmits))
+		}
+		if r.LostPercent > 0 || r.JitterMs > 0 {
+			parts = append(parts,
+				fmt.Sprintf("lost: %.2f%%", r.LostPercent),
+				fmt.Sprintf("jitter: %.3f ms", r.JitterMs))
+		}
+	}
+	return strings.Join(parts, ", ")
+}
@@ -0,0 +1,41 @@
+package loadgen_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/lf-edge/eden/pkg/loadgen"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseAndAggregate(t *testing.T) {
+	t.Parallel()
+
+	vegeta := `LOADGEN_RESULT {"latencies":{"total":0,"mean":2000000,"50th":1500000,"99th":9000000,"max":12000000},` +
+		`"requests":60000,"rate":1000.01,"throughput":999.9,"success":1,"status_codes":{"200":60000}}`
+	first, err := loadgen.ParseResult(vegeta)
+	assert.NoError(t, err)
+	second, err := loadgen.ParseResult(`LOADGEN_RESULT {"latencies":{"mean":4000000,"99th":20000000,"max":30000000},` +
+		`"requests":20000,"rate":333,"throughput":300,"success":0.5,"status_codes":{"200":10000,"0":10000}}`)
+	assert.NoError(t, err)
+	total := loadgen.Aggregate([]*loadgen.Result{first, second})
+	assert.Equal(t, uint64(80000), total.Requests)
+	assert.InDelta(t, 0.875, total.Success, 1e-9)
+	assert.Equal(t, 2500*time.Microsecond, total.LatencyMean)
+	assert.Equal(t, 20*time.Millisecond, total.LatencyP99)
+	assert.Equal(t, 70000, total.StatusCodes["200"])
+
+	tcp, err := loadgen.ParseResult(`LOADGEN_RESULT {"end":{"sum_sent":{"bits_per_second":1.1e9,"retransmits":3},` +
+		`"sum_received":{"bits_per_second":1e9}}}`)
+	assert.NoError(t, err)
+	assert.Equal(t, loadgen.ToolIperf3, tcp.Tool)
+	assert.Equal(t, 1e9, tcp.BitsPerSecond)
+	assert.Equal(t, int64(3), tcp.Retransmits)
+
+	_, err = loadgen.ParseResult(`LOADGEN_RESULT {"start":{},"end":{},"error":"unable to connect to server"}`)
+	assert.Error(t, err)
+
+	result, err := loadgen.ParseResult("Started eclient")
+	assert.NoError(t, err)
+	assert.Nil(t, result)
+}
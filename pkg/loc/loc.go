@@ -0,0 +1,197 @@
+// Package loc emulates Local Operator Console (LOC) which serves config to EVE
+// devices when the controller is not reachable.
+package loc
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/lf-edge/eden/pkg/utils"
+	"github.com/lf-edge/eve-api/go/config"
+	log "github.com/sirupsen/logrus"
+	"google.golang.org/protobuf/proto"
+)
+
+const (
+	// apiPrefix is prefix of V2 API of EVE which LOC implements
+	apiPrefix = "/api/v2/edgeDevice/id/"
+	// compoundConfigAction is requested by EVE to get config from LOC
+	compoundConfigAction = "compound-config"
+
+	configFile = "config.pb"
+	servedFile = "served"
+)
+
+// ErrNotStaged is returned if there is no config staged for device
+var ErrNotStaged = errors.New("no config staged")
+
+// Stage saves config of device into dir to be served by LOC
+func Stage(dir string, devConfig *config.EdgeDevConfig) error {
+	id := devConfig.GetId().GetUuid()
+	if id == "" {
+		return fmt.Errorf("config without id of device")
+	}
+	data, err := proto.Marshal(devConfig)
+	if err != nil {
+		return fmt.Errorf("cannot marshal config: %w", err)
+	}
+	devDir := filepath.Join(dir, id)
+	if err := os.MkdirAll(devDir, 0755); err != nil {
+		return err
+	}
+	tmp := filepath.Join(devDir, configFile+".tmp")
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, filepath.Join(devDir, configFile))
+}
+
+// Unstage removes config of device from dir, so LOC stops serving it
+func Unstage(dir, devID string) error {
+	return os.RemoveAll(filepath.Join(dir, devID))
+}
+
+// Staged returns config of device staged in dir
+func Staged(dir, devID string) (*config.EdgeDevConfig, error) {
+	data, err := os.ReadFile(filepath.Join(dir, devID, configFile))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("%w for %s", ErrNotStaged, devID)
+		}
+		return nil, err
+	}
+	var devConfig config.EdgeDevConfig
+	if err := proto.Unmarshal(data, &devConfig); err != nil {
+		return nil, fmt.Errorf("cannot unmarshal staged config of %s: %w", devID, err)
+	}
+	return &devConfig, nil
+}
+
+// LastServed returns time LOC served config to device the last time,
+// zero time if it was never served
+func LastServed(dir, devID string) (time.Time, error) {
+	data, err := os.ReadFile(filepath.Join(dir, devID, servedFile))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return time.Time{}, nil
+		}
+		return time.Time{}, err
+	}
+	return time.Parse(time.RFC3339Nano, strings.TrimSpace(string(data)))
+}
+
+// Server serves configs staged in Dir. Configs are signed with the signing certificate
+// of the controller, as EVE accepts config from LOC only if it is signed by the controller.
+type Server struct {
+	Dir         string
+	SigningCert string
+	SigningKey  string
+}
+
+// Handler returns handler of API requested by EVE from LOC
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc(apiPrefix, s.handleDevice)
+	return mux
+}
+
+// ListenAndServe serves API of LOC on addr
+func (s *Server) ListenAndServe(addr string) error {
+	log.Infof("LOC is serving configs from %s on %s", s.Dir, addr)
+	return http.ListenAndServe(addr, s.Handler())
+}
+
+func (s *Server) handleDevice(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	devID, action, ok := strings.Cut(strings.TrimPrefix(r.URL.Path, apiPrefix), "/")
+	if !ok || devID == "" || strings.Contains(devID, "..") {
+		http.Error(w, "unexpected path", http.StatusNotFound)
+		return
+	}
+	// request is signed by device, it is not verified by emulator
+	if _, err := io.Copy(io.Discard, r.Body); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if action != compoundConfigAction {
+		// info, metrics and logs are accepted to not make EVE retry them
+		log.Debugf("LOC: %s from %s", action, devID)
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+	resp, err := s.compoundConfig(devID)
+	if err != nil {
+		log.Errorf("LOC: config for %s: %s", devID, err)
+		if errors.Is(err, ErrNotStaged) {
+			http.Error(w, err.Error(), http.StatusNotFound)
+		} else {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+		return
+	}
+	w.Header().Set("Content-Type", "application/x-proto-binary")
+	if _, err := w.Write(resp); err != nil {
+		log.Errorf("LOC: cannot send config to %s: %s", devID, err)
+		return
+	}
+	log.Infof("LOC: config served to %s", devID)
+	served := []byte(time.Now().UTC().Format(time.RFC3339Nano))
+	if err := os.WriteFile(filepath.Join(s.Dir, devID, servedFile), served, 0644); err != nil {
+		log.Errorf("LOC: cannot save time config was served: %s", err)
+	}
+}
+
+// compoundConfig returns signed response with staged config of device
+func (s *Server) compoundConfig(devID string) ([]byte, error) {
+	data, err := os.ReadFile(filepath.Join(s.Dir, devID, configFile))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, ErrNotStaged
+		}
+		return nil, err
+	}
+	protectedConfig, err := utils.PrepareAuthContainer(data, s.SigningCert, s.SigningKey)
+	if err != nil {
+		return nil, fmt.Errorf("cannot sign config: %w", err)
+	}
+	// timestamp refers to local commands, there are none to send
+	compound, err := proto.Marshal(&config.CompoundEdgeDevConfig{ProtectedConfig: protectedConfig})
+	if err != nil {
+		return nil, err
+	}
+	envelope, err := utils.PrepareAuthContainer(compound, s.SigningCert, s.SigningKey)
+	if err != nil {
+		return nil, fmt.Errorf("cannot sign response: %w", err)
+	}
+	return proto.Marshal(envelope)
+}
+
+// StagedDevices returns IDs of devices with config staged in dir
+func StagedDevices(dir string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var ids []string
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		if _, err := os.Stat(filepath.Join(dir, entry.Name(), configFile)); err == nil {
+			ids = append(ids, entry.Name())
+		}
+	}
+	return ids, nil
+}
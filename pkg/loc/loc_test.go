@@ -0,0 +1,95 @@
+package loc_test
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/lf-edge/eden/pkg/loc"
+	"github.com/lf-edge/eve-api/go/auth"
+	"github.com/lf-edge/eve-api/go/config"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/protobuf/proto"
+)
+
+// writeSigningCert writes self-signed certificate and key of controller into dir
+func writeSigningCert(t *testing.T, dir string) (string, string) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "signing"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	assert.NoError(t, err)
+	keyDer, err := x509.MarshalECPrivateKey(key)
+	assert.NoError(t, err)
+	certPath, keyPath := filepath.Join(dir, "signing.pem"), filepath.Join(dir, "signing-key.pem")
+	assert.NoError(t, os.WriteFile(certPath, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0644))
+	assert.NoError(t, os.WriteFile(keyPath, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDer}), 0600))
+	return certPath, keyPath
+}
+
+func TestServer(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	certPath, keyPath := writeSigningCert(t, t.TempDir())
+	server := httptest.NewServer((&loc.Server{Dir: dir, SigningCert: certPath, SigningKey: keyPath}).Handler())
+	defer server.Close()
+	post := func(path string) *http.Response {
+		resp, err := http.Post(server.URL+path, "application/x-proto-binary", bytes.NewReader(nil))
+		assert.NoError(t, err)
+		return resp
+	}
+
+	const devID = "0c3a6b7b-3a0e-4cbb-8e8a-8b2b3a1f6a4d"
+	resp := post("/api/v2/edgeDevice/id/" + devID + "/compound-config")
+	resp.Body.Close()
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+
+	devConfig := &config.EdgeDevConfig{Id: &config.UUIDandVersion{Uuid: devID, Version: "5"}}
+	assert.NoError(t, loc.Stage(dir, devConfig))
+	staged, err := loc.Staged(dir, devID)
+	assert.NoError(t, err)
+	assert.Equal(t, "5", staged.GetId().GetVersion())
+
+	resp = post("/api/v2/edgeDevice/id/" + devID + "/compound-config")
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	var envelope auth.AuthContainer
+	assert.NoError(t, proto.Unmarshal(body, &envelope))
+	assert.NotEmpty(t, envelope.GetSignatureHash())
+	var compound config.CompoundEdgeDevConfig
+	assert.NoError(t, proto.Unmarshal(envelope.GetProtectedPayload().GetPayload(), &compound))
+	var served config.EdgeDevConfig
+	assert.NoError(t, proto.Unmarshal(compound.GetProtectedConfig().GetProtectedPayload().GetPayload(), &served))
+	assert.Equal(t, devID, served.GetId().GetUuid())
+	lastServed, err := loc.LastServed(dir, devID)
+	assert.NoError(t, err)
+	assert.False(t, lastServed.IsZero())
+
+	resp = post("/api/v2/edgeDevice/id/" + devID + "/info")
+	resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	assert.NoError(t, loc.Unstage(dir, devID))
+	_, err = loc.Staged(dir, devID)
+	assert.ErrorIs(t, err, loc.ErrNotStaged)
+}
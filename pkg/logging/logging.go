@@ -0,0 +1,127 @@
+// Package logging configures structured, leveled logging shared by Eden binaries:
+// output format, verbosity of components and IDs of requests sent to the controller.
+// Settings are propagated into child processes (e.g. test binaries) with environment variables.
+package logging
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/lf-edge/eden/pkg/defaults"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// FormatText is human-readable format of logs
+	FormatText = "text"
+	// FormatJSON prints every log entry as JSON object
+	FormatJSON = "json"
+)
+
+// Formats lists supported formats of logs
+var Formats = []string{FormatText, FormatJSON}
+
+var (
+	mu         sync.Mutex
+	components = map[string]*log.Logger{}
+	// componentLevels keeps levels set for components not used yet
+	componentLevels = map[string]log.Level{}
+)
+
+// Setup configures the standard logger with level and format, empty format is taken
+// from environment. Verbosity of components is set in format component=level,...
+// (also taken from environment if empty).
+func Setup(level, format, componentVerbosity string) error {
+	lvl, err := log.ParseLevel(level)
+	if err != nil {
+		return err
+	}
+	if format == "" {
+		format = os.Getenv(defaults.DefaultLogFormatEnv)
+	}
+	var formatter log.Formatter
+	switch format {
+	case "", FormatText:
+		formatter = &log.TextFormatter{}
+	case FormatJSON:
+		formatter = &log.JSONFormatter{}
+	default:
+		return fmt.Errorf("unsupported format of logs %q (supported: %s)", format, strings.Join(Formats, ", "))
+	}
+	if componentVerbosity == "" {
+		componentVerbosity = os.Getenv(defaults.DefaultLogComponentsEnv)
+	}
+	levels, err := parseComponentLevels(componentVerbosity)
+	if err != nil {
+		return err
+	}
+	log.SetOutput(os.Stdout)
+	log.SetLevel(lvl)
+	log.SetFormatter(formatter)
+
+	mu.Lock()
+	defer mu.Unlock()
+	componentLevels = levels
+	for name, logger := range components {
+		configure(name, logger)
+	}
+	// pass settings to child processes
+	if format != "" {
+		if err = os.Setenv(defaults.DefaultLogFormatEnv, format); err != nil {
+			return err
+		}
+	}
+	if componentVerbosity != "" {
+		if err = os.Setenv(defaults.DefaultLogComponentsEnv, componentVerbosity); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func parseComponentLevels(componentVerbosity string) (map[string]log.Level, error) {
+	levels := map[string]log.Level{}
+	if componentVerbosity == "" {
+		return levels, nil
+	}
+	for _, item := range strings.Split(componentVerbosity, ",") {
+		name, level, found := strings.Cut(strings.TrimSpace(item), "=")
+		if !found || name == "" {
+			return nil, fmt.Errorf("invalid verbosity of component %q, expected component=level", item)
+		}
+		lvl, err := log.ParseLevel(level)
+		if err != nil {
+			return nil, fmt.Errorf("invalid verbosity of component %s: %w", name, err)
+		}
+		levels[name] = lvl
+	}
+	return levels, nil
+}
+
+// configure applies settings of the standard logger and level of the component to logger
+func configure(name string, logger *log.Logger) {
+	std := log.StandardLogger()
+	logger.SetOutput(std.Out)
+	logger.SetFormatter(std.Formatter)
+	if level, ok := componentLevels[name]; ok {
+		logger.SetLevel(level)
+	} else {
+		logger.SetLevel(std.GetLevel())
+	}
+}
+
+// Component returns logger of the component which adds the component into every entry.
+// Its level is set with Setup, the level of the standard logger is used by default.
+func Component(name string) *log.Entry {
+	mu.Lock()
+	defer mu.Unlock()
+	logger, ok := components[name]
+	if !ok {
+		logger = log.New()
+		configure(name, logger)
+		components[name] = logger
+	}
+	return logger.WithField("component", name)
+}
@@ -0,0 +1,71 @@
+package logging_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/lf-edge/eden/pkg/logging"
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+// tests are not parallel as they change the standard logger and environment
+
+func TestSetup(t *testing.T) {
+	t.Setenv("EDEN_LOG_FORMAT", "")
+	t.Setenv("EDEN_LOG_COMPONENTS", "")
+
+	assert.NoError(t, logging.Setup("info", logging.FormatJSON, "controller=debug, sdn=error"))
+	assert.Equal(t, log.InfoLevel, log.GetLevel())
+	assert.IsType(t, &log.JSONFormatter{}, log.StandardLogger().Formatter)
+
+	controller := logging.Component("controller")
+	assert.Equal(t, log.DebugLevel, controller.Logger.GetLevel())
+	assert.Equal(t, "controller", controller.Data["component"])
+	assert.IsType(t, &log.JSONFormatter{}, controller.Logger.Formatter)
+	assert.Equal(t, log.ErrorLevel, logging.Component("sdn").Logger.GetLevel())
+	assert.Equal(t, log.InfoLevel, logging.Component("other").Logger.GetLevel())
+
+	// settings are kept in env for child processes and used when not set explicitly
+	assert.NoError(t, logging.Setup("warn", "", ""))
+	assert.IsType(t, &log.JSONFormatter{}, log.StandardLogger().Formatter)
+	assert.Equal(t, log.DebugLevel, controller.Logger.GetLevel())
+	assert.Equal(t, log.WarnLevel, logging.Component("other").Logger.GetLevel())
+
+	assert.Error(t, logging.Setup("info", "xml", ""))
+	assert.Error(t, logging.Setup("info", "", "controller"))
+	assert.Error(t, logging.Setup("info", "", "controller=loud"))
+
+	assert.NoError(t, logging.Setup("info", logging.FormatText, "controller=info"))
+}
+
+func TestRoundTripper(t *testing.T) {
+	var received []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = append(received, r.Header.Get(logging.RequestIDHeader))
+	}))
+	defer server.Close()
+
+	client := &http.Client{Transport: logging.RoundTripper("controller", http.DefaultTransport)}
+	for i := 0; i < 2; i++ {
+		resp, err := client.Get(server.URL)
+		if assert.NoError(t, err) {
+			resp.Body.Close()
+		}
+	}
+	req, err := http.NewRequest(http.MethodGet, server.URL, nil)
+	assert.NoError(t, err)
+	req.Header.Set(logging.RequestIDHeader, "custom")
+	resp, err := client.Do(req)
+	if assert.NoError(t, err) {
+		resp.Body.Close()
+	}
+
+	if assert.Len(t, received, 3) {
+		assert.True(t, strings.HasPrefix(received[0], logging.OperationID()+"-"))
+		assert.NotEqual(t, received[0], received[1])
+		assert.Equal(t, "custom", received[2])
+	}
+}
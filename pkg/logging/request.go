@@ -0,0 +1,86 @@
+package logging
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/lf-edge/eden/pkg/defaults"
+	log "github.com/sirupsen/logrus"
+)
+
+// RequestIDHeader carries ID of request sent to the controller
+const RequestIDHeader = "X-Request-Id"
+
+var (
+	operationOnce sync.Once
+	operationID   string
+	requestSeq    uint64
+)
+
+// OperationID returns ID shared by all requests of the eden invocation including
+// its child processes, it is generated by the first process and passed with environment
+func OperationID() string {
+	operationOnce.Do(func() {
+		operationID = os.Getenv(defaults.DefaultRequestIDEnv)
+		if operationID != "" {
+			return
+		}
+		buf := make([]byte, 4)
+		if _, err := rand.Read(buf); err != nil {
+			operationID = fmt.Sprintf("%08x", time.Now().UnixNano()&0xffffffff)
+		} else {
+			operationID = hex.EncodeToString(buf)
+		}
+		if err := os.Setenv(defaults.DefaultRequestIDEnv, operationID); err != nil {
+			log.Warnf("cannot pass ID of operation to child processes: %s", err)
+		}
+	})
+	return operationID
+}
+
+// NextRequestID returns ID for the next request of the operation
+func NextRequestID() string {
+	return fmt.Sprintf("%s-%d", OperationID(), atomic.AddUint64(&requestSeq, 1))
+}
+
+type requestLogger struct {
+	logger *log.Entry
+	next   http.RoundTripper
+}
+
+// RoundTripper sets ID into every request passing through next (unless already set)
+// and logs the request with the result using logger of the component
+func RoundTripper(component string, next http.RoundTripper) http.RoundTripper {
+	return &requestLogger{logger: Component(component), next: next}
+}
+
+// RoundTrip implements http.RoundTripper
+func (r *requestLogger) RoundTrip(req *http.Request) (*http.Response, error) {
+	requestID := req.Header.Get(RequestIDHeader)
+	if requestID == "" {
+		requestID = NextRequestID()
+		// RoundTripper must not modify the original request
+		req = req.Clone(req.Context())
+		req.Header.Set(RequestIDHeader, requestID)
+	}
+	entry := r.logger.WithFields(log.Fields{
+		"request_id": requestID,
+		"method":     req.Method,
+		"url":        req.URL.Redacted(),
+	})
+	started := time.Now()
+	resp, err := r.next.RoundTrip(req)
+	entry = entry.WithField("duration", time.Since(started).Round(time.Millisecond).String())
+	if err != nil {
+		entry.WithError(err).Debug("request failed")
+		return resp, err
+	}
+	entry.WithField("status", resp.StatusCode).Debug("request done")
+	return resp, nil
+}
@@ -149,3 +149,58 @@ func generatePhysicalIOs(ethCount, wifiCount, usbCount uint) []*config.PhysicalI
 	}
 	return physicalIOs
 }
+
+// NetworkTypeForIPVersions returns type of networks in device config matching IP versions
+// used by Eden (ipv4-only, ipv6-only or dual-stack). For IPv4 it is legacy V4,
+// which EVE interprets the same as dual-stack.
+func NetworkTypeForIPVersions(ipVersions string) (config.NetworkType, error) {
+	switch ipVersions {
+	case "", "ipv4-only":
+		return config.NetworkType_V4, nil
+	case "ipv6-only":
+		return config.NetworkType_V6Only, nil
+	case "dual-stack":
+		return config.NetworkType_DualV4V6, nil
+	}
+	return config.NetworkType_NETWORKTYPENOOP, fmt.Errorf("unsupported network type: %s", ipVersions)
+}
+
+// AddCellularModem adds emulated cellular modem with the APN into the devmodel,
+// the modem is used as uplink with higher cost than ethernet ports
+func AddCellularModem(devModel DevModel, apn string) {
+	label := defaults.DefaultCellularLabel
+	devModel.SetPhysicalIOs(append(devModel.PhysicalIOs(), &config.PhysicalIO{
+		Ptype:        evecommon.PhyIoType_PhyIoNetWWAN,
+		Phylabel:     label,
+		Logicallabel: label,
+		Assigngrp:    label,
+		Phyaddrs:     map[string]string{"Ifname": label},
+		Usage:        evecommon.PhyIoMemberUsage_PhyIoUsageMgmtAndApps,
+		UsagePolicy: &config.PhyIOUsagePolicy{
+			FreeUplink: false,
+		},
+	}))
+	devModel.SetNetworks(append(devModel.Networks(), &config.NetworkConfig{
+		Id:   defaults.NetCellularID,
+		Type: config.NetworkType_V4,
+		Ip: &config.Ipspec{
+			Dhcp:      config.DHCPType_Client,
+			DhcpRange: &config.IpRange{},
+		},
+		Wireless: &config.WirelessConfig{
+			Type: config.WirelessType_Cellular,
+			CellularCfg: []*config.CellularConfig{{
+				AccessPoints: []*config.CellularAccessPoint{{
+					SimSlot: 1,
+					Apn:     apn,
+				}},
+			}},
+		},
+	}))
+	devModel.SetAdapters(append(devModel.Adapters(), &config.SystemAdapter{
+		Name:        label,
+		Uplink:      true,
+		NetworkUUID: defaults.NetCellularID,
+		Cost:        10,
+	}))
+}
@@ -121,7 +121,10 @@ func GetDevModel(devModelType devModelType) (DevModel, error) {
 		return createVBox()
 	case devModelTypeParallels:
 		return createParallels()
-
+	case devModelTypeVMware:
+		return createVMware()
+	case devModelTypeHyperV:
+		return createHyperV()
 	}
 	return nil, fmt.Errorf("not implemented type: %s", devModelType)
 }
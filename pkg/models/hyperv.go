@@ -0,0 +1,121 @@
+package models
+
+import (
+	"github.com/lf-edge/eden/pkg/defaults"
+	"github.com/lf-edge/eve-api/go/config"
+	log "github.com/sirupsen/logrus"
+)
+
+// devModelTypeHyperV is model type for HyperV
+const devModelTypeHyperV devModelType = defaults.DefaultHyperVModel
+
+// DevModelHyperV is dev model fields
+type DevModelHyperV struct {
+	//physicalIOs is PhysicalIO slice for DevModelHyperV
+	physicalIOs []*config.PhysicalIO
+	//networks is NetworkConfig slice for DevModelHyperV
+	networks []*config.NetworkConfig
+	//adapters is SystemAdapter slice for DevModelHyperV
+	adapters     []*config.SystemAdapter
+	vlanAdapters []*config.VlanAdapter
+	bondAdapters []*config.BondAdapter
+	//adapterForSwitches is name of adapter for use in switch
+	adapterForSwitches []string
+}
+
+// Config returns map with config overwrites
+func (ctx *DevModelHyperV) Config() map[string]interface{} {
+	cfg := make(map[string]interface{})
+	cfg["eve.serial"] = "*"
+	cfg["eve.devmodel"] = ctx.DevModelType()
+	return cfg
+}
+
+// DiskReadyMessage to show when image is ready
+func (ctx *DevModelHyperV) DiskReadyMessage() string {
+	return "EVE image ready: %s"
+}
+
+// DiskFormat to use for build image
+func (ctx *DevModelHyperV) DiskFormat() string {
+	return "vhdx"
+}
+
+// GetPortConfig returns PortConfig overwrite
+func (ctx *DevModelHyperV) GetPortConfig(_ string, _ string) string {
+	return ""
+}
+
+// SetWiFiParams not implemented for HyperV
+func (ctx *DevModelHyperV) SetWiFiParams(_ string, _ string) {
+	log.Warning("not implemented for HyperV")
+}
+
+// Adapters returns adapters of DevModelHyperV
+func (ctx *DevModelHyperV) Adapters() []*config.SystemAdapter {
+	return ctx.adapters
+}
+
+// SetAdapters sets systems adapters of devModel
+func (ctx *DevModelHyperV) SetAdapters(adapters []*config.SystemAdapter) {
+	ctx.adapters = adapters
+}
+
+// Networks returns networks of DevModelHyperV
+func (ctx *DevModelHyperV) Networks() []*config.NetworkConfig {
+	return ctx.networks
+}
+
+// SetNetworks sets networks of devModel
+func (ctx *DevModelHyperV) SetNetworks(networks []*config.NetworkConfig) {
+	ctx.networks = networks
+}
+
+// PhysicalIOs returns physicalIOs of DevModelHyperV
+func (ctx *DevModelHyperV) PhysicalIOs() []*config.PhysicalIO {
+	return ctx.physicalIOs
+}
+
+// SetPhysicalIOs sets physicalIOs of devModel
+func (ctx *DevModelHyperV) SetPhysicalIOs(physicalIOs []*config.PhysicalIO) {
+	ctx.physicalIOs = physicalIOs
+}
+
+// VlanAdapters returns Vlan adapters of devModel
+func (ctx *DevModelHyperV) VlanAdapters() []*config.VlanAdapter {
+	return ctx.vlanAdapters
+}
+
+// SetVlanAdapters sets Vlan adapters of devModel
+func (ctx *DevModelHyperV) SetVlanAdapters(vlans []*config.VlanAdapter) {
+	ctx.vlanAdapters = vlans
+}
+
+// BondAdapters returns Bond adapters of devModel
+func (ctx *DevModelHyperV) BondAdapters() []*config.BondAdapter {
+	return ctx.bondAdapters
+}
+
+// SetBondAdapters sets Bond adapters of devModel
+func (ctx *DevModelHyperV) SetBondAdapters(bonds []*config.BondAdapter) {
+	ctx.bondAdapters = bonds
+}
+
+// AdapterForSwitches returns adapterForSwitches of DevModelHyperV
+func (ctx *DevModelHyperV) AdapterForSwitches() []string {
+	return ctx.adapterForSwitches
+}
+
+// DevModelType returns devModelType of DevModelHyperV
+func (ctx *DevModelHyperV) DevModelType() string {
+	return string(devModelTypeHyperV)
+}
+
+func createHyperV() (DevModel, error) {
+	return &DevModelHyperV{
+			physicalIOs:        generatePhysicalIOs(2, 0, 4),
+			networks:           generateNetworkConfigs(2, 0),
+			adapters:           generateSystemAdapters(2, 0),
+			adapterForSwitches: []string{"eth1"}},
+		nil
+}
@@ -0,0 +1,167 @@
+package models
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/lf-edge/eve-api/go/config"
+	"github.com/lf-edge/eve-api/go/evecommon"
+)
+
+// pciAddressRe matches PCI address in long form, like 0000:01:00.0
+var pciAddressRe = regexp.MustCompile(`^[0-9a-f]{4}:[0-9a-f]{2}:[0-9a-f]{2}\.[0-7]$`)
+
+// pciSysfs is location of PCI devices of host
+var pciSysfs = "/sys/bus/pci/devices"
+
+// qemuPCISlot is the first slot of root bus of QEMU used for devices passed through into EVE
+const qemuPCISlot = 0x10
+
+// PCIPassthrough is PCI device assigned to applications on EVE, it is defined in eve.pci-passthrough
+// as <label>=<pci address>[@<group>]. With QEMU address is address of device of host passed into EVE
+// with VFIO, on hardware it is address of device of EVE itself.
+type PCIPassthrough struct {
+	// Label is logical label of adapter to assign it to applications
+	Label string
+	// Address is PCI address of device
+	Address string
+	// Group is assignment group, devices of one IOMMU group must be in the same group
+	Group string
+}
+
+// ParsePCIPassthrough parses definition of PCI passthrough device
+func ParsePCIPassthrough(s string) (*PCIPassthrough, error) {
+	label, address, ok := strings.Cut(strings.TrimSpace(s), "=")
+	if !ok || label == "" {
+		return nil, fmt.Errorf("PCI passthrough %q must be in form <label>=<pci address>[@<group>]", s)
+	}
+	address, group, _ := strings.Cut(address, "@")
+	address = strings.ToLower(address)
+	if !pciAddressRe.MatchString(address) {
+		return nil, fmt.Errorf("PCI passthrough %s: address %q is not in form 0000:01:00.0", label, address)
+	}
+	return &PCIPassthrough{Label: label, Address: address, Group: group}, nil
+}
+
+// ParsePCIPassthroughList parses definitions of PCI passthrough devices and checks that labels are unique
+func ParsePCIPassthroughList(list []string) ([]*PCIPassthrough, error) {
+	var result []*PCIPassthrough
+	labels := map[string]bool{}
+	for _, s := range list {
+		if strings.TrimSpace(s) == "" {
+			continue
+		}
+		device, err := ParsePCIPassthrough(s)
+		if err != nil {
+			return nil, err
+		}
+		if labels[device.Label] {
+			return nil, fmt.Errorf("PCI passthrough label %s is used twice", device.Label)
+		}
+		labels[device.Label] = true
+		result = append(result, device)
+	}
+	return result, nil
+}
+
+// QemuPCIAddress returns address of i-th passed through device inside EVE running in QEMU
+func QemuPCIAddress(i int) string {
+	return fmt.Sprintf("0000:00:%02x.0", qemuPCISlot+i)
+}
+
+// QemuPCISlot returns slot of root bus of QEMU for i-th passed through device
+func QemuPCISlot(i int) int {
+	return qemuPCISlot + i
+}
+
+// HostPCIClass returns class of PCI device of host, like 0x030000 for VGA controller
+func HostPCIClass(address string) (uint32, error) {
+	data, err := os.ReadFile(filepath.Join(pciSysfs, address, "class"))
+	if err != nil {
+		return 0, err
+	}
+	class, err := strconv.ParseUint(strings.TrimSpace(string(data)), 0, 32)
+	if err != nil {
+		return 0, fmt.Errorf("cannot parse class of PCI device %s: %w", address, err)
+	}
+	return uint32(class), nil
+}
+
+// HostIOMMUGroup returns IOMMU group of PCI device of host
+func HostIOMMUGroup(address string) (string, error) {
+	link, err := os.Readlink(filepath.Join(pciSysfs, address, "iommu_group"))
+	if err != nil {
+		return "", fmt.Errorf("no IOMMU group of PCI device %s, is IOMMU enabled on host: %w", address, err)
+	}
+	return filepath.Base(link), nil
+}
+
+// CheckHostVFIO checks that PCI device of host is bound to vfio-pci driver to pass it into QEMU
+func CheckHostVFIO(address string) error {
+	link, err := os.Readlink(filepath.Join(pciSysfs, address, "driver"))
+	if err != nil || filepath.Base(link) != "vfio-pci" {
+		return fmt.Errorf("PCI device %s of host is not bound to vfio-pci, bind it with 'driverctl set-override %s vfio-pci'",
+			address, address)
+	}
+	return nil
+}
+
+// pciPhyIoType returns type of adapter for PCI class
+func pciPhyIoType(class uint32) evecommon.PhyIoType {
+	switch {
+	case class>>16 == 0x02:
+		return evecommon.PhyIoType_PhyIoNetEth
+	case class>>8 == 0x0108:
+		return evecommon.PhyIoType_PhyIoNVMEStorage
+	case class>>8 == 0x0106:
+		return evecommon.PhyIoType_PhyIoSATAStorage
+	case class>>8 == 0x0c03:
+		return evecommon.PhyIoType_PhyIoUSBController
+	case class>>8 == 0x0403 || class>>8 == 0x0401:
+		return evecommon.PhyIoType_PhyIoAudio
+	default:
+		// GPUs and other accelerators
+		return evecommon.PhyIoType_PhyIoOther
+	}
+}
+
+// PCIPhysicalIOs returns adapters for PCI passthrough devices to add into device model.
+// For EVE in QEMU (virtual is true) devices are found on host and placed on root bus of QEMU
+// one by one, their type and IOMMU group are taken from host.
+func PCIPhysicalIOs(devices []*PCIPassthrough, virtual bool) ([]*config.PhysicalIO, error) {
+	var physicalIOs []*config.PhysicalIO
+	for i, device := range devices {
+		address, group, ptype := device.Address, device.Group, evecommon.PhyIoType_PhyIoOther
+		if virtual {
+			class, err := HostPCIClass(device.Address)
+			if err != nil {
+				return nil, fmt.Errorf("PCI device %s not found on host: %w", device.Address, err)
+			}
+			ptype = pciPhyIoType(class)
+			if group == "" {
+				iommuGroup, err := HostIOMMUGroup(device.Address)
+				if err != nil {
+					return nil, err
+				}
+				group = "iommu" + iommuGroup
+			}
+			address = QemuPCIAddress(i)
+		}
+		if group == "" {
+			group = device.Label
+		}
+		physicalIOs = append(physicalIOs, &config.PhysicalIO{
+			Ptype:        ptype,
+			Phylabel:     device.Label,
+			Logicallabel: device.Label,
+			Assigngrp:    group,
+			Phyaddrs:     map[string]string{"PciLong": address},
+			Usage:        evecommon.PhyIoMemberUsage_PhyIoUsageDedicated,
+		})
+	}
+	return physicalIOs, nil
+}
@@ -0,0 +1,38 @@
+package models_test
+
+import (
+	"testing"
+
+	"github.com/lf-edge/eden/pkg/models"
+	"github.com/lf-edge/eve-api/go/evecommon"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPCIPassthrough(t *testing.T) {
+	t.Parallel()
+
+	devices, err := models.ParsePCIPassthroughList([]string{"gpu0=0000:01:00.0", "gpu0-audio=0000:01:00.1@gpu", ""})
+	assert.NoError(t, err)
+	assert.Equal(t, []*models.PCIPassthrough{
+		{Label: "gpu0", Address: "0000:01:00.0"},
+		{Label: "gpu0-audio", Address: "0000:01:00.1", Group: "gpu"},
+	}, devices)
+
+	// addresses of hardware are used as is
+	physicalIOs, err := models.PCIPhysicalIOs(devices, false)
+	assert.NoError(t, err)
+	assert.Len(t, physicalIOs, 2)
+	assert.Equal(t, evecommon.PhyIoType_PhyIoOther, physicalIOs[0].Ptype)
+	assert.Equal(t, "gpu0", physicalIOs[0].Assigngrp)
+	assert.Equal(t, map[string]string{"PciLong": "0000:01:00.1"}, physicalIOs[1].Phyaddrs)
+	assert.Equal(t, "gpu", physicalIOs[1].Assigngrp)
+
+	assert.Equal(t, "0000:00:11.0", models.QemuPCIAddress(1))
+
+	_, err = models.ParsePCIPassthroughList([]string{"gpu0=01:00.0"})
+	assert.Error(t, err)
+	_, err = models.ParsePCIPassthroughList([]string{"0000:01:00.0"})
+	assert.Error(t, err)
+	_, err = models.ParsePCIPassthroughList([]string{"gpu0=0000:01:00.0", "gpu0=0000:02:00.0"})
+	assert.Error(t, err)
+}
@@ -0,0 +1,121 @@
+package models
+
+import (
+	"github.com/lf-edge/eden/pkg/defaults"
+	"github.com/lf-edge/eve-api/go/config"
+	log "github.com/sirupsen/logrus"
+)
+
+// devModelTypeVMware is model type for VMware
+const devModelTypeVMware devModelType = defaults.DefaultVMwareModel
+
+// DevModelVMware is dev model fields
+type DevModelVMware struct {
+	//physicalIOs is PhysicalIO slice for DevModelVMware
+	physicalIOs []*config.PhysicalIO
+	//networks is NetworkConfig slice for DevModelVMware
+	networks []*config.NetworkConfig
+	//adapters is SystemAdapter slice for DevModelVMware
+	adapters     []*config.SystemAdapter
+	vlanAdapters []*config.VlanAdapter
+	bondAdapters []*config.BondAdapter
+	//adapterForSwitches is name of adapter for use in switch
+	adapterForSwitches []string
+}
+
+// Config returns map with config overwrites
+func (ctx *DevModelVMware) Config() map[string]interface{} {
+	cfg := make(map[string]interface{})
+	cfg["eve.serial"] = "*"
+	cfg["eve.devmodel"] = ctx.DevModelType()
+	return cfg
+}
+
+// DiskReadyMessage to show when image is ready
+func (ctx *DevModelVMware) DiskReadyMessage() string {
+	return "EVE image ready: %s"
+}
+
+// DiskFormat to use for build image
+func (ctx *DevModelVMware) DiskFormat() string {
+	return "vmdk"
+}
+
+// GetPortConfig returns PortConfig overwrite
+func (ctx *DevModelVMware) GetPortConfig(_ string, _ string) string {
+	return ""
+}
+
+// SetWiFiParams not implemented for VMware
+func (ctx *DevModelVMware) SetWiFiParams(_ string, _ string) {
+	log.Warning("not implemented for VMware")
+}
+
+// Adapters returns adapters of DevModelVMware
+func (ctx *DevModelVMware) Adapters() []*config.SystemAdapter {
+	return ctx.adapters
+}
+
+// SetAdapters sets systems adapters of devModel
+func (ctx *DevModelVMware) SetAdapters(adapters []*config.SystemAdapter) {
+	ctx.adapters = adapters
+}
+
+// Networks returns networks of DevModelVMware
+func (ctx *DevModelVMware) Networks() []*config.NetworkConfig {
+	return ctx.networks
+}
+
+// SetNetworks sets networks of devModel
+func (ctx *DevModelVMware) SetNetworks(networks []*config.NetworkConfig) {
+	ctx.networks = networks
+}
+
+// PhysicalIOs returns physicalIOs of DevModelVMware
+func (ctx *DevModelVMware) PhysicalIOs() []*config.PhysicalIO {
+	return ctx.physicalIOs
+}
+
+// SetPhysicalIOs sets physicalIOs of devModel
+func (ctx *DevModelVMware) SetPhysicalIOs(physicalIOs []*config.PhysicalIO) {
+	ctx.physicalIOs = physicalIOs
+}
+
+// VlanAdapters returns Vlan adapters of devModel
+func (ctx *DevModelVMware) VlanAdapters() []*config.VlanAdapter {
+	return ctx.vlanAdapters
+}
+
+// SetVlanAdapters sets Vlan adapters of devModel
+func (ctx *DevModelVMware) SetVlanAdapters(vlans []*config.VlanAdapter) {
+	ctx.vlanAdapters = vlans
+}
+
+// BondAdapters returns Bond adapters of devModel
+func (ctx *DevModelVMware) BondAdapters() []*config.BondAdapter {
+	return ctx.bondAdapters
+}
+
+// SetBondAdapters sets Bond adapters of devModel
+func (ctx *DevModelVMware) SetBondAdapters(bonds []*config.BondAdapter) {
+	ctx.bondAdapters = bonds
+}
+
+// AdapterForSwitches returns adapterForSwitches of DevModelVMware
+func (ctx *DevModelVMware) AdapterForSwitches() []string {
+	return ctx.adapterForSwitches
+}
+
+// DevModelType returns devModelType of DevModelVMware
+func (ctx *DevModelVMware) DevModelType() string {
+	return string(devModelTypeVMware)
+}
+
+func createVMware() (DevModel, error) {
+	return &DevModelVMware{
+			physicalIOs:        generatePhysicalIOs(2, 0, 4),
+			networks:           generateNetworkConfigs(2, 0),
+			adapters:           generateSystemAdapters(2, 0),
+			adapterForSwitches: []string{"eth1"}},
+		nil
+}
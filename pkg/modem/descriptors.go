@@ -0,0 +1,161 @@
+package modem
+
+import (
+	"encoding/binary"
+	"unicode/utf16"
+)
+
+// the modem is a high-speed USB device with MBIM function: communication interface with
+// interrupt endpoint for notifications and data interface with bulk endpoints in alternate setting 1
+const (
+	epNotify  = 0x81
+	epDataIn  = 0x82
+	epDataOut = 0x02
+
+	intfControl = 0
+	intfData    = 1
+
+	dataAltSettingMBIM = 1
+
+	// maxControlMessage is maximum length of MBIM control message
+	maxControlMessage = 4096
+	// maxSegmentSize is MTU of the data connection
+	maxSegmentSize = 1500
+)
+
+// descriptor types
+const (
+	descDevice          = 1
+	descConfiguration   = 2
+	descString          = 3
+	descInterface       = 4
+	descEndpoint        = 5
+	descDeviceQualifier = 6
+	descCSInterface     = 0x24
+)
+
+// standard requests not handled by QEMU itself
+const (
+	reqGetStatus     = 0
+	reqClearFeature  = 1
+	reqSetFeature    = 3
+	reqGetDescriptor = 6
+)
+
+type usbEndpoint struct {
+	address       uint8
+	attributes    uint8
+	redirType     uint8
+	maxPacketSize uint16
+	interval      uint8
+	intf          uint8
+}
+
+type usbInterface struct {
+	number   uint8
+	class    uint8
+	subClass uint8
+	protocol uint8
+}
+
+type usbDevice struct {
+	class, subClass, protocol uint8
+	vendorID, productID       uint16
+	version                   uint16
+	strings                   []string
+	interfaces                []usbInterface
+}
+
+// mbimDevice describes the emulated modem
+var mbimDevice = &usbDevice{
+	vendorID:  0x46f4, // vendor of devices emulated by QEMU
+	productID: 0x00e0,
+	version:   0x0100,
+	strings:   []string{"LF Edge", "Eden emulated modem", "0000000001"},
+	interfaces: []usbInterface{
+		{number: intfControl, class: 0x02, subClass: 0x0e, protocol: 0x00}, // CDC MBIM
+		{number: intfData, class: 0x0a, subClass: 0x00, protocol: 0x02},    // CDC data, MBIM NTB
+	},
+}
+
+// endpoints returns endpoints of all interfaces of the device
+func (dev *usbDevice) endpoints() []usbEndpoint {
+	return []usbEndpoint{
+		{address: epNotify, attributes: 0x03, redirType: usbRedirTypeInterrupt, maxPacketSize: 64, interval: 9, intf: intfControl},
+		{address: epDataIn, attributes: 0x02, redirType: usbRedirTypeBulk, maxPacketSize: 512, intf: intfData},
+		{address: epDataOut, attributes: 0x02, redirType: usbRedirTypeBulk, maxPacketSize: 512, intf: intfData},
+		{address: 0x00, redirType: usbRedirTypeControl, maxPacketSize: 64},
+		{address: 0x80, redirType: usbRedirTypeControl, maxPacketSize: 64},
+	}
+}
+
+func (dev *usbDevice) deviceDescriptor() []byte {
+	desc := []byte{18, descDevice, 0x00, 0x02, dev.class, dev.subClass, dev.protocol, 64,
+		0, 0, 0, 0, 0, 0, 1, 2, 3, 1}
+	binary.LittleEndian.PutUint16(desc[8:], dev.vendorID)
+	binary.LittleEndian.PutUint16(desc[10:], dev.productID)
+	binary.LittleEndian.PutUint16(desc[12:], dev.version)
+	return desc
+}
+
+func (dev *usbDevice) qualifierDescriptor() []byte {
+	return []byte{10, descDeviceQualifier, 0x00, 0x02, dev.class, dev.subClass, dev.protocol, 64, 1, 0}
+}
+
+func (dev *usbDevice) configurationDescriptor() []byte {
+	control, data := dev.interfaces[intfControl], dev.interfaces[intfData]
+	desc := []byte{9, descConfiguration, 0, 0, uint8(len(dev.interfaces)), 1, 0, 0x80, 250}
+	desc = append(desc, 9, descInterface, control.number, 0, 1, control.class, control.subClass, control.protocol, 0)
+	// CDC header, union and MBIM functional descriptors
+	desc = append(desc, 5, descCSInterface, 0x00, 0x10, 0x01)
+	desc = append(desc, 5, descCSInterface, 0x06, control.number, data.number)
+	mbim := []byte{12, descCSInterface, 0x1b, 0x00, 0x01, 0, 0, 32, 128, 0, 0, 0}
+	binary.LittleEndian.PutUint16(mbim[5:], maxControlMessage)
+	binary.LittleEndian.PutUint16(mbim[9:], maxSegmentSize)
+	desc = append(desc, mbim...)
+	desc = append(desc, endpointDescriptor(dev.endpoints()[0])...)
+	// data interface without endpoints in alternate setting 0 as required by MBIM
+	desc = append(desc, 9, descInterface, data.number, 0, 0, data.class, data.subClass, data.protocol, 0)
+	desc = append(desc, 9, descInterface, data.number, dataAltSettingMBIM, 2, data.class, data.subClass, data.protocol, 0)
+	desc = append(desc, endpointDescriptor(dev.endpoints()[1])...)
+	desc = append(desc, endpointDescriptor(dev.endpoints()[2])...)
+	binary.LittleEndian.PutUint16(desc[2:], uint16(len(desc)))
+	return desc
+}
+
+func endpointDescriptor(ep usbEndpoint) []byte {
+	desc := []byte{7, descEndpoint, ep.address, ep.attributes, 0, 0, ep.interval}
+	binary.LittleEndian.PutUint16(desc[4:], ep.maxPacketSize)
+	return desc
+}
+
+// stringDescriptor returns string descriptor with index, the index 0 lists supported languages
+func (dev *usbDevice) stringDescriptor(index uint8) []byte {
+	if index == 0 {
+		return []byte{4, descString, 0x09, 0x04} // English (United States)
+	}
+	if int(index) > len(dev.strings) {
+		return nil
+	}
+	desc := []byte{0, descString}
+	for _, r := range utf16.Encode([]rune(dev.strings[index-1])) {
+		desc = binary.LittleEndian.AppendUint16(desc, r)
+	}
+	desc[0] = uint8(len(desc))
+	return desc
+}
+
+// descriptor returns descriptor requested with GET_DESCRIPTOR or nil if there is no such one
+func (dev *usbDevice) descriptor(value uint16) []byte {
+	switch value >> 8 {
+	case descDevice:
+		return dev.deviceDescriptor()
+	case descConfiguration:
+		return dev.configurationDescriptor()
+	case descString:
+		return dev.stringDescriptor(uint8(value))
+	case descDeviceQualifier:
+		return dev.qualifierDescriptor()
+	}
+	return nil
+}
@@ -0,0 +1,543 @@
+package modem
+
+import (
+	"encoding/binary"
+	"strings"
+	"sync"
+	"unicode/utf16"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// MBIM control messages (https://www.usb.org/document-library/mobile-broadband-interface-model-v10-errata-1),
+// the modem implements Basic Connect service enough for ModemManager to register and connect
+
+// message types
+const (
+	mbimOpen           = 0x00000001
+	mbimClose          = 0x00000002
+	mbimCommand        = 0x00000003
+	mbimOpenDone       = 0x80000001
+	mbimCloseDone      = 0x80000002
+	mbimCommandDone    = 0x80000003
+	mbimFunctionError  = 0x80000004
+	mbimIndicateStatus = 0x80000007
+
+	mbimHeaderLen   = 12
+	mbimFragmentLen = 8
+	// mbimCommandLen is length of command message without information buffer
+	mbimCommandLen = mbimHeaderLen + mbimFragmentLen + 16 + 3*4
+
+	mbimCommandSet = 1
+)
+
+// status codes
+const (
+	mbimStatusSuccess             = 0
+	mbimStatusFailure             = 2
+	mbimStatusNoDeviceSupport     = 9
+	mbimStatusInvalidAccessString = 18
+	mbimStatusRadioPowerOff       = 20
+	mbimStatusInvalidParameters   = 21
+
+	mbimErrorNotOpened   = 5
+	mbimErrorUnknown     = 6
+	mbimErrorMaxTransfer = 8
+)
+
+// commands of Basic Connect service
+const (
+	mbimCIDDeviceCaps                 = 1
+	mbimCIDSubscriberReadyStatus      = 2
+	mbimCIDRadioState                 = 3
+	mbimCIDPin                        = 4
+	mbimCIDPinList                    = 5
+	mbimCIDHomeProvider               = 6
+	mbimCIDRegisterState              = 9
+	mbimCIDPacketService              = 10
+	mbimCIDSignalState                = 11
+	mbimCIDConnect                    = 12
+	mbimCIDProvisionedContexts        = 13
+	mbimCIDIPConfiguration            = 15
+	mbimCIDDeviceServices             = 16
+	mbimCIDDeviceServiceSubscribeList = 19
+	mbimCIDPacketStatistics           = 20
+)
+
+var (
+	mbimUUIDBasicConnect = [16]byte{0xa2, 0x89, 0xcc, 0x33, 0xbc, 0xbb, 0x8b, 0x4f, 0xb6, 0xb0, 0x13, 0x3e, 0xc2, 0xaa, 0xe6, 0xdf}
+	mbimContextInternet  = [16]byte{0x7e, 0x5e, 0x2a, 0x7e, 0x4e, 0x6f, 0x72, 0x72, 0x73, 0x6b, 0x65, 0x6e, 0x7e, 0x5e, 0x2a, 0x7e}
+
+	mbimBasicConnectCIDs = []uint32{
+		mbimCIDDeviceCaps, mbimCIDSubscriberReadyStatus, mbimCIDRadioState, mbimCIDPin, mbimCIDPinList,
+		mbimCIDHomeProvider, mbimCIDRegisterState, mbimCIDPacketService, mbimCIDSignalState, mbimCIDConnect,
+		mbimCIDProvisionedContexts, mbimCIDIPConfiguration, mbimCIDDeviceServices,
+		mbimCIDDeviceServiceSubscribeList, mbimCIDPacketStatistics,
+	}
+)
+
+// identity of the emulated modem and its network
+const (
+	mbimIMEI         = "358240051111110"
+	mbimIMSI         = "001010000000001"
+	mbimICCID        = "89001010000000000019"
+	mbimProviderID   = "00101"
+	mbimProviderName = "Eden"
+	mbimFirmware     = "eden-modem"
+
+	mbimDataClassLTE   = 0x20
+	mbimCellularGSM    = 1
+	mbimRSSI           = 20 // -73 dBm
+	mbimErrorRate      = 99 // unknown
+	mbimConnectionRate = 20 * 1000 * 1000
+)
+
+// dataPath is the data connection of the modem into the network
+type dataPath interface {
+	// lease returns address of the modem in the network or nil if it is not obtained yet
+	lease() *dhcpLease
+	// stats returns statistics of packets passed through the data connection
+	stats() packetStats
+}
+
+// packetStats are counters of packets passed to and from the host
+type packetStats struct {
+	inPackets, inOctets, inDiscards    uint64
+	outPackets, outOctets, outDiscards uint64
+}
+
+// mbimFunction is MBIM function of the modem, it keeps state of the radio and of the connection
+type mbimFunction struct {
+	apn  string
+	data dataPath
+
+	mu        sync.Mutex
+	opened    bool
+	radioOn   bool
+	connected bool
+	session   uint32
+}
+
+func newMBIMFunction(apn string, data dataPath) *mbimFunction {
+	return &mbimFunction{apn: apn, data: data, radioOn: true}
+}
+
+// reset returns the function into state after power on
+func (f *mbimFunction) reset() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.opened, f.connected, f.radioOn = false, false, true
+}
+
+// connectedSession returns session of activated connection
+func (f *mbimFunction) connectedSession() (uint32, bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.session, f.connected && f.radioOn
+}
+
+// handle processes message sent by the host and returns messages to send to it
+func (f *mbimFunction) handle(msg []byte) [][]byte {
+	if len(msg) < mbimHeaderLen {
+		log.Warnf("MBIM message is too short: %d", len(msg))
+		return nil
+	}
+	msgType := binary.LittleEndian.Uint32(msg[0:])
+	tid := binary.LittleEndian.Uint32(msg[8:])
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	switch msgType {
+	case mbimOpen:
+		f.opened = true
+		return [][]byte{mbimStatusMessage(mbimOpenDone, tid, mbimStatusSuccess)}
+	case mbimClose:
+		f.opened = false
+		f.connected = false
+		return [][]byte{mbimStatusMessage(mbimCloseDone, tid, mbimStatusSuccess)}
+	case mbimCommand:
+		if !f.opened {
+			return [][]byte{mbimStatusMessage(mbimFunctionError, tid, mbimErrorNotOpened)}
+		}
+		if len(msg) < mbimCommandLen {
+			return [][]byte{mbimStatusMessage(mbimFunctionError, tid, mbimErrorUnknown)}
+		}
+		if binary.LittleEndian.Uint32(msg[12:]) != 1 {
+			// commands of the host are short enough to never be fragmented
+			return [][]byte{mbimStatusMessage(mbimFunctionError, tid, mbimErrorMaxTransfer)}
+		}
+		var service [16]byte
+		copy(service[:], msg[20:36])
+		cid := binary.LittleEndian.Uint32(msg[36:])
+		commandType := binary.LittleEndian.Uint32(msg[40:])
+		info := msg[mbimCommandLen:]
+		if infoLen := int(binary.LittleEndian.Uint32(msg[44:])); infoLen < len(info) {
+			info = info[:infoLen]
+		}
+		status, response, indications := uint32(mbimStatusNoDeviceSupport), []byte(nil), [][]byte(nil)
+		if service == mbimUUIDBasicConnect {
+			status, response, indications = f.basicConnect(cid, commandType, info)
+		}
+		if status == mbimStatusNoDeviceSupport {
+			log.Debugf("MBIM command %d of service %x is not supported", cid, service)
+		}
+		return append([][]byte{mbimCommandDoneMessage(tid, service, cid, status, response)}, indications...)
+	}
+	log.Debugf("MBIM message of type %#x is ignored", msgType)
+	return nil
+}
+
+// basicConnect handles command of Basic Connect service and returns status, information buffer
+// of the response and indications caused by the command
+func (f *mbimFunction) basicConnect(cid, commandType uint32, info []byte) (uint32, []byte, [][]byte) {
+	set := commandType == mbimCommandSet
+	switch {
+	case cid == mbimCIDDeviceCaps && !set:
+		return mbimStatusSuccess, f.deviceCaps(), nil
+	case cid == mbimCIDSubscriberReadyStatus && !set:
+		return mbimStatusSuccess, f.subscriberReadyStatus(), nil
+	case cid == mbimCIDRadioState:
+		var indications [][]byte
+		if set {
+			if len(info) < 4 {
+				return mbimStatusInvalidParameters, nil, nil
+			}
+			if radioOn := binary.LittleEndian.Uint32(info) == 1; radioOn != f.radioOn {
+				indications = f.setRadio(radioOn)
+			}
+		}
+		return mbimStatusSuccess, f.radioState(), indications
+	case cid == mbimCIDPin && !set:
+		// PIN is not required
+		return mbimStatusSuccess, make([]byte, 12), nil
+	case cid == mbimCIDPinList && !set:
+		return mbimStatusSuccess, make([]byte, 10*16), nil
+	case cid == mbimCIDHomeProvider && !set:
+		return mbimStatusSuccess, f.provider(), nil
+	case cid == mbimCIDRegisterState:
+		// the modem is always registered automatically in the home network
+		return mbimStatusSuccess, f.registerState(), nil
+	case cid == mbimCIDPacketService:
+		if !f.radioOn && set {
+			return mbimStatusRadioPowerOff, f.packetService(), nil
+		}
+		return mbimStatusSuccess, f.packetService(), nil
+	case cid == mbimCIDSignalState:
+		return mbimStatusSuccess, f.signalState(), nil
+	case cid == mbimCIDConnect:
+		if set {
+			return f.setConnect(info)
+		}
+		if len(info) < 4 {
+			return mbimStatusInvalidParameters, nil, nil
+		}
+		return mbimStatusSuccess, f.connectInfo(binary.LittleEndian.Uint32(info)), nil
+	case cid == mbimCIDProvisionedContexts && !set:
+		return mbimStatusSuccess, make([]byte, 4), nil
+	case cid == mbimCIDIPConfiguration && !set:
+		if len(info) < 4 {
+			return mbimStatusInvalidParameters, nil, nil
+		}
+		return f.ipConfiguration(binary.LittleEndian.Uint32(info))
+	case cid == mbimCIDDeviceServices && !set:
+		return mbimStatusSuccess, f.deviceServices(), nil
+	case cid == mbimCIDDeviceServiceSubscribeList && set:
+		// all indications of Basic Connect are sent anyway
+		return mbimStatusSuccess, info, nil
+	case cid == mbimCIDPacketStatistics && !set:
+		return mbimStatusSuccess, f.packetStatistics(), nil
+	}
+	return mbimStatusNoDeviceSupport, nil, nil
+}
+
+// setRadio switches radio and returns indications of changed registration and connection
+func (f *mbimFunction) setRadio(radioOn bool) [][]byte {
+	log.Infof("radio of the modem is switched %s", map[bool]string{true: "on", false: "off"}[radioOn])
+	wasConnected := f.connected
+	f.radioOn = radioOn
+	if !radioOn {
+		f.connected = false
+	}
+	indications := [][]byte{
+		mbimIndicationMessage(mbimUUIDBasicConnect, mbimCIDRegisterState, f.registerState()),
+		mbimIndicationMessage(mbimUUIDBasicConnect, mbimCIDPacketService, f.packetService()),
+	}
+	if wasConnected && !f.connected {
+		indications = append(indications,
+			mbimIndicationMessage(mbimUUIDBasicConnect, mbimCIDConnect, f.connectInfo(f.session)))
+	}
+	return indications
+}
+
+func (f *mbimFunction) setConnect(info []byte) (uint32, []byte, [][]byte) {
+	if len(info) < 60 {
+		return mbimStatusInvalidParameters, nil, nil
+	}
+	session := binary.LittleEndian.Uint32(info[0:])
+	activate := binary.LittleEndian.Uint32(info[4:]) == 1
+	apn := readInfoString(info, 8)
+	if !activate {
+		if session == f.session {
+			f.connected = false
+		}
+		return mbimStatusSuccess, f.connectInfo(session), nil
+	}
+	if !f.radioOn {
+		return mbimStatusRadioPowerOff, f.connectInfo(session), nil
+	}
+	if apn != "" && !strings.EqualFold(apn, f.apn) {
+		log.Warnf("connection to APN %q is rejected, the network provides %q", apn, f.apn)
+		return mbimStatusInvalidAccessString, f.connectInfo(session), nil
+	}
+	if f.data.lease() == nil {
+		log.Warnf("connection is rejected, the modem has no address in the network yet")
+		return mbimStatusFailure, f.connectInfo(session), nil
+	}
+	f.connected, f.session = true, session
+	log.Infof("data connection of session %d is activated", session)
+	return mbimStatusSuccess, f.connectInfo(session), nil
+}
+
+func (f *mbimFunction) deviceCaps() []byte {
+	b := newInfoBuffer(64)
+	b.putUint32(0, 1) // embedded
+	b.putUint32(4, mbimCellularGSM)
+	b.putUint32(8, 1)  // no voice
+	b.putUint32(12, 2) // removable SIM
+	b.putUint32(16, mbimDataClassLTE)
+	b.putUint32(28, 1) // max sessions
+	b.putString(40, mbimIMEI)
+	b.putString(48, mbimFirmware)
+	b.putString(56, mbimDevice.strings[1])
+	return b.bytes()
+}
+
+func (f *mbimFunction) subscriberReadyStatus() []byte {
+	b := newInfoBuffer(28)
+	b.putUint32(0, 1) // initialized
+	b.putString(4, mbimIMSI)
+	b.putString(12, mbimICCID)
+	return b.bytes()
+}
+
+func (f *mbimFunction) radioState() []byte {
+	b := newInfoBuffer(8)
+	b.putUint32(0, 1) // no hardware switch
+	if f.radioOn {
+		b.putUint32(4, 1)
+	}
+	return b.bytes()
+}
+
+func (f *mbimFunction) provider() []byte {
+	b := newInfoBuffer(32)
+	b.putString(0, mbimProviderID)
+	b.putUint32(8, 0x1|0x8|0x10) // home, visible, registered
+	b.putString(12, mbimProviderName)
+	b.putUint32(20, mbimCellularGSM)
+	b.putUint32(24, mbimRSSI)
+	b.putUint32(28, mbimErrorRate)
+	return b.bytes()
+}
+
+func (f *mbimFunction) registerState() []byte {
+	b := newInfoBuffer(48)
+	if !f.radioOn {
+		b.putUint32(4, 1) // deregistered
+		b.putUint32(8, 1) // automatic
+		return b.bytes()
+	}
+	b.putUint32(4, 3) // home
+	b.putUint32(8, 1) // automatic
+	b.putUint32(12, mbimDataClassLTE)
+	b.putUint32(16, mbimCellularGSM)
+	b.putString(20, mbimProviderID)
+	b.putString(28, mbimProviderName)
+	b.putString(36, "")
+	b.putUint32(44, 2) // packet service automatic attach
+	return b.bytes()
+}
+
+func (f *mbimFunction) packetService() []byte {
+	b := newInfoBuffer(28)
+	if !f.radioOn {
+		b.putUint32(4, 4) // detached
+		return b.bytes()
+	}
+	b.putUint32(4, 2) // attached
+	b.putUint32(8, mbimDataClassLTE)
+	b.putUint64(12, mbimConnectionRate)
+	b.putUint64(20, mbimConnectionRate)
+	return b.bytes()
+}
+
+func (f *mbimFunction) signalState() []byte {
+	b := newInfoBuffer(20)
+	b.putUint32(0, 99) // unknown
+	if f.radioOn {
+		b.putUint32(0, mbimRSSI)
+	}
+	b.putUint32(4, mbimErrorRate)
+	return b.bytes()
+}
+
+func (f *mbimFunction) connectInfo(session uint32) []byte {
+	b := newInfoBuffer(36)
+	b.putUint32(0, session)
+	b.putUint32(4, 3) // deactivated
+	if f.connected && f.radioOn && session == f.session {
+		b.putUint32(4, 1) // activated
+	}
+	b.putUint32(12, 1) // IPv4
+	copy(b.buf[16:], mbimContextInternet[:])
+	return b.bytes()
+}
+
+func (f *mbimFunction) ipConfiguration(session uint32) (uint32, []byte, [][]byte) {
+	lease := f.data.lease()
+	if !f.connected || !f.radioOn || session != f.session || lease == nil {
+		return mbimStatusFailure, nil, nil
+	}
+	b := newInfoBuffer(60)
+	b.putUint32(0, session)
+	available := uint32(0x1 | 0x8) // address, MTU
+	prefixLen, _ := lease.mask.Size()
+	address := binary.LittleEndian.AppendUint32(nil, uint32(prefixLen))
+	b.putUint32(12, 1)
+	b.putUint32(16, b.putData(append(address, lease.ip.To4()...)))
+	if lease.router != nil {
+		available |= 0x2
+		b.putUint32(28, b.putData(lease.router.To4()))
+	}
+	if len(lease.dns) > 0 {
+		available |= 0x4
+		var dns []byte
+		for _, ip := range lease.dns {
+			dns = append(dns, ip.To4()...)
+		}
+		b.putUint32(36, uint32(len(lease.dns)))
+		b.putUint32(40, b.putData(dns))
+	}
+	b.putUint32(4, available)
+	b.putUint32(52, maxSegmentSize)
+	return mbimStatusSuccess, b.bytes(), nil
+}
+
+func (f *mbimFunction) deviceServices() []byte {
+	element := append([]byte(nil), mbimUUIDBasicConnect[:]...)
+	element = binary.LittleEndian.AppendUint32(element, 0) // DSS payload
+	element = binary.LittleEndian.AppendUint32(element, 0) // max DSS instances
+	element = binary.LittleEndian.AppendUint32(element, uint32(len(mbimBasicConnectCIDs)))
+	for _, cid := range mbimBasicConnectCIDs {
+		element = binary.LittleEndian.AppendUint32(element, cid)
+	}
+	b := newInfoBuffer(16)
+	b.putUint32(0, 1)
+	b.putUint32(8, b.putData(element))
+	b.putUint32(12, uint32(len(element)))
+	return b.bytes()
+}
+
+func (f *mbimFunction) packetStatistics() []byte {
+	stats := f.data.stats()
+	b := newInfoBuffer(48)
+	b.putUint32(0, uint32(stats.inDiscards))
+	b.putUint64(8, stats.inOctets)
+	b.putUint64(16, stats.inPackets)
+	b.putUint64(24, stats.outOctets)
+	b.putUint64(32, stats.outPackets)
+	b.putUint32(44, uint32(stats.outDiscards))
+	return b.bytes()
+}
+
+// infoBuffer builds information buffer of MBIM message, variable-sized fields follow
+// the fixed part and are referenced by their offsets from the start of the buffer
+type infoBuffer struct {
+	buf []byte
+}
+
+func newInfoBuffer(fixedLen int) *infoBuffer {
+	return &infoBuffer{buf: make([]byte, fixedLen)}
+}
+
+func (b *infoBuffer) putUint32(offset int, value uint32) {
+	binary.LittleEndian.PutUint32(b.buf[offset:], value)
+}
+
+func (b *infoBuffer) putUint64(offset int, value uint64) {
+	binary.LittleEndian.PutUint64(b.buf[offset:], value)
+}
+
+// putData appends data padded to 4 bytes and returns its offset
+func (b *infoBuffer) putData(data []byte) uint32 {
+	offset := uint32(len(b.buf))
+	b.buf = append(b.buf, data...)
+	for len(b.buf)%4 != 0 {
+		b.buf = append(b.buf, 0)
+	}
+	return offset
+}
+
+// putString appends UTF-16 string and puts its offset and size at offset
+func (b *infoBuffer) putString(offset int, s string) {
+	if s == "" {
+		return
+	}
+	var data []byte
+	for _, r := range utf16.Encode([]rune(s)) {
+		data = binary.LittleEndian.AppendUint16(data, r)
+	}
+	b.putUint32(offset, b.putData(data))
+	b.putUint32(offset+4, uint32(len(data)))
+}
+
+func (b *infoBuffer) bytes() []byte {
+	return b.buf
+}
+
+// readInfoString returns UTF-16 string referenced at offset of information buffer
+func readInfoString(info []byte, offset int) string {
+	if offset+8 > len(info) {
+		return ""
+	}
+	start := int(binary.LittleEndian.Uint32(info[offset:]))
+	size := int(binary.LittleEndian.Uint32(info[offset+4:]))
+	if size == 0 || start+size > len(info) {
+		return ""
+	}
+	runes := make([]uint16, size/2)
+	for i := range runes {
+		runes[i] = binary.LittleEndian.Uint16(info[start+2*i:])
+	}
+	return string(utf16.Decode(runes))
+}
+
+func mbimMessage(msgType, tid uint32, body []byte) []byte {
+	msg := make([]byte, mbimHeaderLen, mbimHeaderLen+len(body))
+	binary.LittleEndian.PutUint32(msg[0:], msgType)
+	binary.LittleEndian.PutUint32(msg[4:], uint32(mbimHeaderLen+len(body)))
+	binary.LittleEndian.PutUint32(msg[8:], tid)
+	return append(msg, body...)
+}
+
+func mbimStatusMessage(msgType, tid, status uint32) []byte {
+	return mbimMessage(msgType, tid, binary.LittleEndian.AppendUint32(nil, status))
+}
+
+func mbimCommandDoneMessage(tid uint32, service [16]byte, cid, status uint32, info []byte) []byte {
+	body := binary.LittleEndian.AppendUint32(nil, 1) // total fragments
+	body = binary.LittleEndian.AppendUint32(body, 0)
+	body = append(body, service[:]...)
+	body = binary.LittleEndian.AppendUint32(body, cid)
+	body = binary.LittleEndian.AppendUint32(body, status)
+	body = binary.LittleEndian.AppendUint32(body, uint32(len(info)))
+	return mbimMessage(mbimCommandDone, tid, append(body, info...))
+}
+
+func mbimIndicationMessage(service [16]byte, cid uint32, info []byte) []byte {
+	body := binary.LittleEndian.AppendUint32(nil, 1)
+	body = binary.LittleEndian.AppendUint32(body, 0)
+	body = append(body, service[:]...)
+	body = binary.LittleEndian.AppendUint32(body, cid)
+	body = binary.LittleEndian.AppendUint32(body, uint32(len(info)))
+	return mbimMessage(mbimIndicateStatus, 0, append(body, info...))
+}
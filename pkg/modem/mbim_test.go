@@ -0,0 +1,134 @@
+package modem
+
+import (
+	"encoding/binary"
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeDataPath struct {
+	current *dhcpLease
+}
+
+func (d *fakeDataPath) lease() *dhcpLease  { return d.current }
+func (d *fakeDataPath) stats() packetStats { return packetStats{inPackets: 3, outPackets: 5} }
+
+// mbimCommandMessage returns command of Basic Connect service
+func mbimCommandMessage(tid, cid, commandType uint32, info []byte) []byte {
+	body := binary.LittleEndian.AppendUint32(nil, 1)
+	body = binary.LittleEndian.AppendUint32(body, 0)
+	body = append(body, mbimUUIDBasicConnect[:]...)
+	body = binary.LittleEndian.AppendUint32(body, cid)
+	body = binary.LittleEndian.AppendUint32(body, commandType)
+	body = binary.LittleEndian.AppendUint32(body, uint32(len(info)))
+	return mbimMessage(mbimCommand, tid, append(body, info...))
+}
+
+// mbimSetConnect returns information buffer of CONNECT set command
+func mbimSetConnect(activate bool, apn string) []byte {
+	b := newInfoBuffer(60)
+	if activate {
+		b.putUint32(4, 1)
+	}
+	b.putString(8, apn)
+	copy(b.buf[44:], mbimContextInternet[:])
+	return b.bytes()
+}
+
+// commandDone returns status and information buffer of COMMAND_DONE message
+func commandDone(t *testing.T, msg []byte) (uint32, []byte) {
+	t.Helper()
+	if !assert.Equal(t, uint32(mbimCommandDone), binary.LittleEndian.Uint32(msg)) {
+		return 0, nil
+	}
+	return binary.LittleEndian.Uint32(msg[40:]), msg[48:]
+}
+
+func TestMBIMFunction(t *testing.T) {
+	t.Parallel()
+
+	data := &fakeDataPath{}
+	f := newMBIMFunction("internet", data)
+
+	// commands are refused before open
+	responses := f.handle(mbimCommandMessage(1, mbimCIDDeviceCaps, 0, nil))
+	assert.Len(t, responses, 1)
+	assert.Equal(t, uint32(mbimFunctionError), binary.LittleEndian.Uint32(responses[0]))
+
+	responses = f.handle(mbimMessage(mbimOpen, 2, binary.LittleEndian.AppendUint32(nil, maxControlMessage)))
+	assert.Equal(t, [][]byte{mbimStatusMessage(mbimOpenDone, 2, mbimStatusSuccess)}, responses)
+
+	status, info := commandDone(t, f.handle(mbimCommandMessage(3, mbimCIDDeviceCaps, 0, nil))[0])
+	assert.Equal(t, uint32(mbimStatusSuccess), status)
+	assert.Equal(t, mbimIMEI, readInfoString(info, 40))
+
+	status, info = commandDone(t, f.handle(mbimCommandMessage(4, mbimCIDRegisterState, 0, nil))[0])
+	assert.Equal(t, uint32(mbimStatusSuccess), status)
+	assert.Equal(t, uint32(3), binary.LittleEndian.Uint32(info[4:]), "registered in home network")
+	assert.Equal(t, mbimProviderName, readInfoString(info, 28))
+
+	tests := []struct {
+		name   string
+		apn    string
+		lease  *dhcpLease
+		status uint32
+	}{
+		{name: "wrong APN", apn: "other", lease: &dhcpLease{}, status: mbimStatusInvalidAccessString},
+		{name: "no address", apn: "internet", status: mbimStatusFailure},
+	}
+	for _, tt := range tests {
+		data.current = tt.lease
+		status, info = commandDone(t, f.handle(mbimCommandMessage(5, mbimCIDConnect, mbimCommandSet, mbimSetConnect(true, tt.apn)))[0])
+		assert.Equal(t, tt.status, status, tt.name)
+		assert.Equal(t, uint32(3), binary.LittleEndian.Uint32(info[4:]), "%s: deactivated", tt.name)
+	}
+
+	data.current = &dhcpLease{
+		ip:     net.IPv4(172, 22, 20, 10).To4(),
+		mask:   net.CIDRMask(24, 32),
+		router: net.IPv4(172, 22, 20, 1).To4(),
+		dns:    []net.IP{net.IPv4(172, 22, 20, 1).To4()},
+	}
+	status, info = commandDone(t, f.handle(mbimCommandMessage(6, mbimCIDConnect, mbimCommandSet, mbimSetConnect(true, "Internet")))[0])
+	assert.Equal(t, uint32(mbimStatusSuccess), status)
+	assert.Equal(t, uint32(1), binary.LittleEndian.Uint32(info[4:]), "activated")
+	_, connected := f.connectedSession()
+	assert.True(t, connected)
+
+	status, info = commandDone(t, f.handle(mbimCommandMessage(7, mbimCIDIPConfiguration, 0, make([]byte, 60)))[0])
+	assert.Equal(t, uint32(mbimStatusSuccess), status)
+	assert.Equal(t, uint32(0xf), binary.LittleEndian.Uint32(info[4:]), "address, gateway, DNS and MTU")
+	address := info[binary.LittleEndian.Uint32(info[16:]):]
+	assert.Equal(t, uint32(24), binary.LittleEndian.Uint32(address))
+	assert.Equal(t, net.IP(address[4:8]), data.current.ip)
+	assert.Equal(t, net.IP(info[binary.LittleEndian.Uint32(info[28:]):][:4]), data.current.router)
+	assert.Equal(t, uint32(maxSegmentSize), binary.LittleEndian.Uint32(info[52:]))
+
+	status, info = commandDone(t, f.handle(mbimCommandMessage(8, mbimCIDPacketStatistics, 0, nil))[0])
+	assert.Equal(t, uint32(mbimStatusSuccess), status)
+	assert.Equal(t, uint64(3), binary.LittleEndian.Uint64(info[16:]))
+	assert.Equal(t, uint64(5), binary.LittleEndian.Uint64(info[32:]))
+
+	// radio silence deregisters the modem and deactivates the connection
+	responses = f.handle(mbimCommandMessage(9, mbimCIDRadioState, mbimCommandSet, binary.LittleEndian.AppendUint32(nil, 0)))
+	assert.Len(t, responses, 4)
+	status, info = commandDone(t, responses[0])
+	assert.Equal(t, uint32(mbimStatusSuccess), status)
+	assert.Equal(t, uint32(0), binary.LittleEndian.Uint32(info[4:]), "software radio is off")
+	for i, cid := range []uint32{mbimCIDRegisterState, mbimCIDPacketService, mbimCIDConnect} {
+		assert.Equal(t, uint32(mbimIndicateStatus), binary.LittleEndian.Uint32(responses[i+1]))
+		assert.Equal(t, cid, binary.LittleEndian.Uint32(responses[i+1][36:]))
+	}
+	_, connected = f.connectedSession()
+	assert.False(t, connected)
+	status, _ = commandDone(t, f.handle(mbimCommandMessage(10, mbimCIDConnect, mbimCommandSet, mbimSetConnect(true, "internet")))[0])
+	assert.Equal(t, uint32(mbimStatusRadioPowerOff), status)
+
+	// other services are not supported
+	msg := mbimCommandMessage(11, mbimCIDDeviceCaps, 0, nil)
+	msg[20] ^= 0xff
+	status, _ = commandDone(t, f.handle(msg)[0])
+	assert.Equal(t, uint32(mbimStatusNoDeviceSupport), status)
+}
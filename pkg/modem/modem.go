@@ -0,0 +1,389 @@
+// Package modem implements cellular modem emulated for EVE running in QEMU. The modem is
+// an MBIM device attached to QEMU over usbredir protocol, its data connection leads into
+// the network of SDN port the modem is connected to.
+package modem
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"sync"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// downlinkQueueLen limits packets from the network waiting for the host to read them
+const downlinkQueueLen = 256
+
+// CDC class-specific requests of MBIM function
+const (
+	reqSendEncapsulatedCommand = 0x00
+	reqGetEncapsulatedResponse = 0x01
+
+	notificationResponseAvailable = 0x01
+)
+
+// Config of the modem
+type Config struct {
+	// Socket is path of unix socket to serve the modem for QEMU usb-redir device
+	Socket string
+	// NetDev is address of QEMU socket netdev of SDN port the data connection leads into
+	NetDev string
+	// MAC is address of the modem in the network of SDN
+	MAC string
+	// APN is the only access point name the network accepts, any is accepted if empty
+	APN string
+}
+
+// Modem is emulated cellular modem
+type Modem struct {
+	cfg      Config
+	net      *netDev
+	mbim     *mbimFunction
+	downlink chan []byte
+}
+
+// New returns modem with the config
+func New(cfg Config) (*Modem, error) {
+	mac, err := net.ParseMAC(cfg.MAC)
+	if err != nil {
+		return nil, fmt.Errorf("cannot parse MAC of the modem: %w", err)
+	}
+	if cfg.Socket == "" || cfg.NetDev == "" {
+		return nil, errors.New("socket for QEMU and netdev of SDN are required")
+	}
+	m := &Modem{cfg: cfg, downlink: make(chan []byte, downlinkQueueLen)}
+	m.net = newNetDev(cfg.NetDev, mac, m.deliver)
+	m.mbim = newMBIMFunction(cfg.APN, m.net)
+	return m, nil
+}
+
+// Run serves the modem for QEMU and connects its data path to SDN until ctx is done,
+// QEMU reconnects to the socket after restart of EVE
+func (m *Modem) Run(ctx context.Context) error {
+	_ = os.Remove(m.cfg.Socket)
+	listener, err := net.Listen("unix", m.cfg.Socket)
+	if err != nil {
+		return fmt.Errorf("cannot listen on %s: %w", m.cfg.Socket, err)
+	}
+	defer os.Remove(m.cfg.Socket)
+	go func() {
+		<-ctx.Done()
+		_ = listener.Close()
+	}()
+	go m.net.run(ctx)
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return fmt.Errorf("accept on %s: %w", m.cfg.Socket, err)
+		}
+		log.Info("QEMU is connected to the modem")
+		// the modem is powered on with EVE
+		m.mbim.reset()
+		err = newUSBSession(m, conn).serve(ctx)
+		log.Infof("QEMU is disconnected from the modem: %v", err)
+		_ = conn.Close()
+	}
+}
+
+// deliver queues packet from the network for the host if the data connection is activated
+func (m *Modem) deliver(packet []byte) bool {
+	if _, connected := m.mbim.connectedSession(); !connected {
+		return false
+	}
+	select {
+	case m.downlink <- append([]byte(nil), packet...):
+		return true
+	default:
+		return false
+	}
+}
+
+// usbSession serves the modem over usbredir connection with QEMU
+type usbSession struct {
+	modem *Modem
+	conn  *usbRedirConn
+	mbim  *mbimFunction
+
+	mu sync.Mutex
+	// notifying is set after QEMU started to receive notifications of the MBIM function
+	notifying bool
+	// responses are MBIM messages waiting for GET_ENCAPSULATED_RESPONSE
+	responses [][]byte
+	// pending are lengths of bulk IN transfers waiting for packets from the network
+	pending  map[uint32]int
+	inQueue  chan uint32
+	sequence uint16
+}
+
+func newUSBSession(m *Modem, conn net.Conn) *usbSession {
+	return &usbSession{
+		modem:   m,
+		conn:    &usbRedirConn{rw: conn},
+		mbim:    m.mbim,
+		pending: map[uint32]int{},
+		inQueue: make(chan uint32, 64),
+	}
+}
+
+func (s *usbSession) serve(ctx context.Context) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	if err := s.conn.hello(); err != nil {
+		return err
+	}
+	go s.downlinkLoop(ctx)
+	for {
+		packet, err := s.conn.readPacket()
+		if err != nil {
+			return err
+		}
+		if err := s.handle(packet); err != nil {
+			return err
+		}
+	}
+}
+
+// handle processes packet received from QEMU
+func (s *usbSession) handle(packet *usbRedirPacket) error {
+	switch packet.Type {
+	case usbRedirHello:
+		s.conn.handleHello(packet)
+		return s.conn.connectDevice(mbimDevice)
+	case usbRedirReset:
+		s.mu.Lock()
+		s.responses, s.notifying = nil, false
+		s.mu.Unlock()
+		s.mbim.reset()
+		return nil
+	case usbRedirSetConfiguration, usbRedirGetConfiguration:
+		// the only configuration is always set
+		return s.conn.writePacket(usbRedirConfigurationStatus, packet.ID, []byte{usbRedirSuccess, 1}, nil)
+	case usbRedirSetAltSetting:
+		return s.conn.writePacket(usbRedirAltSettingStatus, packet.ID,
+			[]byte{usbRedirSuccess, packet.Header[0], packet.Header[1]}, nil)
+	case usbRedirGetAltSetting:
+		alt := uint8(0)
+		if packet.Header[0] == intfData {
+			alt = dataAltSettingMBIM
+		}
+		return s.conn.writePacket(usbRedirAltSettingStatus, packet.ID, []byte{usbRedirSuccess, packet.Header[0], alt}, nil)
+	case usbRedirStartInterruptReceiving, usbRedirStopInterruptReceiving:
+		endpoint := packet.Header[0]
+		status := uint8(usbRedirSuccess)
+		if endpoint != epNotify {
+			status = usbRedirInval
+		}
+		if err := s.conn.writePacket(usbRedirInterruptReceivingStatus, packet.ID, []byte{status, endpoint}, nil); err != nil {
+			return err
+		}
+		if endpoint == epNotify {
+			s.mu.Lock()
+			s.notifying = packet.Type == usbRedirStartInterruptReceiving
+			notify := s.notifying && len(s.responses) > 0
+			s.mu.Unlock()
+			if notify {
+				return s.notifyResponse()
+			}
+		}
+		return nil
+	case usbRedirCancelDataPacket:
+		s.mu.Lock()
+		_, pending := s.pending[packet.ID]
+		delete(s.pending, packet.ID)
+		s.mu.Unlock()
+		if pending {
+			return s.conn.writePacket(usbRedirBulkPacket, packet.ID, bulkHeader(epDataIn, usbRedirCancelled, 0), nil)
+		}
+		return nil
+	case usbRedirControlPacket:
+		return s.handleControl(packet)
+	case usbRedirBulkPacket:
+		return s.handleBulk(packet)
+	case usbRedirInterruptPacket:
+		return s.conn.writePacket(usbRedirInterruptPacket, packet.ID,
+			[]byte{packet.Header[0], usbRedirStall, 0, 0}, nil)
+	}
+	log.Debugf("usbredir packet of type %d is ignored", packet.Type)
+	return nil
+}
+
+func (s *usbSession) handleControl(packet *usbRedirPacket) error {
+	header := append([]byte(nil), packet.Header...)
+	request, requestType := header[1], header[2]
+	value := binary.LittleEndian.Uint16(header[4:])
+	length := int(binary.LittleEndian.Uint16(header[8:]))
+	status, data := s.control(requestType, request, value, packet.Data)
+	if requestType&0x80 != 0 {
+		if len(data) > length {
+			data = data[:length]
+		}
+		binary.LittleEndian.PutUint16(header[8:], uint16(len(data)))
+	} else {
+		data = nil
+	}
+	header[3] = status
+	return s.conn.writePacket(usbRedirControlPacket, packet.ID, header, data)
+}
+
+// control handles control request and returns its status and data for IN requests
+func (s *usbSession) control(requestType, request uint8, value uint16, data []byte) (uint8, []byte) {
+	switch requestType & 0x60 {
+	case 0x00: // standard
+		switch request {
+		case reqGetDescriptor:
+			if desc := mbimDevice.descriptor(value); desc != nil {
+				return usbRedirSuccess, desc
+			}
+		case reqGetStatus:
+			return usbRedirSuccess, []byte{0, 0}
+		case reqClearFeature, reqSetFeature:
+			return usbRedirSuccess, nil
+		}
+	case 0x20: // class
+		switch request {
+		case reqSendEncapsulatedCommand:
+			responses := s.mbim.handle(data)
+			s.mu.Lock()
+			s.responses = append(s.responses, responses...)
+			notify := s.notifying && len(responses) > 0
+			s.mu.Unlock()
+			if notify {
+				if err := s.notifyResponse(); err != nil {
+					log.Debugf("cannot notify about MBIM response: %s", err)
+				}
+			}
+			return usbRedirSuccess, nil
+		case reqGetEncapsulatedResponse:
+			s.mu.Lock()
+			defer s.mu.Unlock()
+			if len(s.responses) == 0 {
+				return usbRedirSuccess, nil
+			}
+			response := s.responses[0]
+			s.responses = s.responses[1:]
+			if len(s.responses) > 0 {
+				// one notification is sent per response
+				go func() { _ = s.notifyResponse() }()
+			}
+			return usbRedirSuccess, response
+		case reqGetNtbParameters:
+			return usbRedirSuccess, ntbParameters()
+		case reqGetNtbFormat:
+			return usbRedirSuccess, []byte{0, 0}
+		case reqGetNtbInputSize:
+			return usbRedirSuccess, binary.LittleEndian.AppendUint32(nil, ntbMaxSize)
+		case reqGetMaxDatagramSize:
+			return usbRedirSuccess, binary.LittleEndian.AppendUint16(nil, maxSegmentSize)
+		}
+		if requestType&0x80 == 0 {
+			// reset of function, NTB format, input size and others are accepted
+			return usbRedirSuccess, nil
+		}
+	}
+	log.Debugf("control request %#x of type %#x is not supported", request, requestType)
+	return usbRedirStall, nil
+}
+
+// notifyResponse sends RESPONSE_AVAILABLE notification of the MBIM function
+func (s *usbSession) notifyResponse() error {
+	notification := []byte{0xa1, notificationResponseAvailable, 0, 0, intfControl, 0, 0, 0}
+	header := []byte{epNotify, usbRedirSuccess, 0, 0}
+	binary.LittleEndian.PutUint16(header[2:], uint16(len(notification)))
+	return s.conn.writePacket(usbRedirInterruptPacket, 0, header, notification)
+}
+
+func (s *usbSession) handleBulk(packet *usbRedirPacket) error {
+	endpoint := packet.Header[0]
+	length := int(binary.LittleEndian.Uint16(packet.Header[2:]))
+	switch endpoint {
+	case epDataIn:
+		// completed when there is a packet from the network
+		s.mu.Lock()
+		s.pending[packet.ID] = length
+		s.mu.Unlock()
+		select {
+		case s.inQueue <- packet.ID:
+			return nil
+		default:
+			s.mu.Lock()
+			delete(s.pending, packet.ID)
+			s.mu.Unlock()
+			return s.conn.writePacket(usbRedirBulkPacket, packet.ID, bulkHeader(endpoint, usbRedirInval, 0), nil)
+		}
+	case epDataOut:
+		if session, connected := s.mbim.connectedSession(); connected {
+			datagrams, err := parseNTB16(packet.Data, uint8(session))
+			if err != nil {
+				log.Debugf("malformed NTB from the host: %s", err)
+			}
+			for _, datagram := range datagrams {
+				s.modem.net.send(datagram)
+			}
+		}
+		return s.conn.writePacket(usbRedirBulkPacket, packet.ID, bulkHeader(endpoint, usbRedirSuccess, len(packet.Data)), nil)
+	}
+	return s.conn.writePacket(usbRedirBulkPacket, packet.ID, bulkHeader(endpoint, usbRedirStall, 0), nil)
+}
+
+// downlinkLoop completes bulk IN transfers with packets from the network until ctx is done
+func (s *usbSession) downlinkLoop(ctx context.Context) {
+	for {
+		var packet []byte
+		select {
+		case <-ctx.Done():
+			return
+		case packet = <-s.modem.downlink:
+		}
+		for sent := false; !sent; {
+			var id uint32
+			select {
+			case <-ctx.Done():
+				return
+			case id = <-s.inQueue:
+			}
+			s.mu.Lock()
+			length, pending := s.pending[id]
+			delete(s.pending, id)
+			s.sequence++
+			sequence := s.sequence
+			s.mu.Unlock()
+			if !pending {
+				// cancelled
+				continue
+			}
+			session, _ := s.mbim.connectedSession()
+			datagrams := [][]byte{packet}
+			size := nth16Len + ndp16Len + 3*ndp16EntryLen + ntbAlignment + len(packet)
+			// pass other queued packets in the same transfer block if they fit into it
+		more:
+			for size+ndp16EntryLen+ntbAlignment+maxSegmentSize <= length {
+				select {
+				case next := <-s.modem.downlink:
+					datagrams = append(datagrams, next)
+					size += ndp16EntryLen + ntbAlignment + len(next)
+				default:
+					break more
+				}
+			}
+			ntb := buildNTB16(sequence, uint8(session), datagrams...)
+			if err := s.conn.writePacket(usbRedirBulkPacket, id, bulkHeader(epDataIn, usbRedirSuccess, len(ntb)), ntb); err != nil {
+				log.Debugf("cannot pass packets to the host: %s", err)
+				return
+			}
+			sent = true
+		}
+	}
+}
+
+func bulkHeader(endpoint, status uint8, length int) []byte {
+	header := []byte{endpoint, status, 0, 0, 0, 0, 0, 0}
+	binary.LittleEndian.PutUint16(header[2:], uint16(length))
+	return header
+}
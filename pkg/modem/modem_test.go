@@ -0,0 +1,230 @@
+package modem
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"io"
+	"net"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var (
+	testGatewayMAC = net.HardwareAddr{0x02, 0xfe, 0, 0, 0, 1}
+	testGatewayIP  = net.IPv4(172, 22, 20, 1).To4()
+	testModemIP    = net.IPv4(172, 22, 20, 10).To4()
+)
+
+// fakeSDN is the network of SDN port serving DHCP and ARP for the gateway,
+// other IPv4 packets sent to the gateway are passed into uplink
+type fakeSDN struct {
+	conn   net.Conn
+	uplink chan []byte
+}
+
+func (sdn *fakeSDN) writeFrame(frame []byte) {
+	_, _ = sdn.conn.Write(append(binary.BigEndian.AppendUint32(nil, uint32(len(frame))), frame...))
+}
+
+func (sdn *fakeSDN) serve() {
+	for {
+		var length [4]byte
+		if _, err := io.ReadFull(sdn.conn, length[:]); err != nil {
+			return
+		}
+		frame := make([]byte, binary.BigEndian.Uint32(length[:]))
+		if _, err := io.ReadFull(sdn.conn, frame); err != nil {
+			return
+		}
+		modemMAC := frame[6:12]
+		switch binary.BigEndian.Uint16(frame[12:]) {
+		case etherTypeARP:
+			arp := frame[etherHeadLen:]
+			if binary.BigEndian.Uint16(arp[6:]) == 1 && net.IP(arp[24:28]).Equal(testGatewayIP) {
+				reply := append([]byte(nil), arp...)
+				binary.BigEndian.PutUint16(reply[6:], 2)
+				copy(reply[8:], testGatewayMAC)
+				copy(reply[14:], testGatewayIP)
+				copy(reply[18:], arp[8:18])
+				sdn.writeFrame(ethernetFrame(modemMAC, testGatewayMAC, etherTypeARP, reply))
+			}
+		case etherTypeIPv4:
+			packet := frame[etherHeadLen:]
+			if packet[9] == ipProtoUDP && binary.BigEndian.Uint16(packet[22:]) == dhcpServerPort {
+				sdn.replyDHCP(modemMAC, packet[28:])
+				continue
+			}
+			if bytes.Equal(frame[0:6], testGatewayMAC) {
+				sdn.uplink <- packet
+			}
+		}
+	}
+}
+
+func (sdn *fakeSDN) replyDHCP(modemMAC, request []byte) {
+	msgType := byte(dhcpOffer)
+	if request[242] == dhcpRequest {
+		msgType = dhcpAck
+	}
+	reply := append([]byte(nil), request[:240]...)
+	reply[0] = 2
+	copy(reply[16:], testModemIP)
+	reply = append(reply, 53, 1, msgType, 1, 4, 255, 255, 255, 0)
+	reply = append(append(reply, 3, 4), testGatewayIP...)
+	reply = append(append(reply, 6, 4), testGatewayIP...)
+	reply = append(append(reply, 54, 4), testGatewayIP...)
+	reply = append(reply, 51, 4, 0, 0, 0x0e, 0x10, 255)
+	packet := udpBroadcast(reply)
+	binary.BigEndian.PutUint16(packet[20:], dhcpServerPort)
+	binary.BigEndian.PutUint16(packet[22:], dhcpClientPort)
+	sdn.writeFrame(ethernetFrame(broadcastMAC, testGatewayMAC, etherTypeIPv4, packet))
+}
+
+func ethernetFrame(dst, src net.HardwareAddr, etherType uint16, payload []byte) []byte {
+	frame := append(append([]byte(nil), dst...), src...)
+	return append(binary.BigEndian.AppendUint16(frame, etherType), payload...)
+}
+
+// fakeQEMU is usb-redir device of QEMU
+type fakeQEMU struct {
+	t    *testing.T
+	conn net.Conn
+}
+
+func (q *fakeQEMU) write(packetType, id uint32, header, data []byte) {
+	c := &usbRedirConn{rw: q.conn}
+	assert.NoError(q.t, c.writePacket(packetType, id, header, data))
+}
+
+// read returns next packet of type from the modem skipping notifications of MBIM function
+func (q *fakeQEMU) read(packetType uint32) []byte {
+	for {
+		var hdr [usbRedirHeaderLen]byte
+		if _, err := io.ReadFull(q.conn, hdr[:]); !assert.NoError(q.t, err) {
+			q.t.FailNow()
+		}
+		body := make([]byte, binary.LittleEndian.Uint32(hdr[4:]))
+		if _, err := io.ReadFull(q.conn, body); !assert.NoError(q.t, err) {
+			q.t.FailNow()
+		}
+		gotType := binary.LittleEndian.Uint32(hdr[0:])
+		if gotType == packetType {
+			return body
+		}
+		if gotType != usbRedirInterruptPacket {
+			q.t.Fatalf("unexpected usbredir packet of type %d", gotType)
+		}
+	}
+}
+
+// control returns data of control transfer
+func (q *fakeQEMU) control(requestType, request uint8, value, length uint16, data []byte) []byte {
+	header := []byte{0, request, requestType, 0, 0, 0, 0, 0, 0, 0}
+	binary.LittleEndian.PutUint16(header[4:], value)
+	binary.LittleEndian.PutUint16(header[8:], length)
+	q.write(usbRedirControlPacket, 1, header, data)
+	reply := q.read(usbRedirControlPacket)
+	assert.Equal(q.t, uint8(usbRedirSuccess), reply[3])
+	return reply[10:]
+}
+
+// mbim sends MBIM message and returns the response
+func (q *fakeQEMU) mbim(msg []byte) []byte {
+	q.control(0x21, reqSendEncapsulatedCommand, 0, uint16(len(msg)), msg)
+	return q.control(0xa1, reqGetEncapsulatedResponse, 0, maxControlMessage, nil)
+}
+
+func TestModem(t *testing.T) {
+	t.Parallel()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	defer listener.Close()
+	socket := filepath.Join(t.TempDir(), "usbredir-sock")
+	m, err := New(Config{Socket: socket, NetDev: listener.Addr().String(), MAC: "02:00:00:00:00:01", APN: "internet"})
+	assert.NoError(t, err)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() { assert.NoError(t, m.Run(ctx)) }()
+
+	conn, err := listener.Accept()
+	assert.NoError(t, err)
+	defer conn.Close()
+	sdn := &fakeSDN{conn: conn, uplink: make(chan []byte, 1)}
+	go sdn.serve()
+	assert.Eventually(t, func() bool { return m.net.lease() != nil }, 10*time.Second, 50*time.Millisecond)
+	assert.Equal(t, "172.22.20.10/24 via 172.22.20.1", m.net.lease().String())
+
+	var qemuConn net.Conn
+	assert.Eventually(t, func() bool {
+		qemuConn, err = net.Dial("unix", socket)
+		return err == nil
+	}, 10*time.Second, 50*time.Millisecond)
+	defer qemuConn.Close()
+	qemu := &fakeQEMU{t: t, conn: qemuConn}
+
+	// QEMU learns the device after exchange of capabilities
+	qemu.read(usbRedirHello)
+	caps := binary.LittleEndian.AppendUint32(nil, 1<<usbRedirCapConnectDeviceVersion|1<<usbRedirCapEpInfoMaxPacketSize)
+	qemu.write(usbRedirHello, 0, make([]byte, 64), caps)
+	interfaces := qemu.read(usbRedirInterfaceInfo)
+	assert.Equal(t, uint32(2), binary.LittleEndian.Uint32(interfaces))
+	epInfo := qemu.read(usbRedirEpInfo)
+	assert.Equal(t, uint8(usbRedirTypeBulk), epInfo[epIndex(epDataIn)])
+	assert.Equal(t, uint16(512), binary.LittleEndian.Uint16(epInfo[96+2*epIndex(epDataOut):]))
+	connect := qemu.read(usbRedirDeviceConnect)
+	assert.Len(t, connect, 10)
+	assert.Equal(t, mbimDevice.vendorID, binary.LittleEndian.Uint16(connect[4:]))
+
+	device := qemu.control(0x80, reqGetDescriptor, descDevice<<8, 18, nil)
+	assert.Equal(t, mbimDevice.deviceDescriptor(), device)
+	config := qemu.control(0x80, reqGetDescriptor, descConfiguration<<8, 9, nil)
+	assert.Len(t, config, 9, "descriptor is cut to requested length")
+	config = qemu.control(0x80, reqGetDescriptor, descConfiguration<<8, binary.LittleEndian.Uint16(config[2:]), nil)
+	assert.Contains(t, string(config), string([]byte{9, descInterface, intfControl, 0, 1, 0x02, 0x0e, 0x00}))
+
+	qemu.write(usbRedirStartInterruptReceiving, 2, []byte{epNotify}, nil)
+	assert.Equal(t, []byte{usbRedirSuccess, epNotify}, qemu.read(usbRedirInterruptReceivingStatus))
+
+	open := qemu.mbim(mbimMessage(mbimOpen, 1, binary.LittleEndian.AppendUint32(nil, maxControlMessage)))
+	assert.Equal(t, mbimStatusMessage(mbimOpenDone, 1, mbimStatusSuccess), open)
+	status, _ := commandDone(t, qemu.mbim(mbimCommandMessage(2, mbimCIDConnect, mbimCommandSet, mbimSetConnect(true, "internet"))))
+	assert.Equal(t, uint32(mbimStatusSuccess), status)
+
+	// packet of the host leads into SDN through the gateway
+	packet := make([]byte, 28)
+	packet[0], packet[9] = 0x45, ipProtoUDP
+	binary.BigEndian.PutUint16(packet[2:], uint16(len(packet)))
+	copy(packet[12:], testModemIP)
+	copy(packet[16:], net.IPv4(8, 8, 8, 8).To4())
+	ntb := buildNTB16(1, 0, packet)
+	qemu.write(usbRedirBulkPacket, 3, bulkHeader(epDataOut, 0, len(ntb)), ntb)
+	assert.Equal(t, bulkHeader(epDataOut, usbRedirSuccess, len(ntb)), qemu.read(usbRedirBulkPacket))
+	select {
+	case uplink := <-sdn.uplink:
+		assert.Equal(t, packet, uplink)
+	case <-time.After(10 * time.Second):
+		t.Fatal("packet of the host is not passed into SDN")
+	}
+
+	// packet from SDN completes pending transfer
+	qemu.write(usbRedirBulkPacket, 4, bulkHeader(epDataIn, 0, ntbMaxSize), nil)
+	reply := append([]byte(nil), packet...)
+	copy(reply[12:], net.IPv4(8, 8, 8, 8).To4())
+	copy(reply[16:], testModemIP)
+	// Ethernet padding is not passed to the host
+	sdn.writeFrame(ethernetFrame(m.net.mac, testGatewayMAC, etherTypeIPv4, append(reply, 0, 0)))
+	bulk := qemu.read(usbRedirBulkPacket)
+	assert.Equal(t, uint8(usbRedirSuccess), bulk[1])
+	datagrams, err := parseNTB16(bulk[8:], 0)
+	assert.NoError(t, err)
+	assert.Equal(t, [][]byte{reply}, datagrams)
+
+	stats := m.net.stats()
+	assert.Equal(t, uint64(1), stats.inPackets)
+	assert.Equal(t, uint64(1), stats.outPackets)
+}
@@ -0,0 +1,112 @@
+package modem
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+)
+
+// IP packets of the data connection are carried over bulk endpoints in NCM transfer blocks
+// with 16-bit offsets (NTB16), datagrams of MBIM session N are listed in NDPs with signature "IPS"+N
+
+const (
+	nth16Signature = 0x484d434e // "NCMH"
+	nth16Len       = 12
+	ndp16IPS       = 0x00535049 // "IPS" of session 0
+	ndp16Len       = 8
+	ndp16EntryLen  = 4
+
+	// ntbMaxSize is maximum size of transfer block in both directions
+	ntbMaxSize = 16384
+	// ntbAlignment is alignment of NDP and datagrams in transfer block
+	ntbAlignment = 4
+
+	// ncm class-specific requests
+	reqGetNtbParameters   = 0x80
+	reqGetNtbFormat       = 0x83
+	reqGetNtbInputSize    = 0x85
+	reqSetNtbInputSize    = 0x86
+	reqGetMaxDatagramSize = 0x87
+)
+
+// ntbParameters returns response to GET_NTB_PARAMETERS, only NTB16 is supported
+func ntbParameters() []byte {
+	params := make([]byte, 28)
+	binary.LittleEndian.PutUint16(params[0:], 28)
+	binary.LittleEndian.PutUint16(params[2:], 0x0001) // NTB16
+	binary.LittleEndian.PutUint32(params[4:], ntbMaxSize)
+	binary.LittleEndian.PutUint16(params[8:], ntbAlignment)
+	binary.LittleEndian.PutUint16(params[12:], ntbAlignment)
+	binary.LittleEndian.PutUint32(params[16:], ntbMaxSize)
+	binary.LittleEndian.PutUint16(params[20:], ntbAlignment)
+	binary.LittleEndian.PutUint16(params[24:], ntbAlignment)
+	return params
+}
+
+// parseNTB16 returns datagrams of MBIM session from transfer block
+func parseNTB16(ntb []byte, session uint8) ([][]byte, error) {
+	if len(ntb) < nth16Len {
+		return nil, errors.New("NTB is too short")
+	}
+	if binary.LittleEndian.Uint32(ntb) != nth16Signature {
+		return nil, fmt.Errorf("wrong NTH16 signature %#x", binary.LittleEndian.Uint32(ntb))
+	}
+	blockLen := int(binary.LittleEndian.Uint16(ntb[8:]))
+	if blockLen > len(ntb) {
+		return nil, fmt.Errorf("NTB block length %d exceeds transfer %d", blockLen, len(ntb))
+	}
+	ntb = ntb[:blockLen]
+	var datagrams [][]byte
+	ndpIndex := int(binary.LittleEndian.Uint16(ntb[10:]))
+	// NDPs are chained, limit their number to not loop forever on malformed block
+	for ndps := 0; ndpIndex != 0; ndps++ {
+		if ndps > blockLen/ndp16Len || ndpIndex < nth16Len || ndpIndex+ndp16Len > blockLen {
+			return nil, fmt.Errorf("wrong NDP16 index %d", ndpIndex)
+		}
+		ndp := ntb[ndpIndex:]
+		ndpLen := int(binary.LittleEndian.Uint16(ndp[4:]))
+		if ndpLen < ndp16Len+2*ndp16EntryLen || ndpIndex+ndpLen > blockLen {
+			return nil, fmt.Errorf("wrong NDP16 length %d", ndpLen)
+		}
+		signature := binary.LittleEndian.Uint32(ndp)
+		if signature == ndp16IPS|uint32(session)<<24 {
+			for entry := ndp16Len; entry+ndp16EntryLen <= ndpLen; entry += ndp16EntryLen {
+				index := int(binary.LittleEndian.Uint16(ndp[entry:]))
+				length := int(binary.LittleEndian.Uint16(ndp[entry+2:]))
+				if index == 0 || length == 0 {
+					break
+				}
+				if index+length > blockLen {
+					return nil, fmt.Errorf("datagram at %d of length %d is out of NTB", index, length)
+				}
+				datagrams = append(datagrams, ntb[index:index+length])
+			}
+		}
+		ndpIndex = int(binary.LittleEndian.Uint16(ndp[6:]))
+	}
+	return datagrams, nil
+}
+
+// buildNTB16 returns transfer block with datagrams of MBIM session
+func buildNTB16(sequence uint16, session uint8, datagrams ...[]byte) []byte {
+	ndpLen := ndp16Len + (len(datagrams)+1)*ndp16EntryLen
+	ntb := make([]byte, nth16Len+ndpLen)
+	binary.LittleEndian.PutUint32(ntb[0:], nth16Signature)
+	binary.LittleEndian.PutUint16(ntb[4:], nth16Len)
+	binary.LittleEndian.PutUint16(ntb[6:], sequence)
+	binary.LittleEndian.PutUint16(ntb[10:], nth16Len)
+	ndp := ntb[nth16Len:]
+	binary.LittleEndian.PutUint32(ndp[0:], ndp16IPS|uint32(session)<<24)
+	binary.LittleEndian.PutUint16(ndp[4:], uint16(ndpLen))
+	for i, datagram := range datagrams {
+		for len(ntb)%ntbAlignment != 0 {
+			ntb = append(ntb, 0)
+		}
+		entry := nth16Len + ndp16Len + i*ndp16EntryLen
+		binary.LittleEndian.PutUint16(ntb[entry:], uint16(len(ntb)))
+		binary.LittleEndian.PutUint16(ntb[entry+2:], uint16(len(datagram)))
+		ntb = append(ntb, datagram...)
+	}
+	binary.LittleEndian.PutUint16(ntb[8:], uint16(len(ntb)))
+	return ntb
+}
@@ -0,0 +1,42 @@
+package modem
+
+import (
+	"encoding/binary"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNTB16(t *testing.T) {
+	t.Parallel()
+
+	first, second := []byte{0x45, 1, 2}, []byte{0x45, 3, 4, 5, 6}
+	ntb := buildNTB16(7, 0, first, second)
+	assert.Equal(t, uint16(7), binary.LittleEndian.Uint16(ntb[6:]))
+	assert.Equal(t, len(ntb), int(binary.LittleEndian.Uint16(ntb[8:])))
+
+	datagrams, err := parseNTB16(ntb, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, [][]byte{first, second}, datagrams)
+
+	// datagrams of other sessions are skipped
+	datagrams, err = parseNTB16(ntb, 1)
+	assert.NoError(t, err)
+	assert.Empty(t, datagrams)
+
+	// transfer could be longer than the block
+	datagrams, err = parseNTB16(append(ntb, 0, 0, 0, 0), 0)
+	assert.NoError(t, err)
+	assert.Len(t, datagrams, 2)
+
+	_, err = parseNTB16(ntb[:len(ntb)-1], 0)
+	assert.Error(t, err)
+	_, err = parseNTB16([]byte("NCMX00000000"), 0)
+	assert.Error(t, err)
+
+	// NDP pointing to itself must not loop forever
+	looped := append([]byte(nil), ntb...)
+	binary.LittleEndian.PutUint16(looped[nth16Len+6:], nth16Len)
+	_, err = parseNTB16(looped, 0)
+	assert.Error(t, err)
+}
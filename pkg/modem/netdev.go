@@ -0,0 +1,463 @@
+package modem
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"net"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	etherTypeIPv4 = 0x0800
+	etherTypeARP  = 0x0806
+	etherHeadLen  = 14
+
+	ipProtoUDP = 17
+
+	dhcpServerPort = 67
+	dhcpClientPort = 68
+
+	dhcpDiscover = 1
+	dhcpOffer    = 2
+	dhcpRequest  = 3
+	dhcpAck      = 5
+	dhcpNak      = 6
+
+	// dhcpRetryInterval is interval to repeat DHCP requests without reply
+	dhcpRetryInterval = 2 * time.Second
+	// netDevRetryInterval is interval to reconnect to SDN
+	netDevRetryInterval = time.Second
+	// arpQueueLen limits packets waiting for address resolution of their next hop
+	arpQueueLen = 16
+)
+
+var broadcastMAC = net.HardwareAddr{0xff, 0xff, 0xff, 0xff, 0xff, 0xff}
+
+// dhcpLease is address of the modem obtained from DHCP server of the network
+type dhcpLease struct {
+	ip     net.IP
+	mask   net.IPMask
+	router net.IP
+	dns    []net.IP
+	server net.IP
+}
+
+// netDev is the data path of the modem in SDN. It connects to QEMU socket netdev of the SDN port
+// as NIC with the MAC, obtains address of the modem with DHCP and passes IPv4 packets between
+// the network and the host, like the network of a mobile operator does.
+type netDev struct {
+	addr string
+	mac  net.HardwareAddr
+	// deliver passes IPv4 packet from the network to the host, it returns false if packet is dropped
+	deliver func(packet []byte) bool
+
+	wmu  sync.Mutex
+	conn io.Writer
+
+	mu       sync.Mutex
+	current  *dhcpLease
+	offered  *dhcpLease
+	xid      uint32
+	renewAt  time.Time
+	expireAt time.Time
+	arp      map[[4]byte]net.HardwareAddr
+	arpQueue map[[4]byte][][]byte
+	counters packetStats
+}
+
+func newNetDev(addr string, mac net.HardwareAddr, deliver func([]byte) bool) *netDev {
+	return &netDev{
+		addr:     addr,
+		mac:      mac,
+		deliver:  deliver,
+		arp:      map[[4]byte]net.HardwareAddr{},
+		arpQueue: map[[4]byte][][]byte{},
+	}
+}
+
+func (n *netDev) lease() *dhcpLease {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return n.current
+}
+
+func (n *netDev) stats() packetStats {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return n.counters
+}
+
+// run connects to SDN and serves the connection until ctx is done, SDN may start later
+// than the modem or be restarted, so the connection is repeated
+func (n *netDev) run(ctx context.Context) {
+	var dialer net.Dialer
+	for {
+		conn, err := dialer.DialContext(ctx, "tcp", n.addr)
+		if err == nil {
+			log.Infof("modem is connected to SDN at %s", n.addr)
+			err = n.serve(ctx, conn)
+			log.Warnf("connection of modem to SDN is lost: %s", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(netDevRetryInterval):
+		}
+	}
+}
+
+// serve obtains address with DHCP and passes frames received from SDN until connection is closed
+func (n *netDev) serve(ctx context.Context, conn net.Conn) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	go func() {
+		<-ctx.Done()
+		_ = conn.Close()
+	}()
+	n.wmu.Lock()
+	n.conn = conn
+	n.wmu.Unlock()
+	n.mu.Lock()
+	// address is obtained again, the network could be changed
+	n.current, n.offered = nil, nil
+	n.arp = map[[4]byte]net.HardwareAddr{}
+	n.mu.Unlock()
+	go n.dhcpLoop(ctx)
+	for {
+		var length [4]byte
+		if _, err := io.ReadFull(conn, length[:]); err != nil {
+			return err
+		}
+		frame := make([]byte, binary.BigEndian.Uint32(length[:]))
+		if _, err := io.ReadFull(conn, frame); err != nil {
+			return err
+		}
+		n.receive(frame)
+	}
+}
+
+// writeFrame sends Ethernet frame into SDN
+func (n *netDev) writeFrame(frame []byte) error {
+	n.wmu.Lock()
+	defer n.wmu.Unlock()
+	if n.conn == nil {
+		return errors.New("modem is not connected to SDN")
+	}
+	buf := binary.BigEndian.AppendUint32(make([]byte, 0, 4+len(frame)), uint32(len(frame)))
+	_, err := n.conn.Write(append(buf, frame...))
+	return err
+}
+
+func (n *netDev) ethernet(dst net.HardwareAddr, etherType uint16, payload []byte) []byte {
+	frame := make([]byte, etherHeadLen, etherHeadLen+len(payload))
+	copy(frame[0:], dst)
+	copy(frame[6:], n.mac)
+	binary.BigEndian.PutUint16(frame[12:], etherType)
+	return append(frame, payload...)
+}
+
+// receive handles Ethernet frame received from SDN
+func (n *netDev) receive(frame []byte) {
+	if len(frame) < etherHeadLen {
+		return
+	}
+	dst := net.HardwareAddr(frame[0:6])
+	if !bytes.Equal(dst, n.mac) && !bytes.Equal(dst, broadcastMAC) {
+		return
+	}
+	payload := frame[etherHeadLen:]
+	switch binary.BigEndian.Uint16(frame[12:]) {
+	case etherTypeARP:
+		n.receiveARP(payload)
+	case etherTypeIPv4:
+		n.receiveIPv4(payload)
+	}
+}
+
+func (n *netDev) receiveIPv4(packet []byte) {
+	if len(packet) < 20 || packet[0]>>4 != 4 {
+		return
+	}
+	totalLen := int(binary.BigEndian.Uint16(packet[2:]))
+	headerLen := int(packet[0]&0x0f) * 4
+	if totalLen > len(packet) || headerLen < 20 || headerLen > totalLen {
+		return
+	}
+	// Ethernet frames could be padded
+	packet = packet[:totalLen]
+	if packet[9] == ipProtoUDP && totalLen >= headerLen+8 &&
+		binary.BigEndian.Uint16(packet[headerLen+2:]) == dhcpClientPort {
+		n.receiveDHCP(packet[headerLen+8:])
+		return
+	}
+	n.mu.Lock()
+	current := n.current
+	n.mu.Unlock()
+	if current == nil || !net.IP(packet[16:20]).Equal(current.ip) {
+		return
+	}
+	delivered := n.deliver(packet)
+	n.mu.Lock()
+	if delivered {
+		n.counters.inPackets++
+		n.counters.inOctets += uint64(len(packet))
+	} else {
+		n.counters.inDiscards++
+	}
+	n.mu.Unlock()
+}
+
+// send passes IPv4 packet from the host into the network
+func (n *netDev) send(packet []byte) {
+	if len(packet) < 20 || packet[0]>>4 != 4 {
+		// only IPv4 is provided by the network
+		n.discard()
+		return
+	}
+	n.mu.Lock()
+	current := n.current
+	if current == nil {
+		n.counters.outDiscards++
+		n.mu.Unlock()
+		return
+	}
+	nextHop := net.IP(packet[16:20])
+	if !(&net.IPNet{IP: current.ip.Mask(current.mask), Mask: current.mask}).Contains(nextHop) && current.router != nil {
+		nextHop = current.router
+	}
+	var key [4]byte
+	copy(key[:], nextHop.To4())
+	mac, resolved := n.arp[key]
+	if !resolved {
+		if len(n.arpQueue[key]) < arpQueueLen {
+			n.arpQueue[key] = append(n.arpQueue[key], append([]byte(nil), packet...))
+		} else {
+			n.counters.outDiscards++
+		}
+	} else {
+		n.counters.outPackets++
+		n.counters.outOctets += uint64(len(packet))
+	}
+	n.mu.Unlock()
+	if !resolved {
+		n.sendARP(1, broadcastMAC, nil, nextHop, current.ip)
+		return
+	}
+	if err := n.writeFrame(n.ethernet(mac, etherTypeIPv4, packet)); err != nil {
+		log.Debugf("cannot send packet into SDN: %s", err)
+	}
+}
+
+func (n *netDev) discard() {
+	n.mu.Lock()
+	n.counters.outDiscards++
+	n.mu.Unlock()
+}
+
+// sendARP sends ARP request or reply
+func (n *netDev) sendARP(op uint16, dstMAC, targetMAC net.HardwareAddr, targetIP, senderIP net.IP) {
+	arp := make([]byte, 28)
+	binary.BigEndian.PutUint16(arp[0:], 1)
+	binary.BigEndian.PutUint16(arp[2:], etherTypeIPv4)
+	arp[4], arp[5] = 6, 4
+	binary.BigEndian.PutUint16(arp[6:], op)
+	copy(arp[8:], n.mac)
+	copy(arp[14:], senderIP.To4())
+	copy(arp[18:], targetMAC)
+	copy(arp[24:], targetIP.To4())
+	if err := n.writeFrame(n.ethernet(dstMAC, etherTypeARP, arp)); err != nil {
+		log.Debugf("cannot send ARP into SDN: %s", err)
+	}
+}
+
+func (n *netDev) receiveARP(arp []byte) {
+	if len(arp) < 28 || binary.BigEndian.Uint16(arp[2:]) != etherTypeIPv4 || arp[4] != 6 || arp[5] != 4 {
+		return
+	}
+	op := binary.BigEndian.Uint16(arp[6:])
+	senderMAC := net.HardwareAddr(append([]byte(nil), arp[8:14]...))
+	senderIP := net.IP(append([]byte(nil), arp[14:18]...))
+	targetIP := net.IP(arp[24:28])
+	var key [4]byte
+	copy(key[:], senderIP)
+	n.mu.Lock()
+	current := n.current
+	if current == nil {
+		n.mu.Unlock()
+		return
+	}
+	n.arp[key] = senderMAC
+	queued := n.arpQueue[key]
+	delete(n.arpQueue, key)
+	for _, packet := range queued {
+		n.counters.outPackets++
+		n.counters.outOctets += uint64(len(packet))
+	}
+	n.mu.Unlock()
+	for _, packet := range queued {
+		if err := n.writeFrame(n.ethernet(senderMAC, etherTypeIPv4, packet)); err != nil {
+			log.Debugf("cannot send packet into SDN: %s", err)
+		}
+	}
+	if op == 1 && targetIP.Equal(current.ip) {
+		n.sendARP(2, senderMAC, senderMAC, senderIP, current.ip)
+	}
+}
+
+// dhcpLoop sends DHCP requests to obtain and renew address until ctx is done
+func (n *netDev) dhcpLoop(ctx context.Context) {
+	ticker := time.NewTicker(dhcpRetryInterval)
+	defer ticker.Stop()
+	for {
+		n.mu.Lock()
+		now := time.Now()
+		if n.current != nil && now.After(n.expireAt) {
+			log.Warnf("lease of %s expired", n.current.ip)
+			n.current, n.offered = nil, nil
+		}
+		var msg []byte
+		switch {
+		case n.offered != nil:
+			msg = n.dhcpMessage(dhcpRequest, n.offered)
+		case n.current == nil:
+			n.xid = rand.Uint32()
+			msg = n.dhcpMessage(dhcpDiscover, nil)
+		case now.After(n.renewAt):
+			msg = n.dhcpMessage(dhcpRequest, n.current)
+		}
+		n.mu.Unlock()
+		if msg != nil {
+			if err := n.writeFrame(n.ethernet(broadcastMAC, etherTypeIPv4, udpBroadcast(msg))); err != nil {
+				log.Debugf("cannot send DHCP message into SDN: %s", err)
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// dhcpMessage returns DHCP message of type requesting address of lease
+func (n *netDev) dhcpMessage(msgType byte, lease *dhcpLease) []byte {
+	msg := make([]byte, 240)
+	msg[0], msg[1], msg[2] = 1, 1, 6 // request, Ethernet
+	binary.BigEndian.PutUint32(msg[4:], n.xid)
+	binary.BigEndian.PutUint16(msg[10:], 0x8000) // broadcast reply
+	copy(msg[28:], n.mac)
+	copy(msg[236:], []byte{99, 130, 83, 99})
+	msg = append(msg, 53, 1, msgType)
+	if lease != nil {
+		msg = append(append(msg, 50, 4), lease.ip.To4()...)
+		if lease.server != nil {
+			msg = append(append(msg, 54, 4), lease.server.To4()...)
+		}
+	}
+	// mask, router, DNS, lease time, server
+	return append(msg, 55, 5, 1, 3, 6, 51, 54, 255)
+}
+
+func (n *netDev) receiveDHCP(msg []byte) {
+	if len(msg) < 240 || msg[0] != 2 || !bytes.Equal(msg[28:34], n.mac) ||
+		!bytes.Equal(msg[236:240], []byte{99, 130, 83, 99}) {
+		return
+	}
+	lease := &dhcpLease{ip: net.IP(append([]byte(nil), msg[16:20]...))}
+	var msgType byte
+	leaseTime := time.Hour
+	options := msg[240:]
+	for len(options) >= 2 && options[0] != 255 {
+		if options[0] == 0 {
+			options = options[1:]
+			continue
+		}
+		code, length := options[0], int(options[1])
+		if len(options) < 2+length {
+			return
+		}
+		value := options[2 : 2+length]
+		switch {
+		case code == 53 && length == 1:
+			msgType = value[0]
+		case code == 1 && length == 4:
+			lease.mask = net.IPMask(append([]byte(nil), value...))
+		case code == 3 && length >= 4:
+			lease.router = net.IP(append([]byte(nil), value[:4]...))
+		case code == 6:
+			for i := 0; i+4 <= length; i += 4 {
+				lease.dns = append(lease.dns, net.IP(append([]byte(nil), value[i:i+4]...)))
+			}
+		case code == 51 && length == 4:
+			leaseTime = time.Duration(binary.BigEndian.Uint32(value)) * time.Second
+		case code == 54 && length == 4:
+			lease.server = net.IP(append([]byte(nil), value...))
+		}
+		options = options[2+length:]
+	}
+	if lease.mask == nil {
+		lease.mask = lease.ip.DefaultMask()
+	}
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if binary.BigEndian.Uint32(msg[4:]) != n.xid {
+		return
+	}
+	switch msgType {
+	case dhcpOffer:
+		if n.current == nil && n.offered == nil {
+			n.offered = lease
+		}
+	case dhcpAck:
+		if n.offered == nil && n.current == nil {
+			return
+		}
+		if n.current == nil || !n.current.ip.Equal(lease.ip) {
+			log.Infof("modem obtained address %s from SDN", lease)
+		}
+		n.current, n.offered = lease, nil
+		n.renewAt = time.Now().Add(leaseTime / 2)
+		n.expireAt = time.Now().Add(leaseTime)
+	case dhcpNak:
+		log.Warnf("DHCP server %s refused address", lease.server)
+		n.current, n.offered = nil, nil
+	}
+}
+
+// udpBroadcast returns IPv4 packet with DHCP message broadcasted from the client port
+func udpBroadcast(payload []byte) []byte {
+	packet := make([]byte, 28, 28+len(payload))
+	packet[0] = 0x45
+	binary.BigEndian.PutUint16(packet[2:], uint16(len(packet)+len(payload)))
+	packet[8], packet[9] = 64, ipProtoUDP
+	copy(packet[16:], net.IPv4bcast.To4())
+	binary.BigEndian.PutUint16(packet[10:], ipChecksum(packet[:20]))
+	binary.BigEndian.PutUint16(packet[20:], dhcpClientPort)
+	binary.BigEndian.PutUint16(packet[22:], dhcpServerPort)
+	binary.BigEndian.PutUint16(packet[24:], uint16(8+len(payload)))
+	return append(packet, payload...)
+}
+
+func ipChecksum(header []byte) uint16 {
+	var sum uint32
+	for i := 0; i+1 < len(header); i += 2 {
+		sum += uint32(binary.BigEndian.Uint16(header[i:]))
+	}
+	for sum > 0xffff {
+		sum = sum&0xffff + sum>>16
+	}
+	return ^uint16(sum)
+}
+
+func (l *dhcpLease) String() string {
+	prefixLen, _ := l.mask.Size()
+	return fmt.Sprintf("%s/%d via %s", l.ip, prefixLen, l.router)
+}
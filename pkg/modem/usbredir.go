@@ -0,0 +1,201 @@
+package modem
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"sync"
+)
+
+// usbredir protocol (https://gitlab.freedesktop.org/spice/usbredir/-/blob/main/docs/usb-redirection-protocol.md),
+// the modem is the usb-host side of it and QEMU usb-redir device is the usb-guest side
+
+// types of usbredir packets used by the modem
+const (
+	usbRedirHello                    = 0
+	usbRedirDeviceConnect            = 1
+	usbRedirReset                    = 3
+	usbRedirInterfaceInfo            = 4
+	usbRedirEpInfo                   = 5
+	usbRedirSetConfiguration         = 6
+	usbRedirGetConfiguration         = 7
+	usbRedirConfigurationStatus      = 8
+	usbRedirSetAltSetting            = 9
+	usbRedirGetAltSetting            = 10
+	usbRedirAltSettingStatus         = 11
+	usbRedirStartInterruptReceiving  = 15
+	usbRedirStopInterruptReceiving   = 16
+	usbRedirInterruptReceivingStatus = 17
+	usbRedirCancelDataPacket         = 21
+	usbRedirControlPacket            = 100
+	usbRedirBulkPacket               = 101
+	usbRedirInterruptPacket          = 103
+)
+
+// capabilities of usbredir protocol supported by the modem
+const (
+	usbRedirCapConnectDeviceVersion = 1
+	usbRedirCapEpInfoMaxPacketSize  = 4
+)
+
+// status of usbredir requests
+const (
+	usbRedirSuccess   = 0
+	usbRedirCancelled = 1
+	usbRedirInval     = 2
+	usbRedirStall     = 4
+)
+
+const (
+	usbRedirSpeedHigh = 2
+
+	usbRedirTypeControl   = 0
+	usbRedirTypeBulk      = 2
+	usbRedirTypeInterrupt = 3
+	usbRedirTypeInvalid   = 255
+
+	// usbRedirHeaderLen is length of packet header with 32-bit ids, the modem does not support 64-bit ones
+	usbRedirHeaderLen = 12
+	// usbRedirMaxPacketLen limits packets accepted from QEMU
+	usbRedirMaxPacketLen = 1 << 20
+)
+
+// usbRedirVersion is reported in hello packet
+const usbRedirVersion = "eden-modem"
+
+// usbRedirPacket is a packet of usbredir protocol split into type-specific header and data
+type usbRedirPacket struct {
+	Type   uint32
+	ID     uint32
+	Header []byte
+	Data   []byte
+}
+
+// usbRedirHeaderLens are lengths of type-specific headers of packets sent by QEMU
+var usbRedirHeaderLens = map[uint32]int{
+	usbRedirHello:                   64,
+	usbRedirSetConfiguration:        1,
+	usbRedirSetAltSetting:           2,
+	usbRedirGetAltSetting:           1,
+	usbRedirStartInterruptReceiving: 1,
+	usbRedirStopInterruptReceiving:  1,
+	usbRedirControlPacket:           10,
+	usbRedirBulkPacket:              8,
+	usbRedirInterruptPacket:         4,
+}
+
+// usbRedirConn is usbredir connection with QEMU, writes are serialized
+type usbRedirConn struct {
+	rw io.ReadWriter
+	mu sync.Mutex
+	// caps are capabilities supported by both sides, known after hello from QEMU
+	caps uint32
+}
+
+// readPacket reads the next packet sent by QEMU
+func (c *usbRedirConn) readPacket() (*usbRedirPacket, error) {
+	var hdr [usbRedirHeaderLen]byte
+	if _, err := io.ReadFull(c.rw, hdr[:]); err != nil {
+		return nil, err
+	}
+	packet := &usbRedirPacket{
+		Type: binary.LittleEndian.Uint32(hdr[0:]),
+		ID:   binary.LittleEndian.Uint32(hdr[8:]),
+	}
+	length := binary.LittleEndian.Uint32(hdr[4:])
+	if length > usbRedirMaxPacketLen {
+		return nil, fmt.Errorf("usbredir packet of type %d is too long: %d", packet.Type, length)
+	}
+	body := make([]byte, length)
+	if _, err := io.ReadFull(c.rw, body); err != nil {
+		return nil, err
+	}
+	headerLen := usbRedirHeaderLens[packet.Type]
+	if headerLen > len(body) {
+		return nil, fmt.Errorf("usbredir packet of type %d is too short: %d", packet.Type, length)
+	}
+	packet.Header, packet.Data = body[:headerLen], body[headerLen:]
+	return packet, nil
+}
+
+// writePacket sends packet to QEMU
+func (c *usbRedirConn) writePacket(packetType, id uint32, header, data []byte) error {
+	buf := make([]byte, usbRedirHeaderLen, usbRedirHeaderLen+len(header)+len(data))
+	binary.LittleEndian.PutUint32(buf[0:], packetType)
+	binary.LittleEndian.PutUint32(buf[4:], uint32(len(header)+len(data)))
+	binary.LittleEndian.PutUint32(buf[8:], id)
+	buf = append(append(buf, header...), data...)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	_, err := c.rw.Write(buf)
+	return err
+}
+
+// hello sends hello packet with capabilities of the modem
+func (c *usbRedirConn) hello() error {
+	header := make([]byte, 64)
+	copy(header, usbRedirVersion)
+	caps := make([]byte, 4)
+	binary.LittleEndian.PutUint32(caps, 1<<usbRedirCapConnectDeviceVersion|1<<usbRedirCapEpInfoMaxPacketSize)
+	return c.writePacket(usbRedirHello, 0, header, caps)
+}
+
+// handleHello keeps capabilities supported by both the modem and QEMU
+func (c *usbRedirConn) handleHello(packet *usbRedirPacket) {
+	var peerCaps uint32
+	if len(packet.Data) >= 4 {
+		peerCaps = binary.LittleEndian.Uint32(packet.Data)
+	}
+	c.caps = peerCaps & (1<<usbRedirCapConnectDeviceVersion | 1<<usbRedirCapEpInfoMaxPacketSize)
+}
+
+func (c *usbRedirConn) hasCap(capability int) bool {
+	return c.caps&(1<<capability) != 0
+}
+
+// epIndex returns index of endpoint in ep_info packet
+func epIndex(endpoint uint8) int {
+	return int((endpoint&0x80)>>3 | endpoint&0x0f)
+}
+
+// connectDevice announces the modem to QEMU
+func (c *usbRedirConn) connectDevice(dev *usbDevice) error {
+	info := make([]byte, 4+4*32)
+	binary.LittleEndian.PutUint32(info, uint32(len(dev.interfaces)))
+	for i, intf := range dev.interfaces {
+		info[4+i] = intf.number
+		info[4+32+i] = intf.class
+		info[4+64+i] = intf.subClass
+		info[4+96+i] = intf.protocol
+	}
+	if err := c.writePacket(usbRedirInterfaceInfo, 0, info, nil); err != nil {
+		return err
+	}
+	epInfoLen := 3 * 32
+	if c.hasCap(usbRedirCapEpInfoMaxPacketSize) {
+		epInfoLen += 2 * 32
+	}
+	epInfo := make([]byte, epInfoLen)
+	for i := 0; i < 32; i++ {
+		epInfo[i] = usbRedirTypeInvalid
+	}
+	for _, ep := range dev.endpoints() {
+		idx := epIndex(ep.address)
+		epInfo[idx] = ep.redirType
+		epInfo[32+idx] = ep.interval
+		epInfo[64+idx] = ep.intf
+		if c.hasCap(usbRedirCapEpInfoMaxPacketSize) {
+			binary.LittleEndian.PutUint16(epInfo[96+2*idx:], ep.maxPacketSize)
+		}
+	}
+	if err := c.writePacket(usbRedirEpInfo, 0, epInfo, nil); err != nil {
+		return err
+	}
+	connect := []byte{usbRedirSpeedHigh, dev.class, dev.subClass, dev.protocol, 0, 0, 0, 0}
+	binary.LittleEndian.PutUint16(connect[4:], dev.vendorID)
+	binary.LittleEndian.PutUint16(connect[6:], dev.productID)
+	if c.hasCap(usbRedirCapConnectDeviceVersion) {
+		connect = binary.LittleEndian.AppendUint16(connect, dev.version)
+	}
+	return c.writePacket(usbRedirDeviceConnect, 0, connect, nil)
+}
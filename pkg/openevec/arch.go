@@ -0,0 +1,23 @@
+package openevec
+
+import (
+	"fmt"
+
+	"github.com/lf-edge/eden/pkg/defaults"
+)
+
+// checkArch checks that EVE of architecture from cfg can run with eden
+func checkArch(cfg *EdenSetupArgs) error {
+	switch cfg.Eve.Arch {
+	case "", "amd64", "arm64":
+		return nil
+	case "riscv64":
+		// there is no image of Eden-SDN VM for riscv64 yet
+		if cfg.Eve.DevModel == defaults.DefaultQemuModel && isSdnEnabled(cfg.Sdn.Disable, cfg.Eve.Remote, cfg.Eve.DevModel) {
+			return fmt.Errorf("EVE for riscv64 runs without Eden-SDN, please set sdn.disable to true in config")
+		}
+		return nil
+	default:
+		return fmt.Errorf("not supported arch of EVE: %s", cfg.Eve.Arch)
+	}
+}
@@ -0,0 +1,231 @@
+package openevec
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/lf-edge/eden/pkg/benchmark"
+	"github.com/lf-edge/eden/pkg/controller"
+	"github.com/lf-edge/eden/pkg/controller/einfo"
+	"github.com/lf-edge/eden/pkg/device"
+	"github.com/lf-edge/eden/pkg/eve"
+	"github.com/lf-edge/eve-api/go/info"
+	log "github.com/sirupsen/logrus"
+)
+
+// Benchmark deploys app from appLink bc.Repetitions times one by one, measures time of phases
+// of every deployment from info of EVE and writes report. Every app is deleted with its volumes
+// after it is running, before the next repetition.
+func (openEVEC *OpenEVEC) Benchmark(appLink string, pc PodConfig, bc BenchmarkConfig) error {
+	if bc.Repetitions < 1 {
+		return fmt.Errorf("number of repetitions must be positive: %d", bc.Repetitions)
+	}
+	if bc.Format != "json" && bc.Format != "csv" {
+		return fmt.Errorf("unsupported format of report: %s", bc.Format)
+	}
+	report := &benchmark.Report{
+		AppLink: appLink,
+		EVETag:  openEVEC.cfg.Eve.Tag,
+		HV:      openEVEC.cfg.Eve.HV,
+		Arch:    openEVEC.cfg.Eve.Arch,
+		Created: time.Now().UTC(),
+	}
+	for i := 1; i <= bc.Repetitions; i++ {
+		pc.Name = fmt.Sprintf("%s-%d", bc.Name, i)
+		run, err := openEVEC.benchmarkRun(appLink, pc, bc.Timeout)
+		if err != nil {
+			return fmt.Errorf("repetition %d: %w", i, err)
+		}
+		run.Iteration = i
+		report.Runs = append(report.Runs, run)
+		if run.Error != "" {
+			log.Warnf("repetition %d failed: %s", i, run.Error)
+		} else if d, ok := run.Duration(benchmark.PhaseRunning); ok {
+			log.Infof("repetition %d: app %s is running in %s", i, run.App, d.Round(time.Millisecond))
+		}
+		if _, err := openEVEC.PodDelete(pc.Name, true); err != nil {
+			return fmt.Errorf("cannot delete app %s: %w", pc.Name, err)
+		}
+		if err := openEVEC.waitAppRemoved(pc.Name, bc.Timeout); err != nil {
+			return err
+		}
+	}
+	report.Summarize()
+	var out io.Writer = os.Stdout
+	if bc.Output != "" {
+		f, err := os.Create(bc.Output)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		out = f
+		printBenchmarkSummary(report)
+	}
+	if bc.Format == "csv" {
+		return report.WriteCSV(out)
+	}
+	return report.WriteJSON(out)
+}
+
+// benchmarkRun deploys app and tracks its phases until it is running, failed or timeout expired
+func (openEVEC *OpenEVEC) benchmarkRun(appLink string, pc PodConfig, timeout time.Duration) (*benchmark.Run, error) {
+	changer := &adamChanger{}
+	ctrl, dev, err := changer.getControllerAndDevFromConfig(openEVEC.cfg)
+	if err != nil {
+		return nil, fmt.Errorf("getControllerAndDevFromConfig: %w", err)
+	}
+	// stream is started before deployment to not miss info sent by EVE right after it,
+	// messages are kept until IDs of objects of app are known
+	msgs := make(chan *info.ZInfoMsg, 1024)
+	stop := make(chan struct{})
+	defer close(stop)
+	streamDone := make(chan error, 1)
+	go func() {
+		streamDone <- ctrl.InfoChecker(dev.GetID(), nil, func(im *info.ZInfoMsg) bool {
+			select {
+			case <-stop:
+				return true
+			case msgs <- im:
+				return false
+			}
+		}, einfo.InfoNew, timeout)
+	}()
+	if err := openEVEC.PodDeploy(appLink, pc, openEVEC.cfg); err != nil {
+		return nil, err
+	}
+	run := &benchmark.Run{App: pc.Name, Start: time.Now().UTC()}
+	// reload device to get the deployed app
+	ctrl, dev, err = changer.getControllerAndDevFromConfig(openEVEC.cfg)
+	if err != nil {
+		return nil, fmt.Errorf("getControllerAndDevFromConfig: %w", err)
+	}
+	tracker, err := appTracker(ctrl, dev, run, pc.Name)
+	if err != nil {
+		return nil, err
+	}
+	deadline := time.NewTimer(timeout)
+	defer deadline.Stop()
+	for {
+		select {
+		case im := <-msgs:
+			if tracker.Process(im) {
+				return run, nil
+			}
+		case err := <-streamDone:
+			return nil, fmt.Errorf("info stream stopped: %w", err)
+		case <-deadline.C:
+			run.Error = fmt.Sprintf("app is not running in %s", timeout)
+			return run, nil
+		}
+	}
+}
+
+// appTracker returns tracker of app with name and its volumes from config in controller
+func appTracker(ctrl controller.Cloud, dev *device.Ctx, run *benchmark.Run, appName string) (*benchmark.Tracker, error) {
+	for _, id := range dev.GetApplicationInstances() {
+		app, err := ctrl.GetApplicationInstanceConfig(id)
+		if err != nil {
+			return nil, fmt.Errorf("no app in cloud %s: %w", id, err)
+		}
+		if app.Displayname != appName {
+			continue
+		}
+		var volumeIDs, contentTreeIDs []string
+		for _, ref := range app.VolumeRefList {
+			vol, err := ctrl.GetVolume(ref.Uuid)
+			if err != nil {
+				return nil, fmt.Errorf("no volume in cloud %s: %w", ref.Uuid, err)
+			}
+			volumeIDs = append(volumeIDs, vol.Uuid)
+			if ct := vol.GetOrigin().GetDownloadContentTreeID(); ct != "" {
+				contentTreeIDs = append(contentTreeIDs, ct)
+			}
+		}
+		return benchmark.NewTracker(run, app.Uuidandversion.Uuid, volumeIDs, contentTreeIDs), nil
+	}
+	return nil, fmt.Errorf("app %s not found in controller", appName)
+}
+
+// waitAppRemoved waits until EVE does not report app with name
+func (openEVEC *OpenEVEC) waitAppRemoved(appName string, timeout time.Duration) error {
+	changer := &adamChanger{}
+	ctrl, dev, err := changer.getControllerAndDevFromConfig(openEVEC.cfg)
+	if err != nil {
+		return fmt.Errorf("getControllerAndDevFromConfig: %w", err)
+	}
+	removed := func(state *eve.State) bool {
+		for _, app := range state.Applications() {
+			if app.Name == appName {
+				return false
+			}
+		}
+		return true
+	}
+	if err := eve.NewWaiter(ctrl, dev, eve.Init(ctrl, dev)).Wait(removed, timeout); err != nil {
+		return fmt.Errorf("app %s is not removed: %w", appName, err)
+	}
+	return nil
+}
+
+func formatSeconds(v float64) string {
+	return (time.Duration(v * float64(time.Second))).Round(time.Millisecond).String()
+}
+
+func printBenchmarkSummary(report *benchmark.Report) {
+	w := tabwriter.NewWriter(os.Stdout, 1, 1, 1, ' ', 0)
+	fmt.Fprintln(w, "PHASE\tCOUNT\tMIN\tMEDIAN\tMEAN\tMAX")
+	for _, s := range report.Summary {
+		if s.Count == 0 {
+			fmt.Fprintf(w, "%s\t0\t-\t-\t-\t-\n", s.Phase)
+			continue
+		}
+		fmt.Fprintf(w, "%s\t%d\t%s\t%s\t%s\t%s\n", s.Phase, s.Count,
+			formatSeconds(s.Min), formatSeconds(s.Median), formatSeconds(s.Mean), formatSeconds(s.Max))
+	}
+	_ = w.Flush()
+}
+
+// BenchmarkCompare prints changes of median durations of phases between reports in JSON,
+// it returns error if some phase became slower by more than threshold percents
+func BenchmarkCompare(basePath, otherPath string, threshold float64) error {
+	base, err := benchmark.ReadReport(basePath)
+	if err != nil {
+		return err
+	}
+	other, err := benchmark.ReadReport(otherPath)
+	if err != nil {
+		return err
+	}
+	var regressions []string
+	w := tabwriter.NewWriter(os.Stdout, 1, 1, 1, ' ', 0)
+	fmt.Fprintf(w, "PHASE\t%s\t%s\tCHANGE\n", reportLabel(base), reportLabel(other))
+	for _, delta := range benchmark.Compare(base, other, threshold) {
+		change := "-"
+		if !math.IsNaN(delta.Change) {
+			change = fmt.Sprintf("%+.1f%%", delta.Change)
+		}
+		if delta.Regression {
+			change += " REGRESSION"
+			regressions = append(regressions, string(delta.Phase))
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", delta.Phase, formatSeconds(delta.Base), formatSeconds(delta.Other), change)
+	}
+	_ = w.Flush()
+	if len(regressions) > 0 {
+		return fmt.Errorf("phases slower by more than %.1f%%: %s", threshold, strings.Join(regressions, ", "))
+	}
+	return nil
+}
+
+// reportLabel describes environment of report in header of comparison
+func reportLabel(report *benchmark.Report) string {
+	if report.EVETag == "" {
+		return report.Created.Format(time.RFC3339)
+	}
+	return fmt.Sprintf("%s/%s", report.EVETag, report.HV)
+}
@@ -0,0 +1,323 @@
+package openevec
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/lf-edge/eden/pkg/controller/types"
+	"github.com/lf-edge/eden/pkg/defaults"
+	"github.com/lf-edge/eve-api/go/info"
+	log "github.com/sirupsen/logrus"
+)
+
+// Stages of EVE boot reported by 'eden eve boot-profile' besides console markers
+const (
+	BootStagePowerOn           = "power-on"
+	BootStageKernel            = "kernel"
+	BootStageControllerContact = "controller-contact"
+	BootStageConfig            = "config"
+	BootStageInfo              = "onboarded"
+	BootStageAppsRunning       = "apps-running"
+)
+
+// DefaultBootMarkers are markers of EVE console used by 'eden eve boot-profile' by default
+var DefaultBootMarkers = []string{
+	"rootfs=VFS: Mounted root",
+	"init=Run /init as init process",
+}
+
+// printkRe matches timestamp of kernel message in seconds since boot
+var printkRe = regexp.MustCompile(`\[\s*(\d+\.\d+)\]`)
+
+// BootMarker is regular expression to find stage of boot in console of EVE
+type BootMarker struct {
+	Name string
+	Re   *regexp.Regexp
+}
+
+// ParseBootMarkers parses markers in format name=regexp
+func ParseBootMarkers(specs []string) ([]BootMarker, error) {
+	var markers []BootMarker
+	for _, spec := range specs {
+		split := strings.SplitN(spec, "=", 2)
+		if len(split) != 2 || split[0] == "" || split[1] == "" {
+			return nil, fmt.Errorf("marker must be in format name=regexp: %s", spec)
+		}
+		re, err := regexp.Compile(split[1])
+		if err != nil {
+			return nil, fmt.Errorf("wrong regexp of marker %s: %w", split[0], err)
+		}
+		markers = append(markers, BootMarker{Name: split[0], Re: re})
+	}
+	return markers, nil
+}
+
+// BootStage is moment of EVE boot
+type BootStage struct {
+	Name string `json:"name"`
+	// At is zero if stage is not reached
+	At           time.Time     `json:"at"`
+	SincePowerOn time.Duration `json:"since_power_on"`
+}
+
+// Reached checks if stage is reached
+func (s BootStage) Reached() bool {
+	return !s.At.IsZero()
+}
+
+// BootProfile contains stages of EVE boot ordered by time
+type BootProfile struct {
+	EVEVersion string      `json:"eve_version"`
+	PowerOn    time.Time   `json:"power_on"`
+	Stages     []BootStage `json:"stages"`
+}
+
+// add adds stage and keeps stages ordered with not reached ones at the end
+func (p *BootProfile) add(name string, at time.Time) {
+	stage := BootStage{Name: name, At: at}
+	if stage.Reached() {
+		stage.SincePowerOn = at.Sub(p.PowerOn)
+	}
+	p.Stages = append(p.Stages, stage)
+	sort.SliceStable(p.Stages, func(i, j int) bool {
+		if p.Stages[i].Reached() != p.Stages[j].Reached() {
+			return p.Stages[i].Reached()
+		}
+		return p.Stages[i].At.Before(p.Stages[j].At)
+	})
+}
+
+// stage returns stage with provided name
+func (p *BootProfile) stage(name string) (BootStage, bool) {
+	for _, s := range p.Stages {
+		if s.Name == name {
+			return s, true
+		}
+	}
+	return BootStage{}, false
+}
+
+// ConsoleBootStages finds markers in console output of EVE using timestamps of kernel messages
+// relative to kernelStart. Only the last boot in output is used.
+func ConsoleBootStages(r io.Reader, kernelStart time.Time, markers []BootMarker) (map[string]time.Time, error) {
+	result := map[string]time.Time{}
+	last := -1.0
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		match := printkRe.FindStringSubmatch(line)
+		if match == nil {
+			continue
+		}
+		seconds, err := strconv.ParseFloat(match[1], 64)
+		if err != nil {
+			continue
+		}
+		if seconds < last {
+			// timestamps of kernel start from zero, so EVE rebooted
+			result = map[string]time.Time{}
+		}
+		last = seconds
+		for _, marker := range markers {
+			if _, ok := result[marker.Name]; ok {
+				continue
+			}
+			if marker.Re.MatchString(line) {
+				result[marker.Name] = kernelStart.Add(time.Duration(seconds * float64(time.Second)))
+			}
+		}
+	}
+	return result, scanner.Err()
+}
+
+// EveBootProfile measures stages of the last boot of EVE from power-on to running apps
+func (openEVEC *OpenEVEC) EveBootProfile(markerSpecs []string) (*BootProfile, error) {
+	cfg := openEVEC.cfg
+	markers, err := ParseBootMarkers(markerSpecs)
+	if err != nil {
+		return nil, err
+	}
+	changer := &adamChanger{}
+	ctrl, dev, err := changer.getControllerAndDevFromConfig(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("getControllerAndDevFromConfig: %w", err)
+	}
+
+	var bootTime time.Time
+	var version string
+	// the first info of every boot and times when apps were reported as running
+	firstInfo := map[time.Time]time.Time{}
+	appsRunning := map[string][]time.Time{}
+	handleInfo := func(im *info.ZInfoMsg) bool {
+		at := im.GetAtTimeStamp().AsTime()
+		switch im.Ztype {
+		case info.ZInfoTypes_ZiDevice:
+			dinfo := im.GetDinfo()
+			if dinfo.GetBootTime() == nil {
+				return false
+			}
+			infoBoot := dinfo.GetBootTime().AsTime()
+			if infoBoot.After(bootTime) {
+				bootTime = infoBoot
+				for _, sw := range dinfo.GetSwList() {
+					if sw.GetActivated() {
+						version = sw.GetShortVersion()
+					}
+				}
+			}
+			if first, ok := firstInfo[infoBoot]; !ok || at.Before(first) {
+				firstInfo[infoBoot] = at
+			}
+		case info.ZInfoTypes_ZiApp:
+			if im.GetAinfo().GetState() == info.ZSwState_RUNNING {
+				appID := im.GetAinfo().GetAppID()
+				appsRunning[appID] = append(appsRunning[appID], at)
+			}
+		}
+		return false
+	}
+	if err = ctrl.InfoLastCallback(dev.GetID(), nil, handleInfo); err != nil {
+		return nil, fmt.Errorf("fail in get InfoLastCallback: %w", err)
+	}
+	if bootTime.IsZero() {
+		return nil, fmt.Errorf("no info about boot of EVE in controller, is EVE onboarded?")
+	}
+
+	profile := &BootProfile{EVEVersion: version, PowerOn: bootTime}
+	if !cfg.Eve.Remote && cfg.Eve.DevModel == defaults.DefaultQemuModel {
+		// pid file of QEMU is written on start of VM
+		if fi, err := os.Stat(cfg.Eve.Pid); err == nil && fi.ModTime().Before(bootTime) {
+			profile.PowerOn = fi.ModTime()
+		} else {
+			log.Warnf("cannot use start of QEMU as power-on, kernel start is used instead")
+		}
+	}
+	profile.add(BootStagePowerOn, profile.PowerOn)
+	profile.add(BootStageKernel, bootTime)
+
+	if !cfg.Eve.Remote && len(markers) > 0 {
+		if f, err := os.Open(cfg.Eve.Log); err != nil {
+			log.Warnf("cannot read console of EVE: %s", err)
+		} else {
+			stages, err := ConsoleBootStages(f, bootTime, markers)
+			_ = f.Close()
+			if err != nil {
+				return nil, fmt.Errorf("cannot parse console of EVE: %w", err)
+			}
+			for _, marker := range markers {
+				profile.add(marker.Name, stages[marker.Name])
+			}
+		}
+	}
+
+	var contact, config time.Time
+	handleRequest := func(request *types.APIRequest) bool {
+		if request.Timestamp.Before(profile.PowerOn) {
+			return false
+		}
+		if contact.IsZero() || request.Timestamp.Before(contact) {
+			contact = request.Timestamp
+		}
+		if strings.Contains(request.URL, "config") && (config.IsZero() || request.Timestamp.Before(config)) {
+			config = request.Timestamp
+		}
+		return false
+	}
+	if err = ctrl.RequestLastCallback(dev.GetID(), map[string]string{"UUID": dev.GetID().String()}, handleRequest); err != nil {
+		return nil, fmt.Errorf("fail in get RequestLastCallback: %w", err)
+	}
+	profile.add(BootStageControllerContact, contact)
+	profile.add(BootStageConfig, config)
+	profile.add(BootStageInfo, firstInfo[bootTime])
+
+	if apps := dev.GetApplicationInstances(); len(apps) > 0 {
+		var allRunning time.Time
+		for _, appID := range apps {
+			// the first report of running app in the last boot
+			var running time.Time
+			for _, at := range appsRunning[appID] {
+				if !at.Before(bootTime) && (running.IsZero() || at.Before(running)) {
+					running = at
+				}
+			}
+			if running.IsZero() {
+				allRunning = time.Time{}
+				break
+			}
+			if running.After(allRunning) {
+				allRunning = running
+			}
+		}
+		profile.add(BootStageAppsRunning, allRunning)
+	}
+	return profile, nil
+}
+
+// ReadBootProfile reads profile saved with 'eden eve boot-profile --format json'
+func ReadBootProfile(path string) (*BootProfile, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var profile BootProfile
+	if err = json.Unmarshal(data, &profile); err != nil {
+		return nil, fmt.Errorf("cannot parse boot profile %s: %w", path, err)
+	}
+	return &profile, nil
+}
+
+// PrintBootProfile prints profile, with baseline it also prints change of every stage
+func PrintBootProfile(w io.Writer, profile, baseline *BootProfile, format types.OutputFormat) error {
+	if format == types.OutputFormatJSON {
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(profile)
+	}
+	if _, err := fmt.Fprintf(w, "EVE version: %s\npower-on: %s\n\n", profile.EVEVersion, profile.PowerOn.Format(time.RFC3339)); err != nil {
+		return err
+	}
+	tw := tabwriter.NewWriter(w, 0, 8, 1, '\t', 0)
+	header := "STAGE\tSINCE POWER-ON\tDELTA"
+	if baseline != nil {
+		header += fmt.Sprintf("\tBASELINE (%s)\tCHANGE", baseline.EVEVersion)
+	}
+	if _, err := fmt.Fprintln(tw, header); err != nil {
+		return err
+	}
+	var previous time.Duration
+	for _, s := range profile.Stages {
+		line := fmt.Sprintf("%s\t-\t-", s.Name)
+		if s.Reached() {
+			line = fmt.Sprintf("%s\t%s\t%s", s.Name, s.SincePowerOn.Round(time.Millisecond), (s.SincePowerOn - previous).Round(time.Millisecond))
+			previous = s.SincePowerOn
+		}
+		if baseline != nil {
+			line += "\t-\t-"
+			if b, ok := baseline.stage(s.Name); ok && b.Reached() {
+				change := "-"
+				if s.Reached() {
+					diff := (s.SincePowerOn - b.SincePowerOn).Round(time.Millisecond)
+					change = diff.String()
+					if diff >= 0 {
+						change = "+" + change
+					}
+				}
+				line = strings.TrimSuffix(line, "\t-\t-") + fmt.Sprintf("\t%s\t%s", b.SincePowerOn.Round(time.Millisecond), change)
+			}
+		}
+		if _, err := fmt.Fprintln(tw, line); err != nil {
+			return err
+		}
+	}
+	return tw.Flush()
+}
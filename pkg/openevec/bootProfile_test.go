@@ -0,0 +1,68 @@
+package openevec_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/lf-edge/eden/pkg/controller/types"
+	"github.com/lf-edge/eden/pkg/openevec"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseBootMarkers(t *testing.T) {
+	t.Parallel()
+
+	markers, err := openevec.ParseBootMarkers(openevec.DefaultBootMarkers)
+	assert.NoError(t, err)
+	assert.Len(t, markers, 2)
+	assert.Equal(t, "rootfs", markers[0].Name)
+
+	_, err = openevec.ParseBootMarkers([]string{"rootfs"})
+	assert.ErrorContains(t, err, "name=regexp")
+
+	_, err = openevec.ParseBootMarkers([]string{"bad=("})
+	assert.ErrorContains(t, err, "wrong regexp of marker bad")
+}
+
+func TestConsoleBootStages(t *testing.T) {
+	t.Parallel()
+
+	markers, err := openevec.ParseBootMarkers(openevec.DefaultBootMarkers)
+	assert.NoError(t, err)
+	console := `[    0.000000] Linux version 6.1
+[    1.500000] VFS: Mounted root (squashfs filesystem) readonly on device 7:0.
+[    2.250000] Run /init as init process
+[    0.000000] Linux version 6.1
+BIOS messages without timestamp
+[    1.750000] VFS: Mounted root (squashfs filesystem) readonly on device 7:0.
+`
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	stages, err := openevec.ConsoleBootStages(strings.NewReader(console), start, markers)
+	assert.NoError(t, err)
+	// only the last boot is used
+	assert.Equal(t, start.Add(1750*time.Millisecond), stages["rootfs"])
+	_, ok := stages["init"]
+	assert.False(t, ok)
+}
+
+func TestPrintBootProfile(t *testing.T) {
+	t.Parallel()
+
+	powerOn := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	profile := &openevec.BootProfile{EVEVersion: "new", PowerOn: powerOn, Stages: []openevec.BootStage{
+		{Name: "kernel", At: powerOn.Add(2 * time.Second), SincePowerOn: 2 * time.Second},
+		{Name: "onboarded", At: powerOn.Add(30 * time.Second), SincePowerOn: 30 * time.Second},
+	}}
+	baseline := &openevec.BootProfile{EVEVersion: "old", PowerOn: powerOn, Stages: []openevec.BootStage{
+		{Name: "kernel", At: powerOn.Add(3 * time.Second), SincePowerOn: 3 * time.Second},
+		{Name: "onboarded", At: powerOn.Add(25 * time.Second), SincePowerOn: 25 * time.Second},
+	}}
+	var buf bytes.Buffer
+	assert.NoError(t, openevec.PrintBootProfile(&buf, profile, baseline, types.OutputFormatLines))
+	out := buf.String()
+	assert.Contains(t, out, "BASELINE (old)")
+	assert.Contains(t, out, "-1s")
+	assert.Contains(t, out, "+5s")
+}
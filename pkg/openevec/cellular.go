@@ -0,0 +1,80 @@
+package openevec
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/lf-edge/eden/pkg/eden"
+	"github.com/lf-edge/eden/pkg/modem"
+	"github.com/lf-edge/eden/pkg/utils"
+)
+
+// cellularModemStartTimeout limits waiting for the modem emulator to serve its socket
+const cellularModemStartTimeout = 10 * time.Second
+
+// cellularStateDir returns directory with socket, pid and log of the modem emulator
+func cellularStateDir(cfg *EdenSetupArgs) string {
+	return filepath.Join(filepath.Dir(cfg.Eve.ImageFile), "cellular")
+}
+
+// startCellularModem starts 'eden eve cellular-modem' in background and returns its usbredir
+// socket for QEMU once it is served. The data path of the modem leads into SDN port listening
+// on netDevPort and uses the MAC on the SDN side.
+func startCellularModem(cfg *EdenSetupArgs, netDevPort int, mac string) (string, error) {
+	command, err := os.Executable()
+	if err != nil {
+		return "", fmt.Errorf("cannot obtain executable path: %w", err)
+	}
+	stateDir := cellularStateDir(cfg)
+	if err := os.MkdirAll(stateDir, 0755); err != nil {
+		return "", err
+	}
+	pidFile := eden.CellularModemPidFile(stateDir)
+	if status, _ := utils.StatusCommandWithPid(pidFile); status != "process doesn't exist" {
+		_ = utils.StopCommandWithPid(pidFile)
+	}
+	socket := eden.CellularModemSocket(stateDir)
+	// socket left by previous run must not be mistaken for the new one
+	_ = os.Remove(socket)
+	err = utils.RunCommandNohup(command, eden.CellularModemLogFile(stateDir), pidFile,
+		"eve", "cellular-modem",
+		"--socket", socket,
+		"--netdev", "127.0.0.1:"+strconv.Itoa(netDevPort),
+		"--mac", mac,
+		"--apn", cfg.Eve.Cellular.APN)
+	if err != nil {
+		return "", err
+	}
+	deadline := time.Now().Add(cellularModemStartTimeout)
+	for {
+		if _, err := os.Stat(socket); err == nil {
+			return socket, nil
+		}
+		if status, _ := utils.StatusCommandWithPid(pidFile); !strings.Contains(status, "running with pid") {
+			return "", fmt.Errorf("modem emulator exited, see %s", eden.CellularModemLogFile(stateDir))
+		}
+		if time.Now().After(deadline) {
+			_ = utils.StopCommandWithPid(pidFile)
+			return "", fmt.Errorf("modem emulator does not serve %s after %s", socket, cellularModemStartTimeout)
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+}
+
+// CellularModem runs emulator of cellular modem until it is interrupted
+func CellularModem(socket, netDev, mac, apn string) error {
+	m, err := modem.New(modem.Config{Socket: socket, NetDev: netDev, MAC: mac, APN: apn})
+	if err != nil {
+		return err
+	}
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	return m.Run(ctx)
+}
@@ -7,10 +7,13 @@ import (
 	"os"
 
 	"github.com/lf-edge/eden/pkg/controller"
+	"github.com/lf-edge/eden/pkg/defaults"
 	"github.com/lf-edge/eden/pkg/device"
 	"github.com/lf-edge/eden/pkg/projects"
+	"github.com/lf-edge/eden/pkg/utils"
 	"github.com/lf-edge/eve-api/go/config"
 	log "github.com/sirupsen/logrus"
+	"google.golang.org/protobuf/proto"
 )
 
 type configChanger interface {
@@ -22,6 +25,7 @@ type configChanger interface {
 type fileChanger struct {
 	fileConfig string
 	oldHash    [32]byte
+	oldConfig  *config.EdgeDevConfig
 }
 
 func changerByControllerMode(controllerMode string) (configChanger, error) {
@@ -40,6 +44,8 @@ func changerByControllerMode(controllerMode string) (configChanger, error) {
 		changer = &fileChanger{fileConfig: modeURL}
 	case "adam":
 		changer = &adamChanger{adamURL: modeURL}
+	case "zedcloud":
+		changer = &zedcloudChanger{zedcloudURL: modeURL}
 
 	default:
 		return nil, fmt.Errorf("not implemented type: %s", modeType)
@@ -60,6 +66,19 @@ func (ctx *fileChanger) setControllerAndDev(ctrl controller.Cloud, dev *device.C
 		log.Debug("config not modified")
 		return nil
 	}
+	if ctrl.GetVars().DryRun {
+		var newConfig config.EdgeDevConfig
+		if err = proto.Unmarshal(res, &newConfig); err != nil {
+			return fmt.Errorf("unmarshal error: %w", err)
+		}
+		diff, err := controller.ConfigDiff(ctx.oldConfig, &newConfig)
+		if err != nil {
+			return fmt.Errorf("ConfigDiff error: %w", err)
+		}
+		controller.PrintConfigDiff(diff)
+		fmt.Println("dry-run: config not written to file")
+		return nil
+	}
 	if res, err = controller.VersionIncrement(res); err != nil {
 		return fmt.Errorf("VersionIncrement error: %w", err)
 	}
@@ -106,6 +125,7 @@ func (ctx *fileChanger) getControllerAndDevFromConfig(cfg *EdenSetupArgs) (contr
 		return nil, nil, fmt.Errorf("GetConfigBytes error: %w", err)
 	}
 	ctx.oldHash = sha256.Sum256(res)
+	ctx.oldConfig = &deviceConfig
 	return ctrl, dev, nil
 }
 
@@ -156,3 +176,52 @@ func (ctx *adamChanger) setControllerAndDev(ctrl controller.Cloud, dev *device.C
 	}
 	return nil
 }
+
+type zedcloudChanger struct {
+	zedcloudURL string
+}
+
+func (ctx *zedcloudChanger) getController(cfg *EdenSetupArgs) (controller.Cloud, error) {
+	var vars *utils.ConfigVars
+	var err error
+	if cfg != nil {
+		vars, err = InitVarsFromConfig(cfg)
+	} else {
+		vars, err = utils.InitVars()
+	}
+	if err != nil {
+		return nil, fmt.Errorf("init vars error: %w", err)
+	}
+	vars.ControllerType = defaults.DefaultControllerZedcloud
+	if ctx.zedcloudURL != "" {
+		vars.ZedcloudURL = ctx.zedcloudURL
+	}
+	ctrl, err := controller.CloudPrepareWithVars(vars)
+	if err != nil {
+		return nil, fmt.Errorf("CloudPrepareWithVars error: %w", err)
+	}
+	return ctrl, nil
+}
+
+func (ctx *zedcloudChanger) getControllerAndDev() (controller.Cloud, *device.Ctx, error) {
+	return ctx.getControllerAndDevFromConfig(nil)
+}
+
+func (ctx *zedcloudChanger) getControllerAndDevFromConfig(cfg *EdenSetupArgs) (controller.Cloud, *device.Ctx, error) {
+	ctrl, err := ctx.getController(cfg)
+	if err != nil {
+		return nil, nil, fmt.Errorf("getController error: %w", err)
+	}
+	dev, err := ctrl.GetDeviceCurrent()
+	if err != nil {
+		return nil, nil, fmt.Errorf("GetDeviceCurrent error: %w", err)
+	}
+	return ctrl, dev, nil
+}
+
+func (ctx *zedcloudChanger) setControllerAndDev(ctrl controller.Cloud, dev *device.Ctx) error {
+	if err := ctrl.ConfigSync(dev); err != nil {
+		return fmt.Errorf("configSync error: %w", err)
+	}
+	return nil
+}
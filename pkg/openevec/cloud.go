@@ -0,0 +1,258 @@
+package openevec
+
+import (
+	"fmt"
+	"net"
+
+	"github.com/lf-edge/eden/pkg/defaults"
+	"github.com/lf-edge/eden/pkg/linuxkit"
+	"github.com/lf-edge/eden/pkg/models"
+	"github.com/lf-edge/eden/pkg/utils"
+	log "github.com/sirupsen/logrus"
+)
+
+// cloudProvider runs EVE as instance in cloud
+type cloudProvider interface {
+	// diskFormat returns format of EVE image the cloud imports
+	diskFormat() string
+	// provision uploads EVE image, allows access to instance from sourceRanges
+	// and runs instance, it returns public IP of the instance
+	provision(cfg *EdenSetupArgs, sourceRanges []string) (string, error)
+	// teardown removes instance, image and access rules
+	teardown(cfg *EdenSetupArgs) error
+}
+
+func getCloudProvider(provider string) (cloudProvider, error) {
+	switch provider {
+	case defaults.DefaultCloudGCP:
+		return &gcpCloud{}, nil
+	case defaults.DefaultCloudAWS:
+		return &awsCloud{}, nil
+	default:
+		return nil, fmt.Errorf("not supported cloud %q, please use %s or %s",
+			provider, defaults.DefaultCloudGCP, defaults.DefaultCloudAWS)
+	}
+}
+
+// gcpCloud runs EVE in Google Cloud Platform on machine with nested virtualization
+type gcpCloud struct{}
+
+func (c *gcpCloud) diskFormat() string {
+	return "gcp"
+}
+
+func (c *gcpCloud) settings(cfg *EdenSetupArgs) (bucket, machineType, fileName, ruleName string) {
+	bucket, machineType = cfg.Cloud.Bucket, cfg.Cloud.MachineType
+	if bucket == "" {
+		bucket = defaults.DefaultGcpBucketName
+	}
+	if machineType == "" {
+		machineType = defaults.DefaultGcpMachineType
+	}
+	return bucket, machineType, fmt.Sprintf("%s.img.tar.gz", cfg.Cloud.Instance), fmt.Sprintf("%s-rule", cfg.Cloud.Instance)
+}
+
+func (c *gcpCloud) provision(cfg *EdenSetupArgs, sourceRanges []string) (string, error) {
+	gcpClient, err := linuxkit.NewGCPClient(cfg.Gcp.Key, cfg.Cloud.Project)
+	if err != nil {
+		return "", fmt.Errorf("unable to connect to GCP: %w", err)
+	}
+	bucket, machineType, fileName, ruleName := c.settings(cfg)
+	if err := gcpClient.UploadFile(cfg.Eve.ImageFile, fileName, bucket, false); err != nil {
+		return "", fmt.Errorf("error copying to Google Storage: %w", err)
+	}
+	err = gcpClient.CreateImage(cfg.Cloud.Instance, "https://storage.googleapis.com/"+bucket+"/"+fileName, "", cfg.Eve.TPM, true)
+	if err != nil {
+		return "", fmt.Errorf("error creating Google Compute Image: %w", err)
+	}
+	disks := linuxkit.Disks{}
+	for i := 0; i < cfg.Eve.Disks; i++ {
+		disks = append(disks, linuxkit.DiskConfig{Size: cfg.Eve.ImageSizeMB})
+	}
+	if err := gcpClient.CreateInstance(cfg.Cloud.Instance, cfg.Cloud.Instance, cfg.Cloud.Zone, machineType, disks, nil, cfg.Eve.TPM, true); err != nil {
+		return "", fmt.Errorf("CreateInstance: %w", err)
+	}
+	if err := gcpClient.DeleteFirewallAllowRule(ruleName); err != nil {
+		log.Warning(err)
+	}
+	if err := gcpClient.SetFirewallAllowRule(ruleName, defaults.DefaultGcpRulePriority, sourceRanges); err != nil {
+		return "", fmt.Errorf("SetFirewallAllowRule: %w", err)
+	}
+	return gcpClient.GetInstanceNatIP(cfg.Cloud.Instance, cfg.Cloud.Zone)
+}
+
+func (c *gcpCloud) teardown(cfg *EdenSetupArgs) error {
+	gcpClient, err := linuxkit.NewGCPClient(cfg.Gcp.Key, cfg.Cloud.Project)
+	if err != nil {
+		return fmt.Errorf("unable to connect to GCP: %w", err)
+	}
+	bucket, _, fileName, ruleName := c.settings(cfg)
+	if err := gcpClient.DeleteInstance(cfg.Cloud.Instance, cfg.Cloud.Zone, true); err != nil {
+		return fmt.Errorf("DeleteInstance: %w", err)
+	}
+	if err := gcpClient.DeleteFirewallAllowRule(ruleName); err != nil {
+		return fmt.Errorf("DeleteFirewallAllowRule: %w", err)
+	}
+	if err := gcpClient.DeleteImage(cfg.Cloud.Instance); err != nil {
+		return fmt.Errorf("error in delete of Google Compute Image: %w", err)
+	}
+	if err := gcpClient.RemoveFile(fileName, bucket); err != nil {
+		return fmt.Errorf("error in delete from Google Storage: %w", err)
+	}
+	return nil
+}
+
+// awsCloud runs EVE in AWS on bare metal instance
+type awsCloud struct{}
+
+func (c *awsCloud) diskFormat() string {
+	return "raw"
+}
+
+func (c *awsCloud) settings(cfg *EdenSetupArgs) (bucket, instanceType, fileName string) {
+	bucket, instanceType = cfg.Cloud.Bucket, cfg.Cloud.MachineType
+	if bucket == "" {
+		bucket = defaults.DefaultAwsBucketName
+	}
+	if instanceType == "" {
+		instanceType = defaults.DefaultAwsInstanceType
+	}
+	return bucket, instanceType, fmt.Sprintf("%s.raw", cfg.Cloud.Instance)
+}
+
+func (c *awsCloud) provision(cfg *EdenSetupArgs, sourceRanges []string) (string, error) {
+	awsClient, err := linuxkit.NewAWSClient(cfg.Cloud.Region)
+	if err != nil {
+		return "", fmt.Errorf("unable to connect to AWS: %w", err)
+	}
+	bucket, instanceType, fileName := c.settings(cfg)
+	if err := awsClient.UploadFile(cfg.Eve.ImageFile, fileName, bucket); err != nil {
+		return "", fmt.Errorf("error copying to S3: %w", err)
+	}
+	imageID, err := awsClient.CreateImage(cfg.Cloud.Instance, fileName, bucket, cfg.Eve.Arch)
+	if err != nil {
+		return "", fmt.Errorf("error creating AMI: %w", err)
+	}
+	securityGroupID, err := awsClient.SetSecurityGroup(cfg.Cloud.Instance, sourceRanges)
+	if err != nil {
+		return "", fmt.Errorf("SetSecurityGroup: %w", err)
+	}
+	disks := linuxkit.Disks{}
+	for i := 0; i < cfg.Eve.Disks; i++ {
+		disks = append(disks, linuxkit.DiskConfig{Size: cfg.Eve.ImageSizeMB})
+	}
+	if err := awsClient.CreateInstance(cfg.Cloud.Instance, imageID, instanceType, securityGroupID, disks); err != nil {
+		return "", fmt.Errorf("CreateInstance: %w", err)
+	}
+	return awsClient.GetInstancePublicIP(cfg.Cloud.Instance)
+}
+
+func (c *awsCloud) teardown(cfg *EdenSetupArgs) error {
+	awsClient, err := linuxkit.NewAWSClient(cfg.Cloud.Region)
+	if err != nil {
+		return fmt.Errorf("unable to connect to AWS: %w", err)
+	}
+	bucket, _, fileName := c.settings(cfg)
+	// security group cannot be deleted while terminating instance uses it
+	if err := awsClient.DeleteInstance(cfg.Cloud.Instance); err != nil {
+		return fmt.Errorf("DeleteInstance: %w", err)
+	}
+	if err := awsClient.DeleteSecurityGroup(cfg.Cloud.Instance); err != nil {
+		return fmt.Errorf("DeleteSecurityGroup: %w", err)
+	}
+	if err := awsClient.DeleteImage(cfg.Cloud.Instance); err != nil {
+		return fmt.Errorf("error in delete of AMI: %w", err)
+	}
+	if err := awsClient.RemoveFile(fileName, bucket); err != nil {
+		return fmt.Errorf("error in delete from S3: %w", err)
+	}
+	return nil
+}
+
+// checkCloud checks that EVE built by setup can run in the cloud selected in config
+func checkCloud(cfg *EdenSetupArgs) error {
+	provider, err := getCloudProvider(cfg.Cloud.Provider)
+	if err != nil {
+		return err
+	}
+	model, err := models.GetDevModelByName(cfg.Eve.DevModel)
+	if err != nil {
+		return fmt.Errorf("GetDevModelByName: %w", err)
+	}
+	if model.DiskFormat() != provider.diskFormat() {
+		return fmt.Errorf("cloud %s requires EVE image in %s format, but devmodel %s builds %s",
+			cfg.Cloud.Provider, provider.diskFormat(), cfg.Eve.DevModel, model.DiskFormat())
+	}
+	// EVE connects to Adam with IP set into its config
+	ip := net.ParseIP(cfg.Adam.CertsEVEIP)
+	if ip == nil || ip.IsPrivate() || ip.IsLoopback() || ip.IsLinkLocalUnicast() {
+		return fmt.Errorf("adam.eve-ip must be public IP of this host to access Adam from cloud, not %q", cfg.Adam.CertsEVEIP)
+	}
+	return nil
+}
+
+// provisionCloud runs EVE in the cloud selected in config and returns its public IP
+func provisionCloud(cfg *EdenSetupArgs) (string, error) {
+	provider, err := getCloudProvider(cfg.Cloud.Provider)
+	if err != nil {
+		return "", err
+	}
+	// allow eden to access EVE, EVE accesses Internet without restrictions
+	ip, err := provider.provision(cfg, []string{fmt.Sprintf("%s/32", cfg.Adam.CertsEVEIP)})
+	if err != nil {
+		return "", fmt.Errorf("cannot run EVE in %s: %w", cfg.Cloud.Provider, err)
+	}
+	return ip, nil
+}
+
+// setupCloud runs EVE in the cloud and switches config to it as to remote EVE
+func (openEVEC *OpenEVEC) setupCloud(configName string) error {
+	cfg := openEVEC.cfg
+	ip, err := provisionCloud(cfg)
+	if err != nil {
+		return err
+	}
+	log.Infof("EVE is running in %s with IP %s", cfg.Cloud.Provider, ip)
+	log.Infof("Please make ports %d (Adam) and %d (eserver) of %s reachable from Internet",
+		cfg.Adam.Port, cfg.Eden.EServer.Port, cfg.Adam.CertsEVEIP)
+	if err := saveConfigValues(configName,
+		"cloud.provider", cfg.Cloud.Provider, "eve.remote", "true", "eve.remote-addr", ip); err != nil {
+		return fmt.Errorf("cannot save EVE in cloud into config: %w", err)
+	}
+	cfg.Eve.Remote, cfg.Eve.RemoteAddr = true, ip
+	return nil
+}
+
+// cleanCloud removes EVE from the cloud and switches config back to local EVE
+func (openEVEC *OpenEVEC) cleanCloud(configName string) error {
+	cfg := openEVEC.cfg
+	provider, err := getCloudProvider(cfg.Cloud.Provider)
+	if err != nil {
+		return err
+	}
+	if err := provider.teardown(cfg); err != nil {
+		return fmt.Errorf("cannot remove EVE from %s: %w", cfg.Cloud.Provider, err)
+	}
+	log.Infof("EVE removed from %s", cfg.Cloud.Provider)
+	if err := saveConfigValues(configName,
+		"cloud.provider", "", "eve.remote", "false", "eve.remote-addr", defaults.DefaultEVEHost); err != nil {
+		return err
+	}
+	cfg.Cloud.Provider, cfg.Eve.Remote, cfg.Eve.RemoteAddr = "", false, defaults.DefaultEVEHost
+	return nil
+}
+
+// saveConfigValues sets pairs of keys and values in config and updates saved config
+// to pass the next check of config
+func saveConfigValues(configName string, keyValues ...string) error {
+	for i := 0; i+1 < len(keyValues); i += 2 {
+		if err := ConfigSet(configName, keyValues[i], keyValues[i+1]); err != nil {
+			return err
+		}
+	}
+	configSaved := utils.ResolveAbsPath(fmt.Sprintf("%s-%s", configName, defaults.DefaultConfigSaved))
+	if err := utils.CopyFile(utils.GetConfig(configName), configSaved); err != nil {
+		return fmt.Errorf("cannot update saved config: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,162 @@
+package openevec
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/lf-edge/eden/pkg/defaults"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetCloudProvider(t *testing.T) {
+	t.Parallel()
+
+	provider, err := getCloudProvider(defaults.DefaultCloudGCP)
+	if assert.NoError(t, err) {
+		assert.IsType(t, &gcpCloud{}, provider)
+		assert.Equal(t, "gcp", provider.diskFormat())
+	}
+	provider, err = getCloudProvider(defaults.DefaultCloudAWS)
+	if assert.NoError(t, err) {
+		assert.IsType(t, &awsCloud{}, provider)
+		assert.Equal(t, "raw", provider.diskFormat())
+	}
+	_, err = getCloudProvider("")
+	assert.Error(t, err)
+	_, err = getCloudProvider("azure")
+	assert.Error(t, err)
+}
+
+func TestCheckCloud(t *testing.T) {
+	t.Parallel()
+
+	testMatrix := map[string]struct {
+		provider string
+		devModel string
+		eveIP    string
+		valid    bool
+	}{
+		"aws": {
+			provider: defaults.DefaultCloudAWS,
+			devModel: defaults.DefaultGeneralModel,
+			eveIP:    "203.0.113.10",
+			valid:    true,
+		},
+		"gcp": {
+			provider: defaults.DefaultCloudGCP,
+			devModel: defaults.DefaultGCPModel,
+			eveIP:    "203.0.113.10",
+			valid:    true,
+		},
+		"unknown cloud": {
+			provider: "azure",
+			devModel: defaults.DefaultGeneralModel,
+			eveIP:    "203.0.113.10",
+		},
+		"gcp image in aws": {
+			provider: defaults.DefaultCloudAWS,
+			devModel: defaults.DefaultGCPModel,
+			eveIP:    "203.0.113.10",
+		},
+		"qemu image in gcp": {
+			provider: defaults.DefaultCloudGCP,
+			devModel: defaults.DefaultQemuModel,
+			eveIP:    "203.0.113.10",
+		},
+		"private IP": {
+			provider: defaults.DefaultCloudAWS,
+			devModel: defaults.DefaultGeneralModel,
+			eveIP:    "192.168.1.10",
+		},
+		"loopback IP": {
+			provider: defaults.DefaultCloudAWS,
+			devModel: defaults.DefaultGeneralModel,
+			eveIP:    "127.0.0.1",
+		},
+		"link-local IP": {
+			provider: defaults.DefaultCloudAWS,
+			devModel: defaults.DefaultGeneralModel,
+			eveIP:    "169.254.1.1",
+		},
+		"no IP": {
+			provider: defaults.DefaultCloudAWS,
+			devModel: defaults.DefaultGeneralModel,
+		},
+		"hostname": {
+			provider: defaults.DefaultCloudAWS,
+			devModel: defaults.DefaultGeneralModel,
+			eveIP:    "eden.example.com",
+		},
+	}
+	for name, tt := range testMatrix {
+		cfg := &EdenSetupArgs{}
+		cfg.Cloud.Provider = tt.provider
+		cfg.Eve.DevModel = tt.devModel
+		cfg.Adam.CertsEVEIP = tt.eveIP
+		if tt.valid {
+			assert.NoError(t, checkCloud(cfg), name)
+		} else {
+			assert.Error(t, checkCloud(cfg), name)
+		}
+	}
+}
+
+// fakeAWSCLI puts script imitating aws CLI into PATH, it records calls into dir/calls
+// and reports running instance with publicIP
+func fakeAWSCLI(t *testing.T, publicIP string) string {
+	dir := t.TempDir()
+	script := `#!/bin/sh
+echo "$@" >> ` + filepath.Join(dir, "calls") + `
+case "$1 $2" in
+"ec2 import-snapshot") echo '{"ImportTaskId": "import-snap-1"}';;
+"ec2 describe-import-snapshot-tasks") echo '{"ImportSnapshotTasks": [{"SnapshotTaskDetail": {"Status": "completed", "SnapshotId": "snap-1"}}]}';;
+"ec2 register-image") echo '{"ImageId": "ami-1"}';;
+"ec2 create-security-group") echo '{"GroupId": "sg-1"}';;
+"ec2 run-instances") echo '{"Instances": [{"InstanceId": "i-1"}]}';;
+"ec2 describe-instances") echo '{"Reservations": [{"Instances": [{"InstanceId": "i-1", "PublicIpAddress": "` + publicIP + `"}]}]}';;
+esac
+`
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "aws"), []byte(script), 0755))
+	t.Setenv("PATH", dir+string(os.PathListSeparator)+os.Getenv("PATH"))
+	return dir
+}
+
+func TestProvisionCloud(t *testing.T) {
+	cfg := &EdenSetupArgs{}
+	cfg.Cloud.Provider = defaults.DefaultCloudAWS
+	cfg.Cloud.Region = "eu-central-1"
+	cfg.Cloud.Instance = "eden-eve"
+	cfg.Adam.CertsEVEIP = "198.51.100.1"
+	cfg.Eve.ImageFile = "/dist/eve.raw"
+	cfg.Eve.Arch = "amd64"
+
+	dir := fakeAWSCLI(t, "203.0.113.10")
+	ip, err := provisionCloud(cfg)
+	assert.NoError(t, err)
+	assert.Equal(t, "203.0.113.10", ip)
+	calls, err := os.ReadFile(filepath.Join(dir, "calls"))
+	assert.NoError(t, err)
+	// image is uploaded into default bucket and EVE is accessible from eden only
+	assert.Contains(t, string(calls), "s3 cp /dist/eve.raw s3://"+defaults.DefaultAwsBucketName+"/eden-eve.raw")
+	assert.Contains(t, string(calls), "--architecture x86_64")
+	assert.Contains(t, string(calls), "IpRanges=[{CidrIp=198.51.100.1/32}]")
+	assert.Contains(t, string(calls), "--instance-type "+defaults.DefaultAwsInstanceType)
+	for _, call := range strings.Split(strings.TrimSpace(string(calls)), "\n") {
+		assert.True(t, strings.HasSuffix(call, "--region eu-central-1 --output json"), call)
+	}
+
+	// instance without public IP cannot be reached by eden
+	fakeAWSCLI(t, "")
+	_, err = provisionCloud(cfg)
+	assert.ErrorContains(t, err, "cannot run EVE in aws")
+
+	cfg.Cloud.Region = ""
+	_, err = provisionCloud(cfg)
+	assert.ErrorContains(t, err, "region is not specified")
+
+	cfg.Cloud.Provider = "azure"
+	_, err = provisionCloud(cfg)
+	assert.ErrorContains(t, err, "not supported cloud")
+}
@@ -0,0 +1,80 @@
+package openevec
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Components of eden environment which may be selected with 'eden setup --components'
+const (
+	ComponentAdam     = "adam"
+	ComponentRedis    = "redis"
+	ComponentRegistry = "registry"
+	ComponentEServer  = "eserver"
+	ComponentSDN      = "sdn"
+	ComponentEVE      = "eve"
+)
+
+// AllComponents is the list of components in order of their start
+var AllComponents = []string{ComponentRedis, ComponentAdam, ComponentRegistry, ComponentEServer, ComponentSDN, ComponentEVE}
+
+// ParseComponents validates names of components and returns them without duplicates
+// in order of AllComponents
+func ParseComponents(names []string) ([]string, error) {
+	selected := map[string]bool{}
+	for _, name := range names {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" {
+			continue
+		}
+		known := false
+		for _, el := range AllComponents {
+			if el == name {
+				known = true
+				break
+			}
+		}
+		if !known {
+			return nil, fmt.Errorf("unknown component %s, expected one of %s", name, strings.Join(AllComponents, ", "))
+		}
+		selected[name] = true
+	}
+	if len(selected) == 0 {
+		return nil, fmt.Errorf("no components selected")
+	}
+	var result []string
+	for _, el := range AllComponents {
+		if selected[el] {
+			result = append(result, el)
+		}
+	}
+	return result, nil
+}
+
+// enabledComponents returns components selected in config, all components if none selected
+func enabledComponents(cfg *EdenSetupArgs) []string {
+	if len(cfg.Eden.Components) == 0 {
+		return AllComponents
+	}
+	return cfg.Eden.Components
+}
+
+// componentEnabled checks if component is selected in config
+func componentEnabled(cfg *EdenSetupArgs, name string) bool {
+	for _, el := range enabledComponents(cfg) {
+		if el == name {
+			return true
+		}
+	}
+	return false
+}
+
+// selectComponents returns components to deploy: components replace ones from config
+// and addComponents are added to them
+func selectComponents(cfg *EdenSetupArgs, components, addComponents []string) ([]string, error) {
+	current := enabledComponents(cfg)
+	if len(components) > 0 {
+		current = components
+	}
+	return ParseComponents(append(append([]string{}, current...), addComponents...))
+}
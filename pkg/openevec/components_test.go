@@ -0,0 +1,22 @@
+package openevec_test
+
+import (
+	"testing"
+
+	"github.com/lf-edge/eden/pkg/openevec"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseComponents(t *testing.T) {
+	t.Parallel()
+
+	components, err := openevec.ParseComponents([]string{"eserver", " Adam", "redis", "adam", ""})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"redis", "adam", "eserver"}, components)
+
+	_, err = openevec.ParseComponents([]string{"adam", "minio"})
+	assert.ErrorContains(t, err, "unknown component minio")
+
+	_, err = openevec.ParseComponents(nil)
+	assert.Error(t, err)
+}
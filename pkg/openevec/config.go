@@ -7,8 +7,11 @@ import (
 	"path/filepath"
 	"reflect"
 	"strings"
+	"time"
 
 	"github.com/lf-edge/eden/pkg/defaults"
+	"github.com/lf-edge/eden/pkg/logging"
+	"github.com/lf-edge/eden/pkg/secrets"
 	"github.com/lf-edge/eden/pkg/utils"
 	log "github.com/sirupsen/logrus"
 	"github.com/spf13/pflag"
@@ -21,6 +24,23 @@ type EServerConfig struct {
 	Tag    string       `mapstructure:"tag" cobraflag:"eserver-tag"`
 	IP     string       `mapstructure:"ip"`
 	Images ImagesConfig `mapstructure:"images"`
+
+	TLS           bool   `mapstructure:"tls" cobraflag:"eserver-tls"`
+	TLSClientAuth string `mapstructure:"tls-client-auth" cobraflag:"eserver-tls-client-auth"`
+
+	SFTPUser     string `mapstructure:"sftp-user" cobraflag:"eserver-sftp-user"`
+	SFTPPassword string `mapstructure:"sftp-password" cobraflag:"eserver-sftp-password" secret:""`
+}
+
+// LOCConfig is config of Local Operator Console emulated by eden
+type LOCConfig struct {
+	Port int    `mapstructure:"port" cobraflag:"loc-port"`
+	Dist string `mapstructure:"dist" cobraflag:"loc-dist" resolvepath:""`
+}
+
+type EClientConfig struct {
+	Tag   string `mapstructure:"tag"`
+	Image string `mapstructure:"image"`
 }
 
 type ImagesConfig struct {
@@ -29,6 +49,7 @@ type ImagesConfig struct {
 
 type EdenConfig struct {
 	Download     bool   `mapstructure:"download" cobraflag:"download"`
+	NoCache      bool   `mapstructure:"no-cache" cobraflag:"no-cache"`
 	BinDir       string `mapstructure:"bin-dist" cobraflag:"bin-dist" resolvepath:""`
 	CertsDir     string `mapstructure:"certs-dist" cobraflag:"certs-dist" resolvepath:""`
 	Dist         string `mapstructure:"dist"`
@@ -37,8 +58,12 @@ type EdenConfig struct {
 	EdenBin      string `mapstructure:"eden-bin"`
 	TestBin      string `mapstructure:"test-bin"`
 	TestScenario string `mapstructure:"test-scenario"`
+	// Components deployed by eden, all if empty
+	Components []string `mapstructure:"components"`
 
 	EServer EServerConfig `mapstructure:"eserver"`
+	EClient EClientConfig `mapstructure:"eclient"`
+	LOC     LOCConfig     `mapstructure:"loc"`
 
 	Images ImagesConfig `mapstructure:"images"`
 }
@@ -89,9 +114,15 @@ type QemuConfig struct {
 	NetDevSocketPort int `mapstructure:"netdev-socket-port" cobraflag:"qemu-netdev-socket-port"`
 }
 
+type CellularConfig struct {
+	Enabled bool   `mapstructure:"enabled"`
+	APN     string `mapstructure:"apn"`
+}
+
 type EveConfig struct {
 	CustomInstaller CustomInstallerConfig `mapstructure:"custom-installer"`
 	QemuConfig      QemuConfig            `mapstructure:"qemu"`
+	Cellular        CellularConfig        `mapstructure:"cellular"`
 
 	QemuFirmware   []string          `mapstructure:"firmware" cobraflag:"eve-firmware"`
 	QemuConfigPath string            `mapstructure:"config-part" cobraflag:"config-path" resolvepath:""`
@@ -118,6 +149,7 @@ type EveConfig struct {
 	Password       string            `mapstructure:"password" cobraflag:"password"`
 	Serial         string            `mapstructure:"serial" cobraflag:"eve-serial"`
 	Accel          bool              `mapstructure:"accel" cobraflag:"eve-accel"`
+	PCIPassthrough []string          `mapstructure:"pci-passthrough"`
 
 	Pid            string `mapstructure:"pid" cobraflag:"eve-pid" resolvepath:""`
 	Log            string `mapstructure:"log" cobraflag:"eve-log" resolvepath:""`
@@ -131,9 +163,11 @@ type EveConfig struct {
 	AdamLogLevel   string `mapstructure:"adam-log-level"`
 	LogLevel       string `mapstructure:"log-level"`
 	Disks          int    `mapstructure:"disks"`
+	Nodes          int    `mapstructure:"nodes"`
 	BootstrapFile  string `mapstructure:"bootstrap-file" cobraflag:"eve-bootstrap-file"`
 	UsbNetConfFile string `mapstructure:"usbnetconf-file" cobraflag:"eve-usbnetconf-file"`
 	TPM            bool   `mapstructure:"tpm" cobraflag:"tpm"`
+	APIVersion     string `mapstructure:"api-version"`
 }
 
 type RegistryConfig struct {
@@ -151,6 +185,16 @@ type GcpConfig struct {
 	Key string `mapstructure:"key" cobraflag:"key"`
 }
 
+type CloudConfig struct {
+	Provider    string `mapstructure:"provider" cobraflag:"cloud"`
+	Project     string `mapstructure:"project"`
+	Zone        string `mapstructure:"zone"`
+	Region      string `mapstructure:"region"`
+	MachineType string `mapstructure:"machine-type"`
+	Instance    string `mapstructure:"instance"`
+	Bucket      string `mapstructure:"bucket"`
+}
+
 type SdnConfig struct {
 	ImageFile      string `mapstructure:"image-file" cobraflag:"sdn-image-file" resolvepath:""`
 	SourceDir      string `mapstructure:"source-dir" cobraflag:"sdn-source-dir" resolvepath:""`
@@ -159,12 +203,26 @@ type SdnConfig struct {
 	ConfigDir      string `mapstructure:"config-dir" cobraflag:"sdn-config-dir" resolvepath:""`
 	LinuxkitBin    string `mapstructure:"linuxkit-bin" cobraflag:"sdn-linuxkit-bin" resolvepath:""`
 	NetModelFile   string `mapstructure:"network-model" cobraflag:"sdn-network-model" resolvepath:""`
+	NetworkType    string `mapstructure:"network-type" cobraflag:"sdn-network-type"`
 	ConsoleLogFile string `mapstructure:"console-log" cobraflag:"sdn-console-log" resolvepath:""`
 	Disable        bool   `mapstructure:"disable" cobraflag:"sdn-disable"`
 	TelnetPort     int    `mapstructure:"telnet-port" cobraflag:"sdn-telnet-port"`
 	MgmtPort       int    `mapstructure:"mgmt-port" cobraflag:"sdn-mgmt-port"`
 	PidFile        string `mapstructure:"pid" cobraflag:"sdn-pid" resolvepath:""`
 	SSHPort        int    `mapstructure:"ssh-port" cobraflag:"sdn-ssh-port"`
+	Proxy          string `mapstructure:"proxy"`
+}
+
+type ZedcloudConfig struct {
+	URL     string `mapstructure:"url" cobraflag:"zedcloud-url"`
+	Token   string `mapstructure:"token" cobraflag:"zedcloud-token" secret:""`
+	Project string `mapstructure:"project" cobraflag:"zedcloud-project"`
+	Device  string `mapstructure:"device" cobraflag:"zedcloud-device"`
+}
+
+type ControllerConfig struct {
+	Type     string         `mapstructure:"type" cobraflag:"controller-type"`
+	Zedcloud ZedcloudConfig `mapstructure:"zedcloud"`
 }
 
 type EdenSetupArgs struct {
@@ -175,10 +233,18 @@ type EdenSetupArgs struct {
 	Registry RegistryConfig `mapstructure:"registry"`
 	Packet   PacketConfig   `mapstructure:"packet"`
 	Gcp      GcpConfig      `mapstructure:"gcp"`
+	Cloud    CloudConfig    `mapstructure:"cloud"`
 	Sdn      SdnConfig      `mapstructure:"sdn"`
 
+	Controller ControllerConfig `mapstructure:"controller"`
+
 	ConfigFile string
 	ConfigName string
+	DryRun     bool `cobraflag:"dry-run"`
+	ForcePush  bool `cobraflag:"force-push"`
+
+	// Node is EVE node of multi-node setup selected with --node, zero if commands apply to all nodes
+	Node int
 }
 
 // PodConfig store configuration for Pod deployment
@@ -194,6 +260,8 @@ type PodConfig struct {
 	Disks             []string
 	Profiles          []string
 	AppAdapters       []string
+	PCIAdapters       []string
+	IOMMUGroups       []string
 	NoHyper           bool
 	VncDisplay        uint32
 	VncPassword       string
@@ -206,12 +274,31 @@ type PodConfig struct {
 	PinCpus           bool
 	ImageFormat       string
 	SftpLoad          bool
+	S3Load            bool
 	DirectLoad        bool
 	OpenStackMetadata bool
 	DatastoreOverride string
 	ACLOnlyHost       bool
 }
 
+// LoadgenConfig defines generators of traffic deployed by eden loadgen
+type LoadgenConfig struct {
+	Name     string
+	Network  string
+	Port     int
+	Count    int
+	Duration time.Duration
+}
+
+// BenchmarkConfig defines repetitions of deployment of app measured by eden benchmark
+type BenchmarkConfig struct {
+	Name        string
+	Repetitions int
+	Timeout     time.Duration
+	Output      string
+	Format      string
+}
+
 func Merge(dst, src reflect.Value, flags *pflag.FlagSet) {
 	for i := 0; i < dst.NumField(); i++ {
 		if dst.Field(i).Kind() == reflect.Struct {
@@ -241,30 +328,22 @@ func FromViper(configName, verbosity string) (*EdenSetupArgs, error) {
 	}
 	cfg.ConfigFile = utils.GetConfig(configName)
 
-	if verbosity == "debug" {
-		fmt.Println("configName: ", configName)
-		fmt.Println("configFile: ", cfg.ConfigFile)
+	if err := SetUpLogs(verbosity); err != nil {
+		return nil, err
 	}
+	log.WithFields(log.Fields{"configName": configName, "configFile": cfg.ConfigFile}).Debug("loading config")
 
 	cfg, err = LoadConfig(cfg.ConfigFile)
 	if err != nil {
 		return nil, err
 	}
-
-	if err := SetUpLogs(verbosity); err != nil {
-		return nil, err
-	}
 	return cfg, nil
 }
 
+// SetUpLogs sets level of logs, format and verbosity of components are taken
+// from environment (see logging.Setup)
 func SetUpLogs(level string) error {
-	log.SetOutput(os.Stdout)
-	lvl, err := log.ParseLevel(level)
-	if err != nil {
-		return err
-	}
-	log.SetLevel(lvl)
-	return nil
+	return logging.Setup(level, "", "")
 }
 
 func LoadConfig(configFile string) (*EdenSetupArgs, error) {
@@ -286,6 +365,14 @@ func LoadConfig(configFile string) (*EdenSetupArgs, error) {
 
 	resolvePath(reflect.ValueOf(cfg).Elem())
 
+	if err = resolveSecrets(reflect.ValueOf(cfg).Elem(), secrets.Default()); err != nil {
+		return nil, err
+	}
+
+	if !componentEnabled(cfg, ComponentSDN) {
+		cfg.Sdn.Disable = true
+	}
+
 	if configFile == "" {
 		configFile, _ = utils.DefaultConfigPath()
 	}
@@ -315,6 +402,51 @@ func resolvePath(v reflect.Value) {
 	}
 }
 
+// resolveSecrets replaces references to secrets in fields marked with secret tag by their values
+func resolveSecrets(v reflect.Value, store *secrets.Store) error {
+	for i := 0; i < v.NumField(); i++ {
+		f := v.Field(i)
+		if _, ok := v.Type().Field(i).Tag.Lookup("secret"); ok && f.CanSet() && f.Kind() == reflect.String {
+			value, err := store.Resolve(f.String())
+			if err != nil {
+				return fmt.Errorf("cannot resolve %s: %w", v.Type().Field(i).Name, err)
+			}
+			f.SetString(value)
+		}
+		if f.Kind() == reflect.Struct {
+			if err := resolveSecrets(f, store); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// SecretKeys returns keys of config which may keep references to secrets
+func SecretKeys() []string {
+	return secretKeys(reflect.TypeOf(EdenSetupArgs{}), "")
+}
+
+func secretKeys(t reflect.Type, prefix string) (keys []string) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		key := field.Tag.Get("mapstructure")
+		if key == "" {
+			continue
+		}
+		if prefix != "" {
+			key = prefix + "." + key
+		}
+		if _, ok := field.Tag.Lookup("secret"); ok {
+			keys = append(keys, key)
+		}
+		if field.Type.Kind() == reflect.Struct {
+			keys = append(keys, secretKeys(field.Type, key)...)
+		}
+	}
+	return keys
+}
+
 func ConfigCheck(configName string) error {
 	configFile := utils.GetConfig(configName)
 	configSaved := utils.ResolveAbsPath(fmt.Sprintf("%s-%s", configName, defaults.DefaultConfigSaved))
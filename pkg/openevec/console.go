@@ -0,0 +1,97 @@
+package openevec
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"github.com/lf-edge/eden/pkg/defaults"
+	"github.com/lf-edge/eden/pkg/eden"
+	"github.com/lf-edge/eden/pkg/utils"
+)
+
+// consoleCaptured checks if console of EVE is captured into files, it is done for EVE in local QEMU only
+func consoleCaptured(cfg *EdenSetupArgs) bool {
+	if cfg.Eve.Remote {
+		return false
+	}
+	_, ok := eden.GetVMDriver(cfg.Eve.DevModel)
+	return !ok
+}
+
+// consoleLogOffset returns size of console log of QEMU, the log is appended on every start of QEMU
+func consoleLogOffset(cfg *EdenSetupArgs) int64 {
+	info, err := os.Stat(cfg.Eve.Log)
+	if err != nil {
+		return 0
+	}
+	return info.Size()
+}
+
+// startConsoleCapture starts 'eden eve console-capture' in background to capture console of EVE
+// written by QEMU starting from offset. The capture ends by itself when QEMU exits, so the last
+// lines printed by EVE before stop are captured too.
+func startConsoleCapture(cfg *EdenSetupArgs, offset int64) error {
+	command, err := os.Executable()
+	if err != nil {
+		return fmt.Errorf("cannot obtain executable path: %w", err)
+	}
+	dir := eden.ConsoleCaptureDir(cfg.Eve.Log)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	pidFile := eden.ConsoleCapturePidFile(cfg.Eve.Log)
+	// capture of previous run of QEMU cannot see that it is restarted
+	if status, _ := utils.StatusCommandWithPid(pidFile); status != "process doesn't exist" {
+		_ = utils.StopCommandWithPid(pidFile)
+	}
+	return utils.RunCommandNohup(command, filepath.Join(dir, "capture.log"), pidFile,
+		"eve", "console-capture",
+		"--source", cfg.Eve.Log,
+		"--offset", strconv.FormatInt(offset, 10),
+		"--dir", dir,
+		"--qemu-pid", cfg.Eve.Pid)
+}
+
+// ConsoleCapture captures console of EVE written by QEMU into source until QEMU exits
+func ConsoleCapture(source, dir, qemuPidFile string, offset int64) error {
+	capture := &eden.ConsoleCapture{
+		Source:      source,
+		Offset:      offset,
+		Dir:         dir,
+		MaxSize:     defaults.DefaultConsoleMaxSize,
+		MaxFiles:    defaults.DefaultConsoleMaxFiles,
+		QemuPidFile: qemuPidFile,
+	}
+	return capture.Run()
+}
+
+// ParseConsoleSince parses --since of 'eden eve console' as duration back from now or as RFC3339 time
+func ParseConsoleSince(since string) (time.Time, error) {
+	if since == "" {
+		return time.Time{}, nil
+	}
+	if d, err := time.ParseDuration(since); err == nil {
+		return time.Now().Add(-d), nil
+	}
+	t, err := time.Parse(time.RFC3339, since)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("since must be duration like 10m or time like 2006-01-02T15:04:05Z: %s", since)
+	}
+	return t, nil
+}
+
+// ConsoleEveCaptured prints console of EVE captured since the time, with follow it prints new lines
+// until interrupted
+func (openEVEC *OpenEVEC) ConsoleEveCaptured(since time.Time, follow bool) error {
+	cfg := openEVEC.cfg
+	if !consoleCaptured(cfg) {
+		return fmt.Errorf("console of EVE is captured only for local EVE in QEMU")
+	}
+	return eden.ReadConsole(eden.ConsoleCaptureDir(cfg.Eve.Log), since, follow, func(line string) error {
+		_, err := fmt.Println(line)
+		return err
+	})
+}
@@ -2,7 +2,8 @@ package openevec
 
 // OpenEVEC base type for all actions
 type OpenEVEC struct {
-	cfg *EdenSetupArgs
+	cfg    *EdenSetupArgs
+	resume *resumeState
 }
 
 // CreateOpenEVEC returns OpenEVEC instance
@@ -0,0 +1,61 @@
+package openevec
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/lf-edge/eden/pkg/controller/einfo"
+	"github.com/lf-edge/eden/pkg/controller/elog"
+	"github.com/lf-edge/eden/pkg/controller/emetric"
+	"github.com/lf-edge/eden/pkg/dashboard"
+	"github.com/lf-edge/eden/pkg/eve"
+	"github.com/lf-edge/eden/pkg/testhistory"
+	log "github.com/sirupsen/logrus"
+)
+
+// dashboardLogs is count of the last logs of EVE loaded into dashboard on start
+const dashboardLogs = 100
+
+// EdenDashboard serves web UI with state of the device, which is loaded from the controller
+// on start and updated with new info, metric and log messages
+func (openEVEC *OpenEVEC) EdenDashboard(listen, historyDB string, historyRuns int) error {
+	changer := &adamChanger{}
+	ctrl, dev, err := changer.getControllerAndDevFromConfig(openEVEC.cfg)
+	if err != nil {
+		return fmt.Errorf("getControllerAndDevFromConfig: %w", err)
+	}
+	var history *testhistory.DB
+	if history, err = openTestHistory(historyDB); err != nil {
+		log.Warnf("results of tests are not shown: %s", err)
+	} else {
+		defer history.Close()
+	}
+	devUUID := dev.GetID()
+	server := dashboard.New(devUUID.String(), eve.Init(ctrl, dev), history, historyRuns)
+	if err = ctrl.InfoLastCallback(devUUID, nil, server.InfoCallback()); err != nil {
+		return fmt.Errorf("fail in get InfoLastCallback: %w", err)
+	}
+	if err = ctrl.MetricLastCallback(devUUID, nil, server.MetricCallback()); err != nil {
+		return fmt.Errorf("fail in get MetricLastCallback: %w", err)
+	}
+	if err = ctrl.LogChecker(devUUID, nil, server.LogCallback(), elog.LogTail(dashboardLogs), 0); err != nil {
+		return fmt.Errorf("LogChecker: %w", err)
+	}
+	go func() {
+		if err := ctrl.InfoChecker(devUUID, nil, server.InfoCallback(), einfo.InfoNew, 0); err != nil {
+			log.Errorf("InfoChecker: %s", err)
+		}
+	}()
+	go func() {
+		if err := ctrl.MetricChecker(devUUID, nil, server.MetricCallback(), emetric.MetricNew, 0); err != nil {
+			log.Errorf("MetricChecker: %s", err)
+		}
+	}()
+	go func() {
+		if err := ctrl.LogChecker(devUUID, nil, server.LogCallback(), elog.LogNew, 0); err != nil {
+			log.Errorf("LogChecker: %s", err)
+		}
+	}()
+	log.Infof("Dashboard is available on http://%s", listen)
+	return http.ListenAndServe(listen, server.Handler())
+}
@@ -28,6 +28,11 @@ func GetDefaultConfig() *EdenSetupArgs {
 				Force: false,
 				Tag:   defaults.DefaultEServerTag,
 			},
+
+			LOC: LOCConfig{
+				Port: defaults.DefaultLOCPort,
+				Dist: filepath.Join(currentPath, defaults.DefaultDist, defaults.DefaultLOCDist),
+			},
 		},
 
 		Adam: AdamConfig{
@@ -66,6 +71,7 @@ func GetDefaultConfig() *EdenSetupArgs {
 			Log:            filepath.Join(currentPath, defaults.DefaultDist),
 			TelnetPort:     defaults.DefaultTelnetPort,
 			TPM:            defaults.DefaultTPMEnabled,
+			Nodes:          defaults.DefaultEveNodes,
 		},
 
 		Redis: RedisConfig{
@@ -0,0 +1,85 @@
+package openevec
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"sync"
+
+	"github.com/lf-edge/eden/pkg/defaults"
+	"github.com/lf-edge/eden/pkg/utils"
+	log "github.com/sirupsen/logrus"
+)
+
+// artifactCache returns cache of downloaded and built artifacts shared between
+// contexts or nil if cache is disabled
+func artifactCache(cfg *EdenSetupArgs) *utils.ArtifactCache {
+	if cfg.Eden.NoCache {
+		return nil
+	}
+	edenDir, err := utils.DefaultEdenDir()
+	if err != nil {
+		log.Warnf("cache of artifacts is disabled: %s", err)
+		return nil
+	}
+	return utils.NewArtifactCache(filepath.Join(edenDir, defaults.DefaultCacheDist))
+}
+
+// eveImageID pulls image of EVE and returns its ID to use in keys of cache
+func eveImageID(eveDesc utils.EVEDescription) (string, error) {
+	image, err := eveDesc.Image()
+	if err != nil {
+		return "", err
+	}
+	if err := utils.PullImage(image); err != nil {
+		return "", fmt.Errorf("ImagePull (%s): %w", image, err)
+	}
+	return utils.ImageID(image)
+}
+
+// downloadUEFI extracts UEFI firmware from image of EVE into dir through cache
+func downloadUEFI(cache *utils.ArtifactCache, eveDesc utils.EVEDescription, dir string) error {
+	id, err := eveImageID(eveDesc)
+	if err != nil {
+		return err
+	}
+	return cache.Fetch(utils.CacheKey("uefi", id), dir, func(out string) error {
+		return utils.DownloadUEFI(eveDesc, out)
+	})
+}
+
+// downloadEveLive generates live image of EVE into imageFile through cache,
+// the image contains config partition, so hash of config directory is a part of key
+func downloadEveLive(cache *utils.ArtifactCache, eveDesc utils.EVEDescription, imageFile string) error {
+	id, err := eveImageID(eveDesc)
+	if err != nil {
+		return err
+	}
+	configHash := ""
+	if eveDesc.ConfigPath != "" {
+		if configHash, err = utils.SHA256SUMAll(eveDesc.ConfigPath); err != nil {
+			return fmt.Errorf("cannot calculate hash of %s: %w", eveDesc.ConfigPath, err)
+		}
+	}
+	key := utils.CacheKey("live", id, eveDesc.Format, eveDesc.Platform,
+		strconv.Itoa(eveDesc.ImageSizeMB), configHash, filepath.Base(imageFile))
+	return cache.Fetch(key, filepath.Dir(imageFile), func(out string) error {
+		return utils.DownloadEveLive(eveDesc, filepath.Join(out, filepath.Base(imageFile)))
+	})
+}
+
+// runParallel runs tasks concurrently and returns all their errors
+func runParallel(tasks ...func() error) error {
+	var wg sync.WaitGroup
+	errs := make([]error, len(tasks))
+	for i, task := range tasks {
+		wg.Add(1)
+		go func(i int, task func() error) {
+			defer wg.Done()
+			errs[i] = task()
+		}(i, task)
+	}
+	wg.Wait()
+	return errors.Join(errs...)
+}
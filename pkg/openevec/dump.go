@@ -0,0 +1,215 @@
+package openevec
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/lf-edge/eden/pkg/controller"
+	"github.com/lf-edge/eden/pkg/controller/types"
+	"github.com/lf-edge/eden/pkg/defaults"
+	"github.com/lf-edge/eden/pkg/eden"
+	"github.com/lf-edge/eden/pkg/utils"
+	"github.com/lf-edge/eve-api/go/config"
+	uuid "github.com/satori/go.uuid"
+	log "github.com/sirupsen/logrus"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
+
+// commands to run in QEMU monitor of EVE for dump
+var dumpQemuMonitorCommands = []string{"info status", "info network", "info block", "info history"}
+
+type dumpCollector struct {
+	outDir string
+	errors []string
+}
+
+func (d *dumpCollector) write(name string, data []byte) {
+	if err := os.WriteFile(filepath.Join(d.outDir, name), data, 0644); err != nil {
+		d.fail(name, err)
+	}
+}
+
+func (d *dumpCollector) copyFile(name, src string) {
+	if src == "" {
+		return
+	}
+	if err := utils.CopyFile(src, filepath.Join(d.outDir, name)); err != nil {
+		d.fail(name, err)
+	}
+}
+
+func (d *dumpCollector) fail(name string, err error) {
+	log.Warnf("dump of %s failed: %s", name, err)
+	d.errors = append(d.errors, fmt.Sprintf("%s: %s", name, err))
+}
+
+func (d *dumpCollector) containers() {
+	for _, name := range []string{
+		defaults.DefaultAdamContainerName,
+		defaults.DefaultRedisContainerName,
+		defaults.DefaultEServerContainerName,
+		defaults.DefaultRegistryContainerName,
+	} {
+		state, err := utils.StateContainer(name)
+		if err != nil {
+			d.fail(name, err)
+			continue
+		}
+		if state == "" {
+			state = fmt.Sprintf("container with name %s not found", name)
+		}
+		d.write(fmt.Sprintf("%s.state", name), []byte(state+"\n"))
+		var buf bytes.Buffer
+		if err := utils.ContainerLogs(name, &buf); err != nil {
+			d.fail(name, err)
+			continue
+		}
+		d.write(fmt.Sprintf("%s.log", name), buf.Bytes())
+	}
+}
+
+// dumpCloud returns controller to dump its state
+type dumpCloud func() (controller.Cloud, error)
+
+func (d *dumpCollector) adam(cloud dumpCloud) {
+	ctrl, err := cloud()
+	if err != nil {
+		d.fail("adam", err)
+		return
+	}
+	if globalOptions, err := ctrl.GetGlobalOptions(); err != nil {
+		d.fail("adam global options", err)
+	} else if data, err := json.MarshalIndent(globalOptions, "", "    "); err == nil {
+		d.write("adam-global-options.json", data)
+	}
+	devices, err := ctrl.DeviceList(types.AllDevicesFilter)
+	if err != nil {
+		d.fail("adam devices", err)
+		return
+	}
+	d.write("adam-devices.txt", []byte(strings.Join(devices, "\n")+"\n"))
+	for _, el := range devices {
+		devUUID, err := uuid.FromString(el)
+		if err != nil {
+			d.fail(el, err)
+			continue
+		}
+		if options, err := ctrl.GetDeviceOptions(devUUID); err != nil {
+			d.fail(fmt.Sprintf("adam options of %s", el), err)
+		} else if data, err := json.MarshalIndent(options, "", "    "); err == nil {
+			d.write(fmt.Sprintf("adam-device-%s-options.json", el), data)
+		}
+		devConfig, err := ctrl.ConfigGet(devUUID)
+		if err != nil {
+			d.fail(fmt.Sprintf("adam config of %s", el), err)
+			continue
+		}
+		var edgeDevConfig config.EdgeDevConfig
+		if err := proto.Unmarshal([]byte(devConfig), &edgeDevConfig); err != nil {
+			d.fail(fmt.Sprintf("adam config of %s", el), err)
+			continue
+		}
+		data, err := protojson.MarshalOptions{Multiline: true}.Marshal(&edgeDevConfig)
+		if err != nil {
+			d.fail(fmt.Sprintf("adam config of %s", el), err)
+			continue
+		}
+		d.write(fmt.Sprintf("adam-device-%s-config.json", el), data)
+	}
+}
+
+func (d *dumpCollector) eserver(cfg *EdenSetupArgs) {
+	var files []string
+	root := cfg.Eden.Images.EServerImageDist
+	err := filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if !info.IsDir() {
+			rel, _ := filepath.Rel(root, path)
+			files = append(files, fmt.Sprintf("%s %d", rel, info.Size()))
+		}
+		return nil
+	})
+	if err != nil {
+		d.fail("eserver files", err)
+		return
+	}
+	d.write("eserver-files.txt", []byte(strings.Join(files, "\n")+"\n"))
+}
+
+func (d *dumpCollector) sdn(openEVEC *OpenEVEC) {
+	cfg := openEVEC.cfg
+	if !isSdnEnabled(cfg.Sdn.Disable, cfg.Eve.Remote, cfg.Eve.DevModel) {
+		return
+	}
+	d.copyFile("sdn-console.log", cfg.Sdn.ConsoleLogFile)
+	if sdnLogs, err := openEVEC.SdnLogs(); err != nil {
+		d.fail("sdn logs", err)
+	} else {
+		d.write("sdn.log", []byte(sdnLogs))
+	}
+	if netModel, err := openEVEC.SdnNetModelGet(); err != nil {
+		d.fail("sdn network model", err)
+	} else {
+		d.write("sdn-network-model.json", []byte(netModel))
+	}
+}
+
+func (d *dumpCollector) qemu(cfg *EdenSetupArgs) {
+	if cfg.Eve.Remote || cfg.Eve.DevModel != defaults.DefaultQemuModel {
+		return
+	}
+	d.copyFile("eve-console.log", cfg.Eve.Log)
+	// QMP events are logged next to pid file of EVE
+	qmpLogs, _ := filepath.Glob(filepath.Join(filepath.Dir(cfg.Eve.Pid), "*-qmp.log"))
+	for _, el := range qmpLogs {
+		d.copyFile(filepath.Base(el), el)
+	}
+	var buf bytes.Buffer
+	for _, cmd := range dumpQemuMonitorCommands {
+		out, err := eden.QemuMonitorCommand(cfg.Eve.QemuConfig.MonitorPort, cmd)
+		if err != nil {
+			d.fail(fmt.Sprintf("qemu monitor '%s'", cmd), err)
+			continue
+		}
+		fmt.Fprintf(&buf, "(qemu) %s\n%s\n", cmd, out)
+	}
+	if buf.Len() > 0 {
+		d.write("qemu-monitor.txt", buf.Bytes())
+	}
+}
+
+// EdenDump collects logs and state of eden infrastructure components into outDir
+func (openEVEC *OpenEVEC) EdenDump(outDir string) error {
+	return openEVEC.edenDump(outDir, func() (controller.Cloud, error) {
+		vars, err := InitVarsFromConfig(openEVEC.cfg)
+		if err != nil {
+			return nil, err
+		}
+		return controller.CloudPrepareWithVars(vars)
+	})
+}
+
+// edenDump collects dump with state of controller returned by cloud
+func (openEVEC *OpenEVEC) edenDump(outDir string, cloud dumpCloud) error {
+	if err := os.MkdirAll(outDir, 0755); err != nil {
+		return fmt.Errorf("cannot create directory %s: %w", outDir, err)
+	}
+	d := &dumpCollector{outDir: outDir}
+	d.containers()
+	d.adam(cloud)
+	d.eserver(openEVEC.cfg)
+	d.sdn(openEVEC)
+	d.qemu(openEVEC.cfg)
+	if len(d.errors) > 0 {
+		d.write("errors.txt", []byte(strings.Join(d.errors, "\n")+"\n"))
+	}
+	fmt.Printf("Dump saved into %s (%d errors)\n", outDir, len(d.errors))
+	return nil
+}
@@ -0,0 +1,101 @@
+package openevec
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/lf-edge/eden/pkg/controller"
+	"github.com/lf-edge/eden/pkg/controller/types"
+	"github.com/lf-edge/eden/pkg/utils"
+	"github.com/lf-edge/eve-api/go/config"
+	uuid "github.com/satori/go.uuid"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
+
+// dumpController reports devices with configs, config of device without it cannot be read
+type dumpController struct {
+	controller.Controller
+	devices []string
+	configs map[uuid.UUID]*config.EdgeDevConfig
+}
+
+func (ctrl *dumpController) DeviceList(types.DeviceStateFilter) ([]string, error) {
+	return ctrl.devices, nil
+}
+
+func (ctrl *dumpController) GetGlobalOptions() (*types.GlobalOptions, error) {
+	return &types.GlobalOptions{EnforceTemplateAttestation: true}, nil
+}
+
+func (ctrl *dumpController) GetDeviceOptions(devUUID uuid.UUID) (*types.DeviceOptions, error) {
+	return &types.DeviceOptions{Nonce: "nonce-" + devUUID.String()}, nil
+}
+
+func (ctrl *dumpController) ConfigGet(devUUID uuid.UUID) (string, error) {
+	devConfig, ok := ctrl.configs[devUUID]
+	if !ok {
+		return "", errors.New("config not found")
+	}
+	data, err := proto.Marshal(devConfig)
+	return string(data), err
+}
+
+func readDumpFile(t *testing.T, dir, name string) string {
+	data, err := os.ReadFile(filepath.Join(dir, name))
+	assert.NoError(t, err, name)
+	return string(data)
+}
+
+func TestEdenDump(t *testing.T) {
+	t.Parallel()
+
+	dev1, dev2 := uuid.Must(uuid.NewV4()), uuid.Must(uuid.NewV4())
+	ctrl := &dumpController{
+		devices: []string{dev1.String(), dev2.String(), "not-a-uuid"},
+		configs: map[uuid.UUID]*config.EdgeDevConfig{
+			dev1: {Id: &config.UUIDandVersion{Uuid: dev1.String(), Version: "4"}},
+		},
+	}
+	cloud := &controller.CloudCtx{Controller: ctrl}
+	cloud.SetVars(&utils.ConfigVars{})
+
+	images := t.TempDir()
+	assert.NoError(t, os.MkdirAll(filepath.Join(images, "apps"), 0755))
+	assert.NoError(t, os.WriteFile(filepath.Join(images, "apps", "app.qcow2"), []byte("image"), 0644))
+
+	cfg := &EdenSetupArgs{}
+	cfg.Eden.Images.EServerImageDist = images
+	// neither SDN nor QEMU with EVE is dumped for remote EVE
+	cfg.Eve.Remote = true
+	cfg.Sdn.Disable = true
+	out := filepath.Join(t.TempDir(), "dump")
+	openEVEC := CreateOpenEVEC(cfg)
+	assert.NoError(t, openEVEC.edenDump(out, func() (controller.Cloud, error) { return cloud, nil }))
+
+	assert.JSONEq(t, `{"enforceTemplateAttestation": true, "PCRTemplates": null}`, readDumpFile(t, out, "adam-global-options.json"))
+	assert.Equal(t, dev1.String()+"\n"+dev2.String()+"\nnot-a-uuid\n", readDumpFile(t, out, "adam-devices.txt"))
+	assert.Contains(t, readDumpFile(t, out, "adam-device-"+dev2.String()+"-options.json"), "nonce-"+dev2.String())
+	var devConfig config.EdgeDevConfig
+	assert.NoError(t, protojson.Unmarshal([]byte(readDumpFile(t, out, "adam-device-"+dev1.String()+"-config.json")), &devConfig))
+	assert.Equal(t, dev1.String(), devConfig.GetId().GetUuid())
+	assert.Equal(t, "4", devConfig.GetId().GetVersion())
+	assert.NoFileExists(t, filepath.Join(out, "adam-device-"+dev2.String()+"-config.json"))
+	assert.Equal(t, filepath.Join("apps", "app.qcow2")+" 5\n", readDumpFile(t, out, "eserver-files.txt"))
+	assert.NoFileExists(t, filepath.Join(out, "qemu-monitor.txt"))
+
+	// failures are collected, the rest of dump is saved
+	dumpErrors := readDumpFile(t, out, "errors.txt")
+	assert.Contains(t, dumpErrors, "adam config of "+dev2.String()+": config not found")
+	assert.Contains(t, dumpErrors, "not-a-uuid: ")
+
+	failed := filepath.Join(t.TempDir(), "dump")
+	assert.NoError(t, openEVEC.edenDump(failed, func() (controller.Cloud, error) {
+		return nil, errors.New("adam is not running")
+	}))
+	assert.Contains(t, readDumpFile(t, failed, "errors.txt"), "adam: adam is not running")
+	assert.FileExists(t, filepath.Join(failed, "eserver-files.txt"))
+}
@@ -8,6 +8,7 @@ import (
 	"html/template"
 	"io"
 	"io/ioutil"
+	"net"
 	"os"
 	"os/exec"
 	"path"
@@ -23,6 +24,7 @@ import (
 	"github.com/lf-edge/eden/pkg/controller/types"
 	"github.com/lf-edge/eden/pkg/defaults"
 	"github.com/lf-edge/eden/pkg/eden"
+	"github.com/lf-edge/eden/pkg/eve"
 	"github.com/lf-edge/eden/pkg/models"
 	"github.com/lf-edge/eden/pkg/utils"
 	"github.com/lf-edge/eve-api/go/flowlog"
@@ -35,10 +37,29 @@ import (
 	"golang.org/x/term"
 )
 
-func (openEVEC *OpenEVEC) SetupEden(configName, configDir, softSerial, zedControlURL, ipxeOverride string, grubOptions []string, netboot, installer bool) error {
+func (openEVEC *OpenEVEC) SetupEden(configName, configDir, softSerial, zedControlURL, ipxeOverride string, grubOptions []string, netboot, installer bool, components, addComponents []string) error {
+
+	if len(components) > 0 || len(addComponents) > 0 {
+		selected, err := selectComponents(openEVEC.cfg, components, addComponents)
+		if err != nil {
+			return err
+		}
+		if err := saveConfigValues(configName, "eden.components", strings.Join(selected, ",")); err != nil {
+			return fmt.Errorf("cannot save components: %w", err)
+		}
+		openEVEC.cfg.Eden.Components = selected
+		openEVEC.cfg.Sdn.Disable = openEVEC.cfg.Sdn.Disable || !componentEnabled(openEVEC.cfg, ComponentSDN)
+		log.Infof("components of eden: %s", strings.Join(selected, ", "))
+	}
 
 	cfg := *openEVEC.cfg
 
+	if cfg.Cloud.Provider != "" && componentEnabled(&cfg, ComponentEVE) {
+		if err := checkCloud(&cfg); err != nil {
+			return err
+		}
+	}
+
 	if netboot && installer {
 		return fmt.Errorf("please use netboot or installer flag, not both")
 	}
@@ -47,33 +68,91 @@ func (openEVEC *OpenEVEC) SetupEden(configName, configDir, softSerial, zedContro
 			return fmt.Errorf("cannot use netboot for devmodel %s, please use general instead", cfg.Eve.DevModel)
 		}
 	}
-	if cfg.Eve.DevModel == defaults.DefaultQemuModel {
-		if err := setupQemuConfig(cfg); err != nil {
+	if componentEnabled(&cfg, ComponentEVE) {
+		if err := checkArch(&cfg); err != nil {
+			return err
+		}
+		if err := checkNodes(&cfg); err != nil {
 			return err
 		}
 	}
+	// every node of EVE has its own certificates and image
+	nodes := NodeConfigs(&cfg)
 
-	if cfg.Eve.CustomInstaller.Path == "" {
-		if err := setupConfigDir(cfg, configDir, softSerial, zedControlURL, grubOptions); err != nil {
-			return fmt.Errorf("cannot setup ConfigDir: %w", err)
+	if cfg.Eve.DevModel == defaults.DefaultQemuModel && componentEnabled(&cfg, ComponentEVE) {
+		for _, node := range nodes {
+			if err := setupQemuConfig(*node); err != nil {
+				return err
+			}
 		}
 	}
 
-	if err := setupEve(netboot, installer, softSerial, ipxeOverride, cfg); err != nil {
-		return fmt.Errorf("cannot setup EVE: %s", err)
+	if cfg.Eve.CustomInstaller.Path == "" {
+		for _, node := range nodes {
+			if err := setupConfigDir(*node, configDir, softSerial, zedControlURL, grubOptions); err != nil {
+				return fmt.Errorf("cannot setup ConfigDir: %w", err)
+			}
+		}
 	}
 
-	if err := setupEdenScripts(cfg); err != nil {
-		return fmt.Errorf("failed to generate scripts: %w", err)
+	// images of EVE nodes, Eden-SDN and eclient are downloaded and built concurrently
+	var tasks []func() error
+	if componentEnabled(&cfg, ComponentEVE) {
+		if netboot {
+			// every node uploads its files into the same eserver
+			tasks = append(tasks, func() error {
+				for _, node := range nodes {
+					if err := setupEve(netboot, installer, softSerial, ipxeOverride, *node); err != nil {
+						return fmt.Errorf("cannot setup EVE: %s", err)
+					}
+				}
+				return nil
+			})
+		} else {
+			for _, node := range nodes {
+				node := node
+				tasks = append(tasks, func() error {
+					if err := setupEve(netboot, installer, softSerial, ipxeOverride, *node); err != nil {
+						return fmt.Errorf("cannot setup EVE: %s", err)
+					}
+					return nil
+				})
+			}
+		}
 	}
-
 	// Build Eden-SDN VM image unless the SDN is disabled.
 	if isSdnEnabled(cfg.Sdn.Disable, cfg.Eve.Remote, cfg.Eve.DevModel) {
-		if err := setupSdn(cfg); err != nil {
-			return fmt.Errorf("cannot setup Sdn: %w", err)
+		tasks = append(tasks, func() error {
+			if err := setupSdn(cfg); err != nil {
+				return fmt.Errorf("cannot setup Sdn: %w", err)
+			}
+			return nil
+		})
+	}
+	if cfg.Eden.EClient.Image != "" {
+		tasks = append(tasks, func() error {
+			// eclient is used by tests later, so failure to pull it now is not fatal
+			image := fmt.Sprintf("%s:%s", cfg.Eden.EClient.Image, cfg.Eden.EClient.Tag)
+			if err := utils.PullImage(image); err != nil {
+				log.Warnf("cannot pull eclient image %s: %s", image, err)
+			}
+			return nil
+		})
+	}
+	if err := runParallel(tasks...); err != nil {
+		return err
+	}
+
+	if componentEnabled(&cfg, ComponentEVE) && cfg.Cloud.Provider != "" {
+		if err := openEVEC.setupCloud(configName); err != nil {
+			return err
 		}
 	}
 
+	if err := setupEdenScripts(cfg); err != nil {
+		return fmt.Errorf("failed to generate scripts: %w", err)
+	}
+
 	return nil
 }
 
@@ -151,7 +230,7 @@ func setupEve(netboot, installer bool, softSerial, ipxeOverride string, cfg Eden
 	if cfg.Eve.CustomInstaller.Path != "" {
 		// With installer image already prepared, install only UEFI.
 		if imageFormat == "qcow2" {
-			if err := utils.DownloadUEFI(eveDesc, filepath.Dir(cfg.Eve.ImageFile)); err != nil {
+			if err := downloadUEFI(artifactCache(&cfg), eveDesc, filepath.Dir(cfg.Eve.ImageFile)); err != nil {
 				log.Errorf("cannot download UEFI: %s", err.Error())
 			} else {
 				log.Infof("download UEFI done")
@@ -197,7 +276,11 @@ func setupEve(netboot, installer bool, softSerial, ipxeOverride string, cfg Eden
 		if err := utils.DownloadEveNetBoot(eveDesc, filepath.Dir(cfg.Eve.ImageFile)); err != nil {
 			return fmt.Errorf("cannot download EVE: %w", err)
 		}
-		if err := eden.StartEServer(cfg.Eden.EServer.Port, cfg.Eden.EServer.Images.EServerImageDist, cfg.Eden.EServer.Force, cfg.Eden.EServer.Tag); err != nil {
+		opts, err := eserverOpts(&cfg)
+		if err != nil {
+			return err
+		}
+		if err := eden.StartEServer(cfg.Eden.EServer.Port, cfg.Eden.EServer.Images.EServerImageDist, cfg.Eden.EServer.Force, cfg.Eden.EServer.Tag, opts...); err != nil {
 			log.Errorf("cannot start eserver: %s", err.Error())
 		} else {
 			log.Infof("Eserver is running and accessible on port %d", cfg.Eden.EServer.Port)
@@ -207,6 +290,7 @@ func setupEve(netboot, installer bool, softSerial, ipxeOverride string, cfg Eden
 		server := &eden.EServer{
 			EServerIP:   eServerIP,
 			EServerPort: eServerPort,
+			TLS:         cfg.Eden.EServer.TLS,
 		}
 		// we should uncompress kernel for arm64
 		if cfg.Eve.Arch == "arm64" {
@@ -263,7 +347,7 @@ func setupEve(netboot, installer bool, softSerial, ipxeOverride string, cfg Eden
 		}
 		re := regexp.MustCompile("# set url .*")
 		ipxeFileReplaced := re.ReplaceAll(ipxeFileBytes,
-			[]byte(fmt.Sprintf("set url http://%s:%s/%s/", eServerIP, eServerPort, path.Join("eserver", configPrefix))))
+			[]byte(fmt.Sprintf("set url http://%s/%s/", net.JoinHostPort(eServerIP, eServerPort), path.Join("eserver", configPrefix))))
 		if softSerial != "" {
 			ipxeFileReplaced = []byte(strings.ReplaceAll(string(ipxeFileReplaced),
 				"eve_soft_serial=${mac:hexhyp}",
@@ -271,7 +355,7 @@ func setupEve(netboot, installer bool, softSerial, ipxeOverride string, cfg Eden
 		}
 		ipxeOverrideSlice := strings.Split(ipxeOverride, "||")
 		if len(ipxeOverrideSlice) > 1 {
-			fmt.Println(ipxeOverrideSlice)
+			log.Debugf("ipxe overrides: %v", ipxeOverrideSlice)
 
 			for i := 0; ; i += 2 {
 				if i+1 >= len(ipxeOverrideSlice) {
@@ -305,16 +389,26 @@ func setupEve(netboot, installer bool, softSerial, ipxeOverride string, cfg Eden
 		}
 	} else { // download EVE live image
 		if _, err := os.Lstat(cfg.Eve.ImageFile); os.IsNotExist(err) {
-			if err := utils.DownloadEveLive(eveDesc, cfg.Eve.ImageFile); err != nil {
-				return fmt.Errorf("cannot download EVE: %w", err)
-			}
-			log.Infof("download EVE done: %s", imageTag)
-			log.Infof(model.DiskReadyMessage(), cfg.Eve.ImageFile)
-			if imageFormat == "qcow2" {
-				if err := utils.DownloadUEFI(eveDesc, filepath.Dir(cfg.Eve.ImageFile)); err != nil {
-					return fmt.Errorf("cannot download UEFI: %w", err)
+			cache := artifactCache(&cfg)
+			tasks := []func() error{func() error {
+				if err := downloadEveLive(cache, eveDesc, cfg.Eve.ImageFile); err != nil {
+					return fmt.Errorf("cannot download EVE: %w", err)
 				}
-				log.Infof("download UEFI done")
+				log.Infof("download EVE done: %s", imageTag)
+				log.Infof(model.DiskReadyMessage(), cfg.Eve.ImageFile)
+				return nil
+			}}
+			if imageFormat == "qcow2" {
+				tasks = append(tasks, func() error {
+					if err := downloadUEFI(cache, eveDesc, filepath.Dir(cfg.Eve.ImageFile)); err != nil {
+						return fmt.Errorf("cannot download UEFI: %w", err)
+					}
+					log.Infof("download UEFI done")
+					return nil
+				})
+			}
+			if err := runParallel(tasks...); err != nil {
+				return err
 			}
 		} else {
 			log.Infof("download EVE done: %s", imageTag)
@@ -334,8 +428,7 @@ func setupEdenScripts(cfg EdenSetupArgs) error {
 	if err != nil {
 		// Most likely running from inside of "eden test" which sets home directory
 		// to "/no-home".
-		fmt.Printf("Directory %s access error: %s\n",
-			cfgDir, err)
+		log.Warnf("directory %s access error: %s", cfgDir, err)
 	} else {
 		shPath := viper.GetString("eden.root") + "/scripts/shell/"
 
@@ -390,16 +483,23 @@ func setupConfigDir(cfg EdenSetupArgs, eveConfigDir, softSerial, zedControlURL s
 		log.Info("GenerateEveCerts done")
 		log.Infof("Certs already exists in certs dir: %s", cfg.Eden.CertsDir)
 	}
+	bootstrapFile := cfg.Eve.BootstrapFile
+	if cfg.Sdn.Proxy != "" {
+		var err error
+		if bootstrapFile, err = setupSdnProxy(cfg); err != nil {
+			return fmt.Errorf("cannot setup proxy of SDN: %w", err)
+		}
+	}
 	if zedControlURL == "" {
 		err := eden.GenerateEVEConfig(cfg.Eve.DevModel, cfg.Eden.CertsDir, cfg.Adam.CertsDomain, cfg.Adam.CertsEVEIP,
-			cfg.Adam.Port, cfg.Adam.APIv1, softSerial, cfg.Eve.BootstrapFile, isSdnEnabled(cfg.Sdn.Disable, cfg.Eve.Remote, cfg.Eve.DevModel))
+			cfg.Adam.Port, cfg.Adam.APIv1, softSerial, bootstrapFile, isSdnEnabled(cfg.Sdn.Disable, cfg.Eve.Remote, cfg.Eve.DevModel))
 		if err != nil {
 			return fmt.Errorf("cannot GenerateEVEConfig: %w", err)
 		}
 		log.Info("GenerateEVEConfig done")
 	} else {
 		err := eden.GenerateEVEConfig(cfg.Eve.DevModel, cfg.Eden.CertsDir, zedControlURL, "", 0,
-			false, softSerial, cfg.Eve.BootstrapFile, isSdnEnabled(cfg.Sdn.Disable, cfg.Eve.Remote, cfg.Eve.DevModel))
+			false, softSerial, bootstrapFile, isSdnEnabled(cfg.Sdn.Disable, cfg.Eve.Remote, cfg.Eve.DevModel))
 		if err != nil {
 			return fmt.Errorf("cannot GenerateEVEConfig: %w", err)
 		}
@@ -489,7 +589,7 @@ func setupSdn(cfg EdenSetupArgs) error {
 		Registry:   cfg.Eve.Registry,
 		Tag:        cfg.Eve.Tag,
 	}
-	if err := utils.DownloadUEFI(eveDesc, imageDir); err != nil {
+	if err := downloadUEFI(artifactCache(&cfg), eveDesc, imageDir); err != nil {
 		return fmt.Errorf("cannot download UEFI (for SDN): %w", err)
 	}
 	log.Infof("download UEFI (for SDN) done")
@@ -498,6 +598,12 @@ func setupSdn(cfg EdenSetupArgs) error {
 
 func (openEVEC *OpenEVEC) EdenClean(configName, configDist, vmName string, currentContext bool) error {
 	cfg := openEVEC.cfg
+	if cfg.Cloud.Provider != "" {
+		// resources in cloud are not removed with local files
+		if err := openEVEC.cleanCloud(configName); err != nil {
+			return fmt.Errorf("%w (set cloud.provider to empty in config to skip)", err)
+		}
+	}
 	configSaved := utils.ResolveAbsPath(fmt.Sprintf("%s-%s", configName, defaults.DefaultConfigSaved))
 	if currentContext {
 		log.Info("Cleanup current context")
@@ -512,17 +618,30 @@ func (openEVEC *OpenEVEC) EdenClean(configName, configDist, vmName string, curre
 		} else {
 			log.Infof("Adam is running and accessible on port %d", cfg.Adam.Port)
 		}
-		if err := eden.CleanContext(cfg.Eve.Dist, cfg.Eden.CertsDir, filepath.Dir(cfg.Eve.ImageFile), cfg.Eve.Pid, cfg.Eve.CertsUUID,
-			cfg.Sdn.PidFile, vmName, configSaved, cfg.Eve.Remote); err != nil {
-			return fmt.Errorf("cannot CleanContext: %w", err)
+		// files of other nodes are inside directories of the first one, so it is cleaned last
+		nodes := NodeConfigs(cfg)
+		for i := len(nodes) - 1; i >= 0; i-- {
+			node := nodes[i]
+			if err := eden.CleanContext(node.Eve.Dist, node.Eden.CertsDir, filepath.Dir(node.Eve.ImageFile), node.Eve.Pid, node.Eve.CertsUUID,
+				node.Sdn.PidFile, vmName, configSaved, node.Eve.Remote); err != nil {
+				return fmt.Errorf("cannot CleanContext: %w", err)
+			}
 		}
 	} else {
+		// CleanEden stops EVE of the first node only
+		for _, node := range NodeConfigs(cfg)[1:] {
+			eden.StopEve(node.Eve.Pid, filepath.Join(filepath.Dir(node.Eve.ImageFile), "swtpm", "swtpm.pid"),
+				node.Sdn.PidFile, node.Eve.DevModel, vmName)
+		}
 		if err := eden.CleanEden(cfg.Eve.Dist, cfg.Adam.Dist, cfg.Eden.CertsDir, filepath.Dir(cfg.Eve.ImageFile),
 			cfg.Eden.Images.EServerImageDist, cfg.Redis.Dist, cfg.Registry.Dist, configDist, cfg.Eve.Pid,
 			cfg.Sdn.PidFile, configSaved, cfg.Eve.Remote, cfg.Eve.DevModel, vmName); err != nil {
 			return fmt.Errorf("cannot CleanEden: %w", err)
 		}
 	}
+	if err := removeResumeState(); err != nil {
+		log.Warnf("cannot remove saved state: %s", err)
+	}
 	log.Infof("CleanEden done")
 	return nil
 }
@@ -566,7 +685,25 @@ func (openEVEC *OpenEVEC) EdenInfo(outputFormat types.OutputFormat, infoTail uin
 	return nil
 }
 
-func (openEVEC *OpenEVEC) EdenLog(outputFormat types.OutputFormat, follow bool, logTail uint, printFields, args []string) error {
+// EdenLog prints logs of EVE matching query in args and filter, the template
+// (if set) overrides output format and fields to print
+func (openEVEC *OpenEVEC) EdenLog(outputFormat types.OutputFormat, follow bool, logTail uint, printFields, args []string, filter *elog.Filter, logTemplate string) error {
+	if filter == nil {
+		filter = &elog.Filter{}
+	}
+	if err := filter.Validate(); err != nil {
+		return err
+	}
+	var printTemplate func(le *elog.FullLogEntry) error
+	if logTemplate != "" {
+		tmpl, err := elog.NewTemplate(logTemplate)
+		if err != nil {
+			return fmt.Errorf("cannot parse template: %w", err)
+		}
+		printTemplate = func(le *elog.FullLogEntry) error {
+			return elog.LogTemplatePrint(os.Stdout, le, tmpl)
+		}
+	}
 	changer := &adamChanger{}
 	ctrl, devFirst, err := changer.getControllerAndDevFromConfig(openEVEC.cfg)
 	if err != nil {
@@ -581,18 +718,35 @@ func (openEVEC *OpenEVEC) EdenLog(outputFormat types.OutputFormat, follow bool,
 		q[s[0]] = s[1]
 	}
 
-	handleFunc := func(le *elog.FullLogEntry) bool {
-		if printFields == nil {
+	printEntry := func(le *elog.FullLogEntry) bool {
+		switch {
+		case printTemplate != nil:
+			if err := printTemplate(le); err != nil {
+				log.Errorf("cannot print log entry: %s", err)
+			}
+		case printFields == nil:
 			elog.LogPrn(le, outputFormat)
-		} else {
+		default:
 			elog.LogItemPrint(le, outputFormat, printFields).Print()
 		}
 		return false
 	}
+	handleFunc := elog.FilterHandler(filter, printEntry)
 
 	if logTail > 0 {
-		if err = ctrl.LogChecker(devUUID, q, handleFunc, elog.LogTail(logTail), 0); err != nil {
-			return fmt.Errorf("LogChecker: %w", err)
+		// entries are filtered before taking the tail
+		logQueue := utils.InitQueueWithCapacity(int(logTail))
+		enqueue := elog.FilterHandler(filter, func(le *elog.FullLogEntry) bool {
+			if err := logQueue.Enqueue(le); err != nil {
+				log.Error(err)
+			}
+			return false
+		})
+		if err = ctrl.LogLastCallback(devUUID, q, enqueue); err != nil {
+			return fmt.Errorf("LogLastCallback: %w", err)
+		}
+		for el, err := logQueue.Dequeue(); err == nil; el, err = logQueue.Dequeue() {
+			printEntry(el.(*elog.FullLogEntry))
 		}
 	} else {
 		if follow {
@@ -652,7 +806,7 @@ func (openEVEC *OpenEVEC) EdenNetStat(outputFormat types.OutputFormat, follow bo
 	return nil
 }
 
-func (openEVEC *OpenEVEC) EdenMetric(outputFormat types.OutputFormat, follow bool, metricTail uint, printFields, args []string) error {
+func (openEVEC *OpenEVEC) EdenMetric(outputFormat types.OutputFormat, follow, accelerators bool, metricTail uint, printFields, args []string) error {
 	changer := &adamChanger{}
 	ctrl, devFirst, err := changer.getControllerAndDevFromConfig(openEVEC.cfg)
 	if err != nil {
@@ -660,6 +814,17 @@ func (openEVEC *OpenEVEC) EdenMetric(outputFormat types.OutputFormat, follow boo
 	}
 	devUUID := devFirst.GetID()
 
+	if accelerators {
+		state := eve.Init(ctrl, devFirst)
+		if err := ctrl.InfoLastCallback(devUUID, nil, state.InfoCallback()); err != nil {
+			return fmt.Errorf("fail in get InfoLastCallback: %w", err)
+		}
+		if err := ctrl.MetricLastCallback(devUUID, nil, state.MetricCallback()); err != nil {
+			return fmt.Errorf("fail in get MetricLastCallback: %w", err)
+		}
+		return state.AcceleratorsList(outputFormat)
+	}
+
 	q := make(map[string]string)
 
 	for _, a := range args[0:] {
@@ -123,7 +123,8 @@ func ConfigAdd(cfg *EdenSetupArgs, currentContext, contextFile string, force boo
 		viper.Set("eve.arch", cfg.Eve.Arch)
 		imageDist := fmt.Sprintf("%s-%s", context.Current, defaults.DefaultImageDist)
 		switch cfg.Eve.Arch {
-		case "amd64":
+		case "amd64", "riscv64":
+			// UEFI of riscv64 virt machine is loaded into pflash as for amd64
 			viper.Set("eve.firmware", []string{filepath.Join(imageDist, "eve", "firmware", "OVMF_CODE.fd"),
 				filepath.Join(imageDist, "eve", "firmware", "OVMF_VARS.fd")})
 		case "arm64":
@@ -133,6 +134,20 @@ func ConfigAdd(cfg *EdenSetupArgs, currentContext, contextFile string, force boo
 	if cfg.Eve.Ssid != "" {
 		viper.Set("eve.ssid", cfg.Eve.Ssid)
 	}
+	if cfg.Sdn.NetworkType != "" {
+		if _, err := models.NetworkTypeForIPVersions(cfg.Sdn.NetworkType); err != nil {
+			return err
+		}
+		viper.Set("sdn.network-type", cfg.Sdn.NetworkType)
+		if cfg.Sdn.NetworkType == "ipv6-only" {
+			// EVE cannot reach the host using IPv4
+			ip, err := utils.GetIPv6ForDockerAccess()
+			if err != nil {
+				return fmt.Errorf("network type %s requires IPv6 on the host: %w", cfg.Sdn.NetworkType, err)
+			}
+			viper.Set("adam.eve-ip", ip)
+		}
+	}
 
 	for k, v := range model.Config() {
 		viper.Set(k, v)
@@ -2,13 +2,20 @@ package openevec
 
 import (
 	"fmt"
+	"net"
 
+	"github.com/lf-edge/eden/pkg/controller"
 	"github.com/lf-edge/eden/pkg/controller/eflowlog"
 	"github.com/lf-edge/eden/pkg/controller/types"
 	"github.com/lf-edge/eden/pkg/defaults"
+	"github.com/lf-edge/eden/pkg/device"
+	"github.com/lf-edge/eden/pkg/edensdn"
 	"github.com/lf-edge/eden/pkg/eve"
 	"github.com/lf-edge/eden/pkg/expect"
 	"github.com/lf-edge/eden/pkg/utils"
+	sdnapi "github.com/lf-edge/eden/sdn/vm/api"
+	"github.com/lf-edge/eve-api/go/config"
+	uuid "github.com/satori/go.uuid"
 	log "github.com/sirupsen/logrus"
 )
 
@@ -93,14 +100,35 @@ func (openEVEC *OpenEVEC) NetworkNetstat(niName string, outputFormat types.Outpu
 	return nil
 }
 
+// UplinkIPConfig customizes IP configuration of the uplink port of network instance
+// and DHCP server of the SDN network connected to the port
+type UplinkIPConfig struct {
+	// StaticIP configures the port with the static IP, DHCP of SDN is disabled
+	StaticIP string
+	// DHCPRange in format FROM-TO
+	DHCPRange string
+	// StaticLeases in format MAC=IP
+	StaticLeases []string
+	DNS          []string
+	NTP          string
+}
+
+func (uplinkIP UplinkIPConfig) isSet() bool {
+	return uplinkIP.StaticIP != "" || uplinkIP.DHCPRange != "" || len(uplinkIP.StaticLeases) > 0 ||
+		len(uplinkIP.DNS) > 0 || uplinkIP.NTP != ""
+}
+
 func (openEVEC *OpenEVEC) NetworkCreate(subnet, networkType, networkName, uplinkAdapter string,
-	staticDNSEntries []string, enableFlowlog bool) error {
+	staticDNSEntries []string, enableFlowlog bool, uplinkIP UplinkIPConfig) error {
 	if networkType != "local" && networkType != "switch" {
 		return fmt.Errorf("network type %s not supported now", networkType)
 	}
 	if networkType == "local" && subnet == "" {
 		return fmt.Errorf("you must define subnet as first arg for local network")
 	}
+	if uplinkIP.isSet() && uplinkAdapter == "none" {
+		return fmt.Errorf("IP configuration of uplink requires uplink adapter")
+	}
 	changer := &adamChanger{}
 	ctrl, dev, err := changer.getControllerAndDevFromConfig(openEVEC.cfg)
 	if err != nil {
@@ -125,9 +153,110 @@ mainloop:
 		dev.SetNetworkInstanceConfig(append(dev.GetNetworkInstances(), el.Uuidandversion.Uuid))
 		log.Infof("deploy network %s with name %s request sent", el.Uuidandversion.Uuid, el.Displayname)
 	}
+	if uplinkIP.isSet() {
+		if err = openEVEC.configureUplinkIP(ctrl, dev, uplinkAdapter, uplinkIP); err != nil {
+			return err
+		}
+	}
 	if err = changer.setControllerAndDev(ctrl, dev); err != nil {
 		return fmt.Errorf("setControllerAndDev: %w", err)
 	}
 
 	return nil
 }
+
+// configureUplinkIP applies uplinkIP to the SDN network connected to the uplink adapter
+// and, with static IP requested, configures the adapter of EVE with the static IP
+// and with the subnet, gateway, DNS and NTP servers of the SDN network.
+func (openEVEC *OpenEVEC) configureUplinkIP(ctrl controller.Cloud, dev *device.Ctx,
+	uplinkAdapter string, uplinkIP UplinkIPConfig) error {
+	cfg := openEVEC.cfg
+	opts := edensdn.DHCPOptions{
+		Disable: uplinkIP.StaticIP != "",
+		DNS:     uplinkIP.DNS,
+		NTP:     uplinkIP.NTP,
+	}
+	var err error
+	if uplinkIP.DHCPRange != "" {
+		if opts.IPRange, err = edensdn.ParseIPRange(uplinkIP.DHCPRange); err != nil {
+			return err
+		}
+	}
+	for _, lease := range uplinkIP.StaticLeases {
+		staticLease, err := edensdn.ParseStaticLease(lease)
+		if err != nil {
+			return err
+		}
+		opts.StaticLeases = append(opts.StaticLeases, staticLease)
+	}
+	if uplinkIP.StaticIP != "" && !isSdnEnabled(cfg.Sdn.Disable, cfg.Eve.Remote, cfg.Eve.DevModel) {
+		return fmt.Errorf("static IP of uplink requires SDN to disable DHCP and learn the subnet")
+	}
+	adapters, err := getNetworkAdapters(ctrl, dev)
+	if err != nil {
+		return err
+	}
+	if !adapters.exists(uplinkAdapter) {
+		return fmt.Errorf("uplink adapter %s is not defined for the device", uplinkAdapter)
+	}
+	var ipSpec *config.Ipspec
+	err = openEVEC.updateSdnNetModel(adapters.ethPorts(uplinkAdapter),
+		func(model *sdnapi.NetworkModel, sdnPorts []string) error {
+			network, err := edensdn.UpdateNetworkDHCP(model, sdnPorts, opts)
+			if err != nil {
+				return err
+			}
+			ipSpec = &config.Ipspec{
+				Dhcp:    config.DHCPType_Static,
+				Subnet:  network.Subnet,
+				Gateway: network.GwIP,
+				Domain:  network.DHCP.DomainName,
+				Ntp:     edensdn.NetworkNTPServer(*model, network),
+				Dns:     edensdn.NetworkDNSServers(*model, network),
+			}
+			return nil
+		})
+	if err != nil || uplinkIP.StaticIP == "" {
+		return err
+	}
+	_, subnet, err := net.ParseCIDR(ipSpec.Subnet)
+	if err != nil || !subnet.Contains(net.ParseIP(uplinkIP.StaticIP)) {
+		return fmt.Errorf("static IP %s is outside of subnet %s", uplinkIP.StaticIP, ipSpec.Subnet)
+	}
+	// keep IP version and uplink flag of the adapter
+	networkType := config.NetworkType_V4
+	var uplink bool
+	if systemAdapter, ok := adapters.systemAdapters[uplinkAdapter]; ok {
+		uplink = systemAdapter.Uplink
+		if networkConfig, err := ctrl.GetNetworkConfig(systemAdapter.NetworkUUID); err == nil {
+			networkType = networkConfig.Type
+		}
+		if err = removeSystemAdapter(ctrl, dev, adapters.ids["system:"+uplinkAdapter]); err != nil {
+			return err
+		}
+	}
+	id, err := uuid.NewV4()
+	if err != nil {
+		return err
+	}
+	err = ctrl.AddNetworkConfig(&config.NetworkConfig{
+		Id:   id.String(),
+		Type: networkType,
+		Ip:   ipSpec,
+	})
+	if err != nil {
+		return fmt.Errorf("AddNetworkConfig: %w", err)
+	}
+	dev.SetNetworkConfig(append(dev.GetNetworks(), id.String()))
+	err = addSystemAdapter(ctrl, dev, &config.SystemAdapter{
+		Name:        uplinkAdapter,
+		Uplink:      uplink,
+		NetworkUUID: id.String(),
+		Addr:        uplinkIP.StaticIP,
+	})
+	if err != nil {
+		return err
+	}
+	log.Infof("adapter %s configured with static IP %s", uplinkAdapter, uplinkIP.StaticIP)
+	return nil
+}
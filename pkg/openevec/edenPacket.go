@@ -2,7 +2,9 @@ package openevec
 
 import (
 	"fmt"
+	"net"
 	"path"
+	"strconv"
 
 	"github.com/lf-edge/eden/pkg/defaults"
 	"github.com/lf-edge/eden/pkg/packet"
@@ -16,7 +18,7 @@ func (openEVEC *OpenEVEC) PacketRun(packetKey, packetProjectName, packetVMName,
 		if cfg.ConfigName == defaults.DefaultContext {
 			configPrefix = ""
 		}
-		packetIPXEUrl = fmt.Sprintf("http://%s:%d/%s/ipxe.efi.cfg", cfg.Adam.CertsEVEIP, cfg.Eden.EServer.Port, path.Join("eserver", configPrefix))
+		packetIPXEUrl = fmt.Sprintf("http://%s/%s/ipxe.efi.cfg", net.JoinHostPort(cfg.Adam.CertsEVEIP, strconv.Itoa(cfg.Eden.EServer.Port)), path.Join("eserver", configPrefix))
 		log.Debugf("ipxe-url is empty, will use default one: %s", packetIPXEUrl)
 	}
 
@@ -3,7 +3,9 @@ package openevec
 import (
 	"encoding/json"
 	"fmt"
+	"net"
 	"path"
+	"strconv"
 	"strings"
 
 	"github.com/Insei/rolgo"
@@ -21,7 +23,7 @@ func (openEVEC *OpenEVEC) CreateRent(rolProjectID, rolRentName, rolModel, rolMan
 		if cfg.ConfigName == defaults.DefaultContext {
 			configPrefix = ""
 		}
-		rolIPXEUrl = fmt.Sprintf("http://%s:%d/%s/ipxe.efi.cfg", cfg.Adam.CertsEVEIP, cfg.Eden.EServer.Port, path.Join("eserver", configPrefix))
+		rolIPXEUrl = fmt.Sprintf("http://%s/%s/ipxe.efi.cfg", net.JoinHostPort(cfg.Adam.CertsEVEIP, strconv.Itoa(cfg.Eden.EServer.Port)), path.Join("eserver", configPrefix))
 		// log.Debugf("ipxe-url is empty, will use default one: %s", packetIPXEUrl)
 	}
 	r := &rolgo.DeviceRentCreateRequest{Model: rolModel, Manufacturer: rolManufacturer, Name: rolRentName,
@@ -3,11 +3,14 @@ package openevec
 import (
 	"fmt"
 	"math/rand"
+	"strings"
 	"time"
 
 	"github.com/docker/docker/pkg/namesgenerator"
 	"github.com/dustin/go-humanize"
+	"github.com/lf-edge/eden/pkg/controller"
 	"github.com/lf-edge/eden/pkg/controller/types"
+	"github.com/lf-edge/eden/pkg/device"
 	"github.com/lf-edge/eden/pkg/eve"
 	"github.com/lf-edge/eden/pkg/expect"
 	"github.com/lf-edge/eden/pkg/utils"
@@ -35,7 +38,7 @@ func (openEVEC *OpenEVEC) VolumeLs(outputFormat types.OutputFormat) error {
 	return nil
 }
 
-func (openEVEC *OpenEVEC) VolumeCreate(appLink, registry, diskSize, volumeName, volumeType, datastoreOverride string, sftpLoad, directLoad bool) error {
+func (openEVEC *OpenEVEC) VolumeCreate(appLink, registry, diskSize, volumeName, volumeType, datastoreOverride string, sftpLoad, s3Load, directLoad bool) error {
 	changer := &adamChanger{}
 	ctrl, dev, err := changer.getControllerAndDevFromConfig(openEVEC.cfg)
 	if err != nil {
@@ -75,18 +78,12 @@ func (openEVEC *OpenEVEC) VolumeCreate(appLink, registry, diskSize, volumeName,
 		opts = append(opts, expect.WithDiskSize(int64(diskSizeParsed)))
 		opts = append(opts, expect.WithImageFormat(volumeType))
 		opts = append(opts, expect.WithSFTPLoad(sftpLoad))
-		if !sftpLoad {
+		opts = append(opts, expect.WithS3Load(s3Load))
+		if !sftpLoad && !s3Load {
 			opts = append(opts, expect.WithHTTPDirectLoad(directLoad))
 		}
 		opts = append(opts, expect.WithDatastoreOverride(datastoreOverride))
-		registryToUse := registry
-		switch registry {
-		case "local":
-			registryToUse = fmt.Sprintf("%s:%d", openEVEC.cfg.Registry.IP, openEVEC.cfg.Registry.Port)
-		case "remote":
-			registryToUse = ""
-		}
-		opts = append(opts, expect.WithRegistry(registryToUse))
+		opts = append(opts, expect.WithRegistry(RegistryAddress(openEVEC.cfg, registry)))
 		expectation := expect.AppExpectationFromURL(ctrl, dev, appLink, volumeName, opts...)
 		volumeConfig := expectation.Volume()
 		log.Infof("create volume %s with %s request sent", volumeConfig.DisplayName, appLink)
@@ -123,83 +120,177 @@ func (openEVEC *OpenEVEC) VolumeDelete(volumeName string) error {
 	return nil
 }
 
-func (openEVEC *OpenEVEC) VolumeDetach(volumeName string) error {
+// purgeApp increments purge counter of app, so EVE recreates it with new set of volumes
+func purgeApp(app *config.AppInstanceConfig) {
+	purgeCounter := uint32(1)
+	if app.Purge != nil {
+		purgeCounter = app.Purge.Counter + 1
+	}
+	app.Purge = &config.InstanceOpsCmd{Counter: purgeCounter}
+}
+
+// VolumeDetach detaches volume from app with name appName or from all apps if appName is empty.
+// Apps are purged to apply the change unless purge is false (hot-detach).
+func (openEVEC *OpenEVEC) VolumeDetach(volumeName, appName string, purge bool) error {
 	changer := &adamChanger{}
 	ctrl, dev, err := changer.getControllerAndDevFromConfig(openEVEC.cfg)
 	if err != nil {
 		return fmt.Errorf("getControllerAndDevFromConfig: %w", err)
 	}
-	for _, el := range dev.GetVolumes() {
-		volume, err := ctrl.GetVolume(el)
+	volume, err := findVolume(ctrl, dev, volumeName)
+	if err != nil {
+		return err
+	}
+	detached := false
+	for _, appID := range dev.GetApplicationInstances() {
+		app, err := ctrl.GetApplicationInstanceConfig(appID)
 		if err != nil {
-			return fmt.Errorf("no volume in cloud %s: %s", el, err)
+			return fmt.Errorf("no app in cloud %s: %s", appID, err)
 		}
-		if volume.DisplayName == volumeName {
-			for _, appID := range dev.GetApplicationInstances() {
-				app, err := ctrl.GetApplicationInstanceConfig(appID)
-				if err != nil {
-					return fmt.Errorf("no app in cloud %s: %s", el, err)
-				}
-				volumeRefs := app.GetVolumeRefList()
-				utils.DelEleInSliceByFunction(&volumeRefs, func(i interface{}) bool {
-					vol := i.(*config.VolumeRef)
-					if vol.Uuid == volume.Uuid {
-						purgeCounter := uint32(1)
-						if app.Purge != nil {
-							purgeCounter = app.Purge.Counter + 1
-						}
-						app.Purge = &config.InstanceOpsCmd{Counter: purgeCounter}
-						log.Infof("Volume detached from %s, app will be purged", app.Displayname)
-						return true
-					}
-					return false
-				})
-				app.VolumeRefList = volumeRefs
-			}
-			if err = changer.setControllerAndDev(ctrl, dev); err != nil {
-				return fmt.Errorf("setControllerAndDev: %w", err)
+		if appName != "" && app.Displayname != appName {
+			continue
+		}
+		volumeRefs := app.GetVolumeRefList()
+		utils.DelEleInSliceByFunction(&volumeRefs, func(i interface{}) bool {
+			return i.(*config.VolumeRef).Uuid == volume.Uuid
+		})
+		if len(volumeRefs) == len(app.VolumeRefList) {
+			continue
+		}
+		app.VolumeRefList = volumeRefs
+		detached = true
+		if purge {
+			purgeApp(app)
+			log.Infof("Volume detached from %s, app will be purged", app.Displayname)
+		} else {
+			log.Infof("Volume detached from %s", app.Displayname)
+		}
+	}
+	if !detached {
+		log.Infof("volume %s is not attached", volumeName)
+		return nil
+	}
+	if err = changer.setControllerAndDev(ctrl, dev); err != nil {
+		return fmt.Errorf("setControllerAndDev: %w", err)
+	}
+	return nil
+}
+
+// VolumeAttach attaches volume to app, the app is purged to apply the change unless purge is false (hot-attach)
+func (openEVEC *OpenEVEC) VolumeAttach(appName, volumeName, mountPoint string, purge bool) error {
+	changer := &adamChanger{}
+	ctrl, dev, err := changer.getControllerAndDevFromConfig(openEVEC.cfg)
+	if err != nil {
+		return fmt.Errorf("getControllerAndDevFromConfig: %w", err)
+	}
+	volume, err := findVolume(ctrl, dev, volumeName)
+	if err != nil {
+		return err
+	}
+	for _, appID := range dev.GetApplicationInstances() {
+		app, err := ctrl.GetApplicationInstanceConfig(appID)
+		if err != nil {
+			return fmt.Errorf("no app in cloud %s: %s", appID, err)
+		}
+		if app.Displayname != appName {
+			continue
+		}
+		for _, ref := range app.VolumeRefList {
+			if ref.Uuid == volume.Uuid {
+				return fmt.Errorf("volume %s already attached to %s", volumeName, appName)
 			}
-			return nil
 		}
+		app.VolumeRefList = append(app.VolumeRefList, &config.VolumeRef{Uuid: volume.Uuid, MountDir: mountPoint})
+		if purge {
+			purgeApp(app)
+			log.Infof("Volume %s attached to %s, app will be purged", volumeName, app.Displayname)
+		} else {
+			log.Infof("Volume %s attached to %s", volumeName, app.Displayname)
+		}
+		if err = changer.setControllerAndDev(ctrl, dev); err != nil {
+			return fmt.Errorf("setControllerAndDev: %w", err)
+		}
+		return nil
 	}
-	log.Infof("not found volume with name %s", volumeName)
+	return fmt.Errorf("not found app with name %s", appName)
+}
+
+// VolumeResize sets maximum size of volume, volumes can only grow
+func (openEVEC *OpenEVEC) VolumeResize(volumeName, size string) error {
+	newSize, err := humanize.ParseBytes(size)
+	if err != nil {
+		return fmt.Errorf("cannot parse size %s: %w", size, err)
+	}
+	changer := &adamChanger{}
+	ctrl, dev, err := changer.getControllerAndDevFromConfig(openEVEC.cfg)
+	if err != nil {
+		return fmt.Errorf("getControllerAndDevFromConfig: %w", err)
+	}
+	volume, err := findVolume(ctrl, dev, volumeName)
+	if err != nil {
+		return err
+	}
+	if int64(newSize) < volume.Maxsizebytes {
+		return fmt.Errorf("cannot shrink volume %s from %s to %s",
+			volumeName, humanize.Bytes(uint64(volume.Maxsizebytes)), humanize.Bytes(newSize))
+	}
+	if int64(newSize) == volume.Maxsizebytes {
+		log.Infof("volume %s already has size %s", volumeName, humanize.Bytes(newSize))
+		return nil
+	}
+	volume.Maxsizebytes = int64(newSize)
+	if err = changer.setControllerAndDev(ctrl, dev); err != nil {
+		return fmt.Errorf("setControllerAndDev: %w", err)
+	}
+	log.Infof("volume %s resize to %s request sent", volumeName, humanize.Bytes(newSize))
 	return nil
 }
 
-func (openEVEC *OpenEVEC) VolumeAttach(appName, volumeName, mountPoint string) error {
+// VolumeWait waits for volume to meet all conditions reported by EVE
+func (openEVEC *OpenEVEC) VolumeWait(volumeName string, conditions []string, timeout time.Duration) error {
+	conds, err := eve.ParseVolumeConditions(conditions)
+	if err != nil {
+		return err
+	}
 	changer := &adamChanger{}
 	ctrl, dev, err := changer.getControllerAndDevFromConfig(openEVEC.cfg)
 	if err != nil {
 		return fmt.Errorf("getControllerAndDevFromConfig: %w", err)
 	}
+	var pending []string
+	check := func(state *eve.State) bool {
+		pending = []string{}
+		for _, vol := range state.Volumes() {
+			if vol.Name != volumeName {
+				continue
+			}
+			for _, cond := range conds {
+				if !cond.Check(vol, state.Applications()) {
+					pending = append(pending, cond.String())
+				}
+			}
+			return len(pending) == 0
+		}
+		pending = append(pending, "volume found")
+		return false
+	}
+	if err := eve.NewWaiter(ctrl, dev, eve.Init(ctrl, dev)).Wait(check, timeout); err != nil {
+		return fmt.Errorf("volume %s does not meet conditions %s in %s", volumeName, strings.Join(pending, ","), timeout)
+	}
+	log.Infof("volume %s meets conditions %s", volumeName, strings.Join(conditions, ","))
+	return nil
+}
+
+// findVolume returns volume of device with name volumeName
+func findVolume(ctrl controller.Cloud, dev *device.Ctx, volumeName string) (*config.Volume, error) {
 	for _, el := range dev.GetVolumes() {
 		volume, err := ctrl.GetVolume(el)
 		if err != nil {
-			return fmt.Errorf("no volume in cloud %s: %s", el, err)
+			return nil, fmt.Errorf("no volume in cloud %s: %s", el, err)
 		}
 		if volume.DisplayName == volumeName {
-			for _, appID := range dev.GetApplicationInstances() {
-				app, err := ctrl.GetApplicationInstanceConfig(appID)
-				if err != nil {
-					return fmt.Errorf("no app in cloud %s: %s", el, err)
-				}
-				if app.Displayname == appName {
-					purgeCounter := uint32(1)
-					if app.Purge != nil {
-						purgeCounter = app.Purge.Counter + 1
-					}
-					app.Purge = &config.InstanceOpsCmd{Counter: purgeCounter}
-					app.VolumeRefList = append(app.VolumeRefList, &config.VolumeRef{Uuid: volume.Uuid, MountDir: mountPoint})
-					log.Infof("Volume %s attached to %s, app will be purged", volumeName, app.Displayname)
-					if err = changer.setControllerAndDev(ctrl, dev); err != nil {
-						return fmt.Errorf("setControllerAndDev: %w", err)
-					}
-					return nil
-				}
-			}
-			log.Infof("not found app with name %s", appName)
+			return volume, nil
 		}
 	}
-	log.Infof("not found volume with name %s", volumeName)
-	return nil
+	return nil, fmt.Errorf("not found volume with name %s", volumeName)
 }
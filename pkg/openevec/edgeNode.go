@@ -9,14 +9,20 @@ import (
 	"path"
 	"path/filepath"
 	"regexp"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/lf-edge/eden/pkg/controller"
+	"github.com/lf-edge/eden/pkg/controller/export"
+	"github.com/lf-edge/eden/pkg/controller/replay"
 	"github.com/lf-edge/eden/pkg/controller/types"
 	"github.com/lf-edge/eden/pkg/defaults"
+	"github.com/lf-edge/eden/pkg/device"
 	"github.com/lf-edge/eden/pkg/expect"
 	"github.com/lf-edge/eden/pkg/utils"
 	"github.com/lf-edge/eve-api/go/config"
+	uuid "github.com/satori/go.uuid"
 	log "github.com/sirupsen/logrus"
 	"google.golang.org/protobuf/encoding/protojson"
 	"google.golang.org/protobuf/proto"
@@ -70,14 +76,7 @@ func (openEVEC *OpenEVEC) EdgeNodeEVEImageUpdate(baseOSImage, baseOSVersion, reg
 	if err != nil {
 		return fmt.Errorf("getControllerAndDevFromConfig error: %w", err)
 	}
-	registryToUse := registry
-	switch registry {
-	case "local":
-		registryToUse = fmt.Sprintf("%s:%d", openEVEC.cfg.Registry.IP, openEVEC.cfg.Registry.Port)
-	case "remote":
-		registryToUse = ""
-	}
-	opts = append(opts, expect.WithRegistry(registryToUse))
+	opts = append(opts, expect.WithRegistry(RegistryAddress(openEVEC.cfg, registry)))
 	expectation := expect.AppExpectationFromURL(ctrl, dev, baseOSImage, "", opts...)
 	if baseOSVDrive {
 		baseOSImageConfig := expectation.BaseOSConfig(baseOSVersion)
@@ -272,6 +271,86 @@ func (openEVEC *OpenEVEC) EdgeNodeGetConfig(controllerMode, fileWithConfig strin
 	return nil
 }
 
+// ControllerDiff shows difference between config of device stored in controller
+// and config from file, stdin or config which eden will push on the next change
+func (openEVEC *OpenEVEC) ControllerDiff(controllerMode, fileWithConfig string, outputFormat types.OutputFormat) error {
+	changer, err := changerByControllerMode(controllerMode)
+	if err != nil {
+		return err
+	}
+	ctrl, dev, err := changer.getControllerAndDevFromConfig(openEVEC.cfg)
+	if err != nil {
+		return fmt.Errorf("getControllerAndDevFromConfig error: %w", err)
+	}
+	var newConfig []byte
+	if fileWithConfig != "" {
+		newConfig, err = os.ReadFile(fileWithConfig)
+		if err != nil {
+			return fmt.Errorf("file reading error: %w", err)
+		}
+	} else if utils.IsInputFromPipe() {
+		newConfig, err = io.ReadAll(os.Stdin)
+		if err != nil {
+			return fmt.Errorf("stdin reading error: %w", err)
+		}
+	} else if newConfig, err = ctrl.GetConfigBytes(dev, true); err != nil {
+		return fmt.Errorf("GetConfigBytes error: %w", err)
+	}
+	var dConfig config.EdgeDevConfig
+	if err := protojson.Unmarshal(newConfig, &dConfig); err != nil {
+		return fmt.Errorf("cannot unmarshal config: %w", err)
+	}
+	diff, err := ctrl.ConfigDiffWithController(dev, &dConfig)
+	if err != nil {
+		return fmt.Errorf("ConfigDiffWithController: %w", err)
+	}
+	switch outputFormat {
+	case types.OutputFormatJSON:
+		data, err := json.MarshalIndent(diff, "", "    ")
+		if err != nil {
+			return fmt.Errorf("cannot marshal diff: %w", err)
+		}
+		fmt.Println(string(data))
+	case types.OutputFormatLines:
+		controller.PrintConfigDiff(diff)
+	}
+	return nil
+}
+
+// ControllerExport converts config of device stored in controller into objects of zedcloud
+// API or into resources of zedcloud Terraform provider and writes them into file or stdout
+func (openEVEC *OpenEVEC) ControllerExport(controllerMode string, format export.Format, outputFile string) error {
+	changer, err := changerByControllerMode(controllerMode)
+	if err != nil {
+		return err
+	}
+	ctrl, dev, err := changer.getControllerAndDevFromConfig(openEVEC.cfg)
+	if err != nil {
+		return fmt.Errorf("getControllerAndDevFromConfig error: %w", err)
+	}
+	res, err := ctrl.GetConfigBytes(dev, true)
+	if err != nil {
+		return fmt.Errorf("GetConfigBytes error: %w", err)
+	}
+	var dConfig config.EdgeDevConfig
+	if err := protojson.Unmarshal(res, &dConfig); err != nil {
+		return fmt.Errorf("cannot unmarshal config: %w", err)
+	}
+	data, err := export.Export(&dConfig).Render(format)
+	if err != nil {
+		return err
+	}
+	if outputFile == "" {
+		fmt.Print(string(data))
+		return nil
+	}
+	if err = os.WriteFile(outputFile, data, 0644); err != nil {
+		return fmt.Errorf("cannot write %s: %w", outputFile, err)
+	}
+	log.Infof("Config of device exported into %s", outputFile)
+	return nil
+}
+
 func (openEVEC *OpenEVEC) EdgeNodeSetConfig(fileWithConfig string) error {
 	ctrl, err := controller.CloudPrepare()
 	if err != nil {
@@ -306,6 +385,15 @@ func (openEVEC *OpenEVEC) EdgeNodeSetConfig(fileWithConfig string) error {
 	if err := protojson.Unmarshal(newConfig, &dConfig); err != nil {
 		return fmt.Errorf("cannot unmarshal config: %w", err)
 	}
+	if vars.DryRun {
+		diff, err := ctrl.ConfigDiffWithController(devFirst, &dConfig)
+		if err != nil {
+			return fmt.Errorf("ConfigDiffWithController: %w", err)
+		}
+		controller.PrintConfigDiff(diff)
+		fmt.Println("dry-run: config not pushed to controller")
+		return nil
+	}
 	// Adam expects json type
 	cfg, err := proto.Marshal(&dConfig)
 	if err != nil {
@@ -381,6 +469,215 @@ func (openEVEC *OpenEVEC) EdgeNodeSetOptions(controllerMode, fileWithConfig stri
 	return nil
 }
 
+func (openEVEC *OpenEVEC) EdgeNodeAttestState(controllerMode string, waitAttested time.Duration, check bool) error {
+	changer, err := changerByControllerMode(controllerMode)
+	if err != nil {
+		return err
+	}
+	ctrl, dev, err := changer.getControllerAndDevFromConfig(openEVEC.cfg)
+	if err != nil {
+		return fmt.Errorf("getControllerAndDevFromConfig error: %w", err)
+	}
+	state, err := ctrl.GetAttestState(dev)
+	if err != nil {
+		return fmt.Errorf("GetAttestState error: %w", err)
+	}
+	if waitAttested > 0 {
+		timeout := time.After(waitAttested)
+		ticker := time.NewTicker(defaults.DefaultRepeatTimeout)
+		defer ticker.Stop()
+		// EVE reports its attestation state after the controller attested it
+		for !state.Attested || (check && !state.Checks.OK()) {
+			select {
+			case <-timeout:
+				return fmt.Errorf("device is not attested after %s", waitAttested)
+			case <-ticker.C:
+			}
+			if state, err = ctrl.GetAttestState(dev); err != nil {
+				return fmt.Errorf("GetAttestState error: %w", err)
+			}
+		}
+	}
+	data, err := json.MarshalIndent(state, "", "    ")
+	if err != nil {
+		return fmt.Errorf("cannot marshal: %w", err)
+	}
+	fmt.Println(string(data))
+	if check && !state.Checks.OK() {
+		return fmt.Errorf("attestation checks failed")
+	}
+	return nil
+}
+
+func (openEVEC *OpenEVEC) EdgeNodeReattest(controllerMode string) error {
+	changer, err := changerByControllerMode(controllerMode)
+	if err != nil {
+		return err
+	}
+	ctrl, dev, err := changer.getControllerAndDevFromConfig(openEVEC.cfg)
+	if err != nil {
+		return fmt.Errorf("getControllerAndDevFromConfig error: %w", err)
+	}
+	if err := ctrl.ResetAttestation(dev); err != nil {
+		return fmt.Errorf("ResetAttestation error: %w", err)
+	}
+	log.Info("Attestation state reset, EVE will re-attest on next request")
+	return nil
+}
+
+func (openEVEC *OpenEVEC) EdgeNodeTPMState(controllerMode string, check bool) error {
+	changer, err := changerByControllerMode(controllerMode)
+	if err != nil {
+		return err
+	}
+	ctrl, dev, err := changer.getControllerAndDevFromConfig(openEVEC.cfg)
+	if err != nil {
+		return fmt.Errorf("getControllerAndDevFromConfig error: %w", err)
+	}
+	state, err := ctrl.GetTPMState(dev)
+	if err != nil {
+		return fmt.Errorf("GetTPMState error: %w", err)
+	}
+	data, err := json.MarshalIndent(state, "", "    ")
+	if err != nil {
+		return fmt.Errorf("cannot marshal: %w", err)
+	}
+	fmt.Println(string(data))
+	if check && !state.OK() {
+		return fmt.Errorf("TPM checks failed")
+	}
+	return nil
+}
+
+func (openEVEC *OpenEVEC) ControllerBatchApply(devices []string, fileWithConfig string, parallel int) error {
+	ctrl, err := controller.CloudPrepare()
+	if err != nil {
+		return fmt.Errorf("CloudPrepare error: %w", err)
+	}
+	vars, err := InitVarsFromConfig(openEVEC.cfg)
+	if err != nil {
+		return fmt.Errorf("InitVarsFromConfig error: %w", err)
+	}
+	ctrl.SetVars(vars)
+	var patchBytes []byte
+	if fileWithConfig != "" {
+		patchBytes, err = os.ReadFile(fileWithConfig)
+		if err != nil {
+			return fmt.Errorf("file reading error: %w", err)
+		}
+	} else if utils.IsInputFromPipe() {
+		patchBytes, err = io.ReadAll(os.Stdin)
+		if err != nil {
+			return fmt.Errorf("stdin reading error: %w", err)
+		}
+	} else {
+		return fmt.Errorf("please run command with --file or use it with pipe")
+	}
+	var patch config.EdgeDevConfig
+	if err := protojson.Unmarshal(patchBytes, &patch); err != nil {
+		return fmt.Errorf("cannot unmarshal config: %w", err)
+	}
+	var devs []*device.Ctx
+	if len(devices) == 1 && devices[0] == "all" {
+		registered, err := ctrl.DeviceList(types.RegisteredDeviceFilter)
+		if err != nil {
+			return fmt.Errorf("DeviceList error: %w", err)
+		}
+		devices = registered
+	}
+	for _, el := range devices {
+		devUUID, err := uuid.FromString(el)
+		if err != nil {
+			return fmt.Errorf("cannot parse device UUID %s: %w", el, err)
+		}
+		dev, err := ctrl.GetDeviceUUID(devUUID)
+		if err != nil {
+			return fmt.Errorf("device %s: %w", el, err)
+		}
+		devs = append(devs, dev)
+	}
+	failed := 0
+	for _, res := range ctrl.BatchApply(devs, ctrl.PatchChange(&patch), parallel) {
+		switch {
+		case res.Err != nil:
+			failed++
+			fmt.Printf("%s\tFAILED\t%s\n", res.DevUUID, res.Err)
+		case res.Changed:
+			fmt.Printf("%s\tCHANGED\n", res.DevUUID)
+		default:
+			fmt.Printf("%s\tUNCHANGED\n", res.DevUUID)
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("batch apply failed for %d of %d devices", failed, len(devs))
+	}
+	return nil
+}
+
+// ControllerReplay pushes configs recorded by 'eden test --record' into dir to the current device
+// keeping delays between them divided by speed, zero speed pushes them without delays
+func (openEVEC *OpenEVEC) ControllerReplay(dir string, speed float64) error {
+	if speed < 0 {
+		return fmt.Errorf("speed must not be negative")
+	}
+	rec, err := replay.Load(dir)
+	if err != nil {
+		return err
+	}
+	var configs []*replay.Event
+	recordedDevices := map[string]bool{}
+	for _, ev := range rec.Events {
+		if ev.Kind == replay.KindConfig {
+			configs = append(configs, ev)
+			recordedDevices[ev.Device] = true
+		}
+	}
+	if len(configs) == 0 {
+		return fmt.Errorf("no configs in recording %s", dir)
+	}
+	if len(recordedDevices) > 1 {
+		return fmt.Errorf("recording %s contains configs of %d devices", dir, len(recordedDevices))
+	}
+	ctrl, err := controller.CloudPrepare()
+	if err != nil {
+		return fmt.Errorf("CloudPrepare error: %w", err)
+	}
+	dev, err := ctrl.GetDeviceCurrent()
+	if err != nil {
+		return fmt.Errorf("GetDeviceCurrent error: %w", err)
+	}
+	currentConfig, err := ctrl.ConfigGet(dev.GetID())
+	if err != nil {
+		return fmt.Errorf("ConfigGet error: %w", err)
+	}
+	var current config.EdgeDevConfig
+	if err := proto.Unmarshal([]byte(currentConfig), &current); err != nil {
+		return fmt.Errorf("cannot unmarshal config: %w", err)
+	}
+	// device applies config only if its version is newer
+	version, _ := strconv.Atoi(current.GetId().GetVersion())
+	for i, ev := range configs {
+		if i > 0 && speed > 0 {
+			time.Sleep(time.Duration(float64(ev.Time.Sub(configs[i-1].Time)) / speed))
+		}
+		var dConfig config.EdgeDevConfig
+		if err := proto.Unmarshal(ev.Data, &dConfig); err != nil {
+			return fmt.Errorf("cannot unmarshal recorded config %d: %w", i+1, err)
+		}
+		version++
+		dConfig.Id = &config.UUIDandVersion{Uuid: dev.GetID().String(), Version: strconv.Itoa(version)}
+		data, err := proto.Marshal(&dConfig)
+		if err != nil {
+			return fmt.Errorf("cannot marshal config: %w", err)
+		}
+		if err := ctrl.ConfigSet(dev.GetID(), data); err != nil {
+			return fmt.Errorf("ConfigSet error: %w", err)
+		}
+		log.Infof("pushed recorded config %d of %d (version %d)", i+1, len(configs), version)
+	}
+	return nil
+}
+
 func (openEVEC *OpenEVEC) ControllerGetOptions(fileWithConfig string) error {
 	ctrl, err := controller.CloudPrepare()
 	if err != nil {
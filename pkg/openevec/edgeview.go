@@ -0,0 +1,197 @@
+package openevec
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/lf-edge/eden/pkg/controller/types"
+	"github.com/lf-edge/eden/pkg/defaults"
+	"github.com/lf-edge/eden/pkg/edgeview"
+	"github.com/lf-edge/eden/pkg/utils"
+	"github.com/lf-edge/eve-api/go/config"
+	log "github.com/sirupsen/logrus"
+)
+
+// EdgeviewOptions defines edgeview session to start on EVE
+type EdgeviewOptions struct {
+	// Dispatcher is endpoint of edgeview dispatcher reachable from EVE and from the host
+	Dispatcher string
+	// DispatcherCert is path to certificate of the dispatcher if it is not signed by well-known CA
+	DispatcherCert string
+	Expire         time.Duration
+	Instances      uint8
+	Encrypt        bool
+	// AllowApp allows access to apps and AllowExt to external endpoints of EVE
+	AllowApp bool
+	AllowExt bool
+}
+
+// EdgeviewStart signs token of edgeview session with signing key of the controller
+// and sends edgeview config to EVE
+func (openEVEC *OpenEVEC) EdgeviewStart(opts EdgeviewOptions) error {
+	if opts.Dispatcher == "" {
+		return fmt.Errorf("no dispatcher provided")
+	}
+	changer := &adamChanger{}
+	ctrl, dev, err := changer.getControllerAndDevFromConfig(openEVEC.cfg)
+	if err != nil {
+		return fmt.Errorf("getControllerAndDevFromConfig: %w", err)
+	}
+	edenHome, err := utils.DefaultEdenDir()
+	if err != nil {
+		return fmt.Errorf("DefaultEdenDir: %w", err)
+	}
+	keyPath := filepath.Join(edenHome, defaults.DefaultCertsDist, "signing-key.pem")
+	keyPEM, err := os.ReadFile(keyPath)
+	if err != nil {
+		return fmt.Errorf("cannot read %s: %w", keyPath, err)
+	}
+	key, err := utils.ParsePrivateKeyPEM(keyPEM)
+	if err != nil {
+		return fmt.Errorf("cannot parse %s: %w", keyPath, err)
+	}
+	token, err := edgeview.NewToken(opts.Dispatcher, dev.GetID().String(), opts.Expire, opts.Instances, opts.Encrypt)
+	if err != nil {
+		return fmt.Errorf("cannot create token: %w", err)
+	}
+	jwt, err := token.Sign(key)
+	if err != nil {
+		return fmt.Errorf("cannot sign token: %w", err)
+	}
+	edgeViewConfig := &config.EdgeViewConfig{
+		Token:     jwt,
+		DevPolicy: &config.DevDebugAccessPolicy{AllowDev: true},
+		AppPolicy: &config.AppDebugAccessPolicy{AllowApp: opts.AllowApp},
+		ExtPolicy: &config.ExternalEndPointPolicy{AllowExt: opts.AllowExt},
+	}
+	if opts.DispatcherCert != "" {
+		cert, err := os.ReadFile(opts.DispatcherCert)
+		if err != nil {
+			return fmt.Errorf("cannot read certificate of dispatcher: %w", err)
+		}
+		edgeViewConfig.DispCertPem = [][]byte{cert}
+	}
+	// EVE restarts edgeview only if generation changes
+	if old := dev.GetEdgeViewConfig(); old != nil {
+		edgeViewConfig.GenerationId = old.GetGenerationId() + 1
+	}
+	dev.SetEdgeViewConfig(edgeViewConfig)
+	if err = changer.setControllerAndDev(ctrl, dev); err != nil {
+		return fmt.Errorf("setControllerAndDev: %w", err)
+	}
+	log.Infof("edgeview session through %s started, expires at %s", opts.Dispatcher,
+		token.ExpireTime().Format(time.RFC3339))
+	return nil
+}
+
+// EdgeviewStop removes edgeview config from EVE
+func (openEVEC *OpenEVEC) EdgeviewStop() error {
+	changer := &adamChanger{}
+	ctrl, dev, err := changer.getControllerAndDevFromConfig(openEVEC.cfg)
+	if err != nil {
+		return fmt.Errorf("getControllerAndDevFromConfig: %w", err)
+	}
+	if dev.GetEdgeViewConfig() == nil {
+		log.Info("edgeview is not started")
+		return nil
+	}
+	dev.SetEdgeViewConfig(nil)
+	if err = changer.setControllerAndDev(ctrl, dev); err != nil {
+		return fmt.Errorf("setControllerAndDev: %w", err)
+	}
+	log.Info("edgeview session stopped")
+	return nil
+}
+
+// EdgeviewToken returns token of the current edgeview session
+func (openEVEC *OpenEVEC) EdgeviewToken() (string, error) {
+	changer := &adamChanger{}
+	_, dev, err := changer.getControllerAndDevFromConfig(openEVEC.cfg)
+	if err != nil {
+		return "", fmt.Errorf("getControllerAndDevFromConfig: %w", err)
+	}
+	jwt := dev.GetEdgeViewConfig().GetToken()
+	if jwt == "" {
+		return "", fmt.Errorf("edgeview is not started, run 'eden edgeview start' first")
+	}
+	token, err := edgeview.ParseToken(jwt)
+	if err != nil {
+		return "", err
+	}
+	if token.ExpireTime().Before(time.Now()) {
+		return "", fmt.Errorf("token of edgeview session expired at %s", token.ExpireTime().Format(time.RFC3339))
+	}
+	return jwt, nil
+}
+
+// EdgeviewQuery runs query with edgeview client and prints its output
+func (openEVEC *OpenEVEC) EdgeviewQuery(image string, query []string, outputFormat types.OutputFormat) error {
+	jwt, err := openEVEC.EdgeviewToken()
+	if err != nil {
+		return err
+	}
+	client := &edgeview.Client{Image: image, Token: jwt}
+	switch outputFormat {
+	case types.OutputFormatLines:
+		return client.Run(context.Background(), query, os.Stdout)
+	case types.OutputFormatJSON:
+		var buf bytes.Buffer
+		runErr := client.Run(context.Background(), query, &buf)
+		result := struct {
+			Query  string `json:"query"`
+			Output string `json:"output"`
+			Error  string `json:"error,omitempty"`
+		}{Query: strings.Join(query, " "), Output: buf.String()}
+		if runErr != nil {
+			result.Error = runErr.Error()
+		}
+		data, err := json.MarshalIndent(result, "", "    ")
+		if err != nil {
+			return fmt.Errorf("cannot marshal: %w", err)
+		}
+		fmt.Println(string(data))
+		return runErr
+	}
+	return fmt.Errorf("unimplemented output format")
+}
+
+// EdgeviewConsole forwards VNC console of app to local port until interrupted
+func (openEVEC *OpenEVEC) EdgeviewConsole(image, appName string) error {
+	jwt, err := openEVEC.EdgeviewToken()
+	if err != nil {
+		return err
+	}
+	changer := &adamChanger{}
+	ctrl, dev, err := changer.getControllerAndDevFromConfig(openEVEC.cfg)
+	if err != nil {
+		return fmt.Errorf("getControllerAndDevFromConfig: %w", err)
+	}
+	for _, el := range dev.GetApplicationInstances() {
+		app, err := ctrl.GetApplicationInstanceConfig(el)
+		if err != nil {
+			return fmt.Errorf("no app in cloud %s: %w", el, err)
+		}
+		if app.Displayname != appName {
+			continue
+		}
+		if !app.GetFixedresources().GetEnableVnc() {
+			return fmt.Errorf("VNC is not enabled for app %s, deploy it with --vnc-display", appName)
+		}
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+		addr := edgeview.VNCAddr(app.GetFixedresources().GetVncDisplay())
+		log.Infof("Console of app %s (%s on EVE) is forwarded to localhost:%d, press Ctrl+C to stop",
+			appName, addr, edgeview.ClientPort)
+		client := &edgeview.Client{Image: image, Token: jwt}
+		return client.Run(ctx, []string{edgeview.TCPQuery(addr)}, os.Stdout)
+	}
+	return fmt.Errorf("app %s not found", appName)
+}
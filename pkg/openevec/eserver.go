@@ -0,0 +1,49 @@
+package openevec
+
+import (
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+
+	"github.com/lf-edge/eden/pkg/eden"
+	log "github.com/sirupsen/logrus"
+)
+
+// EServerRotateCerts generates new certificate of eserver served over TLS and updates
+// certificates of eserver datastores in config of device. eserver reloads certificate on change.
+func (openEVEC *OpenEVEC) EServerRotateCerts() error {
+	cfg := openEVEC.cfg
+	if !cfg.Eden.EServer.TLS {
+		return fmt.Errorf("eserver is not served over TLS, set eden.eserver.tls")
+	}
+	if err := eden.GenerateEServerCerts([]string{cfg.Eden.EServer.IP, cfg.Adam.CertsEVEIP}, cfg.Adam.CertsDomain, true); err != nil {
+		return fmt.Errorf("cannot generate eserver certs: %w", err)
+	}
+	certs, err := eden.EServerCertsPEM()
+	if err != nil {
+		return fmt.Errorf("cannot read eserver certs: %w", err)
+	}
+	log.Info("eserver certificate rotated")
+	changer := &adamChanger{}
+	ctrl, dev, err := changer.getControllerAndDevFromConfig(cfg)
+	if err != nil {
+		return fmt.Errorf("getControllerAndDevFromConfig: %w", err)
+	}
+	eserverURL := "https://" + net.JoinHostPort(cfg.Adam.CertsDomain, strconv.Itoa(cfg.Eden.EServer.Port))
+	updated := 0
+	for _, ds := range ctrl.ListDataStore() {
+		if strings.HasPrefix(ds.Fqdn, eserverURL) && len(ds.DsCertPEM) > 0 {
+			ds.DsCertPEM = certs
+			updated++
+		}
+	}
+	if updated == 0 {
+		return nil
+	}
+	if err = changer.setControllerAndDev(ctrl, dev); err != nil {
+		return fmt.Errorf("setControllerAndDev: %w", err)
+	}
+	log.Infof("certificates of %d datastores updated", updated)
+	return nil
+}
@@ -1,6 +1,7 @@
 package openevec
 
 import (
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -28,25 +29,47 @@ func (openEVEC *OpenEVEC) StartEve(vmName, tapInterface string) error {
 		return nil
 	}
 
-	switch {
-	case cfg.Eve.DevModel == defaults.DefaultParallelsModel:
-		if err := eden.StartEVEParallels(vmName, cfg.Eve.ImageFile, cfg.Eve.QemuCpus, cfg.Eve.QemuMemory, cfg.Eve.HostFwd); err != nil {
-			return fmt.Errorf("cannot start eve: %w", err)
-		} else {
-			log.Infof("EVE is starting in Parallels")
+	if driver, ok := eden.GetVMDriver(cfg.Eve.DevModel); ok {
+		vm, err := openEVEC.vmConfig(driver, vmName)
+		if err != nil {
+			return err
 		}
-	case cfg.Eve.DevModel == defaults.DefaultVBoxModel:
-		if err := eden.StartEVEVBox(vmName, cfg.Eve.ImageFile, cfg.Eve.QemuCpus, cfg.Eve.QemuMemory, cfg.Eve.HostFwd); err != nil {
+		if err := driver.Start(vm); err != nil {
 			return fmt.Errorf("cannot start eve: %w", err)
-		} else {
-			log.Infof("EVE is starting in Virtual Box")
 		}
-	default:
-		if err := openEVEC.StartEveQemu(tapInterface); err != nil {
-			return err
+		log.Infof("EVE is starting in %s", driver.Name())
+		return nil
+	}
+	return openEVEC.StartEveQemu(tapInterface)
+}
+
+// vmConfig returns description of EVE VM to run with driver of hypervisor
+func (openEVEC *OpenEVEC) vmConfig(driver eden.VMDriver, vmName string) (eden.VMConfig, error) {
+	cfg := openEVEC.cfg
+	vm := eden.VMConfig{
+		Name:       vmName,
+		ImageFile:  utils.ResolveAbsPath(cfg.Eve.ImageFile),
+		CPUs:       cfg.Eve.QemuCpus,
+		MemoryMB:   cfg.Eve.QemuMemory,
+		HostFwd:    cfg.Eve.HostFwd,
+		TelnetPort: cfg.Eve.TelnetPort,
+	}
+	for ind := 0; ind < cfg.Eve.Disks; ind++ {
+		diskFile := filepath.Join(filepath.Dir(vm.ImageFile), fmt.Sprintf("eve-disk-%d.%s", ind+1, driver.DiskFormat()))
+		if _, err := os.Stat(diskFile); os.IsNotExist(err) {
+			if err := utils.CreateDisk(diskFile, driver.DiskFormat(), uint64(cfg.Eve.ImageSizeMB*1024*1024)); err != nil {
+				return vm, err
+			}
 		}
+		vm.Disks = append(vm.Disks, diskFile)
 	}
-	return nil
+	if cfg.Eve.UsbNetConfFile != "" {
+		vm.USBImage = filepath.Join(filepath.Dir(vm.ImageFile), "usb.img")
+		if err := utils.CreateUsbNetConfImg(cfg.Eve.UsbNetConfFile, vm.USBImage); err != nil {
+			return vm, err
+		}
+	}
+	return vm, nil
 }
 
 func (openEVEC *OpenEVEC) StartEveQemu(tapInterface string) error {
@@ -54,8 +77,10 @@ func (openEVEC *OpenEVEC) StartEveQemu(tapInterface string) error {
 	// Load network model and prepare SDN config.
 	var err error
 	var netModel sdnapi.NetworkModel
-	if !isSdnEnabled(cfg.Sdn.Disable, cfg.Eve.Remote, cfg.Eve.DevModel) || cfg.Sdn.NetModelFile == "" {
-		netModel, err = edensdn.GetDefaultNetModel()
+	if openEVEC.resume != nil && openEVEC.resume.NetModel != nil {
+		netModel = *openEVEC.resume.NetModel
+	} else if !isSdnEnabled(cfg.Sdn.Disable, cfg.Eve.Remote, cfg.Eve.DevModel) || cfg.Sdn.NetModelFile == "" {
+		netModel, err = defaultSdnNetModel(cfg)
 		if err != nil {
 			return err
 		}
@@ -75,9 +100,22 @@ func (openEVEC *OpenEVEC) StartEveQemu(tapInterface string) error {
 		netModel.Host.ControllerPort = 443
 	}
 	if isSdnEnabled(cfg.Sdn.Disable, cfg.Eve.Remote, cfg.Eve.DevModel) {
-		nets, err := utils.GetSubnetsNotUsed(1)
-		if err != nil {
-			return fmt.Errorf("failed to get unused IP subnet: %w", err)
+		var mgmtSubnet *edensdn.SdnMgmtSubnet
+		if openEVEC.resume != nil {
+			// reuse management subnet to keep SDN addressing the same
+			if mgmtSubnet, err = openEVEC.resume.MgmtSubnet(); err != nil {
+				return err
+			}
+		}
+		if mgmtSubnet == nil {
+			nets, err := utils.GetSubnetsNotUsed(1)
+			if err != nil {
+				return fmt.Errorf("failed to get unused IP subnet: %w", err)
+			}
+			mgmtSubnet = &edensdn.SdnMgmtSubnet{
+				IPNet:     nets[0].Subnet,
+				DHCPStart: nets[0].FirstAddress,
+			}
 		}
 		imageDir := filepath.Dir(cfg.Sdn.ImageFile)
 		firmware := []string{"OVMF_CODE.fd", "OVMF_VARS.fd"}
@@ -86,23 +124,20 @@ func (openEVEC *OpenEVEC) StartEveQemu(tapInterface string) error {
 				filepath.Join(imageDir, "firmware", firmware[i]))
 		}
 		sdnConfig := edensdn.SdnVMConfig{
-			Architecture: cfg.Eve.Arch,
-			Acceleration: cfg.Eve.Accel,
-			HostOS:       cfg.Eve.QemuOS,
-			ImagePath:    cfg.Sdn.ImageFile,
-			ConfigDir:    cfg.Sdn.ConfigDir,
-			CPU:          cfg.Sdn.CPU,
-			RAM:          cfg.Sdn.RAM,
-			Firmware:     firmware,
-			NetModel:     netModel,
-			TelnetPort:   uint16(cfg.Sdn.TelnetPort),
-			SSHPort:      uint16(cfg.Sdn.SSHPort),
-			SSHKeyPath:   sdnSSHKeyPath(cfg.Sdn.SourceDir),
-			MgmtPort:     uint16(cfg.Sdn.MgmtPort),
-			MgmtSubnet: edensdn.SdnMgmtSubnet{
-				IPNet:     nets[0].Subnet,
-				DHCPStart: nets[0].FirstAddress,
-			},
+			Architecture:   cfg.Eve.Arch,
+			Acceleration:   cfg.Eve.Accel,
+			HostOS:         cfg.Eve.QemuOS,
+			ImagePath:      cfg.Sdn.ImageFile,
+			ConfigDir:      cfg.Sdn.ConfigDir,
+			CPU:            cfg.Sdn.CPU,
+			RAM:            cfg.Sdn.RAM,
+			Firmware:       firmware,
+			NetModel:       netModel,
+			TelnetPort:     uint16(cfg.Sdn.TelnetPort),
+			SSHPort:        uint16(cfg.Sdn.SSHPort),
+			SSHKeyPath:     sdnSSHKeyPath(cfg.Sdn.SourceDir),
+			MgmtPort:       uint16(cfg.Sdn.MgmtPort),
+			MgmtSubnet:     *mgmtSubnet,
 			NetDevBasePort: uint16(cfg.Eve.QemuConfig.NetDevSocketPort),
 			PidFile:        cfg.Sdn.PidFile,
 			ConsoleLogFile: cfg.Sdn.ConsoleLogFile,
@@ -137,6 +172,12 @@ func (openEVEC *OpenEVEC) StartEveQemu(tapInterface string) error {
 			return fmt.Errorf("failed to apply network model: %w", err)
 		}
 		log.Infof("SDN started, network model was submitted.")
+		if err = updateResumeState(func(state *resumeState) {
+			state.NetModel = &netModel
+			state.SetMgmtSubnet(mgmtSubnet)
+		}); err != nil {
+			log.Warnf("cannot save state of SDN: %s", err)
+		}
 	}
 	// Create USB network config override image if requested.
 	var usbImagePath string
@@ -162,20 +203,48 @@ func (openEVEC *OpenEVEC) StartEveQemu(tapInterface string) error {
 	}
 	// Start vTPM.
 	if cfg.Eve.TPM {
-		err = eden.StartSWTPM(filepath.Join(filepath.Dir(imageFile), "swtpm"))
+		// EVE started without TPM onboards with software keys, so fail here
+		// instead of running tests of TPM against it
+		if err = eden.StartSWTPM(filepath.Join(filepath.Dir(imageFile), "swtpm")); err != nil {
+			return fmt.Errorf("cannot start swtpm: %w", err)
+		}
+		log.Infof("swtpm is started")
+	}
+	// Connect nodes of multi-node EVE into shared network.
+	var clusterNet, clusterMAC string
+	if cfg.Eve.Nodes > 1 {
+		clusterNet, clusterMAC = defaults.DefaultClusterNetMcast, clusterNetMAC(cfg)
+	}
+	pciPassthrough, err := qemuPCIPassthrough(cfg)
+	if err != nil {
+		return err
+	}
+	// Start emulator of cellular modem connected to the SDN.
+	var cellularSocket string
+	if cfg.Eve.Cellular.Enabled {
+		idx := edensdn.CellularPortIndex(netModel)
+		if idx < 0 {
+			return errors.New("cellular modem requires SDN with cellular network")
+		}
+		cellularSocket, err = startCellularModem(cfg, cfg.Eve.QemuConfig.NetDevSocketPort+idx,
+			netModel.Ports[idx].EVEConnect.MAC)
 		if err != nil {
-			log.Errorf("cannot start swtpm: %s", err.Error())
-		} else {
-			log.Infof("swtpm is starting")
+			return fmt.Errorf("cannot start cellular modem: %w", err)
 		}
+		log.Infof("cellular modem is started")
 	}
+	consoleOffset := consoleLogOffset(cfg)
 	// Start EVE VM.
 	if err = eden.StartEVEQemu(cfg.Eve.Arch, cfg.Eve.QemuOS, imageFile, imageFormat, isInstaller, cfg.Eve.Serial, cfg.Eve.TelnetPort,
 		cfg.Eve.QemuConfig.MonitorPort, cfg.Eve.QemuConfig.NetDevSocketPort, cfg.Eve.HostFwd, cfg.Eve.Accel, cfg.Eve.QemuFileToSave, cfg.Eve.Log,
-		cfg.Eve.Pid, netModel, isSdnEnabled(cfg.Sdn.Disable, cfg.Eve.Remote, cfg.Eve.DevModel), tapInterface, usbImagePath, cfg.Eve.TPM, false); err != nil {
+		cfg.Eve.Pid, netModel, isSdnEnabled(cfg.Sdn.Disable, cfg.Eve.Remote, cfg.Eve.DevModel), tapInterface, clusterNet, clusterMAC, usbImagePath,
+		cellularSocket, pciPassthrough, cfg.Eve.TPM, false); err != nil {
 		log.Errorf("cannot start eve: %s", err.Error())
 	} else {
 		log.Infof("EVE is starting")
+		if err := startConsoleCapture(cfg, consoleOffset); err != nil {
+			log.Errorf("cannot start capture of console: %s", err)
+		}
 	}
 	return nil
 }
@@ -186,17 +255,11 @@ func (openEVEC *OpenEVEC) StopEve(vmName string) error {
 		log.Debug("Cannot stop remote EVE")
 		return nil
 	}
-	if cfg.Eve.DevModel == defaults.DefaultVBoxModel {
-		if err := eden.StopEVEVBox(vmName); err != nil {
+	if driver, ok := eden.GetVMDriver(cfg.Eve.DevModel); ok {
+		if err := driver.Stop(vmName); err != nil {
 			log.Errorf("cannot stop eve: %s", err.Error())
 		} else {
-			log.Infof("EVE is stopping in Virtual Box")
-		}
-	} else if cfg.Eve.DevModel == defaults.DefaultParallelsModel {
-		if err := eden.StopEVEParallels(vmName); err != nil {
-			log.Errorf("cannot stop eve: %s", err.Error())
-		} else {
-			log.Infof("EVE is stopping in Virtual Box")
+			log.Infof("EVE is stopping in %s", driver.Name())
 		}
 	} else {
 		if err := eden.StopEVEQemu(cfg.Eve.Pid); err != nil {
@@ -212,6 +275,13 @@ func (openEVEC *OpenEVEC) StopEve(vmName string) error {
 				log.Infof("swtpm is stopping")
 			}
 		}
+		if cfg.Eve.Cellular.Enabled {
+			if err := eden.StopCellularModem(cellularStateDir(cfg)); err != nil {
+				log.Errorf("cannot stop cellular modem: %s", err.Error())
+			} else {
+				log.Infof("cellular modem is stopping")
+			}
+		}
 	}
 	eden.StopSDN(cfg.Eve.DevModel, cfg.Sdn.PidFile)
 	return nil
@@ -244,21 +314,18 @@ func (openEVEC *OpenEVEC) VersionEve() error {
 	return nil
 }
 
-func (openEVEC *OpenEVEC) StatusEve(vmName string) error {
+func (openEVEC *OpenEVEC) StatusEve(vmName string, storage StorageStatusOptions) error {
 	cfg := openEVEC.cfg
 	statusAdam, err := eden.StatusAdam()
 	if err == nil && statusAdam != "container doesn't exist" {
-		if err := openEVEC.eveStatusRemote(); err != nil {
+		if err := openEVEC.eveStatusRemote(storage); err != nil {
 			return err
 		}
 	}
 	if !cfg.Eve.Remote {
-		switch {
-		case cfg.Eve.DevModel == defaults.DefaultVBoxModel:
-			openEVEC.eveStatusVBox(vmName)
-		case cfg.Eve.DevModel == defaults.DefaultParallelsModel:
-			openEVEC.eveStatusParallels(vmName)
-		default:
+		if driver, ok := eden.GetVMDriver(cfg.Eve.DevModel); ok {
+			openEVEC.eveStatusVM(driver, vmName)
+		} else {
 			openEVEC.eveStatusQEMU(cfg.ConfigName, cfg.Eve.Pid)
 		}
 	}
@@ -326,11 +393,19 @@ func (openEVEC *OpenEVEC) eveLastRequests() (string, error) {
 	return strings.Split(lastRequest.ClientIP, ":")[0], nil
 }
 
-func (openEVEC *OpenEVEC) ConsoleEve(host string) error {
+func (openEVEC *OpenEVEC) ConsoleEve(host, vmName string) error {
 	cfg := openEVEC.cfg
 	if cfg.Eve.Remote {
 		return fmt.Errorf("cannot telnet to remote EVE")
 	}
+	if driver, ok := eden.GetVMDriver(cfg.Eve.DevModel); ok {
+		// console of VM of hypervisor is available only locally
+		vm := eden.VMConfig{Name: vmName, TelnetPort: cfg.Eve.TelnetPort}
+		if err := driver.Console(vm); err != nil {
+			return fmt.Errorf("console of %s: %w", driver.Name(), err)
+		}
+		return nil
+	}
 	log.Infof("Try to telnet %s:%d", host, cfg.Eve.TelnetPort)
 	if err := utils.RunCommandForeground("telnet", strings.Fields(fmt.Sprintf("%s %d", host, cfg.Eve.TelnetPort))...); err != nil {
 		return fmt.Errorf("telnet error: %w", err)
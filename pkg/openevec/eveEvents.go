@@ -0,0 +1,31 @@
+package openevec
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/lf-edge/eden/pkg/controller/einfo"
+	"github.com/lf-edge/eden/pkg/eve"
+)
+
+// EveEvents prints changes of EVE state (of types, all if empty) detected in new info
+// messages, the state is loaded from the last info messages first
+func (openEVEC *OpenEVEC) EveEvents(types []eve.EventType, timeout time.Duration) error {
+	changer := &adamChanger{}
+	ctrl, dev, err := changer.getControllerAndDevFromConfig(openEVEC.cfg)
+	if err != nil {
+		return fmt.Errorf("getControllerAndDevFromConfig: %w", err)
+	}
+	state := eve.Init(ctrl, dev)
+	if err = ctrl.InfoLastCallback(dev.GetID(), nil, state.InfoCallback()); err != nil {
+		return fmt.Errorf("fail in get InfoLastCallback: %w", err)
+	}
+	unsubscribe := state.Subscribe(func(e eve.Event) {
+		fmt.Printf("%s\t%s\n", e.Time.Format(time.RFC3339), e)
+	}, types...)
+	defer unsubscribe()
+	if err = ctrl.InfoChecker(dev.GetID(), nil, state.InfoCallback(), einfo.InfoNew, timeout); err != nil {
+		return fmt.Errorf("InfoChecker: %w", err)
+	}
+	return nil
+}
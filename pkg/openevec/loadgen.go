@@ -0,0 +1,210 @@
+package openevec
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/dustin/go-humanize"
+	"github.com/lf-edge/eden/pkg/controller"
+	"github.com/lf-edge/eden/pkg/controller/eapps"
+	"github.com/lf-edge/eden/pkg/defaults"
+	"github.com/lf-edge/eden/pkg/device"
+	"github.com/lf-edge/eden/pkg/eve"
+	"github.com/lf-edge/eden/pkg/loadgen"
+	"github.com/lf-edge/eve-api/go/config"
+	"github.com/lf-edge/eve-api/go/logs"
+	uuid "github.com/satori/go.uuid"
+	log "github.com/sirupsen/logrus"
+)
+
+// findApp returns config of app with provided name
+func findApp(ctrl controller.Cloud, dev *device.Ctx, appName string) (*config.AppInstanceConfig, error) {
+	for _, el := range dev.GetApplicationInstances() {
+		app, err := ctrl.GetApplicationInstanceConfig(el)
+		if err != nil {
+			return nil, fmt.Errorf("no app in cloud %s: %w", el, err)
+		}
+		if app.Displayname == appName {
+			return app, nil
+		}
+	}
+	return nil, fmt.Errorf("not found app with name %s", appName)
+}
+
+// loadgenApps returns configs of generators deployed with provided name
+func loadgenApps(ctrl controller.Cloud, dev *device.Ctx, name string) ([]*config.AppInstanceConfig, error) {
+	re := regexp.MustCompile(fmt.Sprintf(`^%s-\d+$`, regexp.QuoteMeta(name)))
+	var apps []*config.AppInstanceConfig
+	for _, el := range dev.GetApplicationInstances() {
+		app, err := ctrl.GetApplicationInstanceConfig(el)
+		if err != nil {
+			return nil, fmt.Errorf("no app in cloud %s: %w", el, err)
+		}
+		if re.MatchString(app.Displayname) {
+			apps = append(apps, app)
+		}
+	}
+	return apps, nil
+}
+
+// loadgenTarget returns name of network instance and IP of target app on it.
+// The first network of target is used if network is not provided.
+func loadgenTarget(ctrl controller.Cloud, dev *device.Ctx, target, network string) (string, string, error) {
+	app, err := findApp(ctrl, dev, target)
+	if err != nil {
+		return "", "", err
+	}
+	index := -1
+	for i, intf := range app.Interfaces {
+		ni, err := ctrl.GetNetworkInstanceConfig(intf.NetworkId)
+		if err != nil {
+			return "", "", fmt.Errorf("no network in cloud %s: %w", intf.NetworkId, err)
+		}
+		if network == "" || ni.Displayname == network {
+			index, network = i, ni.Displayname
+			break
+		}
+	}
+	if index < 0 {
+		if network == "" {
+			return "", "", fmt.Errorf("app %s has no networks", target)
+		}
+		return "", "", fmt.Errorf("app %s is not connected to network %s", target, network)
+	}
+	state := eve.Init(ctrl, dev)
+	if err := ctrl.InfoLastCallback(dev.GetID(), nil, state.InfoCallback()); err != nil {
+		return "", "", fmt.Errorf("fail in get InfoLastCallback: %w", err)
+	}
+	for _, appState := range state.Applications() {
+		if appState.Name != target {
+			continue
+		}
+		if index >= len(appState.InternalIP) || appState.InternalIP[index] == "-" {
+			return "", "", fmt.Errorf("app %s has no IP on network %s yet", target, network)
+		}
+		return network, appState.InternalIP[index], nil
+	}
+	return "", "", fmt.Errorf("no state of app %s yet", target)
+}
+
+// LoadgenStart deploys generators of traffic defined by profile against target app
+func (openEVEC *OpenEVEC) LoadgenStart(target, profileName string, lc LoadgenConfig, cfg *EdenSetupArgs) error {
+	profile, err := loadgen.GetProfile(profileName)
+	if err != nil {
+		return err
+	}
+	if lc.Count < 1 {
+		return fmt.Errorf("count of generators must be positive")
+	}
+	changer := &adamChanger{}
+	ctrl, dev, err := changer.getControllerAndDevFromConfig(openEVEC.cfg)
+	if err != nil {
+		return fmt.Errorf("getControllerAndDevFromConfig: %w", err)
+	}
+	apps, err := loadgenApps(ctrl, dev, lc.Name)
+	if err != nil {
+		return err
+	}
+	if len(apps) > 0 {
+		return fmt.Errorf("generators %s are already deployed, stop them first", lc.Name)
+	}
+	network, targetIP, err := loadgenTarget(ctrl, dev, target, lc.Network)
+	if err != nil {
+		return err
+	}
+	appLink := fmt.Sprintf("docker://%s:%s", cfg.Eden.EClient.Image, cfg.Eden.EClient.Tag)
+	for i := 1; i <= lc.Count; i++ {
+		pc := PodConfig{
+			Name:       fmt.Sprintf("%s-%d", lc.Name, i),
+			Metadata:   profile.Metadata(targetIP, lc.Port, lc.Duration),
+			Registry:   "remote",
+			Networks:   []string{network},
+			DiskSize:   humanize.Bytes(0),
+			VolumeSize: humanize.IBytes(defaults.DefaultVolumeSize),
+			AppMemory:  humanize.Bytes(defaults.DefaultAppMem * 1024),
+			VolumeType: "qcow2",
+			AppCpus:    defaults.DefaultAppCPU,
+			DirectLoad: true,
+		}
+		if err := openEVEC.PodDeploy(appLink, pc, cfg); err != nil {
+			return fmt.Errorf("cannot deploy generator %s: %w", pc.Name, err)
+		}
+	}
+	log.Infof("%d generators of %s started against %s (%s) on network %s", lc.Count, profile.Name, target, targetIP, network)
+	return nil
+}
+
+// LoadgenResults prints reports of generators and their aggregated result
+func (openEVEC *OpenEVEC) LoadgenResults(name string) error {
+	changer := &adamChanger{}
+	ctrl, dev, err := changer.getControllerAndDevFromConfig(openEVEC.cfg)
+	if err != nil {
+		return fmt.Errorf("getControllerAndDevFromConfig: %w", err)
+	}
+	apps, err := loadgenApps(ctrl, dev, name)
+	if err != nil {
+		return err
+	}
+	if len(apps) == 0 {
+		return fmt.Errorf("no generators with name %s", name)
+	}
+	var results []*loadgen.Result
+	for _, app := range apps {
+		appID, err := uuid.FromString(app.Uuidandversion.Uuid)
+		if err != nil {
+			return err
+		}
+		var result *loadgen.Result
+		var resultErr error
+		handler := func(le *logs.LogEntry) bool {
+			if !strings.Contains(le.Content, loadgen.ResultPrefix) {
+				return false
+			}
+			// keep the latest report
+			result, resultErr = loadgen.ParseResult(le.Content)
+			return false
+		}
+		if err = ctrl.LogAppsChecker(dev.GetID(), appID, nil, handler, eapps.LogExist, 0); err != nil {
+			return fmt.Errorf("LogAppsChecker: %w", err)
+		}
+		switch {
+		case resultErr != nil:
+			fmt.Printf("%s: %s\n", app.Displayname, resultErr)
+		case result == nil:
+			fmt.Printf("%s: no report yet\n", app.Displayname)
+		default:
+			result.Generator = app.Displayname
+			fmt.Println(result)
+			results = append(results, result)
+		}
+	}
+	if len(results) > 1 {
+		total := loadgen.Aggregate(results)
+		total.Generator = fmt.Sprintf("total (%d of %d)", len(results), len(apps))
+		fmt.Println(total)
+	}
+	return nil
+}
+
+// LoadgenStop deletes generators with provided name
+func (openEVEC *OpenEVEC) LoadgenStop(name string) error {
+	changer := &adamChanger{}
+	ctrl, dev, err := changer.getControllerAndDevFromConfig(openEVEC.cfg)
+	if err != nil {
+		return fmt.Errorf("getControllerAndDevFromConfig: %w", err)
+	}
+	apps, err := loadgenApps(ctrl, dev, name)
+	if err != nil {
+		return err
+	}
+	if len(apps) == 0 {
+		return fmt.Errorf("no generators with name %s", name)
+	}
+	for _, app := range apps {
+		if _, err := openEVEC.PodDelete(app.Displayname, true); err != nil {
+			return fmt.Errorf("cannot delete generator %s: %w", app.Displayname, err)
+		}
+	}
+	return nil
+}
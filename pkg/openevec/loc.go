@@ -0,0 +1,186 @@
+package openevec
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"github.com/lf-edge/eden/pkg/defaults"
+	"github.com/lf-edge/eden/pkg/loc"
+	"github.com/lf-edge/eden/pkg/utils"
+	"github.com/lf-edge/eve-api/go/config"
+	log "github.com/sirupsen/logrus"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
+
+func locPidFile(cfg *EdenSetupArgs) string {
+	return filepath.Join(cfg.Eden.LOC.Dist, "loc.pid")
+}
+
+func locLogFile(cfg *EdenSetupArgs) string {
+	return filepath.Join(cfg.Eden.LOC.Dist, "loc.log")
+}
+
+// locURL returns URL of LOC for EVE, it is reachable with the same domain as the controller
+func locURL(cfg *EdenSetupArgs) string {
+	return "http://" + net.JoinHostPort(cfg.Adam.CertsDomain, strconv.Itoa(cfg.Eden.LOC.Port))
+}
+
+// LOCStart starts 'eden loc serve' in background to serve configs staged on LOC
+func (openEVEC *OpenEVEC) LOCStart() error {
+	cfg := openEVEC.cfg
+	command, err := os.Executable()
+	if err != nil {
+		return fmt.Errorf("cannot obtain executable path: %w", err)
+	}
+	if err := os.MkdirAll(cfg.Eden.LOC.Dist, 0755); err != nil {
+		return err
+	}
+	if err := utils.RunCommandNohup(command, locLogFile(cfg), locPidFile(cfg),
+		"loc", "serve",
+		"--dir", cfg.Eden.LOC.Dist,
+		"--port", strconv.Itoa(cfg.Eden.LOC.Port)); err != nil {
+		return fmt.Errorf("cannot start LOC: %w", err)
+	}
+	log.Infof("LOC is starting on port %d, logs are in %s", cfg.Eden.LOC.Port, locLogFile(cfg))
+	return nil
+}
+
+// LOCStop stops LOC if it is running
+func (openEVEC *OpenEVEC) LOCStop() error {
+	pidFile := locPidFile(openEVEC.cfg)
+	if status, _ := utils.StatusCommandWithPid(pidFile); status == "process doesn't exist" {
+		return nil
+	}
+	return utils.StopCommandWithPid(pidFile)
+}
+
+// LOCStatus prints status of LOC process and configs staged on it
+func (openEVEC *OpenEVEC) LOCStatus() error {
+	cfg := openEVEC.cfg
+	status, err := utils.StatusCommandWithPid(locPidFile(cfg))
+	if err != nil {
+		return fmt.Errorf("%s cannot obtain status of LOC: %w", statusWarn(), err)
+	}
+	fmt.Printf("%s LOC process status: %s\n", representProcessStatus(status), status)
+	fmt.Printf("\tLOC is expected at %s from EVE\n", locURL(cfg))
+	fmt.Printf("\tLogs for LOC at: %s\n", locLogFile(cfg))
+	devices, err := loc.StagedDevices(cfg.Eden.LOC.Dist)
+	if err != nil {
+		return err
+	}
+	for _, id := range devices {
+		staged, err := loc.Staged(cfg.Eden.LOC.Dist, id)
+		if err != nil {
+			return err
+		}
+		served, err := loc.LastServed(cfg.Eden.LOC.Dist, id)
+		if err != nil {
+			return err
+		}
+		lastServed := "never"
+		if !served.IsZero() {
+			lastServed = served.Local().Format(time.RFC3339)
+		}
+		fmt.Printf("\tConfig of %s: version %s, served to EVE: %s\n", id, staged.GetId().GetVersion(), lastServed)
+	}
+	return nil
+}
+
+// LOCServe serves configs staged in dir on port signed with the certificate of the controller
+func LOCServe(dir string, port int) error {
+	edenHome, err := utils.DefaultEdenDir()
+	if err != nil {
+		return err
+	}
+	certsDir := filepath.Join(edenHome, defaults.DefaultCertsDist)
+	server := &loc.Server{
+		Dir:         dir,
+		SigningCert: filepath.Join(certsDir, "signing.pem"),
+		SigningKey:  filepath.Join(certsDir, "signing-key.pem"),
+	}
+	return server.ListenAndServe(fmt.Sprintf(":%d", port))
+}
+
+// LOCStage stages config of EVE on LOC, the current config of EVE in the controller
+// if fileWithConfig is not set
+func (openEVEC *OpenEVEC) LOCStage(fileWithConfig string) error {
+	changer := &adamChanger{}
+	ctrl, dev, err := changer.getControllerAndDevFromConfig(openEVEC.cfg)
+	if err != nil {
+		return fmt.Errorf("getControllerAndDevFromConfig: %w", err)
+	}
+	var devConfig config.EdgeDevConfig
+	if fileWithConfig != "" {
+		data, err := os.ReadFile(fileWithConfig)
+		if err != nil {
+			return fmt.Errorf("file reading error: %w", err)
+		}
+		if err := protojson.Unmarshal(data, &devConfig); err != nil {
+			return fmt.Errorf("cannot unmarshal config: %w", err)
+		}
+		if devConfig.Id == nil {
+			devConfig.Id = &config.UUIDandVersion{}
+		}
+		if devConfig.Id.Uuid == "" {
+			devConfig.Id.Uuid = dev.GetID().String()
+		}
+		if devConfig.Id.Uuid != dev.GetID().String() {
+			return fmt.Errorf("config is for device %s, not for %s", devConfig.Id.Uuid, dev.GetID())
+		}
+	} else {
+		data, err := ctrl.GetConfigBytes(dev, false)
+		if err != nil {
+			return fmt.Errorf("GetConfigBytes: %w", err)
+		}
+		if err := proto.Unmarshal(data, &devConfig); err != nil {
+			return fmt.Errorf("cannot unmarshal config: %w", err)
+		}
+	}
+	if err := loc.Stage(openEVEC.cfg.Eden.LOC.Dist, &devConfig); err != nil {
+		return fmt.Errorf("cannot stage config: %w", err)
+	}
+	log.Infof("Config version %s of %s is staged on LOC", devConfig.GetId().GetVersion(), devConfig.GetId().GetUuid())
+	return nil
+}
+
+// LOCUnstage removes config of EVE from LOC
+func (openEVEC *OpenEVEC) LOCUnstage() error {
+	changer := &adamChanger{}
+	_, dev, err := changer.getControllerAndDevFromConfig(openEVEC.cfg)
+	if err != nil {
+		return fmt.Errorf("getControllerAndDevFromConfig: %w", err)
+	}
+	return loc.Unstage(openEVEC.cfg.Eden.LOC.Dist, dev.GetID().String())
+}
+
+// LOCEnable pushes URL of LOC to EVE with the config from the controller,
+// URL of LOC managed by eden is used if url is empty
+func (openEVEC *OpenEVEC) LOCEnable(url string) error {
+	if url == "" {
+		url = locURL(openEVEC.cfg)
+	}
+	return openEVEC.setLOCURL(url)
+}
+
+// LOCDisable removes URL of LOC from config of EVE
+func (openEVEC *OpenEVEC) LOCDisable() error {
+	return openEVEC.setLOCURL("")
+}
+
+func (openEVEC *OpenEVEC) setLOCURL(url string) error {
+	changer := &adamChanger{}
+	ctrl, dev, err := changer.getControllerAndDevFromConfig(openEVEC.cfg)
+	if err != nil {
+		return fmt.Errorf("getControllerAndDevFromConfig: %w", err)
+	}
+	dev.SetLocURL(url)
+	if err = changer.setControllerAndDev(ctrl, dev); err != nil {
+		return fmt.Errorf("setControllerAndDev: %w", err)
+	}
+	return nil
+}
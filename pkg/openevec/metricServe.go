@@ -0,0 +1,51 @@
+package openevec
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/lf-edge/eden/pkg/controller/emetric"
+	"github.com/lf-edge/eden/pkg/exporter"
+	"github.com/lf-edge/eve-api/go/metrics"
+	"github.com/prometheus/client_golang/prometheus"
+	log "github.com/sirupsen/logrus"
+)
+
+// EdenMetricServe serves the last metrics of EVE arriving at the controller, results of tests
+// from history and latencies of requests to the controller in Prometheus format on /metrics
+func (openEVEC *OpenEVEC) EdenMetricServe(listen, historyDB string, historyRuns int) error {
+	changer := &adamChanger{}
+	ctrl, dev, err := changer.getControllerAndDevFromConfig(openEVEC.cfg)
+	if err != nil {
+		return fmt.Errorf("getControllerAndDevFromConfig: %w", err)
+	}
+	deviceCollector := exporter.NewDeviceCollector()
+	collectors := []prometheus.Collector{deviceCollector}
+	db, err := openTestHistory(historyDB)
+	if err != nil {
+		log.Warnf("results of tests are not exported: %s", err)
+	} else {
+		defer db.Close()
+		collectors = append(collectors, exporter.NewTestCollector(db, historyRuns))
+	}
+	handler, err := exporter.Handler(collectors...)
+	if err != nil {
+		return fmt.Errorf("cannot register collectors: %w", err)
+	}
+	handleFunc := func(msg *metrics.ZMetricMsg) bool {
+		deviceCollector.Update(msg)
+		return false
+	}
+	if err = ctrl.MetricLastCallback(dev.GetID(), nil, handleFunc); err != nil {
+		return fmt.Errorf("MetricLastCallback: %w", err)
+	}
+	go func() {
+		if err := ctrl.MetricChecker(dev.GetID(), nil, handleFunc, emetric.MetricNew, 0); err != nil {
+			log.Errorf("MetricChecker: %s", err)
+		}
+	}()
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", handler)
+	log.Infof("Serving metrics on %s/metrics", listen)
+	return http.ListenAndServe(listen, mux)
+}
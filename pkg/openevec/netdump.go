@@ -0,0 +1,157 @@
+package openevec
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/lf-edge/eden/pkg/defaults"
+	"github.com/lf-edge/eden/pkg/edensdn"
+	"github.com/lf-edge/eden/pkg/utils"
+	log "github.com/sirupsen/logrus"
+)
+
+// NetDumpOptions defines capture of packets on interfaces of EVE
+type NetDumpOptions struct {
+	// Interfaces of EVE to capture on, all ports of SDN (or eth0 with OnEVE) if empty
+	Interfaces []string
+	Duration   time.Duration
+	// Filter in pcap-filter syntax, e.g. "port 53"
+	Filter    string
+	OutputDir string
+	// OnEVE runs tcpdump on EVE over SSH instead of SDN side of EVE ports
+	OnEVE bool
+}
+
+// NetDump captures packets on EVE interfaces and saves pcap files into the output
+// directory, it returns paths of the saved files
+func (openEVEC *OpenEVEC) NetDump(opts NetDumpOptions) ([]string, error) {
+	if opts.Duration <= 0 {
+		opts.Duration = defaults.DefaultNetDumpDuration
+	}
+	if err := os.MkdirAll(opts.OutputDir, 0755); err != nil {
+		return nil, fmt.Errorf("cannot create output directory: %w", err)
+	}
+	if opts.OnEVE {
+		return openEVEC.netDumpOnEVE(opts)
+	}
+	return openEVEC.netDumpOnSdn(opts)
+}
+
+func netDumpFile(outputDir, eveIfName, side string) string {
+	return filepath.Join(outputDir, fmt.Sprintf("%s-%s-%s.pcap",
+		eveIfName, side, time.Now().Format("20060102-150405")))
+}
+
+// netDumpOnSdn captures all packets of EVE ports as seen by SDN, it does not require
+// access to EVE and works even if EVE lost connectivity with the controller
+func (openEVEC *OpenEVEC) netDumpOnSdn(opts NetDumpOptions) ([]string, error) {
+	cfg := openEVEC.cfg
+	if !isSdnEnabled(cfg.Sdn.Disable, cfg.Eve.Remote, cfg.Eve.DevModel) {
+		return nil, fmt.Errorf("SDN is not enabled, use capture on EVE instead")
+	}
+	client := &edensdn.SdnClient{
+		SSHPort:    uint16(cfg.Sdn.SSHPort),
+		SSHKeyPath: sdnSSHKeyPath(cfg.Sdn.SourceDir),
+		MgmtPort:   uint16(cfg.Sdn.MgmtPort),
+	}
+	interfaces := opts.Interfaces
+	if len(interfaces) == 0 {
+		netModel, err := client.GetNetworkModel()
+		if err != nil {
+			return nil, fmt.Errorf("failed to get network model: %w", err)
+		}
+		for i := range netModel.Ports {
+			interfaces = append(interfaces, fmt.Sprintf("eth%d", i))
+		}
+	}
+	var (
+		wg    sync.WaitGroup
+		mu    sync.Mutex
+		files []string
+		errs  []string
+	)
+	for _, eveIfName := range interfaces {
+		sdnIfName, err := client.GetEveIfSdnSide(eveIfName)
+		if err != nil {
+			return nil, fmt.Errorf("cannot find SDN side of %s: %w", eveIfName, err)
+		}
+		wg.Add(1)
+		go func(eveIfName, sdnIfName string) {
+			defer wg.Done()
+			file := netDumpFile(opts.OutputDir, eveIfName, "sdn")
+			err := captureIntoFile(file, func(f *os.File) error {
+				return client.CapturePackets(sdnIfName, opts.Filter, opts.Duration, f)
+			})
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				errs = append(errs, fmt.Sprintf("%s: %s", eveIfName, err))
+				return
+			}
+			files = append(files, file)
+		}(eveIfName, sdnIfName)
+	}
+	log.Infof("Capturing packets on %s for %s", strings.Join(interfaces, ", "), opts.Duration)
+	wg.Wait()
+	if len(errs) > 0 {
+		return files, fmt.Errorf("capture failed: %s", strings.Join(errs, "; "))
+	}
+	return files, nil
+}
+
+func captureIntoFile(file string, capture func(f *os.File) error) error {
+	f, err := os.Create(file)
+	if err != nil {
+		return err
+	}
+	if err = capture(f); err != nil {
+		f.Close()
+		_ = os.Remove(file)
+		return err
+	}
+	return f.Close()
+}
+
+// netDumpOnEVE runs tcpdump on EVE over SSH, it sees also traffic of interfaces
+// not connected to SDN (e.g. of remote devices), files are copied with scp
+func (openEVEC *OpenEVEC) netDumpOnEVE(opts NetDumpOptions) ([]string, error) {
+	interfaces := opts.Interfaces
+	if len(interfaces) == 0 {
+		interfaces = []string{"eth0"}
+	}
+	seconds := int(opts.Duration.Round(time.Second).Seconds())
+	if seconds < 1 {
+		seconds = 1
+	}
+	var commands []string
+	for _, ifName := range interfaces {
+		command := fmt.Sprintf("timeout -s INT %d tcpdump -U -n -i %s -w %s", seconds, ifName,
+			netDumpEVEFile(ifName))
+		if opts.Filter != "" {
+			command += " " + utils.ShellQuote(opts.Filter)
+		}
+		commands = append(commands, command+" &")
+	}
+	commands = append(commands, "wait")
+	log.Infof("Capturing packets on EVE interfaces %s for %s", strings.Join(interfaces, ", "), opts.Duration)
+	if err := openEVEC.SSHEve(strings.Join(commands, " ")); err != nil {
+		return nil, fmt.Errorf("capture on EVE failed: %w", err)
+	}
+	var files []string
+	for _, ifName := range interfaces {
+		file := netDumpFile(opts.OutputDir, ifName, "eve")
+		if err := openEVEC.SdnForwardSCPFromEve(netDumpEVEFile(ifName), file); err != nil {
+			return files, fmt.Errorf("cannot copy capture of %s from EVE: %w", ifName, err)
+		}
+		files = append(files, file)
+	}
+	return files, nil
+}
+
+func netDumpEVEFile(ifName string) string {
+	return fmt.Sprintf("%s/eden-netdump-%s.pcap", defaults.DefaultNetDumpEVEDir, ifName)
+}
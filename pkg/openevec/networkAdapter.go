@@ -0,0 +1,498 @@
+package openevec
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/lf-edge/eden/pkg/controller"
+	"github.com/lf-edge/eden/pkg/defaults"
+	"github.com/lf-edge/eden/pkg/device"
+	"github.com/lf-edge/eden/pkg/edensdn"
+	sdnapi "github.com/lf-edge/eden/sdn/vm/api"
+	"github.com/lf-edge/eve-api/go/config"
+	uuid "github.com/satori/go.uuid"
+	log "github.com/sirupsen/logrus"
+)
+
+// maxIfNameLen is the limit of Linux for names of network interfaces
+const maxIfNameLen = 15
+
+// BondModes lists supported modes of bond adapter
+var BondModes = []string{"balance-rr", "active-backup", "balance-xor", "broadcast",
+	"802.3ad", "balance-tlb", "balance-alb"}
+
+// adapterNetworks maps IP modes of adapters to networks of devmodel
+var adapterNetworks = map[string]string{
+	"dhcp": defaults.NetDHCPID,
+	"none": defaults.NetSwitch,
+}
+
+// AdapterIPModes lists supported IP modes of system adapters created for VLANs
+var AdapterIPModes = []string{"dhcp", "none"}
+
+// networkAdapters is a snapshot of L2 port configuration of the device
+type networkAdapters struct {
+	physicalIOs    map[string]*config.PhysicalIO
+	vlans          map[string]*config.VlanAdapter
+	bonds          map[string]*config.BondAdapter
+	systemAdapters map[string]*config.SystemAdapter
+	// ids maps logical labels (names for system adapters) to ids in controller
+	ids map[string]string
+}
+
+func getNetworkAdapters(ctrl controller.Cloud, dev *device.Ctx) (*networkAdapters, error) {
+	adapters := &networkAdapters{
+		physicalIOs:    map[string]*config.PhysicalIO{},
+		vlans:          map[string]*config.VlanAdapter{},
+		bonds:          map[string]*config.BondAdapter{},
+		systemAdapters: map[string]*config.SystemAdapter{},
+		ids:            map[string]string{},
+	}
+	for _, id := range dev.GetPhysicalIOs() {
+		physicalIO, err := ctrl.GetPhysicalIO(id)
+		if err != nil {
+			return nil, fmt.Errorf("GetPhysicalIO: %w", err)
+		}
+		adapters.physicalIOs[physicalIO.Logicallabel] = physicalIO
+		adapters.ids[physicalIO.Logicallabel] = id
+	}
+	for _, id := range dev.GetVlanAdapters() {
+		vlan, err := ctrl.GetVlanAdapter(id)
+		if err != nil {
+			return nil, fmt.Errorf("GetVlanAdapter: %w", err)
+		}
+		adapters.vlans[vlan.Logicallabel] = vlan
+		adapters.ids[vlan.Logicallabel] = id
+	}
+	for _, id := range dev.GetBondAdapters() {
+		bond, err := ctrl.GetBondAdapter(id)
+		if err != nil {
+			return nil, fmt.Errorf("GetBondAdapter: %w", err)
+		}
+		adapters.bonds[bond.Logicallabel] = bond
+		adapters.ids[bond.Logicallabel] = id
+	}
+	for _, id := range dev.GetSystemAdapters() {
+		systemAdapter, err := ctrl.GetSystemAdapter(id)
+		if err != nil {
+			return nil, fmt.Errorf("GetSystemAdapter: %w", err)
+		}
+		adapters.systemAdapters[systemAdapter.Name] = systemAdapter
+		// system adapters share names with ports, keep them apart
+		adapters.ids["system:"+systemAdapter.Name] = id
+	}
+	return adapters, nil
+}
+
+func (adapters *networkAdapters) exists(label string) bool {
+	return adapters.physicalIOs[label] != nil || adapters.vlans[label] != nil || adapters.bonds[label] != nil
+}
+
+// ethPorts returns physical ports carrying traffic of the adapter with the label
+func (adapters *networkAdapters) ethPorts(label string) []string {
+	if vlan, ok := adapters.vlans[label]; ok {
+		return adapters.ethPorts(vlan.LowerLayerName)
+	}
+	if bond, ok := adapters.bonds[label]; ok {
+		var ports []string
+		for _, lowerLayer := range bond.LowerLayerNames {
+			ports = append(ports, adapters.ethPorts(lowerLayer)...)
+		}
+		return ports
+	}
+	return []string{label}
+}
+
+func checkIfName(label string) error {
+	if len(label) > maxIfNameLen {
+		return fmt.Errorf("label %s is used as interface name and must not be longer than %d characters",
+			label, maxIfNameLen)
+	}
+	return nil
+}
+
+func addSystemAdapter(ctrl controller.Cloud, dev *device.Ctx, systemAdapter *config.SystemAdapter) error {
+	id, err := uuid.NewV4()
+	if err != nil {
+		return err
+	}
+	if err = ctrl.AddSystemAdapter(id.String(), systemAdapter); err != nil {
+		return fmt.Errorf("AddSystemAdapter: %w", err)
+	}
+	dev.SetSystemAdaptersConfig(append(dev.GetSystemAdapters(), id.String()))
+	return nil
+}
+
+func removeSystemAdapter(ctrl controller.Cloud, dev *device.Ctx, id string) error {
+	if err := ctrl.RemoveSystemAdapter(id); err != nil {
+		return fmt.Errorf("RemoveSystemAdapter: %w", err)
+	}
+	dev.SetSystemAdaptersConfig(removeID(dev.GetSystemAdapters(), id))
+	return nil
+}
+
+func removeID(ids []string, id string) []string {
+	var result []string
+	for _, el := range ids {
+		if el != id {
+			result = append(result, el)
+		}
+	}
+	return result
+}
+
+// updateSdnNetModel modifies network model of SDN for the physical ports of EVE
+// and applies it. Port ethN of EVE is connected to the N-th port of SDN.
+func (openEVEC *OpenEVEC) updateSdnNetModel(ethPorts []string,
+	update func(model *sdnapi.NetworkModel, sdnPorts []string) error) error {
+	cfg := openEVEC.cfg
+	if !isSdnEnabled(cfg.Sdn.Disable, cfg.Eve.Remote, cfg.Eve.DevModel) {
+		log.Warn("SDN is not enabled, configure the other side of the link manually")
+		return nil
+	}
+	client := &edensdn.SdnClient{
+		SSHPort:    uint16(cfg.Sdn.SSHPort),
+		SSHKeyPath: sdnSSHKeyPath(cfg.Sdn.SourceDir),
+		MgmtPort:   uint16(cfg.Sdn.MgmtPort),
+	}
+	netModel, err := client.GetNetworkModel()
+	if err != nil {
+		return fmt.Errorf("failed to get network model: %w", err)
+	}
+	var sdnPorts []string
+	for _, port := range ethPorts {
+		var index int
+		if _, err := fmt.Sscanf(port, "eth%d", &index); err != nil || index >= len(netModel.Ports) {
+			return fmt.Errorf("port %s of EVE is not connected to SDN", port)
+		}
+		sdnPorts = append(sdnPorts, netModel.Ports[index].LogicalLabel)
+	}
+	if err = update(&netModel, sdnPorts); err != nil {
+		return err
+	}
+	if cfg.DryRun {
+		log.Info("dry run, network model of SDN is not changed")
+		return nil
+	}
+	if err = client.ApplyNetworkModel(netModel); err != nil {
+		return fmt.Errorf("failed to apply network model: %w", err)
+	}
+	if err = updateResumeState(func(state *resumeState) {
+		state.NetModel = &netModel
+	}); err != nil {
+		log.Warnf("cannot save state of SDN: %s", err)
+	}
+	return nil
+}
+
+// NetworkAdapterVlanAdd adds VLAN sub-interface on top of the parent port or bond of the device.
+// Unless ipMode is empty, system adapter is created for the VLAN with the network of devmodel
+// for the mode. With withSdn set, SDN is configured to carry traffic tagged with the VLAN ID.
+func (openEVEC *OpenEVEC) NetworkAdapterVlanAdd(label, parent string, vlanID uint16, uplink bool, ipMode string, withSdn bool) error {
+	if err := checkIfName(label); err != nil {
+		return err
+	}
+	if vlanID == 0 || vlanID > 4094 {
+		return fmt.Errorf("invalid VLAN ID: %d", vlanID)
+	}
+	changer := &adamChanger{}
+	ctrl, dev, err := changer.getControllerAndDevFromConfig(openEVEC.cfg)
+	if err != nil {
+		return fmt.Errorf("getControllerAndDevFromConfig: %w", err)
+	}
+	adapters, err := getNetworkAdapters(ctrl, dev)
+	if err != nil {
+		return err
+	}
+	if adapters.exists(label) {
+		return fmt.Errorf("adapter %s already exists", label)
+	}
+	if adapters.physicalIOs[parent] == nil && adapters.bonds[parent] == nil {
+		return fmt.Errorf("parent %s is neither port nor bond of the device", parent)
+	}
+	for _, vlan := range adapters.vlans {
+		if vlan.LowerLayerName == parent && vlan.VlanId == uint32(vlanID) {
+			return fmt.Errorf("VLAN %d already exists on %s: %s", vlanID, parent, vlan.Logicallabel)
+		}
+	}
+	var systemAdapter *config.SystemAdapter
+	if ipMode != "" {
+		networkID, ok := adapterNetworks[ipMode]
+		if !ok {
+			return fmt.Errorf("unsupported IP mode: %s (supported: %s)", ipMode, strings.Join(AdapterIPModes, ", "))
+		}
+		if _, err := ctrl.GetNetworkConfig(networkID); err != nil {
+			return fmt.Errorf("network for IP mode %s is not defined for the device: %w", ipMode, err)
+		}
+		systemAdapter = &config.SystemAdapter{
+			Name:        label,
+			Uplink:      uplink,
+			NetworkUUID: networkID,
+		}
+	}
+	id, err := uuid.NewV4()
+	if err != nil {
+		return err
+	}
+	err = ctrl.AddVlanAdapter(id.String(), &config.VlanAdapter{
+		Logicallabel:   label,
+		InterfaceName:  label,
+		LowerLayerName: parent,
+		VlanId:         uint32(vlanID),
+	})
+	if err != nil {
+		return fmt.Errorf("AddVlanAdapter: %w", err)
+	}
+	dev.SetVlanAdaptersConfig(append(dev.GetVlanAdapters(), id.String()))
+	if systemAdapter != nil {
+		if err = addSystemAdapter(ctrl, dev, systemAdapter); err != nil {
+			return err
+		}
+	}
+	if withSdn {
+		err = openEVEC.updateSdnNetModel(adapters.ethPorts(parent),
+			func(model *sdnapi.NetworkModel, sdnPorts []string) error {
+				return edensdn.AddVlanNetwork(model, sdnPorts, vlanID)
+			})
+		if err != nil {
+			return err
+		}
+	}
+	if err = changer.setControllerAndDev(ctrl, dev); err != nil {
+		return fmt.Errorf("setControllerAndDev: %w", err)
+	}
+	log.Infof("VLAN adapter %s with ID %d added on top of %s", label, vlanID, parent)
+	return nil
+}
+
+// NetworkAdapterBondAdd aggregates ports of the device into bond with the mode.
+// System adapter of the first port which has one is moved to the bond, the other ports
+// lose their system adapters. With withSdn set, ports of SDN are aggregated as well.
+func (openEVEC *OpenEVEC) NetworkAdapterBondAdd(label string, ports []string, mode string, withSdn bool) error {
+	if err := checkIfName(label); err != nil {
+		return err
+	}
+	sdnMode, ok := sdnapi.BondModeToID[mode]
+	if !ok {
+		return fmt.Errorf("unsupported bond mode: %s (supported: %s)", mode, strings.Join(BondModes, ", "))
+	}
+	if len(ports) < 2 {
+		return fmt.Errorf("bond must aggregate at least two ports")
+	}
+	changer := &adamChanger{}
+	ctrl, dev, err := changer.getControllerAndDevFromConfig(openEVEC.cfg)
+	if err != nil {
+		return fmt.Errorf("getControllerAndDevFromConfig: %w", err)
+	}
+	adapters, err := getNetworkAdapters(ctrl, dev)
+	if err != nil {
+		return err
+	}
+	if adapters.exists(label) {
+		return fmt.Errorf("adapter %s already exists", label)
+	}
+	for _, port := range ports {
+		if adapters.physicalIOs[port] == nil {
+			return fmt.Errorf("port %s is not defined for the device", port)
+		}
+		for _, bond := range adapters.bonds {
+			for _, lowerLayer := range bond.LowerLayerNames {
+				if lowerLayer == port {
+					return fmt.Errorf("port %s is already aggregated into bond %s", port, bond.Logicallabel)
+				}
+			}
+		}
+		for _, vlan := range adapters.vlans {
+			if vlan.LowerLayerName == port {
+				return fmt.Errorf("port %s is used by VLAN %s", port, vlan.Logicallabel)
+			}
+		}
+	}
+	var bondSystemAdapter *config.SystemAdapter
+	for _, port := range ports {
+		systemAdapter, ok := adapters.systemAdapters[port]
+		if !ok {
+			continue
+		}
+		if bondSystemAdapter == nil {
+			bondSystemAdapter = &config.SystemAdapter{
+				Name:        label,
+				Uplink:      systemAdapter.Uplink,
+				NetworkUUID: systemAdapter.NetworkUUID,
+			}
+		}
+		if err = removeSystemAdapter(ctrl, dev, adapters.ids["system:"+port]); err != nil {
+			return err
+		}
+	}
+	id, err := uuid.NewV4()
+	if err != nil {
+		return err
+	}
+	err = ctrl.AddBondAdapter(id.String(), &config.BondAdapter{
+		Logicallabel:    label,
+		InterfaceName:   label,
+		LowerLayerNames: ports,
+		// SDN and EVE enumerate bond modes in the same order, EVE starts with unspecified
+		BondMode: config.BondMode(sdnMode + 1),
+		Monitoring: &config.BondAdapter_Mii{
+			Mii: &config.MIIMonitor{Interval: 100},
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("AddBondAdapter: %w", err)
+	}
+	dev.SetBondAdaptersConfig(append(dev.GetBondAdapters(), id.String()))
+	if bondSystemAdapter != nil {
+		if err = addSystemAdapter(ctrl, dev, bondSystemAdapter); err != nil {
+			return err
+		}
+	}
+	if withSdn {
+		err = openEVEC.updateSdnNetModel(ports,
+			func(model *sdnapi.NetworkModel, sdnPorts []string) error {
+				return edensdn.AddBond(model, sdnPorts, sdnMode)
+			})
+		if err != nil {
+			return err
+		}
+	}
+	if err = changer.setControllerAndDev(ctrl, dev); err != nil {
+		return fmt.Errorf("setControllerAndDev: %w", err)
+	}
+	log.Infof("Bond adapter %s of %s added", label, strings.Join(ports, ", "))
+	return nil
+}
+
+// NetworkAdapterDelete removes VLAN or bond adapter with the label together with its system adapter.
+// System adapter of bond is moved back to its first port. With withSdn set,
+// the corresponding configuration of SDN is removed as well.
+func (openEVEC *OpenEVEC) NetworkAdapterDelete(label string, withSdn bool) error {
+	changer := &adamChanger{}
+	ctrl, dev, err := changer.getControllerAndDevFromConfig(openEVEC.cfg)
+	if err != nil {
+		return fmt.Errorf("getControllerAndDevFromConfig: %w", err)
+	}
+	adapters, err := getNetworkAdapters(ctrl, dev)
+	if err != nil {
+		return err
+	}
+	if adapters.vlans[label] == nil && adapters.bonds[label] == nil {
+		return fmt.Errorf("no VLAN or bond adapter with label %s", label)
+	}
+	for _, vlan := range adapters.vlans {
+		if vlan.LowerLayerName == label {
+			return fmt.Errorf("bond %s is used by VLAN %s, delete it first", label, vlan.Logicallabel)
+		}
+	}
+	systemAdapter, hasSystemAdapter := adapters.systemAdapters[label]
+	if hasSystemAdapter {
+		if err = removeSystemAdapter(ctrl, dev, adapters.ids["system:"+label]); err != nil {
+			return err
+		}
+	}
+	var sdnUpdate func(model *sdnapi.NetworkModel, sdnPorts []string) error
+	ethPorts := adapters.ethPorts(label)
+	switch {
+	case adapters.vlans[label] != nil:
+		vlanID := uint16(adapters.vlans[label].VlanId)
+		if err = ctrl.RemoveVlanAdapter(adapters.ids[label]); err != nil {
+			return fmt.Errorf("RemoveVlanAdapter: %w", err)
+		}
+		dev.SetVlanAdaptersConfig(removeID(dev.GetVlanAdapters(), adapters.ids[label]))
+		sdnUpdate = func(model *sdnapi.NetworkModel, sdnPorts []string) error {
+			return edensdn.RemoveVlanNetwork(model, sdnPorts, vlanID)
+		}
+	default:
+		if err = ctrl.RemoveBondAdapter(adapters.ids[label]); err != nil {
+			return fmt.Errorf("RemoveBondAdapter: %w", err)
+		}
+		dev.SetBondAdaptersConfig(removeID(dev.GetBondAdapters(), adapters.ids[label]))
+		if hasSystemAdapter {
+			err = addSystemAdapter(ctrl, dev, &config.SystemAdapter{
+				Name:        adapters.bonds[label].LowerLayerNames[0],
+				Uplink:      systemAdapter.Uplink,
+				NetworkUUID: systemAdapter.NetworkUUID,
+			})
+			if err != nil {
+				return err
+			}
+		}
+		sdnUpdate = func(model *sdnapi.NetworkModel, sdnPorts []string) error {
+			return edensdn.RemoveBond(model, sdnPorts)
+		}
+	}
+	if withSdn {
+		if err = openEVEC.updateSdnNetModel(ethPorts, sdnUpdate); err != nil {
+			return err
+		}
+	}
+	if err = changer.setControllerAndDev(ctrl, dev); err != nil {
+		return fmt.Errorf("setControllerAndDev: %w", err)
+	}
+	log.Infof("Adapter %s deleted", label)
+	return nil
+}
+
+// NetworkAdapterLs prints ports, VLANs and bonds of the device with their system adapters
+func (openEVEC *OpenEVEC) NetworkAdapterLs() error {
+	changer := &adamChanger{}
+	ctrl, dev, err := changer.getControllerAndDevFromConfig(openEVEC.cfg)
+	if err != nil {
+		return fmt.Errorf("getControllerAndDevFromConfig: %w", err)
+	}
+	adapters, err := getNetworkAdapters(ctrl, dev)
+	if err != nil {
+		return err
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 8, 1, '\t', 0)
+	if _, err = fmt.Fprintln(w, "NAME\tTYPE\tLOWER LAYER\tDETAILS\tUPLINK\tNETWORK"); err != nil {
+		return err
+	}
+	var labels []string
+	for label := range adapters.vlans {
+		labels = append(labels, label)
+	}
+	for label := range adapters.bonds {
+		labels = append(labels, label)
+	}
+	for label, physicalIO := range adapters.physicalIOs {
+		if _, ok := physicalIO.Phyaddrs["Ifname"]; ok {
+			labels = append(labels, label)
+		}
+	}
+	sort.Strings(labels)
+	for _, label := range labels {
+		var kind, lowerLayer, details string
+		switch {
+		case adapters.vlans[label] != nil:
+			kind = "vlan"
+			lowerLayer = adapters.vlans[label].LowerLayerName
+			details = fmt.Sprintf("id=%d", adapters.vlans[label].VlanId)
+		case adapters.bonds[label] != nil:
+			kind = "bond"
+			lowerLayer = strings.Join(adapters.bonds[label].LowerLayerNames, ",")
+			details = "mode=unspecified"
+			if mode := adapters.bonds[label].BondMode; mode > 0 {
+				details = "mode=" + sdnapi.BondModeToString[sdnapi.BondMode(mode-1)]
+			}
+		default:
+			kind = "port"
+			lowerLayer = "-"
+			details = "-"
+		}
+		uplink, network := "-", "-"
+		if systemAdapter, ok := adapters.systemAdapters[label]; ok {
+			uplink = fmt.Sprint(systemAdapter.Uplink)
+			network = systemAdapter.NetworkUUID
+		}
+		if _, err = fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n",
+			label, kind, lowerLayer, details, uplink, network); err != nil {
+			return err
+		}
+	}
+	return w.Flush()
+}
@@ -0,0 +1,97 @@
+package openevec
+
+import (
+	"fmt"
+	"hash/fnv"
+	"net"
+	"path/filepath"
+
+	"github.com/lf-edge/eden/pkg/defaults"
+	"github.com/lf-edge/eden/pkg/utils"
+)
+
+// nodeConfig returns config of node of multi-node EVE derived from config of the first node.
+// Every node has its own certificates, image, QEMU config, files of process and ports of host.
+func nodeConfig(cfg EdenSetupArgs, node int) *EdenSetupArgs {
+	cfg.Node = node
+	if node <= 1 {
+		return &cfg
+	}
+	cfg.Eve.Name = utils.NodeEveName(cfg.Eve.Name, node)
+	cfg.Eve.CertsUUID = utils.NodeUUID(cfg.Eve.CertsUUID, node)
+	cfg.Eve.Serial = utils.NodeSerial(cfg.Eve.Serial, node)
+	cfg.Eden.CertsDir = filepath.Join(cfg.Eden.CertsDir, utils.NodeName(node))
+	cfg.Eve.Cert = utils.NodePath(cfg.Eve.Cert, node)
+	cfg.Eve.DeviceCert = utils.NodePath(cfg.Eve.DeviceCert, node)
+	cfg.Eve.ImageFile = utils.NodePath(cfg.Eve.ImageFile, node)
+	cfg.Eve.QemuFileToSave = utils.NodePath(cfg.Eve.QemuFileToSave, node)
+	// QMP socket is created next to pid file, so keep files of process in directory of node
+	cfg.Eve.Pid = utils.NodePath(cfg.Eve.Pid, node)
+	cfg.Eve.Log = utils.NodePath(cfg.Eve.Log, node)
+	cfg.Eve.TelnetPort = utils.NodePort(cfg.Eve.TelnetPort, node)
+	cfg.Eve.QemuConfig.MonitorPort = utils.NodePort(cfg.Eve.QemuConfig.MonitorPort, node)
+	cfg.Eve.QemuConfig.NetDevSocketPort = utils.NodePort(cfg.Eve.QemuConfig.NetDevSocketPort, node)
+	cfg.Eve.HostFwd = utils.NodeHostFwd(cfg.Eve.HostFwd, node)
+	return &cfg
+}
+
+// SelectNode switches cfg to node of multi-node EVE, node 0 keeps cfg for all nodes
+func SelectNode(cfg *EdenSetupArgs, node int) error {
+	if node == 0 {
+		return nil
+	}
+	if err := utils.CheckNode(node, cfg.Eve.Nodes); err != nil {
+		return err
+	}
+	*cfg = *nodeConfig(*cfg, node)
+	return nil
+}
+
+// NodeConfigs returns configs of EVE nodes commands apply to: the selected node or all of them
+func NodeConfigs(cfg *EdenSetupArgs) []*EdenSetupArgs {
+	if cfg.Node != 0 || cfg.Eve.Nodes <= 1 {
+		return []*EdenSetupArgs{cfg}
+	}
+	var configs []*EdenSetupArgs
+	for node := 1; node <= cfg.Eve.Nodes; node++ {
+		configs = append(configs, nodeConfig(*cfg, node))
+	}
+	return configs
+}
+
+// checkNodes checks that EVE with several nodes can run with cfg
+func checkNodes(cfg *EdenSetupArgs) error {
+	if cfg.Eve.Nodes <= 1 {
+		return nil
+	}
+	if cfg.Eve.DevModel != defaults.DefaultQemuModel || cfg.Eve.Remote {
+		return fmt.Errorf("several EVE nodes are supported only for local EVE with devmodel %s", defaults.DefaultQemuModel)
+	}
+	if isSdnEnabled(cfg.Sdn.Disable, cfg.Eve.Remote, cfg.Eve.DevModel) {
+		return fmt.Errorf("several EVE nodes run without Eden-SDN, please set sdn.disable to true in config")
+	}
+	return nil
+}
+
+// clusterNetMAC returns MAC address of interface of node connected to network shared by nodes
+func clusterNetMAC(cfg *EdenSetupArgs) string {
+	h := fnv.New32a()
+	h.Write([]byte(cfg.Eve.Serial))
+	hash := h.Sum32()
+	// locally administered address
+	hwAddr := net.HardwareAddr{0x02, 0xce}
+	for i := 0; i < 4; i++ {
+		hwAddr = append(hwAddr, byte(hash&0xff))
+		hash >>= 8
+	}
+	return hwAddr.String()
+}
+
+// nodes returns OpenEVEC for every EVE node commands apply to
+func (openEVEC *OpenEVEC) nodes() []*OpenEVEC {
+	var nodes []*OpenEVEC
+	for _, cfg := range NodeConfigs(openEVEC.cfg) {
+		nodes = append(nodes, &OpenEVEC{cfg: cfg, resume: openEVEC.resume})
+	}
+	return nodes
+}
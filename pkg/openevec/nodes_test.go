@@ -0,0 +1,39 @@
+package openevec_test
+
+import (
+	"testing"
+
+	"github.com/lf-edge/eden/pkg/openevec"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNodeConfigs(t *testing.T) {
+	t.Parallel()
+
+	cfg := &openevec.EdenSetupArgs{}
+	cfg.Eve.Nodes = 2
+	cfg.Eve.Name = "default"
+	cfg.Eve.CertsUUID = "1ab8761b-5f89-4e0b-b757-4b87a9fa93ec"
+	cfg.Eve.Pid = "/dist/default-eve.pid"
+	cfg.Eve.TelnetPort = 17777
+	cfg.Eve.HostFwd = map[string]string{"2222": "22"}
+
+	nodes := openevec.NodeConfigs(cfg)
+	assert.Len(t, nodes, 2)
+	assert.Equal(t, "default", nodes[0].Eve.Name)
+	assert.Equal(t, cfg.Eve.CertsUUID, nodes[0].Eve.CertsUUID)
+	assert.Equal(t, "default-node2", nodes[1].Eve.Name)
+	assert.NotEqual(t, cfg.Eve.CertsUUID, nodes[1].Eve.CertsUUID)
+	assert.Equal(t, "/dist/node2/default-eve.pid", nodes[1].Eve.Pid)
+	assert.Equal(t, 17877, nodes[1].Eve.TelnetPort)
+	assert.Equal(t, map[string]string{"2322": "22"}, nodes[1].Eve.HostFwd)
+	// config of the first node is not changed
+	assert.Equal(t, map[string]string{"2222": "22"}, cfg.Eve.HostFwd)
+
+	selected := *cfg
+	assert.NoError(t, openevec.SelectNode(&selected, 2))
+	assert.Equal(t, []*openevec.EdenSetupArgs{&selected}, openevec.NodeConfigs(&selected))
+	assert.Equal(t, nodes[1].Eve, selected.Eve)
+
+	assert.Error(t, openevec.SelectNode(cfg, 3))
+}
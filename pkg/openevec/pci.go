@@ -0,0 +1,32 @@
+package openevec
+
+import (
+	"fmt"
+
+	"github.com/lf-edge/eden/pkg/models"
+)
+
+// qemuPCIPassthrough returns PCI devices of host to pass into EVE VM with VFIO
+func qemuPCIPassthrough(cfg *EdenSetupArgs) ([]string, error) {
+	devices, err := models.ParsePCIPassthroughList(cfg.Eve.PCIPassthrough)
+	if err != nil {
+		return nil, err
+	}
+	if len(devices) == 0 {
+		return nil, nil
+	}
+	if cfg.Eve.Nodes > 1 {
+		return nil, fmt.Errorf("PCI devices of host cannot be passed into several EVE nodes")
+	}
+	if !cfg.Eve.Accel {
+		return nil, fmt.Errorf("PCI passthrough into EVE requires acceleration of QEMU, please set eve.accel to true")
+	}
+	var addresses []string
+	for _, device := range devices {
+		if err := models.CheckHostVFIO(device.Address); err != nil {
+			return nil, err
+		}
+		addresses = append(addresses, device.Address)
+	}
+	return addresses, nil
+}
@@ -2,13 +2,19 @@ package openevec
 
 import (
 	"context"
+	"crypto/rand"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"net"
 	"os"
+	"path/filepath"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/dustin/go-humanize"
+	"github.com/lf-edge/eden/pkg/controller"
 	"github.com/lf-edge/eden/pkg/controller/eapps"
 	"github.com/lf-edge/eden/pkg/controller/eflowlog"
 	"github.com/lf-edge/eden/pkg/controller/einfo"
@@ -16,8 +22,12 @@ import (
 	"github.com/lf-edge/eden/pkg/controller/emetric"
 	"github.com/lf-edge/eden/pkg/controller/types"
 	"github.com/lf-edge/eden/pkg/defaults"
+	"github.com/lf-edge/eden/pkg/device"
 	"github.com/lf-edge/eden/pkg/eve"
 	"github.com/lf-edge/eden/pkg/expect"
+	"github.com/lf-edge/eden/pkg/helm"
+	"github.com/lf-edge/eden/pkg/ova"
+	"github.com/lf-edge/eden/pkg/probe"
 	"github.com/lf-edge/eden/pkg/utils"
 	edgeRegistry "github.com/lf-edge/edge-containers/pkg/registry"
 	"github.com/lf-edge/edge-containers/pkg/resolver"
@@ -26,6 +36,7 @@ import (
 	"github.com/lf-edge/eve-api/go/metrics"
 	uuid "github.com/satori/go.uuid"
 	log "github.com/sirupsen/logrus"
+	"google.golang.org/protobuf/proto"
 )
 
 func processAcls(acls []string) expect.ACLs {
@@ -67,6 +78,12 @@ func processVLANs(vlans []string) (map[string]int, error) {
 }
 
 func (openEVEC *OpenEVEC) PodDeploy(appLink string, pc PodConfig, cfg *EdenSetupArgs) error {
+	if ovaFile := strings.TrimPrefix(appLink, "ova://"); ovaFile != appLink {
+		return openEVEC.podDeployOVA(ovaFile, pc, cfg)
+	}
+	if image := strings.TrimPrefix(appLink, "docker-daemon://"); image != appLink {
+		return openEVEC.podDeployFromDaemon(image, pc, cfg)
+	}
 	changer := &adamChanger{}
 	ctrl, dev, err := changer.getControllerAndDevFromConfig(openEVEC.cfg)
 	if err != nil {
@@ -77,6 +94,8 @@ func (openEVEC *OpenEVEC) PodDeploy(appLink string, pc PodConfig, cfg *EdenSetup
 	opts = append(opts, expect.WithVnc(pc.VncDisplay))
 	opts = append(opts, expect.WithVncPassword(pc.VncPassword))
 	opts = append(opts, expect.WithAppAdapters(pc.AppAdapters))
+	opts = append(opts, expect.WithAppPCIAdapters(pc.PCIAdapters))
+	opts = append(opts, expect.WithAppIOMMUGroups(pc.IOMMUGroups))
 	if len(pc.Networks) > 0 {
 		for i, el := range pc.Networks {
 			if i == 0 {
@@ -119,18 +138,12 @@ func (openEVEC *OpenEVEC) PodDeploy(appLink string, pc PodConfig, cfg *EdenSetup
 	}
 	opts = append(opts, expect.WithVLANs(vlansParsed))
 	opts = append(opts, expect.WithSFTPLoad(pc.SftpLoad))
-	if !pc.SftpLoad {
+	opts = append(opts, expect.WithS3Load(pc.S3Load))
+	if !pc.SftpLoad && !pc.S3Load {
 		opts = append(opts, expect.WithHTTPDirectLoad(pc.DirectLoad))
 	}
 	opts = append(opts, expect.WithAdditionalDisks(append(pc.Disks, pc.Mount...)))
-	registryToUse := pc.Registry
-	switch pc.Registry {
-	case "local":
-		registryToUse = fmt.Sprintf("%s:%d", cfg.Registry.IP, cfg.Registry.Port)
-	case "remote":
-		registryToUse = ""
-	}
-	opts = append(opts, expect.WithRegistry(registryToUse))
+	opts = append(opts, expect.WithRegistry(RegistryAddress(cfg, pc.Registry)))
 	if pc.NoHyper {
 		opts = append(opts, expect.WithVirtualizationMode(config.VmMode_NOHYPER))
 	}
@@ -149,25 +162,273 @@ func (openEVEC *OpenEVEC) PodDeploy(appLink string, pc PodConfig, cfg *EdenSetup
 	return nil
 }
 
-func (openEVEC *OpenEVEC) PodPs(outputFormat types.OutputFormat) error {
+// podDeployFromDaemon pushes image from local docker daemon into selected registry
+// (local registry of eden if not selected) and deploys it from there
+func (openEVEC *OpenEVEC) podDeployFromDaemon(image string, pc PodConfig, cfg *EdenSetupArgs) error {
+	localImage, err := utils.HasImage(image)
+	if err != nil {
+		return fmt.Errorf("cannot check image %s in docker: %w", image, err)
+	}
+	if !localImage {
+		return fmt.Errorf("image %s not found in docker", image)
+	}
+	pc.Registry = DaemonRegistry(pc.Registry)
+	registry := RegistryAddress(cfg, pc.Registry)
+	hash, err := utils.LoadRegistry(image, registry)
+	if err != nil {
+		return fmt.Errorf("failed to push image %s into %s: %w", image, registry, err)
+	}
+	log.Infof("image %s pushed into %s with manifest hash %s", image, registry, hash)
+	return openEVEC.PodDeploy("docker://"+image, pc, cfg)
+}
+
+// podDeployOVA unpacks OVA, converts its disks into qcow2 and deploys VM app with
+// CPUs and memory from OVF descriptor, disks are uploaded into eserver
+func (openEVEC *OpenEVEC) podDeployOVA(ovaFile string, pc PodConfig, cfg *EdenSetupArgs) error {
+	edenDir, err := utils.DefaultEdenDir()
+	if err != nil {
+		return err
+	}
+	name := strings.TrimSuffix(filepath.Base(ovaFile), filepath.Ext(ovaFile))
+	dir := filepath.Join(edenDir, "ova", name)
+	vm, err := ova.Unpack(utils.ResolveAbsPath(ovaFile), dir)
+	if err != nil {
+		return err
+	}
+	var links []string
+	for _, disk := range vm.Disks {
+		// files are uploaded into eserver by name, so make it unique
+		qcow2File := filepath.Join(dir, fmt.Sprintf("%s-%s.qcow2", name, disk.ID))
+		log.Infof("Converting disk %s of %s into qcow2", disk.ID, ovaFile)
+		if err := utils.ConvertDisk(disk.Path, disk.Format, qcow2File, "qcow2"); err != nil {
+			return fmt.Errorf("cannot convert disk %s: %w", disk.ID, err)
+		}
+		links = append(links, "file://"+qcow2File)
+	}
+	if pc.Name == "" {
+		pc.Name = vm.Name
+	}
+	if vm.CPUs > 0 {
+		pc.AppCpus = vm.CPUs
+	}
+	if vm.Memory > 0 {
+		pc.AppMemory = humanize.IBytes(vm.Memory)
+	}
+	if len(vm.Interfaces) > 1 && len(pc.Networks) < len(vm.Interfaces) {
+		log.Warnf("%s has %d interfaces, define networks for all of them with --networks",
+			vm.Name, len(vm.Interfaces))
+	}
+	pc.ImageFormat = "qcow2"
+	pc.Disks = append(links[1:], pc.Disks...)
+	return openEVEC.PodDeploy(links[0], pc, cfg)
+}
+
+// PodDeployFromHelm renders Helm chart and deploys containers of its workloads as apps
+// using pc for parameters not defined in the chart
+func (openEVEC *OpenEVEC) PodDeployFromHelm(chart string, opts helm.RenderOptions, pc PodConfig, cfg *EdenSetupArgs) error {
+	if opts.Release == "" {
+		opts.Release = pc.Name
+	}
+	manifests, err := helm.Render(chart, opts)
+	if err != nil {
+		return err
+	}
+	apps, err := helm.Apps(manifests)
+	if err != nil {
+		return err
+	}
+	if len(apps) == 0 {
+		return fmt.Errorf("no workloads found in chart %s", chart)
+	}
+	emptyVolumeLink := defaults.DefaultEmptyVolumeLinkQcow2
+	if !strings.Contains(emptyVolumeLink, "://") {
+		emptyVolumeLink = fmt.Sprintf("file://%s", utils.ResolveAbsPath(emptyVolumeLink))
+	}
+	for _, app := range apps {
+		appConfig := pc
+		appConfig.Name = app.Name
+		appConfig.PortPublish = append(app.Ports, pc.PortPublish...)
+		if len(app.Env) > 0 {
+			if pc.Metadata != "" {
+				log.Warnf("env of %s is not used as metadata is provided", app.Name)
+			} else {
+				appConfig.Metadata = strings.Join(app.Env, "\n")
+			}
+		}
+		appConfig.Mount = append([]string{}, pc.Mount...)
+		for _, mountPath := range app.Mounts {
+			appConfig.Mount = append(appConfig.Mount, fmt.Sprintf("src=%s,dst=%s", emptyVolumeLink, mountPath))
+		}
+		if app.VolumeSize > 0 {
+			appConfig.VolumeSize = humanize.IBytes(app.VolumeSize)
+		}
+		if app.CPUs > 0 {
+			appConfig.AppCpus = app.CPUs
+		}
+		if app.Memory > 0 {
+			appConfig.AppMemory = humanize.IBytes(app.Memory)
+		}
+		if err := openEVEC.PodDeploy("docker://"+app.Image, appConfig, cfg); err != nil {
+			return fmt.Errorf("cannot deploy %s: %w", app.Name, err)
+		}
+	}
+	return nil
+}
+
+// PodPs lists pods, with positive wait it waits for all pods to settle (see eve.AppInstState.Settled) before
+func (openEVEC *OpenEVEC) PodPs(outputFormat types.OutputFormat, wide bool, wait time.Duration) error {
 	changer := &adamChanger{}
 	ctrl, dev, err := changer.getControllerAndDevFromConfig(openEVEC.cfg)
 	if err != nil {
 		return fmt.Errorf("getControllerAndDevFromConfig: %w", err)
 	}
 	state := eve.Init(ctrl, dev)
-	if err := ctrl.InfoLastCallback(dev.GetID(), nil, state.InfoCallback()); err != nil {
+	if wait > 0 {
+		settled := func(state *eve.State) bool {
+			for _, app := range state.Applications() {
+				if !app.Settled() {
+					return false
+				}
+			}
+			return true
+		}
+		if err := eve.NewWaiter(ctrl, dev, state).Wait(settled, wait); err != nil {
+			log.Warnf("pods did not settle: %s", err)
+		}
+	} else if err := ctrl.InfoLastCallback(dev.GetID(), nil, state.InfoCallback()); err != nil {
 		return fmt.Errorf("fail in get InfoLastCallback: %w", err)
 	}
 	if err := ctrl.MetricLastCallback(dev.GetID(), nil, state.MetricCallback()); err != nil {
 		return fmt.Errorf("fail in get MetricLastCallback: %w", err)
 	}
-	if err := state.PodsList(outputFormat); err != nil {
+	if err := openEVEC.setProbesHealth(state.Applications()); err != nil {
+		log.Warnf("cannot run probes of apps: %s", err)
+	}
+	if err := state.PodsList(outputFormat, wide); err != nil {
 		return err
 	}
 	return nil
 }
 
+// PodWait waits for app to meet all conditions checking them on every info from EVE
+func (openEVEC *OpenEVEC) PodWait(appName string, conditions []string, timeout time.Duration) error {
+	conds, err := eve.ParseAppConditions(conditions)
+	if err != nil {
+		return err
+	}
+	changer := &adamChanger{}
+	ctrl, dev, err := changer.getControllerAndDevFromConfig(openEVEC.cfg)
+	if err != nil {
+		return fmt.Errorf("getControllerAndDevFromConfig: %w", err)
+	}
+	// statuses of probes are kept between iterations to apply their thresholds
+	var statuses []*probe.Status
+	for _, cond := range conds {
+		if cond.Name != eve.AppConditionHealthy {
+			continue
+		}
+		store, err := openEVEC.loadProbes()
+		if err != nil {
+			return err
+		}
+		specs := store.Get(appName)
+		if len(specs) == 0 {
+			return fmt.Errorf("no probes defined for app %s", appName)
+		}
+		for _, spec := range specs {
+			statuses = append(statuses, probe.NewStatus(spec))
+		}
+	}
+	var pending []string
+	check := func(state *eve.State) bool {
+		pending = []string{}
+		found := false
+		for _, app := range state.Applications() {
+			if app.Name != appName {
+				continue
+			}
+			found = true
+			if len(statuses) > 0 {
+				app.Health = openEVEC.runProbes(app, statuses)
+			}
+			for _, cond := range conds {
+				if !cond.Check(app) {
+					pending = append(pending, cond.String())
+				}
+			}
+			if len(pending) > 0 {
+				log.Debugf("app %s in state %s, waiting for %s", appName, app.EVEState, strings.Join(pending, ","))
+			}
+			break
+		}
+		if !found {
+			pending = append(pending, "app found")
+		}
+		return len(pending) == 0
+	}
+	if err := eve.NewWaiter(ctrl, dev, eve.Init(ctrl, dev)).Wait(check, timeout); err != nil {
+		if len(pending) > 0 {
+			return fmt.Errorf("app %s does not meet conditions %s in %s", appName, strings.Join(pending, ","), timeout)
+		}
+		return err
+	}
+	log.Infof("app %s meets conditions %s", appName, strings.Join(conditions, ","))
+	return nil
+}
+
+// PodWaitError waits for app to fail with error of provided reason (any error if reason is empty)
+// it returns error if app reaches RUNNING state instead or on timeout
+func (openEVEC *OpenEVEC) PodWaitError(appName, reason string, timeout time.Duration) error {
+	changer := &adamChanger{}
+	ctrl, dev, err := changer.getControllerAndDevFromConfig(openEVEC.cfg)
+	if err != nil {
+		return fmt.Errorf("getControllerAndDevFromConfig: %w", err)
+	}
+	state := eve.Init(ctrl, dev)
+	var matched, lastErrors []*eve.AppError
+	running := false
+	check := func() bool {
+		for _, app := range state.Applications() {
+			if app.Name != appName {
+				continue
+			}
+			lastErrors = app.Errors
+			if matched = app.ErrorsByReason(reason); len(matched) > 0 {
+				return true
+			}
+			if app.EVEState == info.ZSwState_RUNNING.String() {
+				running = true
+				return true
+			}
+		}
+		return false
+	}
+	if err := ctrl.InfoLastCallback(dev.GetID(), nil, state.InfoCallback()); err != nil {
+		return fmt.Errorf("fail in get InfoLastCallback: %w", err)
+	}
+	if !check() {
+		handler := func(im *info.ZInfoMsg) bool {
+			state.InfoCallback()(im)
+			return check()
+		}
+		if err := ctrl.InfoChecker(dev.GetID(), nil, handler, einfo.InfoNew, timeout); err != nil {
+			for _, el := range lastErrors {
+				log.Errorf("app %s error (%s): %s", appName, el.Reason, el.Description)
+			}
+			return fmt.Errorf("app %s did not fail with reason '%s' in %s: %w", appName, reason, timeout, err)
+		}
+	}
+	if running {
+		return fmt.Errorf("app %s is %s, but error expected", appName, info.ZSwState_RUNNING)
+	}
+	data, err := json.MarshalIndent(matched, "", "    ")
+	if err != nil {
+		return fmt.Errorf("cannot marshal: %w", err)
+	}
+	fmt.Println(string(data))
+	return nil
+}
+
 func (openEVEC *OpenEVEC) PodStop(appName string) error {
 	changer := &adamChanger{}
 	ctrl, dev, err := changer.getControllerAndDevFromConfig(openEVEC.cfg)
@@ -613,7 +874,7 @@ func (openEVEC *OpenEVEC) PodPublish(appName, kernelFile, initrdFile, rootFile,
 		if err != nil {
 			return fmt.Errorf("unexpected error when created NewRegistry resolver: %w", err)
 		}
-		appName = fmt.Sprintf("%s:%d/%s", cfg.Registry.IP, cfg.Registry.Port, appName)
+		appName = fmt.Sprintf("%s/%s", RegistryAddress(cfg, "local"), appName)
 	} else {
 		_, remoteTarget, err = resolver.NewRegistry(ctx)
 		if err != nil {
@@ -676,3 +937,169 @@ func (openEVEC *OpenEVEC) PodPublish(appName, kernelFile, initrdFile, rootFile,
 
 	return nil
 }
+
+// randomMAC returns random unicast locally administered MAC address
+func randomMAC() (string, error) {
+	buf := make([]byte, 6)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	buf[0] = (buf[0] | 0x02) & 0xfe
+	return net.HardwareAddr(buf).String(), nil
+}
+
+// findNetworkInstance returns network instance of device with provided name
+func findNetworkInstance(ctrl controller.Cloud, dev *device.Ctx, niName string) (*config.NetworkInstanceConfig, error) {
+	for _, el := range dev.GetNetworkInstances() {
+		ni, err := ctrl.GetNetworkInstanceConfig(el)
+		if err != nil {
+			return nil, fmt.Errorf("no network in cloud %s: %w", el, err)
+		}
+		if ni.Displayname == niName {
+			return ni, nil
+		}
+	}
+	return nil, fmt.Errorf("not found network with name %s", niName)
+}
+
+// PodClone adds new app with name newName and configuration of app appName.
+// App receives new UUIDs, MAC addresses and volumes. Volumes share content trees
+// with the original app unless copyContent is set.
+// If networks are provided, they replace network instances of interfaces in order.
+func (openEVEC *OpenEVEC) PodClone(appName, newName string, networks []string, copyContent bool) error {
+	changer := &adamChanger{}
+	ctrl, dev, err := changer.getControllerAndDevFromConfig(openEVEC.cfg)
+	if err != nil {
+		return fmt.Errorf("getControllerAndDevFromConfig: %w", err)
+	}
+	if err = clonePod(ctrl, dev, appName, newName, networks, copyContent); err != nil {
+		return err
+	}
+	if err = changer.setControllerAndDev(ctrl, dev); err != nil {
+		return fmt.Errorf("setControllerAndDev: %w", err)
+	}
+	log.Infof("app %s cloned into %s", appName, newName)
+	return nil
+}
+
+// clonePod adds clone of app appName into controller and config of device
+func clonePod(ctrl controller.Cloud, dev *device.Ctx, appName, newName string, networks []string, copyContent bool) error {
+	var source *config.AppInstanceConfig
+	for _, appID := range dev.GetApplicationInstances() {
+		app, err := ctrl.GetApplicationInstanceConfig(appID)
+		if err != nil {
+			return fmt.Errorf("no app in cloud %s: %w", appID, err)
+		}
+		if app.Displayname == newName {
+			return fmt.Errorf("app with name %s already exists", newName)
+		}
+		if app.Displayname == appName {
+			source = app
+		}
+	}
+	if source == nil {
+		return fmt.Errorf("not found app with name %s", appName)
+	}
+	if len(networks) > 0 && len(networks) != len(source.Interfaces) {
+		return fmt.Errorf("app %s has %d interfaces, but %d networks provided",
+			appName, len(source.Interfaces), len(networks))
+	}
+	app := proto.Clone(source).(*config.AppInstanceConfig)
+	appID, err := uuid.NewV4()
+	if err != nil {
+		return err
+	}
+	app.Uuidandversion = &config.UUIDandVersion{Uuid: appID.String(), Version: "1"}
+	app.Displayname = newName
+	app.Purge = nil
+	app.Restart = nil
+
+	for i, intf := range app.Interfaces {
+		if len(networks) > 0 {
+			ni, err := findNetworkInstance(ctrl, dev, networks[i])
+			if err != nil {
+				return err
+			}
+			intf.NetworkId = ni.Uuidandversion.Uuid
+			intf.Name = fmt.Sprintf("%s-%d", ni.Displayname, i)
+		}
+		if intf.MacAddress != "" {
+			if intf.MacAddress, err = randomMAC(); err != nil {
+				return err
+			}
+		}
+		// static IP and published ports belong to the original app
+		intf.Addr = ""
+		var acls []*config.ACE
+		for _, ace := range intf.Acls {
+			portmap := false
+			for _, action := range ace.Actions {
+				if action.Portmap {
+					portmap = true
+					break
+				}
+			}
+			if portmap {
+				log.Warnf("port mapping %s of app %s is not cloned", ace.Name, appName)
+				continue
+			}
+			acls = append(acls, ace)
+		}
+		intf.Acls = acls
+	}
+
+	// content trees copied for the clone by ID of the original ones
+	contentTrees := map[string]string{}
+	volumeConfigs := dev.GetVolumes()
+	for i, volumeRef := range app.VolumeRefList {
+		volume, err := ctrl.GetVolume(volumeRef.Uuid)
+		if err != nil {
+			return fmt.Errorf("no volume in cloud %s: %w", volumeRef.Uuid, err)
+		}
+		volume = proto.Clone(volume).(*config.Volume)
+		volumeID, err := uuid.NewV4()
+		if err != nil {
+			return err
+		}
+		volume.Uuid = volumeID.String()
+		volume.DisplayName = fmt.Sprintf("%s_%d_m_0", newName, i)
+		volume.GenerationCount = 0
+		if origin := volume.Origin; copyContent && origin != nil && origin.DownloadContentTreeID != "" {
+			newID, ok := contentTrees[origin.DownloadContentTreeID]
+			if !ok {
+				contentTree, err := ctrl.GetContentTree(origin.DownloadContentTreeID)
+				if err != nil {
+					return fmt.Errorf("no content tree in cloud %s: %w", origin.DownloadContentTreeID, err)
+				}
+				contentTree = proto.Clone(contentTree).(*config.ContentTree)
+				contentTreeID, err := uuid.NewV4()
+				if err != nil {
+					return err
+				}
+				contentTree.Uuid = contentTreeID.String()
+				contentTree.DisplayName = fmt.Sprintf("%s-%d", newName, len(contentTrees))
+				contentTree.GenerationCount = 0
+				if err = ctrl.AddContentTree(contentTree); err != nil {
+					return fmt.Errorf("AddContentTree: %w", err)
+				}
+				dev.SetContentTreeConfig(append(dev.GetContentTrees(), contentTree.Uuid))
+				newID = contentTree.Uuid
+				contentTrees[origin.DownloadContentTreeID] = newID
+			}
+			origin.DownloadContentTreeID = newID
+		}
+		if err = ctrl.AddVolume(volume); err != nil {
+			return fmt.Errorf("AddVolume: %w", err)
+		}
+		volumeConfigs = append(volumeConfigs, volume.Uuid)
+		volumeRef.Uuid = volume.Uuid
+		volumeRef.GenerationCount = 0
+	}
+	dev.SetVolumeConfigs(volumeConfigs)
+
+	if err = ctrl.AddApplicationInstanceConfig(app); err != nil {
+		return fmt.Errorf("AddApplicationInstanceConfig: %w", err)
+	}
+	dev.SetApplicationInstanceConfig(append(dev.GetApplicationInstances(), app.Uuidandversion.Uuid))
+	return nil
+}
@@ -0,0 +1,160 @@
+package openevec
+
+import (
+	"net"
+	"testing"
+
+	"github.com/lf-edge/eden/pkg/controller"
+	"github.com/lf-edge/eden/pkg/device"
+	"github.com/lf-edge/eve-api/go/config"
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	cloneAppID         = "6a8e1b0c-2d7e-4b5a-9c1f-3e4d5f6a7b8c"
+	cloneVolumeID      = "7b9f2c1d-3e8f-4c6b-8d2a-4f5e6a7b8c9d"
+	cloneContentTreeID = "8ca03d2e-4f9a-4d7c-9e3b-5a6f7b8c9d0e"
+	cloneDefaultNIID   = "9db14e3f-5a0b-4e8d-8f4c-6b7a8c9d0e1f"
+	cloneOtherNIID     = "aec25f4a-6b1c-4f9e-9a5d-7c8b9d0e1f2a"
+)
+
+// clonePodCloud returns controller and device with app "app" using volume and network "default"
+func clonePodCloud(t *testing.T) (controller.Cloud, *device.Ctx) {
+	ctrl := &controller.CloudCtx{}
+	dev := device.CreateEdgeNode()
+	for id, name := range map[string]string{cloneDefaultNIID: "default", cloneOtherNIID: "other"} {
+		assert.NoError(t, ctrl.AddNetworkInstanceConfig(&config.NetworkInstanceConfig{
+			Uuidandversion: &config.UUIDandVersion{Uuid: id, Version: "1"},
+			Displayname:    name,
+		}))
+	}
+	dev.SetNetworkInstanceConfig([]string{cloneDefaultNIID, cloneOtherNIID})
+	assert.NoError(t, ctrl.AddContentTree(&config.ContentTree{Uuid: cloneContentTreeID, DisplayName: "app-image"}))
+	dev.SetContentTreeConfig([]string{cloneContentTreeID})
+	assert.NoError(t, ctrl.AddVolume(&config.Volume{
+		Uuid:            cloneVolumeID,
+		DisplayName:     "app_0_m_0",
+		GenerationCount: 2,
+		Origin: &config.VolumeContentOrigin{
+			Type:                  config.VolumeContentOriginType_VCOT_DOWNLOAD,
+			DownloadContentTreeID: cloneContentTreeID,
+		},
+	}))
+	dev.SetVolumeConfigs([]string{cloneVolumeID})
+	assert.NoError(t, ctrl.AddApplicationInstanceConfig(&config.AppInstanceConfig{
+		Uuidandversion: &config.UUIDandVersion{Uuid: cloneAppID, Version: "3"},
+		Displayname:    "app",
+		Restart:        &config.InstanceOpsCmd{Counter: 1},
+		VolumeRefList:  []*config.VolumeRef{{Uuid: cloneVolumeID, GenerationCount: 2, MountDir: "/data"}},
+		Interfaces: []*config.NetworkAdapter{{
+			Name:       "default",
+			NetworkId:  cloneDefaultNIID,
+			MacAddress: "02:00:00:00:00:01",
+			Addr:       "10.11.12.13",
+			Acls: []*config.ACE{
+				{Name: "allow", Matches: []*config.ACEMatch{{Type: "host", Value: "example.com"}}},
+				{Name: "ssh", Actions: []*config.ACEAction{{Portmap: true, AppPort: 22}}},
+			},
+		}},
+	}))
+	dev.SetApplicationInstanceConfig([]string{cloneAppID})
+	return ctrl, dev
+}
+
+func TestClonePod(t *testing.T) {
+	t.Parallel()
+
+	ctrl, dev := clonePodCloud(t)
+	assert.NoError(t, clonePod(ctrl, dev, "app", "app-clone", nil, false))
+
+	apps := dev.GetApplicationInstances()
+	if !assert.Len(t, apps, 2) {
+		return
+	}
+	assert.Equal(t, cloneAppID, apps[0])
+	clone, err := ctrl.GetApplicationInstanceConfig(apps[1])
+	assert.NoError(t, err)
+	// clone gets fresh identity
+	assert.NotEqual(t, cloneAppID, clone.Uuidandversion.Uuid)
+	assert.Equal(t, "1", clone.Uuidandversion.Version)
+	assert.Equal(t, "app-clone", clone.Displayname)
+	assert.Nil(t, clone.Restart)
+
+	// network is kept, MAC, static IP and port mapping belong to the original app
+	intf := clone.Interfaces[0]
+	assert.Equal(t, cloneDefaultNIID, intf.NetworkId)
+	assert.Equal(t, "default", intf.Name)
+	assert.NotEqual(t, "02:00:00:00:00:01", intf.MacAddress)
+	mac, err := net.ParseMAC(intf.MacAddress)
+	if assert.NoError(t, err) {
+		assert.Equal(t, byte(0x02), mac[0]&0x03, "unicast locally administered MAC")
+	}
+	assert.Empty(t, intf.Addr)
+	if assert.Len(t, intf.Acls, 1) {
+		assert.Equal(t, "allow", intf.Acls[0].Name)
+	}
+
+	// volume is new, but its content is shared with the original one
+	if assert.Len(t, clone.VolumeRefList, 1) {
+		volumeRef := clone.VolumeRefList[0]
+		assert.NotEqual(t, cloneVolumeID, volumeRef.Uuid)
+		assert.Equal(t, int64(0), volumeRef.GenerationCount)
+		assert.Equal(t, "/data", volumeRef.MountDir)
+		assert.Equal(t, []string{cloneVolumeID, volumeRef.Uuid}, dev.GetVolumes())
+		volume, err := ctrl.GetVolume(volumeRef.Uuid)
+		if assert.NoError(t, err) {
+			assert.Equal(t, "app-clone_0_m_0", volume.DisplayName)
+			assert.Equal(t, int64(0), volume.GenerationCount)
+			assert.Equal(t, cloneContentTreeID, volume.Origin.DownloadContentTreeID)
+		}
+	}
+	assert.Equal(t, []string{cloneContentTreeID}, dev.GetContentTrees())
+
+	// original app is not changed
+	app, err := ctrl.GetApplicationInstanceConfig(cloneAppID)
+	assert.NoError(t, err)
+	assert.Equal(t, "02:00:00:00:00:01", app.Interfaces[0].MacAddress)
+	assert.Equal(t, "10.11.12.13", app.Interfaces[0].Addr)
+	assert.Len(t, app.Interfaces[0].Acls, 2)
+	assert.Equal(t, cloneVolumeID, app.VolumeRefList[0].Uuid)
+	assert.Equal(t, "3", app.Uuidandversion.Version)
+
+	assert.Error(t, clonePod(ctrl, dev, "app", "app-clone", nil, false), "name is taken")
+	assert.Error(t, clonePod(ctrl, dev, "missing", "app-clone-2", nil, false), "no app")
+	assert.Error(t, clonePod(ctrl, dev, "app", "app-clone-2", []string{"default", "other"}, false),
+		"more networks than interfaces")
+	assert.Error(t, clonePod(ctrl, dev, "app", "app-clone-2", []string{"missing"}, false), "no network")
+}
+
+func TestClonePodCopyContent(t *testing.T) {
+	t.Parallel()
+
+	ctrl, dev := clonePodCloud(t)
+	assert.NoError(t, clonePod(ctrl, dev, "app", "app-copy", []string{"other"}, true))
+
+	apps := dev.GetApplicationInstances()
+	if !assert.Len(t, apps, 2) {
+		return
+	}
+	clone, err := ctrl.GetApplicationInstanceConfig(apps[1])
+	assert.NoError(t, err)
+	assert.Equal(t, cloneOtherNIID, clone.Interfaces[0].NetworkId)
+	assert.Equal(t, "other-0", clone.Interfaces[0].Name)
+
+	contentTrees := dev.GetContentTrees()
+	if assert.Len(t, contentTrees, 2) {
+		assert.NotEqual(t, cloneContentTreeID, contentTrees[1])
+		contentTree, err := ctrl.GetContentTree(contentTrees[1])
+		if assert.NoError(t, err) {
+			assert.Equal(t, "app-copy-0", contentTree.DisplayName)
+		}
+		volume, err := ctrl.GetVolume(clone.VolumeRefList[0].Uuid)
+		if assert.NoError(t, err) {
+			assert.Equal(t, contentTrees[1], volume.Origin.DownloadContentTreeID)
+		}
+	}
+	// content of the original volume is not changed
+	volume, err := ctrl.GetVolume(cloneVolumeID)
+	assert.NoError(t, err)
+	assert.Equal(t, cloneContentTreeID, volume.Origin.DownloadContentTreeID)
+}
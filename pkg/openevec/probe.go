@@ -0,0 +1,242 @@
+package openevec
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/lf-edge/eden/pkg/eve"
+	"github.com/lf-edge/eden/pkg/probe"
+	"github.com/lf-edge/eden/pkg/utils"
+	log "github.com/sirupsen/logrus"
+)
+
+// probesFile returns path to file with probes of apps of the current device
+func (openEVEC *OpenEVEC) probesFile() (string, error) {
+	edenDir, err := utils.DefaultEdenDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(edenDir, fmt.Sprintf("probes-%s.json", openEVEC.cfg.Eve.CertsUUID)), nil
+}
+
+func (openEVEC *OpenEVEC) loadProbes() (*probe.Store, error) {
+	file, err := openEVEC.probesFile()
+	if err != nil {
+		return nil, err
+	}
+	return probe.Load(file)
+}
+
+// PodProbeAdd defines probe of app, probe with the same name is replaced
+func (openEVEC *OpenEVEC) PodProbeAdd(appName string, spec *probe.Spec) error {
+	store, err := openEVEC.loadProbes()
+	if err != nil {
+		return err
+	}
+	if err = store.Add(appName, spec); err != nil {
+		return err
+	}
+	if err = store.Save(); err != nil {
+		return fmt.Errorf("cannot save probes: %w", err)
+	}
+	log.Infof("probe %s of app %s defined", spec, appName)
+	return nil
+}
+
+// PodProbeDelete removes probe of app with name or all probes of app if name is empty
+func (openEVEC *OpenEVEC) PodProbeDelete(appName, name string) error {
+	store, err := openEVEC.loadProbes()
+	if err != nil {
+		return err
+	}
+	if !store.Delete(appName, name) {
+		return fmt.Errorf("no probe %q of app %s", name, appName)
+	}
+	if err = store.Save(); err != nil {
+		return fmt.Errorf("cannot save probes: %w", err)
+	}
+	log.Infof("probes of app %s deleted", appName)
+	return nil
+}
+
+// PodProbeList prints probes of app or of all apps if appName is empty
+func (openEVEC *OpenEVEC) PodProbeList(appName string) error {
+	store, err := openEVEC.loadProbes()
+	if err != nil {
+		return err
+	}
+	apps := store.Apps()
+	if appName != "" {
+		apps = []string{appName}
+	}
+	w := new(tabwriter.Writer)
+	w.Init(os.Stdout, 0, 8, 1, '\t', 0)
+	if _, err = fmt.Fprintln(w, "APP\tNAME\tTYPE\tPORT\tCHECK\tINTERVAL\tTIMEOUT\tTHRESHOLDS(OK/FAIL)"); err != nil {
+		return err
+	}
+	for _, app := range apps {
+		for _, spec := range store.Get(app) {
+			check := spec.Path
+			if spec.Type == probe.TypeExec {
+				check = spec.Command
+			}
+			if check == "" {
+				check = "-"
+			}
+			if _, err = fmt.Fprintf(w, "%s\t%s\t%s\t%d\t%s\t%s\t%s\t%d/%d\n", app, spec.Name, spec.Type,
+				spec.Port, check, spec.Interval, spec.Timeout, spec.SuccessThreshold, spec.FailureThreshold); err != nil {
+				return err
+			}
+		}
+	}
+	return w.Flush()
+}
+
+// PodProbeRun runs probes of app count times with their interval and prints results,
+// it returns error if app is not healthy after the last run
+func (openEVEC *OpenEVEC) PodProbeRun(appName string, count int) error {
+	store, err := openEVEC.loadProbes()
+	if err != nil {
+		return err
+	}
+	specs := store.Get(appName)
+	if len(specs) == 0 {
+		return fmt.Errorf("no probes defined for app %s", appName)
+	}
+	app, err := openEVEC.appState(appName)
+	if err != nil {
+		return err
+	}
+	statuses := make([]*probe.Status, 0, len(specs))
+	interval := specs[0].Interval
+	for _, spec := range specs {
+		statuses = append(statuses, probe.NewStatus(spec))
+		if spec.Interval < interval {
+			interval = spec.Interval
+		}
+	}
+	if count < 1 {
+		count = 1
+	}
+	health := probe.HealthUnknown
+	for i := 0; i < count; i++ {
+		if i > 0 {
+			time.Sleep(interval)
+		}
+		health = openEVEC.runProbes(app, statuses)
+		for _, status := range statuses {
+			result := status.Last
+			message := "OK"
+			if !result.OK {
+				message = result.Message
+			}
+			fmt.Printf("%s\t%s\t%s\t%s\t%s\n", result.Time.Format(time.RFC3339), result.Probe,
+				result.Duration.Round(time.Millisecond), status.Health, message)
+		}
+	}
+	if health != probe.HealthHealthy {
+		return fmt.Errorf("app %s is %s", appName, health)
+	}
+	log.Infof("app %s is %s", appName, health)
+	return nil
+}
+
+// appState returns state of app from the last info messages
+func (openEVEC *OpenEVEC) appState(appName string) (*eve.AppInstState, error) {
+	changer := &adamChanger{}
+	ctrl, dev, err := changer.getControllerAndDevFromConfig(openEVEC.cfg)
+	if err != nil {
+		return nil, fmt.Errorf("getControllerAndDevFromConfig: %w", err)
+	}
+	state := eve.Init(ctrl, dev)
+	if err := ctrl.InfoLastCallback(dev.GetID(), nil, state.InfoCallback()); err != nil {
+		return nil, fmt.Errorf("fail in get InfoLastCallback: %w", err)
+	}
+	for _, app := range state.Applications() {
+		if app.Name == appName {
+			return app, nil
+		}
+	}
+	return nil, fmt.Errorf("no app with name %s found", appName)
+}
+
+// runProbes runs probes once and returns combined health of app
+func (openEVEC *OpenEVEC) runProbes(app *eve.AppInstState, statuses []*probe.Status) string {
+	for _, status := range statuses {
+		target, err := probeTarget(app, status.Spec.Port)
+		if err != nil {
+			status.Observe(probe.Result{Probe: status.Spec.Name, Time: time.Now(), Message: err.Error()})
+			continue
+		}
+		status.Observe(probe.Run(status.Spec, target, openEVEC.probeExec))
+	}
+	return probe.Combine(statuses)
+}
+
+// setProbesHealth runs probes of apps with probes defined once and sets their Health
+func (openEVEC *OpenEVEC) setProbesHealth(apps []*eve.AppInstState) error {
+	store, err := openEVEC.loadProbes()
+	if err != nil {
+		return err
+	}
+	for _, app := range apps {
+		specs := store.Get(app.Name)
+		if len(specs) == 0 {
+			continue
+		}
+		statuses := make([]*probe.Status, 0, len(specs))
+		for _, spec := range specs {
+			// one run is enough to evaluate health for listing
+			oneShot := *spec
+			oneShot.SuccessThreshold, oneShot.FailureThreshold = 1, 1
+			statuses = append(statuses, probe.NewStatus(&oneShot))
+		}
+		app.Health = openEVEC.runProbes(app, statuses)
+	}
+	return nil
+}
+
+// probeTarget maps port of app into the port forwarded to the host
+func probeTarget(app *eve.AppInstState, port int) (probe.Target, error) {
+	if app.ExternalIP == "" || app.ExternalIP == "-" || app.ExternalPort == "" {
+		return probe.Target{}, fmt.Errorf("no ports of app %s are forwarded", app.Name)
+	}
+	internal := strings.Split(app.InternalPort, ",")
+	external := strings.Split(app.ExternalPort, ",")
+	for i, p := range internal {
+		if p != strconv.Itoa(port) || i >= len(external) {
+			continue
+		}
+		extPort, err := strconv.Atoi(external[i])
+		if err != nil {
+			return probe.Target{}, fmt.Errorf("invalid forwarded port %q: %w", external[i], err)
+		}
+		return probe.Target{Host: app.ExternalIP, Port: extPort}, nil
+	}
+	return probe.Target{}, fmt.Errorf("port %d of app %s is not forwarded", port, app.Name)
+}
+
+// probeExec runs command of exec probe with ssh, it uses eden SSH key if the probe
+// does not define its own one
+func (openEVEC *OpenEVEC) probeExec(ctx context.Context, target probe.Target, spec *probe.Spec) (string, error) {
+	key := spec.SSHKey
+	if key == "" {
+		key = strings.TrimSuffix(openEVEC.cfg.Eden.SSHKey, ".pub")
+	}
+	args := []string{"-i", key, "-p", strconv.Itoa(target.Port),
+		"-o", "StrictHostKeyChecking=no", "-o", "UserKnownHostsFile=/dev/null",
+		"-o", "BatchMode=yes", "-o", "LogLevel=ERROR",
+		fmt.Sprintf("%s@%s", spec.User, target.Host), spec.Command}
+	out, err := exec.CommandContext(ctx, "ssh", args...).CombinedOutput()
+	if err != nil {
+		return string(out), fmt.Errorf("%s: %w", strings.TrimSpace(string(out)), err)
+	}
+	return string(out), nil
+}
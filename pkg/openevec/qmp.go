@@ -0,0 +1,148 @@
+package openevec
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/lf-edge/eden/pkg/defaults"
+	"github.com/lf-edge/eden/pkg/eden"
+)
+
+// qmpCommand describes command of QEMU monitor allowed to run with 'eden eve qmp'
+type qmpCommand struct {
+	usage       string
+	description string
+	args        int
+	// check validates arguments and may rewrite them
+	check func(args []string) error
+}
+
+// qmpInfoTopics are topics allowed for info command
+var qmpInfoTopics = []string{"balloon", "block", "cpus", "history", "network", "pci", "qtree", "status", "usb"}
+
+// qmpCommands is the subset of commands of QEMU monitor which do not stop or break EVE
+var qmpCommands = map[string]qmpCommand{
+	"device_add": {
+		usage:       "<driver>[,<prop>=<value>...]",
+		description: "hot-add device, e.g. usb-tablet,id=tablet0",
+		args:        1,
+	},
+	"device_del": {
+		usage:       "<id>",
+		description: "hot-remove device added before",
+		args:        1,
+	},
+	"set_link": {
+		usage:       "<nic> on|off",
+		description: "set link state of nic",
+		args:        2,
+		check: func(args []string) error {
+			if args[1] != "on" && args[1] != "off" {
+				return fmt.Errorf("link state must be on or off, not %s", args[1])
+			}
+			return nil
+		},
+	},
+	"balloon": {
+		usage:       "<MiB>",
+		description: "set size of memory balloon",
+		args:        1,
+		check: func(args []string) error {
+			if size, err := strconv.Atoi(args[0]); err != nil || size <= 0 {
+				return fmt.Errorf("size of balloon must be positive number of MiB, not %s", args[0])
+			}
+			return nil
+		},
+	},
+	"screendump": {
+		usage:       "<file.ppm>",
+		description: "save screen of EVE into file",
+		args:        1,
+		check: func(args []string) error {
+			// QEMU resolves relative path from its working directory
+			path, err := filepath.Abs(args[0])
+			if err != nil {
+				return err
+			}
+			args[0] = path
+			return nil
+		},
+	},
+	"info": {
+		usage:       "<topic>",
+		description: "show state of VM, topic is one of " + strings.Join(qmpInfoTopics, ", "),
+		args:        1,
+		check: func(args []string) error {
+			for _, topic := range qmpInfoTopics {
+				if args[0] == topic {
+					return nil
+				}
+			}
+			return fmt.Errorf("unsupported info topic %s", args[0])
+		},
+	},
+}
+
+// QmpUsage returns description of commands supported by 'eden eve qmp'
+func QmpUsage() string {
+	var names []string
+	for name := range qmpCommands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var lines []string
+	for _, name := range names {
+		cmd := qmpCommands[name]
+		lines = append(lines, fmt.Sprintf("  %-40s %s", name+" "+cmd.usage, cmd.description))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// QmpCommandLine validates command and its arguments against the supported subset
+// and returns line to send into QEMU monitor
+func QmpCommandLine(command string, args []string) (string, error) {
+	cmd, ok := qmpCommands[command]
+	if !ok {
+		return "", fmt.Errorf("unsupported command %s, supported commands:\n%s", command, QmpUsage())
+	}
+	if len(args) != cmd.args {
+		return "", fmt.Errorf("usage: %s %s", command, cmd.usage)
+	}
+	args = append([]string{}, args...)
+	for _, arg := range args {
+		// monitor reads commands line by line, so do not allow to inject another one
+		if arg == "" || strings.ContainsAny(arg, " \t\r\n") {
+			return "", fmt.Errorf("invalid argument %q", arg)
+		}
+	}
+	if cmd.check != nil {
+		if err := cmd.check(args); err != nil {
+			return "", err
+		}
+	}
+	return strings.Join(append([]string{command}, args...), " "), nil
+}
+
+// EveQmp runs command from the supported subset in QEMU monitor of EVE and returns its output
+func (openEVEC *OpenEVEC) EveQmp(command string, args []string) (string, error) {
+	cfg := openEVEC.cfg
+	if cfg.Eve.Remote || cfg.Eve.DevModel != defaults.DefaultQemuModel {
+		return "", fmt.Errorf("QEMU monitor is available only for local EVE with devmodel %s", defaults.DefaultQemuModel)
+	}
+	line, err := QmpCommandLine(command, args)
+	if err != nil {
+		return "", err
+	}
+	out, err := eden.QemuMonitorCommand(cfg.Eve.QemuConfig.MonitorPort, line)
+	if err != nil {
+		return "", fmt.Errorf("qemu monitor '%s': %w", line, err)
+	}
+	// monitor reports failures as text, return them as error for scripts
+	if strings.HasPrefix(out, "Error: ") {
+		return "", fmt.Errorf("qemu monitor '%s': %s", line, strings.TrimPrefix(out, "Error: "))
+	}
+	return out, nil
+}
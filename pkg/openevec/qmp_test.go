@@ -0,0 +1,34 @@
+package openevec_test
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/lf-edge/eden/pkg/openevec"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestQmpCommandLine(t *testing.T) {
+	t.Parallel()
+
+	line, err := openevec.QmpCommandLine("set_link", []string{"eth1", "off"})
+	assert.NoError(t, err)
+	assert.Equal(t, "set_link eth1 off", line)
+
+	line, err = openevec.QmpCommandLine("screendump", []string{"screen.ppm"})
+	assert.NoError(t, err)
+	path, _ := filepath.Abs("screen.ppm")
+	assert.Equal(t, "screendump "+path, line)
+
+	for _, args := range [][]string{
+		{"quit"},
+		{"set_link", "eth1", "down"},
+		{"balloon", "-1"},
+		{"info", "registers"},
+		{"device_del", "dev0\nquit"},
+		{"device_add"},
+	} {
+		_, err = openevec.QmpCommandLine(args[0], args[1:])
+		assert.Error(t, err, args)
+	}
+}
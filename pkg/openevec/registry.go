@@ -2,13 +2,40 @@ package openevec
 
 import (
 	"fmt"
+	"net"
 	"os"
+	"strconv"
 
 	"github.com/lf-edge/eden/pkg/eden"
 	"github.com/lf-edge/eden/pkg/utils"
 	log "github.com/sirupsen/logrus"
 )
 
+// RegistryAddress returns address of registry selected for deployment:
+// "local" for registry started by eden, "eserver" for registry embedded in eserver,
+// "remote" (empty address) for registry from image reference or address as is
+func RegistryAddress(cfg *EdenSetupArgs, registry string) string {
+	switch registry {
+	case "local":
+		return net.JoinHostPort(cfg.Registry.IP, strconv.Itoa(cfg.Registry.Port))
+	case "eserver":
+		return net.JoinHostPort(cfg.Eden.EServer.IP, strconv.Itoa(cfg.Eden.EServer.Port))
+	case "remote":
+		return ""
+	}
+	return registry
+}
+
+// DaemonRegistry returns registry to push images from docker daemon into,
+// EVE cannot pull them from the daemon, so the local registry is used
+// if registry is not selected or the remote one (default) is selected
+func DaemonRegistry(registry string) string {
+	if registry == "" || registry == "remote" {
+		return "local"
+	}
+	return registry
+}
+
 func (openEVEC *OpenEVEC) RegistryStart() error {
 	cfg := openEVEC.cfg.Registry
 	command, err := os.Executable()
@@ -23,9 +50,35 @@ func (openEVEC *OpenEVEC) RegistryStart() error {
 	return nil
 }
 
+// RegistryPush pushes image from tarball into registry embedded in eserver
+func (openEVEC *OpenEVEC) RegistryPush(tarPath, imageName string) error {
+	registry := RegistryAddress(openEVEC.cfg, "eserver")
+	hash, err := utils.PushImageTar(tarPath, imageName, registry)
+	if err != nil {
+		return fmt.Errorf("failed to push image from %s: %w", tarPath, err)
+	}
+	fmt.Printf("image from %s pushed into %s with manifest hash %s\n", tarPath, registry, hash)
+	return nil
+}
+
+// RegistryGC removes blobs not referenced by manifests from registry embedded in eserver
+func (openEVEC *OpenEVEC) RegistryGC() error {
+	cfg := openEVEC.cfg.Eden.EServer
+	server := &eden.EServer{
+		EServerIP:   cfg.IP,
+		EServerPort: strconv.Itoa(cfg.Port),
+		TLS:         cfg.TLS,
+	}
+	result, err := server.EServerRegistryGC()
+	if err != nil {
+		return err
+	}
+	fmt.Println(result)
+	return nil
+}
+
 func (openEVEC *OpenEVEC) RegistryLoad(ref string) error {
-	cfg := openEVEC.cfg.Registry
-	registry := fmt.Sprintf("%s:%d", cfg.IP, cfg.Port)
+	registry := RegistryAddress(openEVEC.cfg, "local")
 	hash, err := utils.LoadRegistry(ref, registry)
 	if err != nil {
 		return fmt.Errorf("failed to load image %s: %w", ref, err)
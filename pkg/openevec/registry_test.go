@@ -0,0 +1,61 @@
+package openevec_test
+
+import (
+	"testing"
+
+	"github.com/lf-edge/eden/pkg/openevec"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRegistryAddress(t *testing.T) {
+	t.Parallel()
+
+	testMatrix := map[string]struct {
+		registryIP string
+		eserverIP  string
+		registry   string
+		address    string
+	}{
+		"local ipv4": {
+			registryIP: "192.168.0.10",
+			registry:   "local",
+			address:    "192.168.0.10:5050",
+		},
+		"local ipv6": {
+			registryIP: "fd00::10",
+			registry:   "local",
+			address:    "[fd00::10]:5050",
+		},
+		"eserver ipv6": {
+			eserverIP: "2001:db8::1",
+			registry:  "eserver",
+			address:   "[2001:db8::1]:8888",
+		},
+		"remote": {
+			registry: "remote",
+			address:  "",
+		},
+		"custom": {
+			registry: "registry.example.com:5000",
+			address:  "registry.example.com:5000",
+		},
+	}
+
+	for name, tt := range testMatrix {
+		cfg := &openevec.EdenSetupArgs{
+			Registry: openevec.RegistryConfig{IP: tt.registryIP, Port: 5050},
+			Eden:     openevec.EdenConfig{EServer: openevec.EServerConfig{IP: tt.eserverIP, Port: 8888}},
+		}
+		assert.Equal(t, tt.address, openevec.RegistryAddress(cfg, tt.registry), name)
+	}
+}
+
+func TestDaemonRegistry(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, "local", openevec.DaemonRegistry(""))
+	assert.Equal(t, "local", openevec.DaemonRegistry("remote"))
+	assert.Equal(t, "local", openevec.DaemonRegistry("local"))
+	assert.Equal(t, "eserver", openevec.DaemonRegistry("eserver"))
+	assert.Equal(t, "registry.example.com:5000", openevec.DaemonRegistry("registry.example.com:5000"))
+}
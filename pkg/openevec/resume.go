@@ -0,0 +1,175 @@
+package openevec
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/lf-edge/eden/pkg/defaults"
+	"github.com/lf-edge/eden/pkg/edensdn"
+	"github.com/lf-edge/eden/pkg/utils"
+	sdnapi "github.com/lf-edge/eden/sdn/vm/api"
+	log "github.com/sirupsen/logrus"
+)
+
+// resumeState stores data required to restore eden environment after host reboot
+type resumeState struct {
+	BootID        string               `json:"bootID"`
+	VMName        string               `json:"vmName"`
+	TapInterface  string               `json:"tapInterface,omitempty"`
+	ZedControlURL string               `json:"zedControlURL,omitempty"`
+	HostFwd       map[string]string    `json:"hostFwd,omitempty"`
+	NetModel      *sdnapi.NetworkModel `json:"netModel,omitempty"`
+	SdnMgmtSubnet string               `json:"sdnMgmtSubnet,omitempty"`
+	SdnDHCPStart  string               `json:"sdnDHCPStart,omitempty"`
+}
+
+func resumeStateFile() (string, error) {
+	edenDir, err := utils.DefaultEdenDir()
+	if err != nil {
+		return "", err
+	}
+	context, err := utils.ContextLoad()
+	if err != nil {
+		return "", fmt.Errorf("load context error: %w", err)
+	}
+	return filepath.Join(edenDir, fmt.Sprintf("%s-%s", context.Current, defaults.DefaultResumeStateFile)), nil
+}
+
+// loadResumeStateFile loads state saved in stateFile
+func loadResumeStateFile(stateFile string) (*resumeState, error) {
+	data, err := os.ReadFile(stateFile)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read state file %s: %w", stateFile, err)
+	}
+	var state resumeState
+	if err := json.Unmarshal(data, &state); err != nil {
+		return nil, fmt.Errorf("cannot unmarshal state file %s: %w", stateFile, err)
+	}
+	return &state, nil
+}
+
+// updateResumeStateFile loads state saved in stateFile (if any), applies changes and saves it back
+func updateResumeStateFile(stateFile string, update func(state *resumeState)) error {
+	state, err := loadResumeStateFile(stateFile)
+	if err != nil {
+		state = &resumeState{}
+	}
+	update(state)
+	state.BootID = utils.HostBootID()
+	data, err := json.MarshalIndent(state, "", "    ")
+	if err != nil {
+		return fmt.Errorf("cannot marshal state: %w", err)
+	}
+	return os.WriteFile(stateFile, data, 0644)
+}
+
+func loadResumeState() (*resumeState, error) {
+	stateFile, err := resumeStateFile()
+	if err != nil {
+		return nil, err
+	}
+	return loadResumeStateFile(stateFile)
+}
+
+// updateResumeState updates state of the current context
+func updateResumeState(update func(state *resumeState)) error {
+	stateFile, err := resumeStateFile()
+	if err != nil {
+		return err
+	}
+	return updateResumeStateFile(stateFile, update)
+}
+
+// removeResumeStateFile removes state saved in stateFile, missing file is not an error
+func removeResumeStateFile(stateFile string) error {
+	if err := os.Remove(stateFile); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// removeResumeState removes state of the current context
+func removeResumeState() error {
+	stateFile, err := resumeStateFile()
+	if err != nil {
+		return err
+	}
+	return removeResumeStateFile(stateFile)
+}
+
+// MgmtSubnet returns SDN management subnet from saved state, nil if not saved
+func (state *resumeState) MgmtSubnet() (*edensdn.SdnMgmtSubnet, error) {
+	if state.SdnMgmtSubnet == "" {
+		return nil, nil
+	}
+	_, ipNet, err := net.ParseCIDR(state.SdnMgmtSubnet)
+	if err != nil {
+		return nil, fmt.Errorf("cannot parse SDN management subnet %s: %w", state.SdnMgmtSubnet, err)
+	}
+	dhcpStart := net.ParseIP(state.SdnDHCPStart)
+	if dhcpStart == nil {
+		return nil, fmt.Errorf("cannot parse SDN DHCP start %s", state.SdnDHCPStart)
+	}
+	if !ipNet.Contains(dhcpStart) {
+		return nil, fmt.Errorf("SDN DHCP start %s is out of management subnet %s", dhcpStart, ipNet)
+	}
+	return &edensdn.SdnMgmtSubnet{IPNet: ipNet, DHCPStart: dhcpStart}, nil
+}
+
+// SetMgmtSubnet saves SDN management subnet to reuse it on resume
+func (state *resumeState) SetMgmtSubnet(subnet *edensdn.SdnMgmtSubnet) {
+	state.SdnMgmtSubnet = subnet.IPNet.String()
+	state.SdnDHCPStart = subnet.DHCPStart.String()
+}
+
+// removeStalePidFile removes pid file left from the previous boot of the host,
+// pid inside may belong to unrelated process now, so we remove it if rebooted
+// is set regardless of the process state
+func removeStalePidFile(pidFile string, rebooted bool) {
+	if pidFile == "" {
+		return
+	}
+	if _, err := os.Stat(pidFile); os.IsNotExist(err) {
+		return
+	}
+	if !rebooted {
+		if status, _ := utils.StatusCommandWithPid(pidFile); strings.Contains(status, "running with pid") {
+			return
+		}
+	}
+	if err := os.Remove(pidFile); err != nil {
+		log.Warnf("cannot remove stale pid file %s: %s", pidFile, err)
+		return
+	}
+	log.Infof("Removed stale pid file %s", pidFile)
+}
+
+// prepareResume loads saved state and cleans leftovers of previous host boot
+func (openEVEC *OpenEVEC) prepareResume() (*resumeState, error) {
+	cfg := openEVEC.cfg
+	state, err := loadResumeState()
+	if err != nil {
+		return nil, fmt.Errorf("no saved state to resume from, please run 'eden start' first: %w", err)
+	}
+	// do not recreate containers to keep the data of controller
+	cfg.Adam.Force = false
+	cfg.Redis.Force = false
+	cfg.Eden.EServer.Force = false
+	if state.HostFwd != nil {
+		cfg.Eve.HostFwd = state.HostFwd
+	}
+	// if we cannot detect boot of the host, we rely on the state of processes
+	bootID := utils.HostBootID()
+	rebooted := bootID != "" && bootID != state.BootID
+	if rebooted {
+		log.Info("Host was rebooted since the last start, cleaning stale pid files")
+	}
+	removeStalePidFile(cfg.Eve.Pid, rebooted)
+	removeStalePidFile(cfg.Sdn.PidFile, rebooted)
+	removeStalePidFile(filepath.Join(filepath.Dir(cfg.Eve.ImageFile), "swtpm", "swtpm.pid"), rebooted)
+	return state, nil
+}
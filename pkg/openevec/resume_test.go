@@ -0,0 +1,105 @@
+package openevec
+
+import (
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/lf-edge/eden/pkg/edensdn"
+	sdnapi "github.com/lf-edge/eden/sdn/vm/api"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestResumeStateRoundTrip(t *testing.T) {
+	t.Parallel()
+
+	stateFile := filepath.Join(t.TempDir(), "resume.json")
+	_, err := loadResumeStateFile(stateFile)
+	assert.Error(t, err)
+
+	// updates are applied on top of the saved state
+	assert.NoError(t, updateResumeStateFile(stateFile, func(state *resumeState) {
+		state.VMName = "eve-vm"
+		state.HostFwd = map[string]string{"2222": "22"}
+	}))
+	assert.NoError(t, updateResumeStateFile(stateFile, func(state *resumeState) {
+		state.NetModel = &sdnapi.NetworkModel{Host: &sdnapi.HostConfig{HostIPs: []string{"fd00::1"}}}
+	}))
+
+	state, err := loadResumeStateFile(stateFile)
+	if assert.NoError(t, err) {
+		assert.Equal(t, "eve-vm", state.VMName)
+		assert.Equal(t, map[string]string{"2222": "22"}, state.HostFwd)
+		if assert.NotNil(t, state.NetModel) {
+			assert.Equal(t, []string{"fd00::1"}, state.NetModel.Host.HostIPs)
+		}
+	}
+
+	// broken state is replaced on update
+	assert.NoError(t, os.WriteFile(stateFile, []byte("{"), 0644))
+	_, err = loadResumeStateFile(stateFile)
+	assert.Error(t, err)
+	assert.NoError(t, updateResumeStateFile(stateFile, func(state *resumeState) {
+		state.TapInterface = "tap0"
+	}))
+	state, err = loadResumeStateFile(stateFile)
+	if assert.NoError(t, err) {
+		assert.Equal(t, "tap0", state.TapInterface)
+		assert.Empty(t, state.VMName)
+	}
+}
+
+func TestResumeStateReset(t *testing.T) {
+	t.Parallel()
+
+	stateFile := filepath.Join(t.TempDir(), "resume.json")
+	assert.NoError(t, removeResumeStateFile(stateFile), "missing state is not an error")
+
+	assert.NoError(t, updateResumeStateFile(stateFile, func(state *resumeState) {
+		state.VMName = "eve-vm"
+		state.NetModel = &sdnapi.NetworkModel{Host: &sdnapi.HostConfig{HostIPs: []string{"fd00::1"}}}
+		state.SdnMgmtSubnet = "192.168.100.0/24"
+	}))
+
+	// fresh start drops state of the previous run, so it is not merged into the new one
+	assert.NoError(t, removeResumeStateFile(stateFile))
+	_, err := loadResumeStateFile(stateFile)
+	assert.Error(t, err)
+	assert.NoError(t, updateResumeStateFile(stateFile, func(state *resumeState) {
+		state.VMName = "eve-vm-2"
+	}))
+	state, err := loadResumeStateFile(stateFile)
+	if assert.NoError(t, err) {
+		assert.Equal(t, "eve-vm-2", state.VMName)
+		assert.Nil(t, state.NetModel)
+		assert.Empty(t, state.SdnMgmtSubnet)
+	}
+}
+
+func TestResumeStateMgmtSubnet(t *testing.T) {
+	t.Parallel()
+
+	state := &resumeState{}
+	subnet, err := state.MgmtSubnet()
+	assert.NoError(t, err)
+	assert.Nil(t, subnet, "subnet is not saved")
+
+	_, ipNet, _ := net.ParseCIDR("192.168.100.0/24")
+	state.SetMgmtSubnet(&edensdn.SdnMgmtSubnet{IPNet: ipNet, DHCPStart: net.ParseIP("192.168.100.10")})
+	subnet, err = state.MgmtSubnet()
+	if assert.NoError(t, err) {
+		assert.Equal(t, "192.168.100.0/24", subnet.IPNet.String())
+		assert.Equal(t, "192.168.100.10", subnet.DHCPStart.String())
+	}
+
+	testMatrix := map[string]resumeState{
+		"broken subnet":      {SdnMgmtSubnet: "192.168.100.0", SdnDHCPStart: "192.168.100.10"},
+		"broken DHCP start":  {SdnMgmtSubnet: "192.168.100.0/24", SdnDHCPStart: "192.168.100"},
+		"DHCP out of subnet": {SdnMgmtSubnet: "192.168.100.0/24", SdnDHCPStart: "192.168.101.10"},
+	}
+	for name, state := range testMatrix {
+		_, err := state.MgmtSubnet()
+		assert.Error(t, err, name)
+	}
+}
@@ -237,7 +237,7 @@ func (openEVEC *OpenEVEC) SdnNetModelApply(ref string) error {
 	var err error
 	var newNetModel sdnapi.NetworkModel
 	if ref == "default" {
-		newNetModel, err = edensdn.GetDefaultNetModel()
+		newNetModel, err = defaultSdnNetModel(cfg)
 		if err != nil {
 			return err
 		}
@@ -274,6 +274,11 @@ func (openEVEC *OpenEVEC) SdnNetModelApply(ref string) error {
 	if err != nil {
 		return fmt.Errorf("failed to apply network model: %w", err)
 	}
+	if err = updateResumeState(func(state *resumeState) {
+		state.NetModel = &newNetModel
+	}); err != nil {
+		log.Warnf("cannot save state of SDN: %s", err)
+	}
 	fmt.Printf("Submitted network model: %s", ref)
 	return nil
 }
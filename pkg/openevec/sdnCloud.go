@@ -0,0 +1,106 @@
+package openevec
+
+import (
+	"fmt"
+	"net"
+
+	"github.com/lf-edge/eden/pkg/edensdn"
+	sdnapi "github.com/lf-edge/eden/sdn/vm/api"
+	log "github.com/sirupsen/logrus"
+)
+
+// cloudCutRules returns firewall rules of SDN dropping traffic to the controller on the host,
+// other services of the host (e.g. LOC or eserver) stay reachable
+func cloudCutRules(host *sdnapi.HostConfig) ([]sdnapi.FwRule, error) {
+	if host == nil || len(host.HostIPs) == 0 {
+		return nil, fmt.Errorf("no host IPs in network model of SDN")
+	}
+	var rules []sdnapi.FwRule
+	for _, hostIP := range host.HostIPs {
+		ip := net.ParseIP(hostIP)
+		if ip == nil {
+			return nil, fmt.Errorf("cannot parse host IP %s", hostIP)
+		}
+		subnet := hostIP + "/32"
+		if ip.To4() == nil {
+			subnet = hostIP + "/128"
+		}
+		rules = append(rules, sdnapi.FwRule{
+			DstSubnet: subnet,
+			Protocol:  sdnapi.TCP,
+			Ports:     []uint16{host.ControllerPort},
+			Action:    sdnapi.FwDrop,
+		})
+	}
+	return rules, nil
+}
+
+// withoutRules returns rules except ones from remove
+func withoutRules(rules, remove []sdnapi.FwRule) []sdnapi.FwRule {
+	var result []sdnapi.FwRule
+	for _, rule := range rules {
+		removed := false
+		for _, r := range remove {
+			if rule.DstSubnet == r.DstSubnet && rule.SrcSubnet == r.SrcSubnet &&
+				rule.Protocol == r.Protocol && rule.Action == r.Action &&
+				len(rule.Ports) == 1 && rule.Ports[0] == r.Ports[0] {
+				removed = true
+				break
+			}
+		}
+		if !removed {
+			result = append(result, rule)
+		}
+	}
+	return result
+}
+
+// SdnCloudCut makes the controller unreachable for EVE and its apps by firewall of SDN,
+// connectivity to other services of the host and to the Internet is kept
+func (openEVEC *OpenEVEC) SdnCloudCut() error {
+	return openEVEC.sdnCloudConnectivity(true)
+}
+
+// SdnCloudRestore removes firewall rules added by SdnCloudCut
+func (openEVEC *OpenEVEC) SdnCloudRestore() error {
+	return openEVEC.sdnCloudConnectivity(false)
+}
+
+func (openEVEC *OpenEVEC) sdnCloudConnectivity(cut bool) error {
+	cfg := openEVEC.cfg
+	if !isSdnEnabled(cfg.Sdn.Disable, cfg.Eve.Remote, cfg.Eve.DevModel) {
+		return fmt.Errorf("SDN is not enabled")
+	}
+	client := &edensdn.SdnClient{
+		SSHPort:    uint16(cfg.Sdn.SSHPort),
+		SSHKeyPath: sdnSSHKeyPath(cfg.Sdn.SourceDir),
+		MgmtPort:   uint16(cfg.Sdn.MgmtPort),
+	}
+	netModel, err := client.GetNetworkModel()
+	if err != nil {
+		return fmt.Errorf("failed to get network model: %w", err)
+	}
+	rules, err := cloudCutRules(netModel.Host)
+	if err != nil {
+		return err
+	}
+	netModel.Firewall.Rules = withoutRules(netModel.Firewall.Rules, rules)
+	if cut {
+		// rules are applied in order, so they must precede rules allowing the traffic
+		netModel.Firewall.Rules = append(rules, netModel.Firewall.Rules...)
+	}
+	if err = client.ApplyNetworkModel(netModel); err != nil {
+		return fmt.Errorf("failed to apply network model: %w", err)
+	}
+	if err = updateResumeState(func(state *resumeState) {
+		state.NetModel = &netModel
+	}); err != nil {
+		log.Warnf("cannot save state of SDN: %s", err)
+	}
+	if cut {
+		log.Infof("Controller on port %d is not reachable from SDN", netModel.Host.ControllerPort)
+	} else {
+		log.Info("Connectivity with the controller is restored")
+	}
+	return nil
+}
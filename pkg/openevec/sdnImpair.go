@@ -0,0 +1,157 @@
+package openevec
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/lf-edge/eden/pkg/edensdn"
+	sdnapi "github.com/lf-edge/eden/sdn/vm/api"
+)
+
+// SdnImpairment is impairment of SDN port in units used by 'eden sdn impair set'
+type SdnImpairment struct {
+	Delay      time.Duration
+	Jitter     time.Duration
+	Loss       uint8
+	Corrupt    uint8
+	Duplicate  uint8
+	Reorder    uint8
+	Rate       uint32 // kilobytes per second
+	Queue      uint32 // kilobytes
+	Burst      uint32 // kilobytes
+	FlapPeriod time.Duration
+	FlapDown   time.Duration
+}
+
+// portImpairment converts impairment into API of SDN, queue and burst are derived from rate if not set
+func (imp SdnImpairment) portImpairment(port string) (sdnapi.PortImpairment, error) {
+	for _, p := range []uint8{imp.Loss, imp.Corrupt, imp.Duplicate, imp.Reorder} {
+		if p > 100 {
+			return sdnapi.PortImpairment{}, fmt.Errorf("probability must be in percent: %d", p)
+		}
+	}
+	queue, burst := imp.Queue, imp.Burst
+	if imp.Rate != 0 {
+		if queue == 0 {
+			// one second of traffic
+			queue = imp.Rate
+		}
+		if burst == 0 {
+			// tbf needs burst of at least one packet
+			burst = imp.Rate / 10
+			if burst < 2 {
+				burst = 2
+			}
+		}
+	}
+	return sdnapi.PortImpairment{
+		Port: port,
+		TC: sdnapi.TrafficControl{
+			Delay:                uint32(imp.Delay.Milliseconds()),
+			DelayJitter:          uint32(imp.Jitter.Milliseconds()),
+			LossProbability:      imp.Loss,
+			CorruptProbability:   imp.Corrupt,
+			DuplicateProbability: imp.Duplicate,
+			ReorderProbability:   imp.Reorder,
+			RateLimit:            imp.Rate,
+			QueueLimit:           queue,
+			BurstLimit:           burst,
+		},
+		LinkFlap: sdnapi.LinkFlap{
+			Period: uint32(imp.FlapPeriod.Seconds()),
+			Down:   uint32(imp.FlapDown.Seconds()),
+		},
+	}, nil
+}
+
+// SdnImpairGet returns impairments applied to SDN ports in JSON
+func (openEVEC *OpenEVEC) SdnImpairGet() (string, error) {
+	cfg := openEVEC.cfg
+	if !isSdnEnabled(cfg.Sdn.Disable, cfg.Eve.Remote, cfg.Eve.DevModel) {
+		return "", fmt.Errorf("SDN is not enabled")
+	}
+	client := &edensdn.SdnClient{
+		SSHPort:    uint16(cfg.Sdn.SSHPort),
+		SSHKeyPath: sdnSSHKeyPath(cfg.Sdn.SourceDir),
+		MgmtPort:   uint16(cfg.Sdn.MgmtPort),
+	}
+	impairments, err := client.GetImpairments()
+	if err != nil {
+		return "", fmt.Errorf("failed to get impairments: %w", err)
+	}
+	jsonBytes, err := json.MarshalIndent(impairments, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal impairments to JSON: %w", err)
+	}
+	return string(jsonBytes), nil
+}
+
+// SdnImpairSet applies impairment to SDN port replacing the previous impairment of the port,
+// impairments of other ports are kept
+func (openEVEC *OpenEVEC) SdnImpairSet(port string, imp SdnImpairment) error {
+	cfg := openEVEC.cfg
+	if !isSdnEnabled(cfg.Sdn.Disable, cfg.Eve.Remote, cfg.Eve.DevModel) {
+		return fmt.Errorf("SDN is not enabled")
+	}
+	portImp, err := imp.portImpairment(port)
+	if err != nil {
+		return err
+	}
+	if portImp.IsEmpty() {
+		return fmt.Errorf("no impairment of port %s set, use 'eden sdn impair clear %s' to remove it", port, port)
+	}
+	client := &edensdn.SdnClient{
+		SSHPort:    uint16(cfg.Sdn.SSHPort),
+		SSHKeyPath: sdnSSHKeyPath(cfg.Sdn.SourceDir),
+		MgmtPort:   uint16(cfg.Sdn.MgmtPort),
+	}
+	impairments, err := client.GetImpairments()
+	if err != nil {
+		return fmt.Errorf("failed to get impairments: %w", err)
+	}
+	var ports []sdnapi.PortImpairment
+	for _, p := range impairments.Ports {
+		if p.Port != port {
+			ports = append(ports, p)
+		}
+	}
+	impairments.Ports = append(ports, portImp)
+	if err = client.ApplyImpairments(impairments); err != nil {
+		return fmt.Errorf("failed to apply impairments: %w", err)
+	}
+	return nil
+}
+
+// SdnImpairClear removes impairments of SDN ports, all of them if no ports are set
+func (openEVEC *OpenEVEC) SdnImpairClear(ports []string) error {
+	cfg := openEVEC.cfg
+	if !isSdnEnabled(cfg.Sdn.Disable, cfg.Eve.Remote, cfg.Eve.DevModel) {
+		return fmt.Errorf("SDN is not enabled")
+	}
+	client := &edensdn.SdnClient{
+		SSHPort:    uint16(cfg.Sdn.SSHPort),
+		SSHKeyPath: sdnSSHKeyPath(cfg.Sdn.SourceDir),
+		MgmtPort:   uint16(cfg.Sdn.MgmtPort),
+	}
+	var impairments sdnapi.Impairments
+	if len(ports) > 0 {
+		current, err := client.GetImpairments()
+		if err != nil {
+			return fmt.Errorf("failed to get impairments: %w", err)
+		}
+		cleared := map[string]bool{}
+		for _, port := range ports {
+			cleared[port] = true
+		}
+		for _, p := range current.Ports {
+			if !cleared[p.Port] {
+				impairments.Ports = append(impairments.Ports, p)
+			}
+		}
+	}
+	if err := client.ApplyImpairments(impairments); err != nil {
+		return fmt.Errorf("failed to apply impairments: %w", err)
+	}
+	return nil
+}
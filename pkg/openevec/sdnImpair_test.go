@@ -0,0 +1,71 @@
+package openevec
+
+import (
+	"testing"
+	"time"
+
+	sdnapi "github.com/lf-edge/eden/sdn/vm/api"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSdnImpairmentPortImpairment(t *testing.T) {
+	t.Parallel()
+
+	testMatrix := map[string]struct {
+		imp     SdnImpairment
+		tc      sdnapi.TrafficControl
+		flap    sdnapi.LinkFlap
+		empty   bool
+		invalid bool
+	}{
+		"no impairment": {
+			empty: true,
+		},
+		"delay and loss": {
+			imp: SdnImpairment{Delay: 200 * time.Millisecond, Jitter: 20 * time.Millisecond, Loss: 10},
+			tc:  sdnapi.TrafficControl{Delay: 200, DelayJitter: 20, LossProbability: 10},
+		},
+		"rate limit without queue and burst": {
+			imp: SdnImpairment{Rate: 100},
+			tc:  sdnapi.TrafficControl{RateLimit: 100, QueueLimit: 100, BurstLimit: 10},
+		},
+		"low rate limit gets minimal burst": {
+			imp: SdnImpairment{Rate: 8},
+			tc:  sdnapi.TrafficControl{RateLimit: 8, QueueLimit: 8, BurstLimit: 2},
+		},
+		"rate limit with queue and burst": {
+			imp: SdnImpairment{Rate: 100, Queue: 50, Burst: 5},
+			tc:  sdnapi.TrafficControl{RateLimit: 100, QueueLimit: 50, BurstLimit: 5},
+		},
+		"queue without rate limit": {
+			imp: SdnImpairment{Queue: 50},
+			tc:  sdnapi.TrafficControl{QueueLimit: 50},
+		},
+		"link flap": {
+			imp:  SdnImpairment{FlapPeriod: time.Minute, FlapDown: 10 * time.Second},
+			flap: sdnapi.LinkFlap{Period: 60, Down: 10},
+		},
+		"link flap without period": {
+			imp:   SdnImpairment{FlapDown: 10 * time.Second},
+			flap:  sdnapi.LinkFlap{Down: 10},
+			empty: true,
+		},
+		"probability out of range": {
+			imp:     SdnImpairment{Corrupt: 101},
+			invalid: true,
+		},
+	}
+	for name, tt := range testMatrix {
+		portImp, err := tt.imp.portImpairment("eth1")
+		if tt.invalid {
+			assert.Error(t, err, name)
+			continue
+		}
+		if assert.NoError(t, err, name) {
+			assert.Equal(t, "eth1", portImp.Port, name)
+			assert.Equal(t, tt.tc, portImp.TC, name)
+			assert.Equal(t, tt.flap, portImp.LinkFlap, name)
+			assert.Equal(t, tt.empty, portImp.IsEmpty(), name)
+		}
+	}
+}
@@ -0,0 +1,192 @@
+package openevec
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/lf-edge/eden/pkg/controller/types"
+	"github.com/lf-edge/eden/pkg/defaults"
+	"github.com/lf-edge/eden/pkg/edensdn"
+	"github.com/lf-edge/eden/pkg/models"
+	sdnapi "github.com/lf-edge/eden/sdn/vm/api"
+	"github.com/lf-edge/eve-api/go/config"
+	log "github.com/sirupsen/logrus"
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+// sdnProxyBootstrapFile is bootstrap config generated for the proxy scenario of SDN
+const sdnProxyBootstrapFile = "proxy-bootstrap-config.json"
+
+// defaultSdnNetModel returns network model applied into SDN unless the user selects a custom one,
+// with sdn.proxy set it is the model of the proxy scenario, with eve.cellular.enabled set
+// it includes network for the emulated modem
+func defaultSdnNetModel(cfg *EdenSetupArgs) (netModel sdnapi.NetworkModel, err error) {
+	withSdn := isSdnEnabled(cfg.Sdn.Disable, cfg.Eve.Remote, cfg.Eve.DevModel)
+	if cfg.Sdn.Proxy == "" || !withSdn {
+		netModel, err = edensdn.GetDefaultNetModel(cfg.Sdn.NetworkType)
+	} else {
+		var caCert, caKey string
+		caCert, caKey, err = edensdn.LoadProxyCA(cfg.Sdn.ConfigDir)
+		if err != nil {
+			return netModel, fmt.Errorf("proxy CA is missing, run 'eden setup' first: %w", err)
+		}
+		netModel, err = edensdn.GetProxyNetModel(cfg.Sdn.Proxy, cfg.Sdn.NetworkType, caCert, caKey)
+	}
+	if err != nil || !cfg.Eve.Cellular.Enabled || !withSdn {
+		return netModel, err
+	}
+	err = edensdn.AddCellularNetwork(&netModel)
+	return netModel, err
+}
+
+// setupSdnProxy generates CA of the proxy and returns bootstrap config with the proxy,
+// EVE cannot onboard without it as the controller is reachable only through the proxy
+func setupSdnProxy(cfg EdenSetupArgs) (bootstrapFile string, err error) {
+	if !isSdnEnabled(cfg.Sdn.Disable, cfg.Eve.Remote, cfg.Eve.DevModel) {
+		return "", fmt.Errorf("proxy scenario %s requires SDN to be enabled", cfg.Sdn.Proxy)
+	}
+	if !edensdn.IsProxyScenario(cfg.Sdn.Proxy) {
+		return "", fmt.Errorf("unsupported proxy scenario: %s (supported: %s)",
+			cfg.Sdn.Proxy, strings.Join(edensdn.ProxyScenarios, ", "))
+	}
+	caCert, _, err := edensdn.LoadProxyCA(cfg.Sdn.ConfigDir)
+	if err != nil {
+		if caCert, err = edensdn.GenerateProxyCA(cfg.Sdn.ConfigDir); err != nil {
+			return "", err
+		}
+		log.Infof("Generated proxy CA in %s", cfg.Sdn.ConfigDir)
+	}
+	if cfg.Eve.BootstrapFile != "" {
+		log.Warnf("bootstrap file %s is used instead of one generated for proxy scenario %s",
+			cfg.Eve.BootstrapFile, cfg.Sdn.Proxy)
+		return cfg.Eve.BootstrapFile, nil
+	}
+	devModel, err := models.GetDevModelByName(cfg.Eve.DevModel)
+	if err != nil {
+		return "", fmt.Errorf("GetDevModelByName: %w", err)
+	}
+	if cfg.Eve.DevModelFile != "" {
+		if err := models.OverwriteDevModelFromFile(cfg.Eve.DevModelFile, devModel); err != nil {
+			return "", fmt.Errorf("cannot overwrite devmodel from file: %w", err)
+		}
+	}
+	proxyConfig, err := edensdn.GetProxyDeviceConfig(cfg.Sdn.Proxy, caCert)
+	if err != nil {
+		return "", err
+	}
+	for _, network := range devModel.Networks() {
+		network.EntProxy = proxyConfig
+	}
+	devConfig := &config.EdgeDevConfig{
+		DeviceIoList:      devModel.PhysicalIOs(),
+		Networks:          devModel.Networks(),
+		SystemAdapterList: devModel.Adapters(),
+	}
+	data, err := protojson.MarshalOptions{Multiline: true}.Marshal(devConfig)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal bootstrap config: %w", err)
+	}
+	if err = os.MkdirAll(cfg.Sdn.ConfigDir, 0755); err != nil {
+		return "", err
+	}
+	bootstrapFile = filepath.Join(cfg.Sdn.ConfigDir, sdnProxyBootstrapFile)
+	if err = os.WriteFile(bootstrapFile, data, 0644); err != nil {
+		return "", fmt.Errorf("failed to write bootstrap config: %w", err)
+	}
+	return bootstrapFile, nil
+}
+
+// SdnProxyRotateCA replaces CA of the proxy in SDN. Unless untrusted is set, EVE is told
+// to trust both the previous and the new CA before the proxy switches to the new one,
+// which should not break connectivity with the controller.
+func (openEVEC *OpenEVEC) SdnProxyRotateCA(untrusted bool, timeout time.Duration) error {
+	cfg := openEVEC.cfg
+	if !isSdnEnabled(cfg.Sdn.Disable, cfg.Eve.Remote, cfg.Eve.DevModel) {
+		return fmt.Errorf("SDN is not enabled")
+	}
+	if cfg.Sdn.Proxy == "" {
+		return fmt.Errorf("no proxy scenario is configured in sdn.proxy")
+	}
+	prevCACert, _, err := edensdn.LoadProxyCA(cfg.Sdn.ConfigDir)
+	if err != nil {
+		return err
+	}
+	caCert, err := edensdn.GenerateProxyCA(cfg.Sdn.ConfigDir)
+	if err != nil {
+		return err
+	}
+	if !untrusted {
+		if err = openEVEC.sdnProxyTrustCA(caCert, prevCACert, timeout); err != nil {
+			return err
+		}
+	}
+	netModel, err := defaultSdnNetModel(cfg)
+	if err != nil {
+		return err
+	}
+	netModel.Host.ControllerPort = uint16(cfg.Adam.Port)
+	client := &edensdn.SdnClient{
+		SSHPort:    uint16(cfg.Sdn.SSHPort),
+		SSHKeyPath: sdnSSHKeyPath(cfg.Sdn.SourceDir),
+		MgmtPort:   uint16(cfg.Sdn.MgmtPort),
+	}
+	if err = client.ApplyNetworkModel(netModel); err != nil {
+		return fmt.Errorf("failed to apply network model: %w", err)
+	}
+	if err = updateResumeState(func(state *resumeState) {
+		state.NetModel = &netModel
+	}); err != nil {
+		log.Warnf("cannot save state of SDN: %s", err)
+	}
+	log.Infof("Proxy CA rotated, the new one is stored in %s", cfg.Sdn.ConfigDir)
+	return nil
+}
+
+// sdnProxyTrustCA configures networks of EVE to trust the given CAs and waits until EVE
+// requests the new config
+func (openEVEC *OpenEVEC) sdnProxyTrustCA(caCert, prevCACert string, timeout time.Duration) error {
+	changer := &adamChanger{}
+	ctrl, dev, err := changer.getControllerAndDevFromConfig(openEVEC.cfg)
+	if err != nil {
+		return fmt.Errorf("getControllerAndDevFromConfig: %w", err)
+	}
+	proxyConfig, err := edensdn.GetProxyDeviceConfig(openEVEC.cfg.Sdn.Proxy, caCert, prevCACert)
+	if err != nil {
+		return err
+	}
+	for _, id := range dev.GetNetworks() {
+		network, err := ctrl.GetNetworkConfig(id)
+		if err != nil {
+			return err
+		}
+		if network.EntProxy == nil {
+			continue
+		}
+		network.EntProxy.ProxyCertPEM = proxyConfig.ProxyCertPEM
+	}
+	pushed := time.Now()
+	if err = changer.setControllerAndDev(ctrl, dev); err != nil {
+		return fmt.Errorf("setControllerAndDev: %w", err)
+	}
+	log.Info("Waiting for EVE to receive config with the new proxy CA")
+	for start := time.Now(); time.Since(start) < timeout; time.Sleep(defaults.DefaultRepeatTimeout) {
+		var received bool
+		handleRequest := func(request *types.APIRequest) bool {
+			if request.Timestamp.After(pushed) && strings.Contains(request.URL, "config") {
+				received = true
+				return true
+			}
+			return false
+		}
+		if err = ctrl.RequestLastCallback(dev.GetID(), map[string]string{"UUID": dev.GetID().String()}, handleRequest); err != nil {
+			return fmt.Errorf("RequestLastCallback: %w", err)
+		}
+		if received {
+			return nil
+		}
+	}
+	return fmt.Errorf("EVE did not request config with the new proxy CA within %s", timeout)
+}
@@ -0,0 +1,98 @@
+package openevec
+
+import (
+	"fmt"
+
+	"github.com/lf-edge/eden/pkg/secrets"
+	"github.com/lf-edge/eden/pkg/utils"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/viper"
+)
+
+// SecretSet stores value of secret with name
+func SecretSet(name, value string) error {
+	if err := secrets.Default().Set(name, value); err != nil {
+		return fmt.Errorf("cannot set secret %s: %w", name, err)
+	}
+	log.Infof("secret %s stored, use %s in config to refer it", name, secrets.Ref(name))
+	return nil
+}
+
+// SecretGet prints value of secret with name
+func SecretGet(name string) error {
+	value, err := secrets.Default().Get(name)
+	if err != nil {
+		return err
+	}
+	fmt.Println(value)
+	return nil
+}
+
+// SecretList prints names of secrets
+func SecretList() error {
+	names, err := secrets.Default().List()
+	if err != nil {
+		return err
+	}
+	for _, name := range names {
+		fmt.Println(name)
+	}
+	return nil
+}
+
+// SecretDelete removes secret with name
+func SecretDelete(name string) error {
+	return secrets.Default().Delete(name)
+}
+
+// SecretMigrate moves plaintext values of config keys which may keep secrets of context
+// into secrets and replaces them with references
+func SecretMigrate(target string) error {
+	context, err := utils.ContextLoad()
+	if err != nil {
+		return fmt.Errorf("load context error: %w", err)
+	}
+	if target == "" {
+		target = context.Current
+	}
+	found := false
+	for _, el := range context.ListContexts() {
+		if el == target {
+			found = true
+			break
+		}
+	}
+	if !found {
+		return fmt.Errorf("context not found %s", target)
+	}
+	oldContext := context.Current
+	context.SetContext(target)
+	defer context.SetContext(oldContext)
+	if _, err := utils.LoadConfigFileContext(context.GetCurrentConfig()); err != nil {
+		return fmt.Errorf("error reading config: %w", err)
+	}
+	store := secrets.Default()
+	migrated := 0
+	for _, key := range SecretKeys() {
+		value := viper.GetString(key)
+		if _, ok := secrets.RefName(value); ok || value == "" {
+			continue
+		}
+		// the same key in different contexts may keep different values
+		name := fmt.Sprintf("%s/%s", target, key)
+		if err := store.Set(name, value); err != nil {
+			return fmt.Errorf("cannot set secret %s: %w", name, err)
+		}
+		viper.Set(key, secrets.Ref(name))
+		log.Infof("%s moved to secret %s", key, name)
+		migrated++
+	}
+	if migrated == 0 {
+		log.Infof("no plaintext secrets in context %s", target)
+		return nil
+	}
+	if err := utils.GenerateConfigFileFromViper(); err != nil {
+		return fmt.Errorf("error writing config: %w", err)
+	}
+	return nil
+}
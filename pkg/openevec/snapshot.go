@@ -0,0 +1,107 @@
+package openevec
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/lf-edge/eden/pkg/defaults"
+	"github.com/lf-edge/eden/pkg/eden"
+	"github.com/lf-edge/eden/pkg/utils"
+	log "github.com/sirupsen/logrus"
+)
+
+// snapshotSocket returns QMP socket to control QEMU with EVE
+func snapshotSocket(cfg *EdenSetupArgs) (string, error) {
+	if cfg.Eve.Remote || cfg.Eve.DevModel != defaults.DefaultQemuModel {
+		return "", fmt.Errorf("snapshots are supported only for local EVE with devmodel %s", defaults.DefaultQemuModel)
+	}
+	context, err := utils.ContextLoad()
+	if err != nil {
+		return "", fmt.Errorf("load context error: %w", err)
+	}
+	return eden.QemuControlSocket(cfg.Eve.Pid, context.Current), nil
+}
+
+// snapshotConfigFile returns file with config of EVE in controller at the moment of snapshot,
+// it is kept next to EVE image which keeps the snapshot itself
+func snapshotConfigFile(cfg *EdenSetupArgs, name string) string {
+	return filepath.Join(filepath.Dir(cfg.Eve.ImageFile), "snapshots", name+".json")
+}
+
+// EveSnapshot saves memory and disks of running EVE and its config in controller into snapshot
+func (openEVEC *OpenEVEC) EveSnapshot(name string) error {
+	socket, err := snapshotSocket(openEVEC.cfg)
+	if err != nil {
+		return err
+	}
+	started := time.Now()
+	if err := eden.QemuSnapshotSave(socket, name); err != nil {
+		return fmt.Errorf("cannot save snapshot of EVE: %w", err)
+	}
+	configFile := snapshotConfigFile(openEVEC.cfg, name)
+	if err := os.MkdirAll(filepath.Dir(configFile), 0755); err != nil {
+		return err
+	}
+	if err := openEVEC.EdgeNodeGetConfig("", configFile); err != nil {
+		return fmt.Errorf("cannot save config of EVE for snapshot: %w", err)
+	}
+	log.Infof("Snapshot %s of EVE saved in %s", name, time.Since(started).Round(time.Millisecond))
+	return nil
+}
+
+// EveRestore brings running EVE and its config in controller back to the state from snapshot
+func (openEVEC *OpenEVEC) EveRestore(name string) error {
+	socket, err := snapshotSocket(openEVEC.cfg)
+	if err != nil {
+		return err
+	}
+	started := time.Now()
+	if err := eden.QemuSnapshotLoad(socket, name); err != nil {
+		return fmt.Errorf("cannot restore snapshot of EVE: %w", err)
+	}
+	// EVE restored from snapshot expects config it had at the moment of snapshot
+	configFile := snapshotConfigFile(openEVEC.cfg, name)
+	if _, err := os.Stat(configFile); err == nil {
+		if err := openEVEC.EdgeNodeSetConfig(configFile); err != nil {
+			return fmt.Errorf("cannot restore config of EVE from snapshot: %w", err)
+		}
+	} else {
+		log.Warnf("No config of EVE saved with snapshot %s, config in controller is not changed", name)
+	}
+	log.Infof("EVE restored from snapshot %s in %s", name, time.Since(started).Round(time.Millisecond))
+	return nil
+}
+
+// EveSnapshotDelete removes snapshot of EVE
+func (openEVEC *OpenEVEC) EveSnapshotDelete(name string) error {
+	socket, err := snapshotSocket(openEVEC.cfg)
+	if err != nil {
+		return err
+	}
+	if err := eden.QemuSnapshotDelete(socket, name); err != nil {
+		return fmt.Errorf("cannot delete snapshot of EVE: %w", err)
+	}
+	if err := os.Remove(snapshotConfigFile(openEVEC.cfg, name)); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// EveSnapshotList prints snapshots of EVE
+func (openEVEC *OpenEVEC) EveSnapshotList() error {
+	socket, err := snapshotSocket(openEVEC.cfg)
+	if err != nil {
+		return err
+	}
+	snapshots, err := eden.QemuSnapshotList(socket)
+	if err != nil {
+		return fmt.Errorf("cannot list snapshots of EVE: %w", err)
+	}
+	for _, snapshot := range snapshots {
+		fmt.Printf("%s\t%s\t%d MB\n", snapshot.Name,
+			time.Unix(snapshot.DateSec, 0).Format(time.RFC3339), snapshot.VMStateSize/1024/1024)
+	}
+	return nil
+}
@@ -64,45 +64,105 @@ func (openEVEC *OpenEVEC) StartRegistry() error {
 	return nil
 }
 
+// eserverOpts returns options for eserver container, it generates certificates if eserver is served over TLS
+func eserverOpts(cfg *EdenSetupArgs) ([]string, error) {
+	opts := eden.EServerSFTPOpts(cfg.Eden.EServer.SFTPUser, cfg.Eden.EServer.SFTPPassword)
+	opts = append(opts, eden.EServerLogOpts()...)
+	if !cfg.Eden.EServer.TLS {
+		return opts, nil
+	}
+	if err := eden.GenerateEServerCerts([]string{cfg.Eden.EServer.IP, cfg.Adam.CertsEVEIP}, cfg.Adam.CertsDomain, false); err != nil {
+		return nil, fmt.Errorf("cannot generate eserver certs: %w", err)
+	}
+	tlsOpts, err := eden.EServerTLSOpts(cfg.Eden.EServer.TLSClientAuth)
+	if err != nil {
+		return nil, err
+	}
+	return append(opts, tlsOpts...), nil
+}
+
 func (openEVEC *OpenEVEC) StartEServer() error {
 	cfg := openEVEC.cfg
-	if err := eden.StartEServer(cfg.Eden.EServer.Port, cfg.Eden.Images.EServerImageDist, cfg.Eden.EServer.Force, cfg.Eden.EServer.Tag); err != nil {
+	opts, err := eserverOpts(cfg)
+	if err != nil {
+		return err
+	}
+	if err := eden.StartEServer(cfg.Eden.EServer.Port, cfg.Eden.Images.EServerImageDist, cfg.Eden.EServer.Force, cfg.Eden.EServer.Tag, opts...); err != nil {
 		return fmt.Errorf("cannot start eserver: %w", err)
 	}
 	log.Infof("Eserver is running and accesible on port %d", cfg.Eden.EServer.Port)
 	return nil
 }
 
-func (openEVEC *OpenEVEC) StartEden(vmName, zedControlURL, tapInterface string) error {
+func (openEVEC *OpenEVEC) StartEden(vmName, zedControlURL, tapInterface string, resume bool) error {
 	cfg := openEVEC.cfg
+	if resume {
+		state, err := openEVEC.prepareResume()
+		if err != nil {
+			return err
+		}
+		openEVEC.resume = state
+		vmName = state.VMName
+		zedControlURL = state.ZedControlURL
+		tapInterface = state.TapInterface
+		log.Info("Resuming eden environment from saved state")
+	} else if err := removeResumeState(); err != nil {
+		// state of the previous run must not leak into 'eden start --resume' of this one
+		log.Warnf("cannot reset state to resume eden: %s", err)
+	}
 	// Note that custom installer only works with zedcloud controller.
 	useZedcloud := cfg.Eve.CustomInstaller.Path != "" || zedControlURL != ""
 
 	if !useZedcloud {
-		if err := openEVEC.StartRedis(); err != nil {
-			return fmt.Errorf("cannot start redis %w", err)
+		if componentEnabled(cfg, ComponentRedis) {
+			if err := openEVEC.StartRedis(); err != nil {
+				return fmt.Errorf("cannot start redis %w", err)
+			}
 		}
 
-		if err := openEVEC.StartAdam(); err != nil {
-			return fmt.Errorf("cannot start adam %w", err)
+		if componentEnabled(cfg, ComponentAdam) {
+			if err := openEVEC.StartAdam(); err != nil {
+				return fmt.Errorf("cannot start adam %w", err)
+			}
 		}
 
-		if err := openEVEC.StartRegistry(); err != nil {
-			return fmt.Errorf("cannot start registry %w", err)
+		if componentEnabled(cfg, ComponentRegistry) {
+			if err := openEVEC.StartRegistry(); err != nil {
+				return fmt.Errorf("cannot start registry %w", err)
+			}
 		}
 
-		if err := openEVEC.StartEServer(); err != nil {
-			return fmt.Errorf("cannot start adam %w", err)
+		if componentEnabled(cfg, ComponentEServer) {
+			if err := openEVEC.StartEServer(); err != nil {
+				return fmt.Errorf("cannot start adam %w", err)
+			}
 		}
 	}
 
-	if cfg.Eve.Remote {
+	if cfg.Eve.Remote || !componentEnabled(cfg, ComponentEVE) {
 		return nil
 	}
 
-	if err := openEVEC.StartEve(vmName, tapInterface); err != nil {
-		return fmt.Errorf("cannot start eve %w", err)
+	if err := checkArch(cfg); err != nil {
+		return err
+	}
+	if err := checkNodes(cfg); err != nil {
+		return err
+	}
+	for _, node := range openEVEC.nodes() {
+		if err := node.StartEve(vmName, tapInterface); err != nil {
+			return fmt.Errorf("cannot start eve %w", err)
+		}
 	}
 	log.Infof("EVE is starting")
+	// eden is started anyway, only 'eden start --resume' depends on the state
+	if err := updateResumeState(func(state *resumeState) {
+		state.VMName = vmName
+		state.ZedControlURL = zedControlURL
+		state.TapInterface = tapInterface
+		state.HostFwd = cfg.Eve.HostFwd
+	}); err != nil {
+		log.Warnf("cannot save state to resume eden: %s", err)
+	}
 	return nil
 }
@@ -22,39 +22,76 @@ const (
 	xmark    = "✘"
 )
 
-func (openEVEC *OpenEVEC) Status(vmName string, allConfigs bool) error {
+// printComponentDisabled prints status of component not selected with 'eden setup --components'
+func printComponentDisabled(name string) {
+	fmt.Printf("%s %s is not deployed by eden (not in eden.components)\n", statusWarn(), name)
+}
+
+// StorageStatusOptions defines thresholds of utilization of disks and volumes of EVE
+// reported by status
+type StorageStatusOptions struct {
+	eve.StorageThresholds
+	// FailOnStorage makes status fail if any disk or volume reaches Fail threshold or has errors
+	FailOnStorage bool
+}
+
+func (openEVEC *OpenEVEC) Status(vmName string, allConfigs bool, storage StorageStatusOptions) error {
 	cfg := openEVEC.cfg
-	statusAdam, err := eden.StatusAdam()
-	if err != nil {
-		return fmt.Errorf("%s cannot obtain status of adam: %w", statusWarn(), err)
+	var err error
+	statusAdam := "container doesn't exist"
+	if componentEnabled(cfg, ComponentAdam) {
+		statusAdam, err = eden.StatusAdam()
+		if err != nil {
+			return fmt.Errorf("%s cannot obtain status of adam: %w", statusWarn(), err)
+		} else {
+			fmt.Printf("%s Adam status: %s\n", representContainerStatus(lastWord(statusAdam)), statusAdam)
+			fmt.Printf("\tAdam is expected at https://%s:%d\n", cfg.Adam.CertsIP, cfg.Adam.Port)
+			fmt.Printf("\tFor local Adam you can run 'docker logs %s' to see logs\n", defaults.DefaultAdamContainerName)
+		}
 	} else {
-		fmt.Printf("%s Adam status: %s\n", representContainerStatus(lastWord(statusAdam)), statusAdam)
-		fmt.Printf("\tAdam is expected at https://%s:%d\n", cfg.Adam.CertsIP, cfg.Adam.Port)
-		fmt.Printf("\tFor local Adam you can run 'docker logs %s' to see logs\n", defaults.DefaultAdamContainerName)
+		printComponentDisabled("Adam")
 	}
-	statusRegistry, err := eden.StatusRegistry()
-	if err != nil {
-		return fmt.Errorf("%s cannot obtain status of registry: %w", statusWarn(), err)
+	if componentEnabled(cfg, ComponentRegistry) {
+		statusRegistry, err := eden.StatusRegistry()
+		if err != nil {
+			return fmt.Errorf("%s cannot obtain status of registry: %w", statusWarn(), err)
+		} else {
+			fmt.Printf("%s Registry status: %s\n", representContainerStatus(lastWord(statusRegistry)), statusRegistry)
+			fmt.Printf("\tRegistry is expected at https://%s:%d\n", cfg.Registry.IP, cfg.Registry.Port)
+			fmt.Printf("\tFor local registry you can run 'docker logs %s' to see logs\n", defaults.DefaultRegistryContainerName)
+		}
 	} else {
-		fmt.Printf("%s Registry status: %s\n", representContainerStatus(lastWord(statusRegistry)), statusRegistry)
-		fmt.Printf("\tRegistry is expected at https://%s:%d\n", cfg.Registry.IP, cfg.Registry.Port)
-		fmt.Printf("\tFor local registry you can run 'docker logs %s' to see logs\n", defaults.DefaultRegistryContainerName)
+		printComponentDisabled("Registry")
 	}
-	statusRedis, err := eden.StatusRedis()
-	if err != nil {
-		return fmt.Errorf("%s cannot obtain status of redis: %w", statusWarn(), err)
+	if componentEnabled(cfg, ComponentRedis) {
+		statusRedis, err := eden.StatusRedis()
+		if err != nil {
+			return fmt.Errorf("%s cannot obtain status of redis: %w", statusWarn(), err)
+		} else {
+			fmt.Printf("%s Redis status: %s\n", representContainerStatus(lastWord(statusRedis)), statusRedis)
+			fmt.Printf("\tRedis is expected at %s\n", cfg.Adam.Redis.Eden)
+			fmt.Printf("\tFor local Redis you can run 'docker logs %s' to see logs\n", defaults.DefaultRedisContainerName)
+		}
 	} else {
-		fmt.Printf("%s Redis status: %s\n", representContainerStatus(lastWord(statusRedis)), statusRedis)
-		fmt.Printf("\tRedis is expected at %s\n", cfg.Adam.Redis.Eden)
-		fmt.Printf("\tFor local Redis you can run 'docker logs %s' to see logs\n", defaults.DefaultRedisContainerName)
+		printComponentDisabled("Redis")
 	}
-	statusEServer, err := eden.StatusEServer()
-	if err != nil {
-		return fmt.Errorf("%s cannot obtain status of redis: %s", statusWarn(), err)
+	if componentEnabled(cfg, ComponentEServer) {
+		statusEServer, err := eden.StatusEServer()
+		if err != nil {
+			return fmt.Errorf("%s cannot obtain status of redis: %s", statusWarn(), err)
+		} else {
+			fmt.Printf("%s EServer process status: %s\n", representContainerStatus(lastWord(statusEServer)), statusEServer)
+			fmt.Printf("\tEServer is expected at http://%s:%d from EVE\n", cfg.Eden.EServer.IP, cfg.Eden.EServer.Port)
+			fmt.Printf("\tFor local EServer you can run 'docker logs %s' to see logs\n", defaults.DefaultEServerContainerName)
+		}
 	} else {
-		fmt.Printf("%s EServer process status: %s\n", representContainerStatus(lastWord(statusEServer)), statusEServer)
-		fmt.Printf("\tEServer is expected at http://%s:%d from EVE\n", cfg.Eden.EServer.IP, cfg.Eden.EServer.Port)
-		fmt.Printf("\tFor local EServer you can run 'docker logs %s' to see logs\n", defaults.DefaultEServerContainerName)
+		printComponentDisabled("EServer")
+	}
+	// LOC is started on demand, so it is reported only if it was started
+	if status, _ := utils.StatusCommandWithPid(locPidFile(cfg)); status != "process doesn't exist" {
+		if err := openEVEC.LOCStatus(); err != nil {
+			return err
+		}
 	}
 	fmt.Println()
 	context, err := utils.ContextLoad()
@@ -72,41 +109,53 @@ func (openEVEC *OpenEVEC) Status(vmName string, allConfigs bool) error {
 			if err != nil {
 				return err
 			}
-			localOpenEVEC := CreateOpenEVEC(localCfg)
-			eveUUID := localCfg.Eve.CertsUUID
-			edenDir, err := utils.DefaultEdenDir()
-			if err != nil {
-				return err
-			}
-			fi, err := os.Stat(filepath.Join(edenDir, fmt.Sprintf("state-%s.yml", eveUUID)))
-			if err != nil {
-				fmt.Printf("EVE state: not onboarded\n")
-			} else {
-				size := fi.Size()
-				if size > 0 {
-					fmt.Printf("EVE state: registered\n")
-				} else {
-					fmt.Printf("EVE state: onboarding\n")
+			if el == currentContext {
+				if err := SelectNode(localCfg, cfg.Node); err != nil {
+					return err
 				}
 			}
-			fmt.Println()
-			if statusAdam != "container doesn't exist" {
-				if err := localOpenEVEC.eveStatusRemote(); err != nil {
+			nodes := CreateOpenEVEC(localCfg).nodes()
+			for _, localOpenEVEC := range nodes {
+				localCfg := localOpenEVEC.cfg
+				if len(nodes) > 1 {
+					fmt.Printf("--- node: %s ---\n", utils.NodeName(localCfg.Node))
+				}
+				eveUUID := localCfg.Eve.CertsUUID
+				edenDir, err := utils.DefaultEdenDir()
+				if err != nil {
 					return err
 				}
-			}
-			if !localCfg.Eve.Remote {
-				switch {
-				case localCfg.Eve.DevModel == defaults.DefaultVBoxModel:
-					localOpenEVEC.eveStatusVBox(vmName)
-				case localCfg.Eve.DevModel == defaults.DefaultParallelsModel:
-					localOpenEVEC.eveStatusParallels(vmName)
-				default:
-					localOpenEVEC.eveStatusQEMU(configName, cfg.Eve.Pid)
+				fi, err := os.Stat(filepath.Join(edenDir, fmt.Sprintf("state-%s.yml", eveUUID)))
+				if err != nil {
+					fmt.Printf("EVE state: not onboarded\n")
+				} else {
+					size := fi.Size()
+					if size > 0 {
+						fmt.Printf("EVE state: registered\n")
+					} else {
+						fmt.Printf("EVE state: onboarding\n")
+					}
+				}
+				fmt.Println()
+				if statusAdam != "container doesn't exist" {
+					if err := localOpenEVEC.eveStatusRemote(storage); err != nil {
+						return err
+					}
+				}
+				if !localCfg.Eve.Remote && componentEnabled(localCfg, ComponentEVE) {
+					if driver, ok := eden.GetVMDriver(localCfg.Eve.DevModel); ok {
+						localOpenEVEC.eveStatusVM(driver, vmName)
+					} else {
+						pid := cfg.Eve.Pid
+						if len(nodes) > 1 {
+							pid = localCfg.Eve.Pid
+						}
+						localOpenEVEC.eveStatusQEMU(configName, pid)
+					}
+				}
+				if statusAdam != "container doesn't exist" {
+					localOpenEVEC.eveRequestsAdam()
 				}
-			}
-			if statusAdam != "container doesn't exist" {
-				localOpenEVEC.eveRequestsAdam()
 			}
 			fmt.Println("------")
 		}
@@ -126,7 +175,7 @@ func (openEVEC *OpenEVEC) eveRequestsAdam() {
 	}
 }
 
-func (openEVEC *OpenEVEC) eveStatusRemote() error {
+func (openEVEC *OpenEVEC) eveStatusRemote(storage StorageStatusOptions) error {
 	log.Debugf("Will try to obtain info from ADAM")
 	changer := &adamChanger{}
 	ctrl, dev, err := changer.getControllerAndDevFromConfig(openEVEC.cfg)
@@ -172,6 +221,39 @@ func (openEVEC *OpenEVEC) eveStatusRemote() error {
 	} else {
 		fmt.Printf("%s EVE memory: %s\n", statusWarn(), "waiting for info...")
 	}
+	return printStorageStatus(eveState, storage)
+}
+
+// printStorageStatus prints utilization of disks and volumes of EVE, it returns error
+// if some of them reach failure threshold and FailOnStorage is set
+func printStorageStatus(eveState *eve.State, storage StorageStatusOptions) error {
+	disks := eveState.DisksUsage()
+	if disks == nil {
+		fmt.Printf("%s EVE storage: %s\n", statusWarn(), "waiting for metrics...")
+		return nil
+	}
+	var failed []string
+	printUsage := func(kind string, usage *eve.StorageUsage) {
+		status := statusOK()
+		switch usage.Level(storage.StorageThresholds) {
+		case eve.StorageWarn:
+			status = statusWarn()
+		case eve.StorageFail:
+			status = statusBad()
+			failed = append(failed, usage.Name)
+		}
+		fmt.Printf("%s EVE %s %s\n", status, kind, usage)
+	}
+	for _, disk := range disks {
+		printUsage("disk", disk)
+	}
+	for _, vol := range eveState.VolumesUsage() {
+		printUsage("volume", vol)
+	}
+	if storage.FailOnStorage && len(failed) > 0 {
+		return fmt.Errorf("storage of EVE exceeds threshold of %.0f%% or has errors: %s",
+			storage.Fail, strings.Join(failed, ", "))
+	}
 	return nil
 }
 
@@ -185,22 +267,13 @@ func (openEVEC *OpenEVEC) eveStatusQEMU(configName, evePidFile string) {
 	fmt.Printf("\tLogs for local EVE at: %s\n", utils.ResolveAbsPath(configName+"-"+"eve.log"))
 }
 
-func (openEVEC *OpenEVEC) eveStatusVBox(vmName string) {
-	statusEVE, err := eden.StatusEVEVBox(vmName)
-	if err != nil {
-		log.Errorf("%s cannot obtain status of EVE VBox process: %s", statusWarn(), err)
-		return
-	}
-	fmt.Printf("%s EVE on VBox status: %s\n", representProcessStatus(statusEVE), statusEVE)
-}
-
-func (openEVEC *OpenEVEC) eveStatusParallels(vmName string) {
-	statusEVE, err := eden.StatusEVEParallels(vmName)
+func (openEVEC *OpenEVEC) eveStatusVM(driver eden.VMDriver, vmName string) {
+	statusEVE, err := driver.Status(vmName)
 	if err != nil {
-		log.Errorf("%s cannot obtain status of EVE Parallels process: %s", statusWarn(), err)
+		log.Errorf("%s cannot obtain status of EVE %s process: %s", statusWarn(), driver.Name(), err)
 		return
 	}
-	fmt.Printf("%s EVE on Parallels status: %s\n", representProcessStatus(statusEVE), statusEVE)
+	fmt.Printf("%s EVE on %s status: %s\n", representProcessStatus(statusEVE), driver.Name(), statusEVE)
 }
 
 // lastWord get last work in string
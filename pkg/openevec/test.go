@@ -1,15 +1,26 @@
 package openevec
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
 	"os"
+	"os/exec"
 	"path/filepath"
+	"regexp"
 	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
 
+	"github.com/lf-edge/eden/pkg/controller/replay"
 	"github.com/lf-edge/eden/pkg/defaults"
+	"github.com/lf-edge/eden/pkg/eden"
+	"github.com/lf-edge/eden/pkg/testhistory"
 	"github.com/lf-edge/eden/pkg/tests"
 	"github.com/lf-edge/eden/pkg/utils"
 	log "github.com/sirupsen/logrus"
+	"github.com/spf13/viper"
 )
 
 type TestArgs struct {
@@ -25,6 +36,19 @@ type TestArgs struct {
 	CurDir       string
 	ConfigFile   string
 	Verbosity    string
+	History      bool
+	HistoryDB    string
+	EVEVersion   string
+	Retries      int
+	Quarantine   string
+	APICoverage  string
+	Matrix       string
+	Record       string
+	Replay       string
+	// ResetSnapshot is snapshot of EVE restored before every test of scenario
+	ResetSnapshot string
+	// Setup is config of eden used to restore ResetSnapshot
+	Setup *EdenSetupArgs
 }
 
 func InitVarsFromConfig(cfg *EdenSetupArgs) (*utils.ConfigVars, error) {
@@ -61,6 +85,9 @@ func InitVarsFromConfig(cfg *EdenSetupArgs) (*utils.ConfigVars, error) {
 	cv.EServerImageDist = utils.ResolveAbsPath(cfg.Eden.Images.EServerImageDist)
 	cv.EServerPort = strconv.Itoa(cfg.Eden.EServer.Port)
 	cv.EServerIP = cfg.Eden.EServer.IP
+	cv.EServerTLS = cfg.Eden.EServer.TLS
+	cv.EServerSFTPUser = cfg.Eden.EServer.SFTPUser
+	cv.EServerSFTPPass = cfg.Eden.EServer.SFTPPassword
 
 	cv.EveCert = utils.ResolveAbsPath(cfg.Eve.Cert)
 	cv.EveDeviceCert = utils.ResolveAbsPath(cfg.Eve.DeviceCert)
@@ -72,17 +99,35 @@ func InitVarsFromConfig(cfg *EdenSetupArgs) (*utils.ConfigVars, error) {
 	cv.EveHV = cfg.Eve.HV
 	cv.DevModel = cfg.Eve.DevModel
 	cv.DevModelFIle = cfg.Eve.DevModelFile
+	cv.EvePCIPassthrough = cfg.Eve.PCIPassthrough
+	if cfg.Eve.Cellular.Enabled {
+		cv.EveCellularAPN = cfg.Eve.Cellular.APN
+	}
 	cv.EveName = cfg.Eve.Name
 	cv.EveUUID = cfg.Eve.CertsUUID
 	cv.AdamLogLevel = cfg.Eve.AdamLogLevel
 	cv.EveRemote = cfg.Eve.Remote
 	cv.EveRemoteAddr = cfg.Eve.RemoteAddr
+	cv.EveAPIVersion = cfg.Eve.APIVersion
 	cv.EveQemuPorts = cfg.Eve.HostFwd
 	cv.LogLevel = cfg.Eve.LogLevel
 
 	cv.RegistryIP = cfg.Registry.IP
 	cv.RegistryPort = strconv.Itoa(cfg.Registry.Port)
 
+	cv.SdnProxy = cfg.Sdn.Proxy
+	cv.SdnConfigDir = cfg.Sdn.ConfigDir
+	cv.SdnNetworkType = cfg.Sdn.NetworkType
+
+	cv.ControllerType = cfg.Controller.Type
+	cv.ZedcloudURL = cfg.Controller.Zedcloud.URL
+	cv.ZedcloudToken = cfg.Controller.Zedcloud.Token
+	cv.ZedcloudProject = cfg.Controller.Zedcloud.Project
+	cv.ZedcloudDevice = cfg.Controller.Zedcloud.Device
+
+	cv.DryRun = cfg.DryRun
+	cv.ForcePush = cfg.ForcePush
+
 	redisPasswordFile := filepath.Join(globalCertsDir, defaults.DefaultRedisPasswordFile)
 	pwd, err := os.ReadFile(redisPasswordFile)
 	if err == nil {
@@ -96,6 +141,86 @@ func InitVarsFromConfig(cfg *EdenSetupArgs) (*utils.ConfigVars, error) {
 
 func Test(tstCfg *TestArgs) error {
 
+	if tstCfg.Matrix != "" && tstCfg.TestList == "" && !tstCfg.TestOpts {
+		if tstCfg.Replay != "" {
+			return fmt.Errorf("replay of recording does not depend on EVE, it cannot run with matrix")
+		}
+		if tstCfg.ResetSnapshot != "" {
+			return fmt.Errorf("every cell of matrix runs its own EVE without snapshot, it cannot reset EVE to snapshot")
+		}
+		return TestMatrix(tstCfg)
+	}
+
+	if tstCfg.TestList == "" && !tstCfg.TestOpts {
+		policy := tests.RetryPolicy{Retries: tstCfg.Retries}
+		if tstCfg.Quarantine != "" {
+			quarantine, err := tests.ReadQuarantine(tstCfg.Quarantine)
+			if err != nil {
+				return err
+			}
+			policy.Quarantine = quarantine
+		}
+		tests.SetRetryPolicy(policy)
+		defer tests.SetRetryPolicy(tests.RetryPolicy{})
+	}
+
+	// suites of tests started inside nested 'eden test' are ended by the outer one
+	endSuites := false
+	if tstCfg.TestList == "" && !tstCfg.TestOpts {
+		started, err := tests.BeginSuites()
+		if err != nil {
+			return err
+		}
+		endSuites = started
+	}
+
+	if tstCfg.History && tstCfg.TestList == "" && !tstCfg.TestOpts {
+		db, err := openTestHistory(tstCfg.HistoryDB)
+		if err != nil {
+			return err
+		}
+		defer db.Close()
+		tests.SetRecorder(testhistory.NewRecorder(db, tstCfg.EVEVersion, contextHash(tstCfg.ConfigFile)))
+		defer tests.SetRecorder(nil)
+	}
+
+	if tstCfg.APICoverage != "" && tstCfg.TestList == "" && !tstCfg.TestOpts {
+		if err := tests.BeginAPICoverage(tstCfg.APICoverage); err != nil {
+			return err
+		}
+	}
+
+	if tstCfg.Record != "" && tstCfg.TestList == "" && !tstCfg.TestOpts {
+		if err := tests.BeginRecording(tstCfg.Record); err != nil {
+			return err
+		}
+	}
+
+	if tstCfg.ResetSnapshot != "" && tstCfg.TestList == "" && !tstCfg.TestOpts {
+		if tstCfg.Replay != "" {
+			return fmt.Errorf("replay of recording does not use EVE, it cannot reset EVE to snapshot")
+		}
+		openEVEC := CreateOpenEVEC(tstCfg.Setup)
+		tests.SetReset(func() error {
+			return openEVEC.EveRestore(tstCfg.ResetSnapshot)
+		})
+		defer tests.SetReset(nil)
+	}
+
+	if tstCfg.Setup != nil && consoleCaptured(tstCfg.Setup) && tstCfg.Replay == "" && tstCfg.TestList == "" && !tstCfg.TestOpts {
+		dir := eden.ConsoleCaptureDir(tstCfg.Setup.Eve.Log)
+		tests.SetConsole(func(since time.Time) ([]string, error) {
+			return eden.ConsoleExcerpt(dir, since, defaults.DefaultConsoleExcerptLines)
+		})
+		defer tests.SetConsole(nil)
+	}
+
+	if tstCfg.Replay != "" {
+		if err := setReplay(tstCfg.Replay); err != nil {
+			return err
+		}
+	}
+
 	switch {
 	case tstCfg.TestList != "":
 		tests.RunTest(tstCfg.TestProg, []string{"-test.list", tstCfg.TestList}, "", tstCfg.TestTimeout, tstCfg.FailScenario, tstCfg.ConfigFile, tstCfg.Verbosity)
@@ -108,6 +233,21 @@ func Test(tstCfg *TestArgs) error {
 	default:
 		tests.RunScenario(tstCfg.TestScenario, tstCfg.TestArgs, tstCfg.TestTimeout, tstCfg.FailScenario, tstCfg.ConfigFile, tstCfg.Verbosity)
 	}
+	tests.LogRetrySummary()
+
+	if endSuites {
+		if err := tests.EndSuites(); err != nil {
+			return err
+		}
+	}
+
+	if err := tests.EndAPICoverage(); err != nil {
+		return err
+	}
+
+	if err := tests.EndRecording(); err != nil {
+		return err
+	}
 
 	if tstCfg.CurDir != "" {
 		err := os.Chdir(tstCfg.CurDir)
@@ -117,3 +257,253 @@ func Test(tstCfg *TestArgs) error {
 	}
 	return nil
 }
+
+// matrixResult is result of tests of one cell of matrix
+type matrixResult struct {
+	cell     tests.MatrixCell
+	err      error
+	duration time.Duration
+}
+
+// TestMatrix runs tests once for every cell of matrix from tstCfg.Matrix file.
+// Every cell uses its own config context with EVE tag, hypervisor and architecture of the cell,
+// tests of cell run by separate 'eden test', so failed cell does not stop the following ones.
+func TestMatrix(tstCfg *TestArgs) error {
+	matrix, err := tests.ReadMatrix(tstCfg.Matrix)
+	if err != nil {
+		return err
+	}
+	edenProg, err := os.Executable()
+	if err != nil {
+		return fmt.Errorf("cannot obtain executable path: %w", err)
+	}
+	var results []matrixResult
+	for _, cell := range matrix.Cells() {
+		log.Infof("Matrix cell %s: running tests in context %s", cell.Label(), cell.Context())
+		started := time.Now()
+		err := prepareMatrixContext(edenProg, cell, matrix.Config)
+		if err == nil {
+			err = utils.RunCommandForegroundWithOpts(edenProg, matrixTestArgs(tstCfg, cell),
+				utils.SetCommandEnvVars(append(os.Environ(),
+					fmt.Sprintf("%s=%s", defaults.DefaultConfigEnv, cell.Context()))))
+		}
+		if err != nil {
+			log.Errorf("Matrix cell %s failed: %s", cell.Label(), err)
+		}
+		results = append(results, matrixResult{cell: cell, err: err, duration: time.Since(started)})
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 8, 1, '\t', 0)
+	if _, err := fmt.Fprintln(w, "CELL\tCONTEXT\tRESULT\tDURATION"); err != nil {
+		return err
+	}
+	failed := 0
+	for _, r := range results {
+		result := "PASS"
+		if r.err != nil {
+			result = "FAIL"
+			failed++
+		}
+		if _, err := fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", r.cell.Label(), r.cell.Context(), result,
+			r.duration.Round(time.Second)); err != nil {
+			return err
+		}
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	if failed > 0 {
+		return fmt.Errorf("tests failed in %d of %d cells of matrix", failed, len(results))
+	}
+	return nil
+}
+
+// prepareMatrixContext creates config context of cell if it does not exist
+// and sets tag, hypervisor and additional config of the matrix into it
+func prepareMatrixContext(edenProg string, cell tests.MatrixCell, config map[string]string) error {
+	addArgs := []string{"config", "add", cell.Context()}
+	if cell.Arch != "" {
+		addArgs = append(addArgs, "--arch", cell.Arch)
+	}
+	if out, err := exec.Command(edenProg, addArgs...).CombinedOutput(); err != nil {
+		return fmt.Errorf("cannot add config context %s: %w: %s", cell.Context(), err, out)
+	}
+	values := map[string]string{}
+	for k, v := range config {
+		values[k] = v
+	}
+	if cell.Tag != "" {
+		values["eve.tag"] = cell.Tag
+	}
+	if cell.HV != "" {
+		values["eve.hv"] = cell.HV
+	}
+	for k, v := range values {
+		out, err := exec.Command(edenProg, "config", "set", cell.Context(), "--key", k, "--value", v).CombinedOutput()
+		if err != nil {
+			return fmt.Errorf("cannot set %s in config context %s: %w: %s", k, cell.Context(), err, out)
+		}
+	}
+	return nil
+}
+
+// matrixTestArgs returns arguments of 'eden test' to run tests of cell
+func matrixTestArgs(tstCfg *TestArgs, cell tests.MatrixCell) []string {
+	args := []string{"test", "-v", tstCfg.Verbosity, "-p", tstCfg.TestProg, "-f", tstCfg.FailScenario}
+	switch {
+	case tstCfg.TestEscript != "":
+		args = append(args, "-e", tstCfg.TestEscript)
+	case tstCfg.TestRun != "":
+		args = append(args, "-r", tstCfg.TestRun)
+	default:
+		args = append(args, "-s", tstCfg.TestScenario)
+	}
+	if tstCfg.TestArgs != "" {
+		args = append(args, "-a", tstCfg.TestArgs)
+	}
+	if tstCfg.TestTimeout != "" {
+		args = append(args, "-t", tstCfg.TestTimeout)
+	}
+	if tstCfg.Retries > 0 {
+		args = append(args, "--retries", strconv.Itoa(tstCfg.Retries))
+	}
+	if tstCfg.Quarantine != "" {
+		args = append(args, "--quarantine", tstCfg.Quarantine)
+	}
+	if tstCfg.History {
+		args = append(args, "--history")
+	}
+	if tstCfg.HistoryDB != "" {
+		args = append(args, "--history-db", tstCfg.HistoryDB)
+	}
+	if tstCfg.APICoverage != "" {
+		// every cell writes its own report
+		args = append(args, "--api-coverage", fmt.Sprintf("%s.%s", tstCfg.APICoverage, cell.Context()))
+	}
+	if tstCfg.Record != "" {
+		args = append(args, "--record", fmt.Sprintf("%s.%s", tstCfg.Record, cell.Context()))
+	}
+	return args
+}
+
+// setReplay makes tests and eden commands called by them use recording
+// from directory instead of controller
+func setReplay(dir string) error {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return err
+	}
+	rec, err := replay.Load(dir)
+	if err != nil {
+		return err
+	}
+	log.Infof("replaying %d recorded events from %s", len(rec.Events), dir)
+	return os.Setenv(defaults.DefaultReplayEnv, dir)
+}
+
+// openTestHistory opens database with history of tests, it is located in eden home by default
+func openTestHistory(path string) (*testhistory.DB, error) {
+	if path == "" {
+		edenHome, err := utils.DefaultEdenDir()
+		if err != nil {
+			return nil, err
+		}
+		path = filepath.Join(edenHome, defaults.DefaultTestHistoryFile)
+	}
+	return testhistory.Open(path)
+}
+
+// contextHash returns short hash of config to distinguish results of tests in different environments
+func contextHash(configFile string) string {
+	if configFile == "" {
+		configFile = viper.ConfigFileUsed()
+	}
+	data, err := os.ReadFile(configFile)
+	if err != nil {
+		log.Warnf("cannot read config %s: %s", configFile, err)
+		return ""
+	}
+	hash := sha256.Sum256(data)
+	return hex.EncodeToString(hash[:])[:12]
+}
+
+// historyString returns statuses of runs as one letter per run
+func historyString(statuses []string) string {
+	var b strings.Builder
+	for _, status := range statuses {
+		b.WriteByte(status[0])
+	}
+	return b.String()
+}
+
+// TestReport prints results of tests from the last runs recorded with 'eden test --history',
+// with trend it prints pass rate and duration trends of every test instead
+func TestReport(historyDB string, runs int, trend bool, testRegexp string) error {
+	re, err := regexp.Compile(testRegexp)
+	if err != nil {
+		return fmt.Errorf("wrong regexp of tests: %w", err)
+	}
+	db, err := openTestHistory(historyDB)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+	all, err := db.Results(runs)
+	if err != nil {
+		return fmt.Errorf("cannot read history of tests: %w", err)
+	}
+	var results []testhistory.Result
+	for _, r := range all {
+		if re.MatchString(r.Test) {
+			results = append(results, r)
+		}
+	}
+	if len(results) == 0 {
+		return fmt.Errorf("no results of tests in history, run 'eden test' with --history")
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 8, 1, '\t', 0)
+	if trend {
+		if _, err := fmt.Fprintln(w, "TEST\tRUNS\tPASS RATE\tRECENT PASS RATE\tMEAN DURATION\tRECENT MEAN DURATION\tDURATION CHANGE\tHISTORY"); err != nil {
+			return err
+		}
+		for _, t := range testhistory.Trends(results) {
+			if _, err := fmt.Fprintf(w, "%s\t%d\t%.0f%%\t%.0f%%\t%s\t%s\t%+.0f%%\t%s\n",
+				t.Test, t.Runs, t.PassRate*100, t.RecentPassRate*100, t.MeanDuration.Round(time.Millisecond),
+				t.RecentMeanDuration.Round(time.Millisecond), t.DurationChange()*100, historyString(t.History)); err != nil {
+				return err
+			}
+		}
+		return w.Flush()
+	}
+	if _, err := fmt.Fprintln(w, "STARTED\tTEST\tSTATUS\tDURATION\tEVE VERSION\tCONTEXT"); err != nil {
+		return err
+	}
+	for _, r := range results {
+		if _, err := fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n", r.Started.Format(time.RFC3339), r.Test, r.Status,
+			r.Duration.Round(time.Millisecond), r.EVEVersion, r.ContextHash); err != nil {
+			return err
+		}
+	}
+	return w.Flush()
+}
+
+// TestNew creates test with name inside testsDir and adds it into suite of workflow tests if set
+func TestNew(testsDir, name, suite string) error {
+	created, err := tests.Scaffold(testsDir, name)
+	for _, path := range created {
+		fmt.Println(path)
+	}
+	if err != nil {
+		return fmt.Errorf("cannot create test %s: %w", name, err)
+	}
+	if suite != "" {
+		suiteFile := filepath.Join(testsDir, "workflow", suite)
+		if err := tests.RegisterInSuite(suiteFile, name); err != nil {
+			return fmt.Errorf("cannot add test %s into suite: %w", name, err)
+		}
+		log.Infof("test %s added into %s", name, suiteFile)
+	}
+	log.Infof("build and run it with 'make -C %s build && eden test %s'",
+		filepath.Join(testsDir, name), filepath.Join(testsDir, name))
+	return nil
+}
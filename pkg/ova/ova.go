@@ -0,0 +1,217 @@
+// Package ova unpacks OVA archives and parses their OVF descriptors to deploy
+// the virtual machines as VM apps on EVE.
+package ova
+
+import (
+	"archive/tar"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// resource types of items of virtual hardware from CIM
+const (
+	resourceProcessor = 3
+	resourceMemory    = 4
+	resourceEthernet  = 10
+	resourceDisk      = 17
+)
+
+type envelope struct {
+	Files []struct {
+		ID   string `xml:"id,attr"`
+		Href string `xml:"href,attr"`
+	} `xml:"References>File"`
+	Disks []struct {
+		DiskID  string `xml:"diskId,attr"`
+		FileRef string `xml:"fileRef,attr"`
+		Format  string `xml:"format,attr"`
+	} `xml:"DiskSection>Disk"`
+	VirtualSystem struct {
+		ID    string `xml:"id,attr"`
+		Name  string `xml:"Name"`
+		Items []struct {
+			ResourceType    int    `xml:"ResourceType"`
+			ElementName     string `xml:"ElementName"`
+			VirtualQuantity uint64 `xml:"VirtualQuantity"`
+			AllocationUnits string `xml:"AllocationUnits"`
+			HostResource    string `xml:"HostResource"`
+			Connection      string `xml:"Connection"`
+		} `xml:"VirtualHardwareSection>Item"`
+	} `xml:"VirtualSystem"`
+}
+
+// Disk is disk of virtual machine
+type Disk struct {
+	// ID of disk in descriptor
+	ID string
+	// Path of unpacked file of disk
+	Path string
+	// Format of disk for qemu-img (e.g. vmdk)
+	Format string
+}
+
+// Interface is network adapter of virtual machine
+type Interface struct {
+	Name string
+	// Network is name of network the adapter is connected to in descriptor
+	Network string
+}
+
+// VM is virtual machine defined in OVA
+type VM struct {
+	Name   string
+	CPUs   uint32
+	Memory uint64
+	// Disks in order of virtual hardware, the first one is boot disk
+	Disks      []*Disk
+	Interfaces []*Interface
+}
+
+// allocationUnits returns multiplier of allocation units in programmatic
+// notation (e.g. byte * 2^20) or in the legacy one (e.g. MegaBytes)
+func allocationUnits(units string) (uint64, error) {
+	units = strings.ReplaceAll(strings.ToLower(units), " ", "")
+	switch units {
+	case "", "byte", "bytes":
+		return 1, nil
+	case "kilobytes":
+		return 1 << 10, nil
+	case "megabytes":
+		return 1 << 20, nil
+	case "gigabytes":
+		return 1 << 30, nil
+	}
+	if strings.HasPrefix(units, "byte*2^") {
+		exp, err := strconv.Atoi(strings.TrimPrefix(units, "byte*2^"))
+		if err != nil || exp < 0 || exp > 63 {
+			return 0, fmt.Errorf("unsupported allocation units %s", units)
+		}
+		return 1 << exp, nil
+	}
+	return 0, fmt.Errorf("unsupported allocation units %s", units)
+}
+
+// diskFormat returns format of disk for qemu-img from format URL of descriptor or extension of file
+func diskFormat(formatURL, fileName string) string {
+	formatURL = strings.ToLower(formatURL)
+	for _, format := range []string{"vmdk", "vhdx", "vhd", "qcow2", "raw"} {
+		if strings.Contains(formatURL, format) {
+			if format == "vhd" {
+				return "vpc"
+			}
+			return format
+		}
+	}
+	ext := strings.TrimPrefix(filepath.Ext(fileName), ".")
+	if ext == "img" {
+		return "raw"
+	}
+	return ext
+}
+
+// Parse returns VM defined in OVF descriptor with disks in dir
+func Parse(descriptor []byte, dir string) (*VM, error) {
+	var env envelope
+	if err := xml.Unmarshal(descriptor, &env); err != nil {
+		return nil, fmt.Errorf("cannot parse OVF descriptor: %w", err)
+	}
+	vm := &VM{Name: env.VirtualSystem.Name}
+	if vm.Name == "" {
+		vm.Name = env.VirtualSystem.ID
+	}
+	files := map[string]string{}
+	for _, f := range env.Files {
+		files[f.ID] = f.Href
+	}
+	disks := map[string]*Disk{}
+	for _, d := range env.Disks {
+		href, ok := files[d.FileRef]
+		if !ok {
+			return nil, fmt.Errorf("file %s of disk %s not found in references", d.FileRef, d.DiskID)
+		}
+		disks[d.DiskID] = &Disk{
+			ID:     d.DiskID,
+			Path:   filepath.Join(dir, filepath.Base(href)),
+			Format: diskFormat(d.Format, href),
+		}
+	}
+	for _, item := range env.VirtualSystem.Items {
+		switch item.ResourceType {
+		case resourceProcessor:
+			vm.CPUs = uint32(item.VirtualQuantity)
+		case resourceMemory:
+			units, err := allocationUnits(item.AllocationUnits)
+			if err != nil {
+				return nil, fmt.Errorf("memory: %w", err)
+			}
+			vm.Memory = item.VirtualQuantity * units
+		case resourceEthernet:
+			vm.Interfaces = append(vm.Interfaces, &Interface{Name: item.ElementName, Network: item.Connection})
+		case resourceDisk:
+			// host resource is in form of ovf:/disk/<diskId>
+			id := item.HostResource[strings.LastIndex(item.HostResource, "/")+1:]
+			disk, ok := disks[id]
+			if !ok {
+				return nil, fmt.Errorf("disk %s not found in disk section", item.HostResource)
+			}
+			vm.Disks = append(vm.Disks, disk)
+		}
+	}
+	if len(vm.Disks) == 0 {
+		return nil, errors.New("no disks found in OVF descriptor")
+	}
+	return vm, nil
+}
+
+// Unpack extracts OVA archive into dir and returns VM defined in its descriptor
+func Unpack(ovaFile, dir string) (*VM, error) {
+	f, err := os.Open(ovaFile)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	var descriptor []byte
+	tr := tar.NewReader(f)
+	for {
+		hdr, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("cannot read %s: %w", ovaFile, err)
+		}
+		if hdr.Typeflag != tar.TypeReg {
+			continue
+		}
+		// files of OVA are in its root, do not allow paths outside of dir
+		name := filepath.Base(hdr.Name)
+		if strings.EqualFold(filepath.Ext(name), ".ovf") {
+			if descriptor, err = io.ReadAll(tr); err != nil {
+				return nil, fmt.Errorf("cannot read %s: %w", name, err)
+			}
+			continue
+		}
+		out, err := os.Create(filepath.Join(dir, name))
+		if err != nil {
+			return nil, err
+		}
+		_, err = io.Copy(out, tr)
+		out.Close()
+		if err != nil {
+			return nil, fmt.Errorf("cannot extract %s: %w", name, err)
+		}
+	}
+	if descriptor == nil {
+		return nil, fmt.Errorf("no OVF descriptor found in %s", ovaFile)
+	}
+	return Parse(descriptor, dir)
+}
@@ -0,0 +1,94 @@
+package ova_test
+
+import (
+	"archive/tar"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/lf-edge/eden/pkg/ova"
+	"github.com/stretchr/testify/assert"
+)
+
+const descriptor = `<?xml version="1.0" encoding="UTF-8"?>
+<Envelope xmlns="http://schemas.dmtf.org/ovf/envelope/1"
+  xmlns:ovf="http://schemas.dmtf.org/ovf/envelope/1"
+  xmlns:rasd="http://schemas.dmtf.org/wbem/wscim/1/cim-schema/2/CIM_ResourceAllocationSettingData">
+  <References>
+    <File ovf:id="file1" ovf:href="appliance-disk1.vmdk"/>
+    <File ovf:id="file2" ovf:href="appliance-disk2.vmdk"/>
+  </References>
+  <DiskSection>
+    <Disk ovf:diskId="vmdisk2" ovf:fileRef="file2" ovf:capacity="1" ovf:format="http://www.vmware.com/interfaces/specifications/vmdk.html#streamOptimized"/>
+    <Disk ovf:diskId="vmdisk1" ovf:fileRef="file1" ovf:capacity="8" ovf:format="http://www.vmware.com/interfaces/specifications/vmdk.html#streamOptimized"/>
+  </DiskSection>
+  <VirtualSystem ovf:id="appliance">
+    <Name>appliance</Name>
+    <VirtualHardwareSection>
+      <Item>
+        <rasd:ElementName>2 virtual CPUs</rasd:ElementName>
+        <rasd:ResourceType>3</rasd:ResourceType>
+        <rasd:VirtualQuantity>2</rasd:VirtualQuantity>
+      </Item>
+      <Item>
+        <rasd:AllocationUnits>byte * 2^20</rasd:AllocationUnits>
+        <rasd:ElementName>2048MB of memory</rasd:ElementName>
+        <rasd:ResourceType>4</rasd:ResourceType>
+        <rasd:VirtualQuantity>2048</rasd:VirtualQuantity>
+      </Item>
+      <Item>
+        <rasd:ElementName>disk1</rasd:ElementName>
+        <rasd:HostResource>ovf:/disk/vmdisk1</rasd:HostResource>
+        <rasd:ResourceType>17</rasd:ResourceType>
+      </Item>
+      <Item>
+        <rasd:ElementName>disk2</rasd:ElementName>
+        <rasd:HostResource>ovf:/disk/vmdisk2</rasd:HostResource>
+        <rasd:ResourceType>17</rasd:ResourceType>
+      </Item>
+      <Item>
+        <rasd:Connection>VM Network</rasd:Connection>
+        <rasd:ElementName>Ethernet 1</rasd:ElementName>
+        <rasd:ResourceType>10</rasd:ResourceType>
+      </Item>
+    </VirtualHardwareSection>
+  </VirtualSystem>
+</Envelope>`
+
+func TestUnpack(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	ovaFile := filepath.Join(dir, "appliance.ova")
+	f, err := os.Create(ovaFile)
+	assert.NoError(t, err)
+	tw := tar.NewWriter(f)
+	for name, content := range map[string]string{
+		"appliance.ovf":        descriptor,
+		"appliance-disk1.vmdk": "disk1",
+		"appliance-disk2.vmdk": "disk2",
+	} {
+		assert.NoError(t, tw.WriteHeader(&tar.Header{Name: name, Mode: 0644, Size: int64(len(content))}))
+		_, err = tw.Write([]byte(content))
+		assert.NoError(t, err)
+	}
+	assert.NoError(t, tw.Close())
+	assert.NoError(t, f.Close())
+
+	outDir := filepath.Join(dir, "out")
+	vm, err := ova.Unpack(ovaFile, outDir)
+	assert.NoError(t, err)
+	assert.Equal(t, &ova.VM{
+		Name:   "appliance",
+		CPUs:   2,
+		Memory: 2048 << 20,
+		Disks: []*ova.Disk{
+			{ID: "vmdisk1", Path: filepath.Join(outDir, "appliance-disk1.vmdk"), Format: "vmdk"},
+			{ID: "vmdisk2", Path: filepath.Join(outDir, "appliance-disk2.vmdk"), Format: "vmdk"},
+		},
+		Interfaces: []*ova.Interface{{Name: "Ethernet 1", Network: "VM Network"}},
+	}, vm)
+	content, err := os.ReadFile(vm.Disks[1].Path)
+	assert.NoError(t, err)
+	assert.Equal(t, "disk2", string(content))
+}
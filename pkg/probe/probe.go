@@ -0,0 +1,223 @@
+// Package probe runs health probes (HTTP, TCP or command over SSH) against application
+// instances deployed on EVE and tracks their health with success and failure thresholds.
+package probe
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"regexp"
+	"strconv"
+	"time"
+)
+
+// Type of probe
+type Type string
+
+// supported types of probes
+const (
+	// TypeHTTP sends GET request and checks status (and optionally body) of response
+	TypeHTTP Type = "http"
+	// TypeTCP checks that connection to port can be established
+	TypeTCP Type = "tcp"
+	// TypeExec runs command inside app over SSH and checks exit code
+	TypeExec Type = "exec"
+)
+
+// defaults of probe specification
+const (
+	DefaultInterval         = 10 * time.Second
+	DefaultTimeout          = 5 * time.Second
+	DefaultSuccessThreshold = 1
+	DefaultFailureThreshold = 3
+	DefaultSSHPort          = 22
+)
+
+// maxBody is size of response body read to match it with ExpectBody
+const maxBody = 64 * 1024
+
+// Spec defines probe of app
+type Spec struct {
+	Name string `json:"name"`
+	Type Type   `json:"type"`
+	// Port of app to probe, it is mapped into port forwarded to the host
+	Port int `json:"port"`
+	// Path of HTTP request
+	Path string `json:"path,omitempty"`
+	// ExpectStatus is HTTP status code to expect, any 2xx or 3xx if not set
+	ExpectStatus int `json:"expectStatus,omitempty"`
+	// ExpectBody is regexp to match body of HTTP response or output of command with
+	ExpectBody string `json:"expectBody,omitempty"`
+	// Command to run with exec probe
+	Command string `json:"command,omitempty"`
+	// User to SSH with exec probe
+	User string `json:"user,omitempty"`
+	// SSHKey is private key to SSH with exec probe
+	SSHKey string `json:"sshKey,omitempty"`
+	// Interval between probes
+	Interval time.Duration `json:"interval"`
+	// Timeout of one probe
+	Timeout time.Duration `json:"timeout"`
+	// SuccessThreshold is count of consecutive successes to consider app healthy
+	SuccessThreshold int `json:"successThreshold"`
+	// FailureThreshold is count of consecutive failures to consider app unhealthy
+	FailureThreshold int `json:"failureThreshold"`
+}
+
+// Validate checks spec and sets defaults for unset fields
+func (s *Spec) Validate() error {
+	if s.Name == "" {
+		s.Name = string(s.Type)
+	}
+	switch s.Type {
+	case TypeHTTP:
+		if s.Path == "" {
+			s.Path = "/"
+		}
+	case TypeTCP:
+	case TypeExec:
+		if s.Command == "" {
+			return fmt.Errorf("probe %s: no command to run", s.Name)
+		}
+		if s.Port == 0 {
+			s.Port = DefaultSSHPort
+		}
+		if s.User == "" {
+			s.User = "root"
+		}
+	default:
+		return fmt.Errorf("probe %s: unknown type %q (supported: %s, %s, %s)", s.Name, s.Type, TypeHTTP, TypeTCP, TypeExec)
+	}
+	if s.Port <= 0 || s.Port > 65535 {
+		return fmt.Errorf("probe %s: invalid port %d", s.Name, s.Port)
+	}
+	if s.ExpectBody != "" {
+		if _, err := regexp.Compile(s.ExpectBody); err != nil {
+			return fmt.Errorf("probe %s: invalid expected body: %w", s.Name, err)
+		}
+	}
+	if s.Interval <= 0 {
+		s.Interval = DefaultInterval
+	}
+	if s.Timeout <= 0 {
+		s.Timeout = DefaultTimeout
+	}
+	if s.SuccessThreshold <= 0 {
+		s.SuccessThreshold = DefaultSuccessThreshold
+	}
+	if s.FailureThreshold <= 0 {
+		s.FailureThreshold = DefaultFailureThreshold
+	}
+	return nil
+}
+
+// String returns short description of probe
+func (s *Spec) String() string {
+	switch s.Type {
+	case TypeHTTP:
+		return fmt.Sprintf("%s: http :%d%s", s.Name, s.Port, s.Path)
+	case TypeExec:
+		return fmt.Sprintf("%s: exec %q", s.Name, s.Command)
+	}
+	return fmt.Sprintf("%s: %s :%d", s.Name, s.Type, s.Port)
+}
+
+// Target is address the port of app is reachable on from the host
+type Target struct {
+	Host string
+	Port int
+}
+
+func (t Target) String() string {
+	return net.JoinHostPort(t.Host, strconv.Itoa(t.Port))
+}
+
+// ExecFunc runs command of exec probe over SSH on target and returns its output
+type ExecFunc func(ctx context.Context, target Target, spec *Spec) (string, error)
+
+// Result of one probe
+type Result struct {
+	Probe    string
+	Time     time.Time
+	OK       bool
+	Message  string
+	Duration time.Duration
+}
+
+// Run runs probe once against target, exec is used for exec probes
+func Run(spec *Spec, target Target, exec ExecFunc) Result {
+	ctx, cancel := context.WithTimeout(context.Background(), spec.Timeout)
+	defer cancel()
+	result := Result{Probe: spec.Name, Time: time.Now()}
+	var err error
+	switch spec.Type {
+	case TypeHTTP:
+		err = runHTTP(ctx, spec, target)
+	case TypeTCP:
+		var conn net.Conn
+		if conn, err = (&net.Dialer{}).DialContext(ctx, "tcp", target.String()); err == nil {
+			_ = conn.Close()
+		}
+	case TypeExec:
+		if exec == nil {
+			err = fmt.Errorf("exec probes are not supported")
+			break
+		}
+		var output string
+		if output, err = exec(ctx, target, spec); err == nil {
+			err = matchBody(spec, output)
+		}
+	default:
+		err = fmt.Errorf("unknown type %q", spec.Type)
+	}
+	result.Duration = time.Since(result.Time)
+	if err != nil {
+		result.Message = err.Error()
+		return result
+	}
+	result.OK = true
+	return result
+}
+
+func runHTTP(ctx context.Context, spec *Spec, target Target) error {
+	url := fmt.Sprintf("http://%s%s", target, spec.Path)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if spec.ExpectStatus != 0 && resp.StatusCode != spec.ExpectStatus {
+		return fmt.Errorf("status %d, expected %d", resp.StatusCode, spec.ExpectStatus)
+	}
+	if spec.ExpectStatus == 0 && (resp.StatusCode < 200 || resp.StatusCode >= 400) {
+		return fmt.Errorf("status %d", resp.StatusCode)
+	}
+	if spec.ExpectBody == "" {
+		return nil
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxBody))
+	if err != nil {
+		return err
+	}
+	return matchBody(spec, string(body))
+}
+
+func matchBody(spec *Spec, body string) error {
+	if spec.ExpectBody == "" {
+		return nil
+	}
+	matched, err := regexp.MatchString(spec.ExpectBody, body)
+	if err != nil {
+		return err
+	}
+	if !matched {
+		return fmt.Errorf("output does not match %q", spec.ExpectBody)
+	}
+	return nil
+}
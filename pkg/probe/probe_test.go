@@ -0,0 +1,108 @@
+package probe_test
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	"github.com/lf-edge/eden/pkg/probe"
+	"github.com/stretchr/testify/assert"
+)
+
+func target(t *testing.T, addr string) probe.Target {
+	host, port, err := net.SplitHostPort(addr)
+	assert.NoError(t, err)
+	p, err := strconv.Atoi(port)
+	assert.NoError(t, err)
+	return probe.Target{Host: host, Port: p}
+}
+
+func TestRun(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/health" {
+			http.NotFound(w, r)
+			return
+		}
+		fmt.Fprint(w, "status: ok")
+	}))
+	defer server.Close()
+	tgt := target(t, server.Listener.Addr().String())
+
+	spec := &probe.Spec{Type: probe.TypeHTTP, Port: 80, Path: "/health", ExpectBody: "ok$"}
+	assert.NoError(t, spec.Validate())
+	assert.True(t, probe.Run(spec, tgt, nil).OK)
+
+	spec = &probe.Spec{Type: probe.TypeHTTP, Port: 80, Path: "/missing"}
+	assert.NoError(t, spec.Validate())
+	result := probe.Run(spec, tgt, nil)
+	assert.False(t, result.OK)
+	assert.Contains(t, result.Message, "404")
+
+	spec = &probe.Spec{Type: probe.TypeTCP, Port: 80}
+	assert.NoError(t, spec.Validate())
+	assert.True(t, probe.Run(spec, tgt, nil).OK)
+
+	spec = &probe.Spec{Type: probe.TypeExec, Command: "cat /ready", ExpectBody: "ready"}
+	assert.NoError(t, spec.Validate())
+	assert.Equal(t, probe.DefaultSSHPort, spec.Port)
+	exec := func(ctx context.Context, target probe.Target, spec *probe.Spec) (string, error) {
+		return "starting", nil
+	}
+	assert.False(t, probe.Run(spec, tgt, exec).OK)
+	assert.False(t, probe.Run(spec, tgt, nil).OK)
+
+	assert.Error(t, (&probe.Spec{Type: "grpc", Port: 80}).Validate())
+	assert.Error(t, (&probe.Spec{Type: probe.TypeTCP}).Validate())
+	assert.Error(t, (&probe.Spec{Type: probe.TypeExec}).Validate())
+}
+
+func TestStatus(t *testing.T) {
+	t.Parallel()
+
+	spec := &probe.Spec{Type: probe.TypeTCP, Port: 80, SuccessThreshold: 2, FailureThreshold: 2}
+	assert.NoError(t, spec.Validate())
+	status := probe.NewStatus(spec)
+	assert.Equal(t, probe.HealthUnknown, status.Observe(probe.Result{OK: true}))
+	assert.Equal(t, probe.HealthHealthy, status.Observe(probe.Result{OK: true}))
+	assert.Equal(t, probe.HealthHealthy, status.Observe(probe.Result{OK: false}))
+	assert.Equal(t, probe.HealthUnhealthy, status.Observe(probe.Result{OK: false}))
+
+	healthy := probe.NewStatus(spec)
+	healthy.Health = probe.HealthHealthy
+	assert.Equal(t, probe.HealthUnknown, probe.Combine(nil))
+	assert.Equal(t, probe.HealthHealthy, probe.Combine([]*probe.Status{healthy}))
+	assert.Equal(t, probe.HealthUnknown, probe.Combine([]*probe.Status{healthy, probe.NewStatus(spec)}))
+	assert.Equal(t, probe.HealthUnhealthy, probe.Combine([]*probe.Status{healthy, status}))
+}
+
+func TestStore(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "probes.json")
+	store, err := probe.Load(path)
+	assert.NoError(t, err)
+	assert.NoError(t, store.Add("app1", &probe.Spec{Type: probe.TypeHTTP, Port: 80}))
+	assert.NoError(t, store.Add("app1", &probe.Spec{Name: "ssh", Type: probe.TypeTCP, Port: 22}))
+	assert.NoError(t, store.Add("app1", &probe.Spec{Type: probe.TypeHTTP, Port: 8080}))
+	assert.Error(t, store.Add("app2", &probe.Spec{Type: probe.TypeTCP}))
+	assert.NoError(t, store.Save())
+
+	store, err = probe.Load(path)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"app1"}, store.Apps())
+	if specs := store.Get("app1"); assert.Len(t, specs, 2) {
+		assert.Equal(t, 8080, specs[0].Port)
+		assert.Equal(t, probe.DefaultInterval, specs[0].Interval)
+	}
+	assert.True(t, store.Delete("app1", "ssh"))
+	assert.False(t, store.Delete("app1", "ssh"))
+	assert.True(t, store.Delete("app1", ""))
+	assert.Empty(t, store.Apps())
+}
@@ -0,0 +1,59 @@
+package probe
+
+// health of app evaluated from results of its probes
+const (
+	HealthUnknown   = "UNKNOWN"
+	HealthHealthy   = "HEALTHY"
+	HealthUnhealthy = "UNHEALTHY"
+)
+
+// Status tracks consecutive results of probe to evaluate health with thresholds of spec
+type Status struct {
+	Spec      *Spec
+	Health    string
+	Last      Result
+	successes int
+	failures  int
+}
+
+// NewStatus returns status of probe with unknown health
+func NewStatus(spec *Spec) *Status {
+	return &Status{Spec: spec, Health: HealthUnknown}
+}
+
+// Observe updates health with result of probe and returns it
+func (s *Status) Observe(result Result) string {
+	s.Last = result
+	if result.OK {
+		s.successes++
+		s.failures = 0
+		if s.successes >= s.Spec.SuccessThreshold {
+			s.Health = HealthHealthy
+		}
+	} else {
+		s.failures++
+		s.successes = 0
+		if s.failures >= s.Spec.FailureThreshold {
+			s.Health = HealthUnhealthy
+		}
+	}
+	return s.Health
+}
+
+// Combine returns health of app with statuses of all its probes: unhealthy if any
+// of probes is unhealthy, healthy if all of them are healthy and unknown otherwise
+func Combine(statuses []*Status) string {
+	if len(statuses) == 0 {
+		return HealthUnknown
+	}
+	health := HealthHealthy
+	for _, status := range statuses {
+		switch status.Health {
+		case HealthUnhealthy:
+			return HealthUnhealthy
+		case HealthUnknown:
+			health = HealthUnknown
+		}
+	}
+	return health
+}
@@ -0,0 +1,97 @@
+package probe
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// Store keeps probes of apps in JSON file
+type Store struct {
+	path   string
+	probes map[string][]*Spec
+}
+
+// Load reads probes from file, missing file means no probes
+func Load(path string) (*Store, error) {
+	store := &Store{path: path, probes: map[string][]*Spec{}}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return store, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err = json.Unmarshal(data, &store.probes); err != nil {
+		return nil, fmt.Errorf("cannot parse probes from %s: %w", path, err)
+	}
+	return store, nil
+}
+
+// Save writes probes into file
+func (s *Store) Save() error {
+	data, err := json.MarshalIndent(s.probes, "", "    ")
+	if err != nil {
+		return err
+	}
+	if err = os.MkdirAll(filepath.Dir(s.path), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(s.path, data, 0644)
+}
+
+// Add validates spec and adds it to probes of app replacing probe with the same name
+func (s *Store) Add(app string, spec *Spec) error {
+	if err := spec.Validate(); err != nil {
+		return err
+	}
+	for i, el := range s.probes[app] {
+		if el.Name == spec.Name {
+			s.probes[app][i] = spec
+			return nil
+		}
+	}
+	s.probes[app] = append(s.probes[app], spec)
+	return nil
+}
+
+// Delete removes probe of app with name (all probes of app if name is empty),
+// it returns false if there was nothing to remove
+func (s *Store) Delete(app, name string) bool {
+	probes, ok := s.probes[app]
+	if !ok {
+		return false
+	}
+	if name == "" {
+		delete(s.probes, app)
+		return true
+	}
+	for i, el := range probes {
+		if el.Name == name {
+			s.probes[app] = append(probes[:i], probes[i+1:]...)
+			if len(s.probes[app]) == 0 {
+				delete(s.probes, app)
+			}
+			return true
+		}
+	}
+	return false
+}
+
+// Get returns probes of app
+func (s *Store) Get(app string) []*Spec {
+	return s.probes[app]
+}
+
+// Apps returns sorted names of apps with probes
+func (s *Store) Apps() []string {
+	apps := make([]string, 0, len(s.probes))
+	for app := range s.probes {
+		apps = append(apps, app)
+	}
+	sort.Strings(apps)
+	return apps
+}
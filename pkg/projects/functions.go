@@ -2,6 +2,8 @@ package projects
 
 import (
 	"fmt"
+	"net"
+	"strconv"
 	"strings"
 
 	"github.com/lf-edge/eden/pkg/controller/eapps"
@@ -50,21 +52,21 @@ func SendCommandSSH(ip *string, port *int, user, password, command string, foreg
 				HostKeyCallback: ssh.InsecureIgnoreHostKey(),
 				Timeout:         defaults.DefaultRepeatTimeout,
 			}
-			conn, err := ssh.Dial("tcp", fmt.Sprintf("%s:%d", *ip, *port), configSSH)
+			conn, err := ssh.Dial("tcp", net.JoinHostPort(*ip, strconv.Itoa(*port)), configSSH)
 			if err != nil {
-				fmt.Printf("No ssh connections: %v", err)
+				log.Errorf("no ssh connections: %v", err)
 				return nil
 			}
 			session, err := conn.NewSession()
 			if err != nil {
-				fmt.Printf("Error creating session: %v", err)
+				log.Errorf("error creating session: %v", err)
 				return nil
 			}
 			if foreground {
 				defer conn.Close()
 				defer session.Close()
 				if err := session.Run(command); err != nil {
-					fmt.Println(err)
+					log.Errorf("command %q failed: %v", command, err)
 					return nil
 				}
 			} else {
@@ -96,21 +98,21 @@ func SendFileSCP(ip *string, port *int, user, password, filename, destpath strin
 				Timeout:         defaults.DefaultRepeatTimeout,
 			}
 
-			conn, err := ssh.Dial("tcp", fmt.Sprintf("%s:%d", *ip, *port), configSSH)
+			conn, err := ssh.Dial("tcp", net.JoinHostPort(*ip, strconv.Itoa(*port)), configSSH)
 			if err != nil {
-				fmt.Printf("No ssh connections: %v", err)
+				log.Errorf("no ssh connections: %v", err)
 				return nil
 			}
 
 			session, err := conn.NewSession()
 			if err != nil {
-				fmt.Printf("Create new session failed: %v\n", err)
+				log.Errorf("create new session failed: %v", err)
 				return nil
 			}
 
 			err = scp.CopyPath(filename, destpath, session)
 			if err != nil {
-				fmt.Printf("Copy file on guest VM failed: %v\n", err)
+				log.Errorf("copy file on guest VM failed: %v", err)
 				return nil
 			}
 			return fmt.Errorf("scp of file %s done", filename)
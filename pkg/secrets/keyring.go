@@ -0,0 +1,106 @@
+package secrets
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os/exec"
+	"runtime"
+	"strings"
+)
+
+const keyringService = "eden"
+
+// Keyring keeps small secrets in storage of OS
+type Keyring interface {
+	Get(account string) ([]byte, error)
+	Set(account string, secret []byte) error
+}
+
+// SystemKeyring returns keyring of OS accessed with its command line tool:
+// secret-tool of libsecret on Linux and security on macOS
+func SystemKeyring() (Keyring, error) {
+	tool := "secret-tool"
+	if runtime.GOOS == "darwin" {
+		tool = "security"
+	}
+	path, err := exec.LookPath(tool)
+	if err != nil {
+		return nil, fmt.Errorf("keyring is not available: %w", err)
+	}
+	if runtime.GOOS == "darwin" {
+		return &macKeyring{tool: path}, nil
+	}
+	return &secretToolKeyring{tool: path}, nil
+}
+
+// secretToolKeyring uses Secret Service (e.g. GNOME Keyring or KWallet) through secret-tool
+type secretToolKeyring struct {
+	tool string
+}
+
+func (k *secretToolKeyring) Get(account string) ([]byte, error) {
+	out, _, err := runKeyringTool(nil, k.tool, "lookup", "service", keyringService, "account", account)
+	if err != nil {
+		return nil, err
+	}
+	return hex.DecodeString(strings.TrimSpace(out))
+}
+
+func (k *secretToolKeyring) Set(account string, secret []byte) error {
+	_, _, err := runKeyringTool([]byte(hex.EncodeToString(secret)), k.tool, "store",
+		"--label", "eden secrets", "service", keyringService, "account", account)
+	return err
+}
+
+// macKeyring uses login keychain of macOS through security
+type macKeyring struct {
+	tool string
+}
+
+func (k *macKeyring) Get(account string) ([]byte, error) {
+	out, _, err := runKeyringTool(nil, k.tool, "find-generic-password", "-s", keyringService, "-a", account, "-w")
+	if err != nil {
+		return nil, err
+	}
+	return hex.DecodeString(strings.TrimSpace(out))
+}
+
+// Set passes the command to interactive mode of security on stdin,
+// so the secret does not appear in arguments of the process
+func (k *macKeyring) Set(account string, secret []byte) error {
+	if strings.ContainsAny(account, "\"\\\n") {
+		return fmt.Errorf("unsupported characters in account %q", account)
+	}
+	command := fmt.Sprintf("add-generic-password -U -s \"%s\" -a \"%s\" -w %s\n",
+		keyringService, account, hex.EncodeToString(secret))
+	_, errOut, err := runKeyringTool([]byte(command), k.tool, "-i")
+	if err != nil {
+		return err
+	}
+	// interactive mode reports errors of commands without failing
+	if errOut != "" {
+		return fmt.Errorf("%s add-generic-password: %s", k.tool, errOut)
+	}
+	return nil
+}
+
+// runKeyringTool returns stdout and stderr of the tool
+func runKeyringTool(stdin []byte, name string, args ...string) (string, string, error) {
+	var stdout, stderr bytes.Buffer
+	cmd := exec.Command(name, args...)
+	if stdin != nil {
+		cmd.Stdin = bytes.NewReader(stdin)
+	}
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) && stderr.Len() > 0 {
+			return "", "", fmt.Errorf("%s %s: %s", name, args[0], strings.TrimSpace(stderr.String()))
+		}
+		return "", "", fmt.Errorf("%s %s: %w", name, args[0], err)
+	}
+	return stdout.String(), strings.TrimSpace(stderr.String()), nil
+}
@@ -0,0 +1,43 @@
+package secrets
+
+import (
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeTool writes script recording its arguments and stdin into dir
+func fakeTool(t *testing.T, dir, stderr string) string {
+	tool := filepath.Join(dir, "security")
+	script := "#!/bin/sh\necho \"$@\" > " + filepath.Join(dir, "args") + "\ncat > " + filepath.Join(dir, "stdin") + "\n"
+	if stderr != "" {
+		script += "echo '" + stderr + "' >&2\n"
+	}
+	assert.NoError(t, os.WriteFile(tool, []byte(script), 0755))
+	return tool
+}
+
+func TestMacKeyringSet(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	secret := []byte("very secret key")
+	k := &macKeyring{tool: fakeTool(t, dir, "")}
+	assert.NoError(t, k.Set("default", secret))
+
+	args, err := os.ReadFile(filepath.Join(dir, "args"))
+	assert.NoError(t, err)
+	assert.Equal(t, "-i\n", string(args))
+	assert.NotContains(t, string(args), hex.EncodeToString(secret))
+	stdin, err := os.ReadFile(filepath.Join(dir, "stdin"))
+	assert.NoError(t, err)
+	assert.Equal(t, "add-generic-password -U -s \"eden\" -a \"default\" -w "+hex.EncodeToString(secret)+"\n", string(stdin))
+
+	assert.Error(t, k.Set("bad\"account", secret))
+
+	failing := &macKeyring{tool: fakeTool(t, t.TempDir(), "security: SecKeychainItemCreateFromContent: User interaction is not allowed.")}
+	assert.ErrorContains(t, failing.Set("default", secret), "User interaction is not allowed")
+}
@@ -0,0 +1,340 @@
+// Package secrets keeps tokens, passwords and credentials used by eden encrypted in one file
+// inside the eden directory instead of plaintext config of context. Values of config in form
+// secret:<name> are references to secrets, which are resolved when config is loaded.
+package secrets
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/user"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/lf-edge/eden/pkg/defaults"
+	"golang.org/x/crypto/scrypt"
+	"golang.org/x/term"
+)
+
+const (
+	refPrefix = "secret:"
+
+	keyFromKeyring    = "keyring"
+	keyFromPassphrase = "passphrase"
+
+	keySize = 32
+)
+
+// ErrNotFound is returned for name not present in store
+var ErrNotFound = errors.New("secret not found")
+
+// Ref returns reference to secret with name to use as value in config
+func Ref(name string) string {
+	return refPrefix + name
+}
+
+// RefName returns name of secret if value is reference to it
+func RefName(value string) (string, bool) {
+	if !strings.HasPrefix(value, refPrefix) {
+		return "", false
+	}
+	return strings.TrimPrefix(value, refPrefix), true
+}
+
+// PassphraseFunc returns passphrase to derive key of store from
+type PassphraseFunc func() ([]byte, error)
+
+// encryptedFile is content of file of store
+type encryptedFile struct {
+	// Key is source of key: random one in OS keyring or derived from passphrase
+	Key   string `json:"key"`
+	Salt  []byte `json:"salt,omitempty"`
+	Nonce []byte `json:"nonce"`
+	Data  []byte `json:"data"`
+}
+
+// Store is a set of named secrets encrypted with AES-GCM in file. The key is kept in OS keyring
+// if it is available, otherwise it is derived from passphrase.
+type Store struct {
+	path       string
+	keyring    Keyring
+	passphrase PassphraseFunc
+	// preferPassphrase selects passphrase for new store even if keyring is available
+	preferPassphrase bool
+
+	mu     sync.Mutex
+	loaded bool
+	values map[string]string
+	// keySource and key are set when store is decrypted or encrypted first time
+	keySource string
+	salt      []byte
+	key       []byte
+}
+
+// NewStore returns store of secrets in file of path. Keyring may be nil to use passphrase only.
+func NewStore(path string, keyring Keyring, passphrase PassphraseFunc) *Store {
+	return &Store{path: path, keyring: keyring, passphrase: passphrase}
+}
+
+var (
+	defaultStore     *Store
+	defaultStoreOnce sync.Once
+)
+
+// Default returns store shared between contexts in eden directory. Passphrase is taken from
+// environment variable or asked in terminal, key in OS keyring is used if variable is not set.
+func Default() *Store {
+	defaultStoreOnce.Do(func() {
+		dir := defaults.DefaultEdenHomeDir
+		if usr, err := user.Current(); err == nil {
+			dir = filepath.Join(usr.HomeDir, defaults.DefaultEdenHomeDir)
+		}
+		keyring, _ := SystemKeyring()
+		defaultStore = NewStore(filepath.Join(dir, defaults.DefaultSecretsFile), keyring, passphraseFromEnv)
+		_, defaultStore.preferPassphrase = os.LookupEnv(defaults.DefaultSecretsPassEnv)
+	})
+	return defaultStore
+}
+
+func passphraseFromEnv() ([]byte, error) {
+	if pass, ok := os.LookupEnv(defaults.DefaultSecretsPassEnv); ok {
+		return []byte(pass), nil
+	}
+	if !term.IsTerminal(int(os.Stdin.Fd())) {
+		return nil, fmt.Errorf("no passphrase for secrets: set %s", defaults.DefaultSecretsPassEnv)
+	}
+	fmt.Fprint(os.Stderr, "Enter passphrase for eden secrets: ")
+	pass, err := term.ReadPassword(int(os.Stdin.Fd()))
+	fmt.Fprintln(os.Stderr)
+	return pass, err
+}
+
+// Resolve returns value of secret if value is reference to it, otherwise value as is
+func (s *Store) Resolve(value string) (string, error) {
+	name, ok := RefName(value)
+	if !ok {
+		return value, nil
+	}
+	return s.Get(name)
+}
+
+// Get returns value of secret with name
+func (s *Store) Get(name string) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.load(); err != nil {
+		return "", err
+	}
+	value, ok := s.values[name]
+	if !ok {
+		return "", fmt.Errorf("%w: %s", ErrNotFound, name)
+	}
+	return value, nil
+}
+
+// Set stores value of secret with name
+func (s *Store) Set(name, value string) error {
+	if name == "" {
+		return errors.New("empty name of secret")
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.load(); err != nil {
+		return err
+	}
+	s.values[name] = value
+	return s.save()
+}
+
+// Delete removes secret with name
+func (s *Store) Delete(name string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.load(); err != nil {
+		return err
+	}
+	if _, ok := s.values[name]; !ok {
+		return fmt.Errorf("%w: %s", ErrNotFound, name)
+	}
+	delete(s.values, name)
+	return s.save()
+}
+
+// List returns sorted names of secrets
+func (s *Store) List() ([]string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.load(); err != nil {
+		return nil, err
+	}
+	names := make([]string, 0, len(s.values))
+	for name := range s.values {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// keyringAccount is the name of key of store in OS keyring
+func (s *Store) keyringAccount() string {
+	if abs, err := filepath.Abs(s.path); err == nil {
+		return abs
+	}
+	return s.path
+}
+
+// load decrypts file of store once, missing file is an empty store
+func (s *Store) load() error {
+	if s.loaded {
+		return nil
+	}
+	data, err := os.ReadFile(s.path)
+	if errors.Is(err, os.ErrNotExist) {
+		s.values = map[string]string{}
+		s.loaded = true
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("cannot read secrets: %w", err)
+	}
+	var file encryptedFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return fmt.Errorf("cannot parse secrets file %s: %w", s.path, err)
+	}
+	s.keySource, s.salt = file.Key, file.Salt
+	if s.key, err = s.getKey(false); err != nil {
+		return err
+	}
+	gcm, err := newGCM(s.key)
+	if err != nil {
+		return err
+	}
+	plain, err := gcm.Open(nil, file.Nonce, file.Data, nil)
+	if err != nil {
+		return fmt.Errorf("cannot decrypt secrets (wrong key?): %w", err)
+	}
+	values := map[string]string{}
+	if err := json.Unmarshal(plain, &values); err != nil {
+		return fmt.Errorf("cannot parse secrets: %w", err)
+	}
+	s.values = values
+	s.loaded = true
+	return nil
+}
+
+// save encrypts values with new nonce and replaces file of store
+func (s *Store) save() error {
+	if s.key == nil {
+		if s.keySource == "" {
+			s.keySource = keyFromPassphrase
+			if s.keyring != nil && !s.preferPassphrase {
+				s.keySource = keyFromKeyring
+			}
+		}
+		key, err := s.getKey(true)
+		if err != nil {
+			return err
+		}
+		s.key = key
+	}
+	gcm, err := newGCM(s.key)
+	if err != nil {
+		return err
+	}
+	plain, err := json.Marshal(s.values)
+	if err != nil {
+		return err
+	}
+	file := encryptedFile{Key: s.keySource, Salt: s.salt, Nonce: make([]byte, gcm.NonceSize())}
+	if _, err := rand.Read(file.Nonce); err != nil {
+		return err
+	}
+	file.Data = gcm.Seal(nil, file.Nonce, plain, nil)
+	data, err := json.Marshal(file)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(s.path), 0o700); err != nil {
+		return err
+	}
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return fmt.Errorf("cannot write secrets: %w", err)
+	}
+	return os.Rename(tmp, s.path)
+}
+
+// getKey returns key from keySource, new key is generated if create is set
+func (s *Store) getKey(create bool) ([]byte, error) {
+	switch s.keySource {
+	case keyFromKeyring:
+		if s.keyring == nil {
+			return nil, fmt.Errorf("secrets in %s are encrypted with key in OS keyring, which is not available", s.path)
+		}
+		if !create {
+			key, err := s.keyring.Get(s.keyringAccount())
+			if err != nil {
+				return nil, fmt.Errorf("cannot get key of secrets from keyring: %w", err)
+			}
+			return key, nil
+		}
+		key := make([]byte, keySize)
+		if _, err := rand.Read(key); err != nil {
+			return nil, err
+		}
+		if err := s.keyring.Set(s.keyringAccount(), key); err != nil {
+			return nil, fmt.Errorf("cannot store key of secrets in keyring: %w", err)
+		}
+		return key, nil
+	case keyFromPassphrase:
+		if s.passphrase == nil {
+			return nil, errors.New("no passphrase for secrets")
+		}
+		if create {
+			s.salt = make([]byte, 16)
+			if _, err := rand.Read(s.salt); err != nil {
+				return nil, err
+			}
+		}
+		pass, err := s.passphrase()
+		if err != nil {
+			return nil, err
+		}
+		return scrypt.Key(pass, s.salt, 1<<15, 8, 1, keySize)
+	default:
+		return nil, fmt.Errorf("unknown source of key of secrets: %q", s.keySource)
+	}
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// RegistryRef returns name of secret with credentials for registry of host
+func RegistryRef(host string) string {
+	return "registry/" + host
+}
+
+// RegistryCredentials returns user and password for registry of host,
+// kept in secret named by RegistryRef in form user:password
+func (s *Store) RegistryCredentials(host string) (user, password string, err error) {
+	value, err := s.Get(RegistryRef(host))
+	if err != nil {
+		return "", "", err
+	}
+	user, password, ok := strings.Cut(value, ":")
+	if !ok {
+		return "", "", fmt.Errorf("credentials for registry %s must be in form user:password", host)
+	}
+	return user, password, nil
+}
@@ -0,0 +1,69 @@
+package secrets_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/lf-edge/eden/pkg/secrets"
+	"github.com/stretchr/testify/assert"
+)
+
+type memKeyring map[string][]byte
+
+func (k memKeyring) Get(account string) ([]byte, error) { return k[account], nil }
+
+func (k memKeyring) Set(account string, secret []byte) error {
+	k[account] = secret
+	return nil
+}
+
+func passphrase(pass string) secrets.PassphraseFunc {
+	return func() ([]byte, error) { return []byte(pass), nil }
+}
+
+func TestStore(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		name    string
+		keyring secrets.Keyring
+	}{
+		{name: "passphrase"},
+		{name: "keyring", keyring: memKeyring{}},
+	} {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			path := filepath.Join(t.TempDir(), "secrets.enc")
+			store := secrets.NewStore(path, tc.keyring, passphrase("right"))
+			assert.NoError(t, store.Set("controller.zedcloud.token", "token-value"))
+			assert.NoError(t, store.Set(secrets.RegistryRef("docker.io"), "user:pa:ss"))
+
+			data, err := os.ReadFile(path)
+			assert.NoError(t, err)
+			assert.False(t, strings.Contains(string(data), "token-value"))
+
+			reopened := secrets.NewStore(path, tc.keyring, passphrase("right"))
+			value, err := reopened.Resolve(secrets.Ref("controller.zedcloud.token"))
+			assert.NoError(t, err)
+			assert.Equal(t, "token-value", value)
+			user, password, err := reopened.RegistryCredentials("docker.io")
+			assert.NoError(t, err)
+			assert.Equal(t, "user", user)
+			assert.Equal(t, "pa:ss", password)
+			_, err = reopened.Get("missing")
+			assert.ErrorIs(t, err, secrets.ErrNotFound)
+			value, err = reopened.Resolve("plain")
+			assert.NoError(t, err)
+			assert.Equal(t, "plain", value)
+
+			if tc.keyring == nil {
+				_, err = secrets.NewStore(path, nil, passphrase("wrong")).List()
+				assert.Error(t, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,110 @@
+// Package testhistory keeps results of eden tests in local sqlite database
+// and calculates trends of pass rate and duration across runs.
+package testhistory
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	// pure Go driver, eden is built without cgo
+	_ "modernc.org/sqlite"
+)
+
+const (
+	// StatusPass is status of passed test
+	StatusPass = "PASS"
+	// StatusFail is status of failed test
+	StatusFail = "FAIL"
+	// StatusSkip is status of skipped test
+	StatusSkip = "SKIP"
+)
+
+const schema = `CREATE TABLE IF NOT EXISTS results (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	run_id TEXT NOT NULL,
+	started INTEGER NOT NULL,
+	test TEXT NOT NULL,
+	status TEXT NOT NULL,
+	duration_ms INTEGER NOT NULL,
+	eve_version TEXT NOT NULL,
+	context_hash TEXT NOT NULL
+);
+CREATE INDEX IF NOT EXISTS results_run ON results (run_id);`
+
+// Result of one test
+type Result struct {
+	// RunID is the same for all results of one 'eden test' invocation
+	RunID       string
+	Started     time.Time
+	Test        string
+	Status      string
+	Duration    time.Duration
+	EVEVersion  string
+	ContextHash string
+}
+
+// DB is database with history of results
+type DB struct {
+	db *sql.DB
+}
+
+// Open opens database in file, creates it if not exists
+func Open(path string) (*DB, error) {
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		return nil, fmt.Errorf("cannot open %s: %w", path, err)
+	}
+	if _, err = db.Exec(schema); err != nil {
+		_ = db.Close()
+		return nil, fmt.Errorf("cannot init %s: %w", path, err)
+	}
+	return &DB{db: db}, nil
+}
+
+// Close closes database
+func (d *DB) Close() error {
+	return d.db.Close()
+}
+
+// Add saves results into database
+func (d *DB) Add(results []Result) error {
+	tx, err := d.db.Begin()
+	if err != nil {
+		return err
+	}
+	for _, r := range results {
+		_, err = tx.Exec(`INSERT INTO results (run_id, started, test, status, duration_ms, eve_version, context_hash)
+VALUES (?, ?, ?, ?, ?, ?, ?)`,
+			r.RunID, r.Started.Unix(), r.Test, r.Status, r.Duration.Milliseconds(), r.EVEVersion, r.ContextHash)
+		if err != nil {
+			_ = tx.Rollback()
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// Results returns results of the last runs ordered from the oldest one
+func (d *DB) Results(runs int) ([]Result, error) {
+	rows, err := d.db.Query(`SELECT run_id, started, test, status, duration_ms, eve_version, context_hash
+FROM results WHERE run_id IN (
+	SELECT run_id FROM results GROUP BY run_id ORDER BY MIN(started) DESC, MIN(id) DESC LIMIT ?
+) ORDER BY started, id`, runs)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var results []Result
+	for rows.Next() {
+		var r Result
+		var started, duration int64
+		if err := rows.Scan(&r.RunID, &started, &r.Test, &r.Status, &duration, &r.EVEVersion, &r.ContextHash); err != nil {
+			return nil, err
+		}
+		r.Started = time.Unix(started, 0)
+		r.Duration = time.Duration(duration) * time.Millisecond
+		results = append(results, r)
+	}
+	return results, rows.Err()
+}
@@ -0,0 +1,48 @@
+package testhistory_test
+
+import (
+	"fmt"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/lf-edge/eden/pkg/testhistory"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRecordAndTrends(t *testing.T) {
+	t.Parallel()
+
+	db, err := testhistory.Open(filepath.Join(t.TempDir(), "history.db"))
+	assert.NoError(t, err)
+	defer db.Close()
+
+	for i, status := range []string{"PASS", "FAIL", "PASS", "PASS"} {
+		rec := testhistory.NewRecorder(db, "0.0.0-test", "abcdef")
+		w := rec.Start("eden.escript.test", nil)
+		// lines may be split between writes
+		_, _ = fmt.Fprintf(w, "=== RUN   TestA\n--- %s: Test", status)
+		_, _ = fmt.Fprintf(w, "A (%d.50s)\n    --- PASS: TestA/sub (0.01s)\n", i+1)
+		assert.NoError(t, rec.Done(nil))
+		// binary without results of tests is recorded itself
+		rec.Start("eden.empty.test", nil)
+		assert.NoError(t, rec.Done(fmt.Errorf("exit status 1")))
+	}
+
+	results, err := db.Results(3)
+	assert.NoError(t, err)
+	assert.Len(t, results, 9)
+
+	trends := testhistory.Trends(results)
+	assert.Len(t, trends, 3)
+	assert.Equal(t, "TestA", trends[0].Test)
+	assert.Equal(t, []string{"FAIL", "PASS", "PASS"}, trends[0].History)
+	assert.InDelta(t, 2.0/3, trends[0].PassRate, 1e-9)
+	assert.Equal(t, 1.0, trends[0].RecentPassRate)
+	assert.Equal(t, 2500*time.Millisecond, trends[0].OlderMeanDuration)
+	assert.Equal(t, 4*time.Second, trends[0].RecentMeanDuration)
+	assert.InDelta(t, 0.6, trends[0].DurationChange(), 1e-9)
+	assert.Equal(t, "TestA/sub", trends[1].Test)
+	assert.Equal(t, "eden.empty.test", trends[2].Test)
+	assert.Equal(t, 0.0, trends[2].PassRate)
+}
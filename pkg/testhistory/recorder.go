@@ -0,0 +1,125 @@
+package testhistory
+
+import (
+	"bytes"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// resultRe matches result lines of verbose output of go test binaries
+var resultRe = regexp.MustCompile(`^\s*--- (PASS|FAIL|SKIP): (\S+) \(([\d.]+)s\)`)
+
+// ParseLine returns result of test from line of verbose output of test binary
+func ParseLine(line string) (*Result, bool) {
+	match := resultRe.FindStringSubmatch(line)
+	if match == nil {
+		return nil, false
+	}
+	seconds, err := strconv.ParseFloat(match[3], 64)
+	if err != nil {
+		return nil, false
+	}
+	return &Result{
+		Test:     match[2],
+		Status:   match[1],
+		Duration: time.Duration(seconds * float64(time.Second)),
+	}, true
+}
+
+// Recorder collects results from output of test binaries and saves them into DB
+type Recorder struct {
+	db          *DB
+	runID       string
+	runStarted  time.Time
+	eveVersion  string
+	contextHash string
+
+	mu      sync.Mutex
+	testApp string
+	started time.Time
+	buf     bytes.Buffer
+	results []Result
+}
+
+// NewRecorder returns Recorder which saves results into db with the same run ID
+func NewRecorder(db *DB, eveVersion, contextHash string) *Recorder {
+	now := time.Now()
+	return &Recorder{
+		db:          db,
+		runID:       now.UTC().Format("20060102T150405.000000000"),
+		runStarted:  now,
+		eveVersion:  eveVersion,
+		contextHash: contextHash,
+	}
+}
+
+// Start begins collection of results of test binary and returns writer for its output
+func (r *Recorder) Start(testApp string, _ []string) io.Writer {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.testApp = testApp
+	r.started = time.Now()
+	r.buf.Reset()
+	r.results = nil
+	return r
+}
+
+// Write parses output of test binary line by line
+func (r *Recorder) Write(p []byte) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.buf.Write(p)
+	for {
+		line, err := r.buf.ReadString('\n')
+		if err != nil {
+			// keep incomplete line for the next write
+			r.buf.Reset()
+			r.buf.WriteString(line)
+			break
+		}
+		r.parse(line)
+	}
+	return len(p), nil
+}
+
+func (r *Recorder) parse(line string) {
+	result, ok := ParseLine(strings.TrimRight(line, "\n"))
+	if !ok {
+		return
+	}
+	result.RunID = r.runID
+	result.Started = r.runStarted
+	result.EVEVersion = r.eveVersion
+	result.ContextHash = r.contextHash
+	r.results = append(r.results, *result)
+}
+
+// Done saves results of test binary, the binary itself is recorded if it reports no results
+func (r *Recorder) Done(runErr error) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.parse(r.buf.String())
+	r.buf.Reset()
+	results := r.results
+	if len(results) == 0 {
+		status := StatusPass
+		if runErr != nil {
+			status = StatusFail
+		}
+		results = []Result{{
+			RunID:       r.runID,
+			Started:     r.runStarted,
+			Test:        r.testApp,
+			Status:      status,
+			Duration:    time.Since(r.started),
+			EVEVersion:  r.eveVersion,
+			ContextHash: r.contextHash,
+		}}
+	}
+	r.results = nil
+	return r.db.Add(results)
+}
@@ -0,0 +1,80 @@
+package testhistory
+
+import (
+	"sort"
+	"time"
+)
+
+// Trend of one test across runs
+type Trend struct {
+	Test string
+	Runs int
+	// PassRate is part of passed runs among runs not skipped
+	PassRate float64
+	// RecentPassRate is PassRate of the recent half of runs
+	RecentPassRate float64
+	MeanDuration   time.Duration
+	// RecentMeanDuration is MeanDuration of the recent half of runs
+	RecentMeanDuration time.Duration
+	// OlderMeanDuration is MeanDuration of the older half of runs
+	OlderMeanDuration time.Duration
+	// History contains statuses of runs from the oldest one
+	History []string
+}
+
+// DurationChange returns relative change of duration in the recent half of runs
+func (t *Trend) DurationChange() float64 {
+	if t.OlderMeanDuration <= 0 {
+		return 0
+	}
+	return float64(t.RecentMeanDuration-t.OlderMeanDuration) / float64(t.OlderMeanDuration)
+}
+
+// stats returns pass rate and mean duration of results
+func stats(results []Result) (float64, time.Duration) {
+	var passed, counted int
+	var duration time.Duration
+	for _, r := range results {
+		duration += r.Duration
+		if r.Status == StatusSkip {
+			continue
+		}
+		counted++
+		if r.Status == StatusPass {
+			passed++
+		}
+	}
+	if len(results) == 0 {
+		return 0, 0
+	}
+	var rate float64
+	if counted > 0 {
+		rate = float64(passed) / float64(counted)
+	}
+	return rate, duration / time.Duration(len(results))
+}
+
+// Trends calculates trends of tests from results ordered from the oldest run
+func Trends(results []Result) []*Trend {
+	byTest := map[string][]Result{}
+	for _, r := range results {
+		byTest[r.Test] = append(byTest[r.Test], r)
+	}
+	var trends []*Trend
+	for test, testResults := range byTest {
+		trend := &Trend{Test: test, Runs: len(testResults)}
+		trend.PassRate, trend.MeanDuration = stats(testResults)
+		// the recent half, the single run is recent too
+		half := len(testResults) / 2
+		trend.RecentPassRate, trend.RecentMeanDuration = stats(testResults[half:])
+		_, trend.OlderMeanDuration = stats(testResults[:half])
+		for _, r := range testResults {
+			trend.History = append(trend.History, r.Status)
+		}
+		trends = append(trends, trend)
+	}
+	sort.Slice(trends, func(i, j int) bool {
+		return trends[i].Test < trends[j].Test
+	})
+	return trends
+}
@@ -0,0 +1,56 @@
+package tests
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/lf-edge/eden/pkg/apicoverage"
+	log "github.com/sirupsen/logrus"
+)
+
+var (
+	// apiCoverageDir is directory to record coverage of EVE API, every test records into own apicoverage.TestDir
+	apiCoverageDir string
+	// apiCoverageReport is file to save report of coverage
+	apiCoverageReport string
+)
+
+// BeginAPICoverage starts recording of coverage of EVE API by the following runs of tests,
+// EndAPICoverage saves report into the file
+func BeginAPICoverage(report string) error {
+	dir, err := os.MkdirTemp("", "eden-api-coverage")
+	if err != nil {
+		return fmt.Errorf("cannot create directory for API coverage: %w", err)
+	}
+	apiCoverageDir = dir
+	apiCoverageReport = report
+	return nil
+}
+
+// EndAPICoverage prints summary of coverage of EVE API, saves full report and stops recording.
+// It does nothing if recording is not started.
+func EndAPICoverage() error {
+	if apiCoverageDir == "" {
+		return nil
+	}
+	dir, file := apiCoverageDir, apiCoverageReport
+	apiCoverageDir, apiCoverageReport = "", ""
+	defer os.RemoveAll(dir)
+	report, err := apicoverage.ReadReport(dir)
+	if err != nil {
+		return fmt.Errorf("cannot read API coverage: %w", err)
+	}
+	if err := report.PrintSummary(os.Stdout); err != nil {
+		return err
+	}
+	f, err := os.Create(file)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	if err := report.Write(f); err != nil {
+		return fmt.Errorf("cannot write API coverage report: %w", err)
+	}
+	log.Infof("API coverage report saved into %s", file)
+	return nil
+}
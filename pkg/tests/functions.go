@@ -3,6 +3,7 @@ package tests
 import (
 	"flag"
 	"fmt"
+	"io"
 	"os"
 	"os/exec"
 	"strings"
@@ -10,6 +11,7 @@ import (
 
 	"github.com/spf13/viper"
 
+	"github.com/lf-edge/eden/pkg/apicoverage"
 	"github.com/lf-edge/eden/pkg/defaults"
 	"github.com/lf-edge/eden/pkg/utils"
 	log "github.com/sirupsen/logrus"
@@ -24,6 +26,52 @@ func TestArgsParse() {
 	flag.Parse()
 }
 
+// Recorder receives output and result of every run of test binary to keep history of tests
+type Recorder interface {
+	Start(testApp string, args []string) io.Writer
+	Done(err error) error
+}
+
+var recorder Recorder
+
+// SetRecorder sets Recorder for the following runs of tests, nil disables recording
+func SetRecorder(r Recorder) {
+	recorder = r
+}
+
+var reset func() error
+
+// SetReset sets function to bring EVE back to clean state before every test binary run by scenario,
+// nil disables reset
+func SetReset(f func() error) {
+	reset = f
+}
+
+var console func(since time.Time) ([]string, error)
+
+// SetConsole sets function returning lines of console of EVE printed since the time,
+// they are printed for every failed test binary, nil disables printing
+func SetConsole(f func(since time.Time) ([]string, error)) {
+	console = f
+}
+
+// printConsole prints console of EVE printed while test was running
+func printConsole(name string, since time.Time) {
+	if console == nil {
+		return
+	}
+	lines, err := console(since)
+	if err != nil {
+		log.Warnf("cannot get console of EVE for failed test %s: %s", name, err)
+		return
+	}
+	fmt.Fprintf(os.Stderr, "--- console of EVE while test %s was running (last %d lines) ---\n", name, len(lines))
+	for _, line := range lines {
+		fmt.Fprintln(os.Stderr, line)
+	}
+	fmt.Fprintf(os.Stderr, "--- end of console of EVE for test %s ---\n", name)
+}
+
 // RunTest -- single test runner.
 func RunTest(testApp string, args []string, testArgs string, testTimeout string, failScenario string, configFile string, verbosity string) {
 	if testApp != "" {
@@ -65,11 +113,6 @@ func RunTest(testApp string, args []string, testArgs string, testTimeout string,
 
 		resultArgs := append(args, strings.Fields(testArgs)...)
 		log.Debugf("Test: %s %s", path, strings.Join(resultArgs, " "))
-		tst := exec.Command(path, resultArgs...)
-		tst.Stdout = os.Stdout
-		tst.Stderr = os.Stderr
-		tst.Env = append(os.Environ(), fmt.Sprintf("%s=%s",
-			defaults.DefaultConfigEnv, viper.Get("eve.name")))
 
 		targs := ""
 		if testTimeout != "" {
@@ -79,21 +122,89 @@ func RunTest(testApp string, args []string, testArgs string, testTimeout string,
 		if verbosity != "info" {
 			targs = fmt.Sprintf("%s -test.v", targs)
 		}
-
 		if targs != "" {
 			log.Debugf("TestArgsEnv: '%s'", targs)
-			tst.Env = append(tst.Env,
-				fmt.Sprintf("%s=%s",
-					defaults.DefaultTestArgsEnv, targs))
 		}
 
-		err = tst.Run()
+		name := RunName(testApp, resultArgs)
+		started := time.Now()
+		for attempt := 0; ; attempt++ {
+			tst := exec.Command(path, resultArgs...)
+			tst.Stdout = os.Stdout
+			tst.Stderr = os.Stderr
+			tst.Env = append(os.Environ(), fmt.Sprintf("%s=%s",
+				defaults.DefaultConfigEnv, viper.Get("eve.name")))
+
+			if targs != "" {
+				tst.Env = append(tst.Env,
+					fmt.Sprintf("%s=%s",
+						defaults.DefaultTestArgsEnv, targs))
+			}
+
+			if apiCoverageDir != "" {
+				dir := apicoverage.TestDir(apiCoverageDir, name)
+				if err := os.MkdirAll(dir, 0755); err != nil {
+					log.Fatalf("cannot create directory for API coverage: %s", err)
+				}
+				tst.Env = append(tst.Env, fmt.Sprintf("%s=%s", defaults.DefaultAPICoverageEnv, dir))
+			}
+
+			if recorder != nil {
+				// results of passed tests are printed only in verbose mode
+				tst.Args = append(tst.Args, "-test.v")
+				tst.Stdout = io.MultiWriter(os.Stdout, recorder.Start(testApp, resultArgs))
+			}
+
+			err = tst.Run()
+
+			if recorder != nil {
+				if recErr := recorder.Done(err); recErr != nil {
+					log.Errorf("cannot record results of tests: %s", recErr)
+				}
+			}
+
+			if err == nil {
+				if attempt > 0 {
+					log.Warnf("test %s passed only on retry %d", name, attempt)
+					flakyTests = append(flakyTests, name)
+				}
+				break
+			}
+			if attempt >= retryPolicy.Retries {
+				break
+			}
+			log.Warnf("test %s failed: %s, retry %d of %d", name, err, attempt+1, retryPolicy.Retries)
+		}
 		close(done)
 
+		if err != nil {
+			printConsole(name, started)
+		}
+
+		if err != nil && retryPolicy.Quarantined(name) {
+			log.Warnf("test %s failed, but it is in quarantine: %s", name, err)
+			quarantinedFailures = append(quarantinedFailures, name)
+			return
+		}
+
+		if err != nil {
+			recordingFailed = true
+		}
+
 		if err != nil && failScenario != "" {
 			log.Debug("failScenario: ", failScenario)
 			RunScenario("", "", testTimeout, "",
 				configFile, "")
+			LogRetrySummary()
+			if err := EndSuites(); err != nil {
+				log.Error(err)
+			}
+			if err := EndAPICoverage(); err != nil {
+				log.Error(err)
+			}
+			if err := EndRecording(); err != nil {
+				log.Error(err)
+			}
 			os.Exit(1)
 		}
 	}
@@ -174,6 +285,12 @@ func RunScenario(testScenario string, testArgs string, testTimeout string, failS
 				log.Info(targs[i])
 			}
 		}
+		// test binaries of eden are named *.test, other lines of scenario are helpers like echo
+		if reset != nil && strings.HasSuffix(targs[0], ".test") {
+			if err := reset(); err != nil {
+				log.Fatalf("cannot reset EVE before %s: %s", targs[0], err)
+			}
+		}
 		RunTest(targs[0], targs[1:], testArgs, testTimeout,
 			failScenario, configFile, verbosity)
 	}
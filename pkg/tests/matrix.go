@@ -0,0 +1,142 @@
+package tests
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+// Matrix declares EVE versions, hypervisors and architectures to run tests with,
+// tests run once for every combination of them
+type Matrix struct {
+	// Tag is list of tags of EVE
+	Tag []string `yaml:"tag"`
+	// HV is list of hypervisors of EVE (kvm, xen)
+	HV []string `yaml:"hv"`
+	// Arch is list of architectures of EVE (amd64, arm64, riscv64)
+	Arch []string `yaml:"arch"`
+	// Exclude removes combinations matching all defined fields of any item
+	Exclude []MatrixCell `yaml:"exclude"`
+	// Config contains values to set into config of every combination, like eve.accel
+	Config map[string]string `yaml:"config"`
+}
+
+// MatrixCell is one combination of Matrix, empty field is not defined by matrix
+type MatrixCell struct {
+	Tag  string `yaml:"tag"`
+	HV   string `yaml:"hv"`
+	Arch string `yaml:"arch"`
+}
+
+var contextNameReplacer = regexp.MustCompile(`[^a-zA-Z0-9._-]`)
+
+// Label returns description of cell to label results of its tests, like tag=12.4.0,hv=kvm,arch=amd64
+func (c MatrixCell) Label() string {
+	var parts []string
+	for _, kv := range [][2]string{{"tag", c.Tag}, {"hv", c.HV}, {"arch", c.Arch}} {
+		if kv[1] != "" {
+			parts = append(parts, kv[0]+"="+kv[1])
+		}
+	}
+	return strings.Join(parts, ",")
+}
+
+// Context returns name of config context of eden to run tests of cell, like matrix-12.4.0-kvm-amd64
+func (c MatrixCell) Context() string {
+	parts := []string{"matrix"}
+	for _, v := range []string{c.Tag, c.HV, c.Arch} {
+		if v != "" {
+			parts = append(parts, contextNameReplacer.ReplaceAllString(v, "_"))
+		}
+	}
+	return strings.Join(parts, "-")
+}
+
+// matches checks if all defined fields of exclude are equal to fields of cell
+func (c MatrixCell) matches(exclude MatrixCell) bool {
+	return (exclude.Tag == "" || exclude.Tag == c.Tag) &&
+		(exclude.HV == "" || exclude.HV == c.HV) &&
+		(exclude.Arch == "" || exclude.Arch == c.Arch)
+}
+
+// orEmpty returns values or list with one empty value if they are not defined
+func orEmpty(values []string) []string {
+	if len(values) == 0 {
+		return []string{""}
+	}
+	return values
+}
+
+// Cells returns combinations of matrix without excluded ones
+func (m *Matrix) Cells() []MatrixCell {
+	var cells []MatrixCell
+	for _, tag := range orEmpty(m.Tag) {
+		for _, hv := range orEmpty(m.HV) {
+			for _, arch := range orEmpty(m.Arch) {
+				cell := MatrixCell{Tag: tag, HV: hv, Arch: arch}
+				excluded := false
+				for _, exclude := range m.Exclude {
+					if cell.matches(exclude) {
+						excluded = true
+						break
+					}
+				}
+				if !excluded {
+					cells = append(cells, cell)
+				}
+			}
+		}
+	}
+	return cells
+}
+
+// ParseMatrix reads Matrix in yaml format, for example:
+//
+//	tag: ["12.4.0", "13.4.0"]
+//	hv: [kvm, xen]
+//	arch: [amd64]
+//	exclude:
+//	  - tag: "12.4.0"
+//	    hv: xen
+//	config:
+//	  eve.accel: "true"
+func ParseMatrix(r io.Reader) (*Matrix, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	var m Matrix
+	if err := yaml.UnmarshalStrict(data, &m); err != nil {
+		return nil, err
+	}
+	if len(m.Tag) == 0 && len(m.HV) == 0 && len(m.Arch) == 0 {
+		return nil, fmt.Errorf("matrix defines neither tag, nor hv, nor arch")
+	}
+	for _, arch := range m.Arch {
+		if arch != "amd64" && arch != "arm64" && arch != "riscv64" {
+			return nil, fmt.Errorf("unsupported arch in matrix: %s", arch)
+		}
+	}
+	if len(m.Cells()) == 0 {
+		return nil, fmt.Errorf("all combinations of matrix are excluded")
+	}
+	return &m, nil
+}
+
+// ReadMatrix reads Matrix from file, see ParseMatrix for format
+func ReadMatrix(path string) (*Matrix, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	result, err := ParseMatrix(f)
+	if err != nil {
+		return nil, fmt.Errorf("cannot parse matrix %s: %w", path, err)
+	}
+	return result, nil
+}
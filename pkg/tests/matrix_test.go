@@ -0,0 +1,53 @@
+package tests_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/lf-edge/eden/pkg/tests"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMatrix(t *testing.T) {
+	t.Parallel()
+
+	matrix, err := tests.ParseMatrix(strings.NewReader(`
+tag: ["12.4.0", "13.4.0"]
+hv: [kvm, xen]
+arch: [amd64, arm64]
+exclude:
+  - hv: xen
+    arch: arm64
+  - tag: "12.4.0"
+    hv: xen
+config:
+  eve.accel: "true"
+`))
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{"eve.accel": "true"}, matrix.Config)
+
+	var labels []string
+	for _, cell := range matrix.Cells() {
+		labels = append(labels, cell.Label())
+	}
+	assert.Equal(t, []string{
+		"tag=12.4.0,hv=kvm,arch=amd64",
+		"tag=12.4.0,hv=kvm,arch=arm64",
+		"tag=13.4.0,hv=kvm,arch=amd64",
+		"tag=13.4.0,hv=kvm,arch=arm64",
+		"tag=13.4.0,hv=xen,arch=amd64",
+	}, labels)
+
+	cell := tests.MatrixCell{Tag: "0.0.0-master-1a2b/x", HV: "kvm"}
+	assert.Equal(t, "tag=0.0.0-master-1a2b/x,hv=kvm", cell.Label())
+	assert.Equal(t, "matrix-0.0.0-master-1a2b_x-kvm", cell.Context())
+
+	_, err = tests.ParseMatrix(strings.NewReader("arch: [s390x]"))
+	assert.ErrorContains(t, err, "unsupported arch")
+	_, err = tests.ParseMatrix(strings.NewReader("exclude: [{hv: kvm}]"))
+	assert.ErrorContains(t, err, "neither tag")
+	_, err = tests.ParseMatrix(strings.NewReader("hv: [kvm]\nexclude: [{hv: kvm}]"))
+	assert.ErrorContains(t, err, "all combinations")
+	_, err = tests.ParseMatrix(strings.NewReader("hypervisor: [kvm]"))
+	assert.Error(t, err)
+}
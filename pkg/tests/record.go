@@ -0,0 +1,69 @@
+package tests
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/lf-edge/eden/pkg/defaults"
+	log "github.com/sirupsen/logrus"
+)
+
+var (
+	// recordingDir is temporary directory to record traffic of controller into
+	recordingDir string
+	// recordingTarget is directory to save recording of passed run
+	recordingTarget string
+	// recordingFailed is set if any test of run failed
+	recordingFailed bool
+)
+
+// BeginRecording starts recording of traffic of controller by the following runs of tests,
+// EndRecording saves recording into the directory target if all of them pass
+func BeginRecording(target string) error {
+	if entries, err := os.ReadDir(target); err == nil && len(entries) > 0 {
+		return fmt.Errorf("directory for recording %s is not empty", target)
+	}
+	target, err := filepath.Abs(target)
+	if err != nil {
+		return err
+	}
+	// create next to the target to move it later
+	dir, err := os.MkdirTemp(filepath.Dir(target), "."+filepath.Base(target))
+	if err != nil {
+		return fmt.Errorf("cannot create directory for recording: %w", err)
+	}
+	if err := os.Chmod(dir, 0755); err != nil {
+		return err
+	}
+	// tests and eden commands called by them inherit environment
+	if err := os.Setenv(defaults.DefaultRecordEnv, dir); err != nil {
+		return err
+	}
+	recordingDir, recordingTarget, recordingFailed = dir, target, false
+	return nil
+}
+
+// EndRecording stops recording and saves it if tests passed, otherwise it is discarded.
+// It does nothing if recording is not started.
+func EndRecording() error {
+	if recordingDir == "" {
+		return nil
+	}
+	dir, target, failed := recordingDir, recordingTarget, recordingFailed
+	recordingDir, recordingTarget, recordingFailed = "", "", false
+	if err := os.Unsetenv(defaults.DefaultRecordEnv); err != nil {
+		return err
+	}
+	if failed {
+		log.Warnf("tests failed, recording is not saved into %s", target)
+		return os.RemoveAll(dir)
+	}
+	// target may exist, but it is empty as checked by BeginRecording
+	_ = os.Remove(target)
+	if err := os.Rename(dir, target); err != nil {
+		return fmt.Errorf("cannot save recording: %w", err)
+	}
+	log.Infof("recording of controller traffic saved into %s", target)
+	return nil
+}
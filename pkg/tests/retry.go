@@ -0,0 +1,109 @@
+package tests
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// RetryPolicy defines handling of failed tests
+type RetryPolicy struct {
+	// Retries is number of additional runs of failed test
+	Retries int
+	// Quarantine contains expressions of names of known flaky tests,
+	// failures of them are reported separately and do not fail the run
+	Quarantine []*regexp.Regexp
+}
+
+var (
+	retryPolicy RetryPolicy
+	// flakyTests passed only on retry
+	flakyTests []string
+	// quarantinedFailures failed with all retries, but are in quarantine
+	quarantinedFailures []string
+)
+
+// SetRetryPolicy sets RetryPolicy for the following runs of tests and resets their summary
+func SetRetryPolicy(p RetryPolicy) {
+	retryPolicy = p
+	flakyTests = nil
+	quarantinedFailures = nil
+}
+
+// ParseQuarantine reads regular expressions of names of tests, one per line.
+// Empty lines and lines started with # are ignored.
+func ParseQuarantine(r io.Reader) ([]*regexp.Regexp, error) {
+	var result []*regexp.Regexp
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		re, err := regexp.Compile(text)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		result = append(result, re)
+	}
+	return result, scanner.Err()
+}
+
+// ReadQuarantine reads quarantine list from file, see ParseQuarantine for format
+func ReadQuarantine(path string) ([]*regexp.Regexp, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	result, err := ParseQuarantine(f)
+	if err != nil {
+		return nil, fmt.Errorf("cannot parse quarantine list %s: %w", path, err)
+	}
+	return result, nil
+}
+
+// RunName returns name of run of test binary used for retries and quarantine:
+// value of -test.run argument (e.g. TestEdenScripts/volume_sftp) or name of binary
+func RunName(testApp string, args []string) string {
+	for i, arg := range args {
+		arg = strings.TrimLeft(arg, "-")
+		if arg == "test.run" && i+1 < len(args) {
+			return args[i+1]
+		}
+		if strings.HasPrefix(arg, "test.run=") {
+			return strings.TrimPrefix(arg, "test.run=")
+		}
+	}
+	return testApp
+}
+
+// Quarantined checks if test with provided name is in quarantine
+func (p RetryPolicy) Quarantined(name string) bool {
+	for _, re := range p.Quarantine {
+		if re.MatchString(name) {
+			return true
+		}
+	}
+	return false
+}
+
+// RetrySummary returns tests passed only on retry and failed tests from quarantine
+func RetrySummary() (flaky, quarantined []string) {
+	return flakyTests, quarantinedFailures
+}
+
+// LogRetrySummary prints tests passed only on retry and failed tests from quarantine
+func LogRetrySummary() {
+	if len(flakyTests) > 0 {
+		log.Warnf("tests passed only on retry: %s", strings.Join(flakyTests, ", "))
+	}
+	if len(quarantinedFailures) > 0 {
+		log.Warnf("quarantined tests failed (non-blocking): %s", strings.Join(quarantinedFailures, ", "))
+	}
+}
@@ -0,0 +1,38 @@
+package tests_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/lf-edge/eden/pkg/tests"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestQuarantine(t *testing.T) {
+	t.Parallel()
+
+	quarantine, err := tests.ParseQuarantine(strings.NewReader(`
+# known flaky escripts
+TestEdenScripts/volume_sftp$
+  ^eden\.reboot\.test$
+`))
+	assert.NoError(t, err)
+	policy := tests.RetryPolicy{Retries: 2, Quarantine: quarantine}
+
+	assert.True(t, policy.Quarantined("TestEdenScripts/volume_sftp"))
+	assert.True(t, policy.Quarantined("eden.reboot.test"))
+	assert.False(t, policy.Quarantined("TestEdenScripts/volume_sftp_large"))
+	assert.False(t, policy.Quarantined("eden.app.test"))
+
+	_, err = tests.ParseQuarantine(strings.NewReader("ok\n(broken\n"))
+	assert.ErrorContains(t, err, "line 2")
+}
+
+func TestRunName(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, "TestEdenScripts/disk",
+		tests.RunName("eden.escript.test", []string{"-testdata", "../eclient/testdata/", "-test.run", "TestEdenScripts/disk"}))
+	assert.Equal(t, "TestApp", tests.RunName("eden.app.test", []string{"--test.run=TestApp", "-test.v"}))
+	assert.Equal(t, "eden.reboot.test", tests.RunName("eden.reboot.test", []string{"-test.v"}))
+}
@@ -0,0 +1,275 @@
+package tests
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"text/template"
+)
+
+// scaffold files use [[ ]] delimiters as escripts and scenarios contain {{ }} templates of eden
+var scaffoldFiles = map[string]string{
+	"Makefile": `DEBUG ?= "debug"
+
+# HOSTARCH is the host architecture
+# ARCH is the target architecture
+# we need to keep track of them separately
+HOSTARCH ?= $(shell uname -m)
+HOSTOS ?= $(shell uname -s | tr A-Z a-z)
+
+# canonicalized names for host architecture
+override HOSTARCH := $(subst aarch64,arm64,$(subst x86_64,amd64,$(HOSTARCH)))
+
+# unless otherwise set, I am building for my own architecture, i.e. not cross-compiling
+# and for my OS
+ARCH ?= $(HOSTARCH)
+OS ?= $(HOSTOS)
+
+# canonicalized names for target architecture
+override ARCH := $(subst aarch64,arm64,$(subst x86_64,amd64,$(ARCH)))
+
+WORKDIR ?= $(CURDIR)/../../dist
+TESTDIR := tests/$(shell basename $(CURDIR))
+BINDIR := $(WORKDIR)/bin
+DATADIR := $(WORKDIR)/$(TESTDIR)/
+BIN := eden
+LOCALBIN := $(BINDIR)/$(BIN)-$(OS)-$(ARCH)
+TESTNAME := eden.[[.Name]]
+TESTBIN := $(TESTNAME).test
+TESTSCN := $(TESTNAME).tests.txt
+LOCALTESTBIN := $(TESTBIN)-$(OS)-$(ARCH)
+LINKDIR := ../../tests/[[.Name]]
+
+.DEFAULT_GOAL := help
+
+clean:
+	rm -rf $(LOCALTESTBIN) $(BINDIR)/$(TESTBIN) $(WORKDIR)/$(TESTSCN) $(CURDIR)/$(TESTBIN) $(BINDIR)/$(TESTBIN)
+
+$(BINDIR):
+	mkdir -p $@
+$(DATADIR):
+	mkdir -p $@
+
+test_[[.Name]]:
+	go test -v -count=1 -timeout 3000s .
+
+test:
+	$(LOCALBIN) test $(CURDIR) -v $(DEBUG)
+
+build: setup
+
+testbin: $(TESTBIN)
+$(LOCALTESTBIN): $(BINDIR) *.go
+	CGO_ENABLED=0 GOOS=$(OS) GOARCH=$(ARCH) go test -c -ldflags "-s -w" -o $@ *.go
+
+$(TESTBIN): $(LOCALTESTBIN)
+	ln -sf $(LOCALTESTBIN) $(CURDIR)/$(TESTBIN)
+
+setup: testbin $(BINDIR) $(DATADIR)
+	cp -a $(LOCALTESTBIN) $(CURDIR)/$(TESTBIN) $(BINDIR)
+	cp -a *.yml $(TESTSCN) testdata $(DATADIR)
+
+.PHONY: test build setup clean all testbin
+
+help:
+	@echo "EDEN is the harness for testing EVE and ADAM"
+	@echo
+	@echo "This Makefile automates commons tasks of EDEN testing"
+	@echo
+	@echo "Commonly used maintenance and development targets:"
+	@echo "   build         build test-binary (OS and ARCH options supported, for ex. OS=linux ARCH=arm64)"
+	@echo "   setup         setup of test environment"
+	@echo "   test          run tests"
+	@echo "   clean         cleanup of test harness"
+	@echo
+	@echo "You need install requirements for EVE (look at https://github.com/lf-edge/eve#install-dependencies)."
+	@echo "You need access to docker socket and installed qemu packages."
+`,
+	"README.md": `# [[.Title]] test
+
+The syntax for calling this test is:
+
+` + "```console" + `
+eden test tests/[[.Name]]
+` + "```" + `
+
+It runs escript testdata/[[.Name]].txt, which calls Test[[.Camel]] of eden.[[.Name]].test
+against onboarded EVE of the current config.
+`,
+	"eden-config.yml": `---
+eden:
+    # test binary
+    test-bin: "eden.[[.Name]].test"
+
+    # test scenario
+    test-scenario: "eden.[[.Name]].tests.txt"
+`,
+	"eden.[[.Name]].tests.txt": `eden.escript.test -testdata ../[[.Name]]/testdata/ -test.run TestEdenScripts/[[.Name]]
+`,
+	"[[.Name]]_test.go": `package [[.Name]]_test
+
+import (
+	"os"
+	"testing"
+
+	tk "github.com/lf-edge/eden/pkg/evetestkit"
+	log "github.com/sirupsen/logrus"
+)
+
+const projectName = "[[.Name]]-test"
+
+var eveNode *tk.EveNode
+
+func TestMain(m *testing.M) {
+	log.Println("[[.Title]] Test Suite started")
+
+	node, err := tk.InitilizeTest(projectName, tk.WithControllerVerbosity("debug"))
+	if err != nil {
+		log.Fatalf("Failed to initialize test: %v", err)
+	}
+
+	eveNode = node
+	res := m.Run()
+	log.Println("[[.Title]] Test Suite finished")
+	os.Exit(res)
+}
+
+func Test[[.Camel]](t *testing.T) {
+	log.Println("Test[[.Camel]] started")
+	defer log.Println("Test[[.Camel]] finished")
+
+	// replace with checks of the feature under test
+	out, err := eveNode.EveRunCommand("eve version")
+	if err != nil {
+		t.Fatal(err)
+	}
+	log.Printf("EVE version: %s", out)
+}
+`,
+	"testdata/[[.Name]].txt": `# [[.Title]] test
+{{$test := "test eden.[[.Name]].test -test.v"}}
+
+{{$test}} -test.run Test[[.Camel]]
+stdout 'PASS'
+
+# Test's config. file
+-- eden-config.yml --
+test:
+    controller: adam://{{EdenConfig "adam.ip"}}:{{EdenConfig "adam.port"}}
+    eve:
+      {{EdenConfig "eve.name"}}:
+        onboard-cert: {{EdenConfigPath "eve.cert"}}
+        serial: "{{EdenConfig "eve.serial"}}"
+        model: {{EdenConfig "eve.devmodel"}}
+`,
+}
+
+var scaffoldName = regexp.MustCompile(`^[a-z][a-z0-9_]*$`)
+
+// scaffoldVars are values for templates of scaffold files
+type scaffoldVars struct {
+	Name  string
+	Title string
+	Camel string
+}
+
+func newScaffoldVars(name string) scaffoldVars {
+	words := strings.Split(name, "_")
+	var camel strings.Builder
+	for _, word := range words {
+		if word != "" {
+			camel.WriteString(strings.ToUpper(word[:1]) + word[1:])
+		}
+	}
+	title := strings.Join(words, " ")
+	return scaffoldVars{
+		Name:  name,
+		Title: strings.ToUpper(title[:1]) + title[1:],
+		Camel: camel.String(),
+	}
+}
+
+// Scaffold creates directory of test with name inside testsDir with Makefile, config,
+// scenario, escript and Go test to run with 'eden test'. Returns paths of created files.
+func Scaffold(testsDir, name string) ([]string, error) {
+	if !scaffoldName.MatchString(name) {
+		return nil, fmt.Errorf("name of test must be a Go package name in lower case: %s", name)
+	}
+	dir := filepath.Join(testsDir, name)
+	if _, err := os.Stat(dir); err == nil {
+		return nil, fmt.Errorf("test directory %s already exists", dir)
+	} else if !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+	vars := newScaffoldVars(name)
+	paths := make([]string, 0, len(scaffoldFiles))
+	for path := range scaffoldFiles {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	var created []string
+	for _, pathTemplate := range paths {
+		content := scaffoldFiles[pathTemplate]
+		path, err := executeScaffold(pathTemplate, vars)
+		if err != nil {
+			return created, err
+		}
+		data, err := executeScaffold(content, vars)
+		if err != nil {
+			return created, fmt.Errorf("cannot generate %s: %w", path, err)
+		}
+		path = filepath.Join(dir, path)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			return created, err
+		}
+		if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+			return created, err
+		}
+		created = append(created, path)
+	}
+	return created, nil
+}
+
+func executeScaffold(text string, vars scaffoldVars) (string, error) {
+	tmpl, err := template.New("scaffold").Delims("[[", "]]").Parse(text)
+	if err != nil {
+		return "", err
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, vars); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+// suiteStop starts block of workflow suites which stops eden after tests
+const suiteStop = `{{if (eq $stop "y")}}`
+
+// RegisterInSuite adds escript of test with name into scenario of suite before the final stop of eden
+func RegisterInSuite(suiteFile, name string) error {
+	data, err := os.ReadFile(suiteFile)
+	if err != nil {
+		return err
+	}
+	vars := newScaffoldVars(name)
+	entry := fmt.Sprintf("/bin/echo %s test\neden.escript.test -testdata ../%s/testdata/ -test.run TestEdenScripts/%s\n",
+		vars.Title, name, name)
+	content := string(data)
+	if strings.Contains(content, "TestEdenScripts/"+name+"\n") {
+		return fmt.Errorf("test %s is already in %s", name, suiteFile)
+	}
+	if pos := strings.LastIndex(content, suiteStop); pos != -1 {
+		content = content[:pos] + entry + "\n" + content[pos:]
+	} else {
+		if content != "" && !strings.HasSuffix(content, "\n") {
+			content += "\n"
+		}
+		content += entry
+	}
+	return os.WriteFile(suiteFile, []byte(content), 0644)
+}
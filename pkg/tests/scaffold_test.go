@@ -0,0 +1,37 @@
+package tests_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/lf-edge/eden/pkg/tests"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestScaffold(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	created, err := tests.Scaffold(dir, "my_feature")
+	assert.NoError(t, err)
+	assert.Contains(t, created, filepath.Join(dir, "my_feature", "testdata", "my_feature.txt"))
+	goTest, err := os.ReadFile(filepath.Join(dir, "my_feature", "my_feature_test.go"))
+	assert.NoError(t, err)
+	assert.Contains(t, string(goTest), "func TestMyFeature(t *testing.T)")
+
+	_, err = tests.Scaffold(dir, "my_feature")
+	assert.Error(t, err, "existing test must not be overwritten")
+	_, err = tests.Scaffold(dir, "My-Feature")
+	assert.Error(t, err)
+
+	suite := filepath.Join(dir, "smoke.tests.txt")
+	assert.NoError(t, os.WriteFile(suite, []byte("eden.escript.test -test.run TestEdenScripts/ssh\n{{if (eq $stop \"y\")}}\nstop\n{{end}}\n"), 0644))
+	assert.NoError(t, tests.RegisterInSuite(suite, "my_feature"))
+	content, err := os.ReadFile(suite)
+	assert.NoError(t, err)
+	assert.Equal(t, "eden.escript.test -test.run TestEdenScripts/ssh\n"+
+		"/bin/echo My feature test\neden.escript.test -testdata ../my_feature/testdata/ -test.run TestEdenScripts/my_feature\n\n"+
+		"{{if (eq $stop \"y\")}}\nstop\n{{end}}\n", string(content))
+	assert.Error(t, tests.RegisterInSuite(suite, "my_feature"))
+}
@@ -0,0 +1,166 @@
+package tests
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/lf-edge/eden/pkg/defaults"
+	log "github.com/sirupsen/logrus"
+)
+
+// Suite is state of suite of tests shared between processes of test binaries.
+// It is saved into directory from defaults.DefaultTestSuiteEnv (EDEN_TEST_SUITE) env. var.
+type Suite struct {
+	// Ready is set when setup of suite is done
+	Ready bool `json:"ready"`
+	// Vars are exported by setup of suite into its tests
+	Vars map[string]string `json:"vars"`
+	// Teardown is command to run when all tests of suite are done
+	Teardown []string `json:"teardown"`
+	// Env is additional environment of Teardown command
+	Env []string `json:"env"`
+	// Started is time of start of setup of suite
+	Started time.Time `json:"started"`
+	// Budget limits resources used by tests of suite
+	Budget Budget `json:"budget"`
+	// RestartCounter is restart counter of EVE at the end of setup of suite,
+	// it is used to count reboots if budget limits them
+	RestartCounter uint32 `json:"restart_counter"`
+}
+
+// Budget limits resources used by all tests of suite
+type Budget struct {
+	// WallTime is maximum time from start of setup of suite, zero if not limited
+	WallTime time.Duration `json:"wall_time,omitempty"`
+	// Reboots is maximum number of reboots of EVE, nil if not limited
+	Reboots *int `json:"reboots,omitempty"`
+}
+
+// Deadline returns time when wall time budget of suite is over, it is zero if wall time is not limited
+func (s *Suite) Deadline() time.Time {
+	if s.Budget.WallTime == 0 {
+		return time.Time{}
+	}
+	return s.Started.Add(s.Budget.WallTime)
+}
+
+// CheckReboots checks if reboots of EVE are within budget of suite
+func (s *Suite) CheckReboots(restartCounter uint32) error {
+	if s.Budget.Reboots == nil {
+		return nil
+	}
+	if reboots := int(restartCounter) - int(s.RestartCounter); reboots > *s.Budget.Reboots {
+		return fmt.Errorf("reboots budget of suite is over: EVE rebooted %d times, allowed %d",
+			reboots, *s.Budget.Reboots)
+	}
+	return nil
+}
+
+// BeginSuites creates directory to share state of suites between tests and exports it
+// into environment of the following tests. It returns false if directory is already defined
+// by the caller, in this case suites will be ended by the caller too.
+func BeginSuites() (bool, error) {
+	if os.Getenv(defaults.DefaultTestSuiteEnv) != "" {
+		return false, nil
+	}
+	dir, err := os.MkdirTemp("", "eden-suite")
+	if err != nil {
+		return false, fmt.Errorf("cannot create directory for state of suites: %w", err)
+	}
+	if err = os.Setenv(defaults.DefaultTestSuiteEnv, dir); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// EndSuites runs teardown of every suite started after BeginSuites and removes their state
+func EndSuites() error {
+	dir := os.Getenv(defaults.DefaultTestSuiteEnv)
+	if dir == "" {
+		return nil
+	}
+	defer func() {
+		_ = os.Unsetenv(defaults.DefaultTestSuiteEnv)
+		_ = os.RemoveAll(dir)
+	}()
+	files, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return err
+	}
+	var errs []error
+	for _, file := range files {
+		suite, err := readSuite(file)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if len(suite.Teardown) == 0 {
+			continue
+		}
+		log.Infof("Teardown of suite: %s", strings.Join(suite.Teardown, " "))
+		cmd := exec.Command(suite.Teardown[0], suite.Teardown[1:]...)
+		cmd.Stdout = os.Stdout
+		cmd.Stderr = os.Stderr
+		cmd.Env = append(os.Environ(), suite.Env...)
+		if err := cmd.Run(); err != nil {
+			errs = append(errs, fmt.Errorf("teardown of suite %s failed: %w", strings.Join(suite.Teardown, " "), err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// suiteFile returns file with state of suite with provided name
+// or empty string if there is no directory for state of suites
+func suiteFile(name string) string {
+	dir := os.Getenv(defaults.DefaultTestSuiteEnv)
+	if dir == "" {
+		return ""
+	}
+	return filepath.Join(dir, fmt.Sprintf("%x.json", sha256.Sum256([]byte(name))))
+}
+
+func readSuite(file string) (*Suite, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	var suite Suite
+	if err = json.Unmarshal(data, &suite); err != nil {
+		return nil, fmt.Errorf("cannot parse state of suite %s: %w", file, err)
+	}
+	return &suite, nil
+}
+
+// LoadSuite returns state of suite with provided name, it returns nil if suite is not started
+// or there is no directory for state of suites
+func LoadSuite(name string) (*Suite, error) {
+	file := suiteFile(name)
+	if file == "" {
+		return nil, nil
+	}
+	suite, err := readSuite(file)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	return suite, err
+}
+
+// SaveSuite saves state of suite with provided name
+func SaveSuite(name string, suite *Suite) error {
+	file := suiteFile(name)
+	if file == "" {
+		return fmt.Errorf("%s env. var. is not set", defaults.DefaultTestSuiteEnv)
+	}
+	data, err := json.Marshal(suite)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(file, data, 0600)
+}
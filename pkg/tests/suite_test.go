@@ -0,0 +1,64 @@
+package tests_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/lf-edge/eden/pkg/defaults"
+	"github.com/lf-edge/eden/pkg/tests"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSuites(t *testing.T) {
+	t.Setenv(defaults.DefaultTestSuiteEnv, "")
+
+	started, err := tests.BeginSuites()
+	assert.NoError(t, err)
+	assert.True(t, started)
+	dir := os.Getenv(defaults.DefaultTestSuiteEnv)
+	assert.DirExists(t, dir)
+
+	// nested run uses the same directory
+	started, err = tests.BeginSuites()
+	assert.NoError(t, err)
+	assert.False(t, started)
+
+	suite, err := tests.LoadSuite("eclient")
+	assert.NoError(t, err)
+	assert.Nil(t, suite)
+
+	marker := filepath.Join(t.TempDir(), "teardown")
+	assert.NoError(t, tests.SaveSuite("eclient", &tests.Suite{
+		Ready:    true,
+		Vars:     map[string]string{"APP": "eclient-shared"},
+		Teardown: []string{"sh", "-c", "echo $APP > " + marker},
+		Env:      []string{"APP=eclient-shared"},
+	}))
+	suite, err = tests.LoadSuite("eclient")
+	assert.NoError(t, err)
+	assert.Equal(t, "eclient-shared", suite.Vars["APP"])
+
+	assert.NoError(t, tests.EndSuites())
+	data, err := os.ReadFile(marker)
+	assert.NoError(t, err)
+	assert.Equal(t, "eclient-shared\n", string(data))
+	assert.NoDirExists(t, dir)
+	assert.Empty(t, os.Getenv(defaults.DefaultTestSuiteEnv))
+}
+
+func TestSuiteBudget(t *testing.T) {
+	t.Parallel()
+
+	started := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	suite := &tests.Suite{Started: started, RestartCounter: 3}
+	assert.True(t, suite.Deadline().IsZero())
+	assert.NoError(t, suite.CheckReboots(10))
+
+	reboots := 1
+	suite.Budget = tests.Budget{WallTime: time.Hour, Reboots: &reboots}
+	assert.Equal(t, started.Add(time.Hour), suite.Deadline())
+	assert.NoError(t, suite.CheckReboots(4))
+	assert.ErrorContains(t, suite.CheckReboots(5), "EVE rebooted 2 times, allowed 1")
+}
@@ -0,0 +1,96 @@
+package utils
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// ArtifactCache stores artifacts of downloads and builds addressed by hash of their
+// key, so they are shared between contexts and not produced again
+type ArtifactCache struct {
+	dir string
+}
+
+// cacheLocks serializes production of artifacts with the same key in the process
+var cacheLocks sync.Map
+
+// NewArtifactCache returns cache stored in dir
+func NewArtifactCache(dir string) *ArtifactCache {
+	return &ArtifactCache{dir: dir}
+}
+
+// CacheKey returns key of artifact from its parts
+func CacheKey(parts ...string) string {
+	hash := sha256.New()
+	for _, part := range parts {
+		// separate parts to not mix "ab","c" with "a","bc"
+		hash.Write([]byte(part))
+		hash.Write([]byte{0})
+	}
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// Fetch copies files of artifact with key into dstDir. If there is no such artifact
+// in cache, produce is called to create files of artifact in directory it gets.
+// Artifacts interrupted during production are discarded and produced again.
+// Nil cache produces artifacts directly in dstDir every time.
+func (c *ArtifactCache) Fetch(key, dstDir string, produce func(dir string) error) error {
+	if c == nil {
+		if err := os.MkdirAll(dstDir, 0755); err != nil {
+			return err
+		}
+		return produce(dstDir)
+	}
+	lock, _ := cacheLocks.LoadOrStore(key, &sync.Mutex{})
+	lock.(*sync.Mutex).Lock()
+	defer lock.(*sync.Mutex).Unlock()
+	entry := filepath.Join(c.dir, key)
+	if _, err := os.Stat(entry); err == nil {
+		log.Debugf("artifact %s found in cache", key)
+		return copyDirFiles(entry, dstDir)
+	}
+	if err := os.MkdirAll(c.dir, 0755); err != nil {
+		return err
+	}
+	// produce into temporary directory and rename it to not leave incomplete entries
+	partial, err := os.MkdirTemp(c.dir, key+".partial-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(partial)
+	if err := produce(partial); err != nil {
+		return err
+	}
+	if err := os.Rename(partial, entry); err != nil {
+		// another process may store the same artifact in the meantime
+		if _, statErr := os.Stat(entry); statErr != nil {
+			return fmt.Errorf("cannot store artifact in cache: %w", err)
+		}
+	}
+	return copyDirFiles(entry, dstDir)
+}
+
+// copyDirFiles copies files and directories from src into dst
+func copyDirFiles(src, dst string) error {
+	return filepath.Walk(src, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dst, rel)
+		if info.IsDir() {
+			return os.MkdirAll(target, 0755)
+		}
+		// artifacts are modified in place (e.g. disks of EVE), so do not link them
+		return CopyFile(path, target)
+	})
+}
@@ -0,0 +1,31 @@
+package utils_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/lf-edge/eden/pkg/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestArtifactCacheFetch(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	cache := utils.NewArtifactCache(filepath.Join(dir, "cache"))
+	key := utils.CacheKey("test", t.Name())
+	produced := 0
+	produce := func(out string) error {
+		produced++
+		return os.WriteFile(filepath.Join(out, "disk.img"), []byte("disk"), 0644)
+	}
+	for _, dst := range []string{"first", "second"} {
+		dstDir := filepath.Join(dir, dst)
+		assert.NoError(t, cache.Fetch(key, dstDir, produce))
+		content, err := os.ReadFile(filepath.Join(dstDir, "disk.img"))
+		assert.NoError(t, err)
+		assert.Equal(t, "disk", string(content))
+	}
+	assert.Equal(t, 1, produced)
+}
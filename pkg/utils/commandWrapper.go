@@ -224,3 +224,23 @@ func RunCommandWithLogAndWait(name string, logLevel log.Level, args ...string) (
 	}
 	return cmd.Run()
 }
+
+// HostBootID returns identifier of the current boot of the host
+// it returns empty string if we cannot detect it
+func HostBootID() string {
+	bootID, err := os.ReadFile("/proc/sys/kernel/random/boot_id")
+	if err == nil {
+		return strings.TrimSpace(string(bootID))
+	}
+	// fallback for darwin
+	stdout, _, err := RunCommandAndWait("sysctl", "-n", "kern.boottime")
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(stdout)
+}
+
+// ShellQuote quotes the argument to be passed as a single word into a remote shell (e.g. with ssh)
+func ShellQuote(arg string) string {
+	return "'" + strings.ReplaceAll(arg, "'", `'\''`) + "'"
+}
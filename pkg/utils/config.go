@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"encoding/json"
 	"fmt"
+	"net"
 	"os"
 	"os/user"
 	"path/filepath"
@@ -14,6 +15,7 @@ import (
 	"text/template"
 
 	"github.com/lf-edge/eden/pkg/defaults"
+	"github.com/lf-edge/eden/pkg/secrets"
 	uuid "github.com/satori/go.uuid"
 	log "github.com/sirupsen/logrus"
 	"github.com/spf13/viper"
@@ -51,6 +53,8 @@ type ConfigVars struct {
 	ZArch             string
 	DevModel          string
 	DevModelFIle      string
+	EvePCIPassthrough []string
+	EveCellularAPN    string
 	EdenBinDir        string
 	EdenProg          string
 	TestProg          string
@@ -58,10 +62,24 @@ type ConfigVars struct {
 	EServerImageDist  string
 	EServerPort       string
 	EServerIP         string
+	EServerTLS        bool
+	EServerSFTPUser   string
+	EServerSFTPPass   string
 	RegistryIP        string
 	RegistryPort      string
 	LogLevel          string
 	AdamLogLevel      string
+	ControllerType    string
+	ZedcloudURL       string
+	ZedcloudToken     string
+	ZedcloudProject   string
+	ZedcloudDevice    string
+	DryRun            bool
+	ForcePush         bool
+	EveAPIVersion     string
+	SdnProxy          string
+	SdnConfigDir      string
+	SdnNetworkType    string
 }
 
 // InitVars loads vars from viper
@@ -108,10 +126,15 @@ func InitVars() (*ConfigVars, error) {
 			EveHV:             viper.GetString("eve.hv"),
 			DevModel:          viper.GetString("eve.devmodel"),
 			DevModelFIle:      viper.GetString("eve.devmodelfile"),
+			EvePCIPassthrough: viper.GetStringSlice("eve.pci-passthrough"),
 			EveName:           viper.GetString("eve.name"),
 			EveUUID:           viper.GetString("eve.uuid"),
 			EveRemote:         viper.GetBool("eve.remote"),
 			EveRemoteAddr:     viper.GetString("eve.remote-addr"),
+			EveAPIVersion:     viper.GetString("eve.api-version"),
+			SdnProxy:          viper.GetString("sdn.proxy"),
+			SdnConfigDir:      ResolveAbsPath(viper.GetString("sdn.config-dir")),
+			SdnNetworkType:    viper.GetString("sdn.network-type"),
 			EveQemuPorts:      viper.GetStringMapString("eve.hostfwd"),
 			AdamRemote:        viper.GetBool("adam.remote.enabled"),
 			AdamRemoteRedis:   viper.GetBool("adam.remote.redis"),
@@ -125,12 +148,40 @@ func InitVars() (*ConfigVars, error) {
 			EServerImageDist:  ResolveAbsPath(viper.GetString("eden.images.dist")),
 			EServerPort:       viper.GetString("eden.eserver.port"),
 			EServerIP:         viper.GetString("eden.eserver.ip"),
+			EServerTLS:        viper.GetBool("eden.eserver.tls"),
+			EServerSFTPUser:   viper.GetString("eden.eserver.sftp-user"),
+			EServerSFTPPass:   viper.GetString("eden.eserver.sftp-password"),
 			RegistryIP:        viper.GetString("registry.ip"),
 			RegistryPort:      viper.GetString("registry.port"),
 			LogLevel:          viper.GetString("eve.log-level"),
 			AdamLogLevel:      viper.GetString("eve.adam-log-level"),
+			ControllerType:    viper.GetString("controller.type"),
+			ZedcloudURL:       viper.GetString("controller.zedcloud.url"),
+			ZedcloudToken:     viper.GetString("controller.zedcloud.token"),
+			ZedcloudProject:   viper.GetString("controller.zedcloud.project"),
+			ZedcloudDevice:    viper.GetString("controller.zedcloud.device"),
 		}
+		// APN is set only with cellular modem emulated
+		if viper.GetBool("eve.cellular.enabled") {
+			vars.EveCellularAPN = viper.GetString("eve.cellular.apn")
+		}
+		nodes := viper.GetInt("eve.nodes")
 		viperAccessMutex.RUnlock()
+		node, err := NodeFromEnv()
+		if err != nil {
+			return nil, err
+		}
+		if node != 0 {
+			if err := CheckNode(node, nodes); err != nil {
+				return nil, err
+			}
+			vars.selectNode(node)
+		}
+		for _, secret := range []*string{&vars.ZedcloudToken, &vars.EServerSFTPPass} {
+			if *secret, err = secrets.Default().Resolve(*secret); err != nil {
+				return nil, fmt.Errorf("cannot resolve secret: %w", err)
+			}
+		}
 		redisPasswordFile := filepath.Join(globalCertsDir, defaults.DefaultRedisPasswordFile)
 		pwd, err := os.ReadFile(redisPasswordFile)
 		if err == nil {
@@ -144,6 +195,17 @@ func InitVars() (*ConfigVars, error) {
 	return nil, nil
 }
 
+// selectNode updates vars to ones of node of multi-node EVE
+func (vars *ConfigVars) selectNode(node int) {
+	vars.EveCert = NodePath(vars.EveCert, node)
+	vars.EveDeviceCert = NodePath(vars.EveDeviceCert, node)
+	vars.EveSerial = NodeSerial(vars.EveSerial, node)
+	vars.EveQemuConfig = NodePath(vars.EveQemuConfig, node)
+	vars.EveName = NodeEveName(vars.EveName, node)
+	vars.EveUUID = NodeUUID(vars.EveUUID, node)
+	vars.EveQemuPorts = NodeHostFwd(vars.EveQemuPorts, node)
+}
+
 // DefaultEdenDir returns path to default directory
 func DefaultEdenDir() (string, error) {
 	usr, err := user.Current()
@@ -280,7 +342,10 @@ func generateConfigFileFromTemplate(filePath string, templateString string, cont
 
 	ip, err := GetIPForDockerAccess()
 	if err != nil {
-		return err
+		// host with IPv6 only
+		if ip, err = GetIPv6ForDockerAccess(); err != nil {
+			return err
+		}
 	}
 	id, err := uuid.NewV4()
 	if err != nil {
@@ -326,7 +391,7 @@ func generateConfigFileFromTemplate(filePath string, templateString string, cont
 		case "adam.ip":
 			return ip
 		case "adam.redis.eden":
-			return fmt.Sprintf("%s:%d", ip, defaults.DefaultRedisPort)
+			return net.JoinHostPort(ip, strconv.Itoa(defaults.DefaultRedisPort))
 		case "adam.redis.adam":
 			return fmt.Sprintf("%s:%d", defaults.DefaultRedisContainerName, defaults.DefaultRedisPort)
 		case "adam.force":
@@ -373,7 +438,7 @@ func generateConfigFileFromTemplate(filePath string, templateString string, cont
 		case "eve.log":
 			return fmt.Sprintf("%s-eve.log", strings.ToLower(context.Current))
 		case "eve.firmware":
-			if runtime.GOARCH == "amd64" {
+			if runtime.GOARCH == "amd64" || runtime.GOARCH == "riscv64" {
 				return fmt.Sprintf("[%s %s]",
 					filepath.Join(imageDist, "eve", "firmware", "OVMF_CODE.fd"),
 					filepath.Join(imageDist, "eve", "firmware", "OVMF_VARS.fd"))
@@ -427,10 +492,20 @@ func generateConfigFileFromTemplate(filePath string, templateString string, cont
 			return defaults.DefaultTPMEnabled
 		case "eve.disks":
 			return defaults.DefaultAdditionalDisks
+		case "eve.nodes":
+			return defaults.DefaultEveNodes
+		case "eve.pci-passthrough":
+			return "[]"
+		case "eve.cellular.enabled":
+			return false
+		case "eve.cellular.apn":
+			return defaults.DefaultCellularAPN
 		case "eve.bootstrap-file":
 			return ""
 		case "eve.usbnetconf-file":
 			return ""
+		case "eve.api-version":
+			return ""
 
 		case "eden.root":
 			return filepath.Join(currentPath, defaults.DefaultDist)
@@ -450,6 +525,18 @@ func generateConfigFileFromTemplate(filePath string, templateString string, cont
 			return defaults.DefaultEServerTag
 		case "eden.eserver.force":
 			return true
+		case "eden.eserver.tls":
+			return false
+		case "eden.eserver.tls-client-auth":
+			return defaults.DefaultEServerTLSClientAuth
+		case "eden.eserver.sftp-user":
+			return defaults.DefaultSFTPUser
+		case "eden.eserver.sftp-password":
+			return defaults.DefaultSFTPPassword
+		case "eden.loc.port":
+			return defaults.DefaultLOCPort
+		case "eden.loc.dist":
+			return filepath.Join(currentPath, defaults.DefaultDist, defaults.DefaultLOCDist)
 		case "eden.eclient.tag":
 			return defaults.DefaultEClientTag
 		case "eden.eclient.image":
@@ -464,12 +551,29 @@ func generateConfigFileFromTemplate(filePath string, templateString string, cont
 			return "eden"
 		case "eden.test-bin":
 			return defaults.DefaultTestProg
+		case "eden.components":
+			return "adam,redis,registry,eserver,sdn,eve"
 		case "eden.test-scenario":
 			return defaults.DefaultTestScenario
 
 		case "gcp.key":
 			return ""
 
+		case "cloud.provider":
+			return ""
+		case "cloud.project":
+			return defaults.DefaultGcpProjectName
+		case "cloud.zone":
+			return defaults.DefaultGcpZone
+		case "cloud.region":
+			return defaults.DefaultAwsRegion
+		case "cloud.machine-type":
+			return ""
+		case "cloud.instance":
+			return defaults.DefaultCloudInstanceName
+		case "cloud.bucket":
+			return ""
+
 		case "packet.key":
 			return ""
 
@@ -515,6 +619,21 @@ func generateConfigFileFromTemplate(filePath string, templateString string, cont
 			return defaults.DefaultSdnMgmtPort
 		case "sdn.network-model":
 			return ""
+		case "sdn.network-type":
+			return defaults.DefaultSdnNetworkType
+		case "sdn.proxy":
+			return ""
+
+		case "controller.type":
+			return defaults.DefaultControllerType
+		case "controller.zedcloud.url":
+			return defaults.DefaultZedcloudURL
+		case "controller.zedcloud.token":
+			return ""
+		case "controller.zedcloud.project":
+			return ""
+		case "controller.zedcloud.device":
+			return ""
 
 		default:
 			log.Fatalf("Not found argument %s in config", inp)
@@ -92,24 +92,43 @@ func RequestHTTPRepeatWithTimeout(url string, returnEmpty bool, timeoutSeconds t
 }
 
 // DownloadFile download a url to a local file.
+// Download interrupted before is resumed from the partially downloaded file if server supports ranges.
 func DownloadFile(filepath string, url string) error {
-	out, err := os.Create(filepath + ".tmp")
+	req, err := http.NewRequest(http.MethodGet, url, nil)
 	if err != nil {
 		return err
 	}
-	resp, err := http.Get(url)
+	tmpFile := filepath + ".tmp"
+	var offset int64
+	if info, err := os.Stat(tmpFile); err == nil && info.Size() > 0 {
+		offset = info.Size()
+		req.Header.Set("Range", fmt.Sprintf("bytes=%d-", offset))
+	}
+	resp, err := http.DefaultClient.Do(req)
 	if err != nil {
 		return err
 	}
 	defer resp.Body.Close()
+	flags := os.O_CREATE | os.O_WRONLY | os.O_TRUNC
+	switch {
+	case resp.StatusCode == http.StatusPartialContent && offset > 0:
+		log.Infof("Resuming download of %s from %d bytes", url, offset)
+		flags = os.O_WRONLY | os.O_APPEND
+	case resp.StatusCode != http.StatusOK:
+		return fmt.Errorf("cannot download %s: %s", url, resp.Status)
+	}
+	out, err := os.OpenFile(tmpFile, flags, 0644)
+	if err != nil {
+		return err
+	}
 	counter := &writeCounter{step: 10 * 1024 * 1024, message: "Downloading..."}
 	if _, err = io.Copy(out, io.TeeReader(resp.Body, counter)); err != nil {
 		out.Close()
 		return err
 	}
 	fmt.Printf("\n")
-	if err = os.Rename(filepath+".tmp", filepath); err != nil {
+	if err = out.Close(); err != nil {
 		return err
 	}
-	return out.Close()
+	return os.Rename(tmpFile, filepath)
 }
@@ -16,6 +16,7 @@ import (
 	"github.com/docker/docker/api/types/mount"
 	"github.com/docker/docker/client"
 	"github.com/docker/docker/pkg/archive"
+	"github.com/docker/docker/pkg/stdcopy"
 	"github.com/docker/go-connections/nat"
 	"github.com/google/go-containerregistry/pkg/name"
 	"github.com/lf-edge/eden/pkg/defaults"
@@ -59,6 +60,23 @@ func RemoveGeneratedVolumeOfContainer(containerName string) error {
 	return cli.VolumeRemove(ctx, volumeName, true)
 }
 
+// resumableContainers keep state of eden environment, so they are restored
+// after reboot of the host to resume it with 'eden start --resume'
+var resumableContainers = []string{
+	defaults.DefaultAdamContainerName,
+	defaults.DefaultRedisContainerName,
+	defaults.DefaultEServerContainerName,
+}
+
+func containerRestartPolicy(containerName string) container.RestartPolicy {
+	for _, name := range resumableContainers {
+		if name == containerName {
+			return container.RestartPolicy{Name: "unless-stopped"}
+		}
+	}
+	return container.RestartPolicy{}
+}
+
 // CreateAndRunContainer run container with defined name from image with port and volume mapping and defined command
 func CreateAndRunContainer(containerName string, imageName string, portMap map[string]string, volumeMap map[string]string, command []string, envs []string) error {
 	log.Debugf("Try to start container from image %s with command %s", imageName, command)
@@ -84,6 +102,13 @@ func CreateAndRunContainer(containerName string, imageName string, portMap map[s
 				HostPort: binding,
 			},
 		}
+		// to make services available for EVE in IPv6-only networks
+		if HostSupportsIPv6() {
+			portBinding[port] = append(portBinding[port], nat.PortBinding{
+				HostIP:   "::",
+				HostPort: binding,
+			})
+		}
 	}
 	var mounts []mount.Mount
 	userCurrent, err := user.Current()
@@ -111,12 +136,13 @@ func CreateAndRunContainer(containerName string, imageName string, portMap map[s
 		return fmt.Errorf("CreateDockerNetwork: %w", err)
 	}
 	hostConfig := &container.HostConfig{
-		PortBindings: portBinding,
-		Mounts:       mounts,
-		DNS:          []string{},
-		DNSOptions:   []string{},
-		DNSSearch:    []string{},
-		NetworkMode:  container.NetworkMode(defaults.DefaultDockerNetworkName),
+		PortBindings:  portBinding,
+		Mounts:        mounts,
+		DNS:           []string{},
+		DNSOptions:    []string{},
+		DNSSearch:     []string{},
+		NetworkMode:   container.NetworkMode(defaults.DefaultDockerNetworkName),
+		RestartPolicy: containerRestartPolicy(containerName),
 	}
 	resp, err := cli.ContainerCreate(ctx, &container.Config{
 		Hostname:     containerName,
@@ -198,6 +224,19 @@ func HasImage(image string) (bool, error) {
 	return false, nil
 }
 
+// ImageID returns ID of local image, it changes with content of image unlike tag
+func ImageID(image string) (string, error) {
+	cli, err := client.NewClientWithOpts(client.FromEnv, client.WithAPIVersionNegotiation())
+	if err != nil {
+		return "", fmt.Errorf("client.NewClientWithOpts: %w", err)
+	}
+	inspect, _, err := cli.ImageInspectWithRaw(context.Background(), image)
+	if err != nil {
+		return "", fmt.Errorf("ImageInspectWithRaw: %w", err)
+	}
+	return inspect.ID, nil
+}
+
 // CreateImage create new image from directory with tag
 // If Dockerfile is inside the directory will use it
 // otherwise will create image from scratch
@@ -440,6 +479,41 @@ func StartContainer(containerName string) error {
 	return nil
 }
 
+// ContainerLogs writes stdout and stderr of container with containerName into w
+func ContainerLogs(containerName string, w io.Writer) error {
+	ctx := context.Background()
+	cli, err := client.NewClientWithOpts(client.FromEnv, client.WithAPIVersionNegotiation())
+	if err != nil {
+		return err
+	}
+	containers, err := cli.ContainerList(ctx, types.ContainerListOptions{All: true})
+	if err != nil {
+		return err
+	}
+	for _, cont := range containers {
+		for _, name := range cont.Names {
+			if !strings.Contains(name, containerName) {
+				continue
+			}
+			reader, err := cli.ContainerLogs(ctx, cont.ID, types.ContainerLogsOptions{
+				ShowStdout: true,
+				ShowStderr: true,
+				Timestamps: true,
+			})
+			if err != nil {
+				return err
+			}
+			defer reader.Close()
+			// containers without TTY multiplex stdout and stderr into one stream
+			if _, err = stdcopy.StdCopy(w, w, reader); err != nil {
+				return err
+			}
+			return nil
+		}
+	}
+	return fmt.Errorf("container %s not found", containerName)
+}
+
 // writeToLog from the build response to the log
 func writeToLog(reader io.ReadCloser) error {
 	defer reader.Close()
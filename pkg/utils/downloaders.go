@@ -100,13 +100,18 @@ func DownloadEveLive(eve EVEDescription, outputFile string) (err error) {
 		}
 	}
 	size := 0
-	if eve.Format == "qcow2" {
+	format := eve.Format
+	if format == "vmdk" || format == "vhdx" {
+		// EVE does not build images for VMware and Hyper-V, so convert qcow2 one
+		format = "qcow2"
+	}
+	if eve.Format == "qcow2" || format != eve.Format {
 		size = eve.ImageSizeMB
 	}
 	if eve.Format == "gcp" || eve.Format == "vdi" || eve.Format == "parallels" {
 		size = eve.ImageSizeMB
 	}
-	fileName, err := genEVELiveImage(image, filepath.Dir(outputFile), eve.Format, eve.Platform, eve.ConfigPath, size)
+	fileName, err := genEVELiveImage(image, filepath.Dir(outputFile), format, eve.Platform, eve.ConfigPath, size)
 	if err != nil {
 		return fmt.Errorf("genEVEImage: %s", err)
 	}
@@ -142,6 +147,12 @@ func DownloadEveLive(eve EVEDescription, outputFile string) (err error) {
 			return fmt.Errorf("cannot write description %s", err)
 		}
 	}
+	if format != eve.Format {
+		if err = ConvertDisk(fileName, format, outputFile, eve.Format); err != nil {
+			return fmt.Errorf("cannot convert image %s", err)
+		}
+		return nil
+	}
 	if err = CopyFile(fileName, outputFile); err != nil {
 		return fmt.Errorf("cannot copy image %s", err)
 	}
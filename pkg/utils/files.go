@@ -214,3 +214,8 @@ func CreateDisk(diskFile, format string, size uint64) error {
 	}
 	return RunCommandForeground("qemu-img", "create", "-f", format, diskFile, fmt.Sprintf("%d", size))
 }
+
+//ConvertDisk converts srcFile disk with srcFormat into dstFile with dstFormat
+func ConvertDisk(srcFile, srcFormat, dstFile, dstFormat string) error {
+	return RunCommandForeground("qemu-img", "convert", "-f", srcFormat, "-O", dstFormat, srcFile, dstFile)
+}
@@ -119,6 +119,42 @@ out:
 	return ip, nil
 }
 
+// GetIPv6ForDockerAccess is service function to obtain IPv6 for adam access
+// It returns the first global unicast IPv6 address of the host
+// which does not belong to docker networks
+func GetIPv6ForDockerAccess() (ip string, err error) {
+	networks, err := GetDockerNetworks()
+	if err != nil {
+		log.Errorf("GetDockerNetworks: %s", err)
+	}
+	addrs, err := net.InterfaceAddrs()
+	if err != nil {
+		return "", err
+	}
+out:
+	for _, a := range addrs {
+		if ipnet, ok := a.(*net.IPNet); ok && ipnet.IP.To4() == nil && ipnet.IP.IsGlobalUnicast() {
+			for _, el := range networks {
+				if el.Contains(ipnet.IP) {
+					continue out
+				}
+			}
+			return ipnet.IP.String(), nil
+		}
+	}
+	return "", errors.New("no IPv6 found")
+}
+
+// HostSupportsIPv6 returns true if it is possible to listen on IPv6 address on the host
+func HostSupportsIPv6() bool {
+	ln, err := net.Listen("tcp6", "[::1]:0")
+	if err != nil {
+		return false
+	}
+	_ = ln.Close()
+	return true
+}
+
 // ResolveURL concatenate parts of url
 func ResolveURL(b, p string) (string, error) {
 	u, err := url.Parse(p)
@@ -0,0 +1,95 @@
+package utils
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"github.com/lf-edge/eden/pkg/defaults"
+	uuid "github.com/satori/go.uuid"
+)
+
+// Nodes of multi-node EVE are numbered from 1. The first node uses values from config as is,
+// values of other nodes are derived from them with functions below.
+
+// NodeName returns name of EVE node
+func NodeName(node int) string {
+	return fmt.Sprintf("node%d", node)
+}
+
+// NodeEveName returns name of EVE of node
+func NodeEveName(eveName string, node int) string {
+	if node <= 1 {
+		return eveName
+	}
+	return fmt.Sprintf("%s-%s", eveName, NodeName(node))
+}
+
+// NodeUUID returns UUID of onboarding certificate of node, it is derived from UUID of the first node
+func NodeUUID(eveUUID string, node int) string {
+	if node <= 1 {
+		return eveUUID
+	}
+	return uuid.NewV5(uuid.FromStringOrNil(eveUUID), NodeName(node)).String()
+}
+
+// NodeSerial returns serial number of node
+func NodeSerial(serial string, node int) string {
+	if node <= 1 {
+		return serial
+	}
+	return fmt.Sprintf("%s-%s", serial, NodeName(node))
+}
+
+// NodePath returns location of file of node, it is placed into subdirectory named by node
+func NodePath(path string, node int) string {
+	if node <= 1 || path == "" {
+		return path
+	}
+	return filepath.Join(filepath.Dir(path), NodeName(node), filepath.Base(path))
+}
+
+// NodePort returns port of host used by node
+func NodePort(port, node int) int {
+	if node <= 1 || port == 0 {
+		return port
+	}
+	return port + (node-1)*defaults.DefaultNodePortOffset
+}
+
+// NodeHostFwd returns forwarding of ports for node with shifted ports of host
+func NodeHostFwd(hostFwd map[string]string, node int) map[string]string {
+	result := make(map[string]string, len(hostFwd))
+	for k, v := range hostFwd {
+		if port, err := strconv.Atoi(k); err == nil {
+			k = strconv.Itoa(NodePort(port, node))
+		}
+		result[k] = v
+	}
+	return result
+}
+
+// NodeFromEnv returns node selected with defaults.DefaultNodeEnv, zero if not set
+func NodeFromEnv() (int, error) {
+	env := os.Getenv(defaults.DefaultNodeEnv)
+	if env == "" {
+		return 0, nil
+	}
+	node, err := strconv.Atoi(env)
+	if err != nil {
+		return 0, fmt.Errorf("cannot parse %s: %w", defaults.DefaultNodeEnv, err)
+	}
+	return node, nil
+}
+
+// CheckNode checks that node is one of nodes, config without eve.nodes has one node
+func CheckNode(node, nodes int) error {
+	if nodes < 1 {
+		nodes = 1
+	}
+	if node < 1 || node > nodes {
+		return fmt.Errorf("node %d not found, eve.nodes is %d", node, nodes)
+	}
+	return nil
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"crypto/sha256"
 	"fmt"
+	"io"
 	"os"
 	"path"
 	"strings"
@@ -87,6 +88,49 @@ func LoadRegistry(image, remote string) (string, error) {
 	return hash, nil
 }
 
+// PushImageTar pushes image from tarball (docker save or OCI layout in tar) into registry
+// with plain http access. If name is empty, the first tag stored in tarball is used.
+// Returns digest of pushed manifest.
+func PushImageTar(tarPath, imageName, remote string) (string, error) {
+	if imageName == "" {
+		manifest, err := v1tarball.LoadManifest(func() (io.ReadCloser, error) { return os.Open(tarPath) })
+		if err != nil {
+			return "", fmt.Errorf("cannot load manifest from %s: %w", tarPath, err)
+		}
+		if len(manifest) == 0 || len(manifest[0].RepoTags) == 0 {
+			return "", fmt.Errorf("no tags found in %s, please provide name of image", tarPath)
+		}
+		imageName = manifest[0].RepoTags[0]
+	}
+	tag, err := name.NewTag(imageName)
+	if err != nil {
+		return "", fmt.Errorf("invalid image name %s: %w", imageName, err)
+	}
+	img, err := v1tarball.ImageFromPath(tarPath, nil)
+	if err != nil {
+		// tarball with several images, select by tag
+		if img, err = v1tarball.ImageFromPath(tarPath, &tag); err != nil {
+			return "", fmt.Errorf("unable to get image from %s: %w", tarPath, err)
+		}
+	}
+	destImage := fmt.Sprintf("%s/%s:%s", remote, tag.Context().RepositoryStr(), tag.Identifier())
+	if err := crane.Push(img, destImage, crane.Insecure); err != nil {
+		return "", fmt.Errorf("error pushing to %s: %w", destImage, err)
+	}
+	digest, err := img.Digest()
+	if err != nil {
+		return "", fmt.Errorf("cannot get digest of image: %w", err)
+	}
+	remoteDigest, err := crane.Digest(destImage, crane.Insecure)
+	if err != nil {
+		return "", fmt.Errorf("cannot get digest of %s: %w", destImage, err)
+	}
+	if remoteDigest != digest.String() {
+		return "", fmt.Errorf("digest mismatch for %s: pushed %s, registry returns %s", destImage, digest, remoteDigest)
+	}
+	return remoteDigest, nil
+}
+
 // RegistryHTTP for http access to local registry
 type RegistryHTTP struct {
 	remotes.Resolver
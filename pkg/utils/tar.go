@@ -10,6 +10,8 @@ import (
 	"path"
 	"path/filepath"
 	"strings"
+
+	log "github.com/sirupsen/logrus"
 )
 
 // FileToSave provides pattern to save or load files based on Location and Destination inside fs and tar
@@ -68,7 +70,7 @@ func CreateTarGz(dstFile string, paths []FileToSave) error {
 			return nil
 		}
 		if err := filepath.Walk(path.Location, walker); err != nil {
-			fmt.Printf("failed to add %s to tar: %s\n", path.Location, err)
+			log.Errorf("failed to add %s to tar: %s", path.Location, err)
 		}
 	}
 	return nil
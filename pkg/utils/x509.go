@@ -80,6 +80,15 @@ func GenCARoot() (*x509.Certificate, *rsa.PrivateKey) {
 
 // GenServerCertElliptic elliptic cert
 func GenServerCertElliptic(cert *x509.Certificate, key *rsa.PrivateKey, serial *big.Int, ip []net.IP, dns []string, uuid string) (*x509.Certificate, *ecdsa.PrivateKey) {
+	return genCertElliptic(cert, key, serial, ip, dns, uuid, x509.ExtKeyUsageServerAuth)
+}
+
+// GenClientCertElliptic elliptic cert for TLS client authentication
+func GenClientCertElliptic(cert *x509.Certificate, key *rsa.PrivateKey, serial *big.Int, commonName string) (*x509.Certificate, *ecdsa.PrivateKey) {
+	return genCertElliptic(cert, key, serial, nil, nil, commonName, x509.ExtKeyUsageClientAuth)
+}
+
+func genCertElliptic(cert *x509.Certificate, key *rsa.PrivateKey, serial *big.Int, ip []net.IP, dns []string, uuid string, extKeyUsage x509.ExtKeyUsage) (*x509.Certificate, *ecdsa.PrivateKey) {
 	priv, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
 	if err != nil {
 		panic(err)
@@ -89,8 +98,8 @@ func GenServerCertElliptic(cert *x509.Certificate, key *rsa.PrivateKey, serial *
 		SerialNumber:   serial,
 		NotBefore:      time.Now().Add(-10 * time.Second),
 		NotAfter:       time.Now().AddDate(10, 0, 0),
-		KeyUsage:       x509.KeyUsageCRLSign,
-		ExtKeyUsage:    []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		KeyUsage:       x509.KeyUsageDigitalSignature | x509.KeyUsageCRLSign,
+		ExtKeyUsage:    []x509.ExtKeyUsage{extKeyUsage},
 		IsCA:           false,
 		MaxPathLenZero: true,
 		IPAddresses:    ip,
@@ -240,6 +249,11 @@ func ParsePrivateKey(keyFile string) (*rsa.PrivateKey, error) {
 	return privateKey, nil
 }
 
+// ParsePrivateKeyPEM parses RSA, ECDSA or PKCS8 private key in PEM format
+func ParsePrivateKeyPEM(keyPEM []byte) (interface{}, error) {
+	return parsePrivateKey(keyPEM, "")
+}
+
 // ParseFirstCertFromBlock process provided certificate date
 func ParseFirstCertFromBlock(b []byte) (*x509.Certificate, error) {
 	certs, err := parseCertFromBlock(b)
@@ -0,0 +1,35 @@
+package api
+
+// Impairments : network impairments applied to ports on top of the network model.
+// Unlike changes of the network model, impairments can be changed at any time without
+// restarting SDN and EVE and they are kept when a new network model is applied.
+type Impairments struct {
+	// Ports : impairments of individual ports. At most one impairment per port.
+	Ports []PortImpairment `json:"ports"`
+}
+
+// PortImpairment : impairment of network connectivity going through a port.
+type PortImpairment struct {
+	// Port : logical label of the impaired port.
+	Port string `json:"port"`
+	// TC : traffic control used for the port instead of the one from the network model.
+	// Zero value keeps traffic control from the network model.
+	TC TrafficControl `json:"trafficControl"`
+	// LinkFlap : periodically put the port down.
+	LinkFlap LinkFlap `json:"linkFlap"`
+}
+
+// LinkFlap : periodic link-down of a port to test link-down and reconnection scenarios on EVE.
+type LinkFlap struct {
+	// Period : time in seconds between two consecutive link-down events.
+	// Zero disables link flaps.
+	Period uint32 `json:"period"`
+	// Down : time in seconds the link stays down within every period.
+	// Must be lower than Period.
+	Down uint32 `json:"down"`
+}
+
+// IsEmpty returns true if impairment does not change anything.
+func (p PortImpairment) IsEmpty() bool {
+	return p.TC == TrafficControl{} && p.LinkFlap.Period == 0
+}
@@ -157,6 +157,11 @@ type EVEConnect struct {
 	// MAC address assigned to the interface on the EVE side.
 	// If not specified by the user, Eden will generate a random MAC address.
 	MAC string `json:"mac"`
+	// Cellular : port is connected to EVE through an emulated cellular modem
+	// (see eve.cellular in the eden config) instead of an Ethernet NIC.
+	// The modem emulator acts as a DHCP client of the network and routes traffic
+	// of the modem data connection through it.
+	Cellular bool `json:"cellular,omitempty"`
 }
 
 // Bridge provides L2 connectivity.
@@ -452,6 +457,8 @@ type HostConfig struct {
 	// HostIPs : list of IP addresses used by the host system (on top of which
 	// Eden runs).
 	// Eden SDN requires at least one routable host IP address.
+	// With dual-stack host, include one routable address of each IP version, both
+	// are then announced by DNS servers of Eden-SDN for the controller (see AdamIPRef).
 	HostIPs []string `json:"hostIPs"`
 	// NetworkType : which IP versions are used by the host.
 	// Even if host uses IPv4 only, it is still possible to have IPv6 inside
@@ -39,6 +39,10 @@ type agent struct {
 	netModel    parsedNetModel
 	newNetModel chan parsedNetModel
 
+	// Network impairments (key: port logical label)
+	impairments    map[string]*impairment
+	newImpairments chan api.Impairments
+
 	// Configuration state
 	currentState  dg.Graph
 	intendedState dg.Graph
@@ -63,6 +67,7 @@ func (a *agent) init() error {
 	}
 	a.registry = registry
 	a.newNetModel = make(chan parsedNetModel, 10)
+	a.newImpairments = make(chan api.Impairments, 10)
 	a.failingItems = make(map[dg.ItemRef]error)
 	// Initially start with an empty network model.
 	// Ever-present config items will get created.
@@ -76,6 +81,8 @@ func (a *agent) init() error {
 }
 
 func (a *agent) run(linkChan chan netlink.LinkUpdate) {
+	linkFlapTicker := time.NewTicker(linkFlapCheckInterval)
+	defer linkFlapTicker.Stop()
 	for {
 		select {
 		case netModel := <-a.newNetModel:
@@ -87,6 +94,23 @@ func (a *agent) run(linkChan chan netlink.LinkUpdate) {
 			a.reconcile()
 			a.Unlock()
 
+		case impairments := <-a.newImpairments:
+			// Impairments are already validated, applying...
+			a.Lock()
+			a.setImpairments(impairments)
+			a.updateLinkFlaps()
+			a.updateIntendedState()
+			a.reconcile()
+			a.Unlock()
+
+		case <-linkFlapTicker.C:
+			a.Lock()
+			if a.updateLinkFlaps() {
+				a.updateIntendedState()
+				a.reconcile()
+			}
+			a.Unlock()
+
 		case <-a.resumeReconciliation:
 			a.Lock()
 			a.reconcile()
@@ -152,10 +176,6 @@ func (a *agent) reconcile() {
 
 	// Log every executed operation.
 	for _, opLog := range status.OperationLog {
-		var withErr string
-		if opLog.Err != nil {
-			withErr = fmt.Sprintf(" with error: %v", opLog.Err)
-		}
 		var verb string
 		if opLog.InProgress {
 			verb = "started async execution of"
@@ -167,9 +187,15 @@ func (a *agent) reconcile() {
 				verb = "executed"
 			}
 		}
-		log.Infof("State Reconciler %s %v for %v%s, content: %s",
-			verb, opLog.Operation, dg.Reference(opLog.Item),
-			withErr, opLog.Item.String())
+		entry := log.WithFields(log.Fields{
+			"operation": opLog.Operation.String(),
+			"item":      dg.Reference(opLog.Item).String(),
+			"content":   opLog.Item.String(),
+		})
+		if opLog.Err != nil {
+			entry = entry.WithError(opLog.Err)
+		}
+		entry.Infof("State Reconciler %s operation", verb)
 	}
 
 	// Log transitions from no-error to error and vice-versa.
@@ -172,32 +172,51 @@ func (a *agent) getIntendedHostConnectivity() dg.Graph {
 		},
 		LogFile: "/run/dhcpcd.log",
 	}, nil)
-	intendedCfg.PutItem(configitems.IptablesChain{
-		ChainName: "POSTROUTING",
-		Table:     "nat",
-		ForIPv6:   false,
-		Rules: []configitems.IptablesRule{
-			{
-				Args:        []string{"-o", netIf.IfName, "-j", "MASQUERADE"},
-				Description: "S-NAT traffic leaving SDN VM towards the host OS",
+	for _, forIPv6 := range a.hostIPVersions() {
+		intendedCfg.PutItem(configitems.IptablesChain{
+			ChainName: "POSTROUTING",
+			Table:     "nat",
+			ForIPv6:   forIPv6,
+			Rules: []configitems.IptablesRule{
+				{
+					Args:        []string{"-o", netIf.IfName, "-j", "MASQUERADE"},
+					Description: "S-NAT traffic leaving SDN VM towards the host OS",
+				},
 			},
-		},
-	}, nil)
+		}, nil)
+	}
 	return intendedCfg
 }
 
+// hostIPVersions returns IP versions used by the host:
+// false stands for IPv4, true for IPv6 (to be used as IptablesChain.ForIPv6).
+func (a *agent) hostIPVersions() []bool {
+	if a.netModel.Host == nil {
+		return []bool{false}
+	}
+	switch a.netModel.Host.NetworkType {
+	case api.Ipv6Only:
+		return []bool{true}
+	case api.DualStack:
+		return []bool{false, true}
+	default:
+		return []bool{false}
+	}
+}
+
 func (a *agent) getIntendedTrafficControl() dg.Graph {
 	graphArgs := dg.InitArgs{Name: trafficControlSG}
 	intendedCfg := dg.New(graphArgs)
 	emptyTC := api.TrafficControl{}
 	for _, port := range a.netModel.Ports {
-		if port.TC == emptyTC {
+		tc := a.portTC(port)
+		if tc == emptyTC {
 			continue
 		}
 		// MAC address is already validated
 		mac, _ := net.ParseMAC(port.MAC)
 		intendedCfg.PutItem(configitems.TrafficControl{
-			TrafficControl: port.TC,
+			TrafficControl: tc,
 			PhysIf: configitems.PhysIf{
 				LogicalLabel: port.LogicalLabel,
 				MAC:          mac,
@@ -232,7 +251,7 @@ func (a *agent) getIntendedBridges() dg.Graph {
 			},
 			ParentLL: masterID.logicalLabel,
 			Usage:    usage,
-			AdminUP:  port.AdminUP,
+			AdminUP:  a.portAdminUP(port),
 			MTU:      maxMTU,
 		}, nil)
 	}
@@ -593,7 +612,7 @@ func (a *agent) getIntendedNetwork(network api.Network) dg.Graph {
 			NetNamespace: nsName,
 			ChainName:    "PREROUTING",
 			Table:        "nat",
-			ForIPv6:      false,
+			ForIPv6:      isIPv6,
 			Rules:        dnatRules,
 		}, nil)
 	}
@@ -602,15 +621,19 @@ func (a *agent) getIntendedNetwork(network api.Network) dg.Graph {
 	// is from the internal IP subnet.
 	// Make sure that the IP address is S-NATed before sending packets to EVE.
 	// Otherwise, the responses could be routed out via wrong EVE network ports.
+	internalSubnet := internalIPv4Subnet
+	if isIPv6 {
+		internalSubnet = internalIPv6Subnet
+	}
 	intendedCfg.PutItem(configitems.IptablesChain{
 		NetNamespace: nsName,
 		ChainName:    "POSTROUTING",
 		Table:        "nat",
-		ForIPv6:      false,
+		ForIPv6:      isIPv6,
 		RefersVeths:  []string{rtVethName},
 		Rules: []configitems.IptablesRule{
 			{
-				Args: []string{"-o", brInIfName, "-s", internalIPv4Subnet.String(),
+				Args: []string{"-o", brInIfName, "-s", internalSubnet.String(),
 					"-j", "MASQUERADE"},
 				Description: "S-NAT traffic leaving SDN VM towards EVE with internal source IP",
 			},
@@ -622,55 +645,80 @@ func (a *agent) getIntendedNetwork(network api.Network) dg.Graph {
 func (a *agent) getIntendedFirewall() dg.Graph {
 	graphArgs := dg.InitArgs{Name: firewallSG}
 	intendedCfg := dg.New(graphArgs)
-	iptablesRules := make([]configitems.IptablesRule, 0, 2+len(a.netModel.Firewall.Rules))
-	// Allow any subsequent traffic that results from an already allowed connection.
-	iptablesRules = append(iptablesRules, configitems.IptablesRule{
-		Args: []string{"-m", "conntrack", "--ctstate", "ESTABLISHED,RELATED", "-j", "ACCEPT"},
-	})
-	// Add explicitly configured firewall rules.
-	for _, rule := range a.netModel.Firewall.Rules {
-		iptablesRules = append(iptablesRules, a.getIntendedFwRule(rule))
-	}
-	// Implicitly allow everything not matched by the rules above.
-	allowTheRest := api.FwRule{Action: api.FwAllow}
-	iptablesRules = append(iptablesRules, a.getIntendedFwRule(allowTheRest))
-	intendedCfg.PutItem(configitems.IptablesChain{
-		NetNamespace: configitems.MainNsName,
-		ChainName:    fwIptablesChain,
-		Table:        "filter",
-		ForIPv6:      false,
-		Rules:        iptablesRules,
-	}, nil)
-	// Link the firewall chain with every network and endpoint (outside) interface.
-	veths := make([]string, 0, len(a.netModel.Networks)+len(a.netModel.Endpoints.GetAll()))
-	iptablesRules = nil
-	for _, network := range a.netModel.Networks {
-		rtVethName, _, rtOutIfName := a.networkRtVethName(network.LogicalLabel)
-		veths = append(veths, rtVethName)
+	// The same firewall is applied for both IPv4 and IPv6 traffic.
+	for _, forIPv6 := range []bool{false, true} {
+		iptablesRules := make([]configitems.IptablesRule, 0, 2+len(a.netModel.Firewall.Rules))
+		// Allow any subsequent traffic that results from an already allowed connection.
 		iptablesRules = append(iptablesRules, configitems.IptablesRule{
-			Args: []string{"-i", rtOutIfName, "-j", fwIptablesChain},
-		})
-	}
-	for _, ep := range a.netModel.Endpoints.GetAll() {
-		epVethName, _, epOutIfName := a.endpointVethName(ep.LogicalLabel)
-		veths = append(veths, epVethName)
-		iptablesRules = append(iptablesRules, configitems.IptablesRule{
-			Args: []string{"-i", epOutIfName, "-j", fwIptablesChain},
+			Args: []string{"-m", "conntrack", "--ctstate", "ESTABLISHED,RELATED", "-j", "ACCEPT"},
 		})
+		// Add explicitly configured firewall rules.
+		for _, rule := range a.netModel.Firewall.Rules {
+			if ruleIPv6 := fwRuleIPv6(rule); ruleIPv6 != nil && *ruleIPv6 != forIPv6 {
+				// Rule is for the other IP version.
+				continue
+			}
+			iptablesRules = append(iptablesRules, a.getIntendedFwRule(rule, forIPv6))
+		}
+		// Implicitly allow everything not matched by the rules above.
+		allowTheRest := api.FwRule{Action: api.FwAllow}
+		iptablesRules = append(iptablesRules, a.getIntendedFwRule(allowTheRest, forIPv6))
+		intendedCfg.PutItem(configitems.IptablesChain{
+			NetNamespace: configitems.MainNsName,
+			ChainName:    fwIptablesChain,
+			Table:        "filter",
+			ForIPv6:      forIPv6,
+			Rules:        iptablesRules,
+		}, nil)
+		// Link the firewall chain with every network and endpoint (outside) interface.
+		veths := make([]string, 0, len(a.netModel.Networks)+len(a.netModel.Endpoints.GetAll()))
+		iptablesRules = nil
+		for _, network := range a.netModel.Networks {
+			rtVethName, _, rtOutIfName := a.networkRtVethName(network.LogicalLabel)
+			veths = append(veths, rtVethName)
+			iptablesRules = append(iptablesRules, configitems.IptablesRule{
+				Args: []string{"-i", rtOutIfName, "-j", fwIptablesChain},
+			})
+		}
+		for _, ep := range a.netModel.Endpoints.GetAll() {
+			epVethName, _, epOutIfName := a.endpointVethName(ep.LogicalLabel)
+			veths = append(veths, epVethName)
+			iptablesRules = append(iptablesRules, configitems.IptablesRule{
+				Args: []string{"-i", epOutIfName, "-j", fwIptablesChain},
+			})
+		}
+		intendedCfg.PutItem(configitems.IptablesChain{
+			NetNamespace: configitems.MainNsName,
+			ChainName:    "FORWARD",
+			Table:        "filter",
+			ForIPv6:      forIPv6,
+			Rules:        iptablesRules,
+			RefersVeths:  veths,
+			RefersChains: []string{fwIptablesChain},
+		}, nil)
 	}
-	intendedCfg.PutItem(configitems.IptablesChain{
-		NetNamespace: configitems.MainNsName,
-		ChainName:    "FORWARD",
-		Table:        "filter",
-		ForIPv6:      false,
-		Rules:        iptablesRules,
-		RefersVeths:  veths,
-		RefersChains: []string{fwIptablesChain},
-	}, nil)
 	return intendedCfg
 }
 
-func (a *agent) getIntendedFwRule(rule api.FwRule) configitems.IptablesRule {
+// fwRuleIPv6 returns pointer to true if the rule matches only IPv6 traffic,
+// pointer to false if it matches only IPv4 traffic and nil if it is not specific
+// to an IP version.
+func fwRuleIPv6(rule api.FwRule) *bool {
+	for _, subnet := range []string{rule.SrcSubnet, rule.DstSubnet} {
+		if subnet == "" {
+			continue
+		}
+		_, ipNet, err := net.ParseCIDR(subnet)
+		if err != nil {
+			continue
+		}
+		isIPv6 := len(ipNet.IP) == net.IPv6len
+		return &isIPv6
+	}
+	return nil
+}
+
+func (a *agent) getIntendedFwRule(rule api.FwRule, forIPv6 bool) configitems.IptablesRule {
 	var ruleArgs []string
 	if rule.SrcSubnet != "" {
 		ruleArgs = append(ruleArgs, "-s", rule.SrcSubnet)
@@ -682,7 +730,11 @@ func (a *agent) getIntendedFwRule(rule api.FwRule) configitems.IptablesRule {
 	case api.AnyProto:
 		ruleArgs = append(ruleArgs, "-p", "all")
 	case api.ICMP:
-		ruleArgs = append(ruleArgs, "-p", "icmp")
+		if forIPv6 {
+			ruleArgs = append(ruleArgs, "-p", "ipv6-icmp")
+		} else {
+			ruleArgs = append(ruleArgs, "-p", "icmp")
+		}
 	case api.TCP:
 		ruleArgs = append(ruleArgs, "-p", "tcp")
 	case api.UDP:
@@ -743,7 +795,14 @@ func (a *agent) getIntendedDNSSrvEp(dnsSrv api.DNSServer) dg.Graph {
 		}
 		switch {
 		case staticEntry.IP == api.AdamIPRef:
-			ip = a.netModel.hostIP
+			// With dual-stack host, the controller is reachable over both IP versions.
+			for _, hostIP := range a.netModel.hostIPs {
+				staticEntries = append(staticEntries, configitems.DnsEntry{
+					FQDN: fqdn,
+					IP:   hostIP,
+				})
+			}
+			continue
 		case strings.HasPrefix(staticEntry.IP, api.EndpointIPRefPrefix):
 			epLL := strings.TrimPrefix(staticEntry.IP, api.EndpointIPRefPrefix)
 			ep := a.getEndpoint(epLL)
@@ -0,0 +1,162 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/lf-edge/eden/sdn/vm/api"
+	log "github.com/sirupsen/logrus"
+)
+
+// How often to check if a flapping link should change its state.
+const linkFlapCheckInterval = time.Second
+
+// impairment applied to a port.
+type impairment struct {
+	api.PortImpairment
+	appliedAt time.Time
+	linkDown  bool
+}
+
+func (a *agent) validateImpairments(impairments api.Impairments) (err error) {
+	ports := make(map[string]struct{})
+	for _, port := range a.netModel.Ports {
+		ports[port.LogicalLabel] = struct{}{}
+	}
+	impaired := make(map[string]struct{})
+	for _, imp := range impairments.Ports {
+		if _, exists := ports[imp.Port]; !exists {
+			return fmt.Errorf("impaired port %s is not in the network model", imp.Port)
+		}
+		if _, duplicate := impaired[imp.Port]; duplicate {
+			return fmt.Errorf("port %s is impaired more than once", imp.Port)
+		}
+		impaired[imp.Port] = struct{}{}
+		if imp.TC.RateLimit != 0 && (imp.TC.QueueLimit == 0 || imp.TC.BurstLimit == 0) {
+			return fmt.Errorf("RateLimit set for impaired port %s without QueueLimit and BurstLimit",
+				imp.Port)
+		}
+		flap := imp.LinkFlap
+		if flap.Period != 0 && (flap.Down == 0 || flap.Down >= flap.Period) {
+			return fmt.Errorf("link flap of port %s must stay down for more than zero "+
+				"and less than period of %d seconds", imp.Port, flap.Period)
+		}
+	}
+	return nil
+}
+
+// Called with agent in locked state.
+func (a *agent) setImpairments(impairments api.Impairments) {
+	prev := a.impairments
+	a.impairments = make(map[string]*impairment)
+	for _, imp := range impairments.Ports {
+		if imp.IsEmpty() {
+			continue
+		}
+		// Keep phase of link flaps if they did not change.
+		if old, found := prev[imp.Port]; found && old.LinkFlap == imp.LinkFlap {
+			a.impairments[imp.Port] = &impairment{
+				PortImpairment: imp,
+				appliedAt:      old.appliedAt,
+				linkDown:       old.linkDown,
+			}
+			continue
+		}
+		a.impairments[imp.Port] = &impairment{PortImpairment: imp, appliedAt: time.Now()}
+	}
+}
+
+// updateLinkFlaps moves flapping links up or down, returns true if some link changed
+// its state.
+// Called with agent in locked state.
+func (a *agent) updateLinkFlaps() (changed bool) {
+	now := time.Now()
+	for _, imp := range a.impairments {
+		if imp.LinkFlap.Period == 0 {
+			continue
+		}
+		period := time.Duration(imp.LinkFlap.Period) * time.Second
+		down := time.Duration(imp.LinkFlap.Down) * time.Second
+		// Link goes down at the end of every period.
+		linkDown := now.Sub(imp.appliedAt)%period >= period-down
+		if linkDown != imp.linkDown {
+			log.WithFields(log.Fields{
+				"port": imp.Port,
+				"link": map[bool]string{true: "DOWN", false: "UP"}[linkDown],
+			}).Info("Link flap")
+			imp.linkDown = linkDown
+			changed = true
+		}
+	}
+	return changed
+}
+
+// portTC returns traffic control of the port with impairment applied.
+func (a *agent) portTC(port api.Port) api.TrafficControl {
+	if imp, found := a.impairments[port.LogicalLabel]; found && imp.TC != (api.TrafficControl{}) {
+		return imp.TC
+	}
+	return port.TC
+}
+
+// portAdminUP returns true if the port should be UP with link flaps applied.
+func (a *agent) portAdminUP(port api.Port) bool {
+	if imp, found := a.impairments[port.LogicalLabel]; found && imp.linkDown {
+		return false
+	}
+	return port.AdminUP
+}
+
+func (a *agent) getImpairments(w http.ResponseWriter, r *http.Request) {
+	impairments := api.Impairments{Ports: []api.PortImpairment{}}
+	a.Lock()
+	for _, imp := range a.impairments {
+		impairments.Ports = append(impairments.Ports, imp.PortImpairment)
+	}
+	a.Unlock()
+	resp, err := json.Marshal(impairments)
+	if err != nil {
+		errMsg := fmt.Sprintf("failed to marshal impairments to JSON: %v", err)
+		log.Error(errMsg)
+		http.Error(w, errMsg, http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if _, err = w.Write(resp); err != nil {
+		log.Errorf("Failed to write impairments to HTTP response: %v", err)
+	}
+}
+
+func (a *agent) applyImpairments(w http.ResponseWriter, r *http.Request) {
+	var impairments api.Impairments
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		errMsg := fmt.Sprintf("Failed to read impairments from HTTP request: %v", err)
+		log.Error(errMsg)
+		http.Error(w, errMsg, http.StatusBadRequest)
+		return
+	}
+	err = json.Unmarshal(body, &impairments)
+	if err != nil {
+		errMsg := fmt.Sprintf("Failed to unmarshal impairments from JSON: %v", err)
+		log.Error(errMsg)
+		http.Error(w, errMsg, http.StatusBadRequest)
+		return
+	}
+	a.Lock()
+	err = a.validateImpairments(impairments)
+	a.Unlock()
+	if err != nil {
+		errMsg := fmt.Sprintf("Impairments are invalid: %v", err)
+		log.Error(errMsg)
+		http.Error(w, errMsg, http.StatusBadRequest)
+		return
+	}
+	log.Debugf("Impairments: %+v", impairments)
+	a.newImpairments <- impairments
+	w.WriteHeader(http.StatusOK)
+}
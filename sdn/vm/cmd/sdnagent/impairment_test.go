@@ -0,0 +1,130 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/lf-edge/eden/sdn/vm/api"
+	"github.com/stretchr/testify/assert"
+)
+
+func testAgent() *agent {
+	a := &agent{}
+	a.netModel.Ports = []api.Port{{LogicalLabel: "eth0"}, {LogicalLabel: "eth1"}}
+	return a
+}
+
+func TestValidateImpairments(t *testing.T) {
+	t.Parallel()
+
+	testMatrix := map[string]struct {
+		ports []api.PortImpairment
+		valid bool
+	}{
+		"no impairments": {
+			valid: true,
+		},
+		"impaired ports": {
+			ports: []api.PortImpairment{
+				{Port: "eth0", TC: api.TrafficControl{Delay: 100}},
+				{Port: "eth1", LinkFlap: api.LinkFlap{Period: 10, Down: 3}},
+			},
+			valid: true,
+		},
+		"unknown port": {
+			ports: []api.PortImpairment{{Port: "eth2", TC: api.TrafficControl{Delay: 100}}},
+		},
+		"duplicate port": {
+			ports: []api.PortImpairment{
+				{Port: "eth0", TC: api.TrafficControl{Delay: 100}},
+				{Port: "eth0", TC: api.TrafficControl{LossProbability: 10}},
+			},
+		},
+		"rate limit with queue and burst": {
+			ports: []api.PortImpairment{
+				{Port: "eth0", TC: api.TrafficControl{RateLimit: 100, QueueLimit: 100, BurstLimit: 10}},
+			},
+			valid: true,
+		},
+		"rate limit without queue": {
+			ports: []api.PortImpairment{
+				{Port: "eth0", TC: api.TrafficControl{RateLimit: 100, BurstLimit: 10}},
+			},
+		},
+		"rate limit without burst": {
+			ports: []api.PortImpairment{
+				{Port: "eth0", TC: api.TrafficControl{RateLimit: 100, QueueLimit: 100}},
+			},
+		},
+		"link flap without down": {
+			ports: []api.PortImpairment{{Port: "eth0", LinkFlap: api.LinkFlap{Period: 10}}},
+		},
+		"link flap down equals period": {
+			ports: []api.PortImpairment{{Port: "eth0", LinkFlap: api.LinkFlap{Period: 10, Down: 10}}},
+		},
+		"link flap down longer than period": {
+			ports: []api.PortImpairment{{Port: "eth0", LinkFlap: api.LinkFlap{Period: 10, Down: 20}}},
+		},
+	}
+
+	a := testAgent()
+	for name, tt := range testMatrix {
+		err := a.validateImpairments(api.Impairments{Ports: tt.ports})
+		if tt.valid {
+			assert.NoError(t, err, name)
+		} else {
+			assert.Error(t, err, name)
+		}
+	}
+}
+
+func TestUpdateLinkFlaps(t *testing.T) {
+	t.Parallel()
+
+	flap := api.LinkFlap{Period: 10, Down: 3}
+	a := testAgent()
+	a.setImpairments(api.Impairments{Ports: []api.PortImpairment{
+		{Port: "eth0", LinkFlap: flap},
+		{Port: "eth1", TC: api.TrafficControl{Delay: 100}},
+	}})
+	assert.Len(t, a.impairments, 2)
+
+	// link is UP at the beginning of the period
+	assert.False(t, a.updateLinkFlaps())
+	assert.True(t, a.portAdminUP(api.Port{LogicalLabel: "eth0", AdminUP: true}))
+
+	// and goes DOWN for the last seconds of the period
+	a.impairments["eth0"].appliedAt = time.Now().Add(-8 * time.Second)
+	assert.True(t, a.updateLinkFlaps())
+	assert.False(t, a.updateLinkFlaps(), "state is changed only once")
+	assert.False(t, a.portAdminUP(api.Port{LogicalLabel: "eth0", AdminUP: true}))
+	assert.True(t, a.portAdminUP(api.Port{LogicalLabel: "eth1", AdminUP: true}), "port without flaps is UP")
+
+	// phase of unchanged link flap is kept when impairments are set again
+	appliedAt := a.impairments["eth0"].appliedAt
+	a.setImpairments(api.Impairments{Ports: []api.PortImpairment{
+		{Port: "eth0", LinkFlap: flap, TC: api.TrafficControl{Delay: 50}},
+	}})
+	assert.Equal(t, appliedAt, a.impairments["eth0"].appliedAt)
+	assert.True(t, a.impairments["eth0"].linkDown)
+	assert.NotContains(t, a.impairments, "eth1")
+
+	// UP again in the next period
+	a.impairments["eth0"].appliedAt = time.Now().Add(-12 * time.Second)
+	assert.True(t, a.updateLinkFlaps())
+	assert.True(t, a.portAdminUP(api.Port{LogicalLabel: "eth0", AdminUP: true}))
+
+	// changed link flap starts a new phase
+	a.impairments["eth0"].appliedAt = time.Now().Add(-8 * time.Second)
+	assert.True(t, a.updateLinkFlaps())
+	a.setImpairments(api.Impairments{Ports: []api.PortImpairment{
+		{Port: "eth0", LinkFlap: api.LinkFlap{Period: 20, Down: 3}},
+	}})
+	assert.False(t, a.impairments["eth0"].linkDown)
+	assert.WithinDuration(t, time.Now(), a.impairments["eth0"].appliedAt, time.Second)
+
+	// empty impairments are dropped
+	a.setImpairments(api.Impairments{Ports: []api.PortImpairment{{Port: "eth0"}}})
+	assert.Empty(t, a.impairments)
+	assert.False(t, a.updateLinkFlaps())
+}
@@ -16,7 +16,10 @@ const (
 
 func logRequest(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		log.Infof("HTTP request %s %s", r.Method, r.URL.Path)
+		log.WithFields(log.Fields{
+			"method": r.Method,
+			"path":   r.URL.Path,
+		}).Info("HTTP request")
 		next.ServeHTTP(w, r)
 	})
 }
@@ -25,8 +28,18 @@ func main() {
 	debug := flag.Bool("debug", false, "Set Debug log level")
 	port := flag.Uint("port", defaultPort, "Port on which to listen")
 	ip := flag.String("ip", defaultIP, "IP address on which to listen")
+	logFormat := flag.String("log-format", "text", "Format of logs: text or json")
 	flag.Parse()
 
+	switch *logFormat {
+	case "text":
+		log.SetFormatter(&log.TextFormatter{})
+	case "json":
+		log.SetFormatter(&log.JSONFormatter{})
+	default:
+		log.Fatalf("Unsupported format of logs: %s", *logFormat)
+	}
+
 	if *debug {
 		log.SetLevel(log.DebugLevel)
 	} else {
@@ -46,6 +59,8 @@ func main() {
 	router.HandleFunc("/net-model.json", agent.applyNetModel).Methods("PUT")
 	router.HandleFunc("/net-config.gv", agent.getNetConfig).Methods("GET")
 	router.HandleFunc("/sdn-status.json", agent.getSDNStatus).Methods("GET")
+	router.HandleFunc("/impairments.json", agent.getImpairments).Methods("GET")
+	router.HandleFunc("/impairments.json", agent.applyImpairments).Methods("PUT")
 	// TODO: metrics?
 
 	srv := &http.Server{
@@ -20,7 +20,6 @@ import (
 
 const (
 	// Minimum accepted MTU value.
-	// Eventually, Eden-SDN will incorporate IPv6 support.
 	// As per RFC 8200, the MTU must not be less than 1280 bytes to accommodate IPv6 packets.
 	minMTU = 1280
 	// Maximum MTU supported by the e1000 driver (used for interfaces connecting
@@ -30,8 +29,9 @@ const (
 
 type parsedNetModel struct {
 	api.NetworkModel
-	items  labeledItems
-	hostIP net.IP
+	items labeledItems
+	// hostIPs : the first routable host IP of each IP version used by the host.
+	hostIPs []net.IP
 }
 
 type labeledItems map[itemID]*labeledItem
@@ -277,7 +277,28 @@ func (a *agent) validateNetworks(netModel *parsedNetModel) (err error) {
 		}
 	}
 
-	// TODO: check that within a network it is IPv4 or IPv6, not both (for now)
+	// Check that within a network it is IPv4 or IPv6, not both (for now).
+	for _, network := range netModel.Networks {
+		_, subnet, _ := net.ParseCIDR(network.Subnet)
+		isIPv6 := len(subnet.IP) == net.IPv6len
+		ips := []string{network.GwIP}
+		if network.DHCP.IPRange.FromIP != "" {
+			ips = append(ips, network.DHCP.IPRange.FromIP, network.DHCP.IPRange.ToIP)
+		}
+		for _, ipStr := range ips {
+			ip := net.ParseIP(ipStr)
+			if (ip.To4() == nil) != isIPv6 {
+				err = fmt.Errorf("network %s mixes IPv4 and IPv6 addresses (%s, %s)",
+					network.LogicalLabel, network.Subnet, ipStr)
+				return
+			}
+		}
+		if isIPv6 && network.TransparentProxy != "" {
+			err = fmt.Errorf("transparent proxy is not supported for IPv6 network %s",
+				network.LogicalLabel)
+			return
+		}
+	}
 	return nil
 }
 
@@ -499,20 +520,24 @@ func (a *agent) validateHostConfig(netModel *parsedNetModel) (err error) {
 		err = errors.New("missing host configuration")
 		return
 	}
-	var routableHostIP bool
+	var haveIPv4, haveIPv6 bool
 	for _, hostIP := range netModel.Host.HostIPs {
 		ip := net.ParseIP(hostIP)
 		if ip == nil {
 			err = fmt.Errorf("failed to parse host IP address %s", hostIP)
 			return
 		}
-		if ip.IsGlobalUnicast() {
-			routableHostIP = true
-			netModel.hostIP = ip
-			break
+		if !ip.IsGlobalUnicast() {
+			continue
+		}
+		isIPv4 := ip.To4() != nil
+		if (isIPv4 && !haveIPv4) || (!isIPv4 && !haveIPv6) {
+			netModel.hostIPs = append(netModel.hostIPs, ip)
 		}
+		haveIPv4 = haveIPv4 || isIPv4
+		haveIPv6 = haveIPv6 || !isIPv4
 	}
-	if !routableHostIP {
+	if len(netModel.hostIPs) == 0 {
 		err = errors.New("eden SDN requires at least one routable host IP address")
 		return
 	}
@@ -10,6 +10,7 @@ require (
 	github.com/lf-edge/eve/libs/depgraph v0.0.0-20220711144346-0659e3b03496
 	github.com/lf-edge/eve/libs/reconciler v0.0.0-20220711144346-0659e3b03496
 	github.com/sirupsen/logrus v1.8.1
+	github.com/stretchr/testify v1.8.4
 	github.com/vishvananda/netlink v1.1.1-0.20210924202909-187053b97868
 	github.com/vishvananda/netns v0.0.0-20200728191858-db3c7e526aae
 	golang.org/x/net v0.23.0 // indirect
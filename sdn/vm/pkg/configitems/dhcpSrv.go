@@ -188,13 +188,20 @@ func (c *DhcpServerConfigurator) createDnsmasqConfFile(server DhcpServer) error
 		// Do Router Advertisements and stateless DHCPv6 for this subnet. Clients will
 		// not get addresses from DHCP, but they will get other configuration information.
 		// They will use SLAAC for addresses.
-		file.WriteString(fmt.Sprintf("dhcp-range=%s,ra-stateless\n", server.Subnet))
+		prefixLen, _ := server.Subnet.Mask.Size()
+		file.WriteString("enable-ra\n")
+		file.WriteString(fmt.Sprintf("dhcp-range=%s,ra-stateless,%d,60m\n",
+			server.Subnet.IP, prefixLen))
 	} else {
 		netmask := net.IP(server.Subnet.Mask)
 		file.WriteString(fmt.Sprintf("dhcp-range=%s,%s,%s,60m\n",
 			server.IPRange.FromIP, server.IPRange.ToIP, netmask))
 	}
 	for _, entry := range server.StaticEntries {
+		if isIPv6 {
+			// Addresses are assigned using SLAAC.
+			break
+		}
 		file.WriteString(fmt.Sprintf("dhcp-host=%s,%s\n",
 			entry.MAC.String(), entry.IP.String()))
 	}
@@ -210,7 +217,7 @@ func (c *DhcpServerConfigurator) createDnsmasqConfFile(server DhcpServer) error
 	// Domain name.
 	if server.DomainName != "" {
 		if isIPv6 {
-			file.WriteString(fmt.Sprintf("dhcp-option=option:domain-search,%s\n",
+			file.WriteString(fmt.Sprintf("dhcp-option=option6:domain-search,%s\n",
 				server.DomainName))
 		} else {
 			file.WriteString(fmt.Sprintf("dhcp-option=option:domain-name,%s\n",
@@ -218,8 +225,13 @@ func (c *DhcpServerConfigurator) createDnsmasqConfFile(server DhcpServer) error
 		}
 	}
 	// Default gateway.
-	// Note that gateway for IPv6 needs to be handled with radvd.
-	if !isIPv6 {
+	// For IPv6 the gateway is announced with Router Advertisement,
+	// router lifetime set to zero means that there is no default route.
+	if isIPv6 {
+		if len(server.GatewayIP) == 0 {
+			file.WriteString(fmt.Sprintf("ra-param=%s,0,0\n", server.VethPeerIfName))
+		}
+	} else {
 		if len(server.GatewayIP) != 0 {
 			gwIP := server.GatewayIP.String()
 			file.WriteString(fmt.Sprintf("dhcp-option=option:router,%s\n", gwIP))
@@ -233,15 +245,24 @@ func (c *DhcpServerConfigurator) createDnsmasqConfFile(server DhcpServer) error
 		for _, srvIP := range server.DNSServers {
 			addrList = append(addrList, srvIP.String())
 		}
-		file.WriteString(fmt.Sprintf("dhcp-option=option:dns-server,%s\n",
-			strings.Join(addrList, ",")))
+		if isIPv6 {
+			file.WriteString(fmt.Sprintf("dhcp-option=option6:dns-server,[%s]\n",
+				strings.Join(addrList, "],[")))
+		} else {
+			file.WriteString(fmt.Sprintf("dhcp-option=option:dns-server,%s\n",
+				strings.Join(addrList, ",")))
+		}
 	}
 	// NTP Server.
 	if server.NTPServer != "" {
-		file.WriteString(fmt.Sprintf("dhcp-option=option:ntp-server,%s\n", server.NTPServer))
+		if isIPv6 {
+			file.WriteString(fmt.Sprintf("dhcp-option=option6:sntp-server,[%s]\n", server.NTPServer))
+		} else {
+			file.WriteString(fmt.Sprintf("dhcp-option=option:ntp-server,%s\n", server.NTPServer))
+		}
 	}
-	// WPAD.
-	if server.WPAD != "" {
+	// WPAD (DHCPv4 only).
+	if server.WPAD != "" && !isIPv6 {
 		file.WriteString(fmt.Sprintf("dhcp-option=252,%s\n", server.WPAD))
 	}
 	if err = file.Sync(); err != nil {
@@ -13,7 +13,6 @@ import (
 	"github.com/lf-edge/eden/pkg/projects"
 	"github.com/lf-edge/eden/pkg/tests"
 	"github.com/lf-edge/eden/pkg/utils"
-	"github.com/lf-edge/eve-api/go/info"
 	uuid "github.com/satori/go.uuid"
 )
 
@@ -130,14 +129,6 @@ func checkState(eveState *eve.State, state string, appNames []string) error {
 	return nil
 }
 
-// checkApp wait for info of ZInfoApp type with state
-func checkApp(state string, appNames []string) projects.ProcInfoFunc {
-	return func(msg *info.ZInfoMsg) error {
-		eveState.InfoCallback()(msg) //feed state with new info
-		return checkState(eveState, state, appNames)
-	}
-}
-
 // TestAppStatus wait for application reaching the selected state
 // with a timewait
 func TestAppStatus(t *testing.T) {
@@ -172,8 +163,6 @@ func TestAppStatus(t *testing.T) {
 
 		if ready := checkState(eveState, state, apps); ready == nil {
 
-			tc.AddProcInfo(edgeNode, checkApp(state, apps))
-
 			callback := func() {
 				t.Errorf("ASSERTION FAILED (%s): expected apps %s in %s state", time.Now().Format(time.RFC3339Nano), apps, state)
 				for k, v := range states {
@@ -200,13 +189,22 @@ func TestAppStatus(t *testing.T) {
 				}
 			}
 
-			tc.WaitForProcWithErrorCallback(secs, callback)
+			// checkState returns error describing reached state when apps are ready
+			var reached error
+			reachedState := func(st *eve.State) bool {
+				reached = checkState(st, state, apps)
+				return reached != nil
+			}
+			waiter := eve.NewWaiter(tc.GetController(), edgeNode, eveState)
+			waiter.StreamOnly = *newitems
+			if err := waiter.Wait(reachedState, *timewait); err != nil {
+				callback()
+				t.Fatalf("expected apps %s in %s state: %s", apps, state, err)
+			}
+			t.Log(utils.AddTimestamp(reached.Error()))
 
 		} else {
 			t.Log(utils.AddTimestamp(ready.Error()))
 		}
-
-		// sleep to reduce concurrency effects
-		time.Sleep(1 * time.Second)
 	}
 }
@@ -35,6 +35,12 @@ nginx
 
 avahi-daemon -D
 
+# target for iperf3 profiles of eden loadgen
+iperf3 -s -D
+
+# traffic generator of eden loadgen configured with LOADGEN_* envs
+if [ -n "$LOADGEN_TOOL" ]; then /root/loadgen.sh & fi
+
 # For app_logs test.
 echo "Started eclient"
 
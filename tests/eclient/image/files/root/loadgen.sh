@@ -0,0 +1,31 @@
+#!/bin/sh
+# Runs traffic generator configured with envs and prints its report
+# as a single line started with LOADGEN_RESULT, eden loadgen collects it from app logs.
+#   LOADGEN_TOOL     - vegeta or iperf3
+#   LOADGEN_TARGET   - IP of target
+#   LOADGEN_PORT     - port of target
+#   LOADGEN_DURATION - duration of generation in seconds
+#   LOADGEN_RATE     - requests per second for vegeta
+#   LOADGEN_ARGS     - additional arguments for the tool
+#   LOADGEN_DELAY    - seconds to wait for network before start (10 by default)
+
+sleep "${LOADGEN_DELAY:-10}"
+
+echo "Starting $LOADGEN_TOOL against $LOADGEN_TARGET:$LOADGEN_PORT for ${LOADGEN_DURATION}s"
+case "$LOADGEN_TOOL" in
+    vegeta)
+        # shellcheck disable=SC2086
+        report="$(echo "GET http://$LOADGEN_TARGET:$LOADGEN_PORT/" | \
+            vegeta attack -rate="$LOADGEN_RATE" -duration="${LOADGEN_DURATION}s" $LOADGEN_ARGS | \
+            vegeta report -type=json)"
+        ;;
+    iperf3)
+        # shellcheck disable=SC2086
+        report="$(iperf3 -c "$LOADGEN_TARGET" -p "$LOADGEN_PORT" -t "$LOADGEN_DURATION" -J $LOADGEN_ARGS)"
+        ;;
+    *)
+        echo "Unknown LOADGEN_TOOL: $LOADGEN_TOOL"
+        exit 1
+        ;;
+esac
+echo "LOADGEN_RESULT $(echo "$report" | jq -c .)"
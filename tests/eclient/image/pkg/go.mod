@@ -4,5 +4,6 @@ go 1.14
 
 require (
 	github.com/lf-edge/eve-api/go v0.0.0-20231214160111-99ce4e43be4b
+	github.com/sirupsen/logrus v1.9.3
 	google.golang.org/protobuf v1.33.0
 )
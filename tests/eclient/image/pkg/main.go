@@ -11,6 +11,7 @@ import (
 
 	"github.com/lf-edge/eve-api/go/info"
 	"github.com/lf-edge/eve-api/go/profile"
+	log "github.com/sirupsen/logrus"
 	"google.golang.org/protobuf/encoding/protojson"
 	"google.golang.org/protobuf/proto"
 )
@@ -41,9 +42,16 @@ var (
 		"File to save location info obtained from EVE")
 	locationThrottleFile = flag.String("location-throttle", "/mnt/location.throttle",
 		"When this file exists, location reporting is throttled")
-	token = flag.String("token", "", "Token of profile server")
+	token    = flag.String("token", "", "Token of profile server")
+	logLevel = flag.String("log-level", "info",
+		"Log level (debug, info, warn, error)")
+	logFormat = flag.String("log-format", "text",
+		"Format of logs (text, json)")
 )
 
+// requestIDHeader carries ID of request, set by Eden to correlate its logs with ours
+const requestIDHeader = "X-Request-Id"
+
 var (
 	radioSilenceIsChanging bool
 	radioSilenceCounter    int
@@ -54,25 +62,62 @@ var (
 
 func main() {
 	flag.Parse()
+	if err := setupLogs(); err != nil {
+		log.Fatal(err)
+	}
 	http.HandleFunc("/api/v1/local_profile", localProfile)
 	http.HandleFunc("/api/v1/radio", radio)
 	http.HandleFunc("/api/v1/appinfo", appinfo)
 	http.HandleFunc("/api/v1/devinfo", devinfo)
 	http.HandleFunc("/api/v1/location", location)
-	fmt.Println(http.ListenAndServe(":8888", nil))
+	log.Error(http.ListenAndServe(":8888", nil))
+}
+
+func setupLogs() error {
+	level, err := log.ParseLevel(*logLevel)
+	if err != nil {
+		return err
+	}
+	log.SetLevel(level)
+	switch *logFormat {
+	case "text":
+		log.SetFormatter(&log.TextFormatter{FullTimestamp: true})
+	case "json":
+		log.SetFormatter(&log.JSONFormatter{})
+	default:
+		return fmt.Errorf("unsupported format of logs: %s", *logFormat)
+	}
+	log.SetOutput(os.Stdout)
+	return nil
+}
+
+// requestLogger returns logger with fields of the request
+func requestLogger(r *http.Request) *log.Entry {
+	fields := log.Fields{
+		"method": r.Method,
+		"path":   r.URL.Path,
+		"remote": r.RemoteAddr,
+	}
+	if requestID := r.Header.Get(requestIDHeader); requestID != "" {
+		fields["request_id"] = requestID
+	}
+	logger := log.WithFields(fields)
+	logger.Debug("request received")
+	return logger
 }
 
 func appinfo(w http.ResponseWriter, r *http.Request) {
+	logger := requestLogger(r)
 	if r.Method != "POST" {
 		errStr := fmt.Sprintf("Unexpected method: %s", r.Method)
-		fmt.Println(errStr)
+		logger.Error(errStr)
 		http.Error(w, errStr, http.StatusMethodNotAllowed)
 		return
 	}
 	body, err := io.ReadAll(r.Body)
 	if err != nil {
 		errStr := fmt.Sprintf("Failed to read request body: %v", err)
-		fmt.Println(errStr)
+		logger.Error(errStr)
 		http.Error(w, errStr, http.StatusBadRequest)
 		return
 	}
@@ -80,21 +125,21 @@ func appinfo(w http.ResponseWriter, r *http.Request) {
 	err = proto.Unmarshal(body, appInfoList)
 	if err != nil {
 		errStr := fmt.Sprintf("Failed to unmarshal request body: %v", err)
-		fmt.Println(errStr)
+		logger.Error(errStr)
 		http.Error(w, errStr, http.StatusBadRequest)
 		return
 	}
 	data, err := protojson.MarshalOptions{Multiline: true}.Marshal(appInfoList)
 	if err != nil {
 		errStr := fmt.Sprintf("Marshal: %s", err)
-		fmt.Println(errStr)
+		logger.Error(errStr)
 		http.Error(w, errStr, http.StatusInternalServerError)
 		return
 	}
 	err = os.WriteFile(*appInfoFile, data, 0644)
 	if err != nil {
 		errStr := fmt.Sprintf("Failed to write request body: %v", err)
-		fmt.Println(errStr)
+		logger.Error(errStr)
 		http.Error(w, errStr, http.StatusBadRequest)
 		return
 	}
@@ -104,7 +149,7 @@ func appinfo(w http.ResponseWriter, r *http.Request) {
 	if err != nil {
 		if !os.IsNotExist(err) {
 			errStr := fmt.Sprintf("Stat: %s", err)
-			fmt.Println(errStr)
+			logger.Error(errStr)
 		}
 		w.WriteHeader(http.StatusNoContent)
 		return
@@ -117,7 +162,7 @@ func appinfo(w http.ResponseWriter, r *http.Request) {
 	data, err = os.ReadFile(*appCmdFile)
 	if err != nil {
 		errStr := fmt.Sprintf("ReadFile: %s", err)
-		fmt.Println(errStr)
+		logger.Error(errStr)
 		http.Error(w, errStr, http.StatusInternalServerError)
 		return
 	}
@@ -125,7 +170,7 @@ func appinfo(w http.ResponseWriter, r *http.Request) {
 	err = protojson.Unmarshal(data, appCommand)
 	if err != nil {
 		errStr := fmt.Sprintf("Unmarshal: %s", err)
-		fmt.Println(errStr)
+		logger.Error(errStr)
 		http.Error(w, errStr, http.StatusInternalServerError)
 		return
 	}
@@ -137,28 +182,29 @@ func appinfo(w http.ResponseWriter, r *http.Request) {
 	})
 	if err != nil {
 		errStr := fmt.Sprintf("Marshal: %s", err)
-		fmt.Println(errStr)
+		logger.Error(errStr)
 		http.Error(w, errStr, http.StatusInternalServerError)
 		return
 	}
 	w.Header().Set(contentType, mimeProto)
 	w.WriteHeader(http.StatusOK)
 	if _, err := w.Write(data); err != nil {
-		fmt.Printf("Failed to write: %s\n", err)
+		logger.Errorf("Failed to write: %s", err)
 	}
 }
 
 func devinfo(w http.ResponseWriter, r *http.Request) {
+	logger := requestLogger(r)
 	if r.Method != "POST" {
 		errStr := fmt.Sprintf("Unexpected method: %s", r.Method)
-		fmt.Println(errStr)
+		logger.Error(errStr)
 		http.Error(w, errStr, http.StatusMethodNotAllowed)
 		return
 	}
 	body, err := io.ReadAll(r.Body)
 	if err != nil {
 		errStr := fmt.Sprintf("Failed to read request body: %v", err)
-		fmt.Println(errStr)
+		logger.Error(errStr)
 		http.Error(w, errStr, http.StatusBadRequest)
 		return
 	}
@@ -166,21 +212,21 @@ func devinfo(w http.ResponseWriter, r *http.Request) {
 	err = proto.Unmarshal(body, devInfo)
 	if err != nil {
 		errStr := fmt.Sprintf("Failed to unmarshal request body: %v", err)
-		fmt.Println(errStr)
+		logger.Error(errStr)
 		http.Error(w, errStr, http.StatusBadRequest)
 		return
 	}
 	data, err := protojson.MarshalOptions{Multiline: true}.Marshal(devInfo)
 	if err != nil {
 		errStr := fmt.Sprintf("Marshal: %s", err)
-		fmt.Println(errStr)
+		logger.Error(errStr)
 		http.Error(w, errStr, http.StatusInternalServerError)
 		return
 	}
 	err = os.WriteFile(*devInfoFile, data, 0644)
 	if err != nil {
 		errStr := fmt.Sprintf("Failed to write request body: %v", err)
-		fmt.Println(errStr)
+		logger.Error(errStr)
 		http.Error(w, errStr, http.StatusBadRequest)
 		return
 	}
@@ -190,7 +236,7 @@ func devinfo(w http.ResponseWriter, r *http.Request) {
 	if err != nil {
 		if !os.IsNotExist(err) {
 			errStr := fmt.Sprintf("Stat: %s", err)
-			fmt.Println(errStr)
+			logger.Error(errStr)
 		}
 		w.WriteHeader(http.StatusNoContent)
 		return
@@ -203,7 +249,7 @@ func devinfo(w http.ResponseWriter, r *http.Request) {
 	data, err = os.ReadFile(*devCmdFile)
 	if err != nil {
 		errStr := fmt.Sprintf("ReadFile: %s", err)
-		fmt.Println(errStr)
+		logger.Error(errStr)
 		http.Error(w, errStr, http.StatusInternalServerError)
 		return
 	}
@@ -211,7 +257,7 @@ func devinfo(w http.ResponseWriter, r *http.Request) {
 	err = protojson.Unmarshal(data, devCommand)
 	if err != nil {
 		errStr := fmt.Sprintf("Unmarshal: %s", err)
-		fmt.Println(errStr)
+		logger.Error(errStr)
 		http.Error(w, errStr, http.StatusInternalServerError)
 		return
 	}
@@ -220,28 +266,29 @@ func devinfo(w http.ResponseWriter, r *http.Request) {
 	data, err = proto.Marshal(devCommand)
 	if err != nil {
 		errStr := fmt.Sprintf("Marshal: %s", err)
-		fmt.Println(errStr)
+		logger.Error(errStr)
 		http.Error(w, errStr, http.StatusInternalServerError)
 		return
 	}
 	w.Header().Set(contentType, mimeProto)
 	w.WriteHeader(http.StatusOK)
 	if _, err := w.Write(data); err != nil {
-		fmt.Printf("Failed to write: %s\n", err)
+		logger.Errorf("Failed to write: %s", err)
 	}
 }
 
 func localProfile(w http.ResponseWriter, r *http.Request) {
+	logger := requestLogger(r)
 	if r.Method != "GET" {
 		errStr := fmt.Sprintf("Unexpected method: %s", r.Method)
-		fmt.Println(errStr)
+		logger.Error(errStr)
 		http.Error(w, errStr, http.StatusMethodNotAllowed)
 		return
 	}
 	profileFromFile, err := os.ReadFile(*profileFile)
 	if err != nil {
 		errStr := fmt.Sprintf("ReadFile: %s", err)
-		fmt.Println(errStr)
+		logger.Error(errStr)
 		if os.IsNotExist(err) {
 			http.Error(w, errStr, http.StatusNotFound)
 		} else {
@@ -256,21 +303,22 @@ func localProfile(w http.ResponseWriter, r *http.Request) {
 	data, err := proto.Marshal(localProfileObject)
 	if err != nil {
 		errStr := fmt.Sprintf("Marshal: %s", err)
-		fmt.Println(errStr)
+		logger.Error(errStr)
 		http.Error(w, errStr, http.StatusInternalServerError)
 		return
 	}
 	w.Header().Set(contentType, mimeProto)
 	w.WriteHeader(http.StatusOK)
 	if _, err := w.Write(data); err != nil {
-		fmt.Printf("Failed to write: %s\n", err)
+		logger.Errorf("Failed to write: %s", err)
 	}
 }
 
 func radio(w http.ResponseWriter, r *http.Request) {
+	logger := requestLogger(r)
 	if r.Method != "POST" {
 		errStr := fmt.Sprintf("Unexpected method: %s", r.Method)
-		fmt.Println(errStr)
+		logger.Error(errStr)
 		http.Error(w, errStr, http.StatusMethodNotAllowed)
 		return
 	}
@@ -279,7 +327,7 @@ func radio(w http.ResponseWriter, r *http.Request) {
 	body, err := io.ReadAll(r.Body)
 	if err != nil {
 		errStr := fmt.Sprintf("Failed to read request body: %v", err)
-		fmt.Println(errStr)
+		logger.Error(errStr)
 		http.Error(w, errStr, http.StatusBadRequest)
 		return
 	}
@@ -287,21 +335,21 @@ func radio(w http.ResponseWriter, r *http.Request) {
 	err = proto.Unmarshal(body, radioStatus)
 	if err != nil {
 		errStr := fmt.Sprintf("Failed to unmarshal request body: %v", err)
-		fmt.Println(errStr)
+		logger.Error(errStr)
 		http.Error(w, errStr, http.StatusBadRequest)
 		return
 	}
 	data, err := protojson.Marshal(radioStatus)
 	if err != nil {
 		errStr := fmt.Sprintf("Marshal: %s", err)
-		fmt.Println(errStr)
+		logger.Error(errStr)
 		http.Error(w, errStr, http.StatusInternalServerError)
 		return
 	}
 	err = os.WriteFile(*radioStatusFile, data, 0644)
 	if err != nil {
 		errStr := fmt.Sprintf("WriteFile: %s", err)
-		fmt.Println(errStr)
+		logger.Error(errStr)
 		http.Error(w, errStr, http.StatusInternalServerError)
 		return
 	}
@@ -314,7 +362,7 @@ func radio(w http.ResponseWriter, r *http.Request) {
 		err := os.WriteFile(*radioSilenceCounterFile, data, 0644)
 		if err != nil {
 			errStr := fmt.Sprintf("WriteFile: %s", err)
-			fmt.Println(errStr)
+			logger.Error(errStr)
 		}
 		radioSilenceIsChanging = false
 	}
@@ -324,7 +372,7 @@ func radio(w http.ResponseWriter, r *http.Request) {
 	if err != nil {
 		if !os.IsNotExist(err) {
 			errStr := fmt.Sprintf("Stat: %s", err)
-			fmt.Println(errStr)
+			logger.Error(errStr)
 		}
 		w.WriteHeader(http.StatusNoContent)
 		return
@@ -337,7 +385,7 @@ func radio(w http.ResponseWriter, r *http.Request) {
 	data, err = os.ReadFile(*radioSilenceCfgFile)
 	if err != nil {
 		errStr := fmt.Sprintf("ReadFile: %s", err)
-		fmt.Println(errStr)
+		logger.Error(errStr)
 		http.Error(w, errStr, http.StatusInternalServerError)
 		return
 	}
@@ -349,30 +397,31 @@ func radio(w http.ResponseWriter, r *http.Request) {
 	data, err = proto.Marshal(radioConfig)
 	if err != nil {
 		errStr := fmt.Sprintf("Marshal: %s", err)
-		fmt.Println(errStr)
+		logger.Error(errStr)
 		http.Error(w, errStr, http.StatusInternalServerError)
 		return
 	}
 	w.Header().Set(contentType, mimeProto)
 	w.WriteHeader(http.StatusOK)
 	if _, err := w.Write(data); err != nil {
-		fmt.Printf("Failed to write: %s\n", err)
+		logger.Errorf("Failed to write: %s", err)
 	} else {
 		radioSilenceIsChanging = radioStatus.RadioSilence != radioConfig.RadioSilence
 	}
 }
 
 func location(w http.ResponseWriter, r *http.Request) {
+	logger := requestLogger(r)
 	if r.Method != "POST" {
 		errStr := fmt.Sprintf("Unexpected method: %s", r.Method)
-		fmt.Println(errStr)
+		logger.Error(errStr)
 		http.Error(w, errStr, http.StatusMethodNotAllowed)
 		return
 	}
 	body, err := io.ReadAll(r.Body)
 	if err != nil {
 		errStr := fmt.Sprintf("Failed to read request body: %v", err)
-		fmt.Println(errStr)
+		logger.Error(errStr)
 		http.Error(w, errStr, http.StatusBadRequest)
 		return
 	}
@@ -380,21 +429,21 @@ func location(w http.ResponseWriter, r *http.Request) {
 	err = proto.Unmarshal(body, locInfo)
 	if err != nil {
 		errStr := fmt.Sprintf("Failed to unmarshal request body: %v", err)
-		fmt.Println(errStr)
+		logger.Error(errStr)
 		http.Error(w, errStr, http.StatusBadRequest)
 		return
 	}
 	data, err := protojson.MarshalOptions{Multiline: true}.Marshal(locInfo)
 	if err != nil {
 		errStr := fmt.Sprintf("Marshal: %s", err)
-		fmt.Println(errStr)
+		logger.Error(errStr)
 		http.Error(w, errStr, http.StatusInternalServerError)
 		return
 	}
 	err = os.WriteFile(*locationFile, data, 0644)
 	if err != nil {
 		errStr := fmt.Sprintf("Failed to write request body: %v", err)
-		fmt.Println(errStr)
+		logger.Error(errStr)
 		http.Error(w, errStr, http.StatusBadRequest)
 		return
 	}
@@ -3,24 +3,93 @@ package escript
 import (
 	"errors"
 	"flag"
+	"fmt"
 	log "github.com/sirupsen/logrus"
 	"os"
+	"path/filepath"
 	"strings"
 	"testing"
+	"time"
 
+	"github.com/lf-edge/eden/pkg/controller"
+	"github.com/lf-edge/eden/pkg/defaults"
 	"github.com/lf-edge/eden/pkg/tests"
 	"github.com/lf-edge/eden/tests/escript/go-internal/testscript"
+	"github.com/lf-edge/eve-api/go/info"
 )
 
 var testData = flag.String("testdata", "testdata", "Test script directory")
 var failScenario = flag.String("fail_scenario", "failScenario.txt", "Scenario that runs after a test fails")
 var args = flag.String("args", "", "Flags to pass into test")
 
+// Scripts of suite inside testdata directory, they run once for all tests from the directory
+const (
+	suiteSetup    = "suite/setup.txt"
+	suiteTeardown = "suite/teardown.txt"
+)
+
 func TestEdenScripts(t *testing.T) {
 	if _, err := os.Stat(*testData); os.IsNotExist(err) {
 		log.Fatalf("can't find %s directory: %s\n", *testData, err)
 	}
 
+	flagsParsed := parseArgs()
+
+	log.Info("testData directory: ", *testData)
+	params := testscript.Params{
+		Dir:       *testData,
+		Flags:     flagsParsed,
+		Condition: customConditions,
+	}
+	if suite := setupSuite(t, flagsParsed); suite != nil {
+		params.Deadline = suite.Deadline()
+		params.Setup = func(env *testscript.Env) error {
+			if suite.Budget.Reboots != nil {
+				counter, err := eveRestartCounter()
+				if err != nil {
+					return fmt.Errorf("cannot count reboots for budget of suite: %w", err)
+				}
+				if err := suite.CheckReboots(counter); err != nil {
+					return err
+				}
+			}
+			for k, v := range suite.Vars {
+				env.Setenv(k, v)
+			}
+			return nil
+		}
+	}
+	testscript.Run(t, params)
+}
+
+// TestEdenSuiteTeardown runs teardown script of suite, it is called when all tests of suite are done
+func TestEdenSuiteTeardown(t *testing.T) {
+	suite, err := tests.LoadSuite(suiteName())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if suite == nil || len(suite.Teardown) == 0 {
+		t.Skip("suite is not started or already torn down")
+	}
+	// teardown runs once even if it fails
+	suite.Teardown = nil
+	if err := tests.SaveSuite(suiteName(), suite); err != nil {
+		t.Fatal(err)
+	}
+	_, _ = testscript.RunSuiteScript(t, testscript.Params{
+		Flags:     parseArgs(),
+		Condition: customConditions,
+		Setup: func(env *testscript.Env) error {
+			for k, v := range suite.Vars {
+				env.Setenv(k, v)
+			}
+			return nil
+		},
+	}, filepath.Join(*testData, suiteTeardown))
+}
+
+// parseArgs parses flags passed into test with -args
+func parseArgs() map[string]string {
 	flagsParsed := make(map[string]string)
 
 	flags := strings.Split(strings.Trim(*args, "\""), ",")
@@ -36,13 +105,92 @@ func TestEdenScripts(t *testing.T) {
 			os.Setenv(split[0], split[1])
 		}
 	}
+	return flagsParsed
+}
 
-	log.Info("testData directory: ", *testData)
-	testscript.Run(t, testscript.Params{
-		Dir:       *testData,
+// suiteName returns name of suite of tests from testdata directory
+func suiteName() string {
+	dir, err := filepath.Abs(*testData)
+	if err != nil {
+		return *testData
+	}
+	return dir
+}
+
+// setupSuite runs setup script of suite if it is not done by previous tests and
+// returns state of suite or nil if there is no suite in testdata directory
+func setupSuite(t *testing.T, flagsParsed map[string]string) *tests.Suite {
+	if _, err := os.Stat(filepath.Join(*testData, suiteSetup)); err != nil {
+		return nil
+	}
+	name := suiteName()
+	suite, err := tests.LoadSuite(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if suite != nil && suite.Ready {
+		return suite
+	}
+	// save teardown before setup to clean up if setup fails
+	suite = &tests.Suite{Started: time.Now()}
+	if _, err := os.Stat(filepath.Join(*testData, suiteTeardown)); err == nil {
+		binary, err := os.Executable()
+		if err != nil {
+			t.Fatal(err)
+		}
+		suite.Teardown = []string{binary, "-test.run", "^TestEdenSuiteTeardown$", "-testdata", name}
+		if *args != "" {
+			suite.Teardown = append(suite.Teardown, fmt.Sprintf("-args=%s", *args))
+		}
+		if config := os.Getenv(defaults.DefaultConfigEnv); config != "" {
+			suite.Env = append(suite.Env, fmt.Sprintf("%s=%s", defaults.DefaultConfigEnv, config))
+		}
+	}
+	if err := tests.SaveSuite(name, suite); err != nil {
+		t.Fatal(err)
+	}
+	log.Info("Setup of suite: ", name)
+	vars, budget := testscript.RunSuiteScript(t, testscript.Params{
 		Flags:     flagsParsed,
 		Condition: customConditions,
-	})
+	}, filepath.Join(*testData, suiteSetup))
+	suite.Vars = vars
+	if budget != nil {
+		suite.Budget = *budget
+		if budget.Reboots != nil {
+			if suite.RestartCounter, err = eveRestartCounter(); err != nil {
+				t.Fatalf("cannot count reboots for budget of suite: %s", err)
+			}
+		}
+	}
+	suite.Ready = true
+	if err := tests.SaveSuite(name, suite); err != nil {
+		t.Fatal(err)
+	}
+	return suite
+}
+
+// eveRestartCounter returns restart counter from the last info of EVE
+func eveRestartCounter() (uint32, error) {
+	ctrl, err := controller.CloudPrepare()
+	if err != nil {
+		return 0, fmt.Errorf("CloudPrepare: %w", err)
+	}
+	dev, err := ctrl.GetDeviceCurrent()
+	if err != nil {
+		return 0, fmt.Errorf("GetDeviceCurrent: %w", err)
+	}
+	var counter uint32
+	handleInfo := func(im *info.ZInfoMsg) bool {
+		if im.GetZtype() == info.ZInfoTypes_ZiDevice {
+			counter = im.GetDinfo().GetRestartCounter()
+		}
+		return false
+	}
+	if err := ctrl.InfoLastCallback(dev.GetID(), map[string]string{"devId": dev.GetID().String()}, handleInfo); err != nil {
+		return 0, fmt.Errorf("InfoLastCallback: %w", err)
+	}
+	return counter, nil
 }
 
 // Function adds additional condition(s) for testscripts:
@@ -59,7 +207,18 @@ func customConditions(ts *testscript.TestScript, cond string) (bool, error) {
 func TestMain(m *testing.M) {
 	tests.TestArgsParse()
 
+	// suites are ended here if the binary is not started by 'eden test'
+	started, err := tests.BeginSuites()
+	if err != nil {
+		log.Fatal(err)
+	}
 	result := m.Run()
+	if started {
+		if err := tests.EndSuites(); err != nil {
+			log.Error(err)
+			result = 1
+		}
+	}
 	if result != 0 {
 		tests.RunScenario(*failScenario, "", "", "", "", "")
 	}
@@ -6,6 +6,7 @@ package testscript
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"os"
 	"os/exec"
@@ -17,6 +18,7 @@ import (
 
 	"github.com/spf13/viper"
 
+	"github.com/lf-edge/eden/pkg/tests"
 	"github.com/lf-edge/eden/pkg/utils"
 	"github.com/lf-edge/eden/tests/escript/go-internal/internal/textutil"
 	"github.com/lf-edge/eden/tests/escript/go-internal/txtar"
@@ -28,6 +30,7 @@ import (
 // NOTE: If you make changes here, update doc.go.
 var scriptCmds = map[string]func(*TestScript, bool, []string){
 	"arg":     (*TestScript).cmdArg,
+	"budget":  (*TestScript).cmdBudget,
 	"cd":      (*TestScript).cmdCd,
 	"chmod":   (*TestScript).cmdChmod,
 	"cmp":     (*TestScript).cmdCmp,
@@ -39,6 +42,8 @@ var scriptCmds = map[string]func(*TestScript, bool, []string){
 	"exec":    (*TestScript).cmdExec,
 	"exists":  (*TestScript).cmdExists,
 	"grep":    (*TestScript).cmdGrep,
+	"impair":  (*TestScript).cmdImpair,
+	"let":     (*TestScript).cmdLet,
 	"message": (*TestScript).cmdMsg,
 	"mkdir":   (*TestScript).cmdMkdir,
 	"rm":      (*TestScript).cmdRm,
@@ -219,8 +224,10 @@ func (ts *TestScript) backgroundHelper(neg bool, bgName, command string, args []
 		return err
 	}
 	wait := make(chan struct{})
+	// context of script, not the one of command with timeout prefix
+	ctxt := ts.ctxt
 	go func() {
-		err = ctxWait(ts.ctxt, cmd)
+		err = ctxWait(ctxt, cmd)
 		close(wait)
 		if err == context.Canceled {
 			return
@@ -307,13 +314,73 @@ func (ts *TestScript) cmdEden(neg bool, args []string) {
 	if err != nil {
 		fmt.Fprintf(&ts.log, "[%v]\n", err)
 		if ts.ctxt.Err() != nil {
-			ts.Fatalf("test interrupted while running command")
+			ts.fatalInterrupted()
+		} else if errors.Is(err, context.DeadlineExceeded) && !neg {
+			ts.Fatalf("command timed out after %s", timewait)
 		} else if !neg {
 			ts.Fatalf("command failure")
 		}
 	}
 }
 
+// impair applies impairment to port of Eden-SDN until the end of script.
+func (ts *TestScript) cmdImpair(neg bool, args []string) {
+	if neg {
+		ts.Fatalf("unsupported: ! impair")
+	}
+	if len(args) < 1 {
+		ts.Fatalf("usage: impair port [options of 'eden sdn impair set'...] | impair -clear [port...]")
+	}
+
+	vars, err := utils.InitVars()
+	if err != nil {
+		ts.Fatalf("error reading config: %s\n", err)
+	}
+	edenProg := utils.ResolveAbsPath(vars.EdenBinDir + "/" + vars.EdenProg)
+
+	var edenArgs []string
+	if args[0] == "-clear" {
+		edenArgs = append([]string{"sdn", "impair", "clear"}, args[1:]...)
+	} else {
+		edenArgs = append([]string{"sdn", "impair", "set"}, args...)
+	}
+	ts.stdout, ts.stderr, err = ts.exec(edenProg, edenArgs...)
+	if ts.stderr != "" {
+		fmt.Fprintf(&ts.log, "[stderr]\n%s", ts.stderr)
+	}
+	if err != nil {
+		fmt.Fprintf(&ts.log, "[%v]\n", err)
+		ts.Fatalf("cannot impair network")
+	}
+
+	if args[0] == "-clear" {
+		if len(args) == 1 {
+			ts.impaired = nil
+		}
+		for _, port := range args[1:] {
+			delete(ts.impaired, port)
+		}
+		return
+	}
+	// network of the following scripts must not stay impaired even if this one fails
+	port := args[0]
+	if ts.impaired[port] {
+		return
+	}
+	if ts.impaired == nil {
+		ts.impaired = map[string]bool{}
+	}
+	ts.impaired[port] = true
+	ts.Defer(func() {
+		if !ts.impaired[port] {
+			return
+		}
+		if _, stderr, err := ts.exec(edenProg, "sdn", "impair", "clear", port); err != nil {
+			fmt.Fprintf(&ts.log, "cannot clear impairment of %s: %v\n%s", port, err, stderr)
+		}
+	})
+}
+
 // eden execute EDEN's test commands.
 func (ts *TestScript) cmdTest(neg bool, args []string) {
 	if len(args) < 1 || (len(args) == 1 && args[0] == "&") {
@@ -370,7 +437,9 @@ func (ts *TestScript) cmdTest(neg bool, args []string) {
 	if err != nil {
 		fmt.Fprintf(&ts.log, "[%v]\n", err)
 		if ts.ctxt.Err() != nil {
-			ts.Fatalf("test interrupted while running command")
+			ts.fatalInterrupted()
+		} else if errors.Is(err, context.DeadlineExceeded) && !neg {
+			ts.Fatalf("command timed out after %s", timewait)
 		} else if !neg {
 			ts.Fatalf("command failure")
 		}
@@ -419,6 +488,39 @@ func (ts *TestScript) cmdArg(neg bool, args []string) {
 	}
 }
 
+// budget limits resources of suite, it is used in setup of suite.
+func (ts *TestScript) cmdBudget(neg bool, args []string) {
+	if neg {
+		ts.Fatalf("unsupported: ! budget")
+	}
+	if len(args) == 0 || len(args)%2 != 0 {
+		ts.Fatalf("usage: budget [-time duration] [-reboots count]")
+	}
+	var budget tests.Budget
+	if ts.budget != nil {
+		budget = *ts.budget
+	}
+	for ; len(args) > 0; args = args[2:] {
+		switch args[0] {
+		case "-time":
+			d, err := time.ParseDuration(args[1])
+			if err != nil {
+				ts.Fatalf("Incorrect time format in 'budget': %s\n", err)
+			}
+			budget.WallTime = d
+		case "-reboots":
+			n, err := strconv.Atoi(args[1])
+			if err != nil || n < 0 {
+				ts.Fatalf("Incorrect number of reboots in 'budget': %s\n", args[1])
+			}
+			budget.Reboots = &n
+		default:
+			ts.Fatalf("usage: budget [-time duration] [-reboots count]")
+		}
+	}
+	ts.budget = &budget
+}
+
 // source parse variables from file to the environment.
 func (ts *TestScript) cmdSource(neg bool, args []string) {
 	if neg {
@@ -484,7 +586,9 @@ func (ts *TestScript) cmdExec(neg bool, args []string) {
 	if err != nil {
 		fmt.Fprintf(&ts.log, "[%v]\n", err)
 		if ts.ctxt.Err() != nil {
-			ts.Fatalf("test interrupted while running command")
+			ts.fatalInterrupted()
+		} else if errors.Is(err, context.DeadlineExceeded) && !neg {
+			ts.Fatalf("command timed out after %s", timewait)
 		} else if !neg {
 			t := ts.ctxt.Value("kind")
 			if t != nil {
@@ -676,13 +780,24 @@ func (ts *TestScript) cmdWait(neg bool, args []string) {
 	}
 }
 
+// waitFor waits for exit of background command, the command is interrupted
+// if the script is interrupted
+func (ts *TestScript) waitFor(bg *backgroundCmd) {
+	select {
+	case <-bg.wait:
+	case <-ts.ctxt.Done():
+		interruptProcess(bg.cmd.Process)
+		<-bg.wait
+	}
+}
+
 func (ts *TestScript) waitBackgroundOne(bgName string) {
 	bg := ts.findBackground(bgName)
 	if bg == nil {
 		ts.Fatalf("unknown background process %q", bgName)
 		return
 	}
-	<-bg.wait
+	ts.waitFor(bg)
 	ts.stdout = bg.cmd.Stdout.(*strings.Builder).String()
 	ts.stderr = bg.cmd.Stderr.(*strings.Builder).String()
 	if ts.stdout != "" {
@@ -693,16 +808,16 @@ func (ts *TestScript) waitBackgroundOne(bgName string) {
 	}
 	// Note: ignore bg.neg, which only takes effect on the non-specific
 	// wait command.
+	// process state is not set if the command is interrupted
+	if ts.ctxt.Err() != nil {
+		ts.fatalInterrupted()
+	}
 	if bg.cmd.ProcessState.Success() {
 		if bg.neg {
 			ts.Fatalf("unexpected command success")
 		}
-	} else {
-		if ts.ctxt.Err() != nil {
-			ts.Fatalf("test timed out while running command")
-		} else if !bg.neg {
-			ts.Fatalf("command failure")
-		}
+	} else if !bg.neg {
+		ts.Fatalf("command failure")
 	}
 	// Remove this process from the list of running background processes.
 	for i := range ts.background {
@@ -716,7 +831,7 @@ func (ts *TestScript) waitBackgroundOne(bgName string) {
 func (ts *TestScript) waitBackground(checkStatus bool) {
 	var stdouts, stderrs []string
 	for _, bg := range ts.background {
-		<-bg.wait
+		ts.waitFor(&bg)
 		args := append([]string{filepath.Base(bg.cmd.Args[0])}, bg.cmd.Args[1:]...)
 		fmt.Fprintf(&ts.log, "[background] %s: %v\n", strings.Join(args, " "), bg.cmd.ProcessState)
 		cmdStdout := bg.cmd.Stdout.(*strings.Builder).String()
@@ -732,16 +847,16 @@ func (ts *TestScript) waitBackground(checkStatus bool) {
 		if !checkStatus {
 			continue
 		}
+		// process state is not set if the command is interrupted
+		if ts.ctxt.Err() != nil {
+			ts.fatalInterrupted()
+		}
 		if bg.cmd.ProcessState.Success() {
 			if bg.neg {
 				ts.Fatalf("unexpected command success")
 			}
-		} else {
-			if ts.ctxt.Err() != nil {
-				ts.Fatalf("test timed out while running command")
-			} else if !bg.neg {
-				ts.Fatalf("command failure")
-			}
+		} else if !bg.neg {
+			ts.Fatalf("command failure")
 		}
 	}
 	ts.stdout = strings.Join(stdouts, "")
@@ -0,0 +1,244 @@
+package testscript
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// block is a for, while or if block of script with indexes of its lines
+type block struct {
+	kind  string
+	start int
+	els   int // index of else line, -1 if there is no else
+	end   int
+
+	// state of for loop
+	name string
+	next int
+	last int
+}
+
+// blocks describes control flow of script
+type blocks struct {
+	// at maps index of opening, else and end lines to their block
+	at map[int]*block
+	// loops maps index of break and continue lines to the innermost loop
+	loops map[int]*block
+}
+
+// errProbe stops command used as condition of if or while
+var errProbe = errors.New("condition is not satisfied")
+
+// keyword returns first word of line after [cond] prefixes
+func keyword(line string) (word string, conditional bool) {
+	for _, f := range strings.Fields(line) {
+		if strings.HasPrefix(f, "#") {
+			return "", false
+		}
+		if strings.HasPrefix(f, "[") && strings.HasSuffix(f, "]") {
+			conditional = true
+			continue
+		}
+		return f, conditional
+	}
+	return "", false
+}
+
+// parseBlocks matches for, while, if, else and end lines of script
+// and finds loops of break and continue lines
+func (ts *TestScript) parseBlocks(lines []string) *blocks {
+	b := &blocks{at: make(map[int]*block), loops: make(map[int]*block)}
+	var stack []*block
+	innermostLoop := func() *block {
+		for i := len(stack) - 1; i >= 0; i-- {
+			if stack[i].kind != "if" {
+				return stack[i]
+			}
+		}
+		return nil
+	}
+	for i, line := range lines {
+		word, conditional := keyword(line)
+		switch word {
+		case "for", "while", "if", "else", "end":
+			if conditional {
+				ts.lineno = i + 1
+				ts.Fatalf("condition prefix is not supported for %s", word)
+			}
+		}
+		switch word {
+		case "for", "while", "if":
+			blk := &block{kind: word, start: i, els: -1, end: -1}
+			b.at[i] = blk
+			stack = append(stack, blk)
+		case "else":
+			if len(stack) == 0 || stack[len(stack)-1].kind != "if" || stack[len(stack)-1].els >= 0 {
+				ts.lineno = i + 1
+				ts.Fatalf("else without if")
+			}
+			stack[len(stack)-1].els = i
+			b.at[i] = stack[len(stack)-1]
+		case "end":
+			if len(stack) == 0 {
+				ts.lineno = i + 1
+				ts.Fatalf("end without for, while or if")
+			}
+			stack[len(stack)-1].end = i
+			b.at[i] = stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+		case "break", "continue":
+			loop := innermostLoop()
+			if loop == nil {
+				ts.lineno = i + 1
+				ts.Fatalf("%s outside of for or while", word)
+			}
+			b.loops[i] = loop
+		}
+	}
+	if len(stack) > 0 {
+		ts.lineno = stack[len(stack)-1].start + 1
+		ts.Fatalf("%s without end", stack[len(stack)-1].kind)
+	}
+	return b
+}
+
+// controlFlow runs for, while, if, else, end, break or continue line
+// with index pc and returns index of the next line to run
+func (ts *TestScript) controlFlow(b *blocks, pc int, args []string) int {
+	if b.at[pc] == nil && b.loops[pc] == nil {
+		// keyword came from expansion of variable
+		ts.Fatalf("unexpected %s", args[0])
+	}
+	switch args[0] {
+	case "else", "end", "break", "continue":
+		if len(args) > 1 {
+			ts.Fatalf("usage: %s", args[0])
+		}
+	}
+	switch args[0] {
+	case "for":
+		blk := b.at[pc]
+		if len(args) != 4 {
+			ts.Fatalf("usage: for var first last")
+		}
+		first, err := strconv.Atoi(args[2])
+		if err != nil {
+			ts.Fatalf("Incorrect first value in 'for': %s", args[2])
+		}
+		last, err := strconv.Atoi(args[3])
+		if err != nil {
+			ts.Fatalf("Incorrect last value in 'for': %s", args[3])
+		}
+		if first > last {
+			return blk.end + 1
+		}
+		blk.name, blk.next, blk.last = args[1], first+1, last
+		ts.Setenv(blk.name, strconv.Itoa(first))
+	case "while":
+		if len(args) < 2 {
+			ts.Fatalf("usage: while [!] command [args...]")
+		}
+		if !ts.probe(args[1:]) {
+			return b.at[pc].end + 1
+		}
+	case "if":
+		if len(args) < 2 {
+			ts.Fatalf("usage: if [!] command [args...]")
+		}
+		if blk := b.at[pc]; !ts.probe(args[1:]) {
+			if blk.els >= 0 {
+				return blk.els + 1
+			}
+			return blk.end + 1
+		}
+	case "else":
+		// the branch of if is done
+		return b.at[pc].end + 1
+	case "end":
+		switch blk := b.at[pc]; blk.kind {
+		case "for":
+			if blk.next <= blk.last {
+				ts.Setenv(blk.name, strconv.Itoa(blk.next))
+				blk.next++
+				return blk.start + 1
+			}
+		case "while":
+			return blk.start
+		}
+	case "break":
+		return b.loops[pc].end + 1
+	case "continue":
+		return b.loops[pc].end
+	}
+	return pc + 1
+}
+
+// probe runs command as condition of if or while, it returns false instead
+// of failing the script if the command fails
+func (ts *TestScript) probe(args []string) (ok bool) {
+	neg := false
+	if args[0] == "!" {
+		neg = true
+		args = args[1:]
+		if len(args) == 0 {
+			ts.Fatalf("missing command after !")
+		}
+	}
+	if backgroundSpecifier.MatchString(args[len(args)-1]) {
+		ts.Fatalf("unsupported: background command in condition")
+	}
+	ts.probing = true
+	defer func() {
+		ts.probing = false
+		if r := recover(); r != nil {
+			if r != errProbe {
+				panic(r)
+			}
+			ok = false
+		}
+	}()
+	ts.runCommand(neg, args)
+	return true
+}
+
+// cmdLet evaluates integer expression and sets result into environment variable.
+func (ts *TestScript) cmdLet(neg bool, args []string) {
+	if neg {
+		ts.Fatalf("unsupported: ! let")
+	}
+	if len(args) != 2 && len(args) != 4 {
+		ts.Fatalf("usage: let var value [op value]")
+	}
+	x, err := strconv.Atoi(args[1])
+	if err != nil {
+		ts.Fatalf("Incorrect value in 'let': %s", args[1])
+	}
+	if len(args) == 4 {
+		y, err := strconv.Atoi(args[3])
+		if err != nil {
+			ts.Fatalf("Incorrect value in 'let': %s", args[3])
+		}
+		switch args[2] {
+		case "+":
+			x += y
+		case "-":
+			x -= y
+		case "*":
+			x *= y
+		case "/", "%":
+			if y == 0 {
+				ts.Fatalf("division by zero in 'let'")
+			}
+			if args[2] == "/" {
+				x /= y
+			} else {
+				x %= y
+			}
+		default:
+			ts.Fatalf("unknown operator in 'let': %s", args[2])
+		}
+	}
+	ts.Setenv(args[0], fmt.Sprint(x))
+}
@@ -110,8 +110,36 @@ when testing.Short() is false.
 
 Additional conditions can be added by passing a function to Params.Condition.
 
+The command prefix "timeout duration" limits time of the command on the rest
+of the line. The command is interrupted when the time is over and the script
+fails even if the command is prefixed with !. It goes after ! and does not
+support background commands.
+
+Loops and conditionals are blocks of lines closed with end:
+
+	for i 1 3
+	  exec touch file$i
+	end
+	while ! exists done
+	  exec sleep 1
+	end
+	if stdout 'ok'
+	  message passed
+	else
+	  message failed
+	end
+
+The for block runs with variable set to every integer from first to last.
+The while and if blocks use a command (optionally prefixed with !) as condition,
+a failed condition does not fail the script. The break and continue lines
+leave the innermost loop or go to its next iteration.
+
 The predefined commands are:
 
+- budget [-time duration] [-reboots count]
+  Set budget of suite in its setup. Tests of suite fail when time since start
+  of setup exceeds -time or EVE reboots more than -reboots times.
+
 - cd dir
   Change to the given directory for future commands.
 
@@ -170,6 +198,18 @@ The predefined commands are:
   The file's content must (or must not) match the regular expression pattern.
   For positive matches, -count=N specifies an exact number of matches to require.
 
+- impair port [options...]
+  Apply impairment to port of Eden-SDN with 'eden sdn impair set port options...',
+  e.g. 'impair eth0 --loss 30 --delay 500ms'. Impairment is removed at the end of
+  the script, even if it fails.
+
+- impair -clear [port...]
+  Remove impairment of the ports now, of all ports if none is given.
+
+- let var value [op value]
+  Set environment variable to integer value or result of op (+, -, *, / or %)
+  applied to values.
+
 - message message
   Print message.
 
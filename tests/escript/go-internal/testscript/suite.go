@@ -0,0 +1,76 @@
+package testscript
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/lf-edge/eden/pkg/tests"
+)
+
+// RunSuiteScript runs the script file once outside of Dir, it is used for
+// setup and teardown of suite. It returns environment variables set or
+// changed by the script, so they can be passed into tests of the suite,
+// and budget of suite set by the script.
+func RunSuiteScript(t *testing.T, p Params, file string) (map[string]string, *tests.Budget) {
+	return RunSuiteScriptT(tshim{t}, p, file)
+}
+
+// RunSuiteScriptT is like RunSuiteScript but uses an interface type instead of the concrete *testing.T
+func RunSuiteScriptT(t T, p Params, file string) (map[string]string, *tests.Budget) {
+	testTempDir, err := os.MkdirTemp(os.Getenv("GOTMPDIR"), "go-test-script")
+	if err != nil {
+		t.Fatal(err)
+	}
+	testTempDir, err = filepath.EvalSymlinks(testTempDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if p.TestWork || *testWork {
+			return
+		}
+		_ = removeAll(testTempDir)
+	}()
+
+	// remember environment before the script to find out what it exports
+	initial := make(map[string]string)
+	setup := p.Setup
+	p.Setup = func(env *Env) error {
+		if setup != nil {
+			if err := setup(env); err != nil {
+				return err
+			}
+		}
+		for _, kv := range env.Vars {
+			if i := strings.Index(kv, "="); i >= 0 {
+				initial[envvarname(kv[:i])] = kv[i+1:]
+			}
+		}
+		return nil
+	}
+
+	ctxt, cancel := p.context()
+	ts := &TestScript{
+		t:             t,
+		testTempDir:   testTempDir,
+		name:          strings.TrimSuffix(filepath.Base(file), ".txt"),
+		file:          file,
+		params:        p,
+		ctxt:          ctxt,
+		cancel:        cancel,
+		deferred:      func() {},
+		scriptFiles:   make(map[string]string),
+		scriptUpdates: make(map[string]string),
+	}
+	ts.run()
+
+	exported := make(map[string]string)
+	for k, v := range ts.envMap {
+		if old, ok := initial[k]; !ok || old != v {
+			exported[k] = v
+		}
+	}
+	return exported, ts.budget
+}
@@ -11,6 +11,7 @@ import (
 	"bufio"
 	"bytes"
 	"context"
+	"errors"
 	"flag"
 	"fmt"
 	"os"
@@ -24,6 +25,7 @@ import (
 	"time"
 
 	"github.com/lf-edge/eden/pkg/defaults"
+	"github.com/lf-edge/eden/pkg/tests"
 	"github.com/lf-edge/eden/tests/escript/go-internal/imports"
 	"github.com/lf-edge/eden/tests/escript/go-internal/internal/os/execpath"
 	"github.com/lf-edge/eden/tests/escript/go-internal/par"
@@ -156,6 +158,10 @@ type Params struct {
 	// script.
 	UpdateScripts bool
 
+	// Deadline, if not zero, stops the script with failure when reached.
+	// It is used to limit wall time of suite of tests.
+	Deadline time.Time
+
 	Flags map[string]string
 }
 
@@ -233,8 +239,7 @@ func RunT(t T, p Params) {
 		name := strings.TrimSuffix(filepath.Base(file), ".txt")
 		t.Run(name, func(t T) {
 			t.Parallel()
-			ctx := context.Background()
-			ctxt, cancel := context.WithCancel(ctx)
+			ctxt, cancel := p.context()
 			ts := &TestScript{
 				t:             t,
 				testTempDir:   testTempDir,
@@ -263,6 +268,14 @@ func RunT(t T, p Params) {
 	}
 }
 
+// context returns context of script limited by Deadline
+func (p Params) context() (context.Context, context.CancelFunc) {
+	if p.Deadline.IsZero() {
+		return context.WithCancel(context.Background())
+	}
+	return context.WithDeadline(context.Background(), p.Deadline)
+}
+
 // A TestScript holds execution state for a single test script.
 type TestScript struct {
 	params        Params
@@ -283,12 +296,16 @@ type TestScript struct {
 	stdout        string                      // standard output from last 'go' command; for 'stdout' command
 	stderr        string                      // standard error from last 'go' command; for 'stderr' command
 	stopped       bool                        // test wants to stop early
+	probing       bool                        // command runs as condition of if or while
 	start         time.Time                   // time phase started
 	background    []backgroundCmd             // backgrounded 'exec' and 'go' commands
 	deferred      func()                      // deferred cleanup actions.
 	archive       *txtar.Archive              // the testscript being run.
 	scriptFiles   map[string]string           // files stored in the txtar archive (absolute paths -> path in script)
 	scriptUpdates map[string]string           // updates to testscript files via UpdateScripts.
+	timeout       time.Duration               // timeout of command currently executing; set by 'timeout' command.
+	budget        *tests.Budget               // budget of suite; set by 'budget' command.
+	impaired      map[string]bool             // SDN ports impaired until the end of script; set by 'impair' command.
 
 	cancel context.CancelFunc
 	ctxt   context.Context // per TestScript context
@@ -433,16 +450,14 @@ func (ts *TestScript) run() {
 
 	// Run script.
 	// See testdata/script/README for documentation of script form.
+	lines := strings.Split(strings.TrimSuffix(script, "\n"), "\n")
+	blocks := ts.parseBlocks(lines)
 Script:
-	for script != "" {
+	for pc := 0; pc < len(lines); {
 		// Extract next line.
-		ts.lineno++
-		var line string
-		if i := strings.Index(script, "\n"); i >= 0 {
-			line, script = script[:i], script[i+1:]
-		} else {
-			line, script = script, ""
-		}
+		line := lines[pc]
+		pc++
+		ts.lineno = pc
 
 		// # is a comment indicating the start of new phase.
 		if strings.HasPrefix(line, "#") {
@@ -468,6 +483,11 @@ Script:
 			continue
 		}
 
+		// Do not start new commands if wall time of suite is over.
+		if ts.ctxt.Err() != nil {
+			ts.fatalInterrupted()
+		}
+
 		// Echo command to log and stdout.
 		fmt.Printf("> %s\n", line)
 		fmt.Fprintf(&ts.log, "> %s\n", line)
@@ -496,6 +516,13 @@ Script:
 			}
 		}
 
+		// Lines of loops and conditionals move to other line of script.
+		switch args[0] {
+		case "for", "while", "if", "else", "end", "break", "continue":
+			pc = ts.controlFlow(blocks, pc-1, args)
+			continue Script
+		}
+
 		// Command prefix ! means negate the expectations about this command:
 		// go command should fail, match should not be found, etc.
 		neg := false
@@ -507,15 +534,7 @@ Script:
 			}
 		}
 
-		// Run command.
-		cmd := scriptCmds[args[0]]
-		if cmd == nil {
-			cmd = ts.params.Cmds[args[0]]
-		}
-		if cmd == nil {
-			ts.Fatalf("unknown command %q", args[0])
-		}
-		cmd(ts, neg, args[1:])
+		ts.runCommand(neg, args)
 
 		// Command can ask script to stop early.
 		if ts.stopped {
@@ -688,6 +707,10 @@ func (ts *TestScript) exec(command string, args ...string) (stdout, stderr strin
 	if err = cmd.Start(); err == nil {
 		err = ctxWait(ctx, cmd)
 	}
+	if ctx.Err() != nil {
+		// report timeout instead of killed process
+		err = ctx.Err()
+	}
 	ts.stdin = ""
 	return stdoutBuf.String(), stderrBuf.String(), err
 }
@@ -835,8 +858,61 @@ func (ts *TestScript) addGHAnnotation() {
 	fmt.Printf("::error file=%s,line=%d::%s\n", pathToPrint, ts.lineno, ghAnnotation)
 }
 
+// runCommand runs command with args, prefixed with timeout if any.
+func (ts *TestScript) runCommand(neg bool, args []string) {
+	// Command prefix timeout limits time of the command.
+	if args[0] == "timeout" {
+		if len(args) < 3 {
+			ts.Fatalf("usage: timeout duration command [args...]")
+		}
+		d, err := time.ParseDuration(args[1])
+		if err != nil {
+			ts.Fatalf("Incorrect time format in 'timeout': %s\n", err)
+		}
+		args = args[2:]
+		if backgroundSpecifier.MatchString(args[len(args)-1]) {
+			ts.Fatalf("unsupported: timeout of background command, use timeout with wait")
+		}
+		parent := ts.ctxt
+		var cancel context.CancelFunc
+		ts.ctxt, cancel = context.WithTimeout(parent, d)
+		ts.timeout = d
+		defer func() {
+			cancel()
+			ts.ctxt = parent
+			ts.timeout = 0
+		}()
+	}
+
+	cmd := scriptCmds[args[0]]
+	if cmd == nil {
+		cmd = ts.params.Cmds[args[0]]
+	}
+	if cmd == nil {
+		ts.Fatalf("unknown command %q", args[0])
+	}
+	cmd(ts, neg, args[1:])
+}
+
+// fatalInterrupted aborts the test interrupted by its context with the reason of interruption.
+func (ts *TestScript) fatalInterrupted() {
+	switch {
+	case !ts.params.Deadline.IsZero() && !time.Now().Before(ts.params.Deadline):
+		ts.Fatalf("wall time budget of suite is over at %s", ts.params.Deadline.Format(time.RFC3339))
+	case ts.timeout > 0 && errors.Is(ts.ctxt.Err(), context.DeadlineExceeded):
+		ts.Fatalf("command timed out after %s", ts.timeout)
+	default:
+		ts.Fatalf("test interrupted while running command")
+	}
+}
+
 // Fatalf aborts the test with the given failure message.
 func (ts *TestScript) Fatalf(format string, args ...interface{}) {
+	if ts.probing && ts.ctxt.Err() == nil {
+		// failed condition of if or while does not fail the script
+		fmt.Fprintf(&ts.log, "[condition not satisfied: %s]\n", fmt.Sprintf(format, args...))
+		panic(errProbe)
+	}
 	defer ts.cancel()
 	ts.stopped = true
 	fmt.Fprintf(&ts.log, "FAIL: %s:%d: %s\n", ts.file, ts.lineno, fmt.Sprintf(format, args...))